@@ -4,6 +4,7 @@ go 1.22
 
 require (
 	github.com/Calcium-Ion/go-epay v0.0.4
+	github.com/alicebob/miniredis/v2 v2.37.0
 	github.com/gin-contrib/gzip v1.2.2
 	github.com/gin-contrib/sessions v0.0.5
 	github.com/gin-contrib/static v1.1.3
@@ -25,7 +26,6 @@ require (
 
 require (
 	filippo.io/edwards25519 v1.1.0 // indirect
-	github.com/alicebob/miniredis/v2 v2.37.0 // indirect
 	github.com/bytedance/sonic v1.12.7 // indirect
 	github.com/bytedance/sonic/loader v0.2.2 // indirect
 	github.com/cespare/xxhash/v2 v2.2.0 // indirect
@@ -0,0 +1,474 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"realms/internal/auth"
+	"realms/internal/middleware"
+	"realms/internal/quota"
+	"realms/internal/store"
+)
+
+const (
+	batchLeaseDuration      = 2 * time.Minute
+	batchStatusPollInterval = 5 * time.Second
+	defaultBatchConcurrency = 4
+	batchMaxRequests        = 50000
+	batchMaxReportedErrors  = 100
+)
+
+// batchQuotaProvider 让批任务的每一行都按 batch 档位预留与结算（命中模型的 batch 折扣）。
+type batchQuotaProvider struct {
+	next quota.Provider
+}
+
+func (p batchQuotaProvider) Reserve(ctx context.Context, in quota.ReserveInput) (quota.ReserveResult, error) {
+	tier := store.ServiceTierBatch
+	in.ServiceTier = &tier
+	return p.next.Reserve(ctx, in)
+}
+
+func (p batchQuotaProvider) Commit(ctx context.Context, in quota.CommitInput) error {
+	tier := store.ServiceTierBatch
+	in.ServiceTier = &tier
+	return p.next.Commit(ctx, in)
+}
+
+func (p batchQuotaProvider) Void(ctx context.Context, usageEventID int64) error {
+	return p.next.Void(ctx, usageEventID)
+}
+
+type batchInputLine struct {
+	index    int
+	customID string
+	body     []byte
+}
+
+type batchLineError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Param   any    `json:"param"`
+	Line    any    `json:"line"`
+}
+
+// parseBatchInput 校验 JSONL 输入：每行需包含唯一 custom_id、method=POST、url 与批任务 endpoint 一致且 body 为对象。
+// 行内的 stream / service_tier 会被移除：批任务统一按非流式执行，计费档位由网关决定。
+func parseBatchInput(content []byte, endpoint string) ([]batchInputLine, []batchLineError) {
+	var lines []batchInputLine
+	var errs []batchLineError
+	seen := make(map[string]struct{})
+	addErr := func(lineNo int, code string, msg string, param any) {
+		if len(errs) < batchMaxReportedErrors {
+			errs = append(errs, batchLineError{Code: code, Message: msg, Param: param, Line: lineNo})
+		}
+	}
+	for i, raw := range bytes.Split(content, []byte("\n")) {
+		lineNo := i + 1
+		raw = bytes.TrimSpace(raw)
+		if len(raw) == 0 {
+			continue
+		}
+		var item struct {
+			CustomID *string        `json:"custom_id"`
+			Method   string         `json:"method"`
+			URL      string         `json:"url"`
+			Body     map[string]any `json:"body"`
+		}
+		if err := json.Unmarshal(raw, &item); err != nil {
+			addErr(lineNo, "invalid_json_line", "该行不是有效 JSON 对象", nil)
+			continue
+		}
+		if item.CustomID == nil || strings.TrimSpace(*item.CustomID) == "" {
+			addErr(lineNo, "missing_required_parameter", "缺少 custom_id", "custom_id")
+			continue
+		}
+		customID := *item.CustomID
+		if _, ok := seen[customID]; ok {
+			addErr(lineNo, "duplicate_custom_id", "custom_id 重复: "+customID, "custom_id")
+			continue
+		}
+		seen[customID] = struct{}{}
+		if !strings.EqualFold(strings.TrimSpace(item.Method), http.MethodPost) {
+			addErr(lineNo, "invalid_method", "method 仅支持 POST", "method")
+			continue
+		}
+		if strings.TrimSpace(item.URL) != endpoint {
+			addErr(lineNo, "mismatched_endpoint", "url 必须与批任务 endpoint 一致: "+endpoint, "url")
+			continue
+		}
+		if item.Body == nil {
+			addErr(lineNo, "missing_required_parameter", "缺少 body", "body")
+			continue
+		}
+		delete(item.Body, "stream")
+		delete(item.Body, "stream_options")
+		delete(item.Body, "service_tier")
+		body, err := json.Marshal(item.Body)
+		if err != nil {
+			addErr(lineNo, "invalid_request", "body 序列化失败", "body")
+			continue
+		}
+		lines = append(lines, batchInputLine{index: len(lines), customID: customID, body: body})
+	}
+	if len(lines) > batchMaxRequests {
+		addErr(0, "too_many_requests", fmt.Sprintf("单个批任务最多 %d 行", batchMaxRequests), nil)
+	}
+	return lines, errs
+}
+
+// batchResponseRecorder 收集批任务单行经由北向 handler 的响应（不需要流式回写）。
+type batchResponseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newBatchResponseRecorder() *batchResponseRecorder {
+	return &batchResponseRecorder{header: make(http.Header)}
+}
+
+func (r *batchResponseRecorder) Header() http.Header { return r.header }
+
+func (r *batchResponseRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *batchResponseRecorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(p)
+}
+
+func (r *batchResponseRecorder) Flush() {}
+
+func (h *Handler) batchConcurrency() int {
+	if h.gateway.batchConcurrency > 0 {
+		return h.gateway.batchConcurrency
+	}
+	return defaultBatchConcurrency
+}
+
+// batchHandler 返回共享调度/上游依赖、但以 batch 档位计费的 handler 副本。
+func (h *Handler) batchHandler() *Handler {
+	cp := *h
+	if h.quota != nil {
+		cp.quota = batchQuotaProvider{next: h.quota}
+	}
+	return &cp
+}
+
+func (h *Handler) batchEndpointHandler(endpoint string) http.Handler {
+	switch endpoint {
+	case "/v1/responses":
+		return http.HandlerFunc(h.Responses)
+	case "/v1/chat/completions":
+		return http.HandlerFunc(h.ChatCompletions)
+	case "/v1/embeddings":
+		return http.HandlerFunc(h.Embeddings)
+	default:
+		return nil
+	}
+}
+
+// ProcessNextBatch 认领并执行一个待处理的批任务；返回 false 表示当前没有可认领的批任务。
+// 执行中断（进程退出、ctx 取消）时已完成的行会保留，租约过期后由任一实例继续。
+func (h *Handler) ProcessNextBatch(ctx context.Context) (bool, error) {
+	if h == nil || h.batches == nil {
+		return false, nil
+	}
+	now := time.Now()
+	b, ok, err := h.batches.ClaimNextBatch(ctx, now, now.Add(batchLeaseDuration))
+	if err != nil || !ok {
+		return false, err
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		ticker := time.NewTicker(batchLeaseDuration / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-runCtx.Done():
+				return
+			case <-ticker.C:
+				_ = h.batches.RenewBatchLease(runCtx, b.ID, time.Now().Add(batchLeaseDuration))
+			}
+		}
+	}()
+	return true, h.runBatch(runCtx, b)
+}
+
+func (h *Handler) runBatch(ctx context.Context, b store.Batch) error {
+	ta, err := h.batches.GetTokenAuthByTokenID(ctx, b.TokenID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return h.failBatch(ctx, b, []batchLineError{{Code: "token_invalid", Message: "创建批任务的 Token 已失效或被禁用"}})
+		}
+		return err
+	}
+	content, found, err := h.batches.GetBatchFileContent(ctx, b.UserID, b.InputFileID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return h.failBatch(ctx, b, []batchLineError{{Code: "input_file_missing", Message: "input_file_id 不存在", Param: "input_file_id"}})
+	}
+	lines, lineErrs := parseBatchInput(content, b.Endpoint)
+	if len(lineErrs) > 0 {
+		return h.failBatch(ctx, b, lineErrs)
+	}
+	if len(lines) == 0 {
+		return h.failBatch(ctx, b, []batchLineError{{Code: "empty_file", Message: "输入文件没有任何请求", Param: "input_file_id"}})
+	}
+	if b.Status == store.BatchStatusValidating {
+		if err := h.batches.MarkBatchInProgress(ctx, b.ID, len(lines), time.Now()); err != nil {
+			return err
+		}
+		if b, err = h.batches.GetBatchByID(ctx, b.ID); err != nil {
+			return err
+		}
+	}
+
+	results, err := h.batches.ListBatchRequestResults(ctx, b.ID)
+	if err != nil {
+		return err
+	}
+	done := make(map[int]struct{}, len(results))
+	for _, res := range results {
+		done[res.LineIndex] = struct{}{}
+	}
+
+	tokenID := ta.TokenID
+	principal := auth.Principal{
+		ActorType: auth.ActorTypeToken,
+		UserID:    ta.UserID,
+		TokenID:   &tokenID,
+		Role:      ta.Role,
+		Groups:    ta.Groups,
+	}
+	outcome := h.executeBatchLines(ctx, b, principal, lines, done)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return h.finalizeBatch(ctx, b, lines, outcome)
+}
+
+// executeBatchLines 以有界并发逐行执行，并在派发间隙检查取消与过期；返回批任务应进入的终态。
+func (h *Handler) executeBatchLines(ctx context.Context, b store.Batch, p auth.Principal, lines []batchInputLine, done map[int]struct{}) string {
+	if b.Status == store.BatchStatusCancelling {
+		return store.BatchStatusCancelled
+	}
+	target := h.batchHandler().batchEndpointHandler(b.Endpoint)
+	if target == nil {
+		return store.BatchStatusCompleted
+	}
+	target = middleware.Chain(target, middleware.RequestID, middleware.BodyCache(0))
+
+	outcome := store.BatchStatusCompleted
+	sem := make(chan struct{}, h.batchConcurrency())
+	var wg sync.WaitGroup
+	lastPoll := time.Now()
+dispatch:
+	for _, ln := range lines {
+		if _, ok := done[ln.index]; ok {
+			continue
+		}
+		now := time.Now()
+		if now.After(b.ExpiresAt) {
+			outcome = store.BatchStatusExpired
+			break
+		}
+		if now.Sub(lastPoll) >= batchStatusPollInterval {
+			lastPoll = now
+			if cur, err := h.batches.GetBatchByID(ctx, b.ID); err == nil && cur.Status == store.BatchStatusCancelling {
+				outcome = store.BatchStatusCancelled
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case sem <- struct{}{}:
+		}
+		wg.Add(1)
+		go func(ln batchInputLine) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			res := executeBatchLine(ctx, target, b, p, ln)
+			if ctx.Err() != nil {
+				// 中断导致的失败不落库，留给下次认领重跑。
+				return
+			}
+			_ = h.batches.RecordBatchRequestResult(ctx, res)
+		}(ln)
+	}
+	wg.Wait()
+	if outcome == store.BatchStatusCompleted {
+		if cur, err := h.batches.GetBatchByID(ctx, b.ID); err == nil && cur.Status == store.BatchStatusCancelling {
+			outcome = store.BatchStatusCancelled
+		}
+	}
+	return outcome
+}
+
+func executeBatchLine(ctx context.Context, target http.Handler, b store.Batch, p auth.Principal, ln batchInputLine) store.BatchRequestResult {
+	res := store.BatchRequestResult{BatchID: b.ID, LineIndex: ln.index, CustomID: ln.customID}
+	req, err := http.NewRequestWithContext(auth.WithPrincipal(ctx, p), http.MethodPost, b.Endpoint, bytes.NewReader(ln.body))
+	if err != nil {
+		code, msg := "request_build_failed", "构造请求失败"
+		res.ErrorCode, res.ErrorMessage = &code, &msg
+		return res
+	}
+	req.Header.Set("Content-Type", "application/json")
+	rec := newBatchResponseRecorder()
+	target.ServeHTTP(rec, req)
+
+	res.RequestID = rec.Header().Get(middleware.RequestIDHeader)
+	res.StatusCode = rec.status
+	if res.StatusCode == 0 {
+		res.StatusCode = http.StatusOK
+	}
+	body := bytes.TrimSpace(rec.body.Bytes())
+	if !json.Valid(body) {
+		// 网关自身拒绝（鉴权/配额/模型未启用等）多为纯文本，包装为 OpenAI 错误体便于统一解析。
+		body, _ = json.Marshal(map[string]any{
+			"error": map[string]any{
+				"message": string(body),
+				"type":    "invalid_request_error",
+			},
+		})
+	}
+	res.Body = body
+	return res
+}
+
+func batchRequestOutputID(res store.BatchRequestResult) string {
+	if res.RequestID != "" {
+		return "batch_req_" + res.RequestID
+	}
+	return fmt.Sprintf("batch_req_%d_%d", res.BatchID, res.LineIndex)
+}
+
+// finalizeBatch 按行号顺序写出输出文件（2xx）与错误文件（非 2xx / 网关错误 / 过期未执行），并落终态。
+func (h *Handler) finalizeBatch(ctx context.Context, b store.Batch, lines []batchInputLine, status string) error {
+	now := time.Now()
+	if status == store.BatchStatusCompleted {
+		if err := h.batches.MarkBatchFinalizing(ctx, b.ID, now); err != nil {
+			return err
+		}
+	}
+	results, err := h.batches.ListBatchRequestResults(ctx, b.ID)
+	if err != nil {
+		return err
+	}
+	var out, errOut bytes.Buffer
+	enc, errEnc := json.NewEncoder(&out), json.NewEncoder(&errOut)
+	seen := make(map[int]struct{}, len(results))
+	for _, res := range results {
+		seen[res.LineIndex] = struct{}{}
+		item := map[string]any{
+			"id":        batchRequestOutputID(res),
+			"custom_id": res.CustomID,
+			"response":  nil,
+			"error":     nil,
+		}
+		if res.ErrorCode != nil {
+			msg := ""
+			if res.ErrorMessage != nil {
+				msg = *res.ErrorMessage
+			}
+			item["error"] = map[string]any{"code": *res.ErrorCode, "message": msg}
+			_ = errEnc.Encode(item)
+			continue
+		}
+		item["response"] = map[string]any{
+			"status_code": res.StatusCode,
+			"request_id":  res.RequestID,
+			"body":        json.RawMessage(res.Body),
+		}
+		if res.StatusCode >= 200 && res.StatusCode < 300 {
+			_ = enc.Encode(item)
+		} else {
+			_ = errEnc.Encode(item)
+		}
+	}
+	if status == store.BatchStatusExpired {
+		for _, ln := range lines {
+			if _, ok := seen[ln.index]; ok {
+				continue
+			}
+			_ = errEnc.Encode(map[string]any{
+				"id":        fmt.Sprintf("batch_req_%d_%d", b.ID, ln.index),
+				"custom_id": ln.customID,
+				"response":  nil,
+				"error": map[string]any{
+					"code":    "batch_expired",
+					"message": "该请求未能在 completion_window 内执行",
+				},
+			})
+		}
+	}
+
+	fin := store.BatchFinalize{ID: b.ID, Status: status, Now: now}
+	if out.Len() > 0 {
+		f, err := h.createBatchOutputFile(ctx, b, "_output.jsonl", out.Bytes())
+		if err != nil {
+			return err
+		}
+		fin.OutputFileID = &f.PublicID
+	}
+	if errOut.Len() > 0 {
+		f, err := h.createBatchOutputFile(ctx, b, "_error.jsonl", errOut.Bytes())
+		if err != nil {
+			return err
+		}
+		fin.ErrorFileID = &f.PublicID
+	}
+	return h.batches.FinalizeBatch(ctx, fin)
+}
+
+func (h *Handler) createBatchOutputFile(ctx context.Context, b store.Batch, suffix string, content []byte) (store.BatchFile, error) {
+	publicID, err := auth.NewRandomToken("file-", 18)
+	if err != nil {
+		return store.BatchFile{}, err
+	}
+	return h.batches.CreateBatchFile(ctx, store.BatchFile{
+		PublicID: publicID,
+		UserID:   b.UserID,
+		TokenID:  b.TokenID,
+		Purpose:  store.BatchFilePurposeBatchOutput,
+		Filename: b.PublicID + suffix,
+	}, content)
+}
+
+func (h *Handler) failBatch(ctx context.Context, b store.Batch, errs []batchLineError) error {
+	for i := range errs {
+		if errs[i].Line == 0 {
+			errs[i].Line = nil
+		}
+	}
+	raw, err := json.Marshal(map[string]any{"object": "list", "data": errs})
+	if err != nil {
+		return err
+	}
+	s := string(raw)
+	return h.batches.FinalizeBatch(ctx, store.BatchFinalize{
+		ID:         b.ID,
+		Status:     store.BatchStatusFailed,
+		ErrorsJSON: &s,
+		Now:        time.Now(),
+	})
+}
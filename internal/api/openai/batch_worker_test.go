@@ -0,0 +1,58 @@
+package openai
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseBatchInput_ValidLinesStripStreamingAndServiceTier(t *testing.T) {
+	content := strings.Join([]string{
+		`{"custom_id":"a","method":"POST","url":"/v1/responses","body":{"model":"gpt-5","input":"hi","stream":true,"service_tier":"priority"}}`,
+		``,
+		`{"custom_id":"b","method":"post","url":"/v1/responses","body":{"model":"gpt-5","input":"yo"}}`,
+	}, "\n")
+
+	lines, errs := parseBatchInput([]byte(content), "/v1/responses")
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %+v", errs)
+	}
+	if len(lines) != 2 {
+		t.Fatalf("lines=%d, want 2", len(lines))
+	}
+	if lines[0].customID != "a" || lines[1].customID != "b" || lines[1].index != 1 {
+		t.Fatalf("unexpected lines: %+v", lines)
+	}
+	body := string(lines[0].body)
+	if strings.Contains(body, "stream") || strings.Contains(body, "service_tier") {
+		t.Fatalf("expected stream/service_tier stripped, got %s", body)
+	}
+}
+
+func TestParseBatchInput_RejectsInvalidLines(t *testing.T) {
+	content := strings.Join([]string{
+		`not json`,
+		`{"method":"POST","url":"/v1/responses","body":{}}`,
+		`{"custom_id":"a","method":"POST","url":"/v1/responses","body":{}}`,
+		`{"custom_id":"a","method":"POST","url":"/v1/responses","body":{}}`,
+		`{"custom_id":"b","method":"GET","url":"/v1/responses","body":{}}`,
+		`{"custom_id":"c","method":"POST","url":"/v1/chat/completions","body":{}}`,
+		`{"custom_id":"d","method":"POST","url":"/v1/responses"}`,
+	}, "\n")
+
+	lines, errs := parseBatchInput([]byte(content), "/v1/responses")
+	if len(lines) != 1 {
+		t.Fatalf("lines=%d, want 1", len(lines))
+	}
+	wantCodes := []string{"invalid_json_line", "missing_required_parameter", "duplicate_custom_id", "invalid_method", "mismatched_endpoint", "missing_required_parameter"}
+	if len(errs) != len(wantCodes) {
+		t.Fatalf("errs=%+v, want %d entries", errs, len(wantCodes))
+	}
+	for i, code := range wantCodes {
+		if errs[i].Code != code {
+			t.Fatalf("errs[%d].code=%q, want %q", i, errs[i].Code, code)
+		}
+	}
+	if errs[2].Line != 4 {
+		t.Fatalf("duplicate line=%d, want 4", errs[2].Line)
+	}
+}
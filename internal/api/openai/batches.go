@@ -0,0 +1,427 @@
+package openai
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"realms/internal/auth"
+	"realms/internal/middleware"
+	"realms/internal/store"
+)
+
+// BatchFileMaxBytes 为 /v1/files 单个上传文件的大小上限。
+const BatchFileMaxBytes int64 = 100 << 20
+
+const (
+	batchCompletionWindow     = "24h"
+	batchCompletionWindowSpan = 24 * time.Hour
+	batchMetadataMaxPairs     = 16
+)
+
+// batchSupportedEndpoints 为批任务可执行的北向端点；每行请求原样交给对应 handler 执行。
+var batchSupportedEndpoints = []string{"/v1/responses", "/v1/chat/completions", "/v1/embeddings"}
+
+type BatchStore interface {
+	CreateBatchFile(ctx context.Context, f store.BatchFile, content []byte) (store.BatchFile, error)
+	GetBatchFile(ctx context.Context, userID int64, publicID string) (store.BatchFile, bool, error)
+	GetBatchFileContent(ctx context.Context, userID int64, publicID string) ([]byte, bool, error)
+	ListBatchFiles(ctx context.Context, userID int64, purpose string, limit int) ([]store.BatchFile, error)
+
+	CreateBatch(ctx context.Context, in store.BatchCreate) (store.Batch, error)
+	GetBatch(ctx context.Context, userID int64, publicID string) (store.Batch, bool, error)
+	GetBatchByID(ctx context.Context, id int64) (store.Batch, error)
+	ListBatches(ctx context.Context, userID int64, after string, limit int) ([]store.Batch, error)
+	CancelBatch(ctx context.Context, userID int64, publicID string, now time.Time) (store.Batch, bool, error)
+
+	ClaimNextBatch(ctx context.Context, now time.Time, leaseUntil time.Time) (store.Batch, bool, error)
+	RenewBatchLease(ctx context.Context, id int64, leaseUntil time.Time) error
+	MarkBatchInProgress(ctx context.Context, id int64, total int, now time.Time) error
+	MarkBatchFinalizing(ctx context.Context, id int64, now time.Time) error
+	RecordBatchRequestResult(ctx context.Context, in store.BatchRequestResult) error
+	ListBatchRequestResults(ctx context.Context, batchID int64) ([]store.BatchRequestResult, error)
+	FinalizeBatch(ctx context.Context, in store.BatchFinalize) error
+
+	GetTokenAuthByTokenID(ctx context.Context, tokenID int64) (store.TokenAuth, error)
+}
+
+func isBatchSupportedEndpoint(endpoint string) bool {
+	for _, ep := range batchSupportedEndpoints {
+		if ep == endpoint {
+			return true
+		}
+	}
+	return false
+}
+
+// pathIDAfter 提取 prefix 之后的第一个路径段（如 /v1/files/{id}/content 中的 id）。
+func pathIDAfter(path string, prefix string) string {
+	if !strings.HasPrefix(path, prefix) {
+		return ""
+	}
+	rest := strings.TrimPrefix(path, prefix)
+	if i := strings.IndexByte(rest, '/'); i >= 0 {
+		rest = rest[:i]
+	}
+	return strings.TrimSpace(rest)
+}
+
+func unixOrNil(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return t.Unix()
+}
+
+func batchFileObject(f store.BatchFile) map[string]any {
+	return map[string]any{
+		"id":         f.PublicID,
+		"object":     "file",
+		"bytes":      f.Bytes,
+		"created_at": f.CreatedAt.Unix(),
+		"filename":   f.Filename,
+		"purpose":    f.Purpose,
+		"status":     "processed",
+		"expires_at": nil,
+	}
+}
+
+func batchObject(b store.Batch) map[string]any {
+	var errs any
+	if b.ErrorsJSON != nil && json.Valid([]byte(*b.ErrorsJSON)) {
+		errs = json.RawMessage(*b.ErrorsJSON)
+	}
+	var metadata any
+	if b.MetadataJSON != nil && json.Valid([]byte(*b.MetadataJSON)) {
+		metadata = json.RawMessage(*b.MetadataJSON)
+	}
+	return map[string]any{
+		"id":                b.PublicID,
+		"object":            "batch",
+		"endpoint":          b.Endpoint,
+		"errors":            errs,
+		"input_file_id":     b.InputFileID,
+		"completion_window": b.CompletionWindow,
+		"status":            b.Status,
+		"output_file_id":    b.OutputFileID,
+		"error_file_id":     b.ErrorFileID,
+		"created_at":        b.CreatedAt.Unix(),
+		"in_progress_at":    unixOrNil(b.InProgressAt),
+		"expires_at":        b.ExpiresAt.Unix(),
+		"finalizing_at":     unixOrNil(b.FinalizingAt),
+		"completed_at":      unixOrNil(b.CompletedAt),
+		"failed_at":         unixOrNil(b.FailedAt),
+		"expired_at":        unixOrNil(b.ExpiredAt),
+		"cancelling_at":     unixOrNil(b.CancellingAt),
+		"cancelled_at":      unixOrNil(b.CancelledAt),
+		"request_counts": map[string]any{
+			"total":     b.RequestTotal,
+			"completed": b.RequestCompleted,
+			"failed":    b.RequestFailed,
+		},
+		"metadata": metadata,
+	}
+}
+
+func writeJSONObject(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeListObject(w http.ResponseWriter, data []map[string]any, hasMore bool) {
+	var firstID, lastID any
+	if len(data) > 0 {
+		firstID = data[0]["id"]
+		lastID = data[len(data)-1]["id"]
+	}
+	writeJSONObject(w, http.StatusOK, map[string]any{
+		"object":   "list",
+		"data":     data,
+		"first_id": firstID,
+		"last_id":  lastID,
+		"has_more": hasMore,
+	})
+}
+
+func parseListLimit(raw string, def int, max int) int {
+	n, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || n <= 0 {
+		return def
+	}
+	if n > max {
+		return max
+	}
+	return n
+}
+
+// batchPrincipal 校验 Batch/Files 接口的调用方；未配置存储时统一返回 404（与对象不存在一致）。
+func (h *Handler) batchPrincipal(w http.ResponseWriter, r *http.Request) (auth.Principal, bool) {
+	p, ok := auth.PrincipalFromContext(r.Context())
+	if !ok || p.ActorType != auth.ActorTypeToken || p.TokenID == nil {
+		http.Error(w, "未鉴权", http.StatusUnauthorized)
+		return auth.Principal{}, false
+	}
+	if h.batches == nil {
+		writeNotFound(w)
+		return auth.Principal{}, false
+	}
+	return p, true
+}
+
+func (h *Handler) FileUpload(w http.ResponseWriter, r *http.Request) {
+	p, ok := h.batchPrincipal(w, r)
+	if !ok {
+		return
+	}
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", "请求体必须为 multipart/form-data")
+		return
+	}
+	if r.MultipartForm != nil {
+		defer func() { _ = r.MultipartForm.RemoveAll() }()
+	}
+	purpose := strings.TrimSpace(r.FormValue("purpose"))
+	if purpose != store.BatchFilePurposeBatch {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", "purpose 仅支持 batch")
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", "缺少 file")
+		return
+	}
+	defer file.Close()
+	content, err := io.ReadAll(io.LimitReader(file, BatchFileMaxBytes+1))
+	if err != nil {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", "读取上传文件失败")
+		return
+	}
+	if int64(len(content)) > BatchFileMaxBytes {
+		writeOpenAIError(w, http.StatusRequestEntityTooLarge, "invalid_request_error", "文件过大")
+		return
+	}
+	if len(strings.TrimSpace(string(content))) == 0 {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", "文件为空")
+		return
+	}
+	publicID, err := auth.NewRandomToken("file-", 18)
+	if err != nil {
+		writeOpenAIError(w, http.StatusInternalServerError, "server_error", "生成文件 ID 失败")
+		return
+	}
+	filename := strings.TrimSpace(header.Filename)
+	if filename == "" {
+		filename = "batch.jsonl"
+	}
+	f, err := h.batches.CreateBatchFile(r.Context(), store.BatchFile{
+		PublicID: publicID,
+		UserID:   p.UserID,
+		TokenID:  *p.TokenID,
+		Purpose:  purpose,
+		Filename: filename,
+	}, content)
+	if err != nil {
+		writeOpenAIError(w, http.StatusInternalServerError, "server_error", "保存文件失败")
+		return
+	}
+	writeJSONObject(w, http.StatusOK, batchFileObject(f))
+}
+
+func (h *Handler) FilesList(w http.ResponseWriter, r *http.Request) {
+	p, ok := h.batchPrincipal(w, r)
+	if !ok {
+		return
+	}
+	limit := parseListLimit(r.URL.Query().Get("limit"), 10000, 10000)
+	files, err := h.batches.ListBatchFiles(r.Context(), p.UserID, r.URL.Query().Get("purpose"), limit+1)
+	if err != nil {
+		writeOpenAIError(w, http.StatusInternalServerError, "server_error", "查询文件失败")
+		return
+	}
+	hasMore := len(files) > limit
+	if hasMore {
+		files = files[:limit]
+	}
+	data := make([]map[string]any, 0, len(files))
+	for _, f := range files {
+		data = append(data, batchFileObject(f))
+	}
+	writeListObject(w, data, hasMore)
+}
+
+func (h *Handler) FileRetrieve(w http.ResponseWriter, r *http.Request) {
+	p, ok := h.batchPrincipal(w, r)
+	if !ok {
+		return
+	}
+	f, found, err := h.batches.GetBatchFile(r.Context(), p.UserID, pathIDAfter(r.URL.Path, "/v1/files/"))
+	if err != nil {
+		writeOpenAIError(w, http.StatusInternalServerError, "server_error", "查询文件失败")
+		return
+	}
+	if !found {
+		writeOpenAIError(w, http.StatusNotFound, "invalid_request_error", "文件不存在")
+		return
+	}
+	writeJSONObject(w, http.StatusOK, batchFileObject(f))
+}
+
+func (h *Handler) FileContent(w http.ResponseWriter, r *http.Request) {
+	p, ok := h.batchPrincipal(w, r)
+	if !ok {
+		return
+	}
+	content, found, err := h.batches.GetBatchFileContent(r.Context(), p.UserID, pathIDAfter(r.URL.Path, "/v1/files/"))
+	if err != nil {
+		writeOpenAIError(w, http.StatusInternalServerError, "server_error", "读取文件失败")
+		return
+	}
+	if !found {
+		writeOpenAIError(w, http.StatusNotFound, "invalid_request_error", "文件不存在")
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(content)
+}
+
+func (h *Handler) BatchCreate(w http.ResponseWriter, r *http.Request) {
+	p, ok := h.batchPrincipal(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		InputFileID      string            `json:"input_file_id"`
+		Endpoint         string            `json:"endpoint"`
+		CompletionWindow string            `json:"completion_window"`
+		Metadata         map[string]string `json:"metadata"`
+	}
+	if err := json.Unmarshal(middleware.CachedBody(r.Context()), &req); err != nil {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", "请求体不是有效 JSON")
+		return
+	}
+	req.Endpoint = strings.TrimSpace(req.Endpoint)
+	if !isBatchSupportedEndpoint(req.Endpoint) {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", "endpoint 仅支持 "+strings.Join(batchSupportedEndpoints, ", "))
+		return
+	}
+	if strings.TrimSpace(req.CompletionWindow) != batchCompletionWindow {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", "completion_window 仅支持 24h")
+		return
+	}
+	if len(req.Metadata) > batchMetadataMaxPairs {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", "metadata 最多 16 个键值对")
+		return
+	}
+	f, found, err := h.batches.GetBatchFile(r.Context(), p.UserID, req.InputFileID)
+	if err != nil {
+		writeOpenAIError(w, http.StatusInternalServerError, "server_error", "查询文件失败")
+		return
+	}
+	if !found {
+		writeOpenAIError(w, http.StatusNotFound, "invalid_request_error", "input_file_id 不存在")
+		return
+	}
+	if f.Purpose != store.BatchFilePurposeBatch {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", "input_file_id 的 purpose 必须为 batch")
+		return
+	}
+	var metadataJSON *string
+	if len(req.Metadata) > 0 {
+		raw, err := json.Marshal(req.Metadata)
+		if err != nil {
+			writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", "metadata 非法")
+			return
+		}
+		s := string(raw)
+		metadataJSON = &s
+	}
+	publicID, err := auth.NewRandomToken("batch_", 18)
+	if err != nil {
+		writeOpenAIError(w, http.StatusInternalServerError, "server_error", "生成批任务 ID 失败")
+		return
+	}
+	b, err := h.batches.CreateBatch(r.Context(), store.BatchCreate{
+		PublicID:         publicID,
+		UserID:           p.UserID,
+		TokenID:          *p.TokenID,
+		Endpoint:         req.Endpoint,
+		CompletionWindow: batchCompletionWindow,
+		InputFileID:      f.PublicID,
+		MetadataJSON:     metadataJSON,
+		ExpiresAt:        time.Now().Add(batchCompletionWindowSpan),
+	})
+	if err != nil {
+		writeOpenAIError(w, http.StatusInternalServerError, "server_error", "创建批任务失败")
+		return
+	}
+	writeJSONObject(w, http.StatusOK, batchObject(b))
+}
+
+func (h *Handler) BatchRetrieve(w http.ResponseWriter, r *http.Request) {
+	p, ok := h.batchPrincipal(w, r)
+	if !ok {
+		return
+	}
+	b, found, err := h.batches.GetBatch(r.Context(), p.UserID, pathIDAfter(r.URL.Path, "/v1/batches/"))
+	if err != nil {
+		writeOpenAIError(w, http.StatusInternalServerError, "server_error", "查询批任务失败")
+		return
+	}
+	if !found {
+		writeOpenAIError(w, http.StatusNotFound, "invalid_request_error", "批任务不存在")
+		return
+	}
+	writeJSONObject(w, http.StatusOK, batchObject(b))
+}
+
+func (h *Handler) BatchCancel(w http.ResponseWriter, r *http.Request) {
+	p, ok := h.batchPrincipal(w, r)
+	if !ok {
+		return
+	}
+	b, changed, err := h.batches.CancelBatch(r.Context(), p.UserID, pathIDAfter(r.URL.Path, "/v1/batches/"), time.Now())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeOpenAIError(w, http.StatusNotFound, "invalid_request_error", "批任务不存在")
+			return
+		}
+		writeOpenAIError(w, http.StatusInternalServerError, "server_error", "取消批任务失败")
+		return
+	}
+	if !changed && b.Status != store.BatchStatusCancelling && b.Status != store.BatchStatusCancelled {
+		writeOpenAIError(w, http.StatusConflict, "invalid_request_error", "批任务状态为 "+b.Status+"，无法取消")
+		return
+	}
+	writeJSONObject(w, http.StatusOK, batchObject(b))
+}
+
+func (h *Handler) BatchesList(w http.ResponseWriter, r *http.Request) {
+	p, ok := h.batchPrincipal(w, r)
+	if !ok {
+		return
+	}
+	limit := parseListLimit(r.URL.Query().Get("limit"), 20, 100)
+	batches, err := h.batches.ListBatches(r.Context(), p.UserID, r.URL.Query().Get("after"), limit+1)
+	if err != nil {
+		writeOpenAIError(w, http.StatusInternalServerError, "server_error", "查询批任务失败")
+		return
+	}
+	hasMore := len(batches) > limit
+	if hasMore {
+		batches = batches[:limit]
+	}
+	data := make([]map[string]any, 0, len(batches))
+	for _, b := range batches {
+		data = append(data, batchObject(b))
+	}
+	writeListObject(w, data, hasMore)
+}
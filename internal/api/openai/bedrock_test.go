@@ -0,0 +1,120 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"realms/internal/scheduler"
+	"realms/internal/store"
+	"realms/internal/upstream"
+)
+
+func TestChatCompletions_BedrockChannelUsesAnthropicTranslation(t *testing.T) {
+	fs := &fakeStore{
+		channels: []store.UpstreamChannel{
+			{ID: 1, Type: store.UpstreamTypeBedrock, Status: 1, Groups: "g1", Setting: store.UpstreamChannelSetting{ChatCompletionsEnabled: true}},
+		},
+		endpoints: map[int64][]store.UpstreamEndpoint{
+			1: {{ID: 11, ChannelID: 1, BaseURL: "https://bedrock-runtime.us-east-1.amazonaws.com", Status: 1}},
+		},
+		bedrockCreds: map[int64][]store.BedrockCredential{
+			11: {{ID: 1, EndpointID: 11, Region: "us-east-1", Status: 1}},
+		},
+		models: map[string]store.ManagedModel{
+			"m1": {ID: 1, PublicID: "m1", GroupName: "g1", Status: 1},
+		},
+		bindings: map[string][]store.ChannelModelBinding{
+			"m1": {{ID: 1, ChannelID: 1, ChannelType: store.UpstreamTypeBedrock, PublicID: "m1", UpstreamModel: "anthropic.claude-3-5-haiku-20241022-v1:0", Status: 1}},
+		},
+	}
+
+	var gotSel scheduler.Selection
+	var gotPath string
+	var gotBody []byte
+	doer := DoerFunc(func(_ context.Context, sel scheduler.Selection, downstream *http.Request, body []byte) (*http.Response, error) {
+		gotSel = sel
+		gotPath = downstream.URL.Path
+		gotBody = body
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"text","text":"pong"}],"stop_reason":"end_turn","usage":{"input_tokens":6,"output_tokens":2}}`)),
+		}, nil
+	})
+	q := &fakeQuota{}
+	h := NewHandler(fs, fs, scheduler.New(fs), doer, nil, nil, q, fakeAudit{}, nil, nil, upstream.SSEPumpOptions{}, nil)
+
+	rr := runHandler(h.ChatCompletions, makeTokenRequest(http.MethodPost, "/v1/chat/completions", `{"model":"m1","messages":[{"role":"user","content":"ping"}]}`, 10))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d body=%s", rr.Code, rr.Body.String())
+	}
+	if gotSel.CredentialType != scheduler.CredentialTypeBedrock {
+		t.Fatalf("expected bedrock selection, got=%+v", gotSel)
+	}
+	if gotPath != "/v1/messages" {
+		t.Fatalf("expected translated /v1/messages, got=%q", gotPath)
+	}
+	var forwarded map[string]any
+	if err := json.Unmarshal(gotBody, &forwarded); err != nil {
+		t.Fatalf("unmarshal forwarded body: %v", err)
+	}
+	if forwarded["model"] != "anthropic.claude-3-5-haiku-20241022-v1:0" {
+		t.Fatalf("unexpected forwarded model: %v", forwarded["model"])
+	}
+	if !strings.Contains(rr.Body.String(), `"object":"chat.completion"`) || !strings.Contains(rr.Body.String(), "pong") {
+		t.Fatalf("expected chat.completion response, got=%s", rr.Body.String())
+	}
+	if len(q.commitCalls) != 1 || q.commitCalls[0].InputTokens == nil || *q.commitCalls[0].InputTokens != 6 {
+		t.Fatalf("unexpected commit calls: %+v", q.commitCalls)
+	}
+}
+
+func TestMessages_BedrockStreamBillsInvocationMetrics(t *testing.T) {
+	fs := &fakeStore{
+		channels: []store.UpstreamChannel{
+			{ID: 1, Type: store.UpstreamTypeBedrock, Status: 1, Groups: "g1", Setting: store.UpstreamChannelSetting{ChatCompletionsEnabled: true}},
+		},
+		endpoints: map[int64][]store.UpstreamEndpoint{
+			1: {{ID: 11, ChannelID: 1, BaseURL: "https://bedrock-runtime.us-east-1.amazonaws.com", Status: 1}},
+		},
+		bedrockCreds: map[int64][]store.BedrockCredential{
+			11: {{ID: 1, EndpointID: 11, Region: "us-east-1", Status: 1}},
+		},
+		models: map[string]store.ManagedModel{
+			"m1": {ID: 1, PublicID: "m1", GroupName: "g1", Status: 1},
+		},
+		bindings: map[string][]store.ChannelModelBinding{
+			"m1": {{ID: 1, ChannelID: 1, ChannelType: store.UpstreamTypeBedrock, PublicID: "m1", UpstreamModel: "anthropic.claude-3-5-haiku-20241022-v1:0", Status: 1}},
+		},
+	}
+
+	doer := DoerFunc(func(_ context.Context, _ scheduler.Selection, _ *http.Request, _ []byte) (*http.Response, error) {
+		// 与 Executor 转换后的 SSE 一致：message_delta 未携带 usage 时以 invocationMetrics 计费。
+		stream := "event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_1\",\"type\":\"message\",\"role\":\"assistant\",\"content\":[]}}\n\n" +
+			"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"pong\"}}\n\n" +
+			"event: message_stop\ndata: {\"type\":\"message_stop\",\"amazon-bedrock-invocationMetrics\":{\"inputTokenCount\":13,\"outputTokenCount\":5,\"invocationLatency\":120,\"firstByteLatency\":80}}\n\n"
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"text/event-stream"}},
+			Body:       io.NopCloser(strings.NewReader(stream)),
+		}, nil
+	})
+	q := &fakeQuota{}
+	h := NewHandler(fs, fs, scheduler.New(fs), doer, nil, nil, q, fakeAudit{}, nil, nil, upstream.SSEPumpOptions{}, nil)
+
+	rr := runHandler(h.Messages, makeTokenRequest(http.MethodPost, "/v1/messages", `{"model":"m1","max_tokens":32,"stream":true,"messages":[{"role":"user","content":"ping"}]}`, 10))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d body=%s", rr.Code, rr.Body.String())
+	}
+	if len(q.commitCalls) != 1 {
+		t.Fatalf("expected 1 commit call, got=%d", len(q.commitCalls))
+	}
+	commit := q.commitCalls[0]
+	if commit.InputTokens == nil || *commit.InputTokens != 13 || commit.OutputTokens == nil || *commit.OutputTokens != 5 {
+		t.Fatalf("unexpected committed usage: in=%v out=%v", commit.InputTokens, commit.OutputTokens)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/tidwall/gjson"
+
 	"realms/internal/scheduler"
 	"realms/internal/store"
 )
@@ -12,6 +14,7 @@ import (
 type resolvedChannelModelBindings struct {
 	upstreamByChannel  map[int64]string
 	bindingIDByChannel map[int64]int64
+	costByChannel      map[int64]store.ChannelModelCost
 }
 
 func resolveChannelModelBindings(bindings []store.ChannelModelBinding, requireChannelType string) resolvedChannelModelBindings {
@@ -19,6 +22,7 @@ func resolveChannelModelBindings(bindings []store.ChannelModelBinding, requireCh
 	out := resolvedChannelModelBindings{
 		upstreamByChannel:  make(map[int64]string, len(bindings)),
 		bindingIDByChannel: make(map[int64]int64, len(bindings)),
+		costByChannel:      make(map[int64]store.ChannelModelCost),
 	}
 	for _, binding := range bindings {
 		if requireChannelType != "" && strings.TrimSpace(binding.ChannelType) != requireChannelType {
@@ -35,6 +39,11 @@ func resolveChannelModelBindings(bindings []store.ChannelModelBinding, requireCh
 		if binding.ID > 0 {
 			out.bindingIDByChannel[binding.ChannelID] = binding.ID
 		}
+		if binding.Cost.Configured() {
+			out.costByChannel[binding.ChannelID] = binding.Cost
+		} else {
+			delete(out.costByChannel, binding.ChannelID)
+		}
 	}
 	return out
 }
@@ -61,6 +70,7 @@ func (r resolvedChannelModelBindings) ApplyToConstraints(cons *scheduler.Constra
 	if len(r.upstreamByChannel) == 0 {
 		cons.AllowChannelIDs = nil
 		cons.ChannelModelBindingIDs = nil
+		cons.ChannelModelCosts = nil
 		return
 	}
 	allowChannelIDs := make(map[int64]struct{}, len(r.upstreamByChannel))
@@ -71,8 +81,13 @@ func (r resolvedChannelModelBindings) ApplyToConstraints(cons *scheduler.Constra
 	for channelID, bindingID := range r.bindingIDByChannel {
 		bindingIDs[channelID] = bindingID
 	}
+	costs := make(map[int64]store.ChannelModelCost, len(r.costByChannel))
+	for channelID, cost := range r.costByChannel {
+		costs[channelID] = cost
+	}
 	cons.AllowChannelIDs = allowChannelIDs
 	cons.ChannelModelBindingIDs = bindingIDs
+	cons.ChannelModelCosts = costs
 }
 
 type upstreamHTTPFailureClassification struct {
@@ -100,6 +115,14 @@ func classifyUpstreamHTTPFailure(statusCode int, body []byte, bindingID int64, c
 			CooldownUntil: codexErr.cooldownUntil(time.Now(), body),
 		}
 	}
+	if isContentFilterFailure(statusCode, body) {
+		// 内容审核拦截由请求内容决定，换渠道重试无意义，也不应惩罚当前渠道。
+		return upstreamHTTPFailureClassification{
+			Retriable:  false,
+			Scope:      scheduler.FailureScopeRequest,
+			ErrorClass: "upstream_content_filter",
+		}
+	}
 	if bindingID > 0 && isExplicitChannelModelFailure(statusCode, body) {
 		return upstreamHTTPFailureClassification{
 			Retriable:  true,
@@ -121,6 +144,19 @@ func classifyUpstreamHTTPFailure(statusCode int, body []byte, bindingID int64, c
 	}
 }
 
+// isContentFilterFailure 识别 Azure OpenAI 内容过滤（content_filter / ResponsibleAIPolicyViolation）错误。
+func isContentFilterFailure(statusCode int, body []byte) bool {
+	if statusCode != http.StatusBadRequest || len(body) == 0 {
+		return false
+	}
+	code, _ := extractUpstreamErrorCodeAndType(body)
+	if strings.EqualFold(code, "content_filter") {
+		return true
+	}
+	inner := gjson.GetBytes(body, "error.innererror.code").String()
+	return strings.EqualFold(strings.TrimSpace(inner), "ResponsibleAIPolicyViolation")
+}
+
 func isExplicitChannelModelFailure(statusCode int, body []byte) bool {
 	if !isExplicitModelFailureStatus(statusCode) {
 		return false
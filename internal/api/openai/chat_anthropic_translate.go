@@ -0,0 +1,592 @@
+package openai
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"realms/internal/upstream"
+)
+
+// chat/completions -> Anthropic Messages 协议转换：
+// - 请求：messages/tools/tool_choice/images/stop/max_tokens 映射到 Messages 结构
+// - 响应：Messages JSON / SSE 事件流转换回 chat.completion / chat.completion.chunk
+// - usage：prompt_tokens 包含缓存读写 token，缓存部分记入 prompt_tokens_details.cached_tokens
+
+// defaultTranslatedAnthropicMaxTokens 为 chat 请求未携带 max_tokens 时的兜底值（Anthropic 要求必填）。
+const defaultTranslatedAnthropicMaxTokens = 4096
+
+var errChatTranslateUnsupportedRole = errors.New("不支持的消息角色")
+
+func chatCompletionsPayloadToAnthropic(payload map[string]any) (map[string]any, error) {
+	out := make(map[string]any, 12)
+	out["model"] = payload["model"]
+
+	var system []any
+	var messages []any
+	appendMessage := func(role string, blocks []any) {
+		if len(blocks) == 0 {
+			return
+		}
+		// Anthropic 要求 user/assistant 交替：相邻同角色消息合并为一条。
+		if n := len(messages); n > 0 {
+			last, _ := messages[n-1].(map[string]any)
+			if last != nil && last["role"] == role {
+				prev, _ := last["content"].([]any)
+				last["content"] = append(prev, blocks...)
+				return
+			}
+		}
+		messages = append(messages, map[string]any{"role": role, "content": blocks})
+	}
+
+	rawMessages, _ := payload["messages"].([]any)
+	for _, item := range rawMessages {
+		msg, ok := item.(map[string]any)
+		if !ok {
+			return nil, errInvalidJSON
+		}
+		role := strings.TrimSpace(stringFromAny(msg["role"]))
+		switch role {
+		case "system", "developer":
+			system = append(system, chatContentToAnthropicBlocks(msg["content"], true)...)
+		case "user":
+			appendMessage("user", chatContentToAnthropicBlocks(msg["content"], false))
+		case "assistant":
+			blocks := chatContentToAnthropicBlocks(msg["content"], true)
+			if calls, ok := msg["tool_calls"].([]any); ok {
+				for _, c := range calls {
+					call, ok := c.(map[string]any)
+					if !ok {
+						continue
+					}
+					fn, _ := call["function"].(map[string]any)
+					input := map[string]any{}
+					if args := strings.TrimSpace(stringFromAny(fn["arguments"])); args != "" {
+						var parsed map[string]any
+						if err := json.Unmarshal([]byte(args), &parsed); err == nil && parsed != nil {
+							input = parsed
+						}
+					}
+					blocks = append(blocks, map[string]any{
+						"type":  "tool_use",
+						"id":    stringFromAny(call["id"]),
+						"name":  stringFromAny(fn["name"]),
+						"input": input,
+					})
+				}
+			}
+			appendMessage("assistant", blocks)
+		case "tool":
+			result := map[string]any{
+				"type":        "tool_result",
+				"tool_use_id": stringFromAny(msg["tool_call_id"]),
+			}
+			if s, ok := msg["content"].(string); ok {
+				result["content"] = s
+			} else if blocks := chatContentToAnthropicBlocks(msg["content"], false); len(blocks) > 0 {
+				result["content"] = blocks
+			}
+			appendMessage("user", []any{result})
+		default:
+			return nil, errChatTranslateUnsupportedRole
+		}
+	}
+	if len(messages) == 0 {
+		return nil, errors.New("messages 不能为空")
+	}
+	out["messages"] = messages
+	if len(system) > 0 {
+		out["system"] = system
+	}
+
+	maxTokens := intFromAny(payload["max_completion_tokens"])
+	if maxTokens == nil {
+		maxTokens = intFromAny(payload["max_tokens"])
+	}
+	if maxTokens != nil && *maxTokens > 0 {
+		out["max_tokens"] = *maxTokens
+	} else {
+		out["max_tokens"] = int64(defaultTranslatedAnthropicMaxTokens)
+	}
+
+	for _, key := range []string{"temperature", "top_p", "stream"} {
+		if v, ok := payload[key]; ok && v != nil {
+			out[key] = v
+		}
+	}
+	switch stop := payload["stop"].(type) {
+	case string:
+		if stop != "" {
+			out["stop_sequences"] = []any{stop}
+		}
+	case []any:
+		if len(stop) > 0 {
+			out["stop_sequences"] = stop
+		}
+	}
+	if user := strings.TrimSpace(stringFromAny(payload["user"])); user != "" {
+		out["metadata"] = map[string]any{"user_id": user}
+	}
+
+	if rawTools, ok := payload["tools"].([]any); ok && len(rawTools) > 0 {
+		tools := make([]any, 0, len(rawTools))
+		for _, t := range rawTools {
+			tool, ok := t.(map[string]any)
+			if !ok || stringFromAny(tool["type"]) != "function" {
+				continue
+			}
+			fn, _ := tool["function"].(map[string]any)
+			name := strings.TrimSpace(stringFromAny(fn["name"]))
+			if name == "" {
+				continue
+			}
+			schema, _ := fn["parameters"].(map[string]any)
+			if schema == nil {
+				schema = map[string]any{"type": "object"}
+			}
+			converted := map[string]any{"name": name, "input_schema": schema}
+			if desc := stringFromAny(fn["description"]); desc != "" {
+				converted["description"] = desc
+			}
+			tools = append(tools, converted)
+		}
+		if len(tools) > 0 {
+			out["tools"] = tools
+		}
+	}
+	if choice := chatToolChoiceToAnthropic(payload["tool_choice"], payload["parallel_tool_calls"]); choice != nil {
+		if _, hasTools := out["tools"]; hasTools {
+			out["tool_choice"] = choice
+		}
+	}
+	return out, nil
+}
+
+func chatToolChoiceToAnthropic(v any, parallel any) map[string]any {
+	var out map[string]any
+	switch tc := v.(type) {
+	case string:
+		switch tc {
+		case "none":
+			return map[string]any{"type": "none"}
+		case "required":
+			out = map[string]any{"type": "any"}
+		case "auto":
+			out = map[string]any{"type": "auto"}
+		}
+	case map[string]any:
+		fn, _ := tc["function"].(map[string]any)
+		if name := strings.TrimSpace(stringFromAny(fn["name"])); name != "" {
+			out = map[string]any{"type": "tool", "name": name}
+		}
+	}
+	if p, ok := parallel.(bool); ok && !p {
+		if out == nil {
+			out = map[string]any{"type": "auto"}
+		}
+		out["disable_parallel_tool_use"] = true
+	}
+	return out
+}
+
+// chatContentToAnthropicBlocks 转换 chat content（string 或 content parts）为 Anthropic content blocks。
+// textOnly=true 时忽略图片等非文本 part（system/assistant 仅允许文本）。
+func chatContentToAnthropicBlocks(content any, textOnly bool) []any {
+	switch c := content.(type) {
+	case string:
+		if c == "" {
+			return nil
+		}
+		return []any{map[string]any{"type": "text", "text": c}}
+	case []any:
+		out := make([]any, 0, len(c))
+		for _, item := range c {
+			part, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			switch stringFromAny(part["type"]) {
+			case "text", "input_text":
+				if text := stringFromAny(part["text"]); text != "" {
+					out = append(out, map[string]any{"type": "text", "text": text})
+				}
+			case "image_url":
+				if textOnly {
+					continue
+				}
+				url := ""
+				switch iu := part["image_url"].(type) {
+				case string:
+					url = iu
+				case map[string]any:
+					url = stringFromAny(iu["url"])
+				}
+				if block := imageURLToAnthropicBlock(url); block != nil {
+					out = append(out, block)
+				}
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+func imageURLToAnthropicBlock(url string) map[string]any {
+	url = strings.TrimSpace(url)
+	if url == "" {
+		return nil
+	}
+	if strings.HasPrefix(url, "data:") {
+		// data:<media_type>;base64,<data>
+		meta, data, ok := strings.Cut(strings.TrimPrefix(url, "data:"), ",")
+		if !ok {
+			return nil
+		}
+		mediaType, encoding, _ := strings.Cut(meta, ";")
+		if encoding != "base64" || mediaType == "" {
+			return nil
+		}
+		return map[string]any{
+			"type": "image",
+			"source": map[string]any{
+				"type":       "base64",
+				"media_type": mediaType,
+				"data":       data,
+			},
+		}
+	}
+	return map[string]any{
+		"type":   "image",
+		"source": map[string]any{"type": "url", "url": url},
+	}
+}
+
+func anthropicStopReasonToFinishReason(reason string) string {
+	switch strings.TrimSpace(reason) {
+	case "", "end_turn", "stop_sequence", "pause_turn":
+		return "stop"
+	case "max_tokens":
+		return "length"
+	case "tool_use":
+		return "tool_calls"
+	case "refusal":
+		return "content_filter"
+	default:
+		return "stop"
+	}
+}
+
+type anthropicUsageAcc struct {
+	input       int64
+	output      int64
+	cacheRead   int64
+	cacheCreate int64
+}
+
+func (u *anthropicUsageAcc) merge(usage map[string]any) {
+	if usage == nil {
+		return
+	}
+	if v := intFromAny(usage["input_tokens"]); v != nil {
+		u.input = *v
+	}
+	if v := intFromAny(usage["output_tokens"]); v != nil {
+		u.output = *v
+	}
+	if v := intFromAny(usage["cache_read_input_tokens"]); v != nil {
+		u.cacheRead = *v
+	}
+	if v := intFromAny(usage["cache_creation_input_tokens"]); v != nil {
+		u.cacheCreate = *v
+	}
+}
+
+func (u anthropicUsageAcc) chatUsage() map[string]any {
+	cached := u.cacheRead + u.cacheCreate
+	prompt := u.input + cached
+	out := map[string]any{
+		"prompt_tokens":     prompt,
+		"completion_tokens": u.output,
+		"total_tokens":      prompt + u.output,
+	}
+	if cached > 0 {
+		out["prompt_tokens_details"] = map[string]any{"cached_tokens": cached}
+	}
+	return out
+}
+
+func anthropicMessageToChatCompletion(body []byte) ([]byte, error) {
+	var msg map[string]any
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, err
+	}
+	var text, thinking strings.Builder
+	var toolCalls []any
+	blocks, _ := msg["content"].([]any)
+	for _, b := range blocks {
+		block, ok := b.(map[string]any)
+		if !ok {
+			continue
+		}
+		switch stringFromAny(block["type"]) {
+		case "text":
+			text.WriteString(stringFromAny(block["text"]))
+		case "thinking":
+			thinking.WriteString(stringFromAny(block["thinking"]))
+		case "tool_use":
+			args, err := json.Marshal(block["input"])
+			if err != nil || string(args) == "null" {
+				args = []byte("{}")
+			}
+			toolCalls = append(toolCalls, map[string]any{
+				"index": len(toolCalls),
+				"id":    stringFromAny(block["id"]),
+				"type":  "function",
+				"function": map[string]any{
+					"name":      stringFromAny(block["name"]),
+					"arguments": string(args),
+				},
+			})
+		}
+	}
+
+	message := map[string]any{"role": "assistant", "content": nil}
+	if text.Len() > 0 {
+		message["content"] = text.String()
+	}
+	if thinking.Len() > 0 {
+		message["reasoning_content"] = thinking.String()
+	}
+	if len(toolCalls) > 0 {
+		message["tool_calls"] = toolCalls
+	}
+
+	var usage anthropicUsageAcc
+	usageMap, _ := msg["usage"].(map[string]any)
+	usage.merge(usageMap)
+
+	return json.Marshal(map[string]any{
+		"id":      chatCompletionIDFromAnthropic(stringFromAny(msg["id"])),
+		"object":  "chat.completion",
+		"created": time.Now().Unix(),
+		"model":   stringFromAny(msg["model"]),
+		"choices": []any{map[string]any{
+			"index":         0,
+			"message":       message,
+			"finish_reason": anthropicStopReasonToFinishReason(stringFromAny(msg["stop_reason"])),
+		}},
+		"usage": usage.chatUsage(),
+	})
+}
+
+func chatCompletionIDFromAnthropic(id string) string {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return "chatcmpl-realms"
+	}
+	return "chatcmpl-" + strings.TrimPrefix(id, "msg_")
+}
+
+// anthropicErrorToOpenAIError 把 Anthropic 错误体（{"type":"error","error":{...}}）改写为 OpenAI 错误格式；
+// 无法识别时原样返回。
+func anthropicErrorToOpenAIError(body []byte) []byte {
+	var root map[string]any
+	if err := json.Unmarshal(body, &root); err != nil {
+		return body
+	}
+	errObj, ok := root["error"].(map[string]any)
+	if !ok {
+		return body
+	}
+	out, err := json.Marshal(map[string]any{
+		"error": map[string]any{
+			"message": stringFromAny(errObj["message"]),
+			"type":    stringFromAny(errObj["type"]),
+			"code":    nil,
+		},
+	})
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+type anthropicToChatStreamState struct {
+	includeUsage bool
+
+	id      string
+	model   string
+	created int64
+
+	usage        anthropicUsageAcc
+	finishReason string
+	// finishSent 表示已输出带 finish_reason 的 chunk；finished 表示已输出 [DONE]。
+	finishSent bool
+	finished   bool
+	// toolIndexByBlock 把 Anthropic content block index 映射为 chat tool_calls[].index。
+	toolIndexByBlock map[int64]int
+}
+
+// newAnthropicToChatCompletionsStreamTransformer 返回 upstream.SSEPumpHooks.TransformData：
+// 把 Anthropic Messages SSE 事件流转换为 chat.completion.chunk 流（以 data: [DONE] 结束）。
+func newAnthropicToChatCompletionsStreamTransformer(includeUsage bool) func(event string, data string) ([]upstream.SSEEvent, bool, error) {
+	st := &anthropicToChatStreamState{
+		includeUsage:     includeUsage,
+		created:          time.Now().Unix(),
+		toolIndexByBlock: make(map[int64]int),
+	}
+	return st.transform
+}
+
+func (st *anthropicToChatStreamState) chunk(delta map[string]any, finishReason any) upstream.SSEEvent {
+	b, _ := json.Marshal(map[string]any{
+		"id":      st.id,
+		"object":  "chat.completion.chunk",
+		"created": st.created,
+		"model":   st.model,
+		"choices": []any{map[string]any{
+			"index":         0,
+			"delta":         delta,
+			"finish_reason": finishReason,
+		}},
+	})
+	return upstream.SSEEvent{Data: string(b)}
+}
+
+// finish 输出结束 chunk（若尚未输出）、usage chunk 与 [DONE]；重复调用时不再输出。
+func (st *anthropicToChatStreamState) finish() []upstream.SSEEvent {
+	if st.finished {
+		return nil
+	}
+	st.finished = true
+	var outs []upstream.SSEEvent
+	if !st.finishSent {
+		st.finishSent = true
+		if st.finishReason == "" {
+			st.finishReason = "stop"
+		}
+		outs = append(outs, st.chunk(map[string]any{}, st.finishReason))
+	}
+	b, _ := json.Marshal(map[string]any{
+		"id":      st.id,
+		"object":  "chat.completion.chunk",
+		"created": st.created,
+		"model":   st.model,
+		"choices": []any{},
+		"usage":   st.usage.chatUsage(),
+	})
+	// 未请求 include_usage 时 usage chunk 只用于计费提取，不写回下游（与 OpenAI 流语义一致）。
+	return append(outs,
+		upstream.SSEEvent{Data: string(b), Internal: !st.includeUsage},
+		upstream.SSEEvent{Data: "[DONE]"},
+	)
+}
+
+func (st *anthropicToChatStreamState) transform(event string, data string) ([]upstream.SSEEvent, bool, error) {
+	data = strings.TrimSpace(data)
+	if data == "[DONE]" {
+		// 上游未发送 message_stop 就结束时由 PumpSSE 在 EOF 触发，补齐结束 chunk。
+		return st.finish(), true, nil
+	}
+	var evt map[string]any
+	if err := json.Unmarshal([]byte(data), &evt); err != nil {
+		return nil, true, nil
+	}
+	typ := stringFromAny(evt["type"])
+	if typ == "" {
+		typ = strings.TrimSpace(event)
+	}
+
+	switch typ {
+	case "message_start":
+		msg, _ := evt["message"].(map[string]any)
+		st.id = chatCompletionIDFromAnthropic(stringFromAny(msg["id"]))
+		st.model = stringFromAny(msg["model"])
+		usage, _ := msg["usage"].(map[string]any)
+		st.usage.merge(usage)
+		return []upstream.SSEEvent{st.chunk(map[string]any{"role": "assistant", "content": ""}, nil)}, true, nil
+	case "content_block_start":
+		block, _ := evt["content_block"].(map[string]any)
+		if stringFromAny(block["type"]) != "tool_use" {
+			return nil, true, nil
+		}
+		blockIndex := int64(0)
+		if v := intFromAny(evt["index"]); v != nil {
+			blockIndex = *v
+		}
+		toolIndex := len(st.toolIndexByBlock)
+		st.toolIndexByBlock[blockIndex] = toolIndex
+		return []upstream.SSEEvent{st.chunk(map[string]any{
+			"tool_calls": []any{map[string]any{
+				"index": toolIndex,
+				"id":    stringFromAny(block["id"]),
+				"type":  "function",
+				"function": map[string]any{
+					"name":      stringFromAny(block["name"]),
+					"arguments": "",
+				},
+			}},
+		}, nil)}, true, nil
+	case "content_block_delta":
+		delta, _ := evt["delta"].(map[string]any)
+		switch stringFromAny(delta["type"]) {
+		case "text_delta":
+			return []upstream.SSEEvent{st.chunk(map[string]any{"content": stringFromAny(delta["text"])}, nil)}, true, nil
+		case "thinking_delta":
+			return []upstream.SSEEvent{st.chunk(map[string]any{"reasoning_content": stringFromAny(delta["thinking"])}, nil)}, true, nil
+		case "input_json_delta":
+			blockIndex := int64(0)
+			if v := intFromAny(evt["index"]); v != nil {
+				blockIndex = *v
+			}
+			toolIndex, ok := st.toolIndexByBlock[blockIndex]
+			if !ok {
+				return nil, true, nil
+			}
+			return []upstream.SSEEvent{st.chunk(map[string]any{
+				"tool_calls": []any{map[string]any{
+					"index":    toolIndex,
+					"function": map[string]any{"arguments": stringFromAny(delta["partial_json"])},
+				}},
+			}, nil)}, true, nil
+		default:
+			return nil, true, nil
+		}
+	case "message_delta":
+		delta, _ := evt["delta"].(map[string]any)
+		st.finishReason = anthropicStopReasonToFinishReason(stringFromAny(delta["stop_reason"]))
+		usage, _ := evt["usage"].(map[string]any)
+		st.usage.merge(usage)
+		st.finishSent = true
+		return []upstream.SSEEvent{st.chunk(map[string]any{}, st.finishReason)}, true, nil
+	case "message_stop":
+		return st.finish(), true, nil
+	case "error":
+		errObj, _ := evt["error"].(map[string]any)
+		b, _ := json.Marshal(map[string]any{
+			"error": map[string]any{
+				"message": stringFromAny(errObj["message"]),
+				"type":    stringFromAny(errObj["type"]),
+			},
+		})
+		return []upstream.SSEEvent{{Data: string(b)}}, true, nil
+	default:
+		// ping / content_block_stop 等无对应 chunk 的事件直接丢弃。
+		return nil, true, nil
+	}
+}
+
+// newChatToAnthropicAdapter 构造 chat/completions 下游 -> anthropic 上游的协议适配器。
+func newChatToAnthropicAdapter(includeUsage bool) *upstreamProtocolAdapter {
+	return &upstreamProtocolAdapter{
+		upstreamPath:      "/v1/messages",
+		translateResponse: anthropicMessageToChatCompletion,
+		translateError:    anthropicErrorToOpenAIError,
+		newStreamTransformer: func() func(event string, data string) ([]upstream.SSEEvent, bool, error) {
+			return newAnthropicToChatCompletionsStreamTransformer(includeUsage)
+		},
+	}
+}
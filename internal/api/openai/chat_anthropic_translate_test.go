@@ -0,0 +1,397 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"realms/internal/auth"
+	"realms/internal/middleware"
+	"realms/internal/scheduler"
+	"realms/internal/store"
+	"realms/internal/upstream"
+)
+
+func TestChatCompletionsPayloadToAnthropic_MapsMessagesToolsAndImages(t *testing.T) {
+	var payload map[string]any
+	if err := json.Unmarshal([]byte(`{
+		"model":"claude-x",
+		"messages":[
+			{"role":"system","content":"be brief"},
+			{"role":"user","content":[{"type":"text","text":"look"},{"type":"image_url","image_url":{"url":"data:image/png;base64,QUJD"}}]},
+			{"role":"assistant","content":null,"tool_calls":[{"id":"call_1","type":"function","function":{"name":"get","arguments":"{\"a\":1}"}}]},
+			{"role":"tool","tool_call_id":"call_1","content":"ok"},
+			{"role":"user","content":"next"}
+		],
+		"tools":[{"type":"function","function":{"name":"get","description":"d","parameters":{"type":"object"}}}],
+		"tool_choice":"required",
+		"stop":"END",
+		"max_completion_tokens":128
+	}`), &payload); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	out, err := chatCompletionsPayloadToAnthropic(payload)
+	if err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	raw, _ := json.Marshal(out)
+	var got struct {
+		System   []map[string]any `json:"system"`
+		Messages []struct {
+			Role    string           `json:"role"`
+			Content []map[string]any `json:"content"`
+		} `json:"messages"`
+		MaxTokens     int              `json:"max_tokens"`
+		StopSequences []string         `json:"stop_sequences"`
+		Tools         []map[string]any `json:"tools"`
+		ToolChoice    map[string]any   `json:"tool_choice"`
+	}
+	if err := json.Unmarshal(raw, &got); err != nil {
+		t.Fatalf("unmarshal converted: %v", err)
+	}
+	if len(got.System) != 1 || got.System[0]["text"] != "be brief" {
+		t.Fatalf("unexpected system: %#v", got.System)
+	}
+	if len(got.Messages) != 3 {
+		t.Fatalf("expected 3 alternating messages, got=%s", raw)
+	}
+	if got.Messages[0].Role != "user" || len(got.Messages[0].Content) != 2 || got.Messages[0].Content[1]["type"] != "image" {
+		t.Fatalf("unexpected first message: %#v", got.Messages[0])
+	}
+	if got.Messages[1].Role != "assistant" || got.Messages[1].Content[0]["type"] != "tool_use" || got.Messages[1].Content[0]["id"] != "call_1" {
+		t.Fatalf("unexpected assistant message: %#v", got.Messages[1])
+	}
+	// tool 结果与随后的 user 文本合并为同一条 user 消息。
+	if got.Messages[2].Role != "user" || len(got.Messages[2].Content) != 2 || got.Messages[2].Content[0]["type"] != "tool_result" {
+		t.Fatalf("unexpected tool_result message: %#v", got.Messages[2])
+	}
+	if got.MaxTokens != 128 {
+		t.Fatalf("expected max_tokens=128, got=%d", got.MaxTokens)
+	}
+	if len(got.StopSequences) != 1 || got.StopSequences[0] != "END" {
+		t.Fatalf("unexpected stop_sequences: %#v", got.StopSequences)
+	}
+	if len(got.Tools) != 1 || got.Tools[0]["name"] != "get" || got.Tools[0]["input_schema"] == nil {
+		t.Fatalf("unexpected tools: %#v", got.Tools)
+	}
+	if got.ToolChoice["type"] != "any" {
+		t.Fatalf("expected tool_choice any, got=%#v", got.ToolChoice)
+	}
+}
+
+func TestAnthropicMessageToChatCompletion_MapsContentUsageAndFinishReason(t *testing.T) {
+	out, err := anthropicMessageToChatCompletion([]byte(`{"id":"msg_abc","type":"message","role":"assistant","model":"claude-x","content":[{"type":"text","text":"hi"},{"type":"tool_use","id":"tu_1","name":"get","input":{"a":1}}],"stop_reason":"tool_use","usage":{"input_tokens":10,"output_tokens":5,"cache_read_input_tokens":3}}`))
+	if err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	var got struct {
+		ID      string `json:"id"`
+		Object  string `json:"object"`
+		Choices []struct {
+			Message struct {
+				Content   string           `json:"content"`
+				ToolCalls []map[string]any `json:"tool_calls"`
+			} `json:"message"`
+			FinishReason string `json:"finish_reason"`
+		} `json:"choices"`
+		Usage struct {
+			PromptTokens        int64 `json:"prompt_tokens"`
+			CompletionTokens    int64 `json:"completion_tokens"`
+			PromptTokensDetails struct {
+				CachedTokens int64 `json:"cached_tokens"`
+			} `json:"prompt_tokens_details"`
+		} `json:"usage"`
+	}
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got.ID != "chatcmpl-abc" || got.Object != "chat.completion" {
+		t.Fatalf("unexpected id/object: %s", out)
+	}
+	if len(got.Choices) != 1 || got.Choices[0].Message.Content != "hi" || got.Choices[0].FinishReason != "tool_calls" {
+		t.Fatalf("unexpected choice: %s", out)
+	}
+	if len(got.Choices[0].Message.ToolCalls) != 1 {
+		t.Fatalf("expected 1 tool call: %s", out)
+	}
+	if got.Usage.PromptTokens != 13 || got.Usage.CompletionTokens != 5 || got.Usage.PromptTokensDetails.CachedTokens != 3 {
+		t.Fatalf("unexpected usage: %s", out)
+	}
+}
+
+func TestAnthropicToChatStreamTransformer_EmitsChunksUsageAndDone(t *testing.T) {
+	tr := newAnthropicToChatCompletionsStreamTransformer(true)
+	events := []struct{ event, data string }{
+		{"message_start", `{"type":"message_start","message":{"id":"msg_1","model":"claude-x","usage":{"input_tokens":9,"output_tokens":1}}}`},
+		{"content_block_start", `{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`},
+		{"content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"hel"}}`},
+		{"content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"lo"}}`},
+		{"content_block_stop", `{"type":"content_block_stop","index":0}`},
+		{"message_delta", `{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":4}}`},
+		{"message_stop", `{"type":"message_stop"}`},
+	}
+	var outs []upstream.SSEEvent
+	for _, ev := range events {
+		o, handled, err := tr(ev.event, ev.data)
+		if err != nil {
+			t.Fatalf("transform %s: %v", ev.event, err)
+		}
+		if !handled {
+			t.Fatalf("expected %s to be handled", ev.event)
+		}
+		outs = append(outs, o...)
+	}
+	if len(outs) == 0 || outs[len(outs)-1].Data != "[DONE]" {
+		t.Fatalf("expected trailing [DONE], got=%#v", outs)
+	}
+	var text strings.Builder
+	var finish string
+	var usage map[string]any
+	for _, o := range outs[:len(outs)-1] {
+		var chunk struct {
+			Object  string `json:"object"`
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+				FinishReason *string `json:"finish_reason"`
+			} `json:"choices"`
+			Usage map[string]any `json:"usage"`
+		}
+		if err := json.Unmarshal([]byte(o.Data), &chunk); err != nil {
+			t.Fatalf("unmarshal chunk %q: %v", o.Data, err)
+		}
+		if chunk.Object != "chat.completion.chunk" {
+			t.Fatalf("unexpected object: %q", o.Data)
+		}
+		for _, c := range chunk.Choices {
+			text.WriteString(c.Delta.Content)
+			if c.FinishReason != nil {
+				finish = *c.FinishReason
+			}
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+	}
+	if text.String() != "hello" {
+		t.Fatalf("unexpected text: %q", text.String())
+	}
+	if finish != "stop" {
+		t.Fatalf("unexpected finish_reason: %q", finish)
+	}
+	if usage == nil || usage["prompt_tokens"] != float64(9) || usage["completion_tokens"] != float64(4) {
+		t.Fatalf("unexpected usage: %#v", usage)
+	}
+}
+
+func TestAnthropicToChatStreamTransformer_UsageInternalWithoutIncludeUsage(t *testing.T) {
+	tr := newAnthropicToChatCompletionsStreamTransformer(false)
+	events := []struct{ event, data string }{
+		{"message_start", `{"type":"message_start","message":{"id":"msg_1","model":"claude-x","usage":{"input_tokens":9,"output_tokens":1}}}`},
+		{"message_delta", `{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":4}}`},
+		{"message_stop", `{"type":"message_stop"}`},
+	}
+	var internal []string
+	for _, ev := range events {
+		outs, _, err := tr(ev.event, ev.data)
+		if err != nil {
+			t.Fatalf("transform %s: %v", ev.event, err)
+		}
+		for _, o := range outs {
+			if o.Internal {
+				internal = append(internal, o.Data)
+				continue
+			}
+			if strings.Contains(o.Data, `"usage"`) {
+				t.Fatalf("expected no usage in downstream chunk without include_usage, got=%s", o.Data)
+			}
+		}
+	}
+	if len(internal) != 1 || !strings.Contains(internal[0], `"completion_tokens":4`) {
+		t.Fatalf("expected one internal usage chunk for billing, got=%v", internal)
+	}
+}
+
+func TestAnthropicToChatStreamTransformer_FinishesOnDoneWithoutMessageStop(t *testing.T) {
+	tr := newAnthropicToChatCompletionsStreamTransformer(true)
+	if _, _, err := tr("message_start", `{"type":"message_start","message":{"id":"msg_1","model":"claude-x","usage":{"input_tokens":3}}}`); err != nil {
+		t.Fatalf("transform message_start: %v", err)
+	}
+	outs, handled, err := tr("", "[DONE]")
+	if err != nil || !handled {
+		t.Fatalf("expected [DONE] to be handled, handled=%v err=%v", handled, err)
+	}
+	if len(outs) != 3 || !strings.Contains(outs[0].Data, `"finish_reason":"stop"`) || outs[2].Data != "[DONE]" {
+		t.Fatalf("expected finish chunk, usage chunk and [DONE], got=%#v", outs)
+	}
+	if again, _, _ := tr("", "[DONE]"); len(again) != 0 {
+		t.Fatalf("expected finish to be emitted once, got=%#v", again)
+	}
+}
+
+func TestChatCompletions_AnthropicChannelTranslatesRequestAndResponse(t *testing.T) {
+	const groupName = "g1"
+	fs := &fakeStore{
+		channels: []store.UpstreamChannel{
+			{ID: 1, Type: store.UpstreamTypeAnthropic, Status: 1, Groups: groupName, Setting: store.UpstreamChannelSetting{ChatCompletionsEnabled: true}},
+		},
+		endpoints: map[int64][]store.UpstreamEndpoint{
+			1: {{ID: 11, ChannelID: 1, BaseURL: "https://a.example", Status: 1}},
+		},
+		anthropicCreds: map[int64][]store.AnthropicCredential{
+			11: {{ID: 1, EndpointID: 11, Status: 1}},
+		},
+		models: map[string]store.ManagedModel{
+			"m1": {ID: 1, PublicID: "m1", GroupName: groupName, Status: 1},
+		},
+		bindings: map[string][]store.ChannelModelBinding{
+			"m1": {{ID: 1, ChannelID: 1, ChannelType: store.UpstreamTypeAnthropic, PublicID: "m1", UpstreamModel: "claude-x", Status: 1}},
+		},
+	}
+
+	var gotPath string
+	var gotBody []byte
+	doer := DoerFunc(func(_ context.Context, _ scheduler.Selection, downstream *http.Request, body []byte) (*http.Response, error) {
+		gotPath = downstream.URL.Path
+		gotBody = body
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(bytes.NewReader([]byte(`{"id":"msg_1","type":"message","role":"assistant","model":"claude-x","content":[{"type":"text","text":"pong"}],"stop_reason":"end_turn","usage":{"input_tokens":6,"output_tokens":2}}`))),
+		}, nil
+	})
+
+	q := &fakeQuota{}
+	h := NewHandler(fs, fs, scheduler.New(fs), doer, nil, nil, q, fakeAudit{}, nil, nil, upstream.SSEPumpOptions{}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/v1/chat/completions", bytes.NewReader([]byte(`{"model":"m1","messages":[{"role":"system","content":"s"},{"role":"user","content":"ping"}]}`)))
+	req.Header.Set("Content-Type", "application/json")
+	tokenID := int64(123)
+	p := auth.Principal{ActorType: auth.ActorTypeToken, UserID: 10, Role: store.UserRoleUser, TokenID: &tokenID, Groups: []string{groupName}}
+	req = req.WithContext(auth.WithPrincipal(req.Context(), p))
+	rr := httptest.NewRecorder()
+	middleware.Chain(http.HandlerFunc(h.ChatCompletions), middleware.BodyCache(1<<20)).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d body=%s", rr.Code, rr.Body.String())
+	}
+	if gotPath != "/v1/messages" {
+		t.Fatalf("expected upstream path /v1/messages, got=%q", gotPath)
+	}
+	var forwarded map[string]any
+	if err := json.Unmarshal(gotBody, &forwarded); err != nil {
+		t.Fatalf("unmarshal forwarded body: %v", err)
+	}
+	if forwarded["model"] != "claude-x" {
+		t.Fatalf("expected upstream model rewrite, got=%#v", forwarded["model"])
+	}
+	if _, ok := forwarded["system"]; !ok {
+		t.Fatalf("expected system prompt to be lifted, body=%s", gotBody)
+	}
+	if v, ok := forwarded["max_tokens"].(float64); !ok || v <= 0 {
+		t.Fatalf("expected max_tokens default, got=%#v", forwarded["max_tokens"])
+	}
+
+	var resp struct {
+		Object  string `json:"object"`
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal response: %v body=%s", err, rr.Body.String())
+	}
+	if resp.Object != "chat.completion" || len(resp.Choices) != 1 || resp.Choices[0].Message.Content != "pong" {
+		t.Fatalf("unexpected translated response: %s", rr.Body.String())
+	}
+
+	if len(q.commitCalls) != 1 {
+		t.Fatalf("expected 1 commit call, got=%d", len(q.commitCalls))
+	}
+	commit := q.commitCalls[0]
+	if commit.InputTokens == nil || *commit.InputTokens != 6 || commit.OutputTokens == nil || *commit.OutputTokens != 2 {
+		t.Fatalf("unexpected committed usage: in=%v out=%v", commit.InputTokens, commit.OutputTokens)
+	}
+}
+
+func TestChatCompletions_OnlyChatCapableChannelsAreSelected(t *testing.T) {
+	const groupName = "g1"
+	chatOn := store.UpstreamChannelSetting{ChatCompletionsEnabled: true}
+	fs := &fakeStore{
+		channels: []store.UpstreamChannel{
+			{ID: 1, Type: store.UpstreamTypeOpenAICompatible, Status: 1, Groups: groupName},
+			// gemini 渠道与绑定 Gemini 模型的 vertex 渠道即使开启 chat_completions 也不应参与选路（组内按成员 ID 倒序会先尝试它们）。
+			{ID: 2, Type: store.UpstreamTypeVertex, Status: 1, Groups: groupName, Setting: chatOn},
+			{ID: 3, Type: store.UpstreamTypeGemini, Status: 1, Groups: groupName, Setting: chatOn},
+		},
+		endpoints: map[int64][]store.UpstreamEndpoint{
+			1: {{ID: 11, ChannelID: 1, BaseURL: "https://o.example", Status: 1}},
+			2: {{ID: 21, ChannelID: 2, BaseURL: "https://v.example", Status: 1}},
+			3: {{ID: 31, ChannelID: 3, BaseURL: "https://g.example", Status: 1}},
+		},
+		creds: map[int64][]store.OpenAICompatibleCredential{
+			11: {{ID: 1, EndpointID: 11, Status: 1}},
+		},
+		vertexCreds: map[int64][]store.VertexCredential{
+			21: {{ID: 2, EndpointID: 21, ProjectID: "p", Status: 1}},
+		},
+		geminiCreds: map[int64][]store.GeminiCredential{
+			31: {{ID: 3, EndpointID: 31, Status: 1}},
+		},
+		models: map[string]store.ManagedModel{
+			"m1": {ID: 1, PublicID: "m1", GroupName: groupName, Status: 1},
+		},
+		bindings: map[string][]store.ChannelModelBinding{
+			"m1": {
+				{ID: 1, ChannelID: 1, ChannelType: store.UpstreamTypeOpenAICompatible, PublicID: "m1", UpstreamModel: "gpt-x", Status: 1},
+				{ID: 2, ChannelID: 2, ChannelType: store.UpstreamTypeVertex, PublicID: "m1", UpstreamModel: "gemini-x", Status: 1},
+				{ID: 3, ChannelID: 3, ChannelType: store.UpstreamTypeGemini, PublicID: "m1", UpstreamModel: "gemini-x", Status: 1},
+			},
+		},
+	}
+	var attempts []int64
+	doer := DoerFunc(func(_ context.Context, sel scheduler.Selection, _ *http.Request, _ []byte) (*http.Response, error) {
+		attempts = append(attempts, sel.ChannelID)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(bytes.NewReader([]byte(`{"id":"c1","object":"chat.completion","choices":[],"usage":{"prompt_tokens":1,"completion_tokens":1}}`))),
+		}, nil
+	})
+	h := NewHandler(fs, fs, scheduler.New(fs), doer, nil, nil, &fakeQuota{}, fakeAudit{}, nil, nil, upstream.SSEPumpOptions{}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/v1/chat/completions", bytes.NewReader([]byte(`{"model":"m1","messages":[{"role":"user","content":"ping"}]}`)))
+	req.Header.Set("Content-Type", "application/json")
+	tokenID := int64(123)
+	p := auth.Principal{ActorType: auth.ActorTypeToken, UserID: 10, Role: store.UserRoleUser, TokenID: &tokenID, Groups: []string{groupName}}
+	req = req.WithContext(auth.WithPrincipal(req.Context(), p))
+	rr := httptest.NewRecorder()
+	middleware.Chain(http.HandlerFunc(h.ChatCompletions), middleware.BodyCache(1<<20)).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d body=%s", rr.Code, rr.Body.String())
+	}
+	if len(attempts) != 1 || attempts[0] != 1 {
+		t.Fatalf("expected only openai_compatible channel=1 to be attempted, got=%v", attempts)
+	}
+}
+
+func TestSpeaksAnthropicMessages_VertexDependsOnPublisher(t *testing.T) {
+	if !speaksAnthropicMessages(store.UpstreamTypeVertex, "claude-sonnet-4@20250514") {
+		t.Fatalf("expected vertex claude model to use Anthropic Messages")
+	}
+	if speaksAnthropicMessages(store.UpstreamTypeVertex, "gemini-2.5-pro") {
+		t.Fatalf("expected vertex gemini model not to use Anthropic Messages")
+	}
+	if !speaksAnthropicMessages(store.UpstreamTypeBedrock, "") || speaksAnthropicMessages(store.UpstreamTypeOpenAICompatible, "claude-x") {
+		t.Fatalf("unexpected channel type classification")
+	}
+}
@@ -1,7 +1,6 @@
 package openai
 
 import (
-	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
@@ -56,6 +55,10 @@ func (h *Handler) proxyChatCompletionsJSON(w http.ResponseWriter, r *http.Reques
 
 	stream := boolFromAny(payload["stream"])
 	wantStore := boolFromAny(payload["store"])
+	includeUsage := false
+	if opts, ok := payload["stream_options"].(map[string]any); ok {
+		includeUsage = boolFromAny(opts["include_usage"])
+	}
 	publicModel := strings.TrimSpace(stringFromAny(payload["model"]))
 	maxOut := intFromAny(payload["max_completion_tokens"])
 	if maxOut == nil {
@@ -80,7 +83,7 @@ func (h *Handler) proxyChatCompletionsJSON(w http.ResponseWriter, r *http.Reques
 	}
 
 	var cons scheduler.Constraints
-	cons.RequireChannelType = store.UpstreamTypeOpenAICompatible
+	// 渠道类型不做硬限制：openai_compatible 原生支持；anthropic 渠道在 setting 开启 chat_completions 后经协议转换参与选路。
 	cons.RequireAPI = scheduler.RequiredAPIChatCompletions
 	ags := allowGroupsFromPrincipal(p)
 	allowSet := ags.Set
@@ -139,12 +142,19 @@ func (h *Handler) proxyChatCompletionsJSON(w http.ResponseWriter, r *http.Reques
 		// passthrough 模式下仍尝试使用“渠道绑定模型”做 model 转发（best-effort）；
 		// 但不强制要求存在绑定（无绑定时直接透传 model）。
 		if bindings, err := h.models.ListEnabledChannelModelBindingsByPublicID(r.Context(), publicModel); err == nil {
-			resolvedBindings = resolveChannelModelBindings(bindings, cons.RequireChannelType)
+			resolvedBindings = resolveChannelModelBindings(chatCompletionsBindings(bindings), cons.RequireChannelType)
 			if !resolvedBindings.Empty() {
 				resolvedBindings.ApplyToConstraints(&cons)
 			}
 		}
 		rewriteBody = func(sel scheduler.Selection) ([]byte, error) {
+			up := resolvedBindings.UpstreamModel(sel.ChannelID, publicModel)
+			if speaksAnthropicMessages(sel.ChannelType, up) {
+				return rewriteChatCompletionsForAnthropic(payload, sel, publicModel, up, r.URL.Path)
+			}
+			if sel.ChannelType == store.UpstreamTypeVertex {
+				return nil, errChatCompletionsVertexModelUnsupported
+			}
 			if sel.PassThroughBodyEnabled {
 				return rawBody, nil
 			}
@@ -207,7 +217,7 @@ func (h *Handler) proxyChatCompletionsJSON(w http.ResponseWriter, r *http.Reques
 			return
 		}
 
-		resolvedBindings = resolveChannelModelBindings(bindings, cons.RequireChannelType)
+		resolvedBindings = resolveChannelModelBindings(chatCompletionsBindings(bindings), cons.RequireChannelType)
 		if resolvedBindings.Empty() {
 			http.Error(w, "模型未配置可用上游", http.StatusBadGateway)
 			return
@@ -215,13 +225,16 @@ func (h *Handler) proxyChatCompletionsJSON(w http.ResponseWriter, r *http.Reques
 		resolvedBindings.ApplyToConstraints(&cons)
 
 		rewriteBody = func(sel scheduler.Selection) ([]byte, error) {
-			if sel.PassThroughBodyEnabled {
-				return rawBody, nil
-			}
 			up := resolvedBindings.UpstreamModel(sel.ChannelID, "")
 			if strings.TrimSpace(up) == "" {
 				return nil, errors.New("选中渠道未配置该模型")
 			}
+			if speaksAnthropicMessages(sel.ChannelType, up) {
+				return rewriteChatCompletionsForAnthropic(payload, sel, publicModel, up, r.URL.Path)
+			}
+			if sel.PassThroughBodyEnabled {
+				return rawBody, nil
+			}
 			out := clonePayload(payload)
 			out["model"] = up
 			applyChannelSystemPromptToChatCompletionsPayload(out, sel)
@@ -269,166 +282,90 @@ func (h *Handler) proxyChatCompletionsJSON(w http.ResponseWriter, r *http.Reques
 	}
 	routeKeyHash := h.sched.RouteKeyHash(routeKey)
 
-	usageID := int64(0)
-	userRelease, userSlotErr := h.acquireUserSlot(r.Context(), p.UserID)
-	if userSlotErr != nil {
-		fail := classifyConcurrencyAcquireFailure(userSlotErr)
-		resp := h.buildFailoverExhaustedResponse("openai", fail)
-		cw := &countingResponseWriter{ResponseWriter: w}
-		writeOpenAIErrorWithRetryAfter(cw, resp.Status, resp.ErrType, resp.Message, resp.RetryAfterSeconds)
-		if !resp.SkipMonitoring {
-			h.maybeLogProxyFailure(r.Context(), r, p, nil, optionalString(publicModel), resp.Status, resp.ErrorClass, resp.Message, time.Since(reqStart), stream)
-		}
-		return
-	}
-	if userRelease != nil {
-		defer userRelease()
-	}
-	if h.quota != nil {
-		res, err := h.quota.Reserve(r.Context(), quota.ReserveInput{
-			RequestID:       middleware.GetRequestID(r.Context()),
-			UserID:          p.UserID,
-			TokenID:         *p.TokenID,
-			Model:           optionalString(publicModel),
+	h.proxyWithFailover(w, r, failoverProxy{
+		reqStart:     reqStart,
+		principal:    p,
+		publicModel:  publicModel,
+		stream:       stream,
+		reqBytes:     int64(len(body)),
+		cons:         cons,
+		routeKeyHash: routeKeyHash,
+		reserve: quota.ReserveInput{
 			ServiceTier:     serviceTier,
 			MaxOutputTokens: maxOut,
-		})
-		if err != nil {
-			if msg := reserveBadRequestMessage(err); msg != "" {
-				http.Error(w, msg, http.StatusBadRequest)
-				return
-			}
-			if errors.Is(err, quota.ErrSubscriptionRequired) || errors.Is(err, quota.ErrQuotaExceeded) {
-				http.Error(w, err.Error(), http.StatusTooManyRequests)
-				return
-			}
-			if errors.Is(err, quota.ErrInsufficientBalance) {
-				http.Error(w, err.Error(), http.StatusPaymentRequired)
-				return
-			}
-			http.Error(w, "配额预留失败", http.StatusTooManyRequests)
-			return
-		}
-		usageID = res.UsageEventID
-	}
-	reqBytes := int64(len(body))
+		},
+		bindings:    resolvedBindings,
+		rewriteBody: rewriteBody,
+		attemptRequest: func(r *http.Request, sel scheduler.Selection) *http.Request {
+			if speaksAnthropicMessages(sel.ChannelType, resolvedBindings.UpstreamModel(sel.ChannelID, publicModel)) {
+				return r.WithContext(withUpstreamProtocolAdapter(r.Context(), newChatToAnthropicAdapter(includeUsage)))
+			}
+			return r
+		},
+	})
+}
 
-	if h.groups == nil {
-		if usageID != 0 && h.quota != nil {
-			bookCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			_ = h.quota.Void(bookCtx, usageID)
-			cancel()
-		}
-		resp := h.buildFailoverExhaustedResponse("openai", proxyFailureInfo{})
-		if !resp.SkipMonitoring {
-			h.auditUpstreamError(r.Context(), r.URL.Path, p, nil, optionalString(publicModel), resp.Status, resp.ErrorClass, 0)
-		}
-		cw := &countingResponseWriter{ResponseWriter: w}
-		writeOpenAIErrorWithRetryAfter(cw, resp.Status, resp.ErrType, resp.Message, resp.RetryAfterSeconds)
-		if !resp.SkipMonitoring {
-			h.maybeLogProxyFailure(r.Context(), r, p, nil, optionalString(publicModel), resp.Status, resp.ErrorClass, resp.Message, time.Since(reqStart), stream)
-		}
-		finalClass := resp.ErrorClass
-		if resp.SkipMonitoring {
-			finalClass = ""
-		}
-		h.finalizeUsageEvent(r, usageID, nil, resp.Status, finalClass, resp.UsageMessage, time.Since(reqStart), 0, stream, reqBytes, cw.bytes)
-		return
+// errChatCompletionsVertexModelUnsupported 表示 vertex 渠道的目标模型不是 Anthropic（Claude）模型，无法经 rawPredict 承接 chat 请求。
+var errChatCompletionsVertexModelUnsupported = errors.New("vertex 渠道仅支持以 Claude 模型承接 chat/completions")
+
+// speaksAnthropicMessages 判断渠道上游对 upstreamModel 是否使用 Anthropic Messages 协议：
+// anthropic 原生与 Bedrock InvokeModel 始终如此；Vertex 按 publisher 区分，仅 Claude 模型走 anthropic rawPredict。
+func speaksAnthropicMessages(channelType string, upstreamModel string) bool {
+	switch channelType {
+	case store.UpstreamTypeAnthropic, store.UpstreamTypeBedrock:
+		return true
+	case store.UpstreamTypeVertex:
+		return vertexAnthropicModel(upstreamModel)
+	default:
+		return false
 	}
+}
 
-	router := scheduler.NewGroupRouter(h.groups, h.sched, p.UserID, routeKeyHash, cons)
-	bestFailure := proxyFailureInfo{}
-	loopStart := time.Now()
-	switches := 0
-	backoff := h.initialBackoff()
-	for {
-		if h.failoverExhausted(loopStart, switches) {
-			break
-		}
-		sel, err := router.Next(r.Context())
-		if err != nil {
-			if h.finalizeIfCanceled(r, usageID, nil, reqStart, stream, reqBytes) {
-				return
-			}
-			if msg := serviceTierSelectionBadRequestMessage(err); msg != "" && !isFastModeSelectionError(err) {
-				if usageID != 0 && h.quota != nil {
-					bookCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-					_ = h.quota.Void(bookCtx, usageID)
-					cancel()
-				}
-				http.Error(w, msg, http.StatusBadRequest)
-				h.finalizeUsageEvent(r, usageID, nil, http.StatusBadRequest, "service_tier", msg, time.Since(reqStart), 0, stream, reqBytes, 0)
-				return
-			}
-			break
-		}
-		rewritten, err := rewriteBody(sel)
-		if err != nil {
-			if isFastModeSelectionError(err) {
-				router.ExcludeChannel(sel.ChannelID)
-				switches++
-				if h.failoverExhausted(loopStart, switches) {
-					break
-				}
-				if !h.waitBackoffWithinRetryElapsed(r.Context(), loopStart, backoff) {
-					if h.finalizeIfCanceled(r, usageID, nil, reqStart, stream, reqBytes) {
-						return
-					}
-					break
-				}
-				backoff = h.nextBackoff(backoff)
-				continue
-			}
-			if usageID != 0 && h.quota != nil {
-				bookCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-				_ = h.quota.Void(bookCtx, usageID)
-				cancel()
-			}
-			if msg := serviceTierSelectionBadRequestMessage(err); msg != "" && !isFastModeSelectionError(err) {
-				cw := &countingResponseWriter{ResponseWriter: w}
-				http.Error(cw, msg, http.StatusBadRequest)
-				h.finalizeUsageEvent(r, usageID, &sel, http.StatusBadRequest, "service_tier", msg, time.Since(reqStart), 0, stream, reqBytes, cw.bytes)
-				return
-			}
-			cw := &countingResponseWriter{ResponseWriter: w}
-			http.Error(cw, "请求体处理失败", http.StatusInternalServerError)
-			h.finalizeUsageEvent(r, usageID, &sel, http.StatusInternalServerError, "rewrite_body", "请求体处理失败", time.Since(reqStart), 0, stream, reqBytes, cw.bytes)
-			return
-		}
-		bindingID := resolvedBindings.BindingID(sel.ChannelID)
-		if h.tryWithSelection(w, r, p, sel, rewritten, stream, optionalString(publicModel), extractTopLevelModel(rewritten), bindingID, usageID, reqStart, reqBytes, loopStart, 1, &bestFailure) {
-			return
-		}
-		switches++
-		if h.failoverExhausted(loopStart, switches) {
-			break
-		}
-		if !h.waitBackoffWithinRetryElapsed(r.Context(), loopStart, backoff) {
-			if h.finalizeIfCanceled(r, usageID, nil, reqStart, stream, reqBytes) {
-				return
-			}
-			break
+// messagesTranslatedToOpenAI 判断 /v1/messages 选中的渠道是否经 OpenAI 协议转换承接；
+// 与调度侧 messages 能力判定共用 scheduler.MessagesViaOpenAITranslation，避免两侧不一致。
+func messagesTranslatedToOpenAI(sel scheduler.Selection) bool {
+	return scheduler.MessagesViaOpenAITranslation(sel.ChannelType)
+}
+
+// vertexAnthropicModel 判断 Vertex 模型是否由 anthropic publisher 提供（Vertex 上的 Claude 模型 ID 均以 claude 开头）。
+func vertexAnthropicModel(model string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(model)), "claude")
+}
+
+// chatCompletionsBindings 过滤掉无法承接 chat 的绑定：vertex 渠道绑定到 Gemini 等 Google 模型时不参与选路。
+func chatCompletionsBindings(bindings []store.ChannelModelBinding) []store.ChannelModelBinding {
+	out := bindings[:0:0]
+	for _, b := range bindings {
+		if strings.TrimSpace(b.ChannelType) == store.UpstreamTypeVertex && !vertexAnthropicModel(b.UpstreamModel) {
+			continue
 		}
-		backoff = h.nextBackoff(backoff)
+		out = append(out, b)
 	}
+	return out
+}
 
-	if usageID != 0 && h.quota != nil {
-		bookCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		_ = h.quota.Void(bookCtx, usageID)
-		cancel()
+// rewriteChatCompletionsForAnthropic 在选中 anthropic / bedrock 渠道时把 chat 请求转换为 Messages 请求体；
+// 协议不同，不支持 pass_through_body（始终按转换结果转发）。
+func rewriteChatCompletionsForAnthropic(payload map[string]any, sel scheduler.Selection, publicModel string, upstreamModel string, path string) ([]byte, error) {
+	out := clonePayload(payload)
+	out["model"] = upstreamModel
+	applyChannelSystemPromptToChatCompletionsPayload(out, sel)
+	converted, err := chatCompletionsPayloadToAnthropic(out)
+	if err != nil {
+		return nil, err
 	}
-	resp := h.buildFailoverExhaustedResponse("openai", bestFailure)
-	if !resp.SkipMonitoring {
-		h.auditUpstreamError(r.Context(), r.URL.Path, p, nil, optionalString(publicModel), resp.Status, resp.ErrorClass, 0)
+	raw, err := json.Marshal(converted)
+	if err != nil {
+		return nil, err
 	}
-	cw := &countingResponseWriter{ResponseWriter: w}
-	writeOpenAIErrorWithRetryAfter(cw, resp.Status, resp.ErrType, resp.Message, resp.RetryAfterSeconds)
-	if !resp.SkipMonitoring {
-		h.maybeLogProxyFailure(r.Context(), r, p, nil, optionalString(publicModel), resp.Status, resp.ErrorClass, resp.Message, time.Since(reqStart), stream)
+	raw, err = applyChannelRequestPolicy(raw, sel)
+	if err != nil {
+		return nil, err
 	}
-	finalClass := resp.ErrorClass
-	if resp.SkipMonitoring {
-		finalClass = ""
+	raw, err = applyChannelBodyFilters(raw, sel)
+	if err != nil {
+		return nil, err
 	}
-	h.finalizeUsageEvent(r, usageID, nil, resp.Status, finalClass, resp.UsageMessage, time.Since(reqStart), 0, stream, reqBytes, cw.bytes)
+	ctx := buildParamOverrideContext(sel, publicModel, upstreamModel, path)
+	return applyChannelParamOverride(raw, sel, ctx)
 }
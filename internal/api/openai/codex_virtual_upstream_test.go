@@ -34,6 +34,22 @@ func (s *codexQuotaCaptureStore) GetAnthropicCredentialSecret(_ context.Context,
 	return store.AnthropicCredentialSecret{}, nil
 }
 
+func (s *codexQuotaCaptureStore) GetGeminiCredentialSecret(_ context.Context, _ int64) (store.GeminiCredentialSecret, error) {
+	return store.GeminiCredentialSecret{}, nil
+}
+
+func (s *codexQuotaCaptureStore) GetAzureOpenAICredentialSecret(_ context.Context, _ int64) (store.AzureOpenAICredentialSecret, error) {
+	return store.AzureOpenAICredentialSecret{}, nil
+}
+
+func (s *codexQuotaCaptureStore) GetBedrockCredentialSecret(_ context.Context, _ int64) (store.BedrockCredentialSecret, error) {
+	return store.BedrockCredentialSecret{}, nil
+}
+
+func (s *codexQuotaCaptureStore) GetVertexCredentialSecret(_ context.Context, _ int64) (store.VertexCredentialSecret, error) {
+	return store.VertexCredentialSecret{}, nil
+}
+
 func (s *codexQuotaCaptureStore) GetCodexOAuthSecret(_ context.Context, accountID int64) (store.CodexOAuthSecret, error) {
 	sec := s.secret
 	sec.ID = accountID
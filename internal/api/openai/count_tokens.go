@@ -0,0 +1,353 @@
+package openai
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"realms/internal/auth"
+	"realms/internal/middleware"
+	"realms/internal/scheduler"
+	"realms/internal/store"
+)
+
+const (
+	// countTokensSourceHeader 标记计数结果来源：upstream（上游计数接口）或 estimate（本地估算）。
+	countTokensSourceHeader = "X-Realms-Token-Count-Source"
+
+	countTokensMaxResponseBytes = 1 << 20
+
+	// 本地估算时图片/文档块按固定 token 数计入（与上游公开的典型值同量级）。
+	estimateAnthropicImageTokens = 1600
+	estimateGeminiMediaTokens    = 258
+	estimatePerMessageTokens     = 3
+)
+
+// anthropicCountTokensFields 为 /v1/messages/count_tokens 上游接受的字段；其余（max_tokens/stream 等）会被上游拒绝。
+var anthropicCountTokensFields = []string{"model", "messages", "system", "tools", "tool_choice", "thinking", "mcp_servers"}
+
+// MessagesCountTokens 提供 Anthropic 计数入口：POST /v1/messages/count_tokens。
+// 计数请求不预留配额、不产生用量记录；仍按 Token 渠道组校验模型权限。无可用上游时返回本地估算。
+func (h *Handler) MessagesCountTokens(w http.ResponseWriter, r *http.Request) {
+	p, ok := auth.PrincipalFromContext(r.Context())
+	if !ok || p.ActorType != auth.ActorTypeToken || p.TokenID == nil {
+		writeAnthropicError(w, http.StatusUnauthorized, "未鉴权")
+		return
+	}
+	body := middleware.CachedBody(r.Context())
+	if len(body) == 0 {
+		writeAnthropicError(w, http.StatusBadRequest, "请求体为空")
+		return
+	}
+	payload, err := unmarshalRequestPayload(body)
+	if err != nil {
+		writeAnthropicError(w, http.StatusBadRequest, "请求体不是有效 JSON")
+		return
+	}
+	publicModel := strings.TrimSpace(stringFromAny(payload["model"]))
+	if publicModel == "" {
+		writeAnthropicError(w, http.StatusBadRequest, "model 不能为空")
+		return
+	}
+	if _, ok := payload["messages"]; !ok || payload["messages"] == nil {
+		writeAnthropicError(w, http.StatusBadRequest, "messages 不能为空")
+		return
+	}
+
+	var cons scheduler.Constraints
+	// 计数接口仅 anthropic 原生渠道提供；bedrock/vertex/openai_compatible 无对应能力，直接走本地估算。
+	cons.RequireChannelType = store.UpstreamTypeAnthropic
+	cons.RequireAPI = scheduler.RequiredAPIMessages
+	resolvedBindings, status, msg := h.resolveCountTokensRoute(r, p, publicModel, &cons)
+	if status != 0 {
+		writeAnthropicError(w, status, msg)
+		return
+	}
+
+	counted := false
+	if !resolvedBindings.Empty() {
+		routeKey := extractRouteKeyFromPayload(payload)
+		if routeKey == "" {
+			routeKey = extractRouteKey(r)
+		}
+		counted = h.countTokensViaUpstream(w, r, p, cons, routeKey, func(sel scheduler.Selection) (*http.Request, []byte, error) {
+			up := resolvedBindings.UpstreamModel(sel.ChannelID, publicModel)
+			if strings.TrimSpace(up) == "" {
+				return nil, nil, errors.New("选中渠道未配置该模型")
+			}
+			out := make(map[string]any, len(anthropicCountTokensFields))
+			for _, k := range anthropicCountTokensFields {
+				if v, ok := payload[k]; ok {
+					out[k] = v
+				}
+			}
+			out["model"] = up
+			applyChannelSystemPromptToMessagesPayload(out, sel)
+			raw, err := json.Marshal(out)
+			if err != nil {
+				return nil, nil, err
+			}
+			return r.Clone(r.Context()), raw, nil
+		})
+	}
+	if counted {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set(countTokensSourceHeader, "estimate")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(map[string]any{"input_tokens": estimatePayloadTokens(payload)})
+}
+
+// geminiCountTokens 处理 Gemini :countTokens：与 MessagesCountTokens 相同，不预留配额、不记用量，
+// 上游均不可用时返回本地估算的 totalTokens。
+func (h *Handler) geminiCountTokens(w http.ResponseWriter, r *http.Request, p auth.Principal, cons scheduler.Constraints, resolvedBindings resolvedChannelModelBindings, pathTail string, publicModel string, body []byte) {
+	if !resolvedBindings.Empty() {
+		routeKey := extractRouteKeyFromRawBody(body)
+		if routeKey == "" {
+			routeKey = extractRouteKey(r)
+		}
+		if h.countTokensViaUpstream(w, r, p, cons, routeKey, func(sel scheduler.Selection) (*http.Request, []byte, error) {
+			upstreamModel := resolvedBindings.UpstreamModel(sel.ChannelID, publicModel)
+			req2 := r.Clone(r.Context())
+			req2.URL.Path = "/v1beta/models/" + upstreamModel + strings.TrimPrefix(pathTail, publicModel)
+			raw, err := applyChannelBodyFilters(body, sel)
+			if err != nil {
+				return nil, nil, err
+			}
+			return req2, raw, nil
+		}) {
+			return
+		}
+	}
+
+	var payload map[string]any
+	_ = json.Unmarshal(body, &payload)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set(countTokensSourceHeader, "estimate")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(map[string]any{"totalTokens": estimatePayloadTokens(payload)})
+}
+
+// resolveCountTokensRoute 按 Token 渠道组校验模型权限并把渠道绑定写入约束。
+// 返回非 0 status 表示应直接拒绝；绑定为空表示无可承接计数的上游（由调用方回退本地估算）。
+func (h *Handler) resolveCountTokensRoute(r *http.Request, p auth.Principal, publicModel string, cons *scheduler.Constraints) (resolvedChannelModelBindings, int, string) {
+	ags := allowGroupsFromPrincipal(p)
+	if len(ags.Order) == 0 {
+		return resolvedChannelModelBindings{}, http.StatusBadRequest, "Token 未配置渠道组"
+	}
+	cons.AllowGroups = ags.Set
+	cons.AllowGroupOrder = ags.Order
+	cons.SequentialChannelFailover = true
+
+	if h.models == nil {
+		return resolvedChannelModelBindings{}, http.StatusBadGateway, "服务未配置模型目录"
+	}
+	modelPassthrough := false
+	if h.features != nil {
+		modelPassthrough = h.features.FeatureStateEffective(r.Context()).ModelsDisabled
+	}
+
+	var mm store.ManagedModel
+	var err error
+	if modelPassthrough {
+		mm, err = h.models.GetManagedModelByPublicID(r.Context(), publicModel)
+	} else {
+		mm, err = h.models.GetEnabledManagedModelByPublicID(r.Context(), publicModel)
+	}
+	if err != nil {
+		switch {
+		case !errors.Is(err, sql.ErrNoRows):
+			return resolvedChannelModelBindings{}, http.StatusBadGateway, "查询模型失败"
+		case !modelPassthrough:
+			return resolvedChannelModelBindings{}, http.StatusBadRequest, "模型未启用"
+		}
+	} else if ags.Set != nil {
+		if _, ok := ags.Set[managedModelGroupName(mm)]; !ok {
+			return resolvedChannelModelBindings{}, http.StatusBadRequest, "无权限使用该模型"
+		}
+	}
+
+	bindings, err := h.models.ListEnabledChannelModelBindingsByPublicID(r.Context(), publicModel)
+	if err != nil {
+		if modelPassthrough {
+			return resolvedChannelModelBindings{}, 0, ""
+		}
+		return resolvedChannelModelBindings{}, http.StatusBadGateway, "查询模型绑定失败"
+	}
+	resolved := resolveChannelModelBindings(bindings, cons.RequireChannelType)
+	if !resolved.Empty() {
+		resolved.ApplyToConstraints(cons)
+	}
+	return resolved, 0, ""
+}
+
+// countTokensViaUpstream 依次尝试可承接计数的上游：2xx 或请求本身不合法（400/413/422）时原样回写并返回 true；
+// 上游不支持（404/405/501）、网络错误、鉴权/限流/5xx 时继续下一个候选，全部失败返回 false 由调用方回退本地估算。
+func (h *Handler) countTokensViaUpstream(w http.ResponseWriter, r *http.Request, p auth.Principal, cons scheduler.Constraints, routeKey string, build func(sel scheduler.Selection) (*http.Request, []byte, error)) bool {
+	if h.groups == nil || h.sched == nil || h.exec == nil {
+		return false
+	}
+	router := scheduler.NewGroupRouter(h.groups, h.sched, p.UserID, h.sched.RouteKeyHash(routeKey), cons)
+	loopStart := time.Now()
+	switches := 0
+	tried := make(map[string]struct{})
+	for !h.failoverExhausted(loopStart, switches) {
+		sel, err := router.Next(r.Context())
+		if err != nil {
+			return false
+		}
+		// 计数失败不一定触发封禁，同一 credential 再次被选中时整体跳过该渠道，避免原地打转。
+		if _, ok := tried[sel.CredentialKey()]; ok {
+			router.ExcludeChannel(sel.ChannelID)
+			continue
+		}
+		tried[sel.CredentialKey()] = struct{}{}
+		switches++
+		req2, body, err := build(sel)
+		if err != nil {
+			continue
+		}
+
+		attemptStart := time.Now()
+		resp, err := h.exec.Do(r.Context(), sel, req2, body)
+		if err != nil {
+			if r.Context().Err() != nil {
+				return false
+			}
+			h.sched.Report(sel, scheduler.Result{
+				Success:    false,
+				Retriable:  true,
+				ErrorClass: "network",
+				Scope:      scheduler.FailureScopeEndpoint,
+			})
+			h.auditUpstreamError(r.Context(), r.URL.Path, p, &sel, nil, 0, "network", time.Since(attemptStart))
+			continue
+		}
+		respBody, readErr := io.ReadAll(io.LimitReader(resp.Body, countTokensMaxResponseBytes))
+		_ = resp.Body.Close()
+		if readErr != nil {
+			continue
+		}
+
+		switch {
+		case resp.StatusCode >= 200 && resp.StatusCode < 300:
+			h.sched.Report(sel, scheduler.Result{Success: true})
+		case resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusRequestEntityTooLarge || resp.StatusCode == http.StatusUnprocessableEntity:
+			// 请求内容本身不合法：换上游也不会成功，直接回写给客户端。
+		case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented:
+			// 上游未提供计数接口属于能力缺失，不计入渠道健康度。
+			router.ExcludeChannel(sel.ChannelID)
+			continue
+		default:
+			h.sched.Report(sel, fixedSelectionStatusResult(resp.StatusCode))
+			h.auditUpstreamError(r.Context(), r.URL.Path, p, &sel, nil, resp.StatusCode, "upstream_status", time.Since(attemptStart))
+			continue
+		}
+
+		copyResponseHeaders(w.Header(), resp.Header)
+		w.Header().Del("Content-Length")
+		w.Header().Set(countTokensSourceHeader, "upstream")
+		w.WriteHeader(resp.StatusCode)
+		_, _ = w.Write(respBody)
+		return true
+	}
+	return false
+}
+
+// estimatePayloadTokens 在无上游计数能力时按请求内容做本地估算（Anthropic messages 与 Gemini contents 通用）。
+// 只统计文本语义：结构字段（type/role/model 等）不计入，tools 按 JSON 序列化后的文本计入。
+func estimatePayloadTokens(payload map[string]any) int64 {
+	var n int64
+	for k, v := range payload {
+		switch k {
+		case "model", "stream", "max_tokens", "generationConfig", "generation_config", "safetySettings", "safety_settings", "tool_choice", "toolConfig", "tool_config", "thinking":
+			continue
+		case "tools", "functionDeclarations", "function_declarations", "mcp_servers":
+			raw, _ := json.Marshal(v)
+			n += estimateTextTokens(string(raw))
+		case "messages", "contents":
+			if items, ok := v.([]any); ok {
+				n += int64(len(items)) * estimatePerMessageTokens
+			}
+			n += estimateValueTokens(v)
+		case "generateContentRequest", "generate_content_request":
+			if m, ok := v.(map[string]any); ok {
+				n += estimatePayloadTokens(m)
+			}
+		default:
+			n += estimateValueTokens(v)
+		}
+	}
+	return n
+}
+
+func estimateValueTokens(v any) int64 {
+	switch t := v.(type) {
+	case string:
+		return estimateTextTokens(t)
+	case []any:
+		var n int64
+		for _, item := range t {
+			n += estimateValueTokens(item)
+		}
+		return n
+	case map[string]any:
+		switch strings.TrimSpace(stringFromAny(t["type"])) {
+		case "image", "document":
+			return estimateAnthropicImageTokens
+		}
+		var n int64
+		for k, item := range t {
+			switch k {
+			case "type", "role", "id", "tool_use_id", "media_type", "mimeType", "mime_type", "cache_control", "signature":
+				continue
+			case "inlineData", "inline_data", "fileData", "file_data":
+				n += estimateGeminiMediaTokens
+				continue
+			case "input", "args", "functionCall", "function_call", "functionResponse", "function_response":
+				raw, _ := json.Marshal(item)
+				n += estimateTextTokens(string(raw))
+				continue
+			}
+			n += estimateValueTokens(item)
+		}
+		return n
+	default:
+		return 0
+	}
+}
+
+// estimateTextTokens 以启发式近似 BPE 分词：CJK 字符按 1 token，字母数字按每 4 字符 1 token，标点符号各 1 token。
+func estimateTextTokens(s string) int64 {
+	var tokens int64
+	wordLen := 0
+	flush := func() {
+		if wordLen > 0 {
+			tokens += int64((wordLen + 3) / 4)
+			wordLen = 0
+		}
+	}
+	for _, r := range s {
+		switch {
+		case unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r):
+			flush()
+			tokens++
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			wordLen++
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			flush()
+			tokens++
+		}
+	}
+	flush()
+	return tokens
+}
@@ -0,0 +1,244 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"realms/internal/auth"
+	"realms/internal/scheduler"
+	"realms/internal/store"
+	"realms/internal/upstream"
+)
+
+func TestMessagesCountTokens_ProxiesToAnthropicWithoutReserving(t *testing.T) {
+	fs := &fakeStore{
+		channels: []store.UpstreamChannel{
+			{ID: 1, Type: store.UpstreamTypeAnthropic, Status: 1, Groups: "g1", Priority: 10},
+			{ID: 2, Type: store.UpstreamTypeGemini, Status: 1, Groups: "g1"},
+		},
+		endpoints: map[int64][]store.UpstreamEndpoint{
+			1: {{ID: 11, ChannelID: 1, BaseURL: "https://api.anthropic.com", Status: 1}},
+			2: {{ID: 21, ChannelID: 2, BaseURL: "https://generativelanguage.googleapis.com", Status: 1}},
+		},
+		anthropicCreds: map[int64][]store.AnthropicCredential{
+			11: {{ID: 1, EndpointID: 11, Status: 1}},
+		},
+		geminiCreds: map[int64][]store.GeminiCredential{
+			21: {{ID: 2, EndpointID: 21, Status: 1}},
+		},
+		models: map[string]store.ManagedModel{
+			"gemini-x": {ID: 1, PublicID: "gemini-x", GroupName: "g1", Status: 1},
+		},
+		bindings: map[string][]store.ChannelModelBinding{
+			"gemini-x": {
+				{ID: 1, ChannelID: 1, ChannelType: store.UpstreamTypeAnthropic, PublicID: "gemini-x", UpstreamModel: "claude-a", Status: 1},
+				{ID: 2, ChannelID: 2, ChannelType: store.UpstreamTypeGemini, PublicID: "gemini-x", UpstreamModel: "gemini-2.5-flash", Status: 1},
+			},
+		},
+	}
+
+	var gotPath string
+	var gotBody map[string]any
+	doer := DoerFunc(func(_ context.Context, sel scheduler.Selection, downstream *http.Request, body []byte) (*http.Response, error) {
+		if sel.CredentialType != scheduler.CredentialTypeAnthropic {
+			t.Fatalf("expected anthropic selection, got=%+v", sel)
+		}
+		gotPath = downstream.URL.Path
+		_ = json.Unmarshal(body, &gotBody)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"input_tokens":42}`)),
+		}, nil
+	})
+	q := &fakeQuota{}
+	h := NewHandler(fs, fs, scheduler.New(fs), doer, nil, nil, q, fakeAudit{}, nil, nil, upstream.SSEPumpOptions{}, nil)
+
+	rr := runHandler(h.MessagesCountTokens, makeTokenRequest(http.MethodPost, "/v1/messages/count_tokens", `{"model":"gemini-x","max_tokens":100,"messages":[{"role":"user","content":"hi"}]}`, 10))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d body=%s", rr.Code, rr.Body.String())
+	}
+	if strings.TrimSpace(rr.Body.String()) != `{"input_tokens":42}` {
+		t.Fatalf("unexpected body: %s", rr.Body.String())
+	}
+	if got := rr.Header().Get(countTokensSourceHeader); got != "upstream" {
+		t.Fatalf("count source=%q, want upstream", got)
+	}
+	if gotPath != "/v1/messages/count_tokens" {
+		t.Fatalf("unexpected upstream path: %q", gotPath)
+	}
+	if gotBody["model"] != "claude-a" {
+		t.Fatalf("expected upstream model rewrite, got=%v", gotBody["model"])
+	}
+	if _, ok := gotBody["max_tokens"]; ok {
+		t.Fatalf("expected max_tokens stripped, got=%v", gotBody)
+	}
+	if len(q.reserveCalls) != 0 || len(q.commitCalls) != 0 {
+		t.Fatalf("expected no quota calls, reserve=%d commit=%d", len(q.reserveCalls), len(q.commitCalls))
+	}
+}
+
+func TestMessagesCountTokens_FallsBackToEstimateWhenUpstreamUnsupported(t *testing.T) {
+	fs := &fakeStore{
+		channels: []store.UpstreamChannel{
+			{ID: 1, Type: store.UpstreamTypeAnthropic, Status: 1, Groups: "g1", Priority: 10},
+			{ID: 2, Type: store.UpstreamTypeGemini, Status: 1, Groups: "g1"},
+		},
+		endpoints: map[int64][]store.UpstreamEndpoint{
+			1: {{ID: 11, ChannelID: 1, BaseURL: "https://api.anthropic.com", Status: 1}},
+			2: {{ID: 21, ChannelID: 2, BaseURL: "https://generativelanguage.googleapis.com", Status: 1}},
+		},
+		anthropicCreds: map[int64][]store.AnthropicCredential{
+			11: {{ID: 1, EndpointID: 11, Status: 1}},
+		},
+		geminiCreds: map[int64][]store.GeminiCredential{
+			21: {{ID: 2, EndpointID: 21, Status: 1}},
+		},
+		models: map[string]store.ManagedModel{
+			"gemini-x": {ID: 1, PublicID: "gemini-x", GroupName: "g1", Status: 1},
+		},
+		bindings: map[string][]store.ChannelModelBinding{
+			"gemini-x": {
+				{ID: 1, ChannelID: 1, ChannelType: store.UpstreamTypeAnthropic, PublicID: "gemini-x", UpstreamModel: "claude-a", Status: 1},
+				{ID: 2, ChannelID: 2, ChannelType: store.UpstreamTypeGemini, PublicID: "gemini-x", UpstreamModel: "gemini-2.5-flash", Status: 1},
+			},
+		},
+	}
+	doer := DoerFunc(func(_ context.Context, _ scheduler.Selection, _ *http.Request, _ []byte) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusNotFound,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"type":"error"}`)),
+		}, nil
+	})
+	h := NewHandler(fs, fs, scheduler.New(fs), doer, nil, nil, &fakeQuota{}, fakeAudit{}, nil, nil, upstream.SSEPumpOptions{}, nil)
+
+	rr := runHandler(h.MessagesCountTokens, makeTokenRequest(http.MethodPost, "/v1/messages/count_tokens", `{"model":"gemini-x","messages":[{"role":"user","content":"hello world"}]}`, 10))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d body=%s", rr.Code, rr.Body.String())
+	}
+	if got := rr.Header().Get(countTokensSourceHeader); got != "estimate" {
+		t.Fatalf("count source=%q, want estimate", got)
+	}
+	var out struct {
+		InputTokens int64 `json:"input_tokens"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &out); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+	if out.InputTokens <= 0 {
+		t.Fatalf("expected positive estimate, got=%d", out.InputTokens)
+	}
+}
+
+func TestMessagesCountTokens_RespectsTokenGroups(t *testing.T) {
+	fs := &fakeStore{
+		channels: []store.UpstreamChannel{
+			{ID: 1, Type: store.UpstreamTypeAnthropic, Status: 1, Groups: "g1", Priority: 10},
+			{ID: 2, Type: store.UpstreamTypeGemini, Status: 1, Groups: "g1"},
+		},
+		endpoints: map[int64][]store.UpstreamEndpoint{
+			1: {{ID: 11, ChannelID: 1, BaseURL: "https://api.anthropic.com", Status: 1}},
+			2: {{ID: 21, ChannelID: 2, BaseURL: "https://generativelanguage.googleapis.com", Status: 1}},
+		},
+		anthropicCreds: map[int64][]store.AnthropicCredential{
+			11: {{ID: 1, EndpointID: 11, Status: 1}},
+		},
+		geminiCreds: map[int64][]store.GeminiCredential{
+			21: {{ID: 2, EndpointID: 21, Status: 1}},
+		},
+		models: map[string]store.ManagedModel{
+			"gemini-x": {ID: 1, PublicID: "gemini-x", GroupName: "g1", Status: 1},
+		},
+		bindings: map[string][]store.ChannelModelBinding{
+			"gemini-x": {
+				{ID: 1, ChannelID: 1, ChannelType: store.UpstreamTypeAnthropic, PublicID: "gemini-x", UpstreamModel: "claude-a", Status: 1},
+				{ID: 2, ChannelID: 2, ChannelType: store.UpstreamTypeGemini, PublicID: "gemini-x", UpstreamModel: "gemini-2.5-flash", Status: 1},
+			},
+		},
+	}
+	h := NewHandler(fs, fs, scheduler.New(fs), &okDoer{}, nil, nil, &fakeQuota{}, fakeAudit{}, nil, nil, upstream.SSEPumpOptions{}, nil)
+
+	req := makeTokenRequest(http.MethodPost, "/v1/messages/count_tokens", `{"model":"gemini-x","messages":[{"role":"user","content":"hi"}]}`, 10)
+	p, _ := auth.PrincipalFromContext(req.Context())
+	p.Groups = []string{"other"}
+	rr := runHandler(h.MessagesCountTokens, req.WithContext(auth.WithPrincipal(req.Context(), p)))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got=%d body=%s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestGeminiProxy_CountTokensDoesNotReserveQuota(t *testing.T) {
+	fs := &fakeStore{
+		channels: []store.UpstreamChannel{
+			{ID: 1, Type: store.UpstreamTypeAnthropic, Status: 1, Groups: "g1", Priority: 10},
+			{ID: 2, Type: store.UpstreamTypeGemini, Status: 1, Groups: "g1"},
+		},
+		endpoints: map[int64][]store.UpstreamEndpoint{
+			1: {{ID: 11, ChannelID: 1, BaseURL: "https://api.anthropic.com", Status: 1}},
+			2: {{ID: 21, ChannelID: 2, BaseURL: "https://generativelanguage.googleapis.com", Status: 1}},
+		},
+		anthropicCreds: map[int64][]store.AnthropicCredential{
+			11: {{ID: 1, EndpointID: 11, Status: 1}},
+		},
+		geminiCreds: map[int64][]store.GeminiCredential{
+			21: {{ID: 2, EndpointID: 21, Status: 1}},
+		},
+		models: map[string]store.ManagedModel{
+			"gemini-x": {ID: 1, PublicID: "gemini-x", GroupName: "g1", Status: 1},
+		},
+		bindings: map[string][]store.ChannelModelBinding{
+			"gemini-x": {
+				{ID: 1, ChannelID: 1, ChannelType: store.UpstreamTypeAnthropic, PublicID: "gemini-x", UpstreamModel: "claude-a", Status: 1},
+				{ID: 2, ChannelID: 2, ChannelType: store.UpstreamTypeGemini, PublicID: "gemini-x", UpstreamModel: "gemini-2.5-flash", Status: 1},
+			},
+		},
+	}
+
+	var gotPath string
+	doer := DoerFunc(func(_ context.Context, _ scheduler.Selection, downstream *http.Request, _ []byte) (*http.Response, error) {
+		gotPath = downstream.URL.Path
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"totalTokens":7}`)),
+		}, nil
+	})
+	q := &fakeQuota{}
+	h := NewHandler(fs, fs, scheduler.New(fs), doer, nil, nil, q, fakeAudit{}, nil, nil, upstream.SSEPumpOptions{}, nil)
+
+	rr := runHandler(h.GeminiProxy, makeTokenRequest(http.MethodPost, "/v1beta/models/gemini-x:countTokens", `{"contents":[{"role":"user","parts":[{"text":"ping"}]}]}`, 10))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d body=%s", rr.Code, rr.Body.String())
+	}
+	if gotPath != "/v1beta/models/gemini-2.5-flash:countTokens" {
+		t.Fatalf("unexpected upstream path: %q", gotPath)
+	}
+	if strings.TrimSpace(rr.Body.String()) != `{"totalTokens":7}` {
+		t.Fatalf("unexpected body: %s", rr.Body.String())
+	}
+	if len(q.reserveCalls) != 0 || len(q.commitCalls) != 0 {
+		t.Fatalf("expected no quota calls, reserve=%d commit=%d", len(q.reserveCalls), len(q.commitCalls))
+	}
+}
+
+func TestEstimateTextTokens(t *testing.T) {
+	cases := []struct {
+		in   string
+		want int64
+	}{
+		{"", 0},
+		{"hello world", 4},
+		{"你好世界", 4},
+		{"a, b.", 4},
+	}
+	for _, tc := range cases {
+		if got := estimateTextTokens(tc.in); got != tc.want {
+			t.Fatalf("estimateTextTokens(%q)=%d, want %d", tc.in, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package openai
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"realms/internal/auth"
+	"realms/internal/scheduler"
+	"realms/internal/store"
+	"realms/internal/upstream"
+)
+
+func TestResponses_SaturatedCredentialIsSkippedWithoutUpstreamCall(t *testing.T) {
+	maxConc := 1
+	fs := &fakeStore{
+		channels: []store.UpstreamChannel{
+			{ID: 1, Type: store.UpstreamTypeOpenAICompatible, Status: 1, Groups: "g1"},
+		},
+		endpoints: map[int64][]store.UpstreamEndpoint{
+			1: {
+				{ID: 11, ChannelID: 1, BaseURL: "https://a.example", Status: 1},
+			},
+		},
+		creds: map[int64][]store.OpenAICompatibleCredential{
+			11: {
+				{ID: 1, EndpointID: 11, Status: 1},
+				{ID: 2, EndpointID: 11, Status: 1, Limits: store.CredentialLimits{MaxConcurrency: &maxConc}},
+			},
+		},
+		models: map[string]store.ManagedModel{
+			"gpt-5.2": {ID: 1, PublicID: "gpt-5.2", GroupName: "g1", Status: 1},
+		},
+		bindings: map[string][]store.ChannelModelBinding{
+			"gpt-5.2": {
+				{ID: 1, ChannelID: 1, ChannelType: store.UpstreamTypeOpenAICompatible, PublicID: "gpt-5.2", UpstreamModel: "gpt-5.2", Status: 1},
+			},
+		},
+	}
+	sched := scheduler.New(fs)
+	doer := &fakeDoer{}
+	h := NewHandler(fs, fs, sched, doer, nil, nil, nil, fakeAudit{}, nil, nil, upstream.SSEPumpOptions{}, nil)
+
+	// 占满 credential 2 的并发槽位：调度应直接选择 credential 1。
+	release, ok := sched.TryAcquireCredentialConcurrency(scheduler.Selection{
+		CredentialType: scheduler.CredentialTypeOpenAI,
+		CredentialID:   2,
+		Limits:         scheduler.CredentialLimits{MaxConcurrency: 1},
+	})
+	if !ok {
+		t.Fatalf("expected to acquire credential slot")
+	}
+	defer release()
+
+	rr := runHandler(h.Responses, makeTokenRequest(http.MethodPost, "/v1/responses", `{"model":"gpt-5.2","input":"hi","stream":false}`, 10))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d body=%s", rr.Code, rr.Body.String())
+	}
+	if len(doer.calls) != 1 || doer.calls[0].CredentialID != 1 {
+		t.Fatalf("expected single upstream call on credential 1, got=%+v", doer.calls)
+	}
+}
+
+func TestTryWithSelection_CredentialConcurrencyFullFailsOver(t *testing.T) {
+	maxConc := 1
+	fs := &fakeStore{
+		channels: []store.UpstreamChannel{
+			{ID: 1, Type: store.UpstreamTypeOpenAICompatible, Status: 1, Groups: "g1"},
+		},
+		endpoints: map[int64][]store.UpstreamEndpoint{
+			1: {
+				{ID: 11, ChannelID: 1, BaseURL: "https://a.example", Status: 1},
+			},
+		},
+		creds: map[int64][]store.OpenAICompatibleCredential{
+			11: {
+				{ID: 1, EndpointID: 11, Status: 1},
+				{ID: 2, EndpointID: 11, Status: 1, Limits: store.CredentialLimits{MaxConcurrency: &maxConc}},
+			},
+		},
+		models: map[string]store.ManagedModel{
+			"gpt-5.2": {ID: 1, PublicID: "gpt-5.2", GroupName: "g1", Status: 1},
+		},
+		bindings: map[string][]store.ChannelModelBinding{
+			"gpt-5.2": {
+				{ID: 1, ChannelID: 1, ChannelType: store.UpstreamTypeOpenAICompatible, PublicID: "gpt-5.2", UpstreamModel: "gpt-5.2", Status: 1},
+			},
+		},
+	}
+	sched := scheduler.New(fs)
+	doer := &fakeDoer{}
+	h := NewHandler(fs, fs, sched, doer, nil, nil, nil, fakeAudit{}, nil, nil, upstream.SSEPumpOptions{}, nil)
+
+	sel := scheduler.Selection{
+		ChannelID:      1,
+		ChannelType:    store.UpstreamTypeOpenAICompatible,
+		EndpointID:     11,
+		BaseURL:        "https://a.example",
+		CredentialType: scheduler.CredentialTypeOpenAI,
+		CredentialID:   2,
+		Limits:         scheduler.CredentialLimits{MaxConcurrency: 1},
+	}
+	// 模拟“选中之后、占用之前”被其他请求抢走最后一个槽位。
+	release, ok := sched.TryAcquireCredentialConcurrency(sel)
+	if !ok {
+		t.Fatalf("expected to acquire credential slot")
+	}
+	defer release()
+
+	req := makeTokenRequest(http.MethodPost, "/v1/responses", "", 10)
+	p, _ := auth.PrincipalFromContext(req.Context())
+	var best proxyFailureInfo
+	now := time.Now()
+	if h.tryWithSelection(httptest.NewRecorder(), req, p, sel, []byte(`{"model":"gpt-5.2"}`), false, nil, nil, 0, 0, now, 0, now, 2, &best) {
+		t.Fatalf("expected tryWithSelection to fail over")
+	}
+	if len(doer.calls) != 0 {
+		t.Fatalf("expected no upstream call for saturated credential, got=%d", len(doer.calls))
+	}
+	if best.Class != "local_throttled" || best.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("unexpected failure info: %+v", best)
+	}
+	if rt := sched.RuntimeChannelStats(1); rt.FailScore != 0 {
+		t.Fatalf("expected saturated credential not to penalize channel, got fail_score=%d", rt.FailScore)
+	}
+}
@@ -0,0 +1,214 @@
+package openai
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"realms/internal/auth"
+	"realms/internal/middleware"
+	"realms/internal/quota"
+	"realms/internal/scheduler"
+)
+
+// Embeddings 提供 OpenAI Embeddings 兼容入口：POST /v1/embeddings。
+//
+// 调度/计费口径与 chat/completions 一致：仅选择启用了 embeddings 能力的 openai_compatible 渠道，
+// 预留按输入 token 估算（embeddings 无输出 token），提交按上游 usage.prompt_tokens 结算。
+func (h *Handler) Embeddings(w http.ResponseWriter, r *http.Request) {
+	reqStart := time.Now()
+	p, ok := auth.PrincipalFromContext(r.Context())
+	if !ok || p.ActorType != auth.ActorTypeToken || p.TokenID == nil {
+		http.Error(w, "未鉴权", http.StatusUnauthorized)
+		return
+	}
+	body := middleware.CachedBody(r.Context())
+	if len(body) == 0 {
+		http.Error(w, "请求体为空", http.StatusBadRequest)
+		return
+	}
+	rawBody := body
+
+	payload, err := sanitizeEmbeddingsPayload(body)
+	if err != nil {
+		if errors.Is(err, errInvalidJSON) {
+			http.Error(w, "请求体不是有效 JSON", http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	publicModel := strings.TrimSpace(stringFromAny(payload["model"]))
+	inputTokens := estimateEmbeddingsInputTokens(payload["input"])
+
+	freeMode := false
+	modelPassthrough := false
+	if h.features != nil {
+		fs := h.features.FeatureStateEffective(r.Context())
+		freeMode = fs.BillingDisabled
+		modelPassthrough = fs.ModelsDisabled
+	}
+
+	if h.models == nil {
+		http.Error(w, "服务未配置模型目录", http.StatusBadGateway)
+		return
+	}
+
+	var cons scheduler.Constraints
+	cons.RequireAPI = scheduler.RequiredAPIEmbeddings
+	ags := allowGroupsFromPrincipal(p)
+	allowSet := ags.Set
+	if len(ags.Order) == 0 {
+		http.Error(w, "Token 未配置渠道组", http.StatusBadRequest)
+		return
+	}
+	cons.AllowGroups = allowSet
+	cons.AllowGroupOrder = ags.Order
+	// 用户侧 token 默认按绑定顺序做 channel 级 failover；sticky 只影响“从哪里继续”，不决定是否启用该语义。
+	cons.SequentialChannelFailover = true
+
+	var resolvedBindings resolvedChannelModelBindings
+	requireBinding := false
+
+	if modelPassthrough {
+		// 非 free_mode 下仍要求模型定价存在（用于配额预留与计费口径），但不要求“启用”。
+		if !freeMode {
+			mm, err := h.models.GetManagedModelByPublicID(r.Context(), publicModel)
+			if err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					http.Error(w, "模型不存在", http.StatusBadRequest)
+					return
+				}
+				http.Error(w, "查询模型失败", http.StatusBadGateway)
+				return
+			}
+			if allowSet != nil {
+				if _, ok := allowSet[managedModelGroupName(mm)]; !ok {
+					http.Error(w, "无权限使用该模型", http.StatusBadRequest)
+					return
+				}
+			}
+		}
+		// passthrough 模式下仍尝试使用“渠道绑定模型”做 model 转发（best-effort）。
+		if bindings, err := h.models.ListEnabledChannelModelBindingsByPublicID(r.Context(), publicModel); err == nil {
+			resolvedBindings = resolveChannelModelBindings(bindings, cons.RequireChannelType)
+			if !resolvedBindings.Empty() {
+				resolvedBindings.ApplyToConstraints(&cons)
+			}
+		}
+	} else {
+		mm, err := h.models.GetEnabledManagedModelByPublicID(r.Context(), publicModel)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				http.Error(w, "模型未启用", http.StatusBadRequest)
+				return
+			}
+			http.Error(w, "查询模型失败", http.StatusBadGateway)
+			return
+		}
+		if allowSet != nil {
+			if _, ok := allowSet[managedModelGroupName(mm)]; !ok {
+				http.Error(w, "无权限使用该模型", http.StatusBadRequest)
+				return
+			}
+		}
+		bindings, err := h.models.ListEnabledChannelModelBindingsByPublicID(r.Context(), publicModel)
+		if err != nil {
+			http.Error(w, "查询模型绑定失败", http.StatusBadGateway)
+			return
+		}
+		resolvedBindings = resolveChannelModelBindings(bindings, cons.RequireChannelType)
+		if resolvedBindings.Empty() {
+			http.Error(w, "模型未配置可用上游", http.StatusBadGateway)
+			return
+		}
+		resolvedBindings.ApplyToConstraints(&cons)
+		requireBinding = true
+	}
+
+	rewriteBody := func(sel scheduler.Selection) ([]byte, error) {
+		if sel.PassThroughBodyEnabled {
+			return rawBody, nil
+		}
+		fallback := publicModel
+		if requireBinding {
+			fallback = ""
+		}
+		up := resolvedBindings.UpstreamModel(sel.ChannelID, fallback)
+		if strings.TrimSpace(up) == "" {
+			return nil, errors.New("选中渠道未配置该模型")
+		}
+		out := clonePayload(payload)
+		out["model"] = up
+		raw, err := json.Marshal(out)
+		if err != nil {
+			return nil, err
+		}
+		raw, err = applyChannelRequestPolicy(raw, sel)
+		if err != nil {
+			return nil, err
+		}
+		raw, err = applyChannelBodyFilters(raw, sel)
+		if err != nil {
+			return nil, err
+		}
+		ctx := buildParamOverrideContext(sel, publicModel, up, r.URL.Path)
+		raw, err = applyChannelParamOverride(raw, sel, ctx)
+		if err != nil {
+			return nil, err
+		}
+		return raw, nil
+	}
+
+	routeKey := extractRouteKeyFromPayload(payload)
+	if routeKey == "" {
+		routeKey = extractRouteKey(r)
+	}
+	routeKeyHash := h.sched.RouteKeyHash(routeKey)
+
+	h.proxyWithFailover(w, r, failoverProxy{
+		reqStart:     reqStart,
+		principal:    p,
+		publicModel:  publicModel,
+		reqBytes:     int64(len(body)),
+		cons:         cons,
+		routeKeyHash: routeKeyHash,
+		reserve:      quota.ReserveInput{InputTokens: inputTokens},
+		bindings:     resolvedBindings,
+		rewriteBody:  rewriteBody,
+	})
+}
+
+// estimateEmbeddingsInputTokens 粗略估算 embeddings 输入 token 数，仅用于配额预留；
+// 最终计费以上游返回的 usage.prompt_tokens 为准。
+//
+// input 支持：string / []string / []int（token 数组）/ [][]int。
+func estimateEmbeddingsInputTokens(input any) *int64 {
+	var total int64
+	var walk func(v any, depth int)
+	walk = func(v any, depth int) {
+		if depth > 2 {
+			return
+		}
+		switch vv := v.(type) {
+		case string:
+			// 经验值：约 4 字节/token；向上取整避免低估。
+			total += int64((len(vv) + 3) / 4)
+		case float64, json.Number:
+			total++
+		case []any:
+			for _, item := range vv {
+				walk(item, depth+1)
+			}
+		}
+	}
+	walk(input, 0)
+	if total <= 0 {
+		return nil
+	}
+	return &total
+}
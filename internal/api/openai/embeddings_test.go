@@ -0,0 +1,190 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"realms/internal/scheduler"
+	"realms/internal/store"
+	"realms/internal/upstream"
+)
+
+func TestEmbeddings_SelectsEmbeddingsChannelAndCommitsInputTokens(t *testing.T) {
+	const groupName = "g1"
+	fs := &fakeStore{
+		channels: []store.UpstreamChannel{
+			{ID: 1, Type: store.UpstreamTypeOpenAICompatible, Status: 1, Groups: groupName, Priority: 10},
+			{ID: 2, Type: store.UpstreamTypeOpenAICompatible, Status: 1, Groups: groupName, Setting: store.UpstreamChannelSetting{EmbeddingsEnabled: true}},
+		},
+		endpoints: map[int64][]store.UpstreamEndpoint{
+			1: {{ID: 11, ChannelID: 1, BaseURL: "https://a.example", Status: 1}},
+			2: {{ID: 21, ChannelID: 2, BaseURL: "https://b.example", Status: 1}},
+		},
+		creds: map[int64][]store.OpenAICompatibleCredential{
+			11: {{ID: 1, EndpointID: 11, Status: 1}},
+			21: {{ID: 2, EndpointID: 21, Status: 1}},
+		},
+		models: map[string]store.ManagedModel{
+			"e1": {ID: 1, PublicID: "e1", GroupName: groupName, Status: 1},
+		},
+		bindings: map[string][]store.ChannelModelBinding{
+			"e1": {
+				{ID: 1, ChannelID: 1, ChannelType: store.UpstreamTypeOpenAICompatible, PublicID: "e1", UpstreamModel: "text-embedding-a", Status: 1},
+				{ID: 2, ChannelID: 2, ChannelType: store.UpstreamTypeOpenAICompatible, PublicID: "e1", UpstreamModel: "text-embedding-b", Status: 1},
+			},
+		},
+	}
+
+	var gotChannelID int64
+	var gotPath string
+	var gotBody []byte
+	doer := DoerFunc(func(_ context.Context, sel scheduler.Selection, downstream *http.Request, body []byte) (*http.Response, error) {
+		gotChannelID = sel.ChannelID
+		gotPath = downstream.URL.Path
+		gotBody = body
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(bytes.NewReader([]byte(`{"object":"list","data":[{"object":"embedding","index":0,"embedding":[0.1]}],"model":"text-embedding-b","usage":{"prompt_tokens":7,"total_tokens":7}}`))),
+		}, nil
+	})
+
+	q := &fakeQuota{}
+	h := NewHandler(fs, fs, scheduler.New(fs), doer, nil, nil, q, fakeAudit{}, nil, nil, upstream.SSEPumpOptions{}, nil)
+
+	rr := runHandler(h.Embeddings, makeTokenRequest(http.MethodPost, "/v1/embeddings", `{"model":"e1","input":["hello world","foo"],"dimensions":256,"stream":true}`, 10))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d body=%s", rr.Code, rr.Body.String())
+	}
+	if gotChannelID != 2 {
+		t.Fatalf("expected embeddings-enabled channel 2, got=%d", gotChannelID)
+	}
+	if gotPath != "/v1/embeddings" {
+		t.Fatalf("unexpected upstream path: %q", gotPath)
+	}
+	var forwarded map[string]any
+	if err := json.Unmarshal(gotBody, &forwarded); err != nil {
+		t.Fatalf("unmarshal forwarded body: %v", err)
+	}
+	if forwarded["model"] != "text-embedding-b" {
+		t.Fatalf("expected upstream model rewrite, got=%#v", forwarded["model"])
+	}
+	if _, ok := forwarded["stream"]; ok {
+		t.Fatalf("expected stream to be stripped, got=%#v", forwarded["stream"])
+	}
+	if v, ok := forwarded["dimensions"].(float64); !ok || int64(v) != 256 {
+		t.Fatalf("expected dimensions to be preserved, got=%#v", forwarded["dimensions"])
+	}
+
+	if len(q.reserveCalls) != 1 {
+		t.Fatalf("expected 1 reserve call, got=%d", len(q.reserveCalls))
+	}
+	reserve := q.reserveCalls[0]
+	if reserve.InputTokens == nil || *reserve.InputTokens <= 0 {
+		t.Fatalf("expected reserve input tokens estimate, got=%v", reserve.InputTokens)
+	}
+	if reserve.MaxOutputTokens != nil {
+		t.Fatalf("expected no max output tokens, got=%v", *reserve.MaxOutputTokens)
+	}
+	if len(q.commitCalls) != 1 {
+		t.Fatalf("expected 1 commit call, got=%d", len(q.commitCalls))
+	}
+	commit := q.commitCalls[0]
+	if commit.InputTokens == nil || *commit.InputTokens != 7 {
+		t.Fatalf("expected commit input_tokens=7, got=%v", commit.InputTokens)
+	}
+	if commit.OutputTokens != nil {
+		t.Fatalf("expected commit output_tokens=nil, got=%v", *commit.OutputTokens)
+	}
+	if commit.UpstreamChannelID == nil || *commit.UpstreamChannelID != 2 {
+		t.Fatalf("expected commit upstream channel=2, got=%v", commit.UpstreamChannelID)
+	}
+}
+
+func TestEmbeddings_NoEmbeddingsChannelVoidsQuota(t *testing.T) {
+	const groupName = "g1"
+	fs := &fakeStore{
+		channels: []store.UpstreamChannel{
+			{ID: 1, Type: store.UpstreamTypeOpenAICompatible, Status: 1, Groups: groupName, Priority: 10},
+			{ID: 2, Type: store.UpstreamTypeOpenAICompatible, Status: 1, Groups: groupName, Setting: store.UpstreamChannelSetting{EmbeddingsEnabled: true}},
+		},
+		endpoints: map[int64][]store.UpstreamEndpoint{
+			1: {{ID: 11, ChannelID: 1, BaseURL: "https://a.example", Status: 1}},
+			2: {{ID: 21, ChannelID: 2, BaseURL: "https://b.example", Status: 1}},
+		},
+		creds: map[int64][]store.OpenAICompatibleCredential{
+			11: {{ID: 1, EndpointID: 11, Status: 1}},
+			21: {{ID: 2, EndpointID: 21, Status: 1}},
+		},
+		models: map[string]store.ManagedModel{
+			"e1": {ID: 1, PublicID: "e1", GroupName: groupName, Status: 1},
+		},
+		bindings: map[string][]store.ChannelModelBinding{
+			"e1": {
+				{ID: 1, ChannelID: 1, ChannelType: store.UpstreamTypeOpenAICompatible, PublicID: "e1", UpstreamModel: "text-embedding-a", Status: 1},
+				{ID: 2, ChannelID: 2, ChannelType: store.UpstreamTypeOpenAICompatible, PublicID: "e1", UpstreamModel: "text-embedding-b", Status: 1},
+			},
+		},
+	}
+	fs.channels[1].Setting.EmbeddingsEnabled = false
+
+	called := false
+	doer := DoerFunc(func(_ context.Context, _ scheduler.Selection, _ *http.Request, _ []byte) (*http.Response, error) {
+		called = true
+		return nil, io.EOF
+	})
+
+	q := &fakeQuota{}
+	h := NewHandler(fs, fs, scheduler.New(fs), doer, nil, nil, q, fakeAudit{}, nil, nil, upstream.SSEPumpOptions{}, nil)
+
+	rr := runHandler(h.Embeddings, makeTokenRequest(http.MethodPost, "/v1/embeddings", `{"model":"e1","input":"hello"}`, 10))
+	if rr.Code == http.StatusOK {
+		t.Fatalf("expected failure without embeddings-enabled channel, body=%s", rr.Body.String())
+	}
+	if called {
+		t.Fatalf("expected upstream not to be called")
+	}
+	if len(q.voidCalls) != 1 {
+		t.Fatalf("expected 1 void call, got=%d", len(q.voidCalls))
+	}
+}
+
+func TestEmbeddings_RejectsEmptyInput(t *testing.T) {
+	const groupName = "g1"
+	fs := &fakeStore{
+		channels: []store.UpstreamChannel{
+			{ID: 1, Type: store.UpstreamTypeOpenAICompatible, Status: 1, Groups: groupName, Priority: 10},
+			{ID: 2, Type: store.UpstreamTypeOpenAICompatible, Status: 1, Groups: groupName, Setting: store.UpstreamChannelSetting{EmbeddingsEnabled: true}},
+		},
+		endpoints: map[int64][]store.UpstreamEndpoint{
+			1: {{ID: 11, ChannelID: 1, BaseURL: "https://a.example", Status: 1}},
+			2: {{ID: 21, ChannelID: 2, BaseURL: "https://b.example", Status: 1}},
+		},
+		creds: map[int64][]store.OpenAICompatibleCredential{
+			11: {{ID: 1, EndpointID: 11, Status: 1}},
+			21: {{ID: 2, EndpointID: 21, Status: 1}},
+		},
+		models: map[string]store.ManagedModel{
+			"e1": {ID: 1, PublicID: "e1", GroupName: groupName, Status: 1},
+		},
+		bindings: map[string][]store.ChannelModelBinding{
+			"e1": {
+				{ID: 1, ChannelID: 1, ChannelType: store.UpstreamTypeOpenAICompatible, PublicID: "e1", UpstreamModel: "text-embedding-a", Status: 1},
+				{ID: 2, ChannelID: 2, ChannelType: store.UpstreamTypeOpenAICompatible, PublicID: "e1", UpstreamModel: "text-embedding-b", Status: 1},
+			},
+		},
+	}
+	h := NewHandler(fs, fs, scheduler.New(fs), DoerFunc(func(_ context.Context, _ scheduler.Selection, _ *http.Request, _ []byte) (*http.Response, error) {
+		t.Fatalf("unexpected upstream call")
+		return nil, nil
+	}), nil, nil, nil, fakeAudit{}, nil, nil, upstream.SSEPumpOptions{}, nil)
+
+	rr := runHandler(h.Embeddings, makeTokenRequest(http.MethodPost, "/v1/embeddings", `{"model":"e1","input":[]}`, 10))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got=%d body=%s", rr.Code, rr.Body.String())
+	}
+}
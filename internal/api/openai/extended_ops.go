@@ -226,7 +226,7 @@ func (h *Handler) ResponseRetrieve(w http.ResponseWriter, r *http.Request) {
 		writeNotFound(w)
 		return
 	}
-	sel, ok := h.ownedSelection(r.Context(), p, openAIObjectTypeResponse, id, scheduler.RequiredAPIResponses)
+	sel, ok := h.ownedSelection(r.Context(), p, openAIObjectTypeResponse, id, scheduler.RequiredAPIResponsesNative)
 	if !ok {
 		writeNotFound(w)
 		return
@@ -249,7 +249,7 @@ func (h *Handler) ResponseDelete(w http.ResponseWriter, r *http.Request) {
 		writeNotFound(w)
 		return
 	}
-	sel, ok := h.ownedSelection(r.Context(), p, openAIObjectTypeResponse, id, scheduler.RequiredAPIResponses)
+	sel, ok := h.ownedSelection(r.Context(), p, openAIObjectTypeResponse, id, scheduler.RequiredAPIResponsesNative)
 	if !ok {
 		writeNotFound(w)
 		return
@@ -275,7 +275,7 @@ func (h *Handler) ResponseCancel(w http.ResponseWriter, r *http.Request) {
 		writeNotFound(w)
 		return
 	}
-	sel, ok := h.ownedSelection(r.Context(), p, openAIObjectTypeResponse, id, scheduler.RequiredAPIResponses)
+	sel, ok := h.ownedSelection(r.Context(), p, openAIObjectTypeResponse, id, scheduler.RequiredAPIResponsesNative)
 	if !ok {
 		writeNotFound(w)
 		return
@@ -299,7 +299,7 @@ func (h *Handler) ResponseInputItems(w http.ResponseWriter, r *http.Request) {
 		writeNotFound(w)
 		return
 	}
-	sel, ok := h.ownedSelection(r.Context(), p, openAIObjectTypeResponse, id, scheduler.RequiredAPIResponses)
+	sel, ok := h.ownedSelection(r.Context(), p, openAIObjectTypeResponse, id, scheduler.RequiredAPIResponsesNative)
 	if !ok {
 		writeNotFound(w)
 		return
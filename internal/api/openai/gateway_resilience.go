@@ -30,6 +30,7 @@ type gatewayOptions struct {
 	maxFailoverSwitches      int
 	userMaxConcurrency       int
 	credentialMaxConcurrency int
+	batchConcurrency         int
 }
 
 type GatewayPolicy struct {
@@ -40,6 +41,7 @@ type GatewayPolicy struct {
 	MaxFailoverSwitches      int
 	UserMaxConcurrency       int
 	CredentialMaxConcurrency int
+	BatchConcurrency         int
 }
 
 type gatewayErrorResponse struct {
@@ -64,6 +66,7 @@ func defaultGatewayOptions() gatewayOptions {
 		maxFailoverSwitches:      0,
 		userMaxConcurrency:       0,
 		credentialMaxConcurrency: 0,
+		batchConcurrency:         0,
 	}
 }
 
@@ -80,6 +83,7 @@ func (h *Handler) SetGatewayPolicy(policy GatewayPolicy) {
 		maxFailoverSwitches:      policy.MaxFailoverSwitches,
 		userMaxConcurrency:       policy.UserMaxConcurrency,
 		credentialMaxConcurrency: policy.CredentialMaxConcurrency,
+		batchConcurrency:         policy.BatchConcurrency,
 	}
 	if opts.maxRetryAttempts < 0 {
 		opts.maxRetryAttempts = 0
@@ -108,6 +112,9 @@ func (h *Handler) SetGatewayPolicy(policy GatewayPolicy) {
 	if opts.credentialMaxConcurrency < 0 {
 		opts.credentialMaxConcurrency = 0
 	}
+	if opts.batchConcurrency < 0 {
+		opts.batchConcurrency = 0
+	}
 	h.gateway = opts
 }
 
@@ -245,6 +252,23 @@ func (h *Handler) acquireCredentialSlot(ctx context.Context, sel scheduler.Selec
 	return h.concurrency.AcquireCredentialSlotWithWait(ctx, credKey, h.gateway.credentialMaxConcurrency)
 }
 
+// acquireCredentialLimitSlot 按 credential 配置的并发上限非阻塞占用槽位；返回 false 表示该 credential 已满。
+func (h *Handler) acquireCredentialLimitSlot(sel scheduler.Selection) (func(), bool) {
+	if h == nil || h.sched == nil {
+		return func() {}, true
+	}
+	return h.sched.TryAcquireCredentialConcurrency(sel)
+}
+
+func credentialLimitSaturatedFailure() proxyFailureInfo {
+	return proxyFailureInfo{
+		Valid:      true,
+		Class:      "local_throttled",
+		StatusCode: http.StatusTooManyRequests,
+		Message:    "credential 并发已达上限",
+	}
+}
+
 func classifyConcurrencyAcquireFailure(err error) proxyFailureInfo {
 	switch {
 	case err == nil:
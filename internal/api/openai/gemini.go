@@ -16,7 +16,6 @@ import (
 	"realms/internal/middleware"
 	"realms/internal/quota"
 	"realms/internal/scheduler"
-	"realms/internal/store"
 )
 
 type geminiModelItem struct {
@@ -91,6 +90,10 @@ func (h *Handler) GeminiProxy(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "model 不能为空", http.StatusBadRequest)
 		return
 	}
+	if !geminiActionSupported(pathTail) {
+		http.Error(w, "不支持的 Gemini 接口", http.StatusNotFound)
+		return
+	}
 
 	body := middleware.CachedBody(r.Context())
 	if len(body) == 0 {
@@ -108,6 +111,19 @@ func (h *Handler) GeminiProxy(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if strings.HasSuffix(pathTail, ":countTokens") {
+		// countTokens 不预留配额、不记用量，仅做渠道组权限校验后转发（或本地估算）。
+		var cons scheduler.Constraints
+		cons.RequireAPI = scheduler.RequiredAPIGemini
+		resolvedBindings, status, msg := h.resolveCountTokensRoute(r, p, publicModel, &cons)
+		if status != 0 {
+			http.Error(w, msg, status)
+			return
+		}
+		h.geminiCountTokens(w, r, p, cons, resolvedBindings, pathTail, publicModel, sanitizedBody)
+		return
+	}
+
 	sanitizedBody, serviceTier, err := normalizeRequestServiceTier(sanitizedBody, nil)
 	if err != nil {
 		http.Error(w, "service_tier 非法", http.StatusBadRequest)
@@ -131,7 +147,8 @@ func (h *Handler) GeminiProxy(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var cons scheduler.Constraints
-	cons.RequireChannelType = store.UpstreamTypeOpenAICompatible
+	// gemini 渠道原生承接；openai_compatible 渠道按聚合网关惯例透传 /v1beta 路径。
+	cons.RequireAPI = scheduler.RequiredAPIGemini
 	ags := allowGroupsFromPrincipal(p)
 	allowSet := ags.Set
 	if len(ags.Order) == 0 {
@@ -241,6 +258,9 @@ func (h *Handler) GeminiProxy(w http.ResponseWriter, r *http.Request) {
 			MaxOutputTokens: maxOut,
 		})
 		if err != nil {
+			if writeTokenLimitReserveError(w, err) {
+				return
+			}
 			if msg := reserveBadRequestMessage(err); msg != "" {
 				http.Error(w, msg, http.StatusBadRequest)
 				return
@@ -369,6 +389,20 @@ func (h *Handler) GeminiProxy(w http.ResponseWriter, r *http.Request) {
 	h.finalizeUsageEvent(r, usageID, nil, resp.Status, finalClass, resp.UsageMessage, time.Since(reqStart), 0, wantStream, reqBytes, cw.bytes)
 }
 
+// geminiActionSupported 限定可转发的 Gemini 方法：generateContent/streamGenerateContent/countTokens/embedContent/batchEmbedContents。
+func geminiActionSupported(pathTail string) bool {
+	parts := strings.SplitN(pathTail, ":", 2)
+	if len(parts) != 2 {
+		return false
+	}
+	switch strings.TrimSpace(parts[1]) {
+	case "generateContent", "streamGenerateContent", "countTokens", "embedContent", "batchEmbedContents":
+		return true
+	default:
+		return false
+	}
+}
+
 func extractGeminiMaxOutputTokens(body []byte) *int64 {
 	v := gjson.GetBytes(body, "generationConfig.maxOutputTokens")
 	if v.Exists() {
@@ -0,0 +1,177 @@
+package openai
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"realms/internal/scheduler"
+	"realms/internal/store"
+	"realms/internal/upstream"
+)
+
+func TestGeminiProxy_GeminiChannelGenerateContentBillsUsageMetadata(t *testing.T) {
+	fs := &fakeStore{
+		channels: []store.UpstreamChannel{
+			{ID: 1, Type: store.UpstreamTypeAnthropic, Status: 1, Groups: "g1", Priority: 10},
+			{ID: 2, Type: store.UpstreamTypeGemini, Status: 1, Groups: "g1"},
+		},
+		endpoints: map[int64][]store.UpstreamEndpoint{
+			1: {{ID: 11, ChannelID: 1, BaseURL: "https://api.anthropic.com", Status: 1}},
+			2: {{ID: 21, ChannelID: 2, BaseURL: "https://generativelanguage.googleapis.com", Status: 1}},
+		},
+		anthropicCreds: map[int64][]store.AnthropicCredential{
+			11: {{ID: 1, EndpointID: 11, Status: 1}},
+		},
+		geminiCreds: map[int64][]store.GeminiCredential{
+			21: {{ID: 2, EndpointID: 21, Status: 1}},
+		},
+		models: map[string]store.ManagedModel{
+			"gemini-x": {ID: 1, PublicID: "gemini-x", GroupName: "g1", Status: 1},
+		},
+		bindings: map[string][]store.ChannelModelBinding{
+			"gemini-x": {
+				{ID: 1, ChannelID: 1, ChannelType: store.UpstreamTypeAnthropic, PublicID: "gemini-x", UpstreamModel: "claude-a", Status: 1},
+				{ID: 2, ChannelID: 2, ChannelType: store.UpstreamTypeGemini, PublicID: "gemini-x", UpstreamModel: "gemini-2.5-flash", Status: 1},
+			},
+		},
+	}
+
+	var gotSel scheduler.Selection
+	var gotPath string
+	doer := DoerFunc(func(_ context.Context, sel scheduler.Selection, downstream *http.Request, _ []byte) (*http.Response, error) {
+		gotSel = sel
+		gotPath = downstream.URL.Path
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body: io.NopCloser(strings.NewReader(`{"candidates":[{"content":{"role":"model","parts":[{"text":"pong"}]},"finishReason":"STOP"}],` +
+				`"usageMetadata":{"promptTokenCount":12,"candidatesTokenCount":3,"thoughtsTokenCount":4,"cachedContentTokenCount":5,"totalTokenCount":19},"modelVersion":"gemini-2.5-flash"}`)),
+		}, nil
+	})
+	q := &fakeQuota{}
+	h := NewHandler(fs, fs, scheduler.New(fs), doer, nil, nil, q, fakeAudit{}, nil, nil, upstream.SSEPumpOptions{}, nil)
+
+	rr := runHandler(h.GeminiProxy, makeTokenRequest(http.MethodPost, "/v1beta/models/gemini-x:generateContent", `{"contents":[{"role":"user","parts":[{"text":"ping"}]}]}`, 10))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d body=%s", rr.Code, rr.Body.String())
+	}
+	if gotSel.ChannelID != 2 || gotSel.CredentialType != scheduler.CredentialTypeGemini {
+		t.Fatalf("expected gemini channel selection, got=%+v", gotSel)
+	}
+	if gotPath != "/v1beta/models/gemini-2.5-flash:generateContent" {
+		t.Fatalf("unexpected upstream path: %q", gotPath)
+	}
+	if len(q.commitCalls) != 1 {
+		t.Fatalf("expected 1 commit call, got=%d", len(q.commitCalls))
+	}
+	commit := q.commitCalls[0]
+	if commit.InputTokens == nil || *commit.InputTokens != 12 {
+		t.Fatalf("unexpected input tokens: %v", commit.InputTokens)
+	}
+	if commit.OutputTokens == nil || *commit.OutputTokens != 7 {
+		t.Fatalf("expected candidates+thoughts=7 output tokens, got=%v", commit.OutputTokens)
+	}
+	if commit.CachedInputTokens == nil || *commit.CachedInputTokens != 5 {
+		t.Fatalf("unexpected cached input tokens: %v", commit.CachedInputTokens)
+	}
+}
+
+func TestGeminiProxy_StreamGenerateContentUsesLastUsageMetadata(t *testing.T) {
+	fs := &fakeStore{
+		channels: []store.UpstreamChannel{
+			{ID: 1, Type: store.UpstreamTypeAnthropic, Status: 1, Groups: "g1", Priority: 10},
+			{ID: 2, Type: store.UpstreamTypeGemini, Status: 1, Groups: "g1"},
+		},
+		endpoints: map[int64][]store.UpstreamEndpoint{
+			1: {{ID: 11, ChannelID: 1, BaseURL: "https://api.anthropic.com", Status: 1}},
+			2: {{ID: 21, ChannelID: 2, BaseURL: "https://generativelanguage.googleapis.com", Status: 1}},
+		},
+		anthropicCreds: map[int64][]store.AnthropicCredential{
+			11: {{ID: 1, EndpointID: 11, Status: 1}},
+		},
+		geminiCreds: map[int64][]store.GeminiCredential{
+			21: {{ID: 2, EndpointID: 21, Status: 1}},
+		},
+		models: map[string]store.ManagedModel{
+			"gemini-x": {ID: 1, PublicID: "gemini-x", GroupName: "g1", Status: 1},
+		},
+		bindings: map[string][]store.ChannelModelBinding{
+			"gemini-x": {
+				{ID: 1, ChannelID: 1, ChannelType: store.UpstreamTypeAnthropic, PublicID: "gemini-x", UpstreamModel: "claude-a", Status: 1},
+				{ID: 2, ChannelID: 2, ChannelType: store.UpstreamTypeGemini, PublicID: "gemini-x", UpstreamModel: "gemini-2.5-flash", Status: 1},
+			},
+		},
+	}
+
+	doer := DoerFunc(func(_ context.Context, _ scheduler.Selection, _ *http.Request, _ []byte) (*http.Response, error) {
+		stream := "data: {\"candidates\":[{\"content\":{\"parts\":[{\"text\":\"po\"}]}}],\"usageMetadata\":{\"promptTokenCount\":8}}\n\n" +
+			"data: {\"candidates\":[{\"content\":{\"parts\":[{\"text\":\"ng\"}]},\"finishReason\":\"STOP\"}],\"usageMetadata\":{\"promptTokenCount\":8,\"candidatesTokenCount\":2}}\n\n"
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"text/event-stream"}},
+			Body:       io.NopCloser(strings.NewReader(stream)),
+		}, nil
+	})
+	q := &fakeQuota{}
+	h := NewHandler(fs, fs, scheduler.New(fs), doer, nil, nil, q, fakeAudit{}, nil, nil, upstream.SSEPumpOptions{}, nil)
+
+	rr := runHandler(h.GeminiProxy, makeTokenRequest(http.MethodPost, "/v1beta/models/gemini-x:streamGenerateContent?alt=sse", `{"contents":[{"role":"user","parts":[{"text":"ping"}]}]}`, 10))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d body=%s", rr.Code, rr.Body.String())
+	}
+	if !strings.Contains(rr.Body.String(), `"ng"`) {
+		t.Fatalf("expected streamed chunks to be forwarded, got=%s", rr.Body.String())
+	}
+	if len(q.commitCalls) != 1 {
+		t.Fatalf("expected 1 commit call, got=%d", len(q.commitCalls))
+	}
+	commit := q.commitCalls[0]
+	if commit.InputTokens == nil || *commit.InputTokens != 8 || commit.OutputTokens == nil || *commit.OutputTokens != 2 {
+		t.Fatalf("unexpected committed usage: in=%v out=%v", commit.InputTokens, commit.OutputTokens)
+	}
+}
+
+func TestGeminiProxy_RejectsUnsupportedAction(t *testing.T) {
+	fs := &fakeStore{
+		channels: []store.UpstreamChannel{
+			{ID: 1, Type: store.UpstreamTypeAnthropic, Status: 1, Groups: "g1", Priority: 10},
+			{ID: 2, Type: store.UpstreamTypeGemini, Status: 1, Groups: "g1"},
+		},
+		endpoints: map[int64][]store.UpstreamEndpoint{
+			1: {{ID: 11, ChannelID: 1, BaseURL: "https://api.anthropic.com", Status: 1}},
+			2: {{ID: 21, ChannelID: 2, BaseURL: "https://generativelanguage.googleapis.com", Status: 1}},
+		},
+		anthropicCreds: map[int64][]store.AnthropicCredential{
+			11: {{ID: 1, EndpointID: 11, Status: 1}},
+		},
+		geminiCreds: map[int64][]store.GeminiCredential{
+			21: {{ID: 2, EndpointID: 21, Status: 1}},
+		},
+		models: map[string]store.ManagedModel{
+			"gemini-x": {ID: 1, PublicID: "gemini-x", GroupName: "g1", Status: 1},
+		},
+		bindings: map[string][]store.ChannelModelBinding{
+			"gemini-x": {
+				{ID: 1, ChannelID: 1, ChannelType: store.UpstreamTypeAnthropic, PublicID: "gemini-x", UpstreamModel: "claude-a", Status: 1},
+				{ID: 2, ChannelID: 2, ChannelType: store.UpstreamTypeGemini, PublicID: "gemini-x", UpstreamModel: "gemini-2.5-flash", Status: 1},
+			},
+		},
+	}
+	h := NewHandler(fs, fs, scheduler.New(fs), &okDoer{}, nil, nil, nil, fakeAudit{}, nil, nil, upstream.SSEPumpOptions{}, nil)
+
+	rr := runHandler(h.GeminiProxy, makeTokenRequest(http.MethodPost, "/v1beta/models/gemini-x:predictLongRunning", `{"contents":[{"role":"user","parts":[{"text":"ping"}]}]}`, 10))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got=%d body=%s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestExtractUsageTokens_GeminiStreamArrayUsesLastChunk(t *testing.T) {
+	body := []byte(`[{"usageMetadata":{"promptTokenCount":9}},{"usageMetadata":{"promptTokenCount":9,"candidatesTokenCount":6}}]`)
+	in, out, _, _ := extractUsageTokens(body)
+	if in == nil || *in != 9 || out == nil || *out != 6 {
+		t.Fatalf("unexpected usage: in=%v out=%v", in, out)
+	}
+}
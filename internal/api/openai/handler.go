@@ -61,6 +61,8 @@ type Handler struct {
 	codexRouteCache *codexSessionRouteCache
 	sessionBindings SessionBindingStore
 
+	batches BatchStore
+
 	compactGateway *upstream.CompactGatewayClient
 }
 
@@ -107,13 +109,16 @@ type OpenAIObjectRefStore interface {
 
 func NewHandler(models ModelCatalog, groups scheduler.ChannelGroupStore, sched *scheduler.Scheduler, exec Doer, proxyLog *proxylog.Writer, features FeatureResolver, qp quota.Provider, audit AuditSink, usage UsageEventSink, refs OpenAIObjectRefStore, sseOpts upstream.SSEPumpOptions, compactGateway *upstream.CompactGatewayClient) *Handler {
 	var sessionBindings SessionBindingStore
+	var batches BatchStore
 	for _, candidate := range []any{models, groups, features, audit, usage, refs} {
 		if candidate == nil {
 			continue
 		}
-		if sb, ok := candidate.(SessionBindingStore); ok && sb != nil {
+		if sb, ok := candidate.(SessionBindingStore); ok && sb != nil && sessionBindings == nil {
 			sessionBindings = sb
-			break
+		}
+		if bs, ok := candidate.(BatchStore); ok && bs != nil && batches == nil {
+			batches = bs
 		}
 	}
 	return &Handler{
@@ -131,6 +136,7 @@ func NewHandler(models ModelCatalog, groups scheduler.ChannelGroupStore, sched *
 		sseOpts:         sseOpts,
 		codexRouteCache: newCodexSessionRouteCache(),
 		sessionBindings: sessionBindings,
+		batches:         batches,
 		compactGateway:  compactGateway,
 	}
 }
@@ -334,8 +340,11 @@ func (h *Handler) proxyJSON(w http.ResponseWriter, r *http.Request) {
 	if r != nil && r.URL != nil && !strings.HasPrefix(r.URL.Path, "/v1/responses") {
 		cons.RequireChannelType = store.UpstreamTypeOpenAICompatible
 		cons.RequireAPI = scheduler.RequiredAPIChatCompletions
-	} else {
+	} else if r.URL.Path == "/v1/responses" {
 		cons.RequireAPI = scheduler.RequiredAPIResponses
+	} else {
+		// /v1/responses/input_tokens 等子接口在 chat 上游没有对应能力，不参与桥接。
+		cons.RequireAPI = scheduler.RequiredAPIResponsesNative
 	}
 
 	ags := allowGroupsFromPrincipal(p)
@@ -351,6 +360,10 @@ func (h *Handler) proxyJSON(w http.ResponseWriter, r *http.Request) {
 	cons.SequentialChannelFailover = true
 
 	var rewriteBody func(sel scheduler.Selection) ([]byte, error)
+	// 仅开启 chat/completions 的 openai_compatible 渠道以桥接方式承接 Responses 请求。
+	bridgeResponses := func(sel scheduler.Selection) bool {
+		return cons.RequireAPI == scheduler.RequiredAPIResponses && scheduler.ResponsesViaChatBridge(sel)
+	}
 
 	var bindings []store.ChannelModelBinding
 	var resolvedBindings resolvedChannelModelBindings
@@ -388,6 +401,9 @@ func (h *Handler) proxyJSON(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 		rewriteBody = func(sel scheduler.Selection) ([]byte, error) {
+			if bridgeResponses(sel) {
+				return rewriteResponsesForChat(payload, sel, publicModel, resolvedBindings.UpstreamModel(sel.ChannelID, publicModel), r.URL.Path)
+			}
 			if sel.PassThroughBodyEnabled {
 				return rawBody, nil
 			}
@@ -454,13 +470,16 @@ func (h *Handler) proxyJSON(w http.ResponseWriter, r *http.Request) {
 		resolvedBindings.ApplyToConstraints(&cons)
 
 		rewriteBody = func(sel scheduler.Selection) ([]byte, error) {
-			if sel.PassThroughBodyEnabled {
+			if sel.PassThroughBodyEnabled && !bridgeResponses(sel) {
 				return rawBody, nil
 			}
 			up := resolvedBindings.UpstreamModel(sel.ChannelID, "")
 			if strings.TrimSpace(up) == "" {
 				return nil, errors.New("选中渠道未配置该模型")
 			}
+			if bridgeResponses(sel) {
+				return rewriteResponsesForChat(payload, sel, publicModel, up, r.URL.Path)
+			}
 			out := clonePayload(payload)
 			out["model"] = up
 			applyChannelSystemPromptToResponsesPayload(out, sel)
@@ -576,6 +595,9 @@ func (h *Handler) proxyJSON(w http.ResponseWriter, r *http.Request) {
 			MaxOutputTokens: maxOut,
 		})
 		if err != nil {
+			if writeTokenLimitReserveError(w, err) {
+				return
+			}
 			if msg := reserveBadRequestMessage(err); msg != "" {
 				http.Error(w, msg, http.StatusBadRequest)
 				return
@@ -706,6 +728,11 @@ func (h *Handler) proxyJSON(w http.ResponseWriter, r *http.Request) {
 		}
 		rewritten, err := rewriteBody(sel)
 		if err != nil {
+			if errors.Is(err, errResponsesChatBridgeUnsupported) || errors.Is(err, errChatCompletionsVertexModelUnsupported) {
+				// 请求无法桥接到 chat/completions，或 vertex 渠道的目标模型不支持转换：换用其他渠道。
+				router.ExcludeChannel(sel.ChannelID)
+				continue
+			}
 			if isFastModeSelectionError(err) {
 				if bindingActive {
 					h.clearCodexStickyBindingBestEffort(r.Context(), p.UserID, stickyRouteKeyHash)
@@ -754,7 +781,11 @@ func (h *Handler) proxyJSON(w http.ResponseWriter, r *http.Request) {
 			selectionRetries = 1
 		}
 		bindingID := resolvedBindings.BindingID(sel.ChannelID)
-		if h.tryWithSelection(w, r, p, sel, rewritten, stream, optionalString(publicModel), extractTopLevelModel(rewritten), bindingID, usageID, reqStart, reqBytes, loopStart, selectionRetries, &bestFailure) {
+		attemptReq := r
+		if bridgeResponses(sel) {
+			attemptReq = r.WithContext(withUpstreamProtocolAdapter(r.Context(), newResponsesToChatAdapter()))
+		}
+		if h.tryWithSelection(w, attemptReq, p, sel, rewritten, stream, optionalString(publicModel), extractTopLevelModel(rewritten), bindingID, usageID, reqStart, reqBytes, loopStart, selectionRetries, &bestFailure) {
 			return
 		}
 		if shouldPreferSequentialChannelSwitch(cons, bindingCredentialPinned) {
@@ -887,8 +918,15 @@ func (h *Handler) tryWithSelection(w http.ResponseWriter, r *http.Request, p aut
 	retries = h.sameSelectionRetries(retries)
 	backoff := h.initialBackoff()
 	for i := 0; i < retries; i++ {
+		// credential 自身并发已满时直接切换到其他 credential，而不是把请求打到上游换回 429。
+		releaseLimit, ok := h.acquireCredentialLimitSlot(sel)
+		if !ok {
+			recordProxyFailure(bestFailure, credentialLimitSaturatedFailure())
+			return false
+		}
 		releaseCred, err := h.acquireCredentialSlot(r.Context(), sel)
 		if err != nil {
+			releaseLimit()
 			if h.finalizeIfCanceledWithModelCheck(r, usageID, &sel, reqStart, wantStream, reqBytes, forwardedModel) {
 				return true
 			}
@@ -899,6 +937,7 @@ func (h *Handler) tryWithSelection(w http.ResponseWriter, r *http.Request, p aut
 		if releaseCred != nil {
 			releaseCred()
 		}
+		releaseLimit()
 		if failure.Valid {
 			recordProxyFailure(bestFailure, failure)
 		}
@@ -934,10 +973,17 @@ func (h *Handler) tryWithSelection(w http.ResponseWriter, r *http.Request, p aut
 func (h *Handler) proxyOnce(w http.ResponseWriter, r *http.Request, sel scheduler.Selection, body []byte, wantStream bool, model *string, forwardedModel *string, bindingID int64, p auth.Principal, usageID int64, reqStart time.Time, reqBytes int64) (proxyAttemptDecision, proxyFailureInfo) {
 	attemptStart := time.Now()
 	serviceTier := requestedServiceTierFromJSONBytes(body)
+	adapter := upstreamProtocolAdapterFromContext(r.Context())
+	upstreamReq := r
+	if adapter != nil && adapter.upstreamPath != "" {
+		upstreamReq = r.Clone(r.Context())
+		upstreamReq.URL.Path = adapter.upstreamPath
+		upstreamReq.URL.RawPath = ""
+	}
 	var resp *http.Response
 	for attempt := 0; attempt < 2; attempt++ {
 		var err error
-		resp, err = h.exec.Do(r.Context(), sel, r, body)
+		resp, err = h.exec.Do(r.Context(), sel, upstreamReq, body)
 		if err != nil {
 			if h.finalizeIfCanceledWithModelCheck(r, usageID, &sel, reqStart, wantStream, reqBytes, forwardedModel) {
 				return proxyAttemptDone, proxyFailureInfo{}
@@ -1034,7 +1080,11 @@ func (h *Handler) proxyOnce(w http.ResponseWriter, r *http.Request, sel schedule
 			downstreamStatus := resetStatusCode(resp.StatusCode, sel.StatusCodeMapping)
 			copyResponseHeaders(cw.Header(), resp.Header)
 			cw.WriteHeader(downstreamStatus)
-			n, _ := cw.Write(bodyBytes)
+			downstreamBody := bodyBytes
+			if adapter != nil && adapter.translateError != nil {
+				downstreamBody = adapter.translateError(bodyBytes)
+			}
+			n, _ := cw.Write(downstreamBody)
 			respBytes := int64(n)
 
 			failMsg := summarizeUpstreamErrorBody(bodyBytes)
@@ -1064,7 +1114,8 @@ func (h *Handler) proxyOnce(w http.ResponseWriter, r *http.Request, sel schedule
 
 	recordCreatedObjectType := ""
 	recordCreatedObject := false
-	if r != nil && r.Method == http.MethodPost {
+	// 协议转换后的对象 ID 不对应上游可检索对象，不记录归属。
+	if r != nil && r.Method == http.MethodPost && adapter == nil {
 		switch r.URL.Path {
 		case "/v1/responses":
 			recordCreatedObjectType = openAIObjectTypeResponse
@@ -1103,6 +1154,8 @@ func (h *Handler) proxyOnce(w http.ResponseWriter, r *http.Request, sel schedule
 		cw.WriteHeader(resp.StatusCode)
 
 		firstTokenLatencyMS := 0
+		// attemptFirstTokenLatency 从本次上游尝试开始计时（不含前序 failover），供调度器延迟 EWMA 使用。
+		var attemptFirstTokenLatency time.Duration
 		hooks := upstream.SSEPumpHooks{
 			OnData: func(data string) {
 				if recordCreatedObject && createdObjectID == "" {
@@ -1125,12 +1178,13 @@ func (h *Handler) proxyOnce(w http.ResponseWriter, r *http.Request, sel schedule
 					if firstTokenLatencyMS < 0 {
 						firstTokenLatencyMS = 0
 					}
+					attemptFirstTokenLatency = time.Since(attemptStart)
 				}
 				if responseModel == nil {
 					responseModel = extractTopLevelModelFromString(data)
 				}
 				// 避免对每个 delta 事件反复 JSON 解析：仅在疑似包含 usage 时尝试。
-				if !strings.Contains(data, "usage") && !strings.Contains(data, "input_tokens") && !strings.Contains(data, "prompt_tokens") {
+				if !strings.Contains(data, "usage") && !strings.Contains(data, "input_tokens") && !strings.Contains(data, "prompt_tokens") && !strings.Contains(data, "invocationMetrics") {
 					if responseRouteKey == "" {
 						var evt any
 						if err := json.Unmarshal([]byte(data), &evt); err == nil {
@@ -1170,7 +1224,10 @@ func (h *Handler) proxyOnce(w http.ResponseWriter, r *http.Request, sel schedule
 			},
 		}
 		if sel.ThinkingToContent {
-			hooks.TransformData = newThinkingToContentTransformer()
+			hooks.TransformData = dataOnlyTransform(newThinkingToContentTransformer())
+		}
+		if adapter != nil && adapter.newStreamTransformer != nil {
+			hooks.TransformData = adapter.newStreamTransformer()
 		}
 
 		doneSSE := obs.TrackSSEConnection()
@@ -1216,7 +1273,7 @@ func (h *Handler) proxyOnce(w http.ResponseWriter, r *http.Request, sel schedule
 
 		// SSE 已写回后不再 failover，但仍记录结果以用于后续调度权重。
 		if pumpRes.ErrorClass == "" || pumpRes.ErrorClass == "client_disconnect" || pumpRes.ErrorClass == "stream_max_duration" {
-			h.sched.Report(sel, scheduler.Result{Success: true, ChannelModelBindingID: bindingID})
+			h.sched.Report(sel, scheduler.Result{Success: true, ChannelModelBindingID: bindingID, FirstTokenLatency: attemptFirstTokenLatency})
 			h.rememberCodexLastSuccessRoute(r, sel)
 		} else {
 			h.sched.Report(sel, scheduler.Result{
@@ -1238,12 +1295,26 @@ func (h *Handler) proxyOnce(w http.ResponseWriter, r *http.Request, sel schedule
 	var capBuf limitedPrefixBuffer
 	capBuf.maxBytes = upstreamNonStreamExtractMaxBytes
 
+	var src io.Reader = resp.Body
+	var translateErr error
+	if adapter != nil && adapter.translateResponse != nil {
+		translated, err := adapter.readTranslatedResponse(resp.Body)
+		if err != nil {
+			translateErr = err
+			translated = nil
+		}
+		src = bytes.NewReader(translated)
+	}
+
 	cw := &countingResponseWriter{ResponseWriter: w}
 	h.patchCodexQuotaBestEffort(sel, resp.Header)
 	copyResponseHeaders(cw.Header(), resp.Header)
 	cw.WriteHeader(resp.StatusCode)
 
-	_, copyErr := io.Copy(cw, io.TeeReader(resp.Body, &capBuf))
+	_, copyErr := io.Copy(cw, io.TeeReader(src, &capBuf))
+	if copyErr == nil {
+		copyErr = translateErr
+	}
 	respBytes := cw.bytes
 	if copyErr != nil {
 		if h.sched != nil {
@@ -1415,7 +1486,17 @@ func (h *Handler) finalizeUsageEventWithModelCheck(r *http.Request, usageID int6
 	var upstreamChannelID *int64
 	var upstreamEndpointID *int64
 	var upstreamCredID *int64
+	var routeGroupID *int64
+	var upstreamCost *store.UsageCostBasis
+	var routingTrace *string
 	if sel != nil {
+		upstreamCost = sel.CostBasis
+		if sel.RouteTrace != nil {
+			if raw, err := json.Marshal(sel.RouteTrace); err == nil {
+				v := string(raw)
+				routingTrace = &v
+			}
+		}
 		if sel.ChannelID > 0 {
 			id := sel.ChannelID
 			upstreamChannelID = &id
@@ -1428,6 +1509,10 @@ func (h *Handler) finalizeUsageEventWithModelCheck(r *http.Request, usageID int6
 			id := sel.CredentialID
 			upstreamCredID = &id
 		}
+		if sel.RouteGroupID > 0 {
+			id := sel.RouteGroupID
+			routeGroupID = &id
+		}
 	}
 
 	var classPtr *string
@@ -1459,9 +1544,12 @@ func (h *Handler) finalizeUsageEventWithModelCheck(r *http.Request, usageID int6
 		UpstreamChannelID:     upstreamChannelID,
 		UpstreamEndpointID:    upstreamEndpointID,
 		UpstreamCredID:        upstreamCredID,
+		RouteGroupID:          routeGroupID,
 		IsStream:              stream,
 		RequestBytes:          reqBytes,
 		ResponseBytes:         respBytes,
+		UpstreamCost:          upstreamCost,
+		RoutingTrace:          routingTrace,
 	})
 }
 
@@ -1810,11 +1898,16 @@ func (b *limitedPrefixBuffer) Write(p []byte) (int, error) {
 }
 
 func copyResponseHeaders(dst, src http.Header) {
+	// 网关已按用户/Token 限流并写入限流头时，不再透传上游（渠道凭据维度）的限流头，避免客户端看到两套互相矛盾的数值。
+	gatewayLimited := dst.Get("x-ratelimit-limit-requests") != "" || dst.Get("x-ratelimit-limit-tokens") != ""
 	for k, vs := range src {
 		kl := strings.ToLower(k)
 		if kl == "content-length" || kl == "connection" || kl == "keep-alive" || kl == "transfer-encoding" || kl == "upgrade" {
 			continue
 		}
+		if gatewayLimited && (strings.HasPrefix(kl, "x-ratelimit-") || strings.HasPrefix(kl, "anthropic-ratelimit-")) {
+			continue
+		}
 		for _, v := range vs {
 			dst.Add(k, v)
 		}
@@ -2296,6 +2389,15 @@ func extractUsageTokens(body []byte) (*int64, *int64, *int64, *int64) {
 		return nil, nil, nil, nil
 	}
 	usage := findUsageMap(root, 10)
+	if arr, ok := root.([]any); ok {
+		// Gemini streamGenerateContent（非 SSE）返回 JSON 数组，usageMetadata 为累计值，以最后一块为准。
+		for i := len(arr) - 1; i >= 0; i-- {
+			if u := findUsageMap(arr[i], 10); u != nil {
+				usage = u
+				break
+			}
+		}
+	}
 	if usage == nil {
 		return nil, nil, nil, nil
 	}
@@ -2337,6 +2439,13 @@ func extractUsageTokensFromUsageMap(usage map[string]any) (*int64, *int64, *int6
 		in = intFromAny(usage["prompt_tokens"])
 		out = intFromAny(usage["completion_tokens"])
 	}
+	if in == nil && out == nil {
+		in, out = geminiUsageTokens(usage)
+	}
+	if in == nil && out == nil {
+		in = intFromAny(usage["inputTokenCount"])
+		out = intFromAny(usage["outputTokenCount"])
+	}
 
 	// prompt caching：常见字段为 usage.{input_tokens_details|prompt_tokens_details}.cached_tokens。
 	cachedIn := intFromAny(usage["cached_input_tokens"])
@@ -2366,6 +2475,11 @@ func extractUsageTokensFromUsageMap(usage map[string]any) (*int64, *int64, *int6
 		}
 	}
 
+	if cachedIn == nil {
+		// Gemini：usageMetadata.cachedContentTokenCount（已包含在 promptTokenCount 内）。
+		cachedIn = intFromAny(usage["cachedContentTokenCount"])
+	}
+
 	// 目前上游很少返回 cached output，但为了兼容/扩展做同样的提取。
 	cachedOut := intFromAny(usage["cached_output_tokens"])
 	if cachedOut == nil {
@@ -2382,6 +2496,42 @@ func extractUsageTokensFromUsageMap(usage map[string]any) (*int64, *int64, *int6
 	return in, out, cachedIn, cachedOut
 }
 
+// geminiUsageTokens 从 Gemini usageMetadata 提取输入/输出 tokens：
+// 输入 = promptTokenCount + toolUsePromptTokenCount；输出 = candidatesTokenCount + thoughtsTokenCount（思考 tokens 按输出计费）。
+func geminiUsageTokens(usage map[string]any) (*int64, *int64) {
+	prompt := intFromAny(usage["promptTokenCount"])
+	toolUse := intFromAny(usage["toolUsePromptTokenCount"])
+	candidates := intFromAny(usage["candidatesTokenCount"])
+	thoughts := intFromAny(usage["thoughtsTokenCount"])
+	var in, out *int64
+	if prompt != nil || toolUse != nil {
+		sum := int64(0)
+		if prompt != nil {
+			sum += *prompt
+		}
+		if toolUse != nil {
+			sum += *toolUse
+		}
+		in = &sum
+	}
+	if candidates != nil || thoughts != nil {
+		sum := int64(0)
+		if candidates != nil {
+			sum += *candidates
+		}
+		if thoughts != nil {
+			sum += *thoughts
+		}
+		out = &sum
+	}
+	if in != nil && out == nil {
+		// 仅有输入（例如输出被安全策略拦截）时，输出按 0 计。
+		zero := int64(0)
+		out = &zero
+	}
+	return in, out
+}
+
 func findUsageMap(v any, depth int) map[string]any {
 	if v == nil || depth <= 0 {
 		return nil
@@ -2393,6 +2543,18 @@ func findUsageMap(v any, depth int) map[string]any {
 				return usage
 			}
 		}
+		// Gemini 原生响应：usageMetadata.{promptTokenCount,candidatesTokenCount,...}。
+		if usageAny, ok := vv["usageMetadata"]; ok {
+			if usage, ok := usageAny.(map[string]any); ok {
+				return usage
+			}
+		}
+		// Bedrock：最后一个事件携带 amazon-bedrock-invocationMetrics.{inputTokenCount,outputTokenCount}。
+		if usageAny, ok := vv["amazon-bedrock-invocationMetrics"]; ok {
+			if usage, ok := usageAny.(map[string]any); ok {
+				return usage
+			}
+		}
 		for _, child := range vv {
 			if u := findUsageMap(child, depth-1); u != nil {
 				return u
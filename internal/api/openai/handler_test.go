@@ -345,6 +345,24 @@ func TestClassifyUpstreamHTTPFailure_ExplicitModelFailureUsesChannelModelScope(t
 	}
 }
 
+func TestClassifyUpstreamHTTPFailure_AzureContentFilterIsRequestScoped(t *testing.T) {
+	for _, body := range [][]byte{
+		[]byte(`{"error":{"code":"content_filter","message":"The response was filtered due to the prompt triggering Azure OpenAI's content management policy."}}`),
+		[]byte(`{"error":{"code":"invalid_request_error","message":"filtered","innererror":{"code":"ResponsibleAIPolicyViolation"}}}`),
+	} {
+		got := classifyUpstreamHTTPFailure(http.StatusBadRequest, body, 101, codexOAuthUpstreamErr{})
+		if got.Retriable {
+			t.Fatalf("expected content filter failure to be non-retriable: %s", body)
+		}
+		if got.Scope != scheduler.FailureScopeRequest {
+			t.Fatalf("scope=%q want=%q", got.Scope, scheduler.FailureScopeRequest)
+		}
+		if got.ErrorClass != "upstream_content_filter" {
+			t.Fatalf("error_class=%q want=%q", got.ErrorClass, "upstream_content_filter")
+		}
+	}
+}
+
 func TestClassifyUpstreamHTTPFailure_RouteMissingStaysChannelScoped(t *testing.T) {
 	body := []byte(`{"error":{"message":"route missing"}}`)
 	got := classifyUpstreamHTTPFailure(http.StatusNotFound, body, 101, codexOAuthUpstreamErr{})
@@ -564,6 +582,10 @@ type fakeStore struct {
 	endpoints      map[int64][]store.UpstreamEndpoint
 	creds          map[int64][]store.OpenAICompatibleCredential
 	anthropicCreds map[int64][]store.AnthropicCredential
+	geminiCreds    map[int64][]store.GeminiCredential
+	azureCreds     map[int64][]store.AzureOpenAICredential
+	bedrockCreds   map[int64][]store.BedrockCredential
+	vertexCreds    map[int64][]store.VertexCredential
 	accounts       map[int64][]store.CodexOAuthAccount
 	models         map[string]store.ManagedModel
 	bindings       map[string][]store.ChannelModelBinding
@@ -611,6 +633,34 @@ func (f *fakeStore) ListAnthropicCredentialsByEndpoint(_ context.Context, endpoi
 	return f.anthropicCreds[endpointID], nil
 }
 
+func (f *fakeStore) ListGeminiCredentialsByEndpoint(_ context.Context, endpointID int64) ([]store.GeminiCredential, error) {
+	if f.geminiCreds == nil {
+		return nil, nil
+	}
+	return f.geminiCreds[endpointID], nil
+}
+
+func (f *fakeStore) ListAzureOpenAICredentialsByEndpoint(_ context.Context, endpointID int64) ([]store.AzureOpenAICredential, error) {
+	if f.azureCreds == nil {
+		return nil, nil
+	}
+	return f.azureCreds[endpointID], nil
+}
+
+func (f *fakeStore) ListBedrockCredentialsByEndpoint(_ context.Context, endpointID int64) ([]store.BedrockCredential, error) {
+	if f.bedrockCreds == nil {
+		return nil, nil
+	}
+	return f.bedrockCreds[endpointID], nil
+}
+
+func (f *fakeStore) ListVertexCredentialsByEndpoint(_ context.Context, endpointID int64) ([]store.VertexCredential, error) {
+	if f.vertexCreds == nil {
+		return nil, nil
+	}
+	return f.vertexCreds[endpointID], nil
+}
+
 func (f *fakeStore) ListCodexOAuthAccountsByEndpoint(_ context.Context, endpointID int64) ([]store.CodexOAuthAccount, error) {
 	return f.accounts[endpointID], nil
 }
@@ -5175,3 +5225,34 @@ func TestGeminiProxy_UserConcurrencyQueueFullReturnsGeminiErrorShape(t *testing.
 		t.Fatalf("expected error.status=RESOURCE_EXHAUSTED, got body=%s", rr.Body.String())
 	}
 }
+
+func TestCopyResponseHeaders_RateLimitHeadersPassThroughUnlessGatewayLimited(t *testing.T) {
+	src := http.Header{}
+	src.Set("x-ratelimit-limit-requests", "500")
+	src.Set("anthropic-ratelimit-tokens-limit", "80000")
+	src.Set("Content-Type", "application/json")
+
+	// 未命中网关限流策略：上游限流头原样透传。
+	dst := http.Header{}
+	copyResponseHeaders(dst, src)
+	if got := dst.Get("x-ratelimit-limit-requests"); got != "500" {
+		t.Fatalf("expected upstream x-ratelimit-limit-requests=500, got %q", got)
+	}
+	if got := dst.Get("anthropic-ratelimit-tokens-limit"); got != "80000" {
+		t.Fatalf("expected upstream anthropic-ratelimit-tokens-limit=80000, got %q", got)
+	}
+
+	// 网关已写入限流头：丢弃上游限流头，其余头照常透传。
+	dst = http.Header{}
+	dst.Set("x-ratelimit-limit-requests", "2")
+	copyResponseHeaders(dst, src)
+	if got := dst.Values("x-ratelimit-limit-requests"); len(got) != 1 || got[0] != "2" {
+		t.Fatalf("expected gateway x-ratelimit-limit-requests=2 only, got %v", got)
+	}
+	if got := dst.Get("anthropic-ratelimit-tokens-limit"); got != "" {
+		t.Fatalf("expected upstream anthropic rate limit header dropped, got %q", got)
+	}
+	if got := dst.Get("Content-Type"); got != "application/json" {
+		t.Fatalf("expected Content-Type passthrough, got %q", got)
+	}
+}
@@ -13,7 +13,6 @@ import (
 	"realms/internal/middleware"
 	"realms/internal/quota"
 	"realms/internal/scheduler"
-	"realms/internal/store"
 )
 
 // Messages 提供 Anthropic Messages 兼容入口：POST /v1/messages。
@@ -74,7 +73,7 @@ func (h *Handler) proxyMessagesJSON(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var cons scheduler.Constraints
-	cons.RequireChannelType = store.UpstreamTypeAnthropic
+	// 渠道类型不做硬限制：anthropic 原生支持；openai_compatible 渠道在 setting 开启 messages 后经协议转换参与选路。
 	cons.RequireAPI = scheduler.RequiredAPIMessages
 	ags := allowGroupsFromPrincipal(p)
 	allowSet := ags.Set
@@ -122,6 +121,9 @@ func (h *Handler) proxyMessagesJSON(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 		rewriteBody = func(sel scheduler.Selection) ([]byte, error) {
+			if messagesTranslatedToOpenAI(sel) {
+				return rewriteMessagesForOpenAI(payload, sel, publicModel, resolvedBindings.UpstreamModel(sel.ChannelID, publicModel), r.URL.Path)
+			}
 			if sel.PassThroughBodyEnabled {
 				return rawBody, nil
 			}
@@ -187,13 +189,16 @@ func (h *Handler) proxyMessagesJSON(w http.ResponseWriter, r *http.Request) {
 		resolvedBindings.ApplyToConstraints(&cons)
 
 		rewriteBody = func(sel scheduler.Selection) ([]byte, error) {
-			if sel.PassThroughBodyEnabled {
+			if sel.PassThroughBodyEnabled && !messagesTranslatedToOpenAI(sel) {
 				return rawBody, nil
 			}
 			up := resolvedBindings.UpstreamModel(sel.ChannelID, "")
 			if strings.TrimSpace(up) == "" {
 				return nil, errors.New("选中渠道未配置该模型")
 			}
+			if messagesTranslatedToOpenAI(sel) {
+				return rewriteMessagesForOpenAI(payload, sel, publicModel, up, r.URL.Path)
+			}
 			out := clonePayload(payload)
 			out["model"] = up
 			applyChannelSystemPromptToMessagesPayload(out, sel)
@@ -258,6 +263,9 @@ func (h *Handler) proxyMessagesJSON(w http.ResponseWriter, r *http.Request) {
 			MaxOutputTokens: maxOut,
 		})
 		if err != nil {
+			if writeTokenLimitReserveError(w, err) {
+				return
+			}
 			if msg := reserveBadRequestMessage(err); msg != "" {
 				writeAnthropicError(w, http.StatusBadRequest, msg)
 				return
@@ -339,7 +347,11 @@ func (h *Handler) proxyMessagesJSON(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		bindingID := resolvedBindings.BindingID(sel.ChannelID)
-		if h.tryWithSelection(w, r, p, sel, rewritten, stream, optionalString(publicModel), extractTopLevelModel(rewritten), bindingID, usageID, reqStart, reqBytes, loopStart, 1, &bestFailure) {
+		attemptReq := r
+		if messagesTranslatedToOpenAI(sel) {
+			attemptReq = r.WithContext(withUpstreamProtocolAdapter(r.Context(), messagesAdapterForSelection(sel)))
+		}
+		if h.tryWithSelection(w, attemptReq, p, sel, rewritten, stream, optionalString(publicModel), extractTopLevelModel(rewritten), bindingID, usageID, reqStart, reqBytes, loopStart, 1, &bestFailure) {
 			return
 		}
 		switches++
@@ -409,3 +421,48 @@ func anthropicErrorTypeForStatus(status int) string {
 		return "invalid_request_error"
 	}
 }
+
+// messagesUseChatUpstream 判断 openai_compatible 渠道承接 /v1/messages 时的转换目标：
+// 优先 chat/completions，仅启用 responses 的渠道转换为 Responses 请求。
+func messagesUseChatUpstream(sel scheduler.Selection) bool {
+	return scheduler.SupportsRequiredAPI(sel, scheduler.RequiredAPIChatCompletions)
+}
+
+func messagesAdapterForSelection(sel scheduler.Selection) *upstreamProtocolAdapter {
+	if messagesUseChatUpstream(sel) {
+		return newMessagesToChatAdapter()
+	}
+	return newMessagesToResponsesAdapter()
+}
+
+// rewriteMessagesForOpenAI 在选中 openai_compatible 渠道时把 Messages 请求转换为 chat/completions 或 Responses 请求体；
+// 协议不同，不支持 pass_through_body（始终按转换结果转发）。
+func rewriteMessagesForOpenAI(payload map[string]any, sel scheduler.Selection, publicModel string, upstreamModel string, path string) ([]byte, error) {
+	out := clonePayload(payload)
+	out["model"] = upstreamModel
+	applyChannelSystemPromptToMessagesPayload(out, sel)
+	var converted map[string]any
+	var err error
+	if messagesUseChatUpstream(sel) {
+		converted, err = anthropicMessagesPayloadToChat(out)
+	} else {
+		converted, err = anthropicMessagesPayloadToResponses(out)
+	}
+	if err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(converted)
+	if err != nil {
+		return nil, err
+	}
+	raw, err = applyChannelRequestPolicy(raw, sel)
+	if err != nil {
+		return nil, err
+	}
+	raw, err = applyChannelBodyFilters(raw, sel)
+	if err != nil {
+		return nil, err
+	}
+	ctx := buildParamOverrideContext(sel, publicModel, upstreamModel, path)
+	return applyChannelParamOverride(raw, sel, ctx)
+}
@@ -0,0 +1,940 @@
+package openai
+
+import (
+	"encoding/json"
+	"strings"
+
+	"realms/internal/upstream"
+)
+
+// Anthropic Messages -> OpenAI（chat/completions 或 Responses）协议转换：
+// - 请求：system/messages/tool_use/tool_result/images/thinking 映射到上游结构；cache_control 无对应语义，直接丢弃
+// - 响应：chat.completion / response JSON 与 SSE 转换回 Messages 结构与事件流（message_start ... message_stop）
+// - usage：input_tokens 不含缓存命中部分，缓存命中记入 cache_read_input_tokens（与 Anthropic 口径一致）
+
+// anthropicThinkingBudgetToEffort 把 thinking.budget_tokens 粗略映射为 reasoning effort。
+func anthropicThinkingBudgetToEffort(thinking any) string {
+	m, ok := thinking.(map[string]any)
+	if !ok || stringFromAny(m["type"]) != "enabled" {
+		return ""
+	}
+	budget := int64(0)
+	if v := intFromAny(m["budget_tokens"]); v != nil {
+		budget = *v
+	}
+	switch {
+	case budget <= 4096:
+		return "low"
+	case budget <= 16384:
+		return "medium"
+	default:
+		return "high"
+	}
+}
+
+// anthropicSystemToText 把 system（string 或 text blocks）拼接为纯文本。
+func anthropicSystemToText(system any) string {
+	switch s := system.(type) {
+	case string:
+		return s
+	case []any:
+		parts := make([]string, 0, len(s))
+		for _, item := range s {
+			block, ok := item.(map[string]any)
+			if !ok || stringFromAny(block["type"]) != "text" {
+				continue
+			}
+			if text := stringFromAny(block["text"]); text != "" {
+				parts = append(parts, text)
+			}
+		}
+		return strings.Join(parts, "\n\n")
+	default:
+		return ""
+	}
+}
+
+// anthropicContentBlocks 统一 content（string 或 blocks）为 blocks。
+func anthropicContentBlocks(content any) []map[string]any {
+	switch c := content.(type) {
+	case string:
+		if c == "" {
+			return nil
+		}
+		return []map[string]any{{"type": "text", "text": c}}
+	case []any:
+		out := make([]map[string]any, 0, len(c))
+		for _, item := range c {
+			if block, ok := item.(map[string]any); ok {
+				out = append(out, block)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+// anthropicImageBlockToURL 把 image block 转为 URL（base64 source 转 data URL）。
+func anthropicImageBlockToURL(block map[string]any) string {
+	src, _ := block["source"].(map[string]any)
+	switch stringFromAny(src["type"]) {
+	case "base64":
+		mediaType := strings.TrimSpace(stringFromAny(src["media_type"]))
+		data := stringFromAny(src["data"])
+		if mediaType == "" || data == "" {
+			return ""
+		}
+		return "data:" + mediaType + ";base64," + data
+	case "url":
+		return strings.TrimSpace(stringFromAny(src["url"]))
+	default:
+		return ""
+	}
+}
+
+// anthropicToolResultText 把 tool_result.content（string 或 text blocks）拼接为纯文本。
+func anthropicToolResultText(content any) string {
+	if s, ok := content.(string); ok {
+		return s
+	}
+	var sb strings.Builder
+	for _, block := range anthropicContentBlocks(content) {
+		if stringFromAny(block["type"]) == "text" {
+			sb.WriteString(stringFromAny(block["text"]))
+		}
+	}
+	return sb.String()
+}
+
+func anthropicToolInputToArguments(input any) string {
+	if input == nil {
+		return "{}"
+	}
+	b, err := json.Marshal(input)
+	if err != nil || string(b) == "null" {
+		return "{}"
+	}
+	return string(b)
+}
+
+// anthropicClientTools 过滤出客户端自定义工具（server tools 如 web_search 无法映射到 OpenAI 上游）。
+func anthropicClientTools(tools any) []map[string]any {
+	list, _ := tools.([]any)
+	out := make([]map[string]any, 0, len(list))
+	for _, item := range list {
+		tool, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		if typ := stringFromAny(tool["type"]); typ != "" && typ != "custom" {
+			continue
+		}
+		if strings.TrimSpace(stringFromAny(tool["name"])) == "" {
+			continue
+		}
+		out = append(out, tool)
+	}
+	return out
+}
+
+func anthropicMessagesPayloadToChat(payload map[string]any) (map[string]any, error) {
+	out := make(map[string]any, 12)
+	out["model"] = payload["model"]
+
+	var messages []any
+	if system := anthropicSystemToText(payload["system"]); system != "" {
+		messages = append(messages, map[string]any{"role": "system", "content": system})
+	}
+
+	rawMessages, _ := payload["messages"].([]any)
+	for _, item := range rawMessages {
+		msg, ok := item.(map[string]any)
+		if !ok {
+			return nil, errInvalidJSON
+		}
+		role := strings.TrimSpace(stringFromAny(msg["role"]))
+		blocks := anthropicContentBlocks(msg["content"])
+		switch role {
+		case "user":
+			// tool_result 需紧跟 assistant tool_calls，转换为独立的 tool 消息并放在 user 内容之前。
+			var parts []any
+			for _, block := range blocks {
+				switch stringFromAny(block["type"]) {
+				case "text":
+					parts = append(parts, map[string]any{"type": "text", "text": stringFromAny(block["text"])})
+				case "image":
+					if url := anthropicImageBlockToURL(block); url != "" {
+						parts = append(parts, map[string]any{"type": "image_url", "image_url": map[string]any{"url": url}})
+					}
+				case "tool_result":
+					messages = append(messages, map[string]any{
+						"role":         "tool",
+						"tool_call_id": stringFromAny(block["tool_use_id"]),
+						"content":      anthropicToolResultText(block["content"]),
+					})
+				}
+			}
+			if len(parts) > 0 {
+				messages = append(messages, map[string]any{"role": "user", "content": parts})
+			}
+		case "assistant":
+			var text strings.Builder
+			var toolCalls []any
+			for _, block := range blocks {
+				switch stringFromAny(block["type"]) {
+				case "text":
+					text.WriteString(stringFromAny(block["text"]))
+				case "tool_use":
+					toolCalls = append(toolCalls, map[string]any{
+						"id":   stringFromAny(block["id"]),
+						"type": "function",
+						"function": map[string]any{
+							"name":      stringFromAny(block["name"]),
+							"arguments": anthropicToolInputToArguments(block["input"]),
+						},
+					})
+				}
+			}
+			if text.Len() == 0 && len(toolCalls) == 0 {
+				continue
+			}
+			m := map[string]any{"role": "assistant", "content": nil}
+			if text.Len() > 0 {
+				m["content"] = text.String()
+			}
+			if len(toolCalls) > 0 {
+				m["tool_calls"] = toolCalls
+			}
+			messages = append(messages, m)
+		default:
+			return nil, errChatTranslateUnsupportedRole
+		}
+	}
+	out["messages"] = messages
+
+	if v, ok := payload["max_tokens"]; ok {
+		out["max_tokens"] = v
+	}
+	for _, key := range []string{"temperature", "top_p"} {
+		if v, ok := payload[key]; ok {
+			out[key] = v
+		}
+	}
+	if boolFromAny(payload["stream"]) {
+		out["stream"] = true
+		// 计费依赖流末尾的 usage chunk。
+		out["stream_options"] = map[string]any{"include_usage": true}
+	}
+	if stops, ok := payload["stop_sequences"].([]any); ok && len(stops) > 0 {
+		out["stop"] = stops
+	}
+	if md, ok := payload["metadata"].(map[string]any); ok {
+		if uid := strings.TrimSpace(stringFromAny(md["user_id"])); uid != "" {
+			out["user"] = uid
+		}
+	}
+	if effort := anthropicThinkingBudgetToEffort(payload["thinking"]); effort != "" {
+		out["reasoning_effort"] = effort
+	}
+
+	if tools := anthropicClientTools(payload["tools"]); len(tools) > 0 {
+		list := make([]any, 0, len(tools))
+		for _, tool := range tools {
+			fn := map[string]any{"name": stringFromAny(tool["name"])}
+			if desc := stringFromAny(tool["description"]); desc != "" {
+				fn["description"] = desc
+			}
+			if schema, ok := tool["input_schema"]; ok {
+				fn["parameters"] = schema
+			}
+			list = append(list, map[string]any{"type": "function", "function": fn})
+		}
+		out["tools"] = list
+		choice, parallel := anthropicToolChoiceToOpenAI(payload["tool_choice"], false)
+		if choice != nil {
+			out["tool_choice"] = choice
+		}
+		if parallel != nil {
+			out["parallel_tool_calls"] = *parallel
+		}
+	}
+	return out, nil
+}
+
+func anthropicMessagesPayloadToResponses(payload map[string]any) (map[string]any, error) {
+	out := make(map[string]any, 12)
+	out["model"] = payload["model"]
+	if system := anthropicSystemToText(payload["system"]); system != "" {
+		out["instructions"] = system
+	}
+
+	var input []any
+	rawMessages, _ := payload["messages"].([]any)
+	for _, item := range rawMessages {
+		msg, ok := item.(map[string]any)
+		if !ok {
+			return nil, errInvalidJSON
+		}
+		role := strings.TrimSpace(stringFromAny(msg["role"]))
+		blocks := anthropicContentBlocks(msg["content"])
+		switch role {
+		case "user":
+			var parts []any
+			for _, block := range blocks {
+				switch stringFromAny(block["type"]) {
+				case "text":
+					parts = append(parts, map[string]any{"type": "input_text", "text": stringFromAny(block["text"])})
+				case "image":
+					if url := anthropicImageBlockToURL(block); url != "" {
+						parts = append(parts, map[string]any{"type": "input_image", "image_url": url})
+					}
+				case "tool_result":
+					input = append(input, map[string]any{
+						"type":    "function_call_output",
+						"call_id": stringFromAny(block["tool_use_id"]),
+						"output":  anthropicToolResultText(block["content"]),
+					})
+				}
+			}
+			if len(parts) > 0 {
+				input = append(input, map[string]any{"type": "message", "role": "user", "content": parts})
+			}
+		case "assistant":
+			for _, block := range blocks {
+				switch stringFromAny(block["type"]) {
+				case "text":
+					if text := stringFromAny(block["text"]); text != "" {
+						input = append(input, map[string]any{
+							"type":    "message",
+							"role":    "assistant",
+							"content": []any{map[string]any{"type": "output_text", "text": text}},
+						})
+					}
+				case "tool_use":
+					input = append(input, map[string]any{
+						"type":      "function_call",
+						"call_id":   stringFromAny(block["id"]),
+						"name":      stringFromAny(block["name"]),
+						"arguments": anthropicToolInputToArguments(block["input"]),
+					})
+				}
+			}
+		default:
+			return nil, errChatTranslateUnsupportedRole
+		}
+	}
+	out["input"] = input
+
+	if v, ok := payload["max_tokens"]; ok {
+		out["max_output_tokens"] = v
+	}
+	for _, key := range []string{"temperature", "top_p"} {
+		if v, ok := payload[key]; ok {
+			out[key] = v
+		}
+	}
+	if boolFromAny(payload["stream"]) {
+		out["stream"] = true
+	}
+	if effort := anthropicThinkingBudgetToEffort(payload["thinking"]); effort != "" {
+		out["reasoning"] = map[string]any{"effort": effort, "summary": "auto"}
+	}
+
+	if tools := anthropicClientTools(payload["tools"]); len(tools) > 0 {
+		list := make([]any, 0, len(tools))
+		for _, tool := range tools {
+			t := map[string]any{"type": "function", "name": stringFromAny(tool["name"])}
+			if desc := stringFromAny(tool["description"]); desc != "" {
+				t["description"] = desc
+			}
+			if schema, ok := tool["input_schema"]; ok {
+				t["parameters"] = schema
+			}
+			list = append(list, t)
+		}
+		out["tools"] = list
+		choice, parallel := anthropicToolChoiceToOpenAI(payload["tool_choice"], true)
+		if choice != nil {
+			out["tool_choice"] = choice
+		}
+		if parallel != nil {
+			out["parallel_tool_calls"] = *parallel
+		}
+	}
+	return out, nil
+}
+
+// anthropicToolChoiceToOpenAI 映射 tool_choice；responsesShape=true 时指定工具使用 Responses 的扁平结构。
+func anthropicToolChoiceToOpenAI(v any, responsesShape bool) (any, *bool) {
+	tc, ok := v.(map[string]any)
+	if !ok {
+		return nil, nil
+	}
+	var parallel *bool
+	if disable, ok := tc["disable_parallel_tool_use"].(bool); ok && disable {
+		f := false
+		parallel = &f
+	}
+	switch stringFromAny(tc["type"]) {
+	case "auto":
+		return "auto", parallel
+	case "any":
+		return "required", parallel
+	case "none":
+		return "none", parallel
+	case "tool":
+		name := strings.TrimSpace(stringFromAny(tc["name"]))
+		if name == "" {
+			return nil, parallel
+		}
+		if responsesShape {
+			return map[string]any{"type": "function", "name": name}, parallel
+		}
+		return map[string]any{"type": "function", "function": map[string]any{"name": name}}, parallel
+	default:
+		return nil, parallel
+	}
+}
+
+func chatFinishReasonToAnthropicStopReason(reason string) string {
+	switch strings.TrimSpace(reason) {
+	case "length":
+		return "max_tokens"
+	case "tool_calls", "function_call":
+		return "tool_use"
+	case "content_filter":
+		return "refusal"
+	default:
+		return "end_turn"
+	}
+}
+
+func anthropicMessageIDFromOpenAI(id string) string {
+	id = strings.TrimSpace(id)
+	for _, prefix := range []string{"chatcmpl-", "resp_"} {
+		id = strings.TrimPrefix(id, prefix)
+	}
+	if id == "" {
+		return "msg_realms"
+	}
+	return "msg_" + id
+}
+
+// openAIUsageToAnthropic 把 chat/responses usage 转换为 Anthropic usage（input_tokens 不含缓存命中部分）。
+func openAIUsageToAnthropic(usage map[string]any) map[string]any {
+	in, out, cachedIn, _ := extractUsageTokensFromUsageMap(usage)
+	input, output, cached := int64(0), int64(0), int64(0)
+	if in != nil {
+		input = *in
+	}
+	if out != nil {
+		output = *out
+	}
+	if cachedIn != nil {
+		cached = *cachedIn
+	}
+	if cached > input {
+		cached = input
+	}
+	return map[string]any{
+		"input_tokens":                input - cached,
+		"output_tokens":               output,
+		"cache_read_input_tokens":     cached,
+		"cache_creation_input_tokens": int64(0),
+	}
+}
+
+func anthropicMessageEnvelope(id string, model string, content []any, stopReason any, usage map[string]any) map[string]any {
+	return map[string]any{
+		"id":            id,
+		"type":          "message",
+		"role":          "assistant",
+		"model":         model,
+		"content":       content,
+		"stop_reason":   stopReason,
+		"stop_sequence": nil,
+		"usage":         usage,
+	}
+}
+
+func chatCompletionToAnthropicMessage(body []byte) ([]byte, error) {
+	var root map[string]any
+	if err := json.Unmarshal(body, &root); err != nil {
+		return nil, err
+	}
+	choices, _ := root["choices"].([]any)
+	if len(choices) == 0 {
+		return nil, errInvalidJSON
+	}
+	choice, _ := choices[0].(map[string]any)
+	msg, _ := choice["message"].(map[string]any)
+
+	content := make([]any, 0, 4)
+	reasoning := stringFromAny(msg["reasoning_content"])
+	if reasoning == "" {
+		reasoning = stringFromAny(msg["reasoning"])
+	}
+	if reasoning != "" {
+		content = append(content, map[string]any{"type": "thinking", "thinking": reasoning, "signature": ""})
+	}
+	if text := stringFromAny(msg["content"]); text != "" {
+		content = append(content, map[string]any{"type": "text", "text": text})
+	}
+	calls, _ := msg["tool_calls"].([]any)
+	for _, c := range calls {
+		call, ok := c.(map[string]any)
+		if !ok {
+			continue
+		}
+		fn, _ := call["function"].(map[string]any)
+		content = append(content, map[string]any{
+			"type":  "tool_use",
+			"id":    stringFromAny(call["id"]),
+			"name":  stringFromAny(fn["name"]),
+			"input": parseToolArguments(stringFromAny(fn["arguments"])),
+		})
+	}
+
+	usage, _ := root["usage"].(map[string]any)
+	return json.Marshal(anthropicMessageEnvelope(
+		anthropicMessageIDFromOpenAI(stringFromAny(root["id"])),
+		stringFromAny(root["model"]),
+		content,
+		chatFinishReasonToAnthropicStopReason(stringFromAny(choice["finish_reason"])),
+		openAIUsageToAnthropic(usage),
+	))
+}
+
+func responsesToAnthropicMessage(body []byte) ([]byte, error) {
+	var root map[string]any
+	if err := json.Unmarshal(body, &root); err != nil {
+		return nil, err
+	}
+	if _, ok := root["output"]; !ok {
+		return nil, errInvalidJSON
+	}
+	content, hasToolUse := responsesOutputToAnthropicContent(root["output"])
+	usage, _ := root["usage"].(map[string]any)
+	return json.Marshal(anthropicMessageEnvelope(
+		anthropicMessageIDFromOpenAI(stringFromAny(root["id"])),
+		stringFromAny(root["model"]),
+		content,
+		responsesStopReason(root, hasToolUse),
+		openAIUsageToAnthropic(usage),
+	))
+}
+
+func responsesOutputToAnthropicContent(output any) ([]any, bool) {
+	items, _ := output.([]any)
+	content := make([]any, 0, len(items))
+	hasToolUse := false
+	for _, it := range items {
+		item, ok := it.(map[string]any)
+		if !ok {
+			continue
+		}
+		switch stringFromAny(item["type"]) {
+		case "reasoning":
+			var sb strings.Builder
+			summary, _ := item["summary"].([]any)
+			for _, s := range summary {
+				if part, ok := s.(map[string]any); ok {
+					sb.WriteString(stringFromAny(part["text"]))
+				}
+			}
+			if sb.Len() > 0 {
+				content = append(content, map[string]any{"type": "thinking", "thinking": sb.String(), "signature": ""})
+			}
+		case "message":
+			parts, _ := item["content"].([]any)
+			for _, p := range parts {
+				part, ok := p.(map[string]any)
+				if !ok {
+					continue
+				}
+				switch stringFromAny(part["type"]) {
+				case "output_text":
+					if text := stringFromAny(part["text"]); text != "" {
+						content = append(content, map[string]any{"type": "text", "text": text})
+					}
+				case "refusal":
+					if text := stringFromAny(part["refusal"]); text != "" {
+						content = append(content, map[string]any{"type": "text", "text": text})
+					}
+				}
+			}
+		case "function_call":
+			hasToolUse = true
+			content = append(content, map[string]any{
+				"type":  "tool_use",
+				"id":    stringFromAny(item["call_id"]),
+				"name":  stringFromAny(item["name"]),
+				"input": parseToolArguments(stringFromAny(item["arguments"])),
+			})
+		}
+	}
+	return content, hasToolUse
+}
+
+func responsesStopReason(resp map[string]any, hasToolUse bool) string {
+	if stringFromAny(resp["status"]) == "incomplete" {
+		details, _ := resp["incomplete_details"].(map[string]any)
+		switch stringFromAny(details["reason"]) {
+		case "max_output_tokens":
+			return "max_tokens"
+		case "content_filter":
+			return "refusal"
+		}
+	}
+	if hasToolUse {
+		return "tool_use"
+	}
+	return "end_turn"
+}
+
+func parseToolArguments(args string) map[string]any {
+	out := map[string]any{}
+	if strings.TrimSpace(args) == "" {
+		return out
+	}
+	var parsed map[string]any
+	if err := json.Unmarshal([]byte(args), &parsed); err == nil && parsed != nil {
+		return parsed
+	}
+	return out
+}
+
+// openAIErrorToAnthropicError 把 OpenAI 错误体（{"error":{...}}）改写为 Anthropic 错误格式；
+// 无法识别时原样返回。
+func openAIErrorToAnthropicError(body []byte) []byte {
+	var root map[string]any
+	if err := json.Unmarshal(body, &root); err != nil {
+		return body
+	}
+	errObj, ok := root["error"].(map[string]any)
+	if !ok {
+		return body
+	}
+	out, err := json.Marshal(map[string]any{
+		"type": "error",
+		"error": map[string]any{
+			"type":    openAIErrorTypeToAnthropic(stringFromAny(errObj["type"])),
+			"message": stringFromAny(errObj["message"]),
+		},
+	})
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+func openAIErrorTypeToAnthropic(typ string) string {
+	switch strings.TrimSpace(typ) {
+	case "invalid_request_error", "authentication_error", "permission_error", "not_found_error", "rate_limit_error", "overloaded_error":
+		return typ
+	case "insufficient_quota":
+		return "rate_limit_error"
+	default:
+		return "api_error"
+	}
+}
+
+// anthropicStreamEmitter 负责按 Anthropic 事件语义合成 SSE：维护 message_start 与 content block 的开闭。
+type anthropicStreamEmitter struct {
+	started bool
+	stopped bool
+
+	id    string
+	model string
+
+	blockIndex int
+	blockType  string
+	blockOpen  bool
+	hasToolUse bool
+
+	stopReason string
+	usage      map[string]any
+}
+
+func (e *anthropicStreamEmitter) event(name string, payload map[string]any) upstream.SSEEvent {
+	b, _ := json.Marshal(payload)
+	return upstream.SSEEvent{Event: name, Data: string(b)}
+}
+
+func (e *anthropicStreamEmitter) start() []upstream.SSEEvent {
+	if e.started {
+		return nil
+	}
+	e.started = true
+	if e.id == "" {
+		e.id = "msg_realms"
+	}
+	msg := anthropicMessageEnvelope(e.id, e.model, []any{}, nil, map[string]any{"input_tokens": 0, "output_tokens": 0})
+	return []upstream.SSEEvent{e.event("message_start", map[string]any{"type": "message_start", "message": msg})}
+}
+
+func (e *anthropicStreamEmitter) closeBlock() []upstream.SSEEvent {
+	if !e.blockOpen {
+		return nil
+	}
+	e.blockOpen = false
+	ev := e.event("content_block_stop", map[string]any{"type": "content_block_stop", "index": e.blockIndex})
+	e.blockIndex++
+	return []upstream.SSEEvent{ev}
+}
+
+// openBlock 打开新的 content block（会先关闭当前 block）。
+func (e *anthropicStreamEmitter) openBlock(block map[string]any) []upstream.SSEEvent {
+	outs := e.start()
+	outs = append(outs, e.closeBlock()...)
+	e.blockOpen = true
+	e.blockType = stringFromAny(block["type"])
+	if e.blockType == "tool_use" {
+		e.hasToolUse = true
+	}
+	return append(outs, e.event("content_block_start", map[string]any{"type": "content_block_start", "index": e.blockIndex, "content_block": block}))
+}
+
+func (e *anthropicStreamEmitter) textDelta(text string) []upstream.SSEEvent {
+	if text == "" {
+		return nil
+	}
+	var outs []upstream.SSEEvent
+	if !e.blockOpen || e.blockType != "text" {
+		outs = e.openBlock(map[string]any{"type": "text", "text": ""})
+	}
+	return append(outs, e.event("content_block_delta", map[string]any{"type": "content_block_delta", "index": e.blockIndex, "delta": map[string]any{"type": "text_delta", "text": text}}))
+}
+
+func (e *anthropicStreamEmitter) thinkingDelta(text string) []upstream.SSEEvent {
+	if text == "" {
+		return nil
+	}
+	var outs []upstream.SSEEvent
+	if !e.blockOpen || e.blockType != "thinking" {
+		outs = e.openBlock(map[string]any{"type": "thinking", "thinking": "", "signature": ""})
+	}
+	return append(outs, e.event("content_block_delta", map[string]any{"type": "content_block_delta", "index": e.blockIndex, "delta": map[string]any{"type": "thinking_delta", "thinking": text}}))
+}
+
+func (e *anthropicStreamEmitter) toolArgumentsDelta(partial string) []upstream.SSEEvent {
+	if partial == "" || !e.blockOpen || e.blockType != "tool_use" {
+		return nil
+	}
+	return []upstream.SSEEvent{e.event("content_block_delta", map[string]any{"type": "content_block_delta", "index": e.blockIndex, "delta": map[string]any{"type": "input_json_delta", "partial_json": partial}})}
+}
+
+// finish 关闭当前 block 并输出 message_delta（携带最终 usage）与 message_stop。
+func (e *anthropicStreamEmitter) finish() []upstream.SSEEvent {
+	if e.stopped {
+		return nil
+	}
+	outs := e.start()
+	outs = append(outs, e.closeBlock()...)
+	e.stopped = true
+	stopReason := e.stopReason
+	if stopReason == "" {
+		stopReason = "end_turn"
+		if e.hasToolUse {
+			stopReason = "tool_use"
+		}
+	}
+	usage := e.usage
+	if usage == nil {
+		usage = map[string]any{"output_tokens": 0}
+	}
+	outs = append(outs, e.event("message_delta", map[string]any{
+		"type":  "message_delta",
+		"delta": map[string]any{"stop_reason": stopReason, "stop_sequence": nil},
+		"usage": usage,
+	}))
+	return append(outs, e.event("message_stop", map[string]any{"type": "message_stop"}))
+}
+
+func (e *anthropicStreamEmitter) errorEvent(errObj map[string]any) []upstream.SSEEvent {
+	return []upstream.SSEEvent{e.event("error", map[string]any{
+		"type": "error",
+		"error": map[string]any{
+			"type":    openAIErrorTypeToAnthropic(stringFromAny(errObj["type"])),
+			"message": stringFromAny(errObj["message"]),
+		},
+	})}
+}
+
+type chatToAnthropicStreamState struct {
+	emitter anthropicStreamEmitter
+	// toolCallIndex 记录当前打开的 tool_use block 对应的 chat tool_calls[].index。
+	toolCallIndex int64
+}
+
+// newChatToAnthropicMessagesStreamTransformer 返回 upstream.SSEPumpHooks.TransformData：
+// 把 chat.completion.chunk 流转换为 Anthropic Messages SSE 事件流。
+// usage chunk 在 finish_reason 之后到达，因此 message_delta/message_stop 延迟到 [DONE] 时输出。
+func newChatToAnthropicMessagesStreamTransformer() func(event string, data string) ([]upstream.SSEEvent, bool, error) {
+	st := &chatToAnthropicStreamState{toolCallIndex: -1}
+	return st.transform
+}
+
+func (st *chatToAnthropicStreamState) transform(_ string, data string) ([]upstream.SSEEvent, bool, error) {
+	e := &st.emitter
+	data = strings.TrimSpace(data)
+	if data == "[DONE]" {
+		return e.finish(), true, nil
+	}
+	var chunk map[string]any
+	if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+		return nil, true, nil
+	}
+	if errObj, ok := chunk["error"].(map[string]any); ok {
+		return e.errorEvent(errObj), true, nil
+	}
+	if e.id == "" {
+		e.id = anthropicMessageIDFromOpenAI(stringFromAny(chunk["id"]))
+	}
+	if e.model == "" {
+		e.model = stringFromAny(chunk["model"])
+	}
+	outs := e.start()
+	if usage, ok := chunk["usage"].(map[string]any); ok {
+		e.usage = openAIUsageToAnthropic(usage)
+	}
+	choices, _ := chunk["choices"].([]any)
+	for _, c := range choices {
+		choice, ok := c.(map[string]any)
+		if !ok {
+			continue
+		}
+		delta, _ := choice["delta"].(map[string]any)
+		reasoning := stringFromAny(delta["reasoning_content"])
+		if reasoning == "" {
+			reasoning = stringFromAny(delta["reasoning"])
+		}
+		outs = append(outs, e.thinkingDelta(reasoning)...)
+		outs = append(outs, e.textDelta(stringFromAny(delta["content"]))...)
+		calls, _ := delta["tool_calls"].([]any)
+		for _, tc := range calls {
+			call, ok := tc.(map[string]any)
+			if !ok {
+				continue
+			}
+			idx := int64(0)
+			if v := intFromAny(call["index"]); v != nil {
+				idx = *v
+			}
+			fn, _ := call["function"].(map[string]any)
+			if idx != st.toolCallIndex || !e.blockOpen || e.blockType != "tool_use" {
+				st.toolCallIndex = idx
+				outs = append(outs, e.openBlock(map[string]any{
+					"type":  "tool_use",
+					"id":    stringFromAny(call["id"]),
+					"name":  stringFromAny(fn["name"]),
+					"input": map[string]any{},
+				})...)
+			}
+			outs = append(outs, e.toolArgumentsDelta(stringFromAny(fn["arguments"]))...)
+		}
+		if reason := stringFromAny(choice["finish_reason"]); reason != "" {
+			e.stopReason = chatFinishReasonToAnthropicStopReason(reason)
+		}
+	}
+	return outs, true, nil
+}
+
+type responsesToAnthropicStreamState struct {
+	emitter anthropicStreamEmitter
+}
+
+// newResponsesToAnthropicMessagesStreamTransformer 返回 upstream.SSEPumpHooks.TransformData：
+// 把 Responses SSE（response.*）事件流转换为 Anthropic Messages SSE 事件流。
+func newResponsesToAnthropicMessagesStreamTransformer() func(event string, data string) ([]upstream.SSEEvent, bool, error) {
+	st := &responsesToAnthropicStreamState{}
+	return st.transform
+}
+
+func (st *responsesToAnthropicStreamState) transform(event string, data string) ([]upstream.SSEEvent, bool, error) {
+	e := &st.emitter
+	data = strings.TrimSpace(data)
+	if data == "[DONE]" {
+		return e.finish(), true, nil
+	}
+	var evt map[string]any
+	if err := json.Unmarshal([]byte(data), &evt); err != nil {
+		return nil, true, nil
+	}
+	typ := stringFromAny(evt["type"])
+	if typ == "" {
+		typ = strings.TrimSpace(event)
+	}
+
+	switch typ {
+	case "response.created", "response.in_progress":
+		resp, _ := evt["response"].(map[string]any)
+		if e.id == "" {
+			e.id = anthropicMessageIDFromOpenAI(stringFromAny(resp["id"]))
+		}
+		if e.model == "" {
+			e.model = stringFromAny(resp["model"])
+		}
+		return e.start(), true, nil
+	case "response.output_item.added":
+		item, _ := evt["item"].(map[string]any)
+		if stringFromAny(item["type"]) != "function_call" {
+			return nil, true, nil
+		}
+		return e.openBlock(map[string]any{
+			"type":  "tool_use",
+			"id":    stringFromAny(item["call_id"]),
+			"name":  stringFromAny(item["name"]),
+			"input": map[string]any{},
+		}), true, nil
+	case "response.output_text.delta", "response.refusal.delta":
+		return e.textDelta(stringFromAny(evt["delta"])), true, nil
+	case "response.reasoning_summary_text.delta", "response.reasoning_text.delta":
+		return e.thinkingDelta(stringFromAny(evt["delta"])), true, nil
+	case "response.function_call_arguments.delta":
+		return e.toolArgumentsDelta(stringFromAny(evt["delta"])), true, nil
+	case "response.completed", "response.incomplete":
+		resp, _ := evt["response"].(map[string]any)
+		if usage, ok := resp["usage"].(map[string]any); ok {
+			e.usage = openAIUsageToAnthropic(usage)
+		}
+		e.stopReason = responsesStopReason(resp, e.hasToolUse)
+		return e.finish(), true, nil
+	case "response.failed":
+		resp, _ := evt["response"].(map[string]any)
+		errObj, _ := resp["error"].(map[string]any)
+		if errObj == nil {
+			errObj = map[string]any{"message": "上游响应失败"}
+		}
+		return e.errorEvent(errObj), true, nil
+	case "error":
+		errObj, _ := evt["error"].(map[string]any)
+		if errObj == nil {
+			errObj = evt
+		}
+		return e.errorEvent(errObj), true, nil
+	default:
+		return nil, true, nil
+	}
+}
+
+// newMessagesToChatAdapter 构造 /v1/messages 下游 -> chat/completions 上游的协议适配器。
+func newMessagesToChatAdapter() *upstreamProtocolAdapter {
+	return &upstreamProtocolAdapter{
+		upstreamPath:         "/v1/chat/completions",
+		translateResponse:    chatCompletionToAnthropicMessage,
+		translateError:       openAIErrorToAnthropicError,
+		newStreamTransformer: newChatToAnthropicMessagesStreamTransformer,
+	}
+}
+
+// newMessagesToResponsesAdapter 构造 /v1/messages 下游 -> Responses 上游的协议适配器。
+func newMessagesToResponsesAdapter() *upstreamProtocolAdapter {
+	return &upstreamProtocolAdapter{
+		upstreamPath:         "/v1/responses",
+		translateResponse:    responsesToAnthropicMessage,
+		translateError:       openAIErrorToAnthropicError,
+		newStreamTransformer: newResponsesToAnthropicMessagesStreamTransformer,
+	}
+}
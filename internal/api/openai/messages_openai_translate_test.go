@@ -0,0 +1,312 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"realms/internal/auth"
+	"realms/internal/middleware"
+	"realms/internal/scheduler"
+	"realms/internal/store"
+	"realms/internal/upstream"
+)
+
+const messagesTranslateRequest = `{
+	"model":"claude-x",
+	"system":[{"type":"text","text":"be brief","cache_control":{"type":"ephemeral"}}],
+	"messages":[
+		{"role":"user","content":[{"type":"text","text":"look"},{"type":"image","source":{"type":"base64","media_type":"image/png","data":"QUJD"}}]},
+		{"role":"assistant","content":[{"type":"thinking","thinking":"hmm","signature":"sig"},{"type":"tool_use","id":"tu_1","name":"get","input":{"a":1}}]},
+		{"role":"user","content":[{"type":"tool_result","tool_use_id":"tu_1","content":"ok"},{"type":"text","text":"next"}]}
+	],
+	"tools":[{"name":"get","description":"d","input_schema":{"type":"object"}},{"type":"web_search_20250305","name":"web_search"}],
+	"tool_choice":{"type":"any","disable_parallel_tool_use":true},
+	"thinking":{"type":"enabled","budget_tokens":2048},
+	"max_tokens":64,
+	"stream":true
+}`
+
+func TestAnthropicMessagesPayloadToChat_MapsBlocksToolsAndThinking(t *testing.T) {
+	var payload map[string]any
+	if err := json.Unmarshal([]byte(messagesTranslateRequest), &payload); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	out, err := anthropicMessagesPayloadToChat(payload)
+	if err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	raw, _ := json.Marshal(out)
+	var got struct {
+		Messages []struct {
+			Role       string           `json:"role"`
+			Content    any              `json:"content"`
+			ToolCalls  []map[string]any `json:"tool_calls"`
+			ToolCallID string           `json:"tool_call_id"`
+		} `json:"messages"`
+		MaxTokens         int              `json:"max_tokens"`
+		StreamOptions     map[string]any   `json:"stream_options"`
+		Tools             []map[string]any `json:"tools"`
+		ToolChoice        any              `json:"tool_choice"`
+		ParallelToolCalls *bool            `json:"parallel_tool_calls"`
+		ReasoningEffort   string           `json:"reasoning_effort"`
+	}
+	if err := json.Unmarshal(raw, &got); err != nil {
+		t.Fatalf("unmarshal converted: %v", err)
+	}
+	roles := make([]string, 0, len(got.Messages))
+	for _, m := range got.Messages {
+		roles = append(roles, m.Role)
+	}
+	if strings.Join(roles, ",") != "system,user,assistant,tool,user" {
+		t.Fatalf("unexpected roles: %v body=%s", roles, raw)
+	}
+	if got.Messages[0].Content != "be brief" {
+		t.Fatalf("unexpected system: %#v", got.Messages[0].Content)
+	}
+	if len(got.Messages[2].ToolCalls) != 1 || got.Messages[2].ToolCalls[0]["id"] != "tu_1" {
+		t.Fatalf("unexpected tool_calls: %#v", got.Messages[2].ToolCalls)
+	}
+	if got.Messages[3].ToolCallID != "tu_1" || got.Messages[3].Content != "ok" {
+		t.Fatalf("unexpected tool message: %#v", got.Messages[3])
+	}
+	if got.MaxTokens != 64 || got.StreamOptions["include_usage"] != true {
+		t.Fatalf("unexpected max_tokens/stream_options: %s", raw)
+	}
+	if len(got.Tools) != 1 {
+		t.Fatalf("expected server tools to be dropped, got=%#v", got.Tools)
+	}
+	if got.ToolChoice != "required" || got.ParallelToolCalls == nil || *got.ParallelToolCalls {
+		t.Fatalf("unexpected tool_choice/parallel: %s", raw)
+	}
+	if got.ReasoningEffort != "low" {
+		t.Fatalf("unexpected reasoning_effort: %q", got.ReasoningEffort)
+	}
+	if strings.Contains(string(raw), "cache_control") {
+		t.Fatalf("expected cache_control to be dropped: %s", raw)
+	}
+}
+
+func TestAnthropicMessagesPayloadToResponses_MapsInputItems(t *testing.T) {
+	var payload map[string]any
+	if err := json.Unmarshal([]byte(messagesTranslateRequest), &payload); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	out, err := anthropicMessagesPayloadToResponses(payload)
+	if err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	if out["instructions"] != "be brief" {
+		t.Fatalf("unexpected instructions: %#v", out["instructions"])
+	}
+	if out["max_output_tokens"] != float64(64) {
+		t.Fatalf("unexpected max_output_tokens: %#v", out["max_output_tokens"])
+	}
+	input, _ := out["input"].([]any)
+	types := make([]string, 0, len(input))
+	for _, it := range input {
+		types = append(types, stringFromAny(it.(map[string]any)["type"]))
+	}
+	if strings.Join(types, ",") != "message,function_call,function_call_output,message" {
+		t.Fatalf("unexpected input item types: %v", types)
+	}
+	choice, _ := out["tool_choice"].(string)
+	if choice != "required" {
+		t.Fatalf("unexpected tool_choice: %#v", out["tool_choice"])
+	}
+}
+
+func TestChatCompletionToAnthropicMessage_MapsContentAndUsage(t *testing.T) {
+	out, err := chatCompletionToAnthropicMessage([]byte(`{"id":"chatcmpl-abc","object":"chat.completion","model":"gpt-x","choices":[{"index":0,"message":{"role":"assistant","content":"hi","tool_calls":[{"id":"call_1","type":"function","function":{"name":"get","arguments":"{\"a\":1}"}}]},"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":10,"completion_tokens":5,"prompt_tokens_details":{"cached_tokens":4}}}`))
+	if err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	var got struct {
+		ID         string           `json:"id"`
+		Type       string           `json:"type"`
+		Content    []map[string]any `json:"content"`
+		StopReason string           `json:"stop_reason"`
+		Usage      map[string]any   `json:"usage"`
+	}
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got.ID != "msg_abc" || got.Type != "message" || got.StopReason != "tool_use" {
+		t.Fatalf("unexpected envelope: %s", out)
+	}
+	if len(got.Content) != 2 || got.Content[0]["type"] != "text" || got.Content[1]["type"] != "tool_use" {
+		t.Fatalf("unexpected content: %s", out)
+	}
+	if got.Usage["input_tokens"] != float64(6) || got.Usage["cache_read_input_tokens"] != float64(4) || got.Usage["output_tokens"] != float64(5) {
+		t.Fatalf("unexpected usage: %s", out)
+	}
+}
+
+func collectAnthropicStream(t *testing.T, tr func(string, string) ([]upstream.SSEEvent, bool, error), inputs []string) []upstream.SSEEvent {
+	t.Helper()
+	var outs []upstream.SSEEvent
+	for _, in := range inputs {
+		o, handled, err := tr("", in)
+		if err != nil {
+			t.Fatalf("transform: %v", err)
+		}
+		if !handled {
+			t.Fatalf("expected %q to be handled", in)
+		}
+		outs = append(outs, o...)
+	}
+	return outs
+}
+
+func anthropicEventNames(events []upstream.SSEEvent) string {
+	names := make([]string, 0, len(events))
+	for _, ev := range events {
+		names = append(names, ev.Event)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestChatToAnthropicStreamTransformer_SynthesizesMessageEvents(t *testing.T) {
+	outs := collectAnthropicStream(t, newChatToAnthropicMessagesStreamTransformer(), []string{
+		`{"id":"chatcmpl-1","model":"gpt-x","choices":[{"index":0,"delta":{"role":"assistant","content":"he"}}]}`,
+		`{"id":"chatcmpl-1","model":"gpt-x","choices":[{"index":0,"delta":{"content":"llo"}}]}`,
+		`{"id":"chatcmpl-1","model":"gpt-x","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get","arguments":"{\"a\""}}]}}]}`,
+		`{"id":"chatcmpl-1","model":"gpt-x","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":":1}"}}]}}]}`,
+		`{"id":"chatcmpl-1","model":"gpt-x","choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}`,
+		`{"id":"chatcmpl-1","model":"gpt-x","choices":[],"usage":{"prompt_tokens":7,"completion_tokens":3}}`,
+		`[DONE]`,
+	})
+	want := "message_start,content_block_start,content_block_delta,content_block_delta,content_block_stop,content_block_start,content_block_delta,content_block_delta,content_block_stop,message_delta,message_stop"
+	if got := anthropicEventNames(outs); got != want {
+		t.Fatalf("unexpected events:\n got=%s\nwant=%s", got, want)
+	}
+	var delta struct {
+		Delta struct {
+			StopReason string `json:"stop_reason"`
+		} `json:"delta"`
+		Usage map[string]any `json:"usage"`
+	}
+	if err := json.Unmarshal([]byte(outs[len(outs)-2].Data), &delta); err != nil {
+		t.Fatalf("unmarshal message_delta: %v", err)
+	}
+	if delta.Delta.StopReason != "tool_use" {
+		t.Fatalf("unexpected stop_reason: %q", delta.Delta.StopReason)
+	}
+	if delta.Usage["input_tokens"] != float64(7) || delta.Usage["output_tokens"] != float64(3) {
+		t.Fatalf("unexpected usage: %#v", delta.Usage)
+	}
+}
+
+func TestResponsesToAnthropicStreamTransformer_SynthesizesMessageEvents(t *testing.T) {
+	outs := collectAnthropicStream(t, newResponsesToAnthropicMessagesStreamTransformer(), []string{
+		`{"type":"response.created","response":{"id":"resp_1","model":"gpt-x"}}`,
+		`{"type":"response.reasoning_summary_text.delta","delta":"think"}`,
+		`{"type":"response.output_text.delta","delta":"hi"}`,
+		`{"type":"response.output_text.done","text":"hi"}`,
+		`{"type":"response.completed","response":{"id":"resp_1","status":"completed","usage":{"input_tokens":9,"output_tokens":2}}}`,
+	})
+	want := "message_start,content_block_start,content_block_delta,content_block_stop,content_block_start,content_block_delta,content_block_stop,message_delta,message_stop"
+	if got := anthropicEventNames(outs); got != want {
+		t.Fatalf("unexpected events:\n got=%s\nwant=%s", got, want)
+	}
+	if !strings.Contains(outs[len(outs)-2].Data, `"end_turn"`) || !strings.Contains(outs[len(outs)-2].Data, `"input_tokens":9`) {
+		t.Fatalf("unexpected message_delta: %s", outs[len(outs)-2].Data)
+	}
+}
+
+func TestMessages_OpenAICompatibleChannelServesViaChatTranslation(t *testing.T) {
+	const groupName = "g1"
+	fs := &fakeStore{
+		channels: []store.UpstreamChannel{
+			{ID: 1, Type: store.UpstreamTypeOpenAICompatible, Status: 1, Groups: groupName, Priority: 10, Setting: store.UpstreamChannelSetting{ChatCompletionsEnabled: true}},
+			{ID: 2, Type: store.UpstreamTypeOpenAICompatible, Status: 1, Groups: groupName, Setting: store.UpstreamChannelSetting{ChatCompletionsEnabled: true, MessagesEnabled: true}},
+		},
+		endpoints: map[int64][]store.UpstreamEndpoint{
+			1: {{ID: 11, ChannelID: 1, BaseURL: "https://a.example", Status: 1}},
+			2: {{ID: 21, ChannelID: 2, BaseURL: "https://b.example", Status: 1}},
+		},
+		creds: map[int64][]store.OpenAICompatibleCredential{
+			11: {{ID: 1, EndpointID: 11, Status: 1}},
+			21: {{ID: 2, EndpointID: 21, Status: 1}},
+		},
+		models: map[string]store.ManagedModel{
+			"claude-x": {ID: 1, PublicID: "claude-x", GroupName: groupName, Status: 1},
+		},
+		bindings: map[string][]store.ChannelModelBinding{
+			"claude-x": {
+				{ID: 1, ChannelID: 1, ChannelType: store.UpstreamTypeOpenAICompatible, PublicID: "claude-x", UpstreamModel: "gpt-a", Status: 1},
+				{ID: 2, ChannelID: 2, ChannelType: store.UpstreamTypeOpenAICompatible, PublicID: "claude-x", UpstreamModel: "gpt-b", Status: 1},
+			},
+		},
+	}
+
+	var gotChannelID int64
+	var gotPath string
+	var gotBody []byte
+	doer := DoerFunc(func(_ context.Context, sel scheduler.Selection, downstream *http.Request, body []byte) (*http.Response, error) {
+		gotChannelID = sel.ChannelID
+		gotPath = downstream.URL.Path
+		gotBody = body
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(bytes.NewReader([]byte(`{"id":"chatcmpl-1","object":"chat.completion","model":"gpt-b","choices":[{"index":0,"message":{"role":"assistant","content":"pong"},"finish_reason":"stop"}],"usage":{"prompt_tokens":6,"completion_tokens":2}}`))),
+		}, nil
+	})
+
+	q := &fakeQuota{}
+	h := NewHandler(fs, fs, scheduler.New(fs), doer, nil, nil, q, fakeAudit{}, nil, nil, upstream.SSEPumpOptions{}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/v1/messages", bytes.NewReader([]byte(`{"model":"claude-x","system":"s","messages":[{"role":"user","content":"ping"}],"max_tokens":32}`)))
+	req.Header.Set("Content-Type", "application/json")
+	tokenID := int64(123)
+	p := auth.Principal{ActorType: auth.ActorTypeToken, UserID: 10, Role: store.UserRoleUser, TokenID: &tokenID, Groups: []string{groupName}}
+	req = req.WithContext(auth.WithPrincipal(req.Context(), p))
+	rr := httptest.NewRecorder()
+	middleware.Chain(http.HandlerFunc(h.Messages), middleware.BodyCache(1<<20)).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d body=%s", rr.Code, rr.Body.String())
+	}
+	if gotChannelID != 2 {
+		t.Fatalf("expected messages-enabled channel 2, got=%d", gotChannelID)
+	}
+	if gotPath != "/v1/chat/completions" {
+		t.Fatalf("expected upstream path /v1/chat/completions, got=%q", gotPath)
+	}
+	var forwarded map[string]any
+	if err := json.Unmarshal(gotBody, &forwarded); err != nil {
+		t.Fatalf("unmarshal forwarded body: %v", err)
+	}
+	if forwarded["model"] != "gpt-b" {
+		t.Fatalf("expected upstream model rewrite, got=%#v", forwarded["model"])
+	}
+	msgs, _ := forwarded["messages"].([]any)
+	if len(msgs) != 2 {
+		t.Fatalf("expected system+user chat messages, body=%s", gotBody)
+	}
+
+	var resp struct {
+		Type    string           `json:"type"`
+		Content []map[string]any `json:"content"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal response: %v body=%s", err, rr.Body.String())
+	}
+	if resp.Type != "message" || len(resp.Content) != 1 || resp.Content[0]["text"] != "pong" {
+		t.Fatalf("unexpected translated response: %s", rr.Body.String())
+	}
+
+	if len(q.commitCalls) != 1 {
+		t.Fatalf("expected 1 commit call, got=%d", len(q.commitCalls))
+	}
+	commit := q.commitCalls[0]
+	if commit.InputTokens == nil || *commit.InputTokens != 6 || commit.OutputTokens == nil || *commit.OutputTokens != 2 {
+		t.Fatalf("unexpected committed usage: in=%v out=%v", commit.InputTokens, commit.OutputTokens)
+	}
+}
@@ -2,11 +2,14 @@ package openai
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
 
 	"github.com/tidwall/gjson"
+
+	"realms/internal/quota"
 )
 
 func writeOpenAIError(w http.ResponseWriter, status int, errType string, message string) {
@@ -34,6 +37,43 @@ func writeOpenAIErrorWithRetryAfter(w http.ResponseWriter, status int, errType s
 	})
 }
 
+// writeOpenAIErrorWithCode 与 writeOpenAIError 相同，额外携带 error.code 供客户端区分具体原因。
+func writeOpenAIErrorWithCode(w http.ResponseWriter, status int, errType string, code string, message string) {
+	if w == nil {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"type": "error",
+		"error": map[string]any{
+			"type":    strings.TrimSpace(errType),
+			"code":    strings.TrimSpace(code),
+			"message": strings.TrimSpace(message),
+		},
+	})
+}
+
+// writeTokenLimitReserveError 将 Token 级限制（模型白名单/消费上限/组织计费）导致的预留失败写为带 code 的错误响应；
+// 非此类错误时返回 false，由调用方继续按原有分支处理。
+func writeTokenLimitReserveError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, quota.ErrTokenModelNotAllowed):
+		writeOpenAIErrorWithCode(w, http.StatusForbidden, "invalid_request_error", "model_not_allowed", err.Error())
+	case errors.Is(err, quota.ErrTokenBudgetExceeded):
+		writeOpenAIErrorWithCode(w, http.StatusTooManyRequests, "insufficient_quota", "token_budget_exceeded", err.Error())
+	case errors.Is(err, quota.ErrOrganizationMemberSpendLimitExceeded):
+		writeOpenAIErrorWithCode(w, http.StatusTooManyRequests, "insufficient_quota", "member_spend_limit_exceeded", err.Error())
+	case errors.Is(err, quota.ErrOrganizationDisabled):
+		writeOpenAIErrorWithCode(w, http.StatusForbidden, "permission_error", "organization_disabled", err.Error())
+	case errors.Is(err, quota.ErrOrganizationMemberRequired):
+		writeOpenAIErrorWithCode(w, http.StatusForbidden, "permission_error", "organization_membership_required", err.Error())
+	default:
+		return false
+	}
+	return true
+}
+
 func isInvalidEncryptedContentUpstreamError(body []byte) bool {
 	if len(body) == 0 {
 		return false
@@ -0,0 +1,60 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"realms/internal/upstream"
+)
+
+// translatedResponseMaxBytes 限制协议转换时非流式响应的缓冲大小（转换需要完整 JSON）。
+const translatedResponseMaxBytes = 32 << 20
+
+var errTranslatedResponseTooLarge = errors.New("上游响应过大，无法完成协议转换")
+
+// upstreamProtocolAdapter 描述“下游协议与上游协议不同”的一次转发：
+// 请求体已在 rewriteBody 中转换为上游协议，这里负责改写上游路径，并把响应转换回下游协议。
+type upstreamProtocolAdapter struct {
+	// upstreamPath 为转发到上游的路径（例如 /v1/messages）；下游 r.URL.Path 保持不变用于审计与用量记录。
+	upstreamPath string
+	// translateResponse 转换非流式 2xx 响应体。
+	translateResponse func(body []byte) ([]byte, error)
+	// translateError 转换不可重试的上游错误体（写回下游前）。
+	translateError func(body []byte) []byte
+	// newStreamTransformer 为每次尝试创建一个有状态的 SSE 事件转换器。
+	newStreamTransformer func() func(event string, data string) ([]upstream.SSEEvent, bool, error)
+}
+
+type upstreamProtocolAdapterCtxKey struct{}
+
+func withUpstreamProtocolAdapter(ctx context.Context, adapter *upstreamProtocolAdapter) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, upstreamProtocolAdapterCtxKey{}, adapter)
+}
+
+func upstreamProtocolAdapterFromContext(ctx context.Context) *upstreamProtocolAdapter {
+	if ctx == nil {
+		return nil
+	}
+	v, _ := ctx.Value(upstreamProtocolAdapterCtxKey{}).(*upstreamProtocolAdapter)
+	return v
+}
+
+func (a *upstreamProtocolAdapter) readTranslatedResponse(body io.Reader) ([]byte, error) {
+	raw, err := io.ReadAll(io.LimitReader(body, translatedResponseMaxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) > translatedResponseMaxBytes {
+		return nil, errTranslatedResponseTooLarge
+	}
+	out, err := a.translateResponse(raw)
+	if err != nil {
+		// 无法识别的响应体原样透传，避免吞掉上游内容。
+		return raw, nil
+	}
+	return out, nil
+}
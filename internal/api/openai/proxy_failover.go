@@ -0,0 +1,175 @@
+package openai
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"realms/internal/auth"
+	"realms/internal/middleware"
+	"realms/internal/quota"
+	"realms/internal/scheduler"
+)
+
+// failoverProxy 描述一次“预留配额 → 按渠道组 failover 转发 → 失败兜底”的无状态 JSON 代理请求。
+// chat/completions 与 embeddings 共用该流程；Responses（proxyJSON）因会话粘性绑定逻辑单独实现。
+type failoverProxy struct {
+	reqStart     time.Time
+	principal    auth.Principal
+	publicModel  string
+	stream       bool
+	reqBytes     int64
+	cons         scheduler.Constraints
+	routeKeyHash string
+	// reserve 为配额预留参数；RequestID/UserID/TokenID/Model 由 proxyWithFailover 填充。
+	reserve  quota.ReserveInput
+	bindings resolvedChannelModelBindings
+	// rewriteBody 按选中渠道生成上游请求体。
+	rewriteBody func(sel scheduler.Selection) ([]byte, error)
+	// attemptRequest 可选：按选中渠道包装本次尝试使用的请求（如挂载协议适配器）。
+	attemptRequest func(r *http.Request, sel scheduler.Selection) *http.Request
+}
+
+// proxyWithFailover 执行 failoverProxy：获取用户并发槽位并预留配额后，逐个尝试调度选出的渠道，
+// 全部失败时作废预留并按最佳失败信息回写 OpenAI 风格错误。
+func (h *Handler) proxyWithFailover(w http.ResponseWriter, r *http.Request, px failoverProxy) {
+	p := px.principal
+	publicModel := optionalString(px.publicModel)
+	stream := px.stream
+	reqStart := px.reqStart
+	reqBytes := px.reqBytes
+
+	usageID := int64(0)
+	userRelease, userSlotErr := h.acquireUserSlot(r.Context(), p.UserID)
+	if userSlotErr != nil {
+		fail := classifyConcurrencyAcquireFailure(userSlotErr)
+		resp := h.buildFailoverExhaustedResponse("openai", fail)
+		cw := &countingResponseWriter{ResponseWriter: w}
+		writeOpenAIErrorWithRetryAfter(cw, resp.Status, resp.ErrType, resp.Message, resp.RetryAfterSeconds)
+		if !resp.SkipMonitoring {
+			h.maybeLogProxyFailure(r.Context(), r, p, nil, publicModel, resp.Status, resp.ErrorClass, resp.Message, time.Since(reqStart), stream)
+		}
+		return
+	}
+	if userRelease != nil {
+		defer userRelease()
+	}
+	if h.quota != nil {
+		in := px.reserve
+		in.RequestID = middleware.GetRequestID(r.Context())
+		in.UserID = p.UserID
+		in.TokenID = *p.TokenID
+		in.Model = publicModel
+		res, err := h.quota.Reserve(r.Context(), in)
+		if err != nil {
+			if writeTokenLimitReserveError(w, err) {
+				return
+			}
+			if msg := reserveBadRequestMessage(err); msg != "" {
+				http.Error(w, msg, http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, quota.ErrSubscriptionRequired) || errors.Is(err, quota.ErrQuotaExceeded) {
+				http.Error(w, err.Error(), http.StatusTooManyRequests)
+				return
+			}
+			if errors.Is(err, quota.ErrInsufficientBalance) {
+				http.Error(w, err.Error(), http.StatusPaymentRequired)
+				return
+			}
+			http.Error(w, "配额预留失败", http.StatusTooManyRequests)
+			return
+		}
+		usageID = res.UsageEventID
+	}
+
+	bestFailure := proxyFailureInfo{}
+	if h.groups != nil {
+		router := scheduler.NewGroupRouter(h.groups, h.sched, p.UserID, px.routeKeyHash, px.cons)
+		loopStart := time.Now()
+		switches := 0
+		backoff := h.initialBackoff()
+		for {
+			if h.failoverExhausted(loopStart, switches) {
+				break
+			}
+			sel, err := router.Next(r.Context())
+			if err != nil {
+				if h.finalizeIfCanceled(r, usageID, nil, reqStart, stream, reqBytes) {
+					return
+				}
+				if msg := serviceTierSelectionBadRequestMessage(err); msg != "" && !isFastModeSelectionError(err) {
+					h.voidQuotaBestEffort(usageID)
+					http.Error(w, msg, http.StatusBadRequest)
+					h.finalizeUsageEvent(r, usageID, nil, http.StatusBadRequest, "service_tier", msg, time.Since(reqStart), 0, stream, reqBytes, 0)
+					return
+				}
+				break
+			}
+			rewritten, err := px.rewriteBody(sel)
+			if err != nil {
+				if isFastModeSelectionError(err) {
+					router.ExcludeChannel(sel.ChannelID)
+					switches++
+					if h.failoverExhausted(loopStart, switches) {
+						break
+					}
+					if !h.waitBackoffWithinRetryElapsed(r.Context(), loopStart, backoff) {
+						if h.finalizeIfCanceled(r, usageID, nil, reqStart, stream, reqBytes) {
+							return
+						}
+						break
+					}
+					backoff = h.nextBackoff(backoff)
+					continue
+				}
+				h.voidQuotaBestEffort(usageID)
+				if msg := serviceTierSelectionBadRequestMessage(err); msg != "" {
+					cw := &countingResponseWriter{ResponseWriter: w}
+					http.Error(cw, msg, http.StatusBadRequest)
+					h.finalizeUsageEvent(r, usageID, &sel, http.StatusBadRequest, "service_tier", msg, time.Since(reqStart), 0, stream, reqBytes, cw.bytes)
+					return
+				}
+				cw := &countingResponseWriter{ResponseWriter: w}
+				http.Error(cw, "请求体处理失败", http.StatusInternalServerError)
+				h.finalizeUsageEvent(r, usageID, &sel, http.StatusInternalServerError, "rewrite_body", "请求体处理失败", time.Since(reqStart), 0, stream, reqBytes, cw.bytes)
+				return
+			}
+			bindingID := px.bindings.BindingID(sel.ChannelID)
+			attemptReq := r
+			if px.attemptRequest != nil {
+				attemptReq = px.attemptRequest(r, sel)
+			}
+			if h.tryWithSelection(w, attemptReq, p, sel, rewritten, stream, publicModel, extractTopLevelModel(rewritten), bindingID, usageID, reqStart, reqBytes, loopStart, 1, &bestFailure) {
+				return
+			}
+			switches++
+			if h.failoverExhausted(loopStart, switches) {
+				break
+			}
+			if !h.waitBackoffWithinRetryElapsed(r.Context(), loopStart, backoff) {
+				if h.finalizeIfCanceled(r, usageID, nil, reqStart, stream, reqBytes) {
+					return
+				}
+				break
+			}
+			backoff = h.nextBackoff(backoff)
+		}
+	}
+
+	h.voidQuotaBestEffort(usageID)
+	resp := h.buildFailoverExhaustedResponse("openai", bestFailure)
+	if !resp.SkipMonitoring {
+		h.auditUpstreamError(r.Context(), r.URL.Path, p, nil, publicModel, resp.Status, resp.ErrorClass, 0)
+	}
+	cw := &countingResponseWriter{ResponseWriter: w}
+	writeOpenAIErrorWithRetryAfter(cw, resp.Status, resp.ErrType, resp.Message, resp.RetryAfterSeconds)
+	if !resp.SkipMonitoring {
+		h.maybeLogProxyFailure(r.Context(), r, p, nil, publicModel, resp.Status, resp.ErrorClass, resp.Message, time.Since(reqStart), stream)
+	}
+	finalClass := resp.ErrorClass
+	if resp.SkipMonitoring {
+		finalClass = ""
+	}
+	h.finalizeUsageEvent(r, usageID, nil, resp.Status, finalClass, resp.UsageMessage, time.Since(reqStart), 0, stream, reqBytes, cw.bytes)
+}
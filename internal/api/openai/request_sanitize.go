@@ -267,3 +267,30 @@ func sanitizeChatCompletionsPayload(body []byte, defaultMaxTokens int) (map[stri
 
 	return out, nil
 }
+
+// sanitizeEmbeddingsPayload 仅做最小结构校验；未知字段（dimensions/encoding_format/user 等）默认透传。
+func sanitizeEmbeddingsPayload(body []byte) (map[string]any, error) {
+	out, err := unmarshalRequestPayload(body)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(stringFromAny(out["model"])) == "" {
+		return nil, errors.New("model 不能为空")
+	}
+	switch v := out["input"].(type) {
+	case string:
+		if v == "" {
+			return nil, errors.New("input 不能为空")
+		}
+	case []any:
+		if len(v) == 0 {
+			return nil, errors.New("input 不能为空")
+		}
+	default:
+		return nil, errors.New("input 不能为空")
+	}
+	// embeddings 不支持流式；避免误透传导致上游/计费口径不一致。
+	delete(out, "stream")
+	return out, nil
+}
@@ -14,6 +14,8 @@ func sanitizeGeminiBody(body []byte, path string) ([]byte, error) {
 		return sanitizeGeminiEmbeddingBody(body)
 	case strings.Contains(path, ":batchEmbedContents"):
 		return sanitizeGeminiBatchEmbeddingBody(body)
+	case strings.Contains(path, ":countTokens"):
+		return sanitizeGeminiCountTokensBody(body)
 	default:
 		return sanitizeGeminiChatBody(body)
 	}
@@ -58,6 +60,21 @@ func sanitizeGeminiChatBody(body []byte) ([]byte, error) {
 	return raw, nil
 }
 
+func sanitizeGeminiCountTokensBody(body []byte) ([]byte, error) {
+	type req struct {
+		Contents               json.RawMessage `json:"contents,omitempty"`
+		GenerateContentRequest json.RawMessage `json:"generateContentRequest,omitempty"`
+	}
+	var r req
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil, errInvalidJSON
+	}
+	if int(gjson.GetBytes(r.Contents, "#").Int()) == 0 && len(r.GenerateContentRequest) == 0 {
+		return nil, errors.New("contents 不能为空")
+	}
+	return json.Marshal(r)
+}
+
 func sanitizeGeminiEmbeddingBody(body []byte) ([]byte, error) {
 	type req struct {
 		Model string `json:"model,omitempty"`
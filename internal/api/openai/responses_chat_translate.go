@@ -0,0 +1,701 @@
+package openai
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"realms/internal/scheduler"
+	"realms/internal/upstream"
+)
+
+// Responses -> chat/completions 桥接（用于仅支持 chat/completions 的 openai_compatible 上游）：
+// - 请求：instructions/input items/function tools/reasoning/text.format 映射为 chat 请求；内置工具与 reasoning 续链项无对应语义，直接丢弃
+// - 响应：chat.completion JSON 与 chunk 流转换回 response 对象与 response.* 事件流
+// - 有状态能力（previous_response_id / conversation）依赖上游存储，无法桥接
+
+var errResponsesChatBridgeUnsupported = errors.New("请求依赖 Responses 上游能力，无法桥接到 chat/completions")
+
+// responsesInputText 把 Responses content（string 或 content parts）拼接为纯文本。
+func responsesInputText(content any) string {
+	if s, ok := content.(string); ok {
+		return s
+	}
+	list, _ := content.([]any)
+	var sb strings.Builder
+	for _, item := range list {
+		part, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		switch stringFromAny(part["type"]) {
+		case "input_text", "output_text", "text", "refusal":
+			sb.WriteString(stringFromAny(part["text"]))
+		}
+	}
+	return sb.String()
+}
+
+// responsesUserContentToChat 把 user 侧 content parts 转为 chat content（含图片）。
+func responsesUserContentToChat(content any) any {
+	if s, ok := content.(string); ok {
+		return s
+	}
+	list, _ := content.([]any)
+	parts := make([]any, 0, len(list))
+	for _, item := range list {
+		part, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		switch stringFromAny(part["type"]) {
+		case "input_text", "text":
+			parts = append(parts, map[string]any{"type": "text", "text": stringFromAny(part["text"])})
+		case "input_image":
+			url := strings.TrimSpace(stringFromAny(part["image_url"]))
+			if url == "" {
+				continue
+			}
+			img := map[string]any{"url": url}
+			if detail := stringFromAny(part["detail"]); detail != "" {
+				img["detail"] = detail
+			}
+			parts = append(parts, map[string]any{"type": "image_url", "image_url": img})
+		}
+	}
+	return parts
+}
+
+// responsesFunctionTools 过滤出 function 工具并转换为 chat tools（web_search/file_search 等内置工具无法桥接）。
+func responsesFunctionTools(tools any) []any {
+	list, _ := tools.([]any)
+	out := make([]any, 0, len(list))
+	for _, item := range list {
+		tool, ok := item.(map[string]any)
+		if !ok || stringFromAny(tool["type"]) != "function" {
+			continue
+		}
+		name := strings.TrimSpace(stringFromAny(tool["name"]))
+		if name == "" {
+			continue
+		}
+		fn := map[string]any{"name": name}
+		if desc := stringFromAny(tool["description"]); desc != "" {
+			fn["description"] = desc
+		}
+		if params, ok := tool["parameters"]; ok && params != nil {
+			fn["parameters"] = params
+		}
+		if strict, ok := tool["strict"].(bool); ok {
+			fn["strict"] = strict
+		}
+		out = append(out, map[string]any{"type": "function", "function": fn})
+	}
+	return out
+}
+
+func responsesToolChoiceToChat(v any) any {
+	switch c := v.(type) {
+	case string:
+		switch c {
+		case "auto", "none", "required":
+			return c
+		}
+	case map[string]any:
+		if stringFromAny(c["type"]) == "function" {
+			if name := stringFromAny(c["name"]); name != "" {
+				return map[string]any{"type": "function", "function": map[string]any{"name": name}}
+			}
+		}
+	}
+	return nil
+}
+
+// responsesTextFormatToChat 把 text.format 映射为 chat response_format。
+func responsesTextFormatToChat(text any) map[string]any {
+	t, _ := text.(map[string]any)
+	format, _ := t["format"].(map[string]any)
+	switch stringFromAny(format["type"]) {
+	case "json_object":
+		return map[string]any{"type": "json_object"}
+	case "json_schema":
+		schema := map[string]any{"name": stringFromAny(format["name"])}
+		for _, key := range []string{"schema", "description", "strict"} {
+			if v, ok := format[key]; ok {
+				schema[key] = v
+			}
+		}
+		return map[string]any{"type": "json_schema", "json_schema": schema}
+	default:
+		return nil
+	}
+}
+
+func responsesPayloadToChat(payload map[string]any) (map[string]any, error) {
+	if strings.TrimSpace(stringFromAny(payload["previous_response_id"])) != "" || payload["conversation"] != nil {
+		return nil, errResponsesChatBridgeUnsupported
+	}
+	out := make(map[string]any, 12)
+	out["model"] = payload["model"]
+
+	var messages []any
+	if instructions := stringFromAny(payload["instructions"]); instructions != "" {
+		messages = append(messages, map[string]any{"role": "system", "content": instructions})
+	}
+	// 连续的 function_call 项合并到同一条 assistant 消息的 tool_calls。
+	var pendingAssistant map[string]any
+	flushAssistant := func() {
+		if pendingAssistant != nil {
+			messages = append(messages, pendingAssistant)
+			pendingAssistant = nil
+		}
+	}
+
+	switch input := payload["input"].(type) {
+	case string:
+		messages = append(messages, map[string]any{"role": "user", "content": input})
+	case []any:
+		for _, raw := range input {
+			item, ok := raw.(map[string]any)
+			if !ok {
+				return nil, errInvalidJSON
+			}
+			typ := stringFromAny(item["type"])
+			if typ == "" && item["role"] != nil {
+				typ = "message"
+			}
+			switch typ {
+			case "message":
+				role := strings.TrimSpace(stringFromAny(item["role"]))
+				switch role {
+				case "user":
+					flushAssistant()
+					messages = append(messages, map[string]any{"role": "user", "content": responsesUserContentToChat(item["content"])})
+				case "system", "developer":
+					flushAssistant()
+					messages = append(messages, map[string]any{"role": "system", "content": responsesInputText(item["content"])})
+				case "assistant":
+					text := responsesInputText(item["content"])
+					if text == "" {
+						continue
+					}
+					if pendingAssistant != nil && pendingAssistant["tool_calls"] != nil {
+						flushAssistant()
+					}
+					if pendingAssistant == nil {
+						pendingAssistant = map[string]any{"role": "assistant", "content": text}
+						continue
+					}
+					pendingAssistant["content"] = stringFromAny(pendingAssistant["content"]) + text
+				default:
+					return nil, errChatTranslateUnsupportedRole
+				}
+			case "function_call":
+				if pendingAssistant == nil {
+					pendingAssistant = map[string]any{"role": "assistant", "content": nil}
+				}
+				calls, _ := pendingAssistant["tool_calls"].([]any)
+				args := stringFromAny(item["arguments"])
+				if strings.TrimSpace(args) == "" {
+					args = "{}"
+				}
+				pendingAssistant["tool_calls"] = append(calls, map[string]any{
+					"id":   stringFromAny(item["call_id"]),
+					"type": "function",
+					"function": map[string]any{
+						"name":      stringFromAny(item["name"]),
+						"arguments": args,
+					},
+				})
+			case "function_call_output":
+				flushAssistant()
+				messages = append(messages, map[string]any{
+					"role":         "tool",
+					"tool_call_id": stringFromAny(item["call_id"]),
+					"content":      responsesInputText(item["output"]),
+				})
+			case "item_reference":
+				return nil, errResponsesChatBridgeUnsupported
+			default:
+				// reasoning（encrypted_content）与内置工具调用项无法在 chat 上游复现，丢弃。
+			}
+		}
+	default:
+		return nil, errInvalidJSON
+	}
+	flushAssistant()
+	out["messages"] = messages
+
+	if v, ok := payload["max_output_tokens"]; ok && v != nil {
+		out["max_tokens"] = v
+	}
+	for _, key := range []string{"temperature", "top_p", "user", "parallel_tool_calls"} {
+		if v, ok := payload[key]; ok && v != nil {
+			out[key] = v
+		}
+	}
+	if boolFromAny(payload["stream"]) {
+		out["stream"] = true
+		// 计费依赖流末尾的 usage chunk。
+		out["stream_options"] = map[string]any{"include_usage": true}
+	}
+	if reasoning, ok := payload["reasoning"].(map[string]any); ok {
+		if effort := strings.TrimSpace(stringFromAny(reasoning["effort"])); effort != "" {
+			out["reasoning_effort"] = effort
+		}
+	}
+	if format := responsesTextFormatToChat(payload["text"]); format != nil {
+		out["response_format"] = format
+	}
+	if tools := responsesFunctionTools(payload["tools"]); len(tools) > 0 {
+		out["tools"] = tools
+		if choice := responsesToolChoiceToChat(payload["tool_choice"]); choice != nil {
+			out["tool_choice"] = choice
+		}
+	} else {
+		delete(out, "parallel_tool_calls")
+	}
+	return out, nil
+}
+
+func responsesIDFromChat(id string) string {
+	id = strings.TrimPrefix(strings.TrimSpace(id), "chatcmpl-")
+	if id == "" {
+		id = strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return "resp_" + id
+}
+
+// chatUsageToResponses 把 chat usage 转换为 Responses usage（input_tokens 含缓存命中部分，与 chat 口径一致）。
+func chatUsageToResponses(usage map[string]any) map[string]any {
+	in, out, cachedIn, _ := extractUsageTokensFromUsageMap(usage)
+	input, output, cached, reasoningTokens := int64(0), int64(0), int64(0), int64(0)
+	if in != nil {
+		input = *in
+	}
+	if out != nil {
+		output = *out
+	}
+	if cachedIn != nil {
+		cached = *cachedIn
+	}
+	if det, ok := usage["completion_tokens_details"].(map[string]any); ok {
+		if v := intFromAny(det["reasoning_tokens"]); v != nil {
+			reasoningTokens = *v
+		}
+	}
+	return map[string]any{
+		"input_tokens":          input,
+		"input_tokens_details":  map[string]any{"cached_tokens": cached},
+		"output_tokens":         output,
+		"output_tokens_details": map[string]any{"reasoning_tokens": reasoningTokens},
+		"total_tokens":          input + output,
+	}
+}
+
+// chatFinishReasonToResponsesIncomplete 返回 finish_reason 对应的 incomplete_details.reason；正常结束返回空串。
+func chatFinishReasonToResponsesIncomplete(reason string) string {
+	switch strings.TrimSpace(reason) {
+	case "length":
+		return "max_output_tokens"
+	case "content_filter":
+		return "content_filter"
+	default:
+		return ""
+	}
+}
+
+func responsesEnvelope(id string, model string, createdAt int64, output []any, incompleteReason string, usage map[string]any) map[string]any {
+	status := "completed"
+	var incomplete any
+	if incompleteReason != "" {
+		status = "incomplete"
+		incomplete = map[string]any{"reason": incompleteReason}
+	}
+	return map[string]any{
+		"id":                 id,
+		"object":             "response",
+		"created_at":         createdAt,
+		"status":             status,
+		"error":              nil,
+		"incomplete_details": incomplete,
+		"model":              model,
+		"output":             output,
+		"usage":              usage,
+	}
+}
+
+func responsesReasoningItem(id string, text string) map[string]any {
+	return map[string]any{
+		"type":    "reasoning",
+		"id":      id,
+		"summary": []any{map[string]any{"type": "summary_text", "text": text}},
+	}
+}
+
+func responsesMessageItem(id string, status string, text string) map[string]any {
+	content := []any{}
+	if status == "completed" {
+		content = append(content, map[string]any{"type": "output_text", "text": text, "annotations": []any{}})
+	}
+	return map[string]any{
+		"type":    "message",
+		"id":      id,
+		"status":  status,
+		"role":    "assistant",
+		"content": content,
+	}
+}
+
+func responsesFunctionCallItem(id string, status string, callID string, name string, args string) map[string]any {
+	return map[string]any{
+		"type":      "function_call",
+		"id":        id,
+		"status":    status,
+		"call_id":   callID,
+		"name":      name,
+		"arguments": args,
+	}
+}
+
+func chatCompletionToResponse(body []byte) ([]byte, error) {
+	var root map[string]any
+	if err := json.Unmarshal(body, &root); err != nil {
+		return nil, err
+	}
+	choices, _ := root["choices"].([]any)
+	if len(choices) == 0 {
+		return nil, errInvalidJSON
+	}
+	choice, _ := choices[0].(map[string]any)
+	msg, _ := choice["message"].(map[string]any)
+	id := responsesIDFromChat(stringFromAny(root["id"]))
+	suffix := strings.TrimPrefix(id, "resp_")
+
+	output := make([]any, 0, 4)
+	reasoning := stringFromAny(msg["reasoning_content"])
+	if reasoning == "" {
+		reasoning = stringFromAny(msg["reasoning"])
+	}
+	if reasoning != "" {
+		output = append(output, responsesReasoningItem("rs_"+suffix, reasoning))
+	}
+	if text := stringFromAny(msg["content"]); text != "" {
+		output = append(output, responsesMessageItem("msg_"+suffix, "completed", text))
+	}
+	calls, _ := msg["tool_calls"].([]any)
+	for i, c := range calls {
+		call, ok := c.(map[string]any)
+		if !ok {
+			continue
+		}
+		fn, _ := call["function"].(map[string]any)
+		output = append(output, responsesFunctionCallItem("fc_"+suffix+"_"+strconv.Itoa(i), "completed", stringFromAny(call["id"]), stringFromAny(fn["name"]), stringFromAny(fn["arguments"])))
+	}
+
+	createdAt := time.Now().Unix()
+	if v := intFromAny(root["created"]); v != nil {
+		createdAt = *v
+	}
+	usage, _ := root["usage"].(map[string]any)
+	return json.Marshal(responsesEnvelope(id, stringFromAny(root["model"]), createdAt, output, chatFinishReasonToResponsesIncomplete(stringFromAny(choice["finish_reason"])), chatUsageToResponses(usage)))
+}
+
+// chatToResponsesStreamState 把 chat.completion.chunk 流合成为 Responses 事件：
+// 维护 response.created 与 output item（reasoning / message / function_call）的开闭，并在 [DONE] 时输出 response.completed。
+type chatToResponsesStreamState struct {
+	started  bool
+	finished bool
+	seq      int64
+
+	id        string
+	model     string
+	createdAt int64
+
+	output []any
+
+	itemOpen bool
+	itemType string
+	itemID   string
+	itemText strings.Builder
+	callID   string
+	callName string
+	// toolCallIndex 记录当前打开的 function_call 项对应的 chat tool_calls[].index。
+	toolCallIndex int64
+
+	incompleteReason string
+	usage            map[string]any
+}
+
+// newChatToResponsesStreamTransformer 返回 upstream.SSEPumpHooks.TransformData：
+// 把 chat.completion.chunk 流转换为 Responses SSE（response.*）事件流。
+// usage chunk 在 finish_reason 之后到达，因此 response.completed 延迟到 [DONE] 时输出。
+func newChatToResponsesStreamTransformer() func(event string, data string) ([]upstream.SSEEvent, bool, error) {
+	st := &chatToResponsesStreamState{toolCallIndex: -1}
+	return st.transform
+}
+
+func (st *chatToResponsesStreamState) event(payload map[string]any) upstream.SSEEvent {
+	payload["sequence_number"] = st.seq
+	st.seq++
+	b, _ := json.Marshal(payload)
+	return upstream.SSEEvent{Event: stringFromAny(payload["type"]), Data: string(b)}
+}
+
+func (st *chatToResponsesStreamState) envelope(status string) map[string]any {
+	env := responsesEnvelope(st.id, st.model, st.createdAt, st.output, st.incompleteReason, st.usage)
+	if status != "" {
+		env["status"] = status
+	}
+	return env
+}
+
+func (st *chatToResponsesStreamState) start() []upstream.SSEEvent {
+	if st.started {
+		return nil
+	}
+	st.started = true
+	if st.id == "" {
+		st.id = responsesIDFromChat("")
+	}
+	if st.createdAt == 0 {
+		st.createdAt = time.Now().Unix()
+	}
+	return []upstream.SSEEvent{
+		st.event(map[string]any{"type": "response.created", "response": st.envelope("in_progress")}),
+		st.event(map[string]any{"type": "response.in_progress", "response": st.envelope("in_progress")}),
+	}
+}
+
+func (st *chatToResponsesStreamState) closeItem() []upstream.SSEEvent {
+	if !st.itemOpen {
+		return nil
+	}
+	st.itemOpen = false
+	idx := len(st.output)
+	text := st.itemText.String()
+	var outs []upstream.SSEEvent
+	var item map[string]any
+	switch st.itemType {
+	case "reasoning":
+		item = responsesReasoningItem(st.itemID, text)
+		part := map[string]any{"type": "summary_text", "text": text}
+		outs = append(outs,
+			st.event(map[string]any{"type": "response.reasoning_summary_text.done", "item_id": st.itemID, "output_index": idx, "summary_index": 0, "text": text}),
+			st.event(map[string]any{"type": "response.reasoning_summary_part.done", "item_id": st.itemID, "output_index": idx, "summary_index": 0, "part": part}),
+		)
+	case "message":
+		item = responsesMessageItem(st.itemID, "completed", text)
+		part := map[string]any{"type": "output_text", "text": text, "annotations": []any{}}
+		outs = append(outs,
+			st.event(map[string]any{"type": "response.output_text.done", "item_id": st.itemID, "output_index": idx, "content_index": 0, "text": text}),
+			st.event(map[string]any{"type": "response.content_part.done", "item_id": st.itemID, "output_index": idx, "content_index": 0, "part": part}),
+		)
+	case "function_call":
+		item = responsesFunctionCallItem(st.itemID, "completed", st.callID, st.callName, text)
+		outs = append(outs, st.event(map[string]any{"type": "response.function_call_arguments.done", "item_id": st.itemID, "output_index": idx, "arguments": text}))
+	}
+	st.output = append(st.output, item)
+	st.itemText.Reset()
+	return append(outs, st.event(map[string]any{"type": "response.output_item.done", "output_index": idx, "item": item}))
+}
+
+// openItem 打开新的 output item（会先关闭当前 item）。
+func (st *chatToResponsesStreamState) openItem(itemType string, callID string, name string) []upstream.SSEEvent {
+	outs := st.start()
+	outs = append(outs, st.closeItem()...)
+	idx := len(st.output)
+	suffix := strings.TrimPrefix(st.id, "resp_") + "_" + strconv.Itoa(idx)
+	st.itemOpen = true
+	st.itemType = itemType
+	st.callID = callID
+	st.callName = name
+	var item map[string]any
+	switch itemType {
+	case "reasoning":
+		st.itemID = "rs_" + suffix
+		item = map[string]any{"type": "reasoning", "id": st.itemID, "summary": []any{}}
+	case "message":
+		st.itemID = "msg_" + suffix
+		item = responsesMessageItem(st.itemID, "in_progress", "")
+	default:
+		st.itemID = "fc_" + suffix
+		item = responsesFunctionCallItem(st.itemID, "in_progress", callID, name, "")
+	}
+	outs = append(outs, st.event(map[string]any{"type": "response.output_item.added", "output_index": idx, "item": item}))
+	switch itemType {
+	case "reasoning":
+		outs = append(outs, st.event(map[string]any{"type": "response.reasoning_summary_part.added", "item_id": st.itemID, "output_index": idx, "summary_index": 0, "part": map[string]any{"type": "summary_text", "text": ""}}))
+	case "message":
+		outs = append(outs, st.event(map[string]any{"type": "response.content_part.added", "item_id": st.itemID, "output_index": idx, "content_index": 0, "part": map[string]any{"type": "output_text", "text": "", "annotations": []any{}}}))
+	}
+	return outs
+}
+
+func (st *chatToResponsesStreamState) reasoningDelta(text string) []upstream.SSEEvent {
+	if text == "" {
+		return nil
+	}
+	var outs []upstream.SSEEvent
+	if !st.itemOpen || st.itemType != "reasoning" {
+		outs = st.openItem("reasoning", "", "")
+	}
+	st.itemText.WriteString(text)
+	return append(outs, st.event(map[string]any{"type": "response.reasoning_summary_text.delta", "item_id": st.itemID, "output_index": len(st.output), "summary_index": 0, "delta": text}))
+}
+
+func (st *chatToResponsesStreamState) textDelta(text string) []upstream.SSEEvent {
+	if text == "" {
+		return nil
+	}
+	var outs []upstream.SSEEvent
+	if !st.itemOpen || st.itemType != "message" {
+		outs = st.openItem("message", "", "")
+	}
+	st.itemText.WriteString(text)
+	return append(outs, st.event(map[string]any{"type": "response.output_text.delta", "item_id": st.itemID, "output_index": len(st.output), "content_index": 0, "delta": text}))
+}
+
+func (st *chatToResponsesStreamState) argumentsDelta(partial string) []upstream.SSEEvent {
+	if partial == "" || !st.itemOpen || st.itemType != "function_call" {
+		return nil
+	}
+	st.itemText.WriteString(partial)
+	return []upstream.SSEEvent{st.event(map[string]any{"type": "response.function_call_arguments.delta", "item_id": st.itemID, "output_index": len(st.output), "delta": partial})}
+}
+
+// finish 关闭当前 item 并输出 response.completed（或 response.incomplete），携带最终 usage。
+func (st *chatToResponsesStreamState) finish() []upstream.SSEEvent {
+	if st.finished {
+		return nil
+	}
+	outs := st.start()
+	outs = append(outs, st.closeItem()...)
+	st.finished = true
+	if st.usage == nil {
+		st.usage = chatUsageToResponses(nil)
+	}
+	typ := "response.completed"
+	if st.incompleteReason != "" {
+		typ = "response.incomplete"
+	}
+	return append(outs, st.event(map[string]any{"type": typ, "response": st.envelope("")}))
+}
+
+func (st *chatToResponsesStreamState) transform(_ string, data string) ([]upstream.SSEEvent, bool, error) {
+	data = strings.TrimSpace(data)
+	if data == "[DONE]" {
+		return st.finish(), true, nil
+	}
+	var chunk map[string]any
+	if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+		return nil, true, nil
+	}
+	if errObj, ok := chunk["error"].(map[string]any); ok {
+		return []upstream.SSEEvent{st.event(map[string]any{
+			"type":    "error",
+			"code":    errObj["code"],
+			"message": stringFromAny(errObj["message"]),
+			"param":   errObj["param"],
+		})}, true, nil
+	}
+	if !st.started {
+		if id := stringFromAny(chunk["id"]); id != "" {
+			st.id = responsesIDFromChat(id)
+		}
+		if v := intFromAny(chunk["created"]); v != nil {
+			st.createdAt = *v
+		}
+	}
+	if st.model == "" {
+		st.model = stringFromAny(chunk["model"])
+	}
+	outs := st.start()
+	if usage, ok := chunk["usage"].(map[string]any); ok {
+		st.usage = chatUsageToResponses(usage)
+	}
+	choices, _ := chunk["choices"].([]any)
+	for _, c := range choices {
+		choice, ok := c.(map[string]any)
+		if !ok {
+			continue
+		}
+		delta, _ := choice["delta"].(map[string]any)
+		reasoning := stringFromAny(delta["reasoning_content"])
+		if reasoning == "" {
+			reasoning = stringFromAny(delta["reasoning"])
+		}
+		outs = append(outs, st.reasoningDelta(reasoning)...)
+		outs = append(outs, st.textDelta(stringFromAny(delta["content"]))...)
+		calls, _ := delta["tool_calls"].([]any)
+		for _, tc := range calls {
+			call, ok := tc.(map[string]any)
+			if !ok {
+				continue
+			}
+			idx := int64(0)
+			if v := intFromAny(call["index"]); v != nil {
+				idx = *v
+			}
+			fn, _ := call["function"].(map[string]any)
+			if idx != st.toolCallIndex || !st.itemOpen || st.itemType != "function_call" {
+				st.toolCallIndex = idx
+				outs = append(outs, st.openItem("function_call", stringFromAny(call["id"]), stringFromAny(fn["name"]))...)
+			}
+			outs = append(outs, st.argumentsDelta(stringFromAny(fn["arguments"]))...)
+		}
+		if reason := stringFromAny(choice["finish_reason"]); reason != "" {
+			st.incompleteReason = chatFinishReasonToResponsesIncomplete(reason)
+		}
+	}
+	return outs, true, nil
+}
+
+// newResponsesToChatAdapter 构造 /v1/responses 下游 -> chat/completions 上游的协议适配器；
+// 两端错误格式一致（{"error":{...}}），错误体原样透传。
+func newResponsesToChatAdapter() *upstreamProtocolAdapter {
+	return &upstreamProtocolAdapter{
+		upstreamPath:         "/v1/chat/completions",
+		translateResponse:    chatCompletionToResponse,
+		newStreamTransformer: newChatToResponsesStreamTransformer,
+	}
+}
+
+// rewriteResponsesForChat 在桥接模式下把 Responses 请求转换为 chat/completions 请求体；
+// 协议不同，不支持 pass_through_body（始终按转换结果转发）。
+func rewriteResponsesForChat(payload map[string]any, sel scheduler.Selection, publicModel string, upstreamModel string, path string) ([]byte, error) {
+	out := clonePayload(payload)
+	out["model"] = upstreamModel
+	applyChannelSystemPromptToResponsesPayload(out, sel)
+	raw, err := json.Marshal(out)
+	if err != nil {
+		return nil, err
+	}
+	raw, err = applyResponsesModelSuffixTransforms(raw, sel, publicModel)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, err
+	}
+	converted, err := responsesPayloadToChat(out)
+	if err != nil {
+		return nil, err
+	}
+	raw, err = json.Marshal(converted)
+	if err != nil {
+		return nil, err
+	}
+	raw, err = applyChannelRequestPolicy(raw, sel)
+	if err != nil {
+		return nil, err
+	}
+	raw, err = applyChannelBodyFilters(raw, sel)
+	if err != nil {
+		return nil, err
+	}
+	ctx := buildParamOverrideContext(sel, publicModel, upstreamModel, path)
+	return applyChannelParamOverride(raw, sel, ctx)
+}
@@ -0,0 +1,282 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"realms/internal/auth"
+	"realms/internal/middleware"
+	"realms/internal/scheduler"
+	"realms/internal/store"
+	"realms/internal/upstream"
+)
+
+const responsesBridgeRequest = `{
+	"model":"gpt-x",
+	"instructions":"be brief",
+	"input":[
+		{"role":"developer","content":"house rules"},
+		{"type":"message","role":"user","content":[{"type":"input_text","text":"look"},{"type":"input_image","image_url":"https://img.example/a.png","detail":"low"}]},
+		{"type":"reasoning","id":"rs_1","encrypted_content":"opaque","summary":[]},
+		{"type":"message","role":"assistant","content":[{"type":"output_text","text":"calling"}]},
+		{"type":"function_call","call_id":"call_1","name":"get","arguments":"{\"a\":1}"},
+		{"type":"function_call","call_id":"call_2","name":"get","arguments":""},
+		{"type":"function_call_output","call_id":"call_1","output":"ok"},
+		{"type":"function_call_output","call_id":"call_2","output":[{"type":"input_text","text":"ok2"}]}
+	],
+	"tools":[{"type":"function","name":"get","description":"d","parameters":{"type":"object"},"strict":true},{"type":"web_search"}],
+	"tool_choice":{"type":"function","name":"get"},
+	"parallel_tool_calls":false,
+	"reasoning":{"effort":"high","summary":"auto"},
+	"text":{"format":{"type":"json_schema","name":"out","schema":{"type":"object"},"strict":true}},
+	"max_output_tokens":64,
+	"prompt_cache_key":"sess-1",
+	"store":false,
+	"stream":true
+}`
+
+func TestResponsesPayloadToChat_MapsInputItemsToolsAndReasoning(t *testing.T) {
+	var payload map[string]any
+	if err := json.Unmarshal([]byte(responsesBridgeRequest), &payload); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	out, err := responsesPayloadToChat(payload)
+	if err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	raw, _ := json.Marshal(out)
+	var got struct {
+		Messages []struct {
+			Role       string           `json:"role"`
+			Content    any              `json:"content"`
+			ToolCalls  []map[string]any `json:"tool_calls"`
+			ToolCallID string           `json:"tool_call_id"`
+		} `json:"messages"`
+		MaxTokens         int              `json:"max_tokens"`
+		StreamOptions     map[string]any   `json:"stream_options"`
+		Tools             []map[string]any `json:"tools"`
+		ToolChoice        map[string]any   `json:"tool_choice"`
+		ParallelToolCalls *bool            `json:"parallel_tool_calls"`
+		ReasoningEffort   string           `json:"reasoning_effort"`
+		ResponseFormat    map[string]any   `json:"response_format"`
+	}
+	if err := json.Unmarshal(raw, &got); err != nil {
+		t.Fatalf("unmarshal converted: %v", err)
+	}
+	roles := make([]string, 0, len(got.Messages))
+	for _, m := range got.Messages {
+		roles = append(roles, m.Role)
+	}
+	if strings.Join(roles, ",") != "system,system,user,assistant,tool,tool" {
+		t.Fatalf("unexpected roles: %v body=%s", roles, raw)
+	}
+	if got.Messages[0].Content != "be brief" || got.Messages[1].Content != "house rules" {
+		t.Fatalf("unexpected system messages: %s", raw)
+	}
+	parts, _ := got.Messages[2].Content.([]any)
+	if len(parts) != 2 {
+		t.Fatalf("expected text+image parts, got %s", raw)
+	}
+	assistant := got.Messages[3]
+	if assistant.Content != "calling" || len(assistant.ToolCalls) != 2 {
+		t.Fatalf("expected merged assistant text+tool_calls, got %s", raw)
+	}
+	if fn, _ := assistant.ToolCalls[1]["function"].(map[string]any); fn["arguments"] != "{}" {
+		t.Fatalf("expected empty arguments to default to {}, got %#v", assistant.ToolCalls[1])
+	}
+	if got.Messages[4].ToolCallID != "call_1" || got.Messages[5].Content != "ok2" {
+		t.Fatalf("unexpected tool messages: %s", raw)
+	}
+	if len(got.Tools) != 1 || got.ToolChoice["type"] != "function" {
+		t.Fatalf("expected function tools only with mapped tool_choice: %s", raw)
+	}
+	if got.ParallelToolCalls == nil || *got.ParallelToolCalls {
+		t.Fatalf("expected parallel_tool_calls=false: %s", raw)
+	}
+	if got.ReasoningEffort != "high" || got.MaxTokens != 64 || got.StreamOptions["include_usage"] != true {
+		t.Fatalf("unexpected reasoning/max_tokens/stream_options: %s", raw)
+	}
+	if got.ResponseFormat["type"] != "json_schema" {
+		t.Fatalf("unexpected response_format: %s", raw)
+	}
+	for _, key := range []string{"input", "instructions", "prompt_cache_key", "store", "reasoning", "text"} {
+		if _, ok := out[key]; ok {
+			t.Fatalf("expected %s to be dropped: %s", key, raw)
+		}
+	}
+
+	if _, err := responsesPayloadToChat(map[string]any{"model": "gpt-x", "input": "hi", "previous_response_id": "resp_1"}); !errors.Is(err, errResponsesChatBridgeUnsupported) {
+		t.Fatalf("expected previous_response_id to be unsupported, got %v", err)
+	}
+}
+
+func TestChatCompletionToResponse_MapsOutputItemsAndUsage(t *testing.T) {
+	body := []byte(`{"id":"chatcmpl-9","created":1700000000,"model":"gpt-x","choices":[{"index":0,"message":{"role":"assistant","content":"hi","reasoning_content":"think","tool_calls":[{"id":"call_1","type":"function","function":{"name":"get","arguments":"{}"}}]},"finish_reason":"length"}],"usage":{"prompt_tokens":10,"completion_tokens":5,"prompt_tokens_details":{"cached_tokens":4},"completion_tokens_details":{"reasoning_tokens":2}}}`)
+	out, err := chatCompletionToResponse(body)
+	if err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	var got struct {
+		ID                string           `json:"id"`
+		Object            string           `json:"object"`
+		Status            string           `json:"status"`
+		IncompleteDetails map[string]any   `json:"incomplete_details"`
+		Output            []map[string]any `json:"output"`
+		Usage             map[string]any   `json:"usage"`
+	}
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got.ID != "resp_9" || got.Object != "response" || got.Status != "incomplete" || got.IncompleteDetails["reason"] != "max_output_tokens" {
+		t.Fatalf("unexpected envelope: %s", out)
+	}
+	if len(got.Output) != 3 || got.Output[0]["type"] != "reasoning" || got.Output[1]["type"] != "message" || got.Output[2]["call_id"] != "call_1" {
+		t.Fatalf("unexpected output: %s", out)
+	}
+	if got.Usage["input_tokens"] != float64(10) || got.Usage["output_tokens"] != float64(5) || got.Usage["total_tokens"] != float64(15) {
+		t.Fatalf("unexpected usage: %s", out)
+	}
+	if det, _ := got.Usage["input_tokens_details"].(map[string]any); det["cached_tokens"] != float64(4) {
+		t.Fatalf("unexpected cached tokens: %s", out)
+	}
+}
+
+func TestChatToResponsesStreamTransformer_SynthesizesResponseEvents(t *testing.T) {
+	outs := collectAnthropicStream(t, newChatToResponsesStreamTransformer(), []string{
+		`{"id":"chatcmpl-1","model":"gpt-x","choices":[{"index":0,"delta":{"role":"assistant","content":"he"}}]}`,
+		`{"id":"chatcmpl-1","model":"gpt-x","choices":[{"index":0,"delta":{"content":"llo"}}]}`,
+		`{"id":"chatcmpl-1","model":"gpt-x","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get","arguments":"{\"a\""}}]}}]}`,
+		`{"id":"chatcmpl-1","model":"gpt-x","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":":1}"}}]}}]}`,
+		`{"id":"chatcmpl-1","model":"gpt-x","choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}`,
+		`{"id":"chatcmpl-1","model":"gpt-x","choices":[],"usage":{"prompt_tokens":7,"completion_tokens":3}}`,
+		`[DONE]`,
+	})
+	want := strings.Join([]string{
+		"response.created", "response.in_progress",
+		"response.output_item.added", "response.content_part.added", "response.output_text.delta", "response.output_text.delta",
+		"response.output_text.done", "response.content_part.done", "response.output_item.done",
+		"response.output_item.added", "response.function_call_arguments.delta", "response.function_call_arguments.delta",
+		"response.function_call_arguments.done", "response.output_item.done",
+		"response.completed",
+	}, ",")
+	if got := anthropicEventNames(outs); got != want {
+		t.Fatalf("unexpected events:\n got=%s\nwant=%s", got, want)
+	}
+	var completed struct {
+		SequenceNumber int `json:"sequence_number"`
+		Response       struct {
+			ID     string           `json:"id"`
+			Status string           `json:"status"`
+			Output []map[string]any `json:"output"`
+			Usage  map[string]any   `json:"usage"`
+		} `json:"response"`
+	}
+	if err := json.Unmarshal([]byte(outs[len(outs)-1].Data), &completed); err != nil {
+		t.Fatalf("unmarshal response.completed: %v", err)
+	}
+	if completed.SequenceNumber != len(outs)-1 || completed.Response.ID != "resp_1" || completed.Response.Status != "completed" {
+		t.Fatalf("unexpected response.completed: %s", outs[len(outs)-1].Data)
+	}
+	if len(completed.Response.Output) != 2 || completed.Response.Output[1]["arguments"] != `{"a":1}` {
+		t.Fatalf("unexpected output items: %s", outs[len(outs)-1].Data)
+	}
+	if completed.Response.Usage["input_tokens"] != float64(7) || completed.Response.Usage["output_tokens"] != float64(3) {
+		t.Fatalf("unexpected usage: %#v", completed.Response.Usage)
+	}
+}
+
+func TestResponses_ChatOnlyChannelServedViaBridge(t *testing.T) {
+	const groupName = "g1"
+	fs := &fakeStore{
+		channels: []store.UpstreamChannel{
+			{ID: 1, Type: store.UpstreamTypeOpenAICompatible, Status: 1, Groups: groupName, Setting: store.UpstreamChannelSetting{ChatCompletionsEnabled: true, PassThroughBodyEnabled: true}},
+		},
+		endpoints: map[int64][]store.UpstreamEndpoint{
+			1: {{ID: 11, ChannelID: 1, BaseURL: "https://a.example", Status: 1}},
+		},
+		creds: map[int64][]store.OpenAICompatibleCredential{
+			11: {{ID: 1, EndpointID: 11, Status: 1}},
+		},
+		models: map[string]store.ManagedModel{
+			"gpt-x": {ID: 1, PublicID: "gpt-x", GroupName: groupName, Status: 1},
+		},
+		bindings: map[string][]store.ChannelModelBinding{
+			"gpt-x": {{ID: 1, ChannelID: 1, ChannelType: store.UpstreamTypeOpenAICompatible, PublicID: "gpt-x", UpstreamModel: "deepseek-chat", Status: 1}},
+		},
+	}
+
+	var gotPaths []string
+	var gotBody []byte
+	doer := DoerFunc(func(_ context.Context, _ scheduler.Selection, downstream *http.Request, body []byte) (*http.Response, error) {
+		gotPaths = append(gotPaths, downstream.URL.Path)
+		gotBody = body
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(bytes.NewReader([]byte(`{"id":"chatcmpl-1","object":"chat.completion","model":"deepseek-chat","choices":[{"index":0,"message":{"role":"assistant","content":"pong"},"finish_reason":"stop"}],"usage":{"prompt_tokens":6,"completion_tokens":2}}`))),
+		}, nil
+	})
+
+	q := &fakeQuota{}
+	h := NewHandler(fs, fs, scheduler.New(fs), doer, nil, nil, q, fakeAudit{}, nil, nil, upstream.SSEPumpOptions{}, nil)
+	tokenID := int64(123)
+	p := auth.Principal{ActorType: auth.ActorTypeToken, UserID: 10, Role: store.UserRoleUser, TokenID: &tokenID, Groups: []string{groupName}}
+	serve := func(path string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com"+path, bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/json")
+		req = req.WithContext(auth.WithPrincipal(req.Context(), p))
+		rr := httptest.NewRecorder()
+		middleware.Chain(http.HandlerFunc(h.Responses), middleware.BodyCache(1<<20)).ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := serve("/v1/responses", `{"model":"gpt-x","instructions":"s","input":"ping","max_output_tokens":32}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d body=%s", rr.Code, rr.Body.String())
+	}
+	if len(gotPaths) != 1 || gotPaths[0] != "/v1/chat/completions" {
+		t.Fatalf("expected upstream path /v1/chat/completions, got=%v", gotPaths)
+	}
+	var forwarded map[string]any
+	if err := json.Unmarshal(gotBody, &forwarded); err != nil {
+		t.Fatalf("unmarshal forwarded body: %v", err)
+	}
+	if forwarded["model"] != "deepseek-chat" || forwarded["max_tokens"] != float64(32) {
+		t.Fatalf("unexpected forwarded body (pass_through_body must not apply): %s", gotBody)
+	}
+	if msgs, _ := forwarded["messages"].([]any); len(msgs) != 2 {
+		t.Fatalf("expected system+user chat messages, body=%s", gotBody)
+	}
+
+	var resp struct {
+		Object string           `json:"object"`
+		Status string           `json:"status"`
+		Output []map[string]any `json:"output"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal response: %v body=%s", err, rr.Body.String())
+	}
+	if resp.Object != "response" || resp.Status != "completed" || len(resp.Output) != 1 || resp.Output[0]["type"] != "message" {
+		t.Fatalf("unexpected translated response: %s", rr.Body.String())
+	}
+	if len(q.commitCalls) != 1 {
+		t.Fatalf("expected 1 commit call, got=%d", len(q.commitCalls))
+	}
+	commit := q.commitCalls[0]
+	if commit.InputTokens == nil || *commit.InputTokens != 6 || commit.OutputTokens == nil || *commit.OutputTokens != 2 {
+		t.Fatalf("unexpected committed usage: in=%v out=%v", commit.InputTokens, commit.OutputTokens)
+	}
+
+	// 有状态续链无法桥接：不转发到 chat-only 渠道。
+	rr = serve("/v1/responses", `{"model":"gpt-x","input":"again","previous_response_id":"resp_1"}`)
+	if rr.Code == http.StatusOK || len(gotPaths) != 1 {
+		t.Fatalf("expected previous_response_id to skip bridged channel, status=%d paths=%v", rr.Code, gotPaths)
+	}
+}
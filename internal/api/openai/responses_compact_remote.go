@@ -252,6 +252,9 @@ func (h *Handler) ResponsesCompact(w http.ResponseWriter, r *http.Request) {
 			MaxOutputTokens: maxOut,
 		})
 		if err != nil {
+			if writeTokenLimitReserveError(w, err) {
+				return
+			}
 			if msg := reserveBadRequestMessage(err); msg != "" {
 				writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", msg)
 				return
@@ -0,0 +1,137 @@
+package openai
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"realms/internal/auth"
+	"realms/internal/scheduler"
+	"realms/internal/store"
+)
+
+// RouteExplainInput 描述一次路由解释：以指定 token 的用户与渠道组、模型和接口路径做 dry-run 路由。
+type RouteExplainInput struct {
+	UserID   int64
+	Groups   []string
+	Model    string
+	Endpoint string
+	// RouteKey 为可选的会话键（如 prompt_cache_key/session_id）；非空时按粘性路由与会话绑定解释。
+	RouteKey string
+}
+
+// routeExplainConstraints 按数据面接口路径还原该接口对上游能力的约束，与各入口 handler 保持一致。
+func routeExplainConstraints(endpoint string) (scheduler.Constraints, error) {
+	var cons scheduler.Constraints
+	switch {
+	case endpoint == "/v1/responses":
+		cons.RequireAPI = scheduler.RequiredAPIResponses
+	case strings.HasPrefix(endpoint, "/v1/responses/"):
+		cons.RequireAPI = scheduler.RequiredAPIResponsesNative
+	case endpoint == "/v1/chat/completions":
+		cons.RequireAPI = scheduler.RequiredAPIChatCompletions
+	case endpoint == "/v1/messages":
+		cons.RequireAPI = scheduler.RequiredAPIMessages
+	case endpoint == "/v1/messages/count_tokens":
+		cons.RequireChannelType = store.UpstreamTypeAnthropic
+		cons.RequireAPI = scheduler.RequiredAPIMessages
+	case endpoint == "/v1/embeddings":
+		cons.RequireAPI = scheduler.RequiredAPIEmbeddings
+	case strings.HasPrefix(endpoint, "/v1beta/"):
+		cons.RequireAPI = scheduler.RequiredAPIGemini
+	default:
+		return scheduler.Constraints{}, fmt.Errorf("不支持的 endpoint: %s", endpoint)
+	}
+	return cons, nil
+}
+
+// ExplainRoute 以 dry-run 方式还原一次真实请求的路由决策（不认领探测、不计 RPM、不写 affinity/组指针），
+// 返回候选顺序、各层跳过原因与最终选择；请求本身不合法（模型未启用、无权限等）时返回错误。
+func (h *Handler) ExplainRoute(ctx context.Context, in RouteExplainInput) (*scheduler.RouteTrace, error) {
+	if h == nil || h.sched == nil || h.groups == nil {
+		return nil, errors.New("调度器未配置")
+	}
+	if h.models == nil {
+		return nil, errors.New("服务未配置模型目录")
+	}
+	publicModel := strings.TrimSpace(in.Model)
+	if publicModel == "" {
+		return nil, errors.New("model 不能为空")
+	}
+	cons, err := routeExplainConstraints(strings.TrimSpace(in.Endpoint))
+	if err != nil {
+		return nil, err
+	}
+
+	ags := allowGroupsFromPrincipal(auth.Principal{Groups: in.Groups})
+	if len(ags.Order) == 0 {
+		return nil, errors.New("Token 未配置渠道组")
+	}
+	cons.AllowGroups = ags.Set
+	cons.AllowGroupOrder = ags.Order
+	cons.SequentialChannelFailover = true
+
+	freeMode := false
+	modelPassthrough := false
+	if h.features != nil {
+		fs := h.features.FeatureStateEffective(ctx)
+		freeMode = fs.BillingDisabled
+		modelPassthrough = fs.ModelsDisabled
+	}
+	if modelPassthrough {
+		// 与数据面一致：非 free_mode 下仍要求模型定价存在，且模型所属渠道组在 Token 允许范围内。
+		if !freeMode {
+			mm, err := h.models.GetManagedModelByPublicID(ctx, publicModel)
+			if err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					return nil, errors.New("模型不存在")
+				}
+				return nil, errors.New("查询模型失败")
+			}
+			if _, ok := ags.Set[managedModelGroupName(mm)]; !ok {
+				return nil, errors.New("无权限使用该模型")
+			}
+		}
+		if bindings, err := h.models.ListEnabledChannelModelBindingsByPublicID(ctx, publicModel); err == nil {
+			resolved := resolveChannelModelBindings(bindings, cons.RequireChannelType)
+			if !resolved.Empty() {
+				resolved.ApplyToConstraints(&cons)
+			}
+		}
+	} else {
+		mm, err := h.models.GetEnabledManagedModelByPublicID(ctx, publicModel)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, errors.New("模型未启用")
+			}
+			return nil, errors.New("查询模型失败")
+		}
+		if _, ok := ags.Set[managedModelGroupName(mm)]; !ok {
+			return nil, errors.New("无权限使用该模型")
+		}
+		bindings, err := h.models.ListEnabledChannelModelBindingsByPublicID(ctx, publicModel)
+		if err != nil {
+			return nil, errors.New("查询模型绑定失败")
+		}
+		resolved := resolveChannelModelBindings(bindings, cons.RequireChannelType)
+		if resolved.Empty() {
+			return nil, errors.New("模型未配置可用上游")
+		}
+		resolved.ApplyToConstraints(&cons)
+	}
+
+	routeKeyHash := h.sched.RouteKeyHash(normalizeRouteKey(in.RouteKey))
+	if boundRoute, ok := h.loadCodexStickyBinding(ctx, in.UserID, routeKeyHash, time.Now()); ok && boundRoute.channelID > 0 {
+		cons.StartChannelID = boundRoute.channelID
+		cons.RouteGroupHint = boundRoute.routeGroup
+		if strings.TrimSpace(boundRoute.credentialKey) != "" {
+			cons.RequireChannelID = boundRoute.channelID
+			cons.RequireCredentialKey = boundRoute.credentialKey
+		}
+	}
+
+	return h.sched.ExplainRoute(ctx, h.groups, in.UserID, routeKeyHash, cons), nil
+}
@@ -0,0 +1,36 @@
+package openai
+
+import (
+	"testing"
+
+	"realms/internal/scheduler"
+	"realms/internal/store"
+)
+
+func TestRouteExplainConstraints_MatchesEntrypoints(t *testing.T) {
+	cases := []struct {
+		endpoint    string
+		requireAPI  string
+		channelType string
+	}{
+		{"/v1/responses", scheduler.RequiredAPIResponses, ""},
+		{"/v1/responses/input_tokens", scheduler.RequiredAPIResponsesNative, ""},
+		{"/v1/chat/completions", scheduler.RequiredAPIChatCompletions, ""},
+		{"/v1/messages", scheduler.RequiredAPIMessages, ""},
+		{"/v1/messages/count_tokens", scheduler.RequiredAPIMessages, store.UpstreamTypeAnthropic},
+		{"/v1/embeddings", scheduler.RequiredAPIEmbeddings, ""},
+		{"/v1beta/models/gemini-2.5-pro:generateContent", scheduler.RequiredAPIGemini, ""},
+	}
+	for _, tc := range cases {
+		cons, err := routeExplainConstraints(tc.endpoint)
+		if err != nil {
+			t.Fatalf("%s: unexpected err: %v", tc.endpoint, err)
+		}
+		if cons.RequireAPI != tc.requireAPI || cons.RequireChannelType != tc.channelType {
+			t.Fatalf("%s: got api=%q type=%q", tc.endpoint, cons.RequireAPI, cons.RequireChannelType)
+		}
+	}
+	if _, err := routeExplainConstraints("/v1/unknown"); err == nil {
+		t.Fatalf("expected unsupported endpoint error")
+	}
+}
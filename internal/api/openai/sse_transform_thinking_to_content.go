@@ -3,6 +3,8 @@ package openai
 import (
 	"encoding/json"
 	"strings"
+
+	"realms/internal/upstream"
 )
 
 type thinkingToContentState struct {
@@ -11,6 +13,25 @@ type thinkingToContentState struct {
 	hasThinking   bool
 }
 
+// dataOnlyTransform 把仅改写 data payload 的转换器适配为 upstream.SSEPumpHooks.TransformData：
+// 具名事件与 [DONE] 原样透传；转换器返回空时同样透传原始事件。
+func dataOnlyTransform(fn func(data string) ([]string, error)) func(event string, data string) ([]upstream.SSEEvent, bool, error) {
+	return func(event string, data string) ([]upstream.SSEEvent, bool, error) {
+		if event != "" || data == "[DONE]" {
+			return nil, false, nil
+		}
+		outs, err := fn(data)
+		if err != nil || len(outs) == 0 {
+			return nil, false, err
+		}
+		events := make([]upstream.SSEEvent, 0, len(outs))
+		for _, out := range outs {
+			events = append(events, upstream.SSEEvent{Data: out})
+		}
+		return events, true, nil
+	}
+}
+
 func newThinkingToContentTransformer() func(data string) ([]string, error) {
 	st := &thinkingToContentState{
 		firstThinking: true,
@@ -4,8 +4,10 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net/netip"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -128,6 +130,10 @@ type RedisConfig struct {
 	Password  string `yaml:"password"`
 	DB        int    `yaml:"db"`
 	KeyPrefix string `yaml:"key_prefix"`
+
+	// SchedulerState 为 true 时调度运行态（封禁/冷却/探测占用/失败计分/RPM/亲和）存入 Redis，
+	// 多副本部署共享同一份状态；默认仅进程内存。
+	SchedulerState bool `yaml:"scheduler_state"`
 }
 
 type GatewayConfig struct {
@@ -143,6 +149,29 @@ type GatewayConfig struct {
 	WaitTimeoutMS            int `yaml:"wait_timeout_ms"`
 	WaitQueueExtraSlots      int `yaml:"wait_queue_extra_slots"`
 
+	// BatchConcurrency 为 Batch API 后台执行时单个批任务的并发行数上限。
+	BatchConcurrency int `yaml:"batch_concurrency"`
+
+	// LatencyRouting 为 true 时，同优先级且未配置权重的渠道按观测首字延迟（EWMA）优先较快者。默认关闭。
+	LatencyRouting bool `yaml:"latency_routing"`
+	// LatencyExplorationRatio 为每次调度跳过延迟排序、随机打散同层渠道的概率（0~1），
+	// 使较慢渠道仍能获得流量以便重新测量。
+	LatencyExplorationRatio float64 `yaml:"latency_exploration_ratio"`
+
+	// ChannelProbe 为 true 时由后台对到期待探测的渠道发起合成请求（使用渠道 test_model），
+	// 探测结果决定解封或延长封禁；用户请求不再被当作探测流量。默认关闭（会产生额外上游请求与费用）。
+	ChannelProbe bool `yaml:"channel_probe"`
+	// ChannelProbeIntervalMS 为后台探测的扫描间隔。
+	ChannelProbeIntervalMS int `yaml:"channel_probe_interval_ms"`
+
+	// CanaryRollbackErrorRate 为灰度渠道成员自动回滚的错误率阈值（0~1）；<=0 关闭自动回滚。
+	CanaryRollbackErrorRate float64 `yaml:"canary_rollback_error_rate"`
+	// CanaryRollbackMinRequests 为触发自动回滚所需的最少灰度请求数，避免样本过少误判。
+	CanaryRollbackMinRequests int `yaml:"canary_rollback_min_requests"`
+
+	// RoutingTrace 为 true 时记录每次请求的路由决策（候选顺序、跳过原因、最终选择）并写入 usage_event 明细。
+	RoutingTrace bool `yaml:"routing_trace"`
+
 	EnableErrorPassthrough bool `yaml:"enable_error_passthrough"`
 }
 
@@ -152,6 +181,39 @@ type SecurityConfig struct {
 	// SubscriptionOrderWebhookSecret 用于支付回调等“系统侧”操作的简单鉴权。
 	// 为空表示禁用相关 webhook（避免未配置时被外部直接调用）。
 	SubscriptionOrderWebhookSecret string `yaml:"subscription_order_webhook_secret"`
+
+	// TrustProxyHeaders 为 true 时才会信任可信代理转发的 X-Forwarded-* / X-Real-IP 头；默认开启。
+	TrustProxyHeaders bool `yaml:"trust_proxy_headers"`
+	// TrustedProxyCIDRs 为可信反向代理的地址段：仅当请求直连来源命中时，才从 X-Forwarded-* / X-Real-IP
+	// 推断客户端 IP（Token 来源 IP 限制）与协议（会话 Cookie Secure 标记）。默认仅信任本机回环地址。
+	TrustedProxyCIDRs []string `yaml:"trusted_proxy_cidrs"`
+}
+
+// TrustedProxyPrefixes 返回已校验的可信代理地址段（需在 normalizeAndValidate 之后调用）。
+func (c SecurityConfig) TrustedProxyPrefixes() []netip.Prefix {
+	out := make([]netip.Prefix, 0, len(c.TrustedProxyCIDRs))
+	for _, raw := range c.TrustedProxyCIDRs {
+		if pfx, err := parseTrustedProxyCIDR(raw); err == nil {
+			out = append(out, pfx)
+		}
+	}
+	return out
+}
+
+func parseTrustedProxyCIDR(raw string) (netip.Prefix, error) {
+	raw = strings.TrimSpace(raw)
+	if strings.Contains(raw, "/") {
+		pfx, err := netip.ParsePrefix(raw)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return pfx.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(raw)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
 }
 
 type DebugConfig struct {
@@ -284,6 +346,9 @@ func normalizeAndValidate(cfg Config) (Config, error) {
 	if cfg.Redis.DB < 0 {
 		return Config{}, errors.New("redis.db 不能为负数")
 	}
+	if cfg.Redis.SchedulerState && cfg.Redis.Addr == "" {
+		return Config{}, errors.New("redis.scheduler_state 需要配置 redis.addr")
+	}
 
 	if cfg.Gateway.MaxRetryAttempts < 0 {
 		cfg.Gateway.MaxRetryAttempts = 0
@@ -312,8 +377,45 @@ func normalizeAndValidate(cfg Config) (Config, error) {
 	if cfg.Gateway.WaitQueueExtraSlots < 0 {
 		cfg.Gateway.WaitQueueExtraSlots = 0
 	}
+	if cfg.Gateway.BatchConcurrency <= 0 {
+		cfg.Gateway.BatchConcurrency = 4
+	}
+	if cfg.Gateway.BatchConcurrency > 64 {
+		cfg.Gateway.BatchConcurrency = 64
+	}
+	if cfg.Gateway.LatencyExplorationRatio < 0 {
+		cfg.Gateway.LatencyExplorationRatio = 0
+	}
+	if cfg.Gateway.LatencyExplorationRatio > 1 {
+		cfg.Gateway.LatencyExplorationRatio = 1
+	}
+	if cfg.Gateway.ChannelProbeIntervalMS <= 0 {
+		cfg.Gateway.ChannelProbeIntervalMS = 15000
+	}
+	if cfg.Gateway.ChannelProbeIntervalMS < 1000 {
+		cfg.Gateway.ChannelProbeIntervalMS = 1000
+	}
+	if cfg.Gateway.CanaryRollbackErrorRate > 1 {
+		cfg.Gateway.CanaryRollbackErrorRate = 1
+	}
+	if cfg.Gateway.CanaryRollbackMinRequests <= 0 {
+		cfg.Gateway.CanaryRollbackMinRequests = 20
+	}
 
 	cfg.Security.AdminAPIKey = strings.TrimSpace(cfg.Security.AdminAPIKey)
+	trustedProxies := make([]string, 0, len(cfg.Security.TrustedProxyCIDRs))
+	for _, raw := range cfg.Security.TrustedProxyCIDRs {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		pfx, err := parseTrustedProxyCIDR(raw)
+		if err != nil {
+			return Config{}, fmt.Errorf("security.trusted_proxy_cidrs 不合法: %q", raw)
+		}
+		trustedProxies = append(trustedProxies, pfx.String())
+	}
+	cfg.Security.TrustedProxyCIDRs = trustedProxies
 	cfg.SessionSecret = strings.TrimSpace(cfg.SessionSecret)
 	cfg.Tickets.AttachmentsDir = strings.TrimSpace(cfg.Tickets.AttachmentsDir)
 	if cfg.Tickets.AttachmentsDir == "" {
@@ -420,19 +522,28 @@ func defaultConfig() Config {
 			KeyPrefix: "realms",
 		},
 		Gateway: GatewayConfig{
-			MaxRetryAttempts:       5,
-			RetryBaseDelayMS:       300,
-			RetryMaxDelayMS:        3000,
-			MaxRetryElapsedMS:      10000,
-			MaxFailoverSwitches:    8,
-			WaitTimeoutMS:          30000,
-			WaitQueueExtraSlots:    20,
-			EnableErrorPassthrough: true,
+			MaxRetryAttempts:          5,
+			RetryBaseDelayMS:          300,
+			RetryMaxDelayMS:           3000,
+			MaxRetryElapsedMS:         10000,
+			MaxFailoverSwitches:       8,
+			WaitTimeoutMS:             30000,
+			WaitQueueExtraSlots:       20,
+			BatchConcurrency:          4,
+			LatencyExplorationRatio:   0.1,
+			ChannelProbeIntervalMS:    15000,
+			CanaryRollbackErrorRate:   0.2,
+			CanaryRollbackMinRequests: 20,
+			EnableErrorPassthrough:    true,
 		},
 		CompactGateway: CompactGatewayConfig{
 			BaseURL:    "",
 			GatewayKey: "",
 		},
+		Security: SecurityConfig{
+			TrustProxyHeaders: true,
+			TrustedProxyCIDRs: []string{"127.0.0.1/32", "::1/128"},
+		},
 		Debug: DebugConfig{
 			ProxyLog: ProxyLogConfig{
 				Enable: false,
@@ -493,6 +604,14 @@ func applyEnvOverrides(cfg *Config) {
 	if v := os.Getenv("REALMS_SUBSCRIPTION_ORDER_WEBHOOK_SECRET"); v != "" {
 		cfg.Security.SubscriptionOrderWebhookSecret = v
 	}
+	if v := strings.TrimSpace(os.Getenv("REALMS_TRUST_PROXY_HEADERS")); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.Security.TrustProxyHeaders = b
+		}
+	}
+	if v := os.Getenv("REALMS_TRUSTED_PROXY_CIDRS"); v != "" {
+		cfg.Security.TrustedProxyCIDRs = strings.Split(v, ",")
+	}
 	if v := os.Getenv("SESSION_SECRET"); v != "" {
 		cfg.SessionSecret = v
 	}
@@ -10,3 +10,21 @@ func TestNormalizeAndValidate_RejectsPersonalMode(t *testing.T) {
 		t.Fatalf("expected personal mode to be rejected")
 	}
 }
+
+func TestNormalizeAndValidate_SchedulerStateRequiresRedisAddr(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.Redis.SchedulerState = true
+
+	if _, err := normalizeAndValidate(cfg); err == nil {
+		t.Fatalf("expected scheduler_state without redis.addr to be rejected")
+	}
+
+	cfg.Redis.Addr = "127.0.0.1:6379"
+	got, err := normalizeAndValidate(cfg)
+	if err != nil {
+		t.Fatalf("normalizeAndValidate: %v", err)
+	}
+	if !got.Redis.SchedulerState {
+		t.Fatalf("expected scheduler_state to stay enabled")
+	}
+}
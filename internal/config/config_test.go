@@ -82,6 +82,39 @@ func TestLoad_AdminAPIKeyEnvOverride(t *testing.T) {
 	}
 }
 
+func TestLoad_TrustedProxyCIDRsEnvOverride(t *testing.T) {
+	t.Setenv("REALMS_DB_DRIVER", "")
+	t.Setenv("REALMS_DB_DSN", "")
+	t.Setenv("REALMS_SQLITE_PATH", "")
+	t.Setenv("REALMS_TRUSTED_PROXY_CIDRS", " 10.1.2.3/8, 172.16.0.1 ,")
+
+	cfg, err := config.LoadFromEnv()
+	if err != nil {
+		t.Fatalf("LoadFromEnv: %v", err)
+	}
+	got := cfg.Security.TrustedProxyPrefixes()
+	if len(got) != 2 || got[0].String() != "10.0.0.0/8" || got[1].String() != "172.16.0.1/32" {
+		t.Fatalf("unexpected trusted proxies: %v", got)
+	}
+	if !cfg.Security.TrustProxyHeaders {
+		t.Fatalf("expected trust_proxy_headers to default to true")
+	}
+
+	t.Setenv("REALMS_TRUST_PROXY_HEADERS", "false")
+	cfg, err = config.LoadFromEnv()
+	if err != nil {
+		t.Fatalf("LoadFromEnv: %v", err)
+	}
+	if cfg.Security.TrustProxyHeaders {
+		t.Fatalf("expected REALMS_TRUST_PROXY_HEADERS=false to disable proxy headers")
+	}
+
+	t.Setenv("REALMS_TRUSTED_PROXY_CIDRS", "not-a-cidr")
+	if _, err := config.LoadFromEnv(); err == nil {
+		t.Fatalf("expected invalid trusted proxy cidr to be rejected")
+	}
+}
+
 func TestLoad_RuntimeDefaultsStayAvailable(t *testing.T) {
 	t.Setenv("REALMS_DB_DRIVER", "")
 	t.Setenv("REALMS_DB_DSN", "")
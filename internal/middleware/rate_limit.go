@@ -0,0 +1,167 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"realms/internal/auth"
+	"realms/internal/ratelimit"
+	"realms/internal/store"
+)
+
+// rateLimitPolicyTTL 为限流策略的进程内缓存时间；管理端修改上限后最多延迟该时长生效。
+const rateLimitPolicyTTL = 10 * time.Second
+
+// rateLimitPolicyCacheMax 为策略缓存的条目上限：写满时先清理过期条目，仍满则整体清空重建。
+const rateLimitPolicyCacheMax = 10000
+
+type cachedRateLimitPolicy struct {
+	policy    store.RateLimitPolicy
+	expiresAt time.Time
+}
+
+// rateLimitPolicyCache 为按 "<userID>:<tokenID>" 缓存的限流策略，条目数受 rateLimitPolicyCacheMax 约束。
+type rateLimitPolicyCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedRateLimitPolicy
+}
+
+func (c *rateLimitPolicyCache) get(key string, now time.Time) (store.RateLimitPolicy, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok || !now.Before(e.expiresAt) {
+		return store.RateLimitPolicy{}, false
+	}
+	return e.policy, true
+}
+
+func (c *rateLimitPolicyCache) put(key string, policy store.RateLimitPolicy, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[string]cachedRateLimitPolicy)
+	}
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= rateLimitPolicyCacheMax {
+		for k, e := range c.entries {
+			if !now.Before(e.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= rateLimitPolicyCacheMax {
+			c.entries = make(map[string]cachedRateLimitPolicy)
+		}
+	}
+	c.entries[key] = cachedRateLimitPolicy{policy: policy, expiresAt: now.Add(rateLimitPolicyTTL)}
+}
+
+// RateLimit 按用户与 Token 维度执行 RPM/TPM 滑动窗口限流（需位于 TokenAuth 之后）。
+// 命中限流策略时，响应携带 OpenAI 风格（x-ratelimit-*）与 Anthropic 风格（anthropic-ratelimit-*）的限流头（仅含已配置的维度）；
+// 未配置策略时不写限流头，由上游限流头原样透传。超限时返回 429 并附带 Retry-After。策略查询失败时放行，避免数据库抖动阻断数据面。
+func RateLimit(st *store.Store, limiter *ratelimit.Limiter) Middleware {
+	if st == nil || limiter == nil {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	cache := &rateLimitPolicyCache{}
+
+	resolve := func(ctx context.Context, userID, tokenID int64) (store.RateLimitPolicy, bool) {
+		now := time.Now()
+		key := strconv.FormatInt(userID, 10) + ":" + strconv.FormatInt(tokenID, 10)
+		if policy, ok := cache.get(key, now); ok {
+			return policy, true
+		}
+		policy, err := st.GetRateLimitPolicy(ctx, userID, tokenID, now)
+		if err != nil {
+			slog.Warn("查询限流策略失败", "user_id", userID, "token_id", tokenID, "err", err)
+			return store.RateLimitPolicy{}, false
+		}
+		cache.put(key, policy, now)
+		return policy, true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, ok := auth.PrincipalFromContext(r.Context())
+			if !ok || p.UserID <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			var tokenID int64
+			if p.TokenID != nil {
+				tokenID = *p.TokenID
+			}
+			policy, ok := resolve(r.Context(), p.UserID, tokenID)
+			if !ok || policy.Empty() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			scopes := []ratelimit.Scope{{
+				Name:  "user",
+				Key:   ratelimit.UserKey(p.UserID),
+				Limit: ratelimit.Limit{RPM: policy.User.RPM, TPM: policy.User.TPM},
+			}}
+			if tokenID > 0 {
+				scopes = append(scopes, ratelimit.Scope{
+					Name:  "token",
+					Key:   ratelimit.TokenKey(tokenID),
+					Limit: ratelimit.Limit{RPM: policy.Token.RPM, TPM: policy.Token.TPM},
+				})
+			}
+			d := limiter.Take(scopes)
+			setRateLimitHeaders(w.Header(), d, time.Now())
+			if !d.Allowed {
+				writeRateLimitExceeded(w, d)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func setRateLimitHeaders(h http.Header, d ratelimit.Decision, now time.Time) {
+	set := func(kind string, q ratelimit.Quota) {
+		if q.Limit <= 0 {
+			return
+		}
+		limit := strconv.FormatInt(q.Limit, 10)
+		remaining := strconv.FormatInt(q.Remaining, 10)
+		h.Set("x-ratelimit-limit-"+kind, limit)
+		h.Set("x-ratelimit-remaining-"+kind, remaining)
+		h.Set("x-ratelimit-reset-"+kind, q.Reset.Round(time.Millisecond).String())
+		h.Set("anthropic-ratelimit-"+kind+"-limit", limit)
+		h.Set("anthropic-ratelimit-"+kind+"-remaining", remaining)
+		h.Set("anthropic-ratelimit-"+kind+"-reset", now.Add(q.Reset).UTC().Format(time.RFC3339))
+	}
+	set("requests", d.Requests)
+	set("tokens", d.Tokens)
+}
+
+func writeRateLimitExceeded(w http.ResponseWriter, d ratelimit.Decision) {
+	retry := int64(math.Ceil(d.RetryAfter.Seconds()))
+	if retry < 1 {
+		retry = 1
+	}
+	msg := "请求频率超出限制（RPM），请在 " + strconv.FormatInt(retry, 10) + " 秒后重试"
+	if d.Reason == ratelimit.ReasonTokens {
+		msg = "Token 用量超出限制（TPM），请在 " + strconv.FormatInt(retry, 10) + " 秒后重试"
+	}
+	w.Header().Set("Retry-After", strconv.FormatInt(retry, 10))
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusTooManyRequests)
+	// 同时兼容 OpenAI（error.code）与 Anthropic（type=error + error.type）客户端的错误解析。
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"type": "error",
+		"error": map[string]any{
+			"type":    "rate_limit_error",
+			"code":    "rate_limit_exceeded",
+			"message": msg,
+		},
+	})
+}
@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"realms/internal/ratelimit"
+	"realms/internal/store"
+)
+
+func TestRateLimit_TokenRPM(t *testing.T) {
+	st := newTokenAuthTestStore(t)
+	ctx := context.Background()
+
+	userID, err := st.CreateUser(ctx, "bob@example.com", "bob", []byte("pw-hash"), store.UserRoleUser)
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	tokenID, _, err := st.CreateUserToken(ctx, userID, nil, "sk-rate-limited")
+	if err != nil {
+		t.Fatalf("CreateUserToken: %v", err)
+	}
+	if _, _, err := st.CreateUserToken(ctx, userID, nil, "sk-unlimited"); err != nil {
+		t.Fatalf("CreateUserToken: %v", err)
+	}
+	if err := st.UpdateUserTokenLimits(ctx, userID, tokenID, store.UserTokenLimits{RPMLimit: 2, TPMLimit: 1000}); err != nil {
+		t.Fatalf("UpdateUserTokenLimits: %v", err)
+	}
+
+	h := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}), TokenAuth(st, false, nil), RateLimit(st, ratelimit.New(nil)))
+	doWith := func(rawToken string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "http://realms.local/v1/messages", nil)
+		req.Header.Set("x-api-key", rawToken)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+	do := func() *httptest.ResponseRecorder { return doWith("sk-rate-limited") }
+
+	// 未配置上限的 Token 不写网关限流头，留给上游限流头透传。
+	if rr := doWith("sk-unlimited"); rr.Code != http.StatusOK || rr.Header().Get("x-ratelimit-limit-requests") != "" || rr.Header().Get("anthropic-ratelimit-tokens-limit") != "" {
+		t.Fatalf("expected no gateway rate limit headers, got %d headers=%v", rr.Code, rr.Header())
+	}
+
+	rr := do()
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected first request allowed, got %d body=%s", rr.Code, rr.Body.String())
+	}
+	if got := rr.Header().Get("x-ratelimit-limit-requests"); got != "2" {
+		t.Fatalf("expected x-ratelimit-limit-requests=2, got %q", got)
+	}
+	if got := rr.Header().Get("x-ratelimit-remaining-requests"); got != "1" {
+		t.Fatalf("expected x-ratelimit-remaining-requests=1, got %q", got)
+	}
+	if got := rr.Header().Get("anthropic-ratelimit-tokens-limit"); got != "1000" {
+		t.Fatalf("expected anthropic-ratelimit-tokens-limit=1000, got %q", got)
+	}
+	if rr.Header().Get("anthropic-ratelimit-requests-reset") == "" || rr.Header().Get("x-ratelimit-reset-requests") == "" {
+		t.Fatalf("expected reset headers, got %v", rr.Header())
+	}
+
+	if rr := do(); rr.Code != http.StatusOK {
+		t.Fatalf("expected second request allowed, got %d", rr.Code)
+	}
+	rr = do()
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected Retry-After header")
+	}
+	if got := rr.Header().Get("x-ratelimit-remaining-requests"); got != "0" {
+		t.Fatalf("expected x-ratelimit-remaining-requests=0, got %q", got)
+	}
+	if !strings.Contains(rr.Body.String(), "rate_limit_error") {
+		t.Fatalf("expected rate_limit_error body, got %s", rr.Body.String())
+	}
+}
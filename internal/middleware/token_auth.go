@@ -1,22 +1,34 @@
-// Package middleware 提供数据面 Token 鉴权：Authorization: Bearer <token> 或 x-api-key。
+// Package middleware 提供数据面 Token 鉴权：Authorization: Bearer <token>、x-api-key 或 x-goog-api-key（Gemini 原生客户端）。
 package middleware
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
 	"net/http"
+	"net/netip"
+	"net/url"
 	"strings"
 
 	"realms/internal/auth"
+	"realms/internal/security"
 	"realms/internal/store"
 )
 
-func TokenAuth(st *store.Store) Middleware {
+// TokenAuth 校验数据面 Token；trustProxyHeaders/trustedProxies 决定是否及从哪些反向代理信任转发头，
+// 用于解析 Token 来源 IP 限制所需的客户端 IP。
+func TokenAuth(st *store.Store, trustProxyHeaders bool, trustedProxies []netip.Prefix) Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			raw := extractBearer(r.Header.Get("Authorization"))
 			if raw == "" {
 				raw = r.Header.Get("x-api-key")
 			}
+			if raw == "" {
+				raw = r.Header.Get("x-goog-api-key")
+			}
 			if raw == "" {
 				http.Error(w, "未提供 Token", http.StatusUnauthorized)
 				return
@@ -32,9 +44,27 @@ func TokenAuth(st *store.Store) Middleware {
 					http.Error(w, "Token 无效", http.StatusUnauthorized)
 					return
 				}
+				if errors.Is(err, store.ErrUserTokenExpired) {
+					writeTokenAuthError(w, http.StatusUnauthorized, "token_expired", "Token 已过期")
+					return
+				}
 				http.Error(w, "鉴权失败", http.StatusInternalServerError)
 				return
 			}
+			clientIP, _ := security.ClientIPFromRequest(r, trustProxyHeaders, trustedProxies)
+			if reason := tokenAccessDeniedReason(ta.Access, r, clientIP); reason != "" {
+				recordTokenAccessViolation(r.Context(), st, r, ta, clientIP, reason)
+				msg := "Token 不允许从当前 IP 使用"
+				if reason == store.UserTokenAccessDeniedReferer {
+					msg = "Token 不允许从当前来源使用"
+				}
+				writeTokenAuthError(w, http.StatusForbidden, reason, msg)
+				return
+			}
+			if clientIP.IsValid() && clientIP.String() != ta.LastClientIP {
+				_ = st.TouchUserTokenClientIP(r.Context(), ta.TokenID, clientIP.String())
+			}
+
 			tokenID := ta.TokenID
 			p := auth.Principal{
 				ActorType: auth.ActorTypeToken,
@@ -48,6 +78,85 @@ func TokenAuth(st *store.Store) Middleware {
 	}
 }
 
+// tokenAccessDeniedReason 校验 Token 来源限制，返回拒绝原因（通过时为空串）。
+func tokenAccessDeniedReason(access store.UserTokenAccess, r *http.Request, clientIP netip.Addr) string {
+	if !access.Restricted() {
+		return ""
+	}
+	if !access.IPAllowed(clientIP) {
+		return store.UserTokenAccessDeniedIP
+	}
+	if !access.RefererAllowed(requestRefererHost(r)) {
+		return store.UserTokenAccessDeniedReferer
+	}
+	return ""
+}
+
+// requestRefererHost 从 Origin（优先）或 Referer 解析来源主机名（不含端口）。
+func requestRefererHost(r *http.Request) string {
+	for _, name := range []string{"Origin", "Referer"} {
+		raw := strings.TrimSpace(r.Header.Get(name))
+		if raw == "" || raw == "null" {
+			continue
+		}
+		u, err := url.Parse(raw)
+		if err != nil || u.Hostname() == "" {
+			continue
+		}
+		return u.Hostname()
+	}
+	return ""
+}
+
+// recordTokenAccessViolation 写入来源违规审计事件，并在达到违规上限时自动撤销 Token。
+func recordTokenAccessViolation(ctx context.Context, st *store.Store, r *http.Request, ta store.TokenAuth, clientIP netip.Addr, reason string) {
+	userID := ta.UserID
+	tokenID := ta.TokenID
+	ipText := "client_ip=unknown"
+	if clientIP.IsValid() {
+		ipText = "client_ip=" + clientIP.String()
+	}
+	insert := func(action string, class string, msg string) {
+		if err := st.InsertAuditEvent(ctx, store.AuditEventInput{
+			RequestID:    GetRequestID(ctx),
+			ActorType:    string(auth.ActorTypeToken),
+			UserID:       &userID,
+			TokenID:      &tokenID,
+			Action:       action,
+			Endpoint:     r.URL.Path,
+			StatusCode:   http.StatusForbidden,
+			ErrorClass:   &class,
+			ErrorMessage: &msg,
+		}); err != nil {
+			slog.Warn("写入 Token 来源违规审计失败", "token_id", tokenID, "err", err)
+		}
+	}
+	insert("token_access_denied", reason, ipText)
+
+	violations, revoked, err := st.RecordUserTokenAccessViolation(ctx, tokenID)
+	if err != nil {
+		slog.Warn("记录 Token 来源违规失败", "token_id", tokenID, "err", err)
+		return
+	}
+	if revoked {
+		slog.Warn("Token 来源违规次数达到上限，已自动撤销", "token_id", tokenID, "user_id", userID, "violations", violations)
+		insert("token_auto_revoked", reason, ipText)
+	}
+}
+
+// writeTokenAuthError 以 OpenAI 风格的错误体返回鉴权失败，error.code 供客户端区分具体原因。
+func writeTokenAuthError(w http.ResponseWriter, status int, code string, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]any{
+			"type":    "invalid_request_error",
+			"code":    code,
+			"message": message,
+		},
+	})
+}
+
 func extractBearer(v string) string {
 	if v == "" {
 		return ""
@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"realms/internal/store"
+)
+
+func newTokenAuthTestStore(t *testing.T) *store.Store {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "realms.db") + "?_busy_timeout=1000"
+	db, err := store.OpenSQLite(path)
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	if err := store.EnsureSQLiteSchema(db); err != nil {
+		t.Fatalf("EnsureSQLiteSchema: %v", err)
+	}
+	st := store.New(db)
+	st.SetDialect(store.DialectSQLite)
+	return st
+}
+
+func TestTokenAuth_AccessRestrictions(t *testing.T) {
+	st := newTokenAuthTestStore(t)
+	ctx := context.Background()
+
+	userID, err := st.CreateUser(ctx, "alice@example.com", "alice", []byte("pw-hash"), store.UserRoleUser)
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	tokenID, _, err := st.CreateUserToken(ctx, userID, nil, "sk-access")
+	if err != nil {
+		t.Fatalf("CreateUserToken: %v", err)
+	}
+	if err := st.UpdateUserTokenAccess(ctx, userID, tokenID, store.UserTokenAccess{
+		AllowedIPs:      []string{"203.0.113.0/24", "2001:db8::1"},
+		AllowedReferers: []string{"*.example.com"},
+		ViolationLimit:  2,
+	}); err != nil {
+		t.Fatalf("UpdateUserTokenAccess: %v", err)
+	}
+
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+	h := TokenAuth(st, true, trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	do := func(remote string, xff string, origin string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "http://realms.local/v1/responses", nil)
+		req.RemoteAddr = remote
+		req.Header.Set("Authorization", "Bearer sk-access")
+		if xff != "" {
+			req.Header.Set("X-Forwarded-For", xff)
+		}
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := do("10.0.0.5:1234", "203.0.113.9", "https://app.example.com"); rr.Code != http.StatusOK {
+		t.Fatalf("expected allowed request via trusted proxy, got %d body=%s", rr.Code, rr.Body.String())
+	}
+	tok, err := st.GetUserTokenByID(ctx, userID, tokenID)
+	if err != nil {
+		t.Fatalf("GetUserTokenByID: %v", err)
+	}
+	if tok.LastClientIP == nil || *tok.LastClientIP != "203.0.113.9" {
+		t.Fatalf("expected last_client_ip to be recorded, got %v", tok.LastClientIP)
+	}
+
+	// 不可信来源伪造 X-Forwarded-For 不生效。
+	if rr := do("198.51.100.1:1234", "203.0.113.9", "https://app.example.com"); rr.Code != http.StatusForbidden || !strings.Contains(rr.Body.String(), store.UserTokenAccessDeniedIP) {
+		t.Fatalf("expected ip_not_allowed, got %d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := do("[2001:db8::1]:1234", "", "https://example.com"); rr.Code != http.StatusForbidden || !strings.Contains(rr.Body.String(), store.UserTokenAccessDeniedReferer) {
+		t.Fatalf("expected referer_not_allowed, got %d body=%s", rr.Code, rr.Body.String())
+	}
+
+	// 第二次违规达到上限，Token 被自动撤销。
+	if rr := do("203.0.113.9:1234", "", "https://app.example.com"); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected revoked token to be rejected, got %d body=%s", rr.Code, rr.Body.String())
+	}
+	tok, err = st.GetUserTokenByID(ctx, userID, tokenID)
+	if err != nil {
+		t.Fatalf("GetUserTokenByID: %v", err)
+	}
+	if tok.Status != 0 || tok.AccessViolations != 2 {
+		t.Fatalf("expected token auto revoked after 2 violations, got status=%d violations=%d", tok.Status, tok.AccessViolations)
+	}
+}
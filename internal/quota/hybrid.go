@@ -2,6 +2,7 @@ package quota
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"time"
 
@@ -52,6 +53,17 @@ func (p *HybridProvider) paygEnabled(ctx context.Context) bool {
 }
 
 func (p *HybridProvider) Reserve(ctx context.Context, in ReserveInput) (ReserveResult, error) {
+	// 组织 Token 由组织计费（组织订阅 + 组织余额）。
+	if in.TokenID > 0 {
+		billing, err := p.st.GetOrganizationBillingByToken(ctx, in.TokenID)
+		if err == nil {
+			return p.reserveForOrganization(ctx, in, billing)
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return ReserveResult{}, err
+		}
+	}
+
 	if p.sub != nil {
 		res, err := p.sub.Reserve(ctx, in)
 		if err == nil {
@@ -67,7 +79,11 @@ func (p *HybridProvider) Reserve(ctx context.Context, in ReserveInput) (ReserveR
 			return ReserveResult{}, err
 		}
 	}
+	return p.reservePayAsYouGo(ctx, in, nil)
+}
 
+// reservePayAsYouGo 按量计费预留：orgID 非空时从组织余额扣减，否则从用户余额扣减。
+func (p *HybridProvider) reservePayAsYouGo(ctx context.Context, in ReserveInput, orgID *int64) (ReserveResult, error) {
 	reservedUSD := decimal.Zero
 	if in.Model != nil && ((in.InputTokens != nil && *in.InputTokens > 0) || (in.MaxOutputTokens != nil && *in.MaxOutputTokens > 0)) {
 		c, err := estimateCostUSD(ctx, p.st, in.Model, in.ServiceTier, in.InputTokens, nil, in.MaxOutputTokens, nil)
@@ -100,6 +116,7 @@ func (p *HybridProvider) Reserve(ctx context.Context, in ReserveInput) (ReserveR
 		ServiceTier:      in.ServiceTier,
 		ReservedUSD:      reservedUSD,
 		ReserveExpiresAt: now.Add(p.reserveTTL),
+		OrgID:            orgID,
 	})
 	if err != nil {
 		if errors.Is(err, store.ErrInsufficientBalance) {
@@ -0,0 +1,57 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"realms/internal/store"
+)
+
+var (
+	ErrOrganizationDisabled                 = errors.New("组织已停用")
+	ErrOrganizationMemberRequired           = errors.New("Token 创建者已不是组织成员")
+	ErrOrganizationMemberSpendLimitExceeded = store.ErrOrganizationMemberSpendLimitExceeded
+)
+
+// reserveForOrganization 为组织 Token 预留：校验成员资格与成员 30 天消费上限后，
+// 先尝试组织订阅，无订阅或额度不足时（启用按量计费）从组织余额扣费。
+// 这里的上限校验仅用于额度已用尽时尽早拒绝；含本次预留金额的权威校验在 store 预留事务内完成。
+func (p *HybridProvider) reserveForOrganization(ctx context.Context, in ReserveInput, billing store.OrganizationBilling) (ReserveResult, error) {
+	if billing.OrgStatus != 1 {
+		return ReserveResult{}, ErrOrganizationDisabled
+	}
+	if billing.MemberRole == "" {
+		return ReserveResult{}, ErrOrganizationMemberRequired
+	}
+	now := time.Now()
+	if billing.SpendLimit30DUSD != nil {
+		committed, reserved, err := p.st.SumOrganizationMemberUSD(ctx, billing.OrgID, in.UserID, now.Add(-store.OrganizationMemberSpendWindow), now)
+		if err != nil {
+			return ReserveResult{}, err
+		}
+		if committed.Add(reserved).GreaterThanOrEqual(*billing.SpendLimit30DUSD) {
+			return ReserveResult{}, ErrOrganizationMemberSpendLimitExceeded
+		}
+	}
+
+	if p.sub != nil {
+		subs, err := p.st.ListActiveOrganizationSubscriptionsWithPlans(ctx, billing.OrgID, now)
+		if err != nil {
+			return ReserveResult{}, err
+		}
+		res, err := p.sub.reserveFromSubscriptions(ctx, in, subs, billing.OrgID, now)
+		if err == nil {
+			return res, nil
+		}
+		if !errors.Is(err, ErrSubscriptionRequired) && !errors.Is(err, ErrQuotaExceeded) {
+			return ReserveResult{}, err
+		}
+		if !p.paygEnabled(ctx) {
+			return ReserveResult{}, err
+		}
+	}
+
+	orgID := billing.OrgID
+	return p.reservePayAsYouGo(ctx, in, &orgID)
+}
@@ -0,0 +1,93 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"realms/internal/store"
+)
+
+func TestHybridProvider_OrganizationTokenBillsOrganization(t *testing.T) {
+	st := newQuotaTestStore(t)
+	ctx := context.Background()
+
+	ownerID, _ := createQuotaTestUser(t, st, ctx, "owner@example.com", "owner")
+	memberID, _ := createQuotaTestUser(t, st, ctx, "member@example.com", "member")
+	orgID, err := st.CreateOrganization(ctx, "acme", ownerID)
+	if err != nil {
+		t.Fatalf("CreateOrganization: %v", err)
+	}
+	if err := st.AddOrganizationMember(ctx, orgID, memberID, store.OrgRoleMember); err != nil {
+		t.Fatalf("AddOrganizationMember: %v", err)
+	}
+	tokenID, _, err := st.CreateOrganizationToken(ctx, orgID, memberID, nil, "tok_org_member_test")
+	if err != nil {
+		t.Fatalf("CreateOrganizationToken: %v", err)
+	}
+
+	p := NewHybridProvider(st, 0, true)
+	in := ReserveInput{RequestID: "org-1", UserID: memberID, TokenID: tokenID}
+
+	// 个人余额与组织余额均为 0：组织 Token 应因组织余额不足被拒绝。
+	if _, err := p.Reserve(ctx, in); !errors.Is(err, ErrInsufficientBalance) {
+		t.Fatalf("expected ErrInsufficientBalance, got %v", err)
+	}
+
+	if _, err := st.AddOrganizationBalanceUSDByAdmin(ctx, orgID, ownerID, decimal.RequireFromString("10"), ""); err != nil {
+		t.Fatalf("AddOrganizationBalanceUSDByAdmin: %v", err)
+	}
+	res, err := p.Reserve(ctx, in)
+	if err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	if err := p.Commit(ctx, CommitInput{UsageEventID: res.UsageEventID}); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	orgBal, err := st.GetOrganizationBalanceUSD(ctx, orgID)
+	if err != nil {
+		t.Fatalf("GetOrganizationBalanceUSD: %v", err)
+	}
+	if !orgBal.LessThan(decimal.NewFromInt(10)) {
+		t.Fatalf("expected org balance to be debited, got %s", orgBal)
+	}
+	userBal, err := st.GetUserBalanceUSD(ctx, memberID)
+	if err != nil {
+		t.Fatalf("GetUserBalanceUSD: %v", err)
+	}
+	if !userBal.IsZero() {
+		t.Fatalf("expected personal balance untouched, got %s", userBal)
+	}
+
+	// 已消费额未达上限，但加上本次预留会超过上限时，在预留事务内拒绝。
+	committed, reserved, err := st.SumOrganizationMemberUSD(ctx, orgID, memberID, time.Now().Add(-store.OrganizationMemberSpendWindow), time.Now())
+	if err != nil {
+		t.Fatalf("SumOrganizationMemberUSD: %v", err)
+	}
+	headroom := committed.Add(reserved).Add(decimal.RequireFromString("0.0005"))
+	if err := st.UpdateOrganizationMember(ctx, orgID, memberID, store.OrgRoleMember, &headroom); err != nil {
+		t.Fatalf("UpdateOrganizationMember: %v", err)
+	}
+	if _, err := p.Reserve(ctx, ReserveInput{RequestID: "org-headroom", UserID: memberID, TokenID: tokenID}); !errors.Is(err, ErrOrganizationMemberSpendLimitExceeded) {
+		t.Fatalf("expected ErrOrganizationMemberSpendLimitExceeded with pending reservation, got %v", err)
+	}
+
+	// 成员 30 天消费上限低于已消费额时拒绝。
+	limit := decimal.RequireFromString("0.000001")
+	if err := st.UpdateOrganizationMember(ctx, orgID, memberID, store.OrgRoleMember, &limit); err != nil {
+		t.Fatalf("UpdateOrganizationMember: %v", err)
+	}
+	if _, err := p.Reserve(ctx, ReserveInput{RequestID: "org-2", UserID: memberID, TokenID: tokenID}); !errors.Is(err, ErrOrganizationMemberSpendLimitExceeded) {
+		t.Fatalf("expected ErrOrganizationMemberSpendLimitExceeded, got %v", err)
+	}
+
+	if err := st.UpdateOrganization(ctx, orgID, "acme", 0); err != nil {
+		t.Fatalf("UpdateOrganization: %v", err)
+	}
+	if _, err := p.Reserve(ctx, ReserveInput{RequestID: "org-3", UserID: memberID, TokenID: tokenID}); !errors.Is(err, ErrOrganizationDisabled) {
+		t.Fatalf("expected ErrOrganizationDisabled, got %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package quota
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"realms/internal/ratelimit"
+)
+
+// rateLimitPendingTTL 为预留未结算记录的最长保留时间；超时的记录视为已丢弃，避免异常路径导致内存增长。
+const rateLimitPendingTTL = 30 * time.Minute
+
+type rateLimitPending struct {
+	keys       []string
+	reservedAt time.Time
+}
+
+// RateLimitProvider 在结算时把实际消耗的 Token 数计入 TPM 滑动窗口（用户与 Token 两个维度）。
+// Commit/Void 只携带 UsageEventID，因此在 Reserve 时记录 UsageEventID 到限流维度的映射。
+type RateLimitProvider struct {
+	next    Provider
+	limiter *ratelimit.Limiter
+
+	mu      sync.Mutex
+	pending map[int64]rateLimitPending
+	calls   int
+}
+
+func NewRateLimitProvider(next Provider, limiter *ratelimit.Limiter) *RateLimitProvider {
+	return &RateLimitProvider{next: next, limiter: limiter, pending: make(map[int64]rateLimitPending)}
+}
+
+func (p *RateLimitProvider) Reserve(ctx context.Context, in ReserveInput) (ReserveResult, error) {
+	res, err := p.next.Reserve(ctx, in)
+	if err != nil || p.limiter == nil || res.UsageEventID <= 0 {
+		return res, err
+	}
+	keys := []string{ratelimit.UserKey(in.UserID)}
+	if in.TokenID > 0 {
+		keys = append(keys, ratelimit.TokenKey(in.TokenID))
+	}
+	now := time.Now()
+	p.mu.Lock()
+	p.pending[res.UsageEventID] = rateLimitPending{keys: keys, reservedAt: now}
+	p.calls++
+	if p.calls%1024 == 0 {
+		for id, v := range p.pending {
+			if now.Sub(v.reservedAt) > rateLimitPendingTTL {
+				delete(p.pending, id)
+			}
+		}
+	}
+	p.mu.Unlock()
+	return res, nil
+}
+
+func (p *RateLimitProvider) Commit(ctx context.Context, in CommitInput) error {
+	if err := p.next.Commit(ctx, in); err != nil {
+		return err
+	}
+	pending, ok := p.take(in.UsageEventID)
+	if !ok {
+		return nil
+	}
+	var tokens int64
+	if in.InputTokens != nil {
+		tokens += *in.InputTokens
+	}
+	if in.OutputTokens != nil {
+		tokens += *in.OutputTokens
+	}
+	p.limiter.RecordTokens(pending.keys, tokens)
+	return nil
+}
+
+func (p *RateLimitProvider) Void(ctx context.Context, usageEventID int64) error {
+	p.take(usageEventID)
+	return p.next.Void(ctx, usageEventID)
+}
+
+func (p *RateLimitProvider) take(usageEventID int64) (rateLimitPending, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	v, ok := p.pending[usageEventID]
+	if ok {
+		delete(p.pending, usageEventID)
+	}
+	return v, ok
+}
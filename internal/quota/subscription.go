@@ -37,6 +37,11 @@ func (p *SubscriptionProvider) Reserve(ctx context.Context, in ReserveInput) (Re
 	if err != nil {
 		return ReserveResult{}, err
 	}
+	return p.reserveFromSubscriptions(ctx, in, subs, 0, now)
+}
+
+// reserveFromSubscriptions 在给定订阅中选出首个各窗口额度均充足者预留；orgID 非 0 时为组织订阅（按组织汇总窗口用量）。
+func (p *SubscriptionProvider) reserveFromSubscriptions(ctx context.Context, in ReserveInput, subs []store.SubscriptionWithPlan, orgID int64, now time.Time) (ReserveResult, error) {
 	if len(subs) == 0 {
 		return ReserveResult{}, ErrSubscriptionRequired
 	}
@@ -100,6 +105,7 @@ func (p *SubscriptionProvider) Reserve(ctx context.Context, in ReserveInput) (Re
 				SubscriptionID: row.Subscription.ID,
 				Since:          since,
 				Now:            now,
+				OrgID:          orgID,
 			})
 			if err != nil {
 				return ReserveResult{}, err
@@ -119,6 +125,10 @@ func (p *SubscriptionProvider) Reserve(ctx context.Context, in ReserveInput) (Re
 		return ReserveResult{}, ErrQuotaExceeded
 	}
 
+	var reserveOrgID *int64
+	if orgID > 0 {
+		reserveOrgID = &orgID
+	}
 	id, err := p.st.ReserveUsage(ctx, store.ReserveUsageInput{
 		RequestID:        in.RequestID,
 		UserID:           in.UserID,
@@ -128,6 +138,7 @@ func (p *SubscriptionProvider) Reserve(ctx context.Context, in ReserveInput) (Re
 		ServiceTier:      in.ServiceTier,
 		ReservedUSD:      chosenReservedUSD,
 		ReserveExpiresAt: now.Add(p.reserveTTL),
+		OrgID:            reserveOrgID,
 	})
 	if err != nil {
 		return ReserveResult{}, err
@@ -0,0 +1,78 @@
+package quota
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"realms/internal/store"
+)
+
+var (
+	ErrTokenBudgetExceeded  = store.ErrUserTokenBudgetExceeded
+	ErrTokenModelNotAllowed = errors.New("Token 不允许使用该模型")
+)
+
+// TokenLimitProvider 在预留前校验 Token 级限制（模型白名单、累计/滚动窗口消费上限），通过后交给 next。
+// 这里的消费上限校验仅用于额度已用尽时尽早拒绝；含本次预留金额的权威校验在 store 预留事务内完成。
+type TokenLimitProvider struct {
+	st   *store.Store
+	next Provider
+
+	now func() time.Time
+}
+
+func NewTokenLimitProvider(st *store.Store, next Provider) *TokenLimitProvider {
+	return &TokenLimitProvider{st: st, next: next, now: time.Now}
+}
+
+func (p *TokenLimitProvider) Reserve(ctx context.Context, in ReserveInput) (ReserveResult, error) {
+	if p.st != nil && in.TokenID > 0 {
+		if err := p.checkTokenLimits(ctx, in); err != nil {
+			return ReserveResult{}, err
+		}
+	}
+	return p.next.Reserve(ctx, in)
+}
+
+func (p *TokenLimitProvider) checkTokenLimits(ctx context.Context, in ReserveInput) error {
+	limits, err := p.st.GetUserTokenLimits(ctx, in.TokenID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	if in.Model != nil && !limits.ModelAllowed(*in.Model) {
+		return ErrTokenModelNotAllowed
+	}
+	now := p.now()
+	for _, b := range limits.BudgetWindows() {
+		since := time.Unix(0, 0).UTC()
+		if b.Window > 0 {
+			since = now.Add(-b.Window)
+		}
+		committed, reserved, err := p.st.SumCommittedAndReservedUSDRangeByToken(ctx, store.UsageSumWithReservedRangeByTokenInput{
+			TokenID: in.TokenID,
+			Since:   since,
+			Until:   now.Add(time.Minute),
+			Now:     now,
+		})
+		if err != nil {
+			return err
+		}
+		if committed.Add(reserved).GreaterThanOrEqual(b.BudgetUSD) {
+			return ErrTokenBudgetExceeded
+		}
+	}
+	return nil
+}
+
+func (p *TokenLimitProvider) Commit(ctx context.Context, in CommitInput) error {
+	return p.next.Commit(ctx, in)
+}
+
+func (p *TokenLimitProvider) Void(ctx context.Context, usageEventID int64) error {
+	return p.next.Void(ctx, usageEventID)
+}
@@ -0,0 +1,238 @@
+// Package ratelimit 提供数据面 RPM/TPM 滑动窗口限流（进程内计数，或配置 Redis 时多副本共享计数）。
+//
+// 采用滑动窗口计数器：以 1 分钟为桶，当前估计值 = 当前桶计数 + 上一桶计数 ×（上一桶仍落在窗口内的比例）。
+// 检查与累加非原子（先检查全部维度再累加），并发下可能少量超出上限，换取单次请求仅一到两次后端往返。
+// 同一次决策涉及的全部 key 通过一次 Counts / Add 批量读写，保证各维度读取自同一后端。
+package ratelimit
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Window 为限流窗口长度（RPM/TPM 均按分钟计）。
+const Window = time.Minute
+
+// 拒绝原因。
+const (
+	ReasonRequests = "requests"
+	ReasonTokens   = "tokens"
+)
+
+// Backend 为滑动窗口计数存储。
+type Backend interface {
+	// Counts 返回各 key 在窗口序号 bucket 与 bucket-1 的计数（与 keys 按下标一一对应）。
+	Counts(keys []string, bucket int64) (cur []int64, prev []int64)
+	// Add 为各 key 在窗口序号 bucket 的计数加 n。
+	Add(keys []string, bucket int64, n int64)
+}
+
+// Limit 为单个维度的上限；0 表示不限制。
+type Limit struct {
+	RPM int64
+	TPM int64
+}
+
+// Scope 为一个限流维度（如某用户、某 Token）。
+type Scope struct {
+	Name  string
+	Key   string
+	Limit Limit
+}
+
+// Quota 为某类上限在最紧维度上的剩余情况；Limit 为 0 表示未配置。
+type Quota struct {
+	Limit     int64
+	Remaining int64
+	Reset     time.Duration
+}
+
+// Decision 为一次 Take 的结果。
+type Decision struct {
+	Allowed    bool
+	Requests   Quota
+	Tokens     Quota
+	RetryAfter time.Duration
+	// Reason/Scope 为拒绝时触发的上限类型与维度名称。
+	Reason string
+	Scope  string
+}
+
+// Limiter 基于 Backend 执行 RPM/TPM 检查。
+type Limiter struct {
+	backend Backend
+	now     func() time.Time
+
+	// tpmKeys 记录近期配置了 TPM 上限的维度 key，RecordTokens 仅为这些维度累加，避免无上限时的无效写入。
+	tpmKeys sync.Map
+}
+
+func New(backend Backend) *Limiter {
+	if backend == nil {
+		backend = NewMemoryBackend()
+	}
+	return &Limiter{backend: backend, now: time.Now}
+}
+
+// UserKey/TokenKey 返回用户/Token 维度的计数 key。
+func UserKey(userID int64) string {
+	return "user:" + strconv.FormatInt(userID, 10)
+}
+
+func TokenKey(tokenID int64) string {
+	return "token:" + strconv.FormatInt(tokenID, 10)
+}
+
+type windowPosition struct {
+	bucket   int64
+	fraction float64
+}
+
+func positionAt(now time.Time) windowPosition {
+	ns := now.UnixNano()
+	bucket := ns / int64(Window)
+	return windowPosition{
+		bucket:   bucket,
+		fraction: float64(ns-bucket*int64(Window)) / float64(Window),
+	}
+}
+
+func estimate(cur, prev int64, pos windowPosition) int64 {
+	return cur + int64(math.Floor(float64(prev)*(1-pos.fraction)))
+}
+
+// retryAfter 估算再次放行 cost 所需等待的时长。
+func retryAfter(cur, prev, limit, cost int64, pos windowPosition) time.Duration {
+	if cost > limit {
+		return Window
+	}
+	var wait float64
+	if cur+cost <= limit {
+		// 等待上一桶的权重衰减到足够小。
+		if prev > 0 {
+			need := 1 - float64(limit-cur-cost)/float64(prev)
+			wait = need - pos.fraction
+		}
+	} else {
+		// 当前桶本身已超限：需进入下一桶，并等待当前桶（届时为上一桶）的权重衰减。
+		need := 1 - float64(limit-cost)/float64(cur)
+		wait = (1 - pos.fraction) + need
+	}
+	if wait <= 0 {
+		return time.Second
+	}
+	return time.Duration(wait * float64(Window))
+}
+
+// resetAfter 估算计数完全回落到 0 的时长。
+func resetAfter(cur, prev int64, pos windowPosition) time.Duration {
+	switch {
+	case cur > 0:
+		return time.Duration((2 - pos.fraction) * float64(Window))
+	case prev > 0:
+		return time.Duration((1 - pos.fraction) * float64(Window))
+	default:
+		return 0
+	}
+}
+
+func tighter(q Quota, limit, remaining int64, reset time.Duration) Quota {
+	if remaining < 0 {
+		remaining = 0
+	}
+	if q.Limit == 0 || remaining < q.Remaining || (remaining == q.Remaining && limit < q.Limit) {
+		return Quota{Limit: limit, Remaining: remaining, Reset: reset}
+	}
+	return q
+}
+
+// Take 检查各维度的 RPM/TPM；全部通过时为配置了 RPM 的维度各计 1 次请求。
+func (l *Limiter) Take(scopes []Scope) Decision {
+	now := l.now()
+	pos := positionAt(now)
+	out := Decision{Allowed: true}
+
+	reject := func(reason, scope string, wait time.Duration) {
+		// 多个维度同时超限时，以最晚恢复者为准。
+		if out.Allowed || wait > out.RetryAfter {
+			out.RetryAfter = wait
+			out.Reason = reason
+			out.Scope = scope
+		}
+		out.Allowed = false
+	}
+
+	var keys []string
+	for _, sc := range scopes {
+		if sc.Limit.RPM > 0 {
+			keys = append(keys, sc.Key+":rpm")
+		}
+		if sc.Limit.TPM > 0 {
+			keys = append(keys, sc.Key+":tpm")
+		}
+	}
+	if len(keys) == 0 {
+		return out
+	}
+	curs, prevs := l.backend.Counts(keys, pos.bucket)
+
+	var toAdd []string
+	i := 0
+	for _, sc := range scopes {
+		if sc.Limit.RPM > 0 {
+			key := keys[i]
+			cur, prev := curs[i], prevs[i]
+			i++
+			est := estimate(cur, prev, pos)
+			if est+1 > sc.Limit.RPM {
+				reject(ReasonRequests, sc.Name, retryAfter(cur, prev, sc.Limit.RPM, 1, pos))
+				out.Requests = tighter(out.Requests, sc.Limit.RPM, sc.Limit.RPM-est, resetAfter(cur, prev, pos))
+			} else {
+				toAdd = append(toAdd, key)
+				out.Requests = tighter(out.Requests, sc.Limit.RPM, sc.Limit.RPM-est-1, resetAfter(cur+1, prev, pos))
+			}
+		}
+		if sc.Limit.TPM > 0 {
+			l.tpmKeys.Store(keys[i], now)
+			cur, prev := curs[i], prevs[i]
+			i++
+			est := estimate(cur, prev, pos)
+			if est >= sc.Limit.TPM {
+				reject(ReasonTokens, sc.Name, retryAfter(cur, prev, sc.Limit.TPM, 1, pos))
+			}
+			out.Tokens = tighter(out.Tokens, sc.Limit.TPM, sc.Limit.TPM-est, resetAfter(cur, prev, pos))
+		}
+	}
+	if !out.Allowed || len(toAdd) == 0 {
+		return out
+	}
+	l.backend.Add(toAdd, pos.bucket, 1)
+	return out
+}
+
+// RecordTokens 为近期配置了 TPM 上限的维度累加实际消耗的 Token 数。
+func (l *Limiter) RecordTokens(keys []string, tokens int64) {
+	if l == nil || tokens <= 0 {
+		return
+	}
+	now := l.now()
+	var toAdd []string
+	for _, k := range keys {
+		key := k + ":tpm"
+		v, ok := l.tpmKeys.Load(key)
+		if !ok {
+			continue
+		}
+		if seen, _ := v.(time.Time); now.Sub(seen) > 2*Window {
+			l.tpmKeys.Delete(key)
+			continue
+		}
+		toAdd = append(toAdd, key)
+	}
+	if len(toAdd) == 0 {
+		return
+	}
+	l.backend.Add(toAdd, positionAt(now).bucket, tokens)
+}
@@ -0,0 +1,122 @@
+package ratelimit
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func newTestLimiter(backend Backend, now *time.Time) *Limiter {
+	l := New(backend)
+	l.now = func() time.Time { return *now }
+	return l
+}
+
+func TestLimiter_RPMSlidingWindow(t *testing.T) {
+	now := time.Unix(1_700_000_040, 0) // 窗口起点
+	l := newTestLimiter(NewMemoryBackend(), &now)
+	scopes := []Scope{
+		{Name: "user", Key: UserKey(1), Limit: Limit{RPM: 10}},
+		{Name: "token", Key: TokenKey(2), Limit: Limit{RPM: 3}},
+	}
+
+	for i := 0; i < 3; i++ {
+		d := l.Take(scopes)
+		if !d.Allowed {
+			t.Fatalf("request %d: expected allowed, got %+v", i, d)
+		}
+		if d.Requests.Limit != 3 || d.Requests.Remaining != int64(2-i) {
+			t.Fatalf("request %d: unexpected requests quota %+v", i, d.Requests)
+		}
+	}
+	d := l.Take(scopes)
+	if d.Allowed || d.Reason != ReasonRequests || d.Scope != "token" {
+		t.Fatalf("expected token rpm rejection, got %+v", d)
+	}
+	if d.RetryAfter <= 0 || d.RetryAfter > 2*Window {
+		t.Fatalf("unexpected retry after: %v", d.RetryAfter)
+	}
+
+	// 下一窗口过半：上一窗口的 3 次按 50% 计入，估计值 1，仍可再放行 2 次。
+	now = now.Add(Window + Window/2)
+	for i := 0; i < 2; i++ {
+		if d := l.Take(scopes); !d.Allowed {
+			t.Fatalf("expected allowed after window slides, got %+v", d)
+		}
+	}
+	if d := l.Take(scopes); d.Allowed {
+		t.Fatalf("expected rejection at sliding limit, got %+v", d)
+	}
+
+	// 两个窗口后完全恢复。
+	now = now.Add(2 * Window)
+	if d := l.Take(scopes); !d.Allowed || d.Requests.Remaining != 2 {
+		t.Fatalf("expected full quota after two windows, got %+v", d)
+	}
+}
+
+func TestLimiter_TPM(t *testing.T) {
+	now := time.Unix(1_700_000_040, 0)
+	l := newTestLimiter(NewMemoryBackend(), &now)
+	scopes := []Scope{{Name: "user", Key: UserKey(1), Limit: Limit{TPM: 1000}}}
+
+	if d := l.Take(scopes); !d.Allowed || d.Tokens.Limit != 1000 || d.Tokens.Remaining != 1000 {
+		t.Fatalf("expected allowed with full token quota, got %+v", d)
+	}
+	// 未配置 TPM 的维度不计数。
+	l.RecordTokens([]string{UserKey(1), TokenKey(9)}, 1200)
+	if cur, _ := l.backend.Counts([]string{TokenKey(9) + ":tpm"}, positionAt(now).bucket); cur[0] != 0 {
+		t.Fatalf("expected no tpm counter for unlimited scope, got %d", cur[0])
+	}
+
+	d := l.Take(scopes)
+	if d.Allowed || d.Reason != ReasonTokens || d.Tokens.Remaining != 0 {
+		t.Fatalf("expected tpm rejection, got %+v", d)
+	}
+	if d.RetryAfter < Window {
+		t.Fatalf("expected retry after to cover next window, got %v", d.RetryAfter)
+	}
+}
+
+func TestLimiter_RedisSharedAcrossReplicas(t *testing.T) {
+	mr := miniredis.RunT(t)
+	newBackend := func() *RedisBackend {
+		b := NewRedisBackend(RedisBackendOptions{Addr: mr.Addr(), KeyPrefix: "test"})
+		t.Cleanup(func() { _ = b.Close() })
+		return b
+	}
+	now := time.Unix(1_700_000_040, 0)
+	a := newTestLimiter(newBackend(), &now)
+	b := newTestLimiter(newBackend(), &now)
+	scopes := []Scope{{Name: "user", Key: UserKey(1), Limit: Limit{RPM: 2}}}
+
+	if d := a.Take(scopes); !d.Allowed {
+		t.Fatalf("expected allowed on replica a, got %+v", d)
+	}
+	if d := b.Take(scopes); !d.Allowed || d.Requests.Remaining != 0 {
+		t.Fatalf("expected shared count on replica b, got %+v", d)
+	}
+	if d := a.Take(scopes); d.Allowed {
+		t.Fatalf("expected rejection after shared limit, got %+v", d)
+	}
+	if !mr.Exists("test:rl:user:1:rpm:" + strconv.FormatInt(positionAt(now).bucket, 10)) {
+		t.Fatalf("expected redis counter key, got keys=%v", mr.Keys())
+	}
+
+	// Redis 不可用时整次决策回退到进程内计数，不阻塞请求。
+	mr.Close()
+	rb := a.backend.(*RedisBackend)
+	multi := []Scope{
+		{Name: "user", Key: UserKey(1), Limit: Limit{RPM: 2}},
+		{Name: "token", Key: TokenKey(2), Limit: Limit{RPM: 5}},
+	}
+	if d := a.Take(multi); !d.Allowed {
+		t.Fatalf("expected fallback to allow, got %+v", d)
+	}
+	cur, _ := rb.fallback.Counts([]string{UserKey(1) + ":rpm", TokenKey(2) + ":rpm"}, positionAt(now).bucket)
+	if cur[0] != 1 || cur[1] != 1 {
+		t.Fatalf("expected both scopes counted in fallback, got %v", cur)
+	}
+}
@@ -0,0 +1,74 @@
+package ratelimit
+
+import "sync"
+
+// memorySweepEvery 为每累加多少次清理一次过期计数。
+const memorySweepEvery = 1024
+
+type memoryCounter struct {
+	bucket int64
+	cur    int64
+	prev   int64
+}
+
+// counts 返回以 bucket 为当前窗口时的 (cur, prev)。
+func (c *memoryCounter) counts(bucket int64) (int64, int64) {
+	switch c.bucket {
+	case bucket:
+		return c.cur, c.prev
+	case bucket - 1:
+		return 0, c.cur
+	default:
+		return 0, 0
+	}
+}
+
+// MemoryBackend 为进程内 Backend；多副本部署时各实例独立计数。
+type MemoryBackend struct {
+	mu       sync.Mutex
+	counters map[string]*memoryCounter
+	adds     int
+}
+
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{counters: make(map[string]*memoryCounter)}
+}
+
+func (m *MemoryBackend) Counts(keys []string, bucket int64) ([]int64, []int64) {
+	curs := make([]int64, len(keys))
+	prevs := make([]int64, len(keys))
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, key := range keys {
+		if c, ok := m.counters[key]; ok {
+			curs[i], prevs[i] = c.counts(bucket)
+		}
+	}
+	return curs, prevs
+}
+
+func (m *MemoryBackend) Add(keys []string, bucket int64, n int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, key := range keys {
+		c, ok := m.counters[key]
+		if !ok {
+			c = &memoryCounter{bucket: bucket}
+			m.counters[key] = c
+		}
+		if c.bucket != bucket {
+			cur, prev := c.counts(bucket)
+			c.bucket, c.cur, c.prev = bucket, cur, prev
+		}
+		c.cur += n
+	}
+
+	m.adds++
+	if m.adds%memorySweepEvery == 0 {
+		for k, v := range m.counters {
+			if v.bucket < bucket-1 {
+				delete(m.counters, k)
+			}
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisBackendOptions 复用 config.RedisConfig 的连接参数。
+type RedisBackendOptions struct {
+	Addr      string
+	Password  string
+	DB        int
+	KeyPrefix string
+
+	// OpTimeout 为单次 Redis 操作超时；超时或出错时该次读写整体回退到进程内计数，避免阻塞转发链路。
+	OpTimeout time.Duration
+}
+
+// RedisBackend 是基于 Redis 的 Backend 实现：多副本共享同一用户/Token 的窗口计数。
+// 每个窗口桶一个 key（INCRBY + PEXPIRE），过期由 Redis 负责。
+// 一次决策的全部 key 在同一次 MGET / pipeline 中读写；Redis 出错时整批回退到 fallback 并记录告警（按 redisFallbackLogInterval 限频）。
+type RedisBackend struct {
+	client    *redis.Client
+	prefix    string
+	opTimeout time.Duration
+	fallback  *MemoryBackend
+
+	lastFallbackLog atomic.Int64 // unix nano
+}
+
+// redisFallbackLogInterval 为回退告警的最小间隔，避免 Redis 故障期间每个请求都打日志。
+const redisFallbackLogInterval = 30 * time.Second
+
+func NewRedisBackend(opts RedisBackendOptions) *RedisBackend {
+	prefix := strings.TrimSpace(opts.KeyPrefix)
+	if prefix == "" {
+		prefix = "realms"
+	}
+	if opts.OpTimeout <= 0 {
+		opts.OpTimeout = 500 * time.Millisecond
+	}
+	client := redis.NewClient(&redis.Options{
+		Addr:     strings.TrimSpace(opts.Addr),
+		Password: opts.Password,
+		DB:       opts.DB,
+	})
+	return &RedisBackend{
+		client:    client,
+		prefix:    prefix + ":rl:",
+		opTimeout: opts.OpTimeout,
+		fallback:  NewMemoryBackend(),
+	}
+}
+
+func (r *RedisBackend) PingContext(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
+func (r *RedisBackend) Close() error {
+	return r.client.Close()
+}
+
+func (r *RedisBackend) key(key string, bucket int64) string {
+	return r.prefix + key + ":" + strconv.FormatInt(bucket, 10)
+}
+
+func (r *RedisBackend) ctx() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), r.opTimeout)
+}
+
+func (r *RedisBackend) Counts(keys []string, bucket int64) ([]int64, []int64) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	ctx, cancel := r.ctx()
+	defer cancel()
+	rkeys := make([]string, 0, 2*len(keys))
+	for _, key := range keys {
+		rkeys = append(rkeys, r.key(key, bucket), r.key(key, bucket-1))
+	}
+	vals, err := r.client.MGet(ctx, rkeys...).Result()
+	if err == nil && len(vals) != len(rkeys) {
+		err = fmt.Errorf("mget 返回 %d 项，期望 %d 项", len(vals), len(rkeys))
+	}
+	if err != nil {
+		r.logFallback("counts", err)
+		return r.fallback.Counts(keys, bucket)
+	}
+	curs := make([]int64, len(keys))
+	prevs := make([]int64, len(keys))
+	for i := range keys {
+		curs[i], prevs[i] = redisInt(vals[2*i]), redisInt(vals[2*i+1])
+	}
+	return curs, prevs
+}
+
+func (r *RedisBackend) Add(keys []string, bucket int64, n int64) {
+	if len(keys) == 0 {
+		return
+	}
+	ctx, cancel := r.ctx()
+	defer cancel()
+	pipe := r.client.TxPipeline()
+	for _, key := range keys {
+		k := r.key(key, bucket)
+		pipe.IncrBy(ctx, k, n)
+		pipe.PExpire(ctx, k, 2*Window+5*time.Second)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		r.logFallback("add", err)
+		r.fallback.Add(keys, bucket, n)
+	}
+}
+
+func (r *RedisBackend) logFallback(op string, err error) {
+	now := time.Now().UnixNano()
+	last := r.lastFallbackLog.Load()
+	if now-last < int64(redisFallbackLogInterval) || !r.lastFallbackLog.CompareAndSwap(last, now) {
+		return
+	}
+	slog.Warn("限流 Redis 不可用，回退到进程内计数", "op", op, "err", err)
+}
+
+func redisInt(v any) int64 {
+	s, ok := v.(string)
+	if !ok {
+		return 0
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,57 @@
+package scheduler
+
+import (
+	"strings"
+
+	"realms/internal/store"
+)
+
+// canaryAdmitted 判断本次路由是否落入灰度成员的流量桶：按 routeKeyHash（无 routeKey 时为每请求随机种子）
+// 与成员 ID 做确定性哈希，使同一会话稳定地命中或避开灰度渠道。
+func (r *GroupRouter) canaryAdmitted(m store.ChannelGroupMemberDetail) bool {
+	if m.CanaryPercent >= 100 {
+		return true
+	}
+	if m.CanaryPercent <= 0 {
+		return false
+	}
+	return rendezvousScore64(r.weightSeed, "canary", m.MemberID)%100 < uint64(m.CanaryPercent)
+}
+
+// applyCanaryMembers 返回按灰度状态处理后的成员副本：
+//   - 已回滚的渠道成员不参与路由；
+//   - 灰度中的渠道成员仅在流量桶命中时参与，且排在非 promotion 成员之首优先承接该请求；
+//     已 promotion 的成员仍排在灰度成员之前，未命中时按普通成员顺序 failover 到其他渠道。
+func (r *GroupRouter) applyCanaryMembers(members []store.ChannelGroupMemberDetail) []store.ChannelGroupMemberDetail {
+	topPriority, hasRegular := 0, false
+	for _, m := range members {
+		if m.Promotion {
+			continue
+		}
+		if !hasRegular || m.Priority > topPriority {
+			topPriority, hasRegular = m.Priority, true
+		}
+	}
+	out := make([]store.ChannelGroupMemberDetail, 0, len(members))
+	for _, m := range members {
+		if m.MemberChannelID == nil || m.MemberGroupID != nil {
+			out = append(out, m)
+			continue
+		}
+		switch strings.TrimSpace(m.CanaryStatus) {
+		case store.ChannelGroupCanaryRolledBack:
+			r.traceChannelSkip(*m.MemberChannelID, RouteSkipCanaryRolledBack)
+			continue
+		case store.ChannelGroupCanaryActive:
+			if !r.canaryAdmitted(m) {
+				r.traceChannelSkip(*m.MemberChannelID, RouteSkipCanaryHoldout)
+				continue
+			}
+			if !m.Promotion {
+				m.Priority = topPriority + 1
+			}
+		}
+		out = append(out, m)
+	}
+	return out
+}
@@ -0,0 +1,107 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"realms/internal/store"
+)
+
+// canaryRouteKeys 返回分别落入/不落入灰度成员流量桶的 routeKeyHash。
+func canaryRouteKeys(t *testing.T, memberID int64, percent int) (string, string) {
+	t.Helper()
+	var in, out string
+	for i := 0; i < 1000 && (in == "" || out == ""); i++ {
+		key := fmt.Sprintf("rk-%d", i)
+		if rendezvousScore64(key, "canary", memberID)%100 < uint64(percent) {
+			if in == "" {
+				in = key
+			}
+		} else if out == "" {
+			out = key
+		}
+	}
+	if in == "" || out == "" {
+		t.Fatalf("failed to find route keys for canary bucket")
+	}
+	return in, out
+}
+
+func TestGroupRouter_CanaryMemberRoutesDeterministicPercentage(t *testing.T) {
+	s, gs, g0 := costRoutingFixture(store.ChannelGroupRoutingPriority)
+	// 渠道 3 优先级最低，灰度命中时应被优先选中。
+	gs.members[1][2].CanaryStatus = store.ChannelGroupCanaryActive
+	gs.members[1][2].CanaryPercent = 30
+	inKey, outKey := canaryRouteKeys(t, 3, 30)
+
+	for i := 0; i < 3; i++ {
+		sel, err := NewGroupRouter(gs, s, 10, inKey, costRoutingConstraints(g0)).Next(context.Background())
+		if err != nil {
+			t.Fatalf("Next err: %v", err)
+		}
+		if sel.ChannelID != 3 || sel.RouteGroupID != g0.ID {
+			t.Fatalf("expected in-bucket route key to hit canary channel=3 via group=%d, got=%+v", g0.ID, sel)
+		}
+	}
+
+	trace := &RouteTrace{}
+	router := NewGroupRouter(gs, s, 10, outKey, costRoutingConstraints(g0).WithRouteTrace(trace))
+	for {
+		sel, err := router.Next(context.Background())
+		if err != nil {
+			break
+		}
+		if sel.ChannelID == 3 {
+			t.Fatalf("expected out-of-bucket route key never to hit canary channel")
+		}
+		router.ExcludeChannel(sel.ChannelID)
+	}
+	if !hasRouteSkip(trace, RouteTraceSkip{Level: RouteTraceLevelChannel, ChannelID: 3, Reason: RouteSkipCanaryHoldout}) {
+		t.Fatalf("expected canary_holdout skip, got=%+v", trace.Skips)
+	}
+}
+
+func TestGroupRouter_RolledBackCanaryMemberReceivesNoTraffic(t *testing.T) {
+	s, gs, g0 := costRoutingFixture(store.ChannelGroupRoutingPriority)
+	gs.members[1][0].CanaryStatus = store.ChannelGroupCanaryRolledBack
+	gs.members[1][0].CanaryPercent = 100
+
+	router := NewGroupRouter(gs, s, 10, "", costRoutingConstraints(g0))
+	seen := map[int64]bool{}
+	for {
+		sel, err := router.Next(context.Background())
+		if err != nil {
+			break
+		}
+		seen[sel.ChannelID] = true
+		router.ExcludeChannel(sel.ChannelID)
+	}
+	if seen[1] {
+		t.Fatalf("expected rolled-back canary channel=1 to be skipped, got=%v", seen)
+	}
+	if !seen[2] || !seen[3] {
+		t.Fatalf("expected remaining channels to stay routable, got=%v", seen)
+	}
+}
+
+func TestGroupRouter_CanaryMemberStaysBehindPromotedMembers(t *testing.T) {
+	s, gs, g0 := costRoutingFixture(store.ChannelGroupRoutingPriority)
+	gs.members[1][1].Promotion = true
+	gs.members[1][2].CanaryStatus = store.ChannelGroupCanaryActive
+	gs.members[1][2].CanaryPercent = 100
+
+	router := NewGroupRouter(gs, s, 10, "", costRoutingConstraints(g0))
+	var got []int64
+	for {
+		sel, err := router.Next(context.Background())
+		if err != nil {
+			break
+		}
+		got = append(got, sel.ChannelID)
+		router.ExcludeChannel(sel.ChannelID)
+	}
+	if len(got) != 3 || got[0] != 2 || got[1] != 3 || got[2] != 1 {
+		t.Fatalf("expected promoted channel, then canary, then regular members, got=%v", got)
+	}
+}
@@ -0,0 +1,55 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"realms/internal/store"
+)
+
+// DueChannelProbes 返回启用且探测已到期、尚未被认领的渠道；封禁已过期的渠道会在此转入待探测。
+func (s *Scheduler) DueChannelProbes(ctx context.Context, now time.Time) ([]store.UpstreamChannel, error) {
+	if s == nil || s.st == nil || s.state == nil {
+		return nil, nil
+	}
+	chs, err := s.st.ListUpstreamChannels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var out []store.UpstreamChannel
+	for _, ch := range chs {
+		if ch.Status != 1 {
+			continue
+		}
+		if s.state.IsChannelBanned(ch.ID, now) {
+			continue
+		}
+		if !s.state.IsChannelProbePending(ch.ID, now) {
+			continue
+		}
+		out = append(out, ch)
+	}
+	return out, nil
+}
+
+// SelectChannelProbe 认领渠道探测并选出用于合成请求的 endpoint/credential；
+// 探测已被其他实例认领或渠道内无可用 credential 时返回错误。
+func (s *Scheduler) SelectChannelProbe(ctx context.Context, channelID int64) (Selection, error) {
+	return s.selectWithConstraints(ctx, 0, "", Constraints{RequireChannelID: channelID, channelProbe: true}, false)
+}
+
+// ReportChannelProbe 记录后台合成探测结果：成功则解封并清空失败计分；失败则延长封禁，待下次到期再探测。
+func (s *Scheduler) ReportChannelProbe(sel Selection, ok bool) {
+	if s == nil || s.state == nil || sel.ChannelID <= 0 {
+		return
+	}
+	if ok {
+		s.ClearChannelBan(sel.ChannelID)
+		s.state.ResetChannelFailScore(sel.ChannelID)
+		return
+	}
+	now := time.Now()
+	until := s.state.BanChannelImmediate(sel.ChannelID, now, s.cooldownBase)
+	s.persistChannelBan(sel.ChannelID, now, until)
+	s.state.ReleaseChannelProbeClaim(sel.ChannelID)
+}
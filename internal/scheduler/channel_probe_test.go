@@ -0,0 +1,98 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func expireChannelBan(t *testing.T, s *Scheduler, channelID int64) {
+	t.Helper()
+	s.state.RestoreChannelBan(channelID, time.Now().Add(-time.Second), 2)
+	if s.state.IsChannelBanned(channelID, time.Now()) {
+		t.Fatalf("expected expired ban for channel=%d", channelID)
+	}
+	if !s.state.IsChannelProbeDue(channelID) {
+		t.Fatalf("expected probe due for channel=%d", channelID)
+	}
+}
+
+func TestBackgroundChannelProbe_CustomerTrafficSkipsDueChannel(t *testing.T) {
+	s := NewWithOptions(latencyTestStore(), Options{BackgroundChannelProbe: true})
+	expireChannelBan(t, s, 2)
+
+	for i := 0; i < 20; i++ {
+		sel, err := s.SelectWithConstraints(context.Background(), int64(i+1), "", Constraints{})
+		if err != nil {
+			t.Fatalf("Select err: %v", err)
+		}
+		if sel.ChannelID != 1 {
+			t.Fatalf("expected customer traffic to avoid probe-due channel, got=%d", sel.ChannelID)
+		}
+	}
+	if _, err := s.SelectWithConstraints(context.Background(), 1, "", Constraints{RequireChannelID: 2}); err == nil {
+		t.Fatalf("expected required probe-due channel to be unavailable to customer traffic")
+	}
+
+	due, err := s.DueChannelProbes(context.Background(), time.Now())
+	if err != nil {
+		t.Fatalf("DueChannelProbes err: %v", err)
+	}
+	if len(due) != 1 || due[0].ID != 2 {
+		t.Fatalf("expected due probe for channel=2, got=%v", due)
+	}
+
+	sel, err := s.SelectChannelProbe(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("SelectChannelProbe err: %v", err)
+	}
+	if sel.ChannelID != 2 || sel.CredentialID != 211 {
+		t.Fatalf("unexpected probe selection: %+v", sel)
+	}
+	if _, err := s.SelectChannelProbe(context.Background(), 2); err == nil {
+		t.Fatalf("expected claimed probe not to be selected twice")
+	}
+	if due, _ := s.DueChannelProbes(context.Background(), time.Now()); len(due) != 0 {
+		t.Fatalf("expected claimed probe not listed as due, got=%v", due)
+	}
+
+	s.ReportChannelProbe(sel, true)
+	if s.state.IsChannelProbeDue(2) {
+		t.Fatalf("expected probe cleared after success")
+	}
+	if rt := s.RuntimeChannelStats(2); rt.BanStreak != 0 {
+		t.Fatalf("expected ban streak reset after success, got=%+v", rt)
+	}
+}
+
+func TestBackgroundChannelProbe_FailureExtendsBan(t *testing.T) {
+	s := NewWithOptions(latencyTestStore(), Options{BackgroundChannelProbe: true})
+	expireChannelBan(t, s, 2)
+
+	sel, err := s.SelectChannelProbe(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("SelectChannelProbe err: %v", err)
+	}
+	s.ReportChannelProbe(sel, false)
+
+	until, banned := s.state.ChannelBanUntil(2, time.Now())
+	if !banned || !until.After(time.Now()) {
+		t.Fatalf("expected channel banned again after failed probe, until=%v banned=%v", until, banned)
+	}
+	if due, _ := s.DueChannelProbes(context.Background(), time.Now()); len(due) != 0 {
+		t.Fatalf("expected no due probes while banned, got=%v", due)
+	}
+}
+
+func TestChannelProbe_TrafficProbeWhenBackgroundDisabled(t *testing.T) {
+	s := New(latencyTestStore())
+	expireChannelBan(t, s, 2)
+
+	sel, err := s.SelectWithConstraints(context.Background(), 1, "", Constraints{RequireChannelID: 2})
+	if err != nil {
+		t.Fatalf("expected customer request to claim probe when background probing disabled: %v", err)
+	}
+	if sel.ChannelID != 2 {
+		t.Fatalf("expected channel=2, got=%d", sel.ChannelID)
+	}
+}
@@ -0,0 +1,158 @@
+package scheduler
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"realms/internal/store"
+)
+
+// CooldownPersistStore 持久化渠道封禁、channel_model 封禁与 credential 冷却（写穿），用于重启后恢复运行态。
+type CooldownPersistStore interface {
+	UpsertSchedulerCooldown(ctx context.Context, in store.SchedulerCooldown) error
+	DeleteSchedulerCooldown(ctx context.Context, kind string, subject string) error
+	ListActiveSchedulerCooldowns(ctx context.Context, now time.Time) ([]store.SchedulerCooldown, error)
+}
+
+type cooldownPersister struct {
+	st CooldownPersistStore
+
+	mu sync.Mutex
+	// persisted 记录本进程已写入（或已恢复）的封禁，成功请求仅在命中时才删除，避免每次成功都写库。
+	persisted map[string]struct{}
+}
+
+func cooldownPersistKey(kind, subject string) string {
+	return kind + "\x00" + subject
+}
+
+func (s *Scheduler) SetCooldownPersistStore(ps CooldownPersistStore) {
+	if s == nil {
+		return
+	}
+	if ps == nil {
+		s.cooldowns = nil
+		return
+	}
+	s.cooldowns = &cooldownPersister{
+		st:        ps,
+		persisted: make(map[string]struct{}),
+	}
+}
+
+// RestorePersistedCooldowns 在启动时把未过期的封禁/冷却恢复到运行态，返回恢复条数。
+func (s *Scheduler) RestorePersistedCooldowns(ctx context.Context) (int, error) {
+	if s == nil || s.cooldowns == nil || s.state == nil {
+		return 0, nil
+	}
+	now := time.Now()
+	items, err := s.cooldowns.st.ListActiveSchedulerCooldowns(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+	restored := 0
+	for _, it := range items {
+		until := it.Until()
+		switch it.Kind {
+		case store.SchedulerCooldownChannelBan:
+			id, err := strconv.ParseInt(it.Subject, 10, 64)
+			if err != nil || id <= 0 {
+				continue
+			}
+			s.state.RestoreChannelBan(id, until, it.Streak)
+		case store.SchedulerCooldownChannelModelBan:
+			id, err := strconv.ParseInt(it.Subject, 10, 64)
+			if err != nil || id <= 0 {
+				continue
+			}
+			s.state.RestoreChannelModelBan(id, until, it.Streak)
+		case store.SchedulerCooldownCredentialCooldown:
+			s.state.SetCredentialCooling(it.Subject, until)
+		default:
+			continue
+		}
+		s.cooldowns.mark(it.Kind, it.Subject)
+		restored++
+	}
+	return restored, nil
+}
+
+func (p *cooldownPersister) mark(kind, subject string) {
+	p.mu.Lock()
+	p.persisted[cooldownPersistKey(kind, subject)] = struct{}{}
+	p.mu.Unlock()
+}
+
+func (p *cooldownPersister) upsert(kind, subject string, until time.Time, streak int) {
+	if p == nil || subject == "" {
+		return
+	}
+	p.mark(kind, subject)
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	_ = p.st.UpsertSchedulerCooldown(ctx, store.SchedulerCooldown{
+		Kind:        kind,
+		Subject:     subject,
+		UntilUnixMS: until.UnixMilli(),
+		Streak:      streak,
+	})
+}
+
+func (p *cooldownPersister) remove(kind, subject string) {
+	if p == nil || subject == "" {
+		return
+	}
+	key := cooldownPersistKey(kind, subject)
+	p.mu.Lock()
+	_, ok := p.persisted[key]
+	delete(p.persisted, key)
+	p.mu.Unlock()
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	_ = p.st.DeleteSchedulerCooldown(ctx, kind, subject)
+}
+
+func (s *Scheduler) persistCredentialCooldown(credentialKey string, until time.Time) {
+	if s.cooldowns == nil {
+		return
+	}
+	s.cooldowns.upsert(store.SchedulerCooldownCredentialCooldown, credentialKey, until, 0)
+}
+
+func (s *Scheduler) persistChannelBan(channelID int64, now time.Time, until time.Time) {
+	if s.cooldowns == nil || channelID <= 0 {
+		return
+	}
+	subject := itoa64(channelID)
+	if !until.After(now) {
+		s.cooldowns.remove(store.SchedulerCooldownChannelBan, subject)
+		return
+	}
+	s.cooldowns.upsert(store.SchedulerCooldownChannelBan, subject, until, s.state.ChannelRuntime(channelID, now).BanStreak)
+}
+
+func (s *Scheduler) persistChannelModelBan(bindingID int64, now time.Time, until time.Time) {
+	if s.cooldowns == nil || bindingID <= 0 {
+		return
+	}
+	s.cooldowns.upsert(store.SchedulerCooldownChannelModelBan, itoa64(bindingID), until, s.state.ChannelModelRuntime(bindingID, now).BanStreak)
+}
+
+func (s *Scheduler) forgetChannelBan(channelID int64) {
+	if s.cooldowns == nil || channelID <= 0 {
+		return
+	}
+	s.cooldowns.remove(store.SchedulerCooldownChannelBan, itoa64(channelID))
+}
+
+func (s *Scheduler) forgetChannelModelBan(bindingID int64) {
+	if s.cooldowns == nil || bindingID <= 0 {
+		return
+	}
+	s.cooldowns.remove(store.SchedulerCooldownChannelModelBan, itoa64(bindingID))
+}
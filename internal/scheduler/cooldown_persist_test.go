@@ -0,0 +1,107 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"realms/internal/store"
+)
+
+type fakeCooldownStore struct {
+	rows    map[string]store.SchedulerCooldown
+	deletes int
+}
+
+func newFakeCooldownStore() *fakeCooldownStore {
+	return &fakeCooldownStore{rows: make(map[string]store.SchedulerCooldown)}
+}
+
+func (f *fakeCooldownStore) UpsertSchedulerCooldown(_ context.Context, in store.SchedulerCooldown) error {
+	f.rows[cooldownPersistKey(in.Kind, in.Subject)] = in
+	return nil
+}
+
+func (f *fakeCooldownStore) DeleteSchedulerCooldown(_ context.Context, kind string, subject string) error {
+	f.deletes++
+	delete(f.rows, cooldownPersistKey(kind, subject))
+	return nil
+}
+
+func (f *fakeCooldownStore) ListActiveSchedulerCooldowns(_ context.Context, now time.Time) ([]store.SchedulerCooldown, error) {
+	var out []store.SchedulerCooldown
+	for _, r := range f.rows {
+		if r.UntilUnixMS > now.UnixMilli() {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func TestCooldownPersist_BanAndCooldownSurviveRestart(t *testing.T) {
+	ps := newFakeCooldownStore()
+	s := New(&fakeStore{})
+	s.SetCooldownPersistStore(ps)
+
+	sel := Selection{ChannelID: 1, EndpointID: 11, CredentialType: CredentialTypeOpenAI, CredentialID: 111, AutoBan: true}
+	s.Report(sel, Result{Success: false, Retriable: true, Scope: FailureScopeChannel, ErrorClass: "network"})
+	s.Report(Selection{ChannelID: 2, CredentialType: CredentialTypeOpenAI, CredentialID: 211}, Result{
+		Success: false, Retriable: true, Scope: FailureScopeChannelModel, ChannelModelBindingID: 9,
+	})
+
+	if _, ok := ps.rows[cooldownPersistKey(store.SchedulerCooldownChannelBan, "1")]; !ok {
+		t.Fatalf("expected channel ban persisted, rows=%v", ps.rows)
+	}
+	if _, ok := ps.rows[cooldownPersistKey(store.SchedulerCooldownCredentialCooldown, sel.CredentialKey())]; !ok {
+		t.Fatalf("expected credential cooldown persisted, rows=%v", ps.rows)
+	}
+	if _, ok := ps.rows[cooldownPersistKey(store.SchedulerCooldownChannelModelBan, "9")]; !ok {
+		t.Fatalf("expected channel model ban persisted, rows=%v", ps.rows)
+	}
+
+	// 模拟重启：新的调度器从持久化记录恢复。
+	restarted := New(&fakeStore{})
+	restarted.SetCooldownPersistStore(ps)
+	n, err := restarted.RestorePersistedCooldowns(context.Background())
+	if err != nil {
+		t.Fatalf("RestorePersistedCooldowns: %v", err)
+	}
+	if n != 3 {
+		t.Fatalf("expected 3 restored entries, got=%d", n)
+	}
+	now := time.Now()
+	if !restarted.state.IsChannelBanned(1, now) {
+		t.Fatalf("expected channel ban restored")
+	}
+	if rt := restarted.RuntimeChannelStats(1); rt.BanStreak != 1 {
+		t.Fatalf("expected ban streak restored, got=%d", rt.BanStreak)
+	}
+	if !restarted.state.IsCredentialCooling(sel.CredentialKey(), now) {
+		t.Fatalf("expected credential cooldown restored")
+	}
+	if !restarted.state.IsChannelModelBanned(9, now) {
+		t.Fatalf("expected channel model ban restored")
+	}
+
+	restarted.Report(sel, Result{Success: true, ChannelModelBindingID: 9})
+	if _, ok := ps.rows[cooldownPersistKey(store.SchedulerCooldownChannelBan, "1")]; ok {
+		t.Fatalf("expected channel ban row removed after success")
+	}
+	if _, ok := ps.rows[cooldownPersistKey(store.SchedulerCooldownChannelModelBan, "9")]; ok {
+		t.Fatalf("expected channel model ban row removed after success")
+	}
+}
+
+func TestCooldownPersist_SuccessWithoutBanSkipsDelete(t *testing.T) {
+	ps := newFakeCooldownStore()
+	s := New(&fakeStore{})
+	s.SetCooldownPersistStore(ps)
+
+	sel := Selection{ChannelID: 1, CredentialType: CredentialTypeOpenAI, CredentialID: 1}
+	for i := 0; i < 3; i++ {
+		s.Report(sel, Result{Success: true})
+	}
+	if ps.deletes != 0 {
+		t.Fatalf("expected no delete calls without persisted bans, got=%d", ps.deletes)
+	}
+}
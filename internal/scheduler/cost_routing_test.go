@@ -0,0 +1,142 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"realms/internal/store"
+)
+
+func costRoutingFixture(mode string) (*Scheduler, *fakeGroupStore, store.ChannelGroup) {
+	fs := &fakeStore{
+		channels: []store.UpstreamChannel{
+			{ID: 1, Type: store.UpstreamTypeOpenAICompatible, Status: 1, Groups: "g0"},
+			{ID: 2, Type: store.UpstreamTypeOpenAICompatible, Status: 1, Groups: "g0"},
+			{ID: 3, Type: store.UpstreamTypeOpenAICompatible, Status: 1, Groups: "g0"},
+		},
+		endpoints: map[int64][]store.UpstreamEndpoint{
+			1: {{ID: 11, ChannelID: 1, BaseURL: "https://a.example", Status: 1}},
+			2: {{ID: 21, ChannelID: 2, BaseURL: "https://b.example", Status: 1}},
+			3: {{ID: 31, ChannelID: 3, BaseURL: "https://c.example", Status: 1}},
+		},
+		creds: map[int64][]store.OpenAICompatibleCredential{
+			11: {{ID: 101, EndpointID: 11, Status: 1}},
+			21: {{ID: 201, EndpointID: 21, Status: 1}},
+			31: {{ID: 301, EndpointID: 31, Status: 1}},
+		},
+	}
+	s := New(fs)
+
+	g0 := store.ChannelGroup{ID: 1, Name: "g0", Status: 1, RoutingMode: mode, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	gs := &fakeGroupStore{
+		groupsByID:   map[int64]store.ChannelGroup{1: g0},
+		groupsByName: map[string]store.ChannelGroup{g0.Name: g0},
+		members: map[int64][]store.ChannelGroupMemberDetail{
+			1: {
+				{MemberID: 1, ParentGroupID: 1, MemberChannelID: ptrInt64(1), MemberChannelType: ptrString(store.UpstreamTypeOpenAICompatible), MemberChannelGroups: ptrString(g0.Name), Priority: 100, CreatedAt: time.Now(), UpdatedAt: time.Now()},
+				{MemberID: 2, ParentGroupID: 1, MemberChannelID: ptrInt64(2), MemberChannelType: ptrString(store.UpstreamTypeOpenAICompatible), MemberChannelGroups: ptrString(g0.Name), Priority: 100, CreatedAt: time.Now(), UpdatedAt: time.Now()},
+				{MemberID: 3, ParentGroupID: 1, MemberChannelID: ptrInt64(3), MemberChannelType: ptrString(store.UpstreamTypeOpenAICompatible), MemberChannelGroups: ptrString(g0.Name), Priority: 50, CreatedAt: time.Now(), UpdatedAt: time.Now()},
+			},
+		},
+	}
+	return s, gs, g0
+}
+
+func costPtr(v string) *decimal.Decimal {
+	d := decimal.RequireFromString(v)
+	return &d
+}
+
+func costRoutingConstraints(g0 store.ChannelGroup) Constraints {
+	return Constraints{
+		AllowGroups:     map[string]struct{}{g0.Name: {}},
+		AllowGroupOrder: []string{g0.Name},
+		ChannelModelBindingIDs: map[int64]int64{
+			1: 1001,
+			2: 2001,
+			3: 3001,
+		},
+		ChannelModelCosts: map[int64]store.ChannelModelCost{
+			1: {InputUSDPer1M: costPtr("1"), OutputUSDPer1M: costPtr("5")},
+			2: {InputUSDPer1M: costPtr("3"), OutputUSDPer1M: costPtr("15")},
+			// 渠道 3 最便宜但 priority 更低，不应越级。
+			3: {InputUSDPer1M: costPtr("0.1"), OutputUSDPer1M: costPtr("0.1")},
+		},
+	}
+}
+
+func TestGroupRouter_Next_CostRoutingPrefersCheaperWithinPriority(t *testing.T) {
+	s, gs, g0 := costRoutingFixture(store.ChannelGroupRoutingCost)
+	router := NewGroupRouter(gs, s, 10, "", costRoutingConstraints(g0))
+
+	first, err := router.Next(context.Background())
+	if err != nil {
+		t.Fatalf("first Next err: %v", err)
+	}
+	if first.ChannelID != 1 {
+		t.Fatalf("expected cheapest channel within top priority=1, got=%d", first.ChannelID)
+	}
+	if first.CostBasis == nil || first.CostBasis.ChannelModelID != 1001 || first.CostBasis.Cost.InputUSDPer1M == nil || !first.CostBasis.Cost.InputUSDPer1M.Equal(decimal.NewFromInt(1)) {
+		t.Fatalf("unexpected cost basis: %+v", first.CostBasis)
+	}
+	s.Report(first, Result{Success: false, Retriable: true, StatusCode: 500, Scope: FailureScopeChannel})
+	s.Report(first, Result{Success: false, Retriable: true, StatusCode: 500, Scope: FailureScopeChannel})
+
+	second, err := router.Next(context.Background())
+	if err != nil {
+		t.Fatalf("second Next err: %v", err)
+	}
+	if second.ChannelID != 2 {
+		t.Fatalf("expected failover to same-priority channel=2 before lower priority, got=%d", second.ChannelID)
+	}
+}
+
+func TestGroupRouter_Next_CostRoutingPrefersLowerFailScoreOverCost(t *testing.T) {
+	s, gs, g0 := costRoutingFixture(store.ChannelGroupRoutingCost)
+	// 最便宜的渠道 1 有失败记录（未封禁），应让位于同优先级、无失败的渠道 2。
+	s.state.RecordChannelResult(1, false)
+
+	sel, err := NewGroupRouter(gs, s, 10, "", costRoutingConstraints(g0)).Next(context.Background())
+	if err != nil {
+		t.Fatalf("Next err: %v", err)
+	}
+	if sel.ChannelID != 2 {
+		t.Fatalf("expected channel with lower fail score=2, got=%d", sel.ChannelID)
+	}
+}
+
+func TestGroupRouter_NextSequential_CostRoutingPrefersCheaperWithinPriority(t *testing.T) {
+	s, gs, g0 := costRoutingFixture(store.ChannelGroupRoutingCost)
+	cons := costRoutingConstraints(g0)
+	cons.SequentialChannelFailover = true
+	router := NewGroupRouter(gs, s, 10, "", cons)
+
+	var got []int64
+	for i := 0; i < 3; i++ {
+		sel, err := router.Next(context.Background())
+		if err != nil {
+			t.Fatalf("Next #%d err: %v", i+1, err)
+		}
+		got = append(got, sel.ChannelID)
+		router.ExcludeChannel(sel.ChannelID)
+	}
+	if got[0] != 1 || got[1] != 2 || got[2] != 3 {
+		t.Fatalf("expected sequential order by cost within priority [1 2 3], got=%v", got)
+	}
+}
+
+func TestGroupRouter_Next_PriorityRoutingIgnoresCost(t *testing.T) {
+	s, gs, g0 := costRoutingFixture(store.ChannelGroupRoutingPriority)
+	router := NewGroupRouter(gs, s, 10, "", costRoutingConstraints(g0))
+
+	first, err := router.Next(context.Background())
+	if err != nil {
+		t.Fatalf("first Next err: %v", err)
+	}
+	if first.ChannelID != 2 {
+		t.Fatalf("expected default tie-break channel=2 under priority routing, got=%d", first.ChannelID)
+	}
+}
@@ -0,0 +1,63 @@
+package scheduler
+
+import (
+	"time"
+
+	"realms/internal/store"
+)
+
+// credentialInflightLease 为单次并发占用的最长持有时间；进程异常退出未释放时到期自动回收。
+const credentialInflightLease = 30 * time.Minute
+
+// CredentialLimits 为 credential 实际生效的限流上限（<=0 表示不限制）。
+type CredentialLimits struct {
+	RPM            int
+	TPM            int
+	MaxConcurrency int
+}
+
+// resolveCredentialLimits 按“credential 自身配置优先、endpoint 默认值兜底”计算生效上限。
+func resolveCredentialLimits(cred store.CredentialLimits, ep store.CredentialLimits) CredentialLimits {
+	pick := func(own, def *int) int {
+		if own != nil && *own > 0 {
+			return *own
+		}
+		if def != nil && *def > 0 {
+			return *def
+		}
+		return 0
+	}
+	return CredentialLimits{
+		RPM:            pick(cred.RPM, ep.RPM),
+		TPM:            pick(cred.TPM, ep.TPM),
+		MaxConcurrency: pick(cred.MaxConcurrency, ep.MaxConcurrency),
+	}
+}
+
+// credentialSaturated 判断 credential 是否已达到 RPM/TPM/并发上限；达到上限的 credential 不参与本次选择。
+func (s *Scheduler) credentialSaturated(credentialKey string, lim CredentialLimits, now time.Time) bool {
+	if lim.RPM > 0 && s.state.RPM(credentialKey, now, s.rpmWindow) >= lim.RPM {
+		return true
+	}
+	if lim.TPM > 0 && s.state.TPM(credentialKey, now, s.rpmWindow) >= lim.TPM {
+		return true
+	}
+	if lim.MaxConcurrency > 0 && s.state.CredentialInflight(credentialKey, now) >= lim.MaxConcurrency {
+		return true
+	}
+	return false
+}
+
+// TryAcquireCredentialConcurrency 按 Selection 上的并发上限非阻塞地占用一个槽位。
+// 未配置并发上限时直接成功；返回 false 表示该 credential 已满，调用方应切换到其他 credential。
+func (s *Scheduler) TryAcquireCredentialConcurrency(sel Selection) (func(), bool) {
+	if s == nil || s.state == nil || sel.Limits.MaxConcurrency <= 0 {
+		return func() {}, true
+	}
+	key := sel.CredentialKey()
+	token, ok := s.state.AcquireCredentialInflight(key, sel.Limits.MaxConcurrency, time.Now(), credentialInflightLease)
+	if !ok {
+		return nil, false
+	}
+	return func() { s.state.ReleaseCredentialInflight(key, token) }, true
+}
@@ -0,0 +1,192 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"realms/internal/store"
+)
+
+func intPtr(v int) *int { return &v }
+
+func TestSelectCredential_SkipsCredentialAtRPMLimit(t *testing.T) {
+	s := New(&fakeStore{
+		channels: []store.UpstreamChannel{
+			{ID: 1, Type: store.UpstreamTypeOpenAICompatible, Status: 1},
+		},
+		endpoints: map[int64][]store.UpstreamEndpoint{
+			1: {
+				{ID: 11, ChannelID: 1, BaseURL: "https://a.example", Status: 1},
+			},
+		},
+		creds: map[int64][]store.OpenAICompatibleCredential{
+			11: {
+				{ID: 1, EndpointID: 11, Status: 1, Limits: store.CredentialLimits{RPM: intPtr(1000)}},
+				{ID: 2, EndpointID: 11, Status: 1, Limits: store.CredentialLimits{RPM: intPtr(2)}},
+			},
+		},
+	})
+	ctx := context.Background()
+
+	var got []int64
+	// 不限额时两个 credential 按 RPM 轮流（各 3 次）；限额后 credential 2 只能被选中 2 次。
+	for i := 0; i < 6; i++ {
+		sel, err := s.SelectWithConstraints(ctx, int64(100+i), "", Constraints{})
+		if err != nil {
+			t.Fatalf("Select err: %v", err)
+		}
+		got = append(got, sel.CredentialID)
+	}
+	cred2 := 0
+	for _, id := range got {
+		if id == 2 {
+			cred2++
+		}
+	}
+	if cred2 != 2 {
+		t.Fatalf("expected credential 2 selected exactly rpm_limit=2 times, got=%v", got)
+	}
+}
+
+func TestSelectCredential_SkipsCredentialAtTPMLimit(t *testing.T) {
+	s := New(&fakeStore{
+		channels: []store.UpstreamChannel{
+			{ID: 1, Type: store.UpstreamTypeOpenAICompatible, Status: 1},
+		},
+		endpoints: map[int64][]store.UpstreamEndpoint{
+			1: {
+				{ID: 11, ChannelID: 1, BaseURL: "https://a.example", Status: 1},
+			},
+		},
+		creds: map[int64][]store.OpenAICompatibleCredential{
+			11: {
+				{ID: 1, EndpointID: 11, Status: 1, Limits: store.CredentialLimits{RPM: intPtr(1000)}},
+				{ID: 2, EndpointID: 11, Status: 1, Limits: store.CredentialLimits{TPM: intPtr(1000)}},
+			},
+		},
+	})
+	ctx := context.Background()
+
+	s.RecordTokens("openai_compatible:2", 1000)
+	sel, err := s.SelectWithConstraints(ctx, 10, "", Constraints{})
+	if err != nil {
+		t.Fatalf("Select err: %v", err)
+	}
+	if sel.CredentialID != 1 {
+		t.Fatalf("expected credential over tpm limit to be skipped, got=%d", sel.CredentialID)
+	}
+}
+
+func TestSelectCredential_EndpointDefaultConcurrencyLimit(t *testing.T) {
+	// credential 1 仅配置 RPM，并发上限继承 endpoint 默认值 1；credential 2 覆盖为 2。
+	s := New(&fakeStore{
+		channels: []store.UpstreamChannel{
+			{ID: 1, Type: store.UpstreamTypeOpenAICompatible, Status: 1},
+		},
+		endpoints: map[int64][]store.UpstreamEndpoint{
+			1: {
+				{ID: 11, ChannelID: 1, BaseURL: "https://a.example", Status: 1, Limits: store.CredentialLimits{MaxConcurrency: intPtr(1)}},
+			},
+		},
+		creds: map[int64][]store.OpenAICompatibleCredential{
+			11: {
+				{ID: 1, EndpointID: 11, Status: 1, Limits: store.CredentialLimits{RPM: intPtr(1000)}},
+				{ID: 2, EndpointID: 11, Status: 1, Limits: store.CredentialLimits{MaxConcurrency: intPtr(2)}},
+			},
+		},
+	})
+	ctx := context.Background()
+
+	sel, err := s.SelectWithConstraints(ctx, 10, "", Constraints{})
+	if err != nil {
+		t.Fatalf("Select err: %v", err)
+	}
+	if sel.CredentialID != 2 || sel.Limits.MaxConcurrency != 2 {
+		t.Fatalf("unexpected selection: cred=%d limits=%+v", sel.CredentialID, sel.Limits)
+	}
+	var releases []func()
+	for i := 0; i < 2; i++ {
+		release, ok := s.TryAcquireCredentialConcurrency(sel)
+		if !ok {
+			t.Fatalf("expected slot %d to be acquired", i)
+		}
+		releases = append(releases, release)
+	}
+	if _, ok := s.TryAcquireCredentialConcurrency(sel); ok {
+		t.Fatalf("expected third slot to be rejected")
+	}
+
+	sel, err = s.SelectWithConstraints(ctx, 11, "", Constraints{})
+	if err != nil {
+		t.Fatalf("Select err: %v", err)
+	}
+	if sel.CredentialID != 1 || sel.Limits.MaxConcurrency != 1 || sel.Limits.RPM != 1000 {
+		t.Fatalf("expected saturated credential skipped with inherited limits, got cred=%d limits=%+v", sel.CredentialID, sel.Limits)
+	}
+	release1, ok := s.TryAcquireCredentialConcurrency(sel)
+	if !ok {
+		t.Fatalf("expected credential 1 slot")
+	}
+	if _, err := s.SelectWithConstraints(ctx, 12, "", Constraints{}); err == nil {
+		t.Fatalf("expected no credential available when all are saturated")
+	}
+
+	release1()
+	for _, release := range releases {
+		release()
+	}
+	sel, err = s.SelectWithConstraints(ctx, 13, "", Constraints{})
+	if err != nil || sel.CredentialID != 2 {
+		t.Fatalf("expected credential 2 available after release, got=%d err=%v", sel.CredentialID, err)
+	}
+}
+
+func TestState_CredentialInflightLeaseExpires(t *testing.T) {
+	st := NewState()
+	now := time.Now()
+	if _, ok := st.AcquireCredentialInflight("k", 1, now, time.Minute); !ok {
+		t.Fatalf("expected acquire")
+	}
+	if _, ok := st.AcquireCredentialInflight("k", 1, now, time.Minute); ok {
+		t.Fatalf("expected second acquire rejected")
+	}
+	later := now.Add(2 * time.Minute)
+	if got := st.CredentialInflight("k", later); got != 0 {
+		t.Fatalf("expected leaked slot expired, got=%d", got)
+	}
+	if _, ok := st.AcquireCredentialInflight("k", 1, later, time.Minute); !ok {
+		t.Fatalf("expected acquire after lease expiry")
+	}
+}
+
+func TestRedisState_TPMAndInflightShared(t *testing.T) {
+	mr := miniredis.RunT(t)
+	a := newTestRedisState(t, mr)
+	b := newTestRedisState(t, mr)
+	now := time.Now()
+
+	a.RecordTokens("openai_compatible:1", now.Add(-90*time.Second), 500)
+	a.RecordTokens("openai_compatible:1", now.Add(-10*time.Second), 300)
+	b.RecordTokens("openai_compatible:1", now.Add(-5*time.Second), 200)
+	if got := b.TPM("openai_compatible:1", now, time.Minute); got != 500 {
+		t.Fatalf("expected shared tpm=500, got=%d", got)
+	}
+
+	token, ok := a.AcquireCredentialInflight("openai_compatible:1", 1, now, time.Minute)
+	if !ok {
+		t.Fatalf("expected replica a to acquire slot")
+	}
+	if _, ok := b.AcquireCredentialInflight("openai_compatible:1", 1, now, time.Minute); ok {
+		t.Fatalf("expected replica b to be rejected while slot held")
+	}
+	if got := b.CredentialInflight("openai_compatible:1", now); got != 1 {
+		t.Fatalf("expected shared inflight=1, got=%d", got)
+	}
+	a.ReleaseCredentialInflight("openai_compatible:1", token)
+	if _, ok := b.AcquireCredentialInflight("openai_compatible:1", 1, now, time.Minute); !ok {
+		t.Fatalf("expected replica b to acquire after release")
+	}
+}
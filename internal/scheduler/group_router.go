@@ -8,6 +8,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/shopspring/decimal"
+
 	"realms/internal/store"
 )
 
@@ -27,6 +29,13 @@ type GroupRouter struct {
 	cons         Constraints
 	userID       int64
 	routeKeyHash string
+	// weightSeed 决定同优先级渠道的加权顺序：有 routeKey 时取 routeKeyHash（会话粘性），
+	// 否则每个 router 随机生成一次，保证同一请求内多次 Next 的顺序一致。
+	weightSeed string
+	// latencyExplore 为本请求是否跳过延迟排序（按 ExplorationRatio 每个 router 决定一次）。
+	latencyExplore bool
+	// sequentialLatency 缓存顺序 failover 路径上各渠道首次读取的延迟得分，保证同一请求内候选顺序一致。
+	sequentialLatency map[int64]sequentialLatencyScore
 
 	cursors          map[int64]*groupCursor
 	activePath       map[int64]struct{}
@@ -54,15 +63,25 @@ type groupCursor struct {
 }
 
 func NewGroupRouter(st ChannelGroupStore, sched *Scheduler, userID int64, routeKeyHash string, cons Constraints) *GroupRouter {
+	weightSeed := routeKeyHash
+	if weightSeed == "" {
+		weightSeed = randomWeightSeed()
+	}
+	if cons.trace == nil && sched != nil && sched.routingTrace {
+		cons.trace = &RouteTrace{}
+	}
 	return &GroupRouter{
 		st:                       st,
 		sched:                    sched,
 		userID:                   userID,
 		routeKeyHash:             routeKeyHash,
+		weightSeed:               weightSeed,
+		latencyExplore:           sched.latencyExplore(),
 		cons:                     cons,
 		cursors:                  make(map[int64]*groupCursor),
 		activePath:               make(map[int64]struct{}),
 		excludedChannels:         make(map[int64]struct{}),
+		sequentialLatency:        make(map[int64]sequentialLatencyScore),
 		sequentialStartChannelID: cons.StartChannelID,
 	}
 }
@@ -87,6 +106,55 @@ func routeScopeError(cons Constraints) error {
 }
 
 func (r *GroupRouter) Next(ctx context.Context) (Selection, error) {
+	sel, err := r.next(ctx)
+	if r.cons.trace != nil {
+		r.cons.trace.setResult(sel, err)
+		if err == nil {
+			sel.RouteTrace = r.cons.trace
+		}
+	}
+	return sel, err
+}
+
+// traceGroupSkip 记录渠道组级跳过原因（组不存在/已禁用/不在时间窗内）。
+func (r *GroupRouter) traceGroupSkip(name string, reason string) {
+	if r.cons.trace == nil {
+		return
+	}
+	r.cons.trace.addSkip(RouteTraceSkip{Level: RouteTraceLevelGroup, Group: name, Reason: reason})
+}
+
+// traceMemberGroupSkip 记录子渠道组成员的跳过原因。
+func (r *GroupRouter) traceMemberGroupSkip(m store.ChannelGroupMemberDetail, reason string) {
+	if m.MemberGroupName == nil {
+		return
+	}
+	r.traceGroupSkip(strings.TrimSpace(*m.MemberGroupName), reason)
+}
+
+// traceChannelSkip 记录 group router 层面的渠道跳过原因（封禁、本次 failover 已排除、成员不满足约束等）。
+func (r *GroupRouter) traceChannelSkip(channelID int64, reason string) {
+	if r.cons.trace == nil {
+		return
+	}
+	r.cons.trace.addSkip(RouteTraceSkip{Level: RouteTraceLevelChannel, ChannelID: channelID, Reason: reason})
+}
+
+func (r *GroupRouter) traceCandidates(cands []channelCandidate) {
+	if r.cons.trace == nil {
+		return
+	}
+	for _, cand := range cands {
+		r.cons.trace.addCandidate(RouteTraceCandidate{
+			ChannelID:  cand.ChannelID,
+			RouteGroup: normalizeRouteGroup(cand.RouteGroup),
+			Priority:   cand.Priority,
+			Promotion:  cand.Promotion,
+		})
+	}
+}
+
+func (r *GroupRouter) next(ctx context.Context) (Selection, error) {
 	if r.sched == nil {
 		return Selection{}, errors.New("group router 未配置")
 	}
@@ -150,7 +218,7 @@ func (r *GroupRouter) nextFromOrderedGroups(ctx context.Context) (Selection, err
 		return Selection{}, errGroupExhausted
 	}
 	now := time.Now()
-	if r.sched != nil && r.sched.state != nil {
+	if r.sched != nil && r.sched.state != nil && !r.cons.dryRun {
 		r.sched.state.SweepExpiredChannelBans(now)
 	}
 
@@ -158,6 +226,7 @@ func (r *GroupRouter) nextFromOrderedGroups(ctx context.Context) (Selection, err
 	bestBannedUntil := time.Time{}
 	bestBannedGroupID := int64(0)
 	bestBannedRouteGroup := ""
+	bestBannedRouteGroupID := int64(0)
 	fastModeUnsupported := false
 	for _, raw := range r.cons.AllowGroupOrder {
 		name := strings.TrimSpace(raw)
@@ -167,11 +236,13 @@ func (r *GroupRouter) nextFromOrderedGroups(ctx context.Context) (Selection, err
 		g, err := r.st.GetChannelGroupByName(ctx, name)
 		if err != nil {
 			if errors.Is(err, sql.ErrNoRows) {
+				r.traceGroupSkip(name, RouteSkipNotFound)
 				continue
 			}
 			return Selection{}, err
 		}
 		if g.Status != 1 {
+			r.traceGroupSkip(name, RouteSkipDisabled)
 			continue
 		}
 		sel, err := r.nextFromGroup(ctx, g.ID)
@@ -182,12 +253,13 @@ func (r *GroupRouter) nextFromOrderedGroups(ctx context.Context) (Selection, err
 			}
 			if errors.Is(err, errGroupExhausted) {
 				if r.sched != nil && r.sched.state != nil {
-					if chID, until, routeGroup, ok, e := r.earliestBannedCandidateInGroup(ctx, g.ID, now); e == nil && ok {
+					if cand, until, ok, e := r.earliestBannedCandidateInGroup(ctx, g.ID, now); e == nil && ok {
 						if bestBannedID == 0 || until.Before(bestBannedUntil) {
-							bestBannedID = chID
+							bestBannedID = cand.ChannelID
 							bestBannedUntil = until
 							bestBannedGroupID = g.ID
-							bestBannedRouteGroup = routeGroup
+							bestBannedRouteGroup = cand.RouteGroup
+							bestBannedRouteGroupID = cand.SourceGroupID
 						}
 					} else if e != nil {
 						return Selection{}, e
@@ -213,8 +285,11 @@ func (r *GroupRouter) nextFromOrderedGroups(ctx context.Context) (Selection, err
 				r.lastSelectedChannelID = bestBannedID
 				r.lastSelectedStreak = 1
 			}
-			r.sched.touchChannelGroupPointer(ctx, bestBannedGroupID, bestBannedID, "route")
+			if !r.cons.dryRun {
+				r.sched.touchChannelGroupPointer(ctx, bestBannedGroupID, bestBannedID, "route")
+			}
 			sel.RouteGroup = bestBannedRouteGroup
+			sel.RouteGroupID = bestBannedRouteGroupID
 			return sel, nil
 		}
 	}
@@ -282,7 +357,7 @@ func (r *GroupRouter) nextFromOrderedGroupsSequential(ctx context.Context) (Sele
 		return Selection{}, errGroupExhausted
 	}
 	now := time.Now()
-	if r.sched.state != nil {
+	if r.sched.state != nil && !r.cons.dryRun {
 		r.sched.state.SweepExpiredChannelBans(now)
 	}
 
@@ -290,6 +365,15 @@ func (r *GroupRouter) nextFromOrderedGroupsSequential(ctx context.Context) (Sele
 	if err != nil {
 		return Selection{}, err
 	}
+	if r.cons.trace != nil {
+		cands := make([]channelCandidate, 0, len(ordered))
+		for _, cand := range ordered {
+			c := cand.channelCandidate
+			c.RouteGroup = cand.RouteGroup
+			cands = append(cands, c)
+		}
+		r.traceCandidates(cands)
+	}
 	if len(ordered) == 0 {
 		if r.cons.RequireFastMode {
 			return Selection{}, ErrFastModeUnsupported
@@ -330,16 +414,20 @@ func (r *GroupRouter) nextFromOrderedGroupsSequential(ctx context.Context) (Sele
 	bestBannedID := int64(0)
 	bestBannedUntil := time.Time{}
 	bestBannedRouteGroup := ""
+	bestBannedRouteGroupID := int64(0)
 	for _, cand := range ordered[startIdx:] {
 		if _, excluded := r.excludedChannels[cand.ChannelID]; excluded {
+			r.traceChannelSkip(cand.ChannelID, RouteSkipExcluded)
 			continue
 		}
 		if r.sched.state != nil && r.sched.state.IsChannelBanned(cand.ChannelID, now) {
+			r.traceChannelSkip(cand.ChannelID, RouteSkipBanned)
 			if until, ok := r.sched.state.ChannelBanUntil(cand.ChannelID, now); ok {
 				if bestBannedID == 0 || until.Before(bestBannedUntil) {
 					bestBannedID = cand.ChannelID
 					bestBannedUntil = until
 					bestBannedRouteGroup = normalizeRouteGroup(cand.RouteGroup)
+					bestBannedRouteGroupID = cand.SourceGroupID
 				}
 			}
 			continue
@@ -357,6 +445,7 @@ func (r *GroupRouter) nextFromOrderedGroupsSequential(ctx context.Context) (Sele
 		r.sequentialStartChannelID = cand.ChannelID
 		r.sequentialStartExclusive = false
 		sel.RouteGroup = normalizeRouteGroup(cand.RouteGroup)
+		sel.RouteGroupID = cand.SourceGroupID
 		return sel, nil
 	}
 	if bestBannedID != 0 {
@@ -366,6 +455,7 @@ func (r *GroupRouter) nextFromOrderedGroupsSequential(ctx context.Context) (Sele
 			r.sequentialStartChannelID = bestBannedID
 			r.sequentialStartExclusive = false
 			sel.RouteGroup = normalizeRouteGroup(bestBannedRouteGroup)
+			sel.RouteGroupID = bestBannedRouteGroupID
 			return sel, nil
 		}
 	}
@@ -389,11 +479,13 @@ func (r *GroupRouter) orderedSequentialCandidates(ctx context.Context) ([]ordere
 		g, err := r.st.GetChannelGroupByName(ctx, name)
 		if err != nil {
 			if errors.Is(err, sql.ErrNoRows) {
+				r.traceGroupSkip(name, RouteSkipNotFound)
 				continue
 			}
 			return nil, err
 		}
 		if g.Status != 1 {
+			r.traceGroupSkip(name, RouteSkipDisabled)
 			continue
 		}
 		if err := r.appendSequentialCandidatesFromGroup(ctx, g.ID, nil, seen, &out); err != nil {
@@ -425,7 +517,7 @@ func (r *GroupRouter) appendSequentialCandidatesFromGroup(ctx context.Context, g
 		return err
 	}
 
-	members := append([]store.ChannelGroupMemberDetail(nil), c.members...)
+	members := r.applyCanaryMembers(c.members)
 	sort.SliceStable(members, func(i, j int) bool {
 		if members[i].Promotion != members[j].Promotion {
 			return members[i].Promotion
@@ -435,6 +527,34 @@ func (r *GroupRouter) appendSequentialCandidatesFromGroup(ctx context.Context, g
 		}
 		return members[i].MemberID > members[j].MemberID
 	})
+	weightedTierOrder(len(members), func(i, j int) bool {
+		return members[i].Promotion == members[j].Promotion && members[i].Priority == members[j].Priority
+	}, func(i int) int {
+		return memberChannelWeight(members[i])
+	}, func(i int) int64 {
+		if members[i].MemberChannelID != nil {
+			return *members[i].MemberChannelID
+		}
+		return -members[i].MemberID
+	}, r.weightSeed, func(i, j int) {
+		members[i], members[j] = members[j], members[i]
+	})
+	if score := r.sequentialLatencyScorer(); score != nil {
+		// 同层且未配置权重的渠道成员按观测延迟排序；子组成员不参与。
+		latencyTierOrder(len(members), func(i, j int) bool {
+			return members[i].MemberChannelID != nil && members[j].MemberChannelID != nil &&
+				members[i].Promotion == members[j].Promotion && members[i].Priority == members[j].Priority
+		}, func(i int) int {
+			return memberChannelWeight(members[i])
+		}, func(i int) (float64, bool) {
+			return score(*members[i].MemberChannelID)
+		}, false, func(i, j int) {
+			members[i], members[j] = members[j], members[i]
+		})
+	}
+	if c.group.RoutingMode == store.ChannelGroupRoutingCost {
+		r.applyMemberCosts(members)
+	}
 
 	for _, m := range members {
 		if m.MemberGroupID != nil && m.MemberChannelID != nil {
@@ -447,6 +567,10 @@ func (r *GroupRouter) appendSequentialCandidatesFromGroup(ctx context.Context, g
 			if c.group.Name == store.DefaultGroupName && !r.groupAllowed(m.MemberGroupName) {
 				continue
 			}
+			if !r.memberScheduleActive(ctx, m) {
+				r.traceMemberGroupSkip(m, RouteSkipOutOfSchedule)
+				continue
+			}
 			if err := r.appendSequentialCandidatesFromGroup(ctx, *m.MemberGroupID, path, seen, out); err != nil {
 				return err
 			}
@@ -459,7 +583,12 @@ func (r *GroupRouter) appendSequentialCandidatesFromGroup(ctx context.Context, g
 		if _, ok := seen[chID]; ok {
 			continue
 		}
-		if !r.channelAllowed(m.MemberChannelType, m.MemberChannelGroups, chID) {
+		if reason := r.channelSkipReason(m.MemberChannelType, chID); reason != "" {
+			r.traceChannelSkip(chID, reason)
+			continue
+		}
+		if !r.memberScheduleActive(ctx, m) {
+			r.traceChannelSkip(chID, RouteSkipOutOfSchedule)
 			continue
 		}
 		seen[chID] = struct{}{}
@@ -476,20 +605,82 @@ func (r *GroupRouter) appendSequentialCandidatesFromGroup(ctx context.Context, g
 	return nil
 }
 
-func (r *GroupRouter) earliestBannedCandidateInGroup(ctx context.Context, groupID int64, now time.Time) (int64, time.Time, string, bool, error) {
+type sequentialLatencyScore struct {
+	score float64
+	ok    bool
+}
+
+// sequentialLatencyScorer 返回顺序 failover 路径的延迟得分函数；有 routeKey 或未启用延迟路由时返回 nil。
+// 每个渠道的得分在首次读取后固定，避免同一请求内 Report 更新 EWMA 导致候选顺序变化；
+// 本请求需要探索时，以 weightSeed 派生的稳定随机键代替观测值，在同层内打散。
+func (r *GroupRouter) sequentialLatencyScorer() func(channelID int64) (float64, bool) {
+	if r.routeKeyHash != "" {
+		return nil
+	}
+	score := r.sched.latencyScorer(r.cons.ChannelModelBindingIDs)
+	if score == nil {
+		return nil
+	}
+	return func(channelID int64) (float64, bool) {
+		if v, ok := r.sequentialLatency[channelID]; ok {
+			return v.score, v.ok
+		}
+		v, ok := score(channelID)
+		if ok && r.latencyExplore {
+			v = -weightedKey(r.weightSeed, channelID, 1)
+		}
+		r.sequentialLatency[channelID] = sequentialLatencyScore{score: v, ok: ok}
+		return v, ok
+	}
+}
+
+// applyMemberCosts 为顺序 failover 路径上 routing_mode=cost 的组排序成员：在 promotion/priority 均相同的成员内，
+// 按成员渠道的 channel_model 成本价从低到高排序；未配置成本价的渠道与子组成员排在其后，同价时保持原有顺序。
+// 顺序路径依赖同一请求内多次 Next 的候选顺序一致，因此这里不引入会随失败变化的失败分。
+func (r *GroupRouter) applyMemberCosts(members []store.ChannelGroupMemberDetail) {
+	if len(r.cons.ChannelModelCosts) == 0 || len(members) < 2 {
+		return
+	}
+	expected := func(m store.ChannelGroupMemberDetail) (decimal.Decimal, bool) {
+		if m.MemberChannelID == nil {
+			return decimal.Zero, false
+		}
+		cost, ok := r.cons.ChannelModelCosts[*m.MemberChannelID]
+		if !ok {
+			return decimal.Zero, false
+		}
+		return cost.ExpectedUSDPer1M()
+	}
+	sort.SliceStable(members, func(i, j int) bool {
+		if members[i].Promotion != members[j].Promotion {
+			return members[i].Promotion
+		}
+		if members[i].Priority != members[j].Priority {
+			return members[i].Priority > members[j].Priority
+		}
+		ci, oki := expected(members[i])
+		cj, okj := expected(members[j])
+		if oki != okj {
+			return oki
+		}
+		return oki && ci.LessThan(cj)
+	})
+}
+
+// earliestBannedCandidateInGroup 返回组内（含子组）解禁时间最早的被封禁渠道候选，RouteGroup 已规范化。
+func (r *GroupRouter) earliestBannedCandidateInGroup(ctx context.Context, groupID int64, now time.Time) (channelCandidate, time.Time, bool, error) {
 	if groupID == 0 {
-		return 0, time.Time{}, "", false, nil
+		return channelCandidate{}, time.Time{}, false, nil
 	}
 	if r.sched == nil || r.sched.state == nil {
-		return 0, time.Time{}, "", false, nil
+		return channelCandidate{}, time.Time{}, false, nil
 	}
 	cands := make(map[int64]channelCandidate)
 	if err := r.collectCandidates(ctx, groupID, cands); err != nil {
-		return 0, time.Time{}, "", false, err
+		return channelCandidate{}, time.Time{}, false, err
 	}
-	bestID := int64(0)
+	var best channelCandidate
 	bestUntil := time.Time{}
-	bestRouteGroup := ""
 	for chID, cand := range cands {
 		if chID <= 0 {
 			continue
@@ -501,16 +692,16 @@ func (r *GroupRouter) earliestBannedCandidateInGroup(ctx context.Context, groupI
 		if !ok {
 			continue
 		}
-		if bestID == 0 || until.Before(bestUntil) {
-			bestID = chID
+		if best.ChannelID == 0 || until.Before(bestUntil) {
+			best = cand
+			best.RouteGroup = normalizeRouteGroup(cand.RouteGroup)
 			bestUntil = until
-			bestRouteGroup = normalizeRouteGroup(cand.RouteGroup)
 		}
 	}
-	if bestID == 0 {
-		return 0, time.Time{}, "", false, nil
+	if best.ChannelID == 0 {
+		return channelCandidate{}, time.Time{}, false, nil
 	}
-	return bestID, bestUntil, bestRouteGroup, true, nil
+	return best, bestUntil, true, nil
 }
 
 func (r *GroupRouter) cursorForGroup(ctx context.Context, groupID int64) (*groupCursor, error) {
@@ -562,7 +753,7 @@ func (r *GroupRouter) nextFromGroup(ctx context.Context, groupID int64) (Selecti
 		return Selection{}, errGroupExhausted
 	}
 	now := time.Now()
-	if r.sched != nil && r.sched.state != nil {
+	if r.sched != nil && r.sched.state != nil && !r.cons.dryRun {
 		r.sched.state.SweepExpiredChannelBans(now)
 	}
 	fastModeUnsupported := false
@@ -574,6 +765,13 @@ func (r *GroupRouter) nextFromGroup(ctx context.Context, groupID int64) (Selecti
 		rec, _ := r.sched.maybeSyncChannelGroupPointerFromStore(ctx, groupID)
 		if rec.Pinned {
 			ring := buildCandidateRing(cands)
+			if r.cons.trace != nil {
+				ringCands := make([]channelCandidate, 0, len(ring))
+				for _, id := range ring {
+					ringCands = append(ringCands, cands[id])
+				}
+				r.traceCandidates(ringCands)
+			}
 			if len(ring) > 0 {
 				startID := rec.ChannelID
 				if startID <= 0 {
@@ -587,7 +785,9 @@ func (r *GroupRouter) nextFromGroup(ctx context.Context, groupID int64) (Selecti
 				if !ok {
 					startID = ring[0]
 					startIdx = 0
-					r.sched.setChannelGroupPointer(groupID, startID, rec.Pinned, "invalid")
+					if !r.cons.dryRun {
+						r.sched.setChannelGroupPointer(groupID, startID, rec.Pinned, "invalid")
+					}
 				}
 
 				// 若指针当前渠道处于 ban，按 ring 向后轮转到下一个未封禁渠道并持久化。
@@ -598,7 +798,9 @@ func (r *GroupRouter) nextFromGroup(ctx context.Context, groupID int64) (Selecti
 					if rotatedOK && rotatedID != startID {
 						startID = rotatedID
 						startIdx = rotatedIdx
-						r.sched.setChannelGroupPointer(groupID, startID, rec.Pinned, "ban")
+						if !r.cons.dryRun {
+							r.sched.setChannelGroupPointer(groupID, startID, rec.Pinned, "ban")
+						}
 					}
 				}
 
@@ -615,9 +817,11 @@ func (r *GroupRouter) nextFromGroup(ctx context.Context, groupID int64) (Selecti
 						return Selection{}, false
 					}
 					if _, excluded := r.excludedChannels[chID]; excluded {
+						r.traceChannelSkip(chID, RouteSkipExcluded)
 						return Selection{}, false
 					}
 					if r.sched.state.IsChannelBanned(chID, now) {
+						r.traceChannelSkip(chID, RouteSkipBanned)
 						return Selection{}, false
 					}
 					cons := r.constraintsForResolvedChannel(chID)
@@ -639,9 +843,12 @@ func (r *GroupRouter) nextFromGroup(ctx context.Context, groupID int64) (Selecti
 						r.lastSelectedStreak = 1
 					}
 
-					r.sched.touchChannelGroupPointer(ctx, groupID, chID, "route")
+					if !r.cons.dryRun {
+						r.sched.touchChannelGroupPointer(ctx, groupID, chID, "route")
+					}
 					if cand, ok := cands[chID]; ok {
 						sel.RouteGroup = cand.RouteGroup
+						sel.RouteGroupID = cand.SourceGroupID
 					}
 					return sel, true
 				}
@@ -669,17 +876,20 @@ func (r *GroupRouter) nextFromGroup(ctx context.Context, groupID int64) (Selecti
 			}
 		}
 	}
-	ordered := sortCandidates(cands, func(channelID int64) bool {
-		if r.sched == nil || r.sched.state == nil {
-			return false
-		}
-		return r.sched.state.IsChannelProbePending(channelID, now)
-	}, func(channelID int64) int {
-		if r.sched == nil || r.sched.state == nil {
-			return 0
+	// 失败分与探测标记每次选路只读取一次，后续各排序阶段共用同一快照。
+	var snap ChannelRoutingSnapshot
+	if r.sched != nil && r.sched.state != nil {
+		ids := make([]int64, 0, len(cands))
+		for id := range cands {
+			ids = append(ids, id)
 		}
-		return r.sched.state.ChannelFailScore(channelID)
-	})
+		snap = r.sched.state.ChannelRoutingSnapshot(ids, now)
+	}
+	ordered := sortCandidates(cands, snap.IsProbePending, snap.FailScore)
+	r.applyCandidateWeights(ordered, snap)
+	if c.group.RoutingMode == store.ChannelGroupRoutingCost {
+		r.applyCandidateCosts(ordered, snap)
+	}
 
 	// failover 时给同一渠道一定重试机会，然后再切换到“下一个”渠道（若存在）。
 	// 典型场景：同渠道多 key/账号可接管；或短暂抖动下重试可恢复。
@@ -701,9 +911,11 @@ func (r *GroupRouter) nextFromGroup(ctx context.Context, groupID int64) (Selecti
 		}
 		ordered = reordered
 	}
+	r.traceCandidates(ordered)
 
 	for _, cand := range ordered {
 		if r.sched != nil && r.sched.state != nil && r.sched.state.IsChannelBanned(cand.ChannelID, now) {
+			r.traceChannelSkip(cand.ChannelID, RouteSkipBanned)
 			continue
 		}
 		cons := r.constraintsForResolvedChannel(cand.ChannelID)
@@ -723,10 +935,11 @@ func (r *GroupRouter) nextFromGroup(ctx context.Context, groupID int64) (Selecti
 			r.lastSelectedChannelID = cand.ChannelID
 			r.lastSelectedStreak = 1
 		}
-		if r.sched != nil {
+		if r.sched != nil && !r.cons.dryRun {
 			r.sched.touchChannelGroupPointer(ctx, groupID, cand.ChannelID, "route")
 		}
 		sel.RouteGroup = normalizeRouteGroup(cand.RouteGroup)
+		sel.RouteGroupID = cand.SourceGroupID
 		return sel, nil
 	}
 	if fastModeUnsupported && !hasNonFastModeFailure {
@@ -740,9 +953,101 @@ type channelCandidate struct {
 	SourceGroupID int64
 	Priority      int
 	Promotion     bool
+	Weight        int
 	RouteGroup    string
 }
 
+func memberChannelWeight(m store.ChannelGroupMemberDetail) int {
+	if m.MemberChannelWeight == nil {
+		return 0
+	}
+	return *m.MemberChannelWeight
+}
+
+// applyCandidateWeights 在 sortCandidates 的结果上，对 probe/promotion/priority/失败分均相同的渠道按 weight 加权排序。
+func (r *GroupRouter) applyCandidateWeights(ordered []channelCandidate, snap ChannelRoutingSnapshot) {
+	if r.sched == nil || r.sched.state == nil {
+		return
+	}
+	failScore := snap.FailScore
+	probePending := snap.IsProbePending
+	weightedTierOrder(len(ordered), func(i, j int) bool {
+		return probePending(ordered[i].ChannelID) == probePending(ordered[j].ChannelID) &&
+			ordered[i].Promotion == ordered[j].Promotion &&
+			ordered[i].Priority == ordered[j].Priority &&
+			failScore(ordered[i].ChannelID) == failScore(ordered[j].ChannelID)
+	}, func(i int) int {
+		return ordered[i].Weight
+	}, func(i int) int64 {
+		return ordered[i].ChannelID
+	}, r.weightSeed, func(i, j int) {
+		ordered[i], ordered[j] = ordered[j], ordered[i]
+	})
+	r.applyCandidateLatency(ordered, probePending, failScore)
+}
+
+// applyCandidateLatency 对同层且未配置权重的渠道按观测延迟排序；有 routeKey 时保持会话粘性，不做延迟排序。
+func (r *GroupRouter) applyCandidateLatency(ordered []channelCandidate, probePending func(channelID int64) bool, failScore func(channelID int64) int) {
+	if r.routeKeyHash != "" {
+		return
+	}
+	score := r.sched.latencyScorer(r.cons.ChannelModelBindingIDs)
+	if score == nil {
+		return
+	}
+	latencyTierOrder(len(ordered), func(i, j int) bool {
+		return probePending(ordered[i].ChannelID) == probePending(ordered[j].ChannelID) &&
+			ordered[i].Promotion == ordered[j].Promotion &&
+			ordered[i].Priority == ordered[j].Priority &&
+			failScore(ordered[i].ChannelID) == failScore(ordered[j].ChannelID)
+	}, func(i int) int {
+		return ordered[i].Weight
+	}, func(i int) (float64, bool) {
+		return score(ordered[i].ChannelID)
+	}, r.latencyExplore, func(i, j int) {
+		ordered[i], ordered[j] = ordered[j], ordered[i]
+	})
+}
+
+// applyCandidateCosts 用于 routing_mode=cost 的组：在 probe/promotion/priority/失败分均相同的渠道内，
+// 按 channel_model 成本价从低到高排序，未配置成本价的渠道排在已配置者之后；同价时保持原有顺序。
+// 失败分优先于成本价，避免最便宜的渠道在持续失败（未达封禁阈值）时仍被优先选中。
+func (r *GroupRouter) applyCandidateCosts(ordered []channelCandidate, snap ChannelRoutingSnapshot) {
+	if len(r.cons.ChannelModelCosts) == 0 || len(ordered) < 2 {
+		return
+	}
+	probePending := snap.IsProbePending
+	failScore := snap.FailScore
+	expected := func(channelID int64) (decimal.Decimal, bool) {
+		cost, ok := r.cons.ChannelModelCosts[channelID]
+		if !ok {
+			return decimal.Zero, false
+		}
+		return cost.ExpectedUSDPer1M()
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		pi, pj := probePending(ordered[i].ChannelID), probePending(ordered[j].ChannelID)
+		if pi != pj {
+			return pi
+		}
+		if ordered[i].Promotion != ordered[j].Promotion {
+			return ordered[i].Promotion
+		}
+		if ordered[i].Priority != ordered[j].Priority {
+			return ordered[i].Priority > ordered[j].Priority
+		}
+		if fi, fj := failScore(ordered[i].ChannelID), failScore(ordered[j].ChannelID); fi != fj {
+			return fi < fj
+		}
+		ci, oki := expected(ordered[i].ChannelID)
+		cj, okj := expected(ordered[j].ChannelID)
+		if oki != okj {
+			return oki
+		}
+		return oki && ci.LessThan(cj)
+	})
+}
+
 func (r *GroupRouter) collectCandidates(ctx context.Context, groupID int64, out map[int64]channelCandidate) error {
 	return r.collectCandidatesWithPath(ctx, groupID, nil, out)
 }
@@ -769,7 +1074,7 @@ func (r *GroupRouter) collectCandidatesWithPath(ctx context.Context, groupID int
 		return err
 	}
 
-	for _, m := range c.members {
+	for _, m := range r.applyCanaryMembers(c.members) {
 		// 成员类型校验：必须且只能存在一种 member。
 		if m.MemberGroupID != nil && m.MemberChannelID != nil {
 			continue
@@ -788,6 +1093,10 @@ func (r *GroupRouter) collectCandidatesWithPath(ctx context.Context, groupID int
 					continue
 				}
 			}
+			if !r.memberScheduleActive(ctx, m) {
+				r.traceMemberGroupSkip(m, RouteSkipOutOfSchedule)
+				continue
+			}
 			if err := r.collectCandidatesWithPath(ctx, *m.MemberGroupID, path, out); err != nil {
 				return err
 			}
@@ -798,7 +1107,12 @@ func (r *GroupRouter) collectCandidatesWithPath(ctx context.Context, groupID int
 		if _, ok := r.excludedChannels[chID]; ok {
 			continue
 		}
-		if !r.channelAllowed(m.MemberChannelType, m.MemberChannelGroups, chID) {
+		if reason := r.channelSkipReason(m.MemberChannelType, chID); reason != "" {
+			r.traceChannelSkip(chID, reason)
+			continue
+		}
+		if !r.memberScheduleActive(ctx, m) {
+			r.traceChannelSkip(chID, RouteSkipOutOfSchedule)
 			continue
 		}
 		cand := channelCandidate{
@@ -806,6 +1120,7 @@ func (r *GroupRouter) collectCandidatesWithPath(ctx context.Context, groupID int
 			SourceGroupID: groupID,
 			Priority:      m.Priority,
 			Promotion:     m.Promotion,
+			Weight:        memberChannelWeight(m),
 			RouteGroup:    path.String(),
 		}
 		if prev, ok := out[chID]; ok {
@@ -1032,20 +1347,46 @@ func (r *GroupRouter) groupAllowed(name *string) bool {
 }
 
 func (r *GroupRouter) channelAllowed(chType *string, chGroups *string, chID int64) bool {
+	return r.channelSkipReason(chType, chID) == ""
+}
+
+// channelSkipReason 返回组成员渠道不满足当前约束的原因；满足时返回空串。
+func (r *GroupRouter) channelSkipReason(chType *string, chID int64) string {
 	if r.cons.RequireChannelType != "" {
 		if chType == nil || strings.TrimSpace(*chType) != r.cons.RequireChannelType {
-			return false
+			return RouteSkipChannelTypeMismatch
 		}
 	}
 	if r.cons.RequireAPI == RequiredAPIMessages {
-		if chType == nil || strings.TrimSpace(*chType) != store.UpstreamTypeAnthropic {
-			return false
+		// openai_compatible 渠道是否开启 messages 转换由 Scheduler 按 setting 精确判断。
+		if chType == nil {
+			return RouteSkipAPIUnsupported
+		}
+		switch strings.TrimSpace(*chType) {
+		case store.UpstreamTypeAnthropic, store.UpstreamTypeBedrock, store.UpstreamTypeVertex, store.UpstreamTypeOpenAICompatible:
+		default:
+			return RouteSkipAPIUnsupported
+		}
+	}
+	if r.cons.RequireAPI == RequiredAPIGemini {
+		if chType == nil {
+			return RouteSkipAPIUnsupported
+		}
+		switch strings.TrimSpace(*chType) {
+		case store.UpstreamTypeGemini, store.UpstreamTypeVertex, store.UpstreamTypeOpenAICompatible:
+		default:
+			return RouteSkipAPIUnsupported
+		}
+	}
+	if r.cons.RequireAPI == RequiredAPIEmbeddings {
+		if chType == nil || !embeddingsCapable(strings.TrimSpace(*chType)) {
+			return RouteSkipAPIUnsupported
 		}
 	}
 	if r.cons.AllowChannelIDs != nil {
 		if _, ok := r.cons.AllowChannelIDs[chID]; !ok {
-			return false
+			return RouteSkipModelBindingMissing
 		}
 	}
-	return true
+	return ""
 }
@@ -38,6 +38,22 @@ func (f *fakeUpstreamStore) ListAnthropicCredentialsByEndpoint(_ context.Context
 	return nil, nil
 }
 
+func (f *fakeUpstreamStore) ListGeminiCredentialsByEndpoint(_ context.Context, _ int64) ([]store.GeminiCredential, error) {
+	return nil, nil
+}
+
+func (f *fakeUpstreamStore) ListAzureOpenAICredentialsByEndpoint(_ context.Context, _ int64) ([]store.AzureOpenAICredential, error) {
+	return nil, nil
+}
+
+func (f *fakeUpstreamStore) ListBedrockCredentialsByEndpoint(_ context.Context, _ int64) ([]store.BedrockCredential, error) {
+	return nil, nil
+}
+
+func (f *fakeUpstreamStore) ListVertexCredentialsByEndpoint(_ context.Context, _ int64) ([]store.VertexCredential, error) {
+	return nil, nil
+}
+
 func (f *fakeUpstreamStore) ListCodexOAuthAccountsByEndpoint(_ context.Context, _ int64) ([]store.CodexOAuthAccount, error) {
 	return nil, nil
 }
@@ -736,8 +752,8 @@ func TestGroupRouter_Next_SingleGroupAllBannedFallsBackToNearestUnban(t *testing
 	}
 
 	now := time.Now()
-	s.state.channelBanUntil[1] = now.Add(3 * time.Minute)
-	s.state.channelBanUntil[2] = now.Add(30 * time.Second)
+	s.state.(*State).channelBanUntil[1] = now.Add(3 * time.Minute)
+	s.state.(*State).channelBanUntil[2] = now.Add(30 * time.Second)
 
 	cons := Constraints{
 		AllowGroups:     map[string]struct{}{g0.Name: {}},
@@ -1266,7 +1282,7 @@ func TestGroupRouter_Next_AllGroupsBannedNestedFallbackIgnoresRootAllowGroups(t
 	}
 
 	now := time.Now()
-	s.state.channelBanUntil[1] = now.Add(10 * time.Second)
+	s.state.(*State).channelBanUntil[1] = now.Add(10 * time.Second)
 
 	router := NewGroupRouter(gs, s, 10, "", Constraints{
 		AllowGroups:     map[string]struct{}{parent.Name: {}},
@@ -1352,8 +1368,8 @@ func TestGroupRouter_Next_AllGroupsBannedFallsBackToNearestUnbanAcrossGroups(t *
 	}
 
 	now := time.Now()
-	s.state.channelBanUntil[1] = now.Add(5 * time.Minute)
-	s.state.channelBanUntil[2] = now.Add(10 * time.Second)
+	s.state.(*State).channelBanUntil[1] = now.Add(5 * time.Minute)
+	s.state.(*State).channelBanUntil[2] = now.Add(10 * time.Second)
 
 	cons := Constraints{
 		AllowGroups: map[string]struct{}{
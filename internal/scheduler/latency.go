@@ -0,0 +1,221 @@
+package scheduler
+
+import (
+	"math/rand"
+	"sync"
+)
+
+const (
+	// defaultLatencyEWMAAlpha 为新样本在 EWMA 中的权重。
+	defaultLatencyEWMAAlpha = 0.2
+	// latencyErrorPenalty 控制错误率对延迟得分的放大：score = ttft * (1 + penalty*errorRate)。
+	latencyErrorPenalty = 4.0
+	// latencyUnknownTTFTMS 用于仅有失败样本、尚无 TTFT 观测的对象，避免其因“未测量”被优先。
+	latencyUnknownTTFTMS = 30000.0
+)
+
+// LatencyRoutingOptions 控制同优先级候选按观测延迟排序。
+type LatencyRoutingOptions struct {
+	Enabled bool
+	// ExplorationRatio 为每次调度跳过延迟排序、在同层内随机打散的概率（0~1），
+	// 保证较慢渠道仍能获得流量以便重新测量。
+	ExplorationRatio float64
+}
+
+// latencyStats 为单个渠道或 channel_model binding 的 EWMA 观测。
+type latencyStats struct {
+	ttftMS      float64
+	ttftSamples int64
+	errorRate   float64
+	results     int64
+}
+
+func (l *latencyStats) score() (float64, bool) {
+	if l == nil || l.results == 0 {
+		return 0, false
+	}
+	ttft := l.ttftMS
+	if l.ttftSamples == 0 {
+		ttft = latencyUnknownTTFTMS
+	}
+	return ttft * (1 + latencyErrorPenalty*l.errorRate), true
+}
+
+func (l *latencyStats) snapshot() (float64, float64, bool) {
+	if l == nil || l.results == 0 {
+		return 0, 0, false
+	}
+	return l.ttftMS, l.errorRate, true
+}
+
+// latencyTracker 维护渠道与 channel_model binding 的首字延迟（TTFT）与错误率 EWMA。
+// 仅保存在进程内：观测值是本副本视角下的网络延迟，不随 StateBackend 共享。
+type latencyTracker struct {
+	mu       sync.Mutex
+	alpha    float64
+	channels map[int64]*latencyStats
+	bindings map[int64]*latencyStats
+}
+
+func newLatencyTracker() *latencyTracker {
+	return &latencyTracker{
+		alpha:    defaultLatencyEWMAAlpha,
+		channels: make(map[int64]*latencyStats),
+		bindings: make(map[int64]*latencyStats),
+	}
+}
+
+func (t *latencyTracker) ewma(prev float64, sample float64, n int64) float64 {
+	if n == 0 {
+		return sample
+	}
+	return prev + t.alpha*(sample-prev)
+}
+
+func (t *latencyTracker) record(m map[int64]*latencyStats, id int64, ttftMS float64, failed bool) {
+	if id <= 0 {
+		return
+	}
+	st := m[id]
+	if st == nil {
+		st = &latencyStats{}
+		m[id] = st
+	}
+	errSample := 0.0
+	if failed {
+		errSample = 1
+	}
+	st.errorRate = t.ewma(st.errorRate, errSample, st.results)
+	st.results++
+	if !failed && ttftMS > 0 {
+		st.ttftMS = t.ewma(st.ttftMS, ttftMS, st.ttftSamples)
+		st.ttftSamples++
+	}
+}
+
+// RecordSuccess 记录一次成功；ttftMS<=0（如非流式请求）时只更新错误率。
+func (t *latencyTracker) RecordSuccess(channelID, bindingID int64, ttftMS float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.record(t.channels, channelID, ttftMS, false)
+	t.record(t.bindings, bindingID, ttftMS, false)
+}
+
+func (t *latencyTracker) RecordChannelFailure(channelID int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.record(t.channels, channelID, 0, true)
+}
+
+func (t *latencyTracker) RecordChannelModelFailure(bindingID int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.record(t.bindings, bindingID, 0, true)
+}
+
+// Score 返回候选的延迟得分（越小越快）；binding 有观测时优先使用 binding 级数据。
+func (t *latencyTracker) Score(channelID, bindingID int64) (float64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if bindingID > 0 {
+		if score, ok := t.bindings[bindingID].score(); ok {
+			return score, true
+		}
+	}
+	return t.channels[channelID].score()
+}
+
+// channelSnapshot/bindingSnapshot 返回 EWMA 首字延迟（毫秒）与错误率；无观测时 ok=false。
+func (t *latencyTracker) channelSnapshot(channelID int64) (float64, float64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.channels[channelID].snapshot()
+}
+
+func (t *latencyTracker) bindingSnapshot(bindingID int64) (float64, float64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.bindings[bindingID].snapshot()
+}
+
+// latencyTierOrder 对 [0,n) 中相邻且 sameTier 的连续区间按延迟得分升序重排：
+// 未测量的候选排在最前（尽快获得样本），其余按得分从快到慢。
+// 区间内存在显式权重（>0）时保持加权分流结果；区间内全部未测量时保持原顺序。
+// explore 为 true 时改为在区间内随机打散，使较慢渠道仍有机会被重新测量。
+func latencyTierOrder(n int, sameTier func(i, j int) bool, weight func(i int) int, score func(i int) (float64, bool), explore bool, swap func(i, j int)) {
+	for start := 0; start < n; {
+		end := start + 1
+		for end < n && sameTier(start, end) {
+			end++
+		}
+		if end-start > 1 {
+			configured := false
+			measured := false
+			keys := make([]float64, end-start)
+			for i := start; i < end; i++ {
+				if weight(i) > 0 {
+					configured = true
+				}
+				if s, ok := score(i); ok {
+					keys[i-start] = s
+					measured = true
+				} else {
+					keys[i-start] = -1
+				}
+			}
+			if !configured && measured {
+				if explore {
+					for i := end - start - 1; i > 0; i-- {
+						j := rand.Intn(i + 1)
+						swap(start+i, start+j)
+					}
+				} else {
+					for i := 1; i < len(keys); i++ {
+						for j := i; j > 0 && keys[j] < keys[j-1]; j-- {
+							keys[j], keys[j-1] = keys[j-1], keys[j]
+							swap(start+j, start+j-1)
+						}
+					}
+				}
+			}
+		}
+		start = end
+	}
+}
+
+// latencyScorer 返回候选渠道的延迟得分函数；未启用延迟路由时返回 nil。
+// bindingIDs 非空时优先使用当前模型对应 channel_model binding 的观测。
+func (s *Scheduler) latencyScorer(bindingIDs map[int64]int64) func(channelID int64) (float64, bool) {
+	if s == nil || s.latency == nil || !s.latencyRouting.Enabled {
+		return nil
+	}
+	return func(channelID int64) (float64, bool) {
+		return s.latency.Score(channelID, bindingIDs[channelID])
+	}
+}
+
+// latencyExplore 按 ExplorationRatio 决定本次调度是否跳过延迟排序。
+func (s *Scheduler) latencyExplore() bool {
+	if s == nil || !s.latencyRouting.Enabled || s.latencyRouting.ExplorationRatio <= 0 {
+		return false
+	}
+	return rand.Float64() < s.latencyRouting.ExplorationRatio
+}
+
+// recordLatencyResult 将 Report 结果计入延迟/错误率 EWMA。
+// credential/request 级失败（账号耗尽、请求本身不合法等）不代表渠道质量，不计入错误率。
+func (s *Scheduler) recordLatencyResult(sel Selection, res Result, scope FailureScope) {
+	if s.latency == nil {
+		return
+	}
+	if res.Success {
+		s.latency.RecordSuccess(sel.ChannelID, res.ChannelModelBindingID, float64(res.FirstTokenLatency.Milliseconds()))
+		return
+	}
+	switch scope {
+	case FailureScopeChannel, FailureScopeEndpoint:
+		s.latency.RecordChannelFailure(sel.ChannelID)
+	case FailureScopeChannelModel:
+		s.latency.RecordChannelModelFailure(res.ChannelModelBindingID)
+	}
+}
@@ -0,0 +1,262 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"realms/internal/store"
+)
+
+func latencyTestStore() *fakeStore {
+	return &fakeStore{
+		channels: []store.UpstreamChannel{
+			{ID: 1, Type: store.UpstreamTypeOpenAICompatible, Status: 1, Priority: 0},
+			{ID: 2, Type: store.UpstreamTypeOpenAICompatible, Status: 1, Priority: 0},
+		},
+		endpoints: map[int64][]store.UpstreamEndpoint{
+			1: {
+				{ID: 11, ChannelID: 1, BaseURL: "https://a.example", Status: 1},
+			},
+			2: {
+				{ID: 21, ChannelID: 2, BaseURL: "https://b.example", Status: 1},
+			},
+		},
+		creds: map[int64][]store.OpenAICompatibleCredential{
+			11: {
+				{ID: 111, EndpointID: 11, Status: 1},
+			},
+			21: {
+				{ID: 211, EndpointID: 21, Status: 1},
+			},
+		},
+	}
+}
+
+func reportTTFT(s *Scheduler, channelID int64, credID int64, bindingID int64, ttft time.Duration, n int) {
+	sel := Selection{ChannelID: channelID, CredentialType: CredentialTypeOpenAI, CredentialID: credID}
+	for i := 0; i < n; i++ {
+		s.Report(sel, Result{Success: true, ChannelModelBindingID: bindingID, FirstTokenLatency: ttft})
+	}
+}
+
+func TestSelectWithConstraints_LatencyRoutingPrefersFasterChannel(t *testing.T) {
+	s := NewWithOptions(latencyTestStore(), Options{LatencyRouting: LatencyRoutingOptions{Enabled: true}})
+	reportTTFT(s, 1, 111, 0, 2*time.Second, 5)
+	reportTTFT(s, 2, 211, 0, 200*time.Millisecond, 5)
+
+	for i := 0; i < 50; i++ {
+		sel, err := s.SelectWithConstraints(context.Background(), int64(i+1), "", Constraints{})
+		if err != nil {
+			t.Fatalf("Select err: %v", err)
+		}
+		if sel.ChannelID != 2 {
+			t.Fatalf("expected faster channel=2, got=%d", sel.ChannelID)
+		}
+	}
+
+	rt := s.RuntimeChannelStats(2)
+	if !rt.LatencyObserved || rt.TTFTEWMAMS < 199 || rt.TTFTEWMAMS > 201 || rt.ErrorRateEWMA != 0 {
+		t.Fatalf("unexpected latency runtime: %+v", rt)
+	}
+}
+
+func TestSelectWithConstraints_LatencyRoutingDisabledKeepsOrder(t *testing.T) {
+	s := New(latencyTestStore())
+	reportTTFT(s, 1, 111, 0, 2*time.Second, 5)
+	reportTTFT(s, 2, 211, 0, 200*time.Millisecond, 5)
+
+	sel, err := s.SelectWithConstraints(context.Background(), 1, "", Constraints{})
+	if err != nil {
+		t.Fatalf("Select err: %v", err)
+	}
+	if sel.ChannelID != 1 {
+		t.Fatalf("expected store order channel=1 when latency routing disabled, got=%d", sel.ChannelID)
+	}
+}
+
+func TestSelectWithConstraints_LatencyRoutingPenalizesErrors(t *testing.T) {
+	s := NewWithOptions(latencyTestStore(), Options{LatencyRouting: LatencyRoutingOptions{Enabled: true}})
+	reportTTFT(s, 1, 111, 0, 500*time.Millisecond, 3)
+	reportTTFT(s, 2, 211, 0, 300*time.Millisecond, 3)
+	// 非可重试失败：只计入错误率，不触发冷却/封禁。
+	sel2 := Selection{ChannelID: 2, CredentialType: CredentialTypeOpenAI, CredentialID: 211}
+	for i := 0; i < 5; i++ {
+		s.Report(sel2, Result{Success: false, Scope: FailureScopeChannel})
+	}
+	s.state.ResetChannelFailScore(2)
+
+	sel, err := s.SelectWithConstraints(context.Background(), 1, "", Constraints{})
+	if err != nil {
+		t.Fatalf("Select err: %v", err)
+	}
+	if sel.ChannelID != 1 {
+		t.Fatalf("expected channel=1 after channel=2 error rate rose, got=%d", sel.ChannelID)
+	}
+}
+
+func TestSelectWithConstraints_LatencyRoutingUsesBindingStats(t *testing.T) {
+	s := NewWithOptions(latencyTestStore(), Options{LatencyRouting: LatencyRoutingOptions{Enabled: true}})
+	// 渠道整体上 1 更快，但当前模型在渠道 1 上明显更慢。
+	reportTTFT(s, 1, 111, 0, 100*time.Millisecond, 5)
+	reportTTFT(s, 2, 211, 0, 300*time.Millisecond, 5)
+	reportTTFT(s, 1, 111, 101, 3*time.Second, 5)
+	reportTTFT(s, 2, 211, 201, 300*time.Millisecond, 5)
+
+	sel, err := s.SelectWithConstraints(context.Background(), 1, "", Constraints{
+		ChannelModelBindingIDs: map[int64]int64{1: 101, 2: 201},
+	})
+	if err != nil {
+		t.Fatalf("Select err: %v", err)
+	}
+	if sel.ChannelID != 2 {
+		t.Fatalf("expected channel=2 by binding latency, got=%d", sel.ChannelID)
+	}
+	if rt := s.RuntimeChannelModelStats(101); !rt.LatencyObserved || rt.TTFTEWMAMS < 2999 {
+		t.Fatalf("unexpected binding latency runtime: %+v", rt)
+	}
+}
+
+func TestSelectWithConstraints_LatencyExplorationStillReachesSlowerChannel(t *testing.T) {
+	s := NewWithOptions(latencyTestStore(), Options{LatencyRouting: LatencyRoutingOptions{Enabled: true, ExplorationRatio: 0.5}})
+	reportTTFT(s, 1, 111, 0, 2*time.Second, 5)
+	reportTTFT(s, 2, 211, 0, 200*time.Millisecond, 5)
+
+	const total = 2000
+	counts := map[int64]int{}
+	for i := 0; i < total; i++ {
+		sel, err := s.SelectWithConstraints(context.Background(), int64(i+1), "", Constraints{})
+		if err != nil {
+			t.Fatalf("Select err: %v", err)
+		}
+		counts[sel.ChannelID]++
+	}
+	// 探索比例 0.5 且同层两个渠道随机打散：慢渠道期望占比约 25%。
+	share := float64(counts[1]) / total
+	if share < 0.18 || share > 0.32 {
+		t.Fatalf("expected slower channel share≈0.25, got=%.3f (counts=%v)", share, counts)
+	}
+}
+
+func TestGroupRouter_NextSequential_LatencyRoutingPrefersFasterMember(t *testing.T) {
+	s := NewWithOptions(latencyTestStore(), Options{LatencyRouting: LatencyRoutingOptions{Enabled: true}})
+	reportTTFT(s, 2, 211, 0, 2*time.Second, 5)
+	reportTTFT(s, 1, 111, 0, 200*time.Millisecond, 5)
+
+	g0 := store.ChannelGroup{ID: 1, Name: "g0", Status: 1, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	gs := &fakeGroupStore{
+		groupsByID:   map[int64]store.ChannelGroup{1: g0},
+		groupsByName: map[string]store.ChannelGroup{g0.Name: g0},
+		members: map[int64][]store.ChannelGroupMemberDetail{
+			1: {
+				{MemberID: 1, ParentGroupID: 1, MemberChannelID: ptrInt64(1), MemberChannelType: ptrString(store.UpstreamTypeOpenAICompatible), CreatedAt: time.Now(), UpdatedAt: time.Now()},
+				{MemberID: 2, ParentGroupID: 1, MemberChannelID: ptrInt64(2), MemberChannelType: ptrString(store.UpstreamTypeOpenAICompatible), CreatedAt: time.Now(), UpdatedAt: time.Now()},
+			},
+		},
+	}
+	cons := Constraints{
+		AllowGroups:               map[string]struct{}{g0.Name: {}},
+		AllowGroupOrder:           []string{g0.Name},
+		SequentialChannelFailover: true,
+	}
+
+	// 无延迟排序时按 memberID 倒序会先选渠道 2；延迟路由应先选更快的渠道 1，并在同一请求内按该顺序继续。
+	router := NewGroupRouter(gs, s, 10, "", cons)
+	router.latencyExplore = false
+	first, err := router.Next(context.Background())
+	if err != nil {
+		t.Fatalf("first Next err: %v", err)
+	}
+	if first.ChannelID != 1 {
+		t.Fatalf("expected faster member channel=1, got=%d", first.ChannelID)
+	}
+	// 同一请求内 EWMA 的更新不应打乱候选顺序。
+	reportTTFT(s, 2, 211, 0, time.Millisecond, 50)
+	router.ExcludeChannel(first.ChannelID)
+	second, err := router.Next(context.Background())
+	if err != nil {
+		t.Fatalf("second Next err: %v", err)
+	}
+	if second.ChannelID != 2 {
+		t.Fatalf("expected sequential failover to channel=2, got=%d", second.ChannelID)
+	}
+
+	// 带 routeKey 的请求保持会话粘性，不做延迟排序。
+	sticky := NewGroupRouter(gs, s, 10, "route-key", cons)
+	a, err := sticky.Next(context.Background())
+	if err != nil {
+		t.Fatalf("sticky Next err: %v", err)
+	}
+	again, err := NewGroupRouter(gs, s, 11, "route-key", cons).Next(context.Background())
+	if err != nil || again.ChannelID != a.ChannelID {
+		t.Fatalf("expected routeKey stickiness, got=%d then=%d err=%v", a.ChannelID, again.ChannelID, err)
+	}
+}
+
+func TestGroupRouter_NextSequential_LatencyExplorationReachesSlowerMember(t *testing.T) {
+	s := NewWithOptions(latencyTestStore(), Options{LatencyRouting: LatencyRoutingOptions{Enabled: true, ExplorationRatio: 1}})
+	reportTTFT(s, 1, 111, 0, 2*time.Second, 5)
+	reportTTFT(s, 2, 211, 0, 200*time.Millisecond, 5)
+
+	g0 := store.ChannelGroup{ID: 1, Name: "g0", Status: 1, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	gs := &fakeGroupStore{
+		groupsByID:   map[int64]store.ChannelGroup{1: g0},
+		groupsByName: map[string]store.ChannelGroup{g0.Name: g0},
+		members: map[int64][]store.ChannelGroupMemberDetail{
+			1: {
+				{MemberID: 1, ParentGroupID: 1, MemberChannelID: ptrInt64(1), MemberChannelType: ptrString(store.UpstreamTypeOpenAICompatible), CreatedAt: time.Now(), UpdatedAt: time.Now()},
+				{MemberID: 2, ParentGroupID: 1, MemberChannelID: ptrInt64(2), MemberChannelType: ptrString(store.UpstreamTypeOpenAICompatible), CreatedAt: time.Now(), UpdatedAt: time.Now()},
+			},
+		},
+	}
+	cons := Constraints{
+		AllowGroups:               map[string]struct{}{g0.Name: {}},
+		AllowGroupOrder:           []string{g0.Name},
+		SequentialChannelFailover: true,
+	}
+	counts := map[int64]int{}
+	for i := 0; i < 400; i++ {
+		sel, err := NewGroupRouter(gs, s, int64(i+1), "", cons).Next(context.Background())
+		if err != nil {
+			t.Fatalf("Next err: %v", err)
+		}
+		counts[sel.ChannelID]++
+	}
+	if counts[1] < 120 || counts[2] < 120 {
+		t.Fatalf("expected exploration to spread traffic across members, got=%v", counts)
+	}
+}
+
+func TestLatencyTierOrder_UnmeasuredFirstAndWeightedTierUntouched(t *testing.T) {
+	ids := []int64{1, 2, 3}
+	scores := map[int64]float64{1: 500, 2: 100}
+	latencyTierOrder(len(ids),
+		func(i, j int) bool { return true },
+		func(i int) int { return 0 },
+		func(i int) (float64, bool) {
+			v, ok := scores[ids[i]]
+			return v, ok
+		},
+		false,
+		func(i, j int) { ids[i], ids[j] = ids[j], ids[i] },
+	)
+	if ids[0] != 3 || ids[1] != 2 || ids[2] != 1 {
+		t.Fatalf("expected unmeasured first then fastest, got=%v", ids)
+	}
+
+	ids = []int64{1, 2}
+	weights := map[int64]int{1: 3, 2: 1}
+	latencyTierOrder(len(ids),
+		func(i, j int) bool { return true },
+		func(i int) int { return weights[ids[i]] },
+		func(i int) (float64, bool) {
+			v, ok := scores[ids[i]]
+			return v, ok
+		},
+		false,
+		func(i, j int) { ids[i], ids[j] = ids[j], ids[i] },
+	)
+	if ids[0] != 1 || ids[1] != 2 {
+		t.Fatalf("expected weighted tier unchanged, got=%v", ids)
+	}
+}
@@ -0,0 +1,290 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"realms/internal/store"
+)
+
+// 路由追踪中记录跳过原因的层级。
+const (
+	RouteTraceLevelGroup      = "group"
+	RouteTraceLevelChannel    = "channel"
+	RouteTraceLevelEndpoint   = "endpoint"
+	RouteTraceLevelCredential = "credential"
+)
+
+// 路由追踪中的跳过原因。
+const (
+	RouteSkipDisabled            = "disabled"
+	RouteSkipNotFound            = "not_found"
+	RouteSkipChannelTypeMismatch = "channel_type_mismatch"
+	RouteSkipAPIUnsupported      = "api_unsupported"
+	RouteSkipGroupACL            = "group_acl"
+	RouteSkipFastModeUnsupported = "fast_mode_unsupported"
+	RouteSkipModelBindingMissing = "model_binding_missing"
+	RouteSkipModelBanned         = "model_banned"
+	RouteSkipBanned              = "banned"
+	RouteSkipProbePending        = "probe_pending"
+	RouteSkipCooling             = "cooling"
+	RouteSkipRateLimited         = "rate_limited"
+	RouteSkipNoCredential        = "no_credential"
+	RouteSkipExcluded            = "excluded"
+	RouteSkipOutOfSchedule       = "out_of_schedule"
+	RouteSkipCanaryHoldout       = "canary_holdout"
+	RouteSkipCanaryRolledBack    = "canary_rolled_back"
+)
+
+// RouteTrace 记录一次路由决策的候选顺序、各层跳过原因与最终选择，用于排查“请求为何落到某个渠道”。
+// 同一 GroupRouter 的多次 Next（failover）共享同一份 trace。
+type RouteTrace struct {
+	mu sync.Mutex
+
+	DryRun     bool                  `json:"dry_run"`
+	Candidates []RouteTraceCandidate `json:"candidates"`
+	Skips      []RouteTraceSkip      `json:"skips"`
+	Pick       *RouteTracePick       `json:"pick,omitempty"`
+	Error      string                `json:"error,omitempty"`
+
+	seenCandidates map[int64]struct{}
+	seenSkips      map[RouteTraceSkip]struct{}
+}
+
+type RouteTraceCandidate struct {
+	ChannelID  int64  `json:"channel_id"`
+	RouteGroup string `json:"route_group,omitempty"`
+	Priority   int    `json:"priority"`
+	Promotion  bool   `json:"promotion,omitempty"`
+}
+
+type RouteTraceSkip struct {
+	Level         string `json:"level"`
+	Group         string `json:"group,omitempty"`
+	ChannelID     int64  `json:"channel_id,omitempty"`
+	EndpointID    int64  `json:"endpoint_id,omitempty"`
+	CredentialKey string `json:"credential_key,omitempty"`
+	Reason        string `json:"reason"`
+}
+
+type RouteTracePick struct {
+	ChannelID     int64  `json:"channel_id"`
+	ChannelType   string `json:"channel_type"`
+	EndpointID    int64  `json:"endpoint_id"`
+	CredentialKey string `json:"credential_key"`
+	RouteGroup    string `json:"route_group,omitempty"`
+}
+
+func (t *RouteTrace) addCandidate(c RouteTraceCandidate) {
+	if t == nil || c.ChannelID <= 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.seenCandidates == nil {
+		t.seenCandidates = make(map[int64]struct{})
+	}
+	if _, ok := t.seenCandidates[c.ChannelID]; ok {
+		return
+	}
+	t.seenCandidates[c.ChannelID] = struct{}{}
+	t.Candidates = append(t.Candidates, c)
+}
+
+func (t *RouteTrace) addSkip(s RouteTraceSkip) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.seenSkips == nil {
+		t.seenSkips = make(map[RouteTraceSkip]struct{})
+	}
+	if _, ok := t.seenSkips[s]; ok {
+		return
+	}
+	t.seenSkips[s] = struct{}{}
+	t.Skips = append(t.Skips, s)
+}
+
+func (t *RouteTrace) setResult(sel Selection, err error) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err != nil {
+		t.Pick = nil
+		t.Error = err.Error()
+		return
+	}
+	t.Error = ""
+	t.Pick = &RouteTracePick{
+		ChannelID:     sel.ChannelID,
+		ChannelType:   sel.ChannelType,
+		EndpointID:    sel.EndpointID,
+		CredentialKey: sel.CredentialKey(),
+		RouteGroup:    sel.RouteGroup,
+	}
+}
+
+// MarshalJSON 在锁内序列化，避免与仍在进行的 failover 并发读写。
+func (t *RouteTrace) MarshalJSON() ([]byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	type view struct {
+		DryRun     bool                  `json:"dry_run"`
+		Candidates []RouteTraceCandidate `json:"candidates"`
+		Skips      []RouteTraceSkip      `json:"skips"`
+		Pick       *RouteTracePick       `json:"pick,omitempty"`
+		Error      string                `json:"error,omitempty"`
+	}
+	v := view{DryRun: t.DryRun, Candidates: t.Candidates, Skips: t.Skips, Pick: t.Pick, Error: t.Error}
+	if v.Candidates == nil {
+		v.Candidates = []RouteTraceCandidate{}
+	}
+	if v.Skips == nil {
+		v.Skips = []RouteTraceSkip{}
+	}
+	return json.Marshal(v)
+}
+
+// WithRouteTrace 让后续选择把决策过程记录到 trace（不改变选择行为）。
+func (c Constraints) WithRouteTrace(trace *RouteTrace) Constraints {
+	c.trace = trace
+	return c
+}
+
+// traceChannelSkip 记录渠道级跳过原因；RequireChannelID 约束下只记录目标渠道，避免把无关渠道写入 trace。
+func (c *Constraints) traceChannelSkip(channelID int64, reason string) {
+	if c.trace == nil || (c.RequireChannelID != 0 && channelID != c.RequireChannelID) {
+		return
+	}
+	c.trace.addSkip(RouteTraceSkip{Level: RouteTraceLevelChannel, ChannelID: channelID, Reason: reason})
+}
+
+// ExplainRoute 以 dry-run 方式执行一次分组路由：不认领探测、不计 RPM/选择次数、不写 affinity 与组指针，
+// 返回候选顺序、各层跳过原因与最终选择。
+func (s *Scheduler) ExplainRoute(ctx context.Context, groups ChannelGroupStore, userID int64, routeKeyHash string, cons Constraints) *RouteTrace {
+	trace := &RouteTrace{DryRun: true}
+	cons.trace = trace
+	cons.dryRun = true
+	_, _ = NewGroupRouter(groups, s, userID, routeKeyHash, cons).Next(ctx)
+	return trace
+}
+
+type traceCredential struct {
+	id            int64
+	status        int
+	limits        store.CredentialLimits
+	cooldownUntil *time.Time
+}
+
+// traceCredentialSkips 在 endpoint 内没有可选 credential 时补记每个 credential 的跳过原因；
+// 判定顺序与 selectCredential 保持一致。
+func (s *Scheduler) traceCredentialSkips(ctx context.Context, ch store.UpstreamChannel, ep store.UpstreamEndpoint, now time.Time, cons Constraints) error {
+	if cons.trace == nil {
+		return nil
+	}
+	var (
+		credType CredentialType
+		creds    []traceCredential
+	)
+	switch ch.Type {
+	case store.UpstreamTypeOpenAICompatible:
+		credType = CredentialTypeOpenAI
+		rows, err := s.st.ListOpenAICompatibleCredentialsByEndpoint(ctx, ep.ID)
+		if err != nil {
+			return err
+		}
+		for _, c := range rows {
+			creds = append(creds, traceCredential{id: c.ID, status: c.Status, limits: c.Limits})
+		}
+	case store.UpstreamTypeAnthropic:
+		credType = CredentialTypeAnthropic
+		rows, err := s.st.ListAnthropicCredentialsByEndpoint(ctx, ep.ID)
+		if err != nil {
+			return err
+		}
+		for _, c := range rows {
+			creds = append(creds, traceCredential{id: c.ID, status: c.Status, limits: c.Limits})
+		}
+	case store.UpstreamTypeGemini:
+		credType = CredentialTypeGemini
+		rows, err := s.st.ListGeminiCredentialsByEndpoint(ctx, ep.ID)
+		if err != nil {
+			return err
+		}
+		for _, c := range rows {
+			creds = append(creds, traceCredential{id: c.ID, status: c.Status, limits: c.Limits})
+		}
+	case store.UpstreamTypeAzureOpenAI:
+		credType = CredentialTypeAzure
+		rows, err := s.st.ListAzureOpenAICredentialsByEndpoint(ctx, ep.ID)
+		if err != nil {
+			return err
+		}
+		for _, c := range rows {
+			creds = append(creds, traceCredential{id: c.ID, status: c.Status, limits: c.Limits})
+		}
+	case store.UpstreamTypeBedrock:
+		credType = CredentialTypeBedrock
+		rows, err := s.st.ListBedrockCredentialsByEndpoint(ctx, ep.ID)
+		if err != nil {
+			return err
+		}
+		for _, c := range rows {
+			creds = append(creds, traceCredential{id: c.ID, status: c.Status, limits: c.Limits})
+		}
+	case store.UpstreamTypeVertex:
+		credType = CredentialTypeVertex
+		rows, err := s.st.ListVertexCredentialsByEndpoint(ctx, ep.ID)
+		if err != nil {
+			return err
+		}
+		for _, c := range rows {
+			creds = append(creds, traceCredential{id: c.ID, status: c.Status, limits: c.Limits})
+		}
+	case store.UpstreamTypeCodexOAuth:
+		credType = CredentialTypeCodex
+		rows, err := s.st.ListCodexOAuthAccountsByEndpoint(ctx, ep.ID)
+		if err != nil {
+			return err
+		}
+		for _, a := range rows {
+			creds = append(creds, traceCredential{id: a.ID, status: a.Status, limits: a.Limits, cooldownUntil: a.CooldownUntil})
+		}
+	default:
+		return nil
+	}
+
+	requireCredKey := cons.RequireCredentialKey
+	if len(creds) == 0 {
+		cons.trace.addSkip(RouteTraceSkip{Level: RouteTraceLevelEndpoint, ChannelID: ch.ID, EndpointID: ep.ID, Reason: RouteSkipNoCredential})
+		return nil
+	}
+	for _, c := range creds {
+		key := fmt.Sprintf("%s:%d", credType, c.id)
+		if requireCredKey != "" && key != requireCredKey {
+			continue
+		}
+		reason := ""
+		switch {
+		case c.status != 1:
+			reason = RouteSkipDisabled
+		case c.cooldownUntil != nil && now.Before(*c.cooldownUntil):
+			reason = RouteSkipCooling
+		case s.state.IsCredentialCooling(key, now):
+			reason = RouteSkipCooling
+		case s.credentialSaturated(key, resolveCredentialLimits(c.limits, ep.Limits), now):
+			reason = RouteSkipRateLimited
+		default:
+			continue
+		}
+		cons.trace.addSkip(RouteTraceSkip{Level: RouteTraceLevelCredential, ChannelID: ch.ID, EndpointID: ep.ID, CredentialKey: key, Reason: reason})
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"realms/internal/store"
+)
+
+func hasRouteSkip(trace *RouteTrace, want RouteTraceSkip) bool {
+	for _, s := range trace.Skips {
+		if s == want {
+			return true
+		}
+	}
+	return false
+}
+
+func TestExplainRoute_RecordsSkipReasonsWithoutSideEffects(t *testing.T) {
+	s, gs, g0 := costRoutingFixture(store.ChannelGroupRoutingPriority)
+	now := time.Now()
+	s.state.SetCredentialCooling("openai_compatible:101", now.Add(time.Minute))
+	s.state.BanChannelImmediate(2, now, time.Minute)
+	// 已过期的封禁：dry-run 不应清扫（清扫会把渠道标记为待探测）。
+	s.state.BanChannelImmediate(4, now.Add(-time.Hour), time.Minute)
+
+	cons := costRoutingConstraints(g0)
+	cons.AllowChannelIDs = map[int64]struct{}{1: {}, 2: {}}
+
+	trace := s.ExplainRoute(context.Background(), gs, 10, "", cons)
+	if !trace.DryRun {
+		t.Fatalf("expected dry-run trace")
+	}
+	for _, want := range []RouteTraceSkip{
+		{Level: RouteTraceLevelChannel, ChannelID: 3, Reason: RouteSkipModelBindingMissing},
+		{Level: RouteTraceLevelChannel, ChannelID: 2, Reason: RouteSkipBanned},
+		{Level: RouteTraceLevelCredential, ChannelID: 1, EndpointID: 11, CredentialKey: "openai_compatible:101", Reason: RouteSkipCooling},
+	} {
+		if !hasRouteSkip(trace, want) {
+			t.Fatalf("expected skip %+v, got=%+v", want, trace.Skips)
+		}
+	}
+	if len(trace.Candidates) != 2 || trace.Candidates[0].ChannelID != 2 || trace.Candidates[1].ChannelID != 1 {
+		t.Fatalf("unexpected candidates: %+v", trace.Candidates)
+	}
+	// 其余渠道均不可用时回退到最早解封的封禁渠道。
+	if trace.Pick == nil || trace.Pick.ChannelID != 2 || trace.Pick.CredentialKey != "openai_compatible:201" {
+		t.Fatalf("unexpected pick: %+v (err=%q)", trace.Pick, trace.Error)
+	}
+
+	if rpm := s.state.RPM("openai_compatible:201", time.Now(), s.rpmWindow); rpm != 0 {
+		t.Fatalf("expected dry-run not to record rpm, got=%d", rpm)
+	}
+	if _, ok := s.state.GetAffinity(10, time.Now()); ok {
+		t.Fatalf("expected dry-run not to set affinity")
+	}
+	if s.state.IsChannelProbeDue(4) {
+		t.Fatalf("expected dry-run not to sweep expired channel bans")
+	}
+}
+
+func TestGroupRouter_RoutingTraceAttachedToSelection(t *testing.T) {
+	s, gs, g0 := costRoutingFixture(store.ChannelGroupRoutingPriority)
+	s.routingTrace = true
+
+	sel, err := NewGroupRouter(gs, s, 10, "", costRoutingConstraints(g0)).Next(context.Background())
+	if err != nil {
+		t.Fatalf("Next err: %v", err)
+	}
+	if sel.RouteTrace == nil || sel.RouteTrace.DryRun {
+		t.Fatalf("expected live route trace on selection, got=%+v", sel.RouteTrace)
+	}
+	if sel.RouteTrace.Pick == nil || sel.RouteTrace.Pick.ChannelID != sel.ChannelID {
+		t.Fatalf("unexpected pick: %+v", sel.RouteTrace.Pick)
+	}
+	if rpm := s.state.RPM(sel.CredentialKey(), time.Now(), s.rpmWindow); rpm != 1 {
+		t.Fatalf("expected live selection to record rpm, got=%d", rpm)
+	}
+}
@@ -7,6 +7,14 @@ type RuntimeChannelStats struct {
 
 	BannedUntil *time.Time
 	BanStreak   int
+
+	// Selections 为进程启动以来该渠道被调度选中的次数。
+	Selections int64
+
+	// LatencyObserved 为 true 时，TTFTEWMAMS/ErrorRateEWMA 为本进程观测到的首字延迟与错误率 EWMA。
+	LatencyObserved bool
+	TTFTEWMAMS      float64
+	ErrorRateEWMA   float64
 }
 
 type RuntimeChannelModelStats struct {
@@ -14,21 +22,43 @@ type RuntimeChannelModelStats struct {
 
 	BannedUntil *time.Time
 	BanStreak   int
+
+	LatencyObserved bool
+	TTFTEWMAMS      float64
+	ErrorRateEWMA   float64
 }
 
 func (s *Scheduler) RuntimeChannelStats(channelID int64) RuntimeChannelStats {
 	if s == nil || s.state == nil || channelID == 0 {
 		return RuntimeChannelStats{}
 	}
+	out := s.state.ChannelRuntime(channelID, time.Now())
+	if s.latency != nil {
+		out.TTFTEWMAMS, out.ErrorRateEWMA, out.LatencyObserved = s.latency.channelSnapshot(channelID)
+	}
+	return out
+}
 
-	now := time.Now()
-	st := s.state
+func (s *Scheduler) RuntimeChannelModelStats(bindingID int64) RuntimeChannelModelStats {
+	if s == nil || s.state == nil || bindingID == 0 {
+		return RuntimeChannelModelStats{}
+	}
+	out := s.state.ChannelModelRuntime(bindingID, time.Now())
+	if s.latency != nil {
+		out.TTFTEWMAMS, out.ErrorRateEWMA, out.LatencyObserved = s.latency.bindingSnapshot(bindingID)
+	}
+	return out
+}
+
+// ChannelRuntime 汇总渠道运行态；已过期的封禁会被清理并标记为待探测。
+func (st *State) ChannelRuntime(channelID int64, now time.Time) RuntimeChannelStats {
 	st.mu.Lock()
 	defer st.mu.Unlock()
 
 	out := RuntimeChannelStats{
-		FailScore: st.channelFails[channelID],
-		BanStreak: st.channelBanStreak[channelID],
+		FailScore:  st.channelFails[channelID],
+		BanStreak:  st.channelBanStreak[channelID],
+		Selections: st.channelSelections[channelID],
 	}
 
 	if until, ok := st.channelBanUntil[channelID]; ok {
@@ -48,13 +78,8 @@ func (s *Scheduler) RuntimeChannelStats(channelID int64) RuntimeChannelStats {
 	return out
 }
 
-func (s *Scheduler) RuntimeChannelModelStats(bindingID int64) RuntimeChannelModelStats {
-	if s == nil || s.state == nil || bindingID == 0 {
-		return RuntimeChannelModelStats{}
-	}
-
-	now := time.Now()
-	st := s.state
+// ChannelModelRuntime 汇总 channel_model binding 运行态；已过期的封禁会被清理。
+func (st *State) ChannelModelRuntime(bindingID int64, now time.Time) RuntimeChannelModelStats {
 	st.mu.Lock()
 	defer st.mu.Unlock()
 
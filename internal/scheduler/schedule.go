@@ -0,0 +1,110 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"realms/internal/store"
+)
+
+// scheduleLocationRefresh 为时区来源的缓存时长：管理后台修改时区后最多延迟该时长生效。
+const scheduleLocationRefresh = 30 * time.Second
+
+type scheduleLocationState struct {
+	loc       *time.Location
+	refreshed time.Time
+}
+
+// SetScheduleLocation 设置解释渠道/渠道组成员可用时间窗所用的时区来源（通常读取 admin_time_zone 设置）；
+// 未设置时使用 time.Local。
+func (s *Scheduler) SetScheduleLocation(fn func(ctx context.Context) *time.Location) {
+	if s == nil {
+		return
+	}
+	s.scheduleMu.Lock()
+	defer s.scheduleMu.Unlock()
+	s.scheduleLocation = fn
+	s.scheduleLoc = scheduleLocationState{}
+}
+
+func (s *Scheduler) scheduleLocationAt(ctx context.Context, now time.Time) *time.Location {
+	s.scheduleMu.Lock()
+	defer s.scheduleMu.Unlock()
+	if s.scheduleLocation == nil {
+		return time.Local
+	}
+	if s.scheduleLoc.loc != nil && now.Sub(s.scheduleLoc.refreshed) < scheduleLocationRefresh {
+		return s.scheduleLoc.loc
+	}
+	loc := s.scheduleLocation(ctx)
+	if loc == nil {
+		loc = time.Local
+	}
+	s.scheduleLoc = scheduleLocationState{loc: loc, refreshed: now}
+	return loc
+}
+
+type scheduleCacheKind uint8
+
+const (
+	scheduleCacheChannel scheduleCacheKind = iota + 1
+	scheduleCacheMember
+)
+
+// scheduleCacheKey 按渠道/渠道组成员 ID 缓存解析结果，缓存规模以渠道与成员数量为上限。
+type scheduleCacheKey struct {
+	kind scheduleCacheKind
+	id   int64
+}
+
+// scheduleCacheEntry 记录解析时的原始 JSON：原文变化即视为失效并重新解析。
+type scheduleCacheEntry struct {
+	raw   string
+	sched store.ChannelSchedule
+	err   error
+}
+
+// scheduleActive 判断 schedule JSON 在 now 时刻是否处于可用时间窗内；未配置时视为可用。
+// 解析失败时视为不可用并记录告警（写入时已校验，脏数据不应让“限时使用”的渠道变为全天可用）。
+func (s *Scheduler) scheduleActive(ctx context.Context, key scheduleCacheKey, raw string, now time.Time) bool {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return true
+	}
+	var entry scheduleCacheEntry
+	if v, ok := s.scheduleCache.Load(key); ok && v.(scheduleCacheEntry).raw == raw {
+		entry = v.(scheduleCacheEntry)
+	} else {
+		parsed, err := store.ParseChannelSchedule(raw)
+		entry = scheduleCacheEntry{raw: raw, sched: parsed, err: err}
+		s.scheduleCache.Store(key, entry)
+		if err != nil {
+			slog.Warn("可用时间窗配置无法解析，已视为不可用", "kind", key.kind, "id", key.id, "err", err)
+		}
+	}
+	if entry.err != nil {
+		return false
+	}
+	if len(entry.sched.Windows) == 0 {
+		return true
+	}
+	return entry.sched.ActiveAt(now.In(s.scheduleLocationAt(ctx, now)))
+}
+
+// memberScheduleActive 判断渠道组成员当前是否可用：成员关系自身的时间窗，以及成员渠道的时间窗（若有）。
+func (r *GroupRouter) memberScheduleActive(ctx context.Context, m store.ChannelGroupMemberDetail) bool {
+	if r.sched == nil {
+		return true
+	}
+	now := time.Now()
+	if !r.sched.scheduleActive(ctx, scheduleCacheKey{kind: scheduleCacheMember, id: m.MemberID}, m.Schedule, now) {
+		return false
+	}
+	if m.MemberChannelID != nil && m.MemberChannelSchedule != nil &&
+		!r.sched.scheduleActive(ctx, scheduleCacheKey{kind: scheduleCacheChannel, id: *m.MemberChannelID}, *m.MemberChannelSchedule, now) {
+		return false
+	}
+	return true
+}
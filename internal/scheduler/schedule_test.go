@@ -0,0 +1,82 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"realms/internal/store"
+)
+
+func TestGroupRouter_SkipsChannelsOutsideSchedule(t *testing.T) {
+	s, gs, g0 := costRoutingFixture(store.ChannelGroupRoutingPriority)
+	s.SetScheduleLocation(func(context.Context) *time.Location { return time.UTC })
+
+	today := int(time.Now().UTC().Weekday())
+	offDay := fmt.Sprintf(`{"windows":[{"days":[%d],"start":"00:00","end":"00:00"}]}`, (today+3)%7)
+	onDay := fmt.Sprintf(`{"windows":[{"days":[%d],"start":"00:00","end":"24:00"}]}`, today)
+
+	// 渠道 1 自身时间窗不含今天；渠道 2 的成员关系时间窗不含今天。
+	s.st.(*fakeStore).channels[0].Schedule = offDay
+	gs.members[1][1].Schedule = offDay
+	gs.members[1][2].Schedule = onDay
+
+	cons := costRoutingConstraints(g0)
+	trace := &RouteTrace{}
+	sel, err := NewGroupRouter(gs, s, 10, "", cons.WithRouteTrace(trace)).Next(context.Background())
+	if err != nil {
+		t.Fatalf("Next err: %v", err)
+	}
+	if sel.ChannelID != 3 {
+		t.Fatalf("expected only in-window channel=3, got=%d", sel.ChannelID)
+	}
+	for _, chID := range []int64{1, 2} {
+		if !hasRouteSkip(trace, RouteTraceSkip{Level: RouteTraceLevelChannel, ChannelID: chID, Reason: RouteSkipOutOfSchedule}) {
+			t.Fatalf("expected out_of_schedule skip for channel=%d, got=%+v", chID, trace.Skips)
+		}
+		if s.state.IsChannelBanned(chID, time.Now()) {
+			t.Fatalf("expected out-of-window channel=%d not to be banned", chID)
+		}
+	}
+}
+
+func TestScheduler_ScheduleUsesConfiguredLocation(t *testing.T) {
+	s := New(&fakeStore{})
+	// 2026-01-05 是周一；UTC 02:00 在 UTC+8 为同日 10:00。
+	now := time.Date(2026, 1, 5, 2, 0, 0, 0, time.UTC)
+	raw := `{"windows":[{"days":[1,2,3,4,5],"start":"09:00","end":"18:00"}]}`
+	key := scheduleCacheKey{kind: scheduleCacheChannel, id: 1}
+
+	s.SetScheduleLocation(func(context.Context) *time.Location { return time.UTC })
+	if s.scheduleActive(context.Background(), key, raw, now) {
+		t.Fatalf("expected 02:00 UTC to be outside business hours")
+	}
+	s.SetScheduleLocation(func(context.Context) *time.Location { return time.FixedZone("CST", 8*60*60) })
+	if !s.scheduleActive(context.Background(), key, raw, now) {
+		t.Fatalf("expected 10:00 CST to be inside business hours")
+	}
+}
+
+func TestScheduler_ScheduleCacheFollowsRawAndFailsClosed(t *testing.T) {
+	s := New(&fakeStore{})
+	s.SetScheduleLocation(func(context.Context) *time.Location { return time.UTC })
+	now := time.Date(2026, 1, 5, 2, 0, 0, 0, time.UTC)
+	key := scheduleCacheKey{kind: scheduleCacheChannel, id: 7}
+
+	if s.scheduleActive(context.Background(), key, `{"windows":[{"days":[1],"start":"09:00","end":"18:00"}]}`, now) {
+		t.Fatalf("expected 02:00 to be outside the window")
+	}
+	// 同一渠道的时间窗被修改后，缓存按原文失效。
+	if !s.scheduleActive(context.Background(), key, `{"windows":[{"days":[1],"start":"00:00","end":"24:00"}]}`, now) {
+		t.Fatalf("expected updated schedule to take effect")
+	}
+	if s.scheduleActive(context.Background(), key, `{"windows":[`, now) {
+		t.Fatalf("expected malformed schedule to be treated as inactive")
+	}
+	n := 0
+	s.scheduleCache.Range(func(any, any) bool { n++; return true })
+	if n != 1 {
+		t.Fatalf("expected one cache entry per channel, got=%d", n)
+	}
+}
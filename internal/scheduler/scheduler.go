@@ -26,6 +26,10 @@ const (
 	CredentialTypeOpenAI    CredentialType = "openai_compatible"
 	CredentialTypeCodex     CredentialType = "codex_oauth"
 	CredentialTypeAnthropic CredentialType = "anthropic"
+	CredentialTypeGemini    CredentialType = "gemini"
+	CredentialTypeAzure     CredentialType = "azure_openai"
+	CredentialTypeBedrock   CredentialType = "bedrock"
+	CredentialTypeVertex    CredentialType = "vertex"
 )
 
 type FailureScope string
@@ -43,6 +47,8 @@ type Selection struct {
 	ChannelType   string
 	ChannelGroups string
 	RouteGroup    string
+	// RouteGroupID 为直接包含所选渠道成员的渠道组 ID（用于按组统计灰度健康度）；未经渠道组路由时为 0。
+	RouteGroupID int64
 
 	AllowServiceTier       bool
 	FastMode               bool
@@ -54,6 +60,8 @@ type Selection struct {
 	ThinkingToContent      bool
 	ChatCompletionsEnabled bool
 	ResponsesEnabled       bool
+	EmbeddingsEnabled      bool
+	MessagesEnabled        bool
 	PassThroughBodyEnabled bool
 	Proxy                  string
 	SystemPrompt           string
@@ -68,9 +76,18 @@ type Selection struct {
 
 	EndpointID int64
 	BaseURL    string
+	// APIVersion 为 endpoint 配置的上游 api-version（目前仅 azure_openai 使用）。
+	APIVersion string
 
 	CredentialType CredentialType
 	CredentialID   int64
+	// Limits 为所选 credential 生效的 RPM/TPM/并发上限（credential 配置优先，endpoint 默认值兜底）。
+	Limits CredentialLimits
+
+	// CostBasis 为命中 channel_model 的上游成本价（来自 Constraints.ChannelModelCosts），未配置时为 nil。
+	CostBasis *store.UsageCostBasis
+	// RouteTrace 为产生本次选择的路由决策追踪（开启 routing trace 时由 GroupRouter 填充），未开启时为 nil。
+	RouteTrace *RouteTrace
 }
 
 func (s Selection) CredentialKey() string {
@@ -88,18 +105,32 @@ type Result struct {
 	// CooldownUntil 用于上层传入精确的冷却截止时间（例如上游返回 resets_at）。
 	// 为空时按调度器默认策略计算。
 	CooldownUntil *time.Time
+	// FirstTokenLatency 为本次尝试的首字延迟（仅流式成功时有意义），用于延迟感知路由的 EWMA。
+	FirstTokenLatency time.Duration
 }
 
 type Scheduler struct {
 	st UpstreamStore
 
-	state         *State
+	state         StateBackend
 	groupPointers ChannelGroupPointerStore
+	cooldowns     *cooldownPersister
 	affinityTTL   time.Duration
 	rpmWindow     time.Duration
 	cooldownBase  time.Duration
 	probeClaimTTL time.Duration
 
+	latency        *latencyTracker
+	latencyRouting LatencyRoutingOptions
+
+	backgroundChannelProbe bool
+	routingTrace           bool
+
+	scheduleMu       sync.Mutex
+	scheduleLocation func(ctx context.Context) *time.Location
+	scheduleLoc      scheduleLocationState
+	scheduleCache    sync.Map
+
 	disableCodexOAuth bool
 
 	groupPointerPersistMu   sync.Mutex
@@ -121,6 +152,9 @@ type Constraints struct {
 	// ChannelModelBindingIDs 将“当前请求允许的 channel”映射到对应的 channel_model binding id，
 	// 用于按 binding 运行态封禁筛掉仅对该模型不可用的渠道。
 	ChannelModelBindingIDs map[int64]int64
+	// ChannelModelCosts 为候选 channel 对应 channel_model 的上游成本价（仅含已配置者），
+	// 用于 cost 路由组的同优先级排序，并随 Selection 带出作为 usage_event 的成本基准。
+	ChannelModelCosts map[int64]store.ChannelModelCost
 	// SequentialChannelFailover 用于用户侧 API key 的顺序转移：
 	// 候选 channel 按绑定顺序从前往后尝试，失败后只向后推进，不做 ring/回绕/运行时重排。
 	// 这里的“失败”定义在 channel 层：只有当前 channel 已无法选出任何可用 credential/account，
@@ -129,10 +163,24 @@ type Constraints struct {
 	// StartChannelID 表示“本次顺序转移”的当前起点。
 	// 命中后先尝试该 channel；若该 channel 整体不可继续，才继续尝试它后面的 channel。
 	StartChannelID int64
+
+	// channelProbe 标记后台合成探测的选择：仅它可以认领到期的渠道探测。
+	channelProbe bool
+	// trace 非空时记录候选顺序与跳过原因；dryRun 为 true 时选择过程不产生任何运行态副作用。
+	trace  *RouteTrace
+	dryRun bool
 }
 
 type Options struct {
 	DisableCodexOAuth bool
+	// State 指定运行态存储；为空时使用进程内 State。
+	State StateBackend
+	// LatencyRouting 开启后同优先级候选优先观测首字延迟更低的渠道。
+	LatencyRouting LatencyRoutingOptions
+	// BackgroundChannelProbe 开启后，到期的渠道探测只由后台合成请求认领，用户请求不再充当探测流量。
+	BackgroundChannelProbe bool
+	// RoutingTrace 开启后每个 GroupRouter 记录路由决策追踪，并随 Selection 带出（用于写入 usage_event 明细）。
+	RoutingTrace bool
 }
 
 var ErrRequiredCredentialUnavailable = errors.New("required credential unavailable")
@@ -140,9 +188,14 @@ var ErrRequiredChannelUnavailable = errors.New("required channel unavailable")
 var ErrConstrainedSelectionUnavailable = errors.New("constrained selection unavailable")
 
 const (
-	RequiredAPIResponses       = "responses"
+	RequiredAPIResponses = "responses"
+	// RequiredAPIResponsesNative 要求上游原生支持 Responses（不接受 chat 桥接），
+	// 用于依赖上游存储的接口（检索/取消/删除 response、input_tokens 等）。
+	RequiredAPIResponsesNative = "responses_native"
 	RequiredAPIChatCompletions = "chat_completions"
 	RequiredAPIMessages        = "messages"
+	RequiredAPIEmbeddings      = "embeddings"
+	RequiredAPIGemini          = "gemini"
 )
 
 type UpstreamStore interface {
@@ -150,6 +203,10 @@ type UpstreamStore interface {
 	ListUpstreamEndpointsByChannel(ctx context.Context, channelID int64) ([]store.UpstreamEndpoint, error)
 	ListOpenAICompatibleCredentialsByEndpoint(ctx context.Context, endpointID int64) ([]store.OpenAICompatibleCredential, error)
 	ListAnthropicCredentialsByEndpoint(ctx context.Context, endpointID int64) ([]store.AnthropicCredential, error)
+	ListGeminiCredentialsByEndpoint(ctx context.Context, endpointID int64) ([]store.GeminiCredential, error)
+	ListAzureOpenAICredentialsByEndpoint(ctx context.Context, endpointID int64) ([]store.AzureOpenAICredential, error)
+	ListBedrockCredentialsByEndpoint(ctx context.Context, endpointID int64) ([]store.BedrockCredential, error)
+	ListVertexCredentialsByEndpoint(ctx context.Context, endpointID int64) ([]store.VertexCredential, error)
 	ListCodexOAuthAccountsByEndpoint(ctx context.Context, endpointID int64) ([]store.CodexOAuthAccount, error)
 }
 
@@ -170,10 +227,17 @@ func NewWithOptions(st UpstreamStore, opts Options) *Scheduler {
 		rpmWindow:               60 * time.Second,
 		cooldownBase:            30 * time.Second,
 		probeClaimTTL:           30 * time.Second,
+		latency:                 newLatencyTracker(),
+		latencyRouting:          opts.LatencyRouting,
+		backgroundChannelProbe:  opts.BackgroundChannelProbe,
+		routingTrace:            opts.RoutingTrace,
 		disableCodexOAuth:       opts.DisableCodexOAuth,
 		groupPointerPersistLast: make(map[int64]groupPointerPersistState),
 		groupPointerSync:        make(map[int64]groupPointerSyncState),
 	}
+	if opts.State != nil {
+		s.state = opts.State
+	}
 	return s
 }
 
@@ -314,6 +378,7 @@ func (s *Scheduler) ClearChannelBan(channelID int64) {
 		return
 	}
 	s.state.ClearChannelBan(channelID)
+	s.forgetChannelBan(channelID)
 }
 
 func (s *Scheduler) RouteKeyHash(routeKey string) string {
@@ -353,40 +418,55 @@ func (s *Scheduler) selectWithConstraints(ctx context.Context, userID int64, rou
 	var candidates []store.UpstreamChannel
 	for _, ch := range channels {
 		if ch.Status != 1 {
+			cons.traceChannelSkip(ch.ID, RouteSkipDisabled)
 			continue
 		}
-		if ch.Type != store.UpstreamTypeOpenAICompatible && ch.Type != store.UpstreamTypeCodexOAuth && ch.Type != store.UpstreamTypeAnthropic {
+		if ch.Type != store.UpstreamTypeOpenAICompatible && ch.Type != store.UpstreamTypeCodexOAuth && ch.Type != store.UpstreamTypeAnthropic && ch.Type != store.UpstreamTypeGemini && ch.Type != store.UpstreamTypeAzureOpenAI && ch.Type != store.UpstreamTypeBedrock && ch.Type != store.UpstreamTypeVertex {
+			cons.traceChannelSkip(ch.ID, RouteSkipChannelTypeMismatch)
 			continue
 		}
 		if s.disableCodexOAuth && ch.Type == store.UpstreamTypeCodexOAuth {
+			cons.traceChannelSkip(ch.ID, RouteSkipChannelTypeMismatch)
 			continue
 		}
 		if cons.RequireChannelType != "" && ch.Type != cons.RequireChannelType {
+			cons.traceChannelSkip(ch.ID, RouteSkipChannelTypeMismatch)
 			continue
 		}
 		if cons.RequireAPI != "" && !channelSupportsRequiredAPI(ch, cons.RequireAPI) {
+			cons.traceChannelSkip(ch.ID, RouteSkipAPIUnsupported)
 			continue
 		}
 		if cons.RequireChannelID != 0 && ch.ID != cons.RequireChannelID {
 			continue
 		}
 		if cons.AllowGroups != nil && !channelInAnyGroup(ch.Groups, cons.AllowGroups) {
+			cons.traceChannelSkip(ch.ID, RouteSkipGroupACL)
 			continue
 		}
 		if cons.RequireFastMode && (!ch.AllowServiceTier || !ch.FastMode) {
+			cons.traceChannelSkip(ch.ID, RouteSkipFastModeUnsupported)
+			continue
+		}
+		// 时间窗外的渠道只是暂不可用：不计失败、不触发封禁。
+		if !s.scheduleActive(ctx, scheduleCacheKey{kind: scheduleCacheChannel, id: ch.ID}, ch.Schedule, now) {
+			cons.traceChannelSkip(ch.ID, RouteSkipOutOfSchedule)
 			continue
 		}
 		if cons.AllowChannelIDs != nil {
 			if _, ok := cons.AllowChannelIDs[ch.ID]; !ok {
+				cons.traceChannelSkip(ch.ID, RouteSkipModelBindingMissing)
 				continue
 			}
 		}
 		if cons.ChannelModelBindingIDs != nil {
 			if bindingID, ok := cons.ChannelModelBindingIDs[ch.ID]; ok && s.state.IsChannelModelBanned(bindingID, now) {
+				cons.traceChannelSkip(ch.ID, RouteSkipModelBanned)
 				continue
 			}
 		}
 		if s.state.IsChannelBanned(ch.ID, now) && !(allowBannedRequiredChannel && cons.RequireChannelID != 0 && ch.ID == cons.RequireChannelID) {
+			cons.traceChannelSkip(ch.ID, RouteSkipBanned)
 			continue
 		}
 		candidates = append(candidates, ch)
@@ -405,18 +485,31 @@ func (s *Scheduler) selectWithConstraints(ctx context.Context, userID int64, rou
 	}
 
 	affinityChannelID, affinityOK := s.state.GetAffinity(userID, now)
-	if affinityOK && s.state.ChannelFailScore(affinityChannelID) > 0 {
+	// 失败分与探测标记每次选路只读取一次，排序比较器只访问快照。
+	snapshotIDs := make([]int64, 0, len(candidates)+1)
+	for _, ch := range candidates {
+		snapshotIDs = append(snapshotIDs, ch.ID)
+	}
+	if affinityOK {
+		snapshotIDs = append(snapshotIDs, affinityChannelID)
+	}
+	snap := s.state.ChannelRoutingSnapshot(snapshotIDs, now)
+	if affinityOK && snap.FailScore(affinityChannelID) > 0 {
 		affinityOK = false
 	}
-	ordered := orderChannels(candidates, affinityChannelID, affinityOK, func(channelID int64) bool {
-		return s.state.IsChannelProbePending(channelID, now)
-	}, s.state.ChannelFailScore)
+	// 粘性路由下不做延迟排序，避免同一会话随观测值漂移。
+	var latencyScore func(channelID int64) (float64, bool)
+	if routeKeyHash == "" {
+		latencyScore = s.latencyScorer(cons.ChannelModelBindingIDs)
+	}
+	ordered := orderChannels(candidates, affinityChannelID, affinityOK, snap.IsProbePending, snap.FailScore, randomWeightSeed(), latencyScore, s.latencyExplore())
 	if routeKeyHash != "" && len(ordered) > 1 {
 		// 粘性路由：对“同一会话”的请求做稳定排序，减少跨上游漂移。
+		// 按 weight 做加权 rendezvous：同一会话结果稳定，不同会话按权重比例分布。
 		// 注意：可用性仍由候选集过滤与 probe/ban/cooldown 机制保证。
 		sort.SliceStable(ordered, func(i, j int) bool {
-			si := rendezvousScore64(routeKeyHash, "channel", ordered[i].ID)
-			sj := rendezvousScore64(routeKeyHash, "channel", ordered[j].ID)
+			si := weightedKey(routeKeyHash, ordered[i].ID, ordered[i].Weight)
+			sj := weightedKey(routeKeyHash, ordered[j].ID, ordered[j].Weight)
 			if si != sj {
 				return si > sj
 			}
@@ -424,14 +517,32 @@ func (s *Scheduler) selectWithConstraints(ctx context.Context, userID int64, rou
 		})
 	}
 
+	if cons.trace != nil {
+		for _, ch := range ordered {
+			cons.trace.addCandidate(RouteTraceCandidate{ChannelID: ch.ID, Priority: ch.Priority, Promotion: ch.Promotion})
+		}
+	}
+
 	// 2) 选择 endpoint + credential
 	for _, ch := range ordered {
 		claimedProbe := false
 		if s.state.IsChannelProbeDue(ch.ID) {
-			if !s.state.TryClaimChannelProbe(ch.ID, now, s.probeClaimTTL) {
+			if s.backgroundChannelProbe && !cons.channelProbe {
+				cons.traceChannelSkip(ch.ID, RouteSkipProbePending)
 				continue
 			}
-			claimedProbe = true
+			if cons.dryRun {
+				// dry-run 不认领探测，仅判断此刻是否仍可认领。
+				if !s.state.IsChannelProbePending(ch.ID, now) {
+					cons.traceChannelSkip(ch.ID, RouteSkipProbePending)
+					continue
+				}
+			} else if !s.state.TryClaimChannelProbe(ch.ID, now, s.probeClaimTTL) {
+				cons.traceChannelSkip(ch.ID, RouteSkipProbePending)
+				continue
+			} else {
+				claimedProbe = true
+			}
 		}
 		endpoints, err := s.st.ListUpstreamEndpointsByChannel(ctx, ch.ID)
 		if err != nil {
@@ -443,13 +554,22 @@ func (s *Scheduler) selectWithConstraints(ctx context.Context, userID int64, rou
 		var eps []store.UpstreamEndpoint
 		for _, e := range endpoints {
 			if e.Status != 1 {
+				if cons.trace != nil {
+					cons.trace.addSkip(RouteTraceSkip{Level: RouteTraceLevelEndpoint, ChannelID: ch.ID, EndpointID: e.ID, Reason: RouteSkipDisabled})
+				}
 				continue
 			}
 			if !requirePinnedSelection && s.state.IsEndpointCooling(e.ID, now) {
+				if cons.trace != nil {
+					cons.trace.addSkip(RouteTraceSkip{Level: RouteTraceLevelEndpoint, ChannelID: ch.ID, EndpointID: e.ID, Reason: RouteSkipCooling})
+				}
 				continue
 			}
 			eps = append(eps, e)
 		}
+		if len(eps) == 0 && len(endpoints) == 0 {
+			cons.traceChannelSkip(ch.ID, RouteSkipNoCredential)
+		}
 		sort.SliceStable(eps, func(i, j int) bool {
 			if eps[i].Priority != eps[j].Priority {
 				return eps[i].Priority > eps[j].Priority
@@ -465,13 +585,26 @@ func (s *Scheduler) selectWithConstraints(ctx context.Context, userID int64, rou
 				return Selection{}, err
 			}
 			if ok {
+				if cost, ok := cons.ChannelModelCosts[ch.ID]; ok {
+					sel.CostBasis = &store.UsageCostBasis{ChannelModelID: cons.ChannelModelBindingIDs[ch.ID], Cost: cost}
+				}
+				if cons.dryRun {
+					return sel, nil
+				}
 				s.state.RecordRPM(sel.CredentialKey(), now)
+				s.state.RecordChannelSelection(ch.ID)
 				// routeKeyHash 非空时，调度优先满足“同一会话粘性”，避免把 affinity（user 级）扩散成跨会话副作用。
-				if routeKeyHash == "" {
+				if routeKeyHash == "" && !cons.channelProbe {
 					s.state.SetAffinity(userID, ch.ID, now.Add(s.affinityTTL))
 				}
 				return sel, nil
 			}
+			if err := s.traceCredentialSkips(ctx, ch, ep, now, cons); err != nil {
+				if claimedProbe {
+					s.state.ReleaseChannelProbeClaim(ch.ID)
+				}
+				return Selection{}, err
+			}
 		}
 		if claimedProbe {
 			s.state.ReleaseChannelProbeClaim(ch.ID)
@@ -519,11 +652,17 @@ func channelSupportsRequiredAPI(ch store.UpstreamChannel, requiredAPI string) bo
 	case "":
 		return true
 	case RequiredAPIResponses:
+		return responsesCapable(ch.Type, chatEnabled, responsesEnabled)
+	case RequiredAPIResponsesNative:
 		return responsesEnabled
 	case RequiredAPIChatCompletions:
-		return chatEnabled
+		return chatCompletionsCapable(ch.Type, chatEnabled)
 	case RequiredAPIMessages:
-		return ch.Type == store.UpstreamTypeAnthropic
+		return messagesCapable(ch.Type, ch.Setting.MessagesEnabled, chatEnabled, responsesEnabled)
+	case RequiredAPIEmbeddings:
+		return embeddingsCapable(ch.Type) && ch.Setting.EmbeddingsEnabled
+	case RequiredAPIGemini:
+		return geminiCapable(ch.Type)
 	default:
 		return true
 	}
@@ -535,16 +674,92 @@ func selectionSupportsRequiredAPI(sel Selection, requiredAPI string) bool {
 	case "":
 		return true
 	case RequiredAPIResponses:
+		return responsesCapable(sel.ChannelType, chatEnabled, responsesEnabled)
+	case RequiredAPIResponsesNative:
 		return responsesEnabled
 	case RequiredAPIChatCompletions:
-		return chatEnabled
+		return chatCompletionsCapable(sel.ChannelType, chatEnabled)
 	case RequiredAPIMessages:
-		return sel.ChannelType == store.UpstreamTypeAnthropic
+		return messagesCapable(sel.ChannelType, sel.MessagesEnabled, chatEnabled, responsesEnabled)
+	case RequiredAPIEmbeddings:
+		return embeddingsCapable(sel.ChannelType) && sel.EmbeddingsEnabled
+	case RequiredAPIGemini:
+		return geminiCapable(sel.ChannelType)
 	default:
 		return true
 	}
 }
 
+// responsesCapable 判断渠道能否承接 /v1/responses：原生开启 responses，
+// 或 openai_compatible 渠道仅开启 chat/completions（由网关桥接为 chat 请求）。
+func responsesCapable(channelType string, chatEnabled bool, responsesEnabled bool) bool {
+	if responsesEnabled {
+		return true
+	}
+	return channelType == store.UpstreamTypeOpenAICompatible && chatEnabled
+}
+
+// ResponsesViaChatBridge 判断选中渠道承接 /v1/responses 时是否需要桥接到 chat/completions。
+func ResponsesViaChatBridge(sel Selection) bool {
+	chatEnabled, responsesEnabled := resolvedAPICapabilities(sel.ChannelType, sel.ChatCompletionsEnabled, sel.ResponsesEnabled)
+	return !responsesEnabled && chatEnabled && sel.ChannelType == store.UpstreamTypeOpenAICompatible
+}
+
+// chatCompletionsCapable 判断渠道能否承接 /v1/chat/completions（均需开启 chat_completions）：
+// openai_compatible / azure_openai 原生支持；anthropic / bedrock / vertex 经 Messages 协议转换承接；
+// gemini / codex_oauth 等其他类型不参与，避免收到未经转换的 chat 请求体。
+func chatCompletionsCapable(channelType string, chatEnabled bool) bool {
+	if !chatEnabled {
+		return false
+	}
+	switch channelType {
+	case store.UpstreamTypeOpenAICompatible, store.UpstreamTypeAzureOpenAI,
+		store.UpstreamTypeAnthropic, store.UpstreamTypeBedrock, store.UpstreamTypeVertex:
+		return true
+	default:
+		return false
+	}
+}
+
+// messagesCapable 判断渠道能否承接 /v1/messages：anthropic / bedrock / vertex（Claude 模型）原生支持；
+// openai_compatible 需开启 messages 转换，且至少具备 chat/completions 或 responses 之一作为转换目标。
+func messagesCapable(channelType string, messagesEnabled bool, chatEnabled bool, responsesEnabled bool) bool {
+	switch channelType {
+	case store.UpstreamTypeAnthropic, store.UpstreamTypeBedrock, store.UpstreamTypeVertex:
+		return true
+	}
+	if MessagesViaOpenAITranslation(channelType) {
+		return messagesEnabled && (chatEnabled || responsesEnabled)
+	}
+	return false
+}
+
+// MessagesViaOpenAITranslation 判断渠道承接 /v1/messages 时是否需转换为 OpenAI 协议（chat/completions 或 Responses）。
+func MessagesViaOpenAITranslation(channelType string) bool {
+	return channelType == store.UpstreamTypeOpenAICompatible
+}
+
+// embeddingsCapable 判断渠道类型是否具备 embeddings 上游接口（仍需 setting 显式开启）。
+func embeddingsCapable(channelType string) bool {
+	switch channelType {
+	case store.UpstreamTypeOpenAICompatible, store.UpstreamTypeAzureOpenAI:
+		return true
+	default:
+		return false
+	}
+}
+
+// geminiCapable 判断渠道能否承接原生 Gemini（/v1beta/models/*）请求：gemini / vertex 渠道原生支持；
+// openai_compatible 渠道按聚合网关惯例透传 /v1beta 路径。
+func geminiCapable(channelType string) bool {
+	switch channelType {
+	case store.UpstreamTypeGemini, store.UpstreamTypeVertex, store.UpstreamTypeOpenAICompatible:
+		return true
+	default:
+		return false
+	}
+}
+
 func SupportsRequiredAPI(sel Selection, requiredAPI string) bool {
 	return selectionSupportsRequiredAPI(sel, requiredAPI)
 }
@@ -554,7 +769,7 @@ func resolvedAPICapabilities(channelType string, chatEnabled bool, responsesEnab
 		return chatEnabled, responsesEnabled
 	}
 	switch strings.TrimSpace(channelType) {
-	case store.UpstreamTypeOpenAICompatible:
+	case store.UpstreamTypeOpenAICompatible, store.UpstreamTypeAzureOpenAI:
 		return true, true
 	case store.UpstreamTypeCodexOAuth:
 		return false, true
@@ -586,6 +801,13 @@ func (s *Scheduler) ChannelSupportsRequiredAPI(ctx context.Context, channelID in
 	return false, false, nil
 }
 
+func endpointAPIVersion(ep store.UpstreamEndpoint) string {
+	if ep.APIVersion == nil {
+		return ""
+	}
+	return strings.TrimSpace(*ep.APIVersion)
+}
+
 func parseCredentialKey(key string) (CredentialType, int64, bool) {
 	key = strings.TrimSpace(key)
 	if key == "" {
@@ -606,6 +828,16 @@ func parseCredentialKey(key string) (CredentialType, int64, bool) {
 	return typ, id, true
 }
 
+// credentialCandidate 为各类型 credential/账号在调度时共用的视图。
+type credentialCandidate struct {
+	ID     int64
+	Status int
+	Limits store.CredentialLimits
+	// CooldownUntil/LastUsedAt 仅 codex_oauth 账号使用：持久化冷却与“最久未使用优先”。
+	CooldownUntil *time.Time
+	LastUsedAt    *time.Time
+}
+
 func (s *Scheduler) selectCredential(ctx context.Context, ch store.UpstreamChannel, ep store.UpstreamEndpoint, now time.Time, routeKeyHash string, cons Constraints) (Selection, bool, error) {
 	requireCredKey := strings.TrimSpace(cons.RequireCredentialKey)
 	requireCredType, requireCredID, requireCredOK := parseCredentialKey(requireCredKey)
@@ -613,250 +845,203 @@ func (s *Scheduler) selectCredential(ctx context.Context, ch store.UpstreamChann
 		// Invalid/stale bindings should not hard-fail routing; treat as "no match".
 		return Selection{}, false, nil
 	}
+	var (
+		credType CredentialType
+		cands    []credentialCandidate
+	)
 	switch ch.Type {
 	case store.UpstreamTypeOpenAICompatible:
-		if requireCredKey != "" && requireCredType != CredentialTypeOpenAI {
+		credType = CredentialTypeOpenAI
+		if requireCredKey != "" && requireCredType != credType {
 			return Selection{}, false, nil
 		}
 		creds, err := s.st.ListOpenAICompatibleCredentialsByEndpoint(ctx, ep.ID)
 		if err != nil {
 			return Selection{}, false, err
 		}
-		var ids []int64
 		for _, c := range creds {
-			if c.Status != 1 {
-				continue
-			}
-			if requireCredKey != "" && c.ID != requireCredID {
-				continue
-			}
-			key := fmt.Sprintf("%s:%d", CredentialTypeOpenAI, c.ID)
-			if s.state.IsCredentialCooling(key, now) {
-				continue
-			}
-			ids = append(ids, c.ID)
+			cands = append(cands, credentialCandidate{ID: c.ID, Status: c.Status, Limits: c.Limits})
 		}
-		if len(ids) == 0 {
-			return Selection{}, false, nil
-		}
-		if routeKeyHash != "" && len(ids) > 1 {
-			sort.SliceStable(ids, func(i, j int) bool {
-				si := rendezvousScore64(routeKeyHash, string(CredentialTypeOpenAI), ids[i])
-				sj := rendezvousScore64(routeKeyHash, string(CredentialTypeOpenAI), ids[j])
-				if si != sj {
-					return si > sj
-				}
-				return ids[i] > ids[j]
-			})
-		} else {
-			sort.SliceStable(ids, func(i, j int) bool {
-				ki := fmt.Sprintf("%s:%d", CredentialTypeOpenAI, ids[i])
-				kj := fmt.Sprintf("%s:%d", CredentialTypeOpenAI, ids[j])
-				ri := s.state.RPM(ki, now, s.rpmWindow)
-				rj := s.state.RPM(kj, now, s.rpmWindow)
-				if ri != rj {
-					return ri < rj
-				}
-				return ids[i] > ids[j]
-			})
-		}
-		return Selection{
-			ChannelID:              ch.ID,
-			ChannelType:            ch.Type,
-			ChannelGroups:          ch.Groups,
-			AllowServiceTier:       ch.AllowServiceTier,
-			FastMode:               ch.FastMode,
-			DisableStore:           ch.DisableStore,
-			AllowSafetyIdentifier:  ch.AllowSafetyIdentifier,
-			OpenAIOrganization:     ch.OpenAIOrganization,
-			AutoBan:                ch.AutoBan,
-			ForceFormat:            ch.Setting.ForceFormat,
-			ThinkingToContent:      ch.Setting.ThinkingToContent,
-			ChatCompletionsEnabled: ch.Setting.ChatCompletionsEnabled,
-			ResponsesEnabled:       ch.Setting.ResponsesEnabled,
-			PassThroughBodyEnabled: ch.Setting.PassThroughBodyEnabled,
-			Proxy:                  ch.Setting.Proxy,
-			SystemPrompt:           ch.Setting.SystemPrompt,
-			SystemPromptOverride:   ch.Setting.SystemPromptOverride,
-			CacheTTLPreference:     ch.Setting.CacheTTLPreference,
-			ParamOverride:          ch.ParamOverride,
-			HeaderOverride:         ch.HeaderOverride,
-			StatusCodeMapping:      ch.StatusCodeMapping,
-			ModelSuffixPreserve:    ch.ModelSuffixPreserve,
-			RequestBodyBlacklist:   ch.RequestBodyBlacklist,
-			RequestBodyWhitelist:   ch.RequestBodyWhitelist,
-			EndpointID:             ep.ID,
-			BaseURL:                ep.BaseURL,
-			CredentialType:         CredentialTypeOpenAI,
-			CredentialID:           ids[0],
-		}, true, nil
 	case store.UpstreamTypeAnthropic:
-		if requireCredKey != "" && requireCredType != CredentialTypeAnthropic {
+		credType = CredentialTypeAnthropic
+		if requireCredKey != "" && requireCredType != credType {
 			return Selection{}, false, nil
 		}
 		creds, err := s.st.ListAnthropicCredentialsByEndpoint(ctx, ep.ID)
 		if err != nil {
 			return Selection{}, false, err
 		}
-		var ids []int64
 		for _, c := range creds {
-			if c.Status != 1 {
-				continue
-			}
-			if requireCredKey != "" && c.ID != requireCredID {
-				continue
-			}
-			key := fmt.Sprintf("%s:%d", CredentialTypeAnthropic, c.ID)
-			if s.state.IsCredentialCooling(key, now) {
-				continue
-			}
-			ids = append(ids, c.ID)
+			cands = append(cands, credentialCandidate{ID: c.ID, Status: c.Status, Limits: c.Limits})
 		}
-		if len(ids) == 0 {
+	case store.UpstreamTypeGemini:
+		credType = CredentialTypeGemini
+		if requireCredKey != "" && requireCredType != credType {
 			return Selection{}, false, nil
 		}
-		if routeKeyHash != "" && len(ids) > 1 {
-			sort.SliceStable(ids, func(i, j int) bool {
-				si := rendezvousScore64(routeKeyHash, string(CredentialTypeAnthropic), ids[i])
-				sj := rendezvousScore64(routeKeyHash, string(CredentialTypeAnthropic), ids[j])
-				if si != sj {
-					return si > sj
-				}
-				return ids[i] > ids[j]
-			})
-		} else {
-			sort.SliceStable(ids, func(i, j int) bool {
-				ki := fmt.Sprintf("%s:%d", CredentialTypeAnthropic, ids[i])
-				kj := fmt.Sprintf("%s:%d", CredentialTypeAnthropic, ids[j])
-				ri := s.state.RPM(ki, now, s.rpmWindow)
-				rj := s.state.RPM(kj, now, s.rpmWindow)
-				if ri != rj {
-					return ri < rj
-				}
-				return ids[i] > ids[j]
-			})
-		}
-		return Selection{
-			ChannelID:              ch.ID,
-			ChannelType:            ch.Type,
-			ChannelGroups:          ch.Groups,
-			AllowServiceTier:       ch.AllowServiceTier,
-			FastMode:               ch.FastMode,
-			DisableStore:           ch.DisableStore,
-			AllowSafetyIdentifier:  ch.AllowSafetyIdentifier,
-			OpenAIOrganization:     ch.OpenAIOrganization,
-			AutoBan:                ch.AutoBan,
-			ForceFormat:            ch.Setting.ForceFormat,
-			ThinkingToContent:      ch.Setting.ThinkingToContent,
-			ChatCompletionsEnabled: ch.Setting.ChatCompletionsEnabled,
-			ResponsesEnabled:       ch.Setting.ResponsesEnabled,
-			PassThroughBodyEnabled: ch.Setting.PassThroughBodyEnabled,
-			Proxy:                  ch.Setting.Proxy,
-			SystemPrompt:           ch.Setting.SystemPrompt,
-			SystemPromptOverride:   ch.Setting.SystemPromptOverride,
-			CacheTTLPreference:     ch.Setting.CacheTTLPreference,
-			ParamOverride:          ch.ParamOverride,
-			HeaderOverride:         ch.HeaderOverride,
-			StatusCodeMapping:      ch.StatusCodeMapping,
-			ModelSuffixPreserve:    ch.ModelSuffixPreserve,
-			RequestBodyBlacklist:   ch.RequestBodyBlacklist,
-			RequestBodyWhitelist:   ch.RequestBodyWhitelist,
-			EndpointID:             ep.ID,
-			BaseURL:                ep.BaseURL,
-			CredentialType:         CredentialTypeAnthropic,
-			CredentialID:           ids[0],
-		}, true, nil
+		creds, err := s.st.ListGeminiCredentialsByEndpoint(ctx, ep.ID)
+		if err != nil {
+			return Selection{}, false, err
+		}
+		for _, c := range creds {
+			cands = append(cands, credentialCandidate{ID: c.ID, Status: c.Status, Limits: c.Limits})
+		}
+	case store.UpstreamTypeAzureOpenAI:
+		credType = CredentialTypeAzure
+		if requireCredKey != "" && requireCredType != credType {
+			return Selection{}, false, nil
+		}
+		creds, err := s.st.ListAzureOpenAICredentialsByEndpoint(ctx, ep.ID)
+		if err != nil {
+			return Selection{}, false, err
+		}
+		for _, c := range creds {
+			cands = append(cands, credentialCandidate{ID: c.ID, Status: c.Status, Limits: c.Limits})
+		}
+	case store.UpstreamTypeBedrock:
+		credType = CredentialTypeBedrock
+		if requireCredKey != "" && requireCredType != credType {
+			return Selection{}, false, nil
+		}
+		creds, err := s.st.ListBedrockCredentialsByEndpoint(ctx, ep.ID)
+		if err != nil {
+			return Selection{}, false, err
+		}
+		for _, c := range creds {
+			cands = append(cands, credentialCandidate{ID: c.ID, Status: c.Status, Limits: c.Limits})
+		}
+	case store.UpstreamTypeVertex:
+		credType = CredentialTypeVertex
+		if requireCredKey != "" && requireCredType != credType {
+			return Selection{}, false, nil
+		}
+		creds, err := s.st.ListVertexCredentialsByEndpoint(ctx, ep.ID)
+		if err != nil {
+			return Selection{}, false, err
+		}
+		for _, c := range creds {
+			cands = append(cands, credentialCandidate{ID: c.ID, Status: c.Status, Limits: c.Limits})
+		}
 	case store.UpstreamTypeCodexOAuth:
-		if requireCredKey != "" && requireCredType != CredentialTypeCodex {
+		credType = CredentialTypeCodex
+		if requireCredKey != "" && requireCredType != credType {
 			return Selection{}, false, nil
 		}
 		accs, err := s.st.ListCodexOAuthAccountsByEndpoint(ctx, ep.ID)
 		if err != nil {
 			return Selection{}, false, err
 		}
-		var eligible []store.CodexOAuthAccount
 		for _, a := range accs {
-			if a.Status != 1 {
-				continue
-			}
-			if requireCredKey != "" && a.ID != requireCredID {
-				continue
-			}
-			if a.CooldownUntil != nil && now.Before(*a.CooldownUntil) {
-				continue
-			}
-			key := fmt.Sprintf("%s:%d", CredentialTypeCodex, a.ID)
-			if s.state.IsCredentialCooling(key, now) {
-				continue
-			}
-			eligible = append(eligible, a)
+			cands = append(cands, credentialCandidate{ID: a.ID, Status: a.Status, Limits: a.Limits, CooldownUntil: a.CooldownUntil, LastUsedAt: a.LastUsedAt})
 		}
-		if len(eligible) == 0 {
-			return Selection{}, false, nil
+	default:
+		return Selection{}, false, nil
+	}
+	c, lim, ok := s.pickCredential(credType, cands, ep, now, routeKeyHash, requireCredID)
+	if !ok {
+		return Selection{}, false, nil
+	}
+	sel := channelSelection(ch, ep)
+	if ch.Type == store.UpstreamTypeAzureOpenAI {
+		sel.APIVersion = endpointAPIVersion(ep)
+	}
+	sel.CredentialType = credType
+	sel.CredentialID = c.ID
+	sel.Limits = lim
+	return sel, true, nil
+}
+
+// pickCredential 过滤停用/冷却/饱和的 credential（requireID 非 0 时仅保留该 credential），
+// 有会话键时按 rendezvous 稳定选择，否则优先最近 RPM 最低者（codex 账号先比较最久未使用）。
+func (s *Scheduler) pickCredential(credType CredentialType, cands []credentialCandidate, ep store.UpstreamEndpoint, now time.Time, routeKeyHash string, requireID int64) (credentialCandidate, CredentialLimits, bool) {
+	var eligible []credentialCandidate
+	limits := make(map[int64]CredentialLimits)
+	for _, c := range cands {
+		if c.Status != 1 {
+			continue
 		}
-		if routeKeyHash != "" && len(eligible) > 1 {
-			sort.SliceStable(eligible, func(i, j int) bool {
-				ai := eligible[i]
-				aj := eligible[j]
-				si := rendezvousScore64(routeKeyHash, string(CredentialTypeCodex), ai.ID)
-				sj := rendezvousScore64(routeKeyHash, string(CredentialTypeCodex), aj.ID)
-				if si != sj {
-					return si > sj
-				}
-				return ai.ID > aj.ID
-			})
-		} else {
-			sort.SliceStable(eligible, func(i, j int) bool {
-				ai := eligible[i]
-				aj := eligible[j]
+		if requireID != 0 && c.ID != requireID {
+			continue
+		}
+		if c.CooldownUntil != nil && now.Before(*c.CooldownUntil) {
+			continue
+		}
+		key := fmt.Sprintf("%s:%d", credType, c.ID)
+		if s.state.IsCredentialCooling(key, now) {
+			continue
+		}
+		lim := resolveCredentialLimits(c.Limits, ep.Limits)
+		if s.credentialSaturated(key, lim, now) {
+			continue
+		}
+		eligible = append(eligible, c)
+		limits[c.ID] = lim
+	}
+	if len(eligible) == 0 {
+		return credentialCandidate{}, CredentialLimits{}, false
+	}
+	if routeKeyHash != "" && len(eligible) > 1 {
+		sort.SliceStable(eligible, func(i, j int) bool {
+			si := rendezvousScore64(routeKeyHash, string(credType), eligible[i].ID)
+			sj := rendezvousScore64(routeKeyHash, string(credType), eligible[j].ID)
+			if si != sj {
+				return si > sj
+			}
+			return eligible[i].ID > eligible[j].ID
+		})
+	} else {
+		sort.SliceStable(eligible, func(i, j int) bool {
+			ai, aj := eligible[i], eligible[j]
+			if credType == CredentialTypeCodex {
 				if codexLastUsedBefore(ai.LastUsedAt, aj.LastUsedAt) {
 					return true
 				}
 				if codexLastUsedBefore(aj.LastUsedAt, ai.LastUsedAt) {
 					return false
 				}
-				ki := fmt.Sprintf("%s:%d", CredentialTypeCodex, ai.ID)
-				kj := fmt.Sprintf("%s:%d", CredentialTypeCodex, aj.ID)
-				ri := s.state.RPM(ki, now, s.rpmWindow)
-				rj := s.state.RPM(kj, now, s.rpmWindow)
-				if ri != rj {
-					return ri < rj
-				}
-				return ai.ID > aj.ID
-			})
-		}
-		return Selection{
-			ChannelID:              ch.ID,
-			ChannelType:            ch.Type,
-			ChannelGroups:          ch.Groups,
-			AllowServiceTier:       ch.AllowServiceTier,
-			FastMode:               ch.FastMode,
-			DisableStore:           ch.DisableStore,
-			AllowSafetyIdentifier:  ch.AllowSafetyIdentifier,
-			OpenAIOrganization:     ch.OpenAIOrganization,
-			AutoBan:                ch.AutoBan,
-			ForceFormat:            ch.Setting.ForceFormat,
-			ThinkingToContent:      ch.Setting.ThinkingToContent,
-			ChatCompletionsEnabled: ch.Setting.ChatCompletionsEnabled,
-			ResponsesEnabled:       ch.Setting.ResponsesEnabled,
-			PassThroughBodyEnabled: ch.Setting.PassThroughBodyEnabled,
-			Proxy:                  ch.Setting.Proxy,
-			SystemPrompt:           ch.Setting.SystemPrompt,
-			SystemPromptOverride:   ch.Setting.SystemPromptOverride,
-			CacheTTLPreference:     ch.Setting.CacheTTLPreference,
-			ParamOverride:          ch.ParamOverride,
-			HeaderOverride:         ch.HeaderOverride,
-			StatusCodeMapping:      ch.StatusCodeMapping,
-			ModelSuffixPreserve:    ch.ModelSuffixPreserve,
-			RequestBodyBlacklist:   ch.RequestBodyBlacklist,
-			RequestBodyWhitelist:   ch.RequestBodyWhitelist,
-			EndpointID:             ep.ID,
-			BaseURL:                ep.BaseURL,
-			CredentialType:         CredentialTypeCodex,
-			CredentialID:           eligible[0].ID,
-		}, true, nil
-	default:
-		return Selection{}, false, nil
+			}
+			ri := s.state.RPM(fmt.Sprintf("%s:%d", credType, ai.ID), now, s.rpmWindow)
+			rj := s.state.RPM(fmt.Sprintf("%s:%d", credType, aj.ID), now, s.rpmWindow)
+			if ri != rj {
+				return ri < rj
+			}
+			return ai.ID > aj.ID
+		})
+	}
+	return eligible[0], limits[eligible[0].ID], true
+}
+
+// channelSelection 返回由渠道与 endpoint 决定的 Selection 字段（不含 credential）。
+func channelSelection(ch store.UpstreamChannel, ep store.UpstreamEndpoint) Selection {
+	return Selection{
+		ChannelID:              ch.ID,
+		ChannelType:            ch.Type,
+		ChannelGroups:          ch.Groups,
+		AllowServiceTier:       ch.AllowServiceTier,
+		FastMode:               ch.FastMode,
+		DisableStore:           ch.DisableStore,
+		AllowSafetyIdentifier:  ch.AllowSafetyIdentifier,
+		OpenAIOrganization:     ch.OpenAIOrganization,
+		AutoBan:                ch.AutoBan,
+		ForceFormat:            ch.Setting.ForceFormat,
+		ThinkingToContent:      ch.Setting.ThinkingToContent,
+		ChatCompletionsEnabled: ch.Setting.ChatCompletionsEnabled,
+		ResponsesEnabled:       ch.Setting.ResponsesEnabled,
+		EmbeddingsEnabled:      ch.Setting.EmbeddingsEnabled,
+		MessagesEnabled:        ch.Setting.MessagesEnabled,
+		PassThroughBodyEnabled: ch.Setting.PassThroughBodyEnabled,
+		Proxy:                  ch.Setting.Proxy,
+		SystemPrompt:           ch.Setting.SystemPrompt,
+		SystemPromptOverride:   ch.Setting.SystemPromptOverride,
+		CacheTTLPreference:     ch.Setting.CacheTTLPreference,
+		ParamOverride:          ch.ParamOverride,
+		HeaderOverride:         ch.HeaderOverride,
+		StatusCodeMapping:      ch.StatusCodeMapping,
+		ModelSuffixPreserve:    ch.ModelSuffixPreserve,
+		RequestBodyBlacklist:   ch.RequestBodyBlacklist,
+		RequestBodyWhitelist:   ch.RequestBodyWhitelist,
+		EndpointID:             ep.ID,
+		BaseURL:                ep.BaseURL,
 	}
 }
 
@@ -887,6 +1072,7 @@ func (s *Scheduler) Report(sel Selection, res Result) {
 		scope = FailureScopeChannel
 	}
 	s.state.ClearChannelProbe(sel.ChannelID)
+	s.recordLatencyResult(sel, res, scope)
 	if res.Success {
 		s.state.ClearEndpointCooldown(sel.EndpointID)
 		s.state.RecordChannelResult(sel.ChannelID, true)
@@ -895,6 +1081,8 @@ func (s *Scheduler) Report(sel Selection, res Result) {
 		s.state.ResetChannelFailScore(sel.ChannelID)
 		s.state.ClearChannelModelBan(res.ChannelModelBindingID)
 		s.state.ResetChannelModelFailScore(res.ChannelModelBindingID)
+		s.forgetChannelBan(sel.ChannelID)
+		s.forgetChannelModelBan(res.ChannelModelBindingID)
 		s.touchCredentialLastUsed(sel)
 		return
 	}
@@ -919,23 +1107,29 @@ func (s *Scheduler) Report(sel Selection, res Result) {
 		switch scope {
 		case FailureScopeCredential:
 			s.state.SetCredentialCooling(sel.CredentialKey(), cooldownUntil)
+			s.persistCredentialCooldown(sel.CredentialKey(), cooldownUntil)
 		case FailureScopeEndpoint:
 			s.state.SetEndpointCooling(sel.EndpointID, cooldownUntil)
 		case FailureScopeChannel:
 			s.state.SetEndpointCooling(sel.EndpointID, cooldownUntil)
 			s.state.SetCredentialCooling(sel.CredentialKey(), cooldownUntil)
+			s.persistCredentialCooldown(sel.CredentialKey(), cooldownUntil)
 		case FailureScopeChannelModel:
-			s.state.BanChannelModel(res.ChannelModelBindingID, now, cooldown, cooldownUntil)
+			until := s.state.BanChannelModel(res.ChannelModelBindingID, now, cooldown, cooldownUntil)
+			s.persistChannelModelBan(res.ChannelModelBindingID, now, until)
 		default:
 			s.state.SetCredentialCooling(sel.CredentialKey(), cooldownUntil)
+			s.persistCredentialCooldown(sel.CredentialKey(), cooldownUntil)
 		}
 		// usage_limit_reached / rate_limit_exceeded / credential invalid 属于账号级耗尽/限流/不可用，不应牵连整个 channel。
 		if scope == FailureScopeChannel && sel.AutoBan && res.ErrorClass != "upstream_exhausted" && res.ErrorClass != "upstream_throttled" && res.ErrorClass != "upstream_credential_invalid" {
+			var until time.Time
 			if shouldBanChannelImmediately(res) {
-				s.state.BanChannelImmediate(sel.ChannelID, now, cooldown)
+				until = s.state.BanChannelImmediate(sel.ChannelID, now, cooldown)
 			} else {
-				s.state.BanChannel(sel.ChannelID, now, cooldown)
+				until = s.state.BanChannel(sel.ChannelID, now, cooldown)
 			}
+			s.persistChannelBan(sel.ChannelID, now, until)
 		}
 	}
 }
@@ -1010,7 +1204,7 @@ func (s *Scheduler) RecordTokens(credentialKey string, tokens int) {
 	s.state.RecordTokens(credentialKey, time.Now(), tokens)
 }
 
-func orderChannels(chs []store.UpstreamChannel, affinityChannelID int64, affinityOK bool, isProbePending func(channelID int64) bool, failScore func(channelID int64) int) []store.UpstreamChannel {
+func orderChannels(chs []store.UpstreamChannel, affinityChannelID int64, affinityOK bool, isProbePending func(channelID int64) bool, failScore func(channelID int64) int, weightSeed string, latencyScore func(channelID int64) (float64, bool), latencyExplore bool) []store.UpstreamChannel {
 	seen := make(map[int64]struct{}, len(chs))
 	var probed []store.UpstreamChannel
 	var promoted []store.UpstreamChannel
@@ -1044,6 +1238,28 @@ func orderChannels(chs []store.UpstreamChannel, affinityChannelID int64, affinit
 		}
 		return failScore(normal[i].ID) < failScore(normal[j].ID)
 	})
+	// 同优先级、同失败分的渠道按 weight 加权随机分流。
+	weightedTierOrder(len(normal), func(i, j int) bool {
+		return normal[i].Priority == normal[j].Priority && failScore(normal[i].ID) == failScore(normal[j].ID)
+	}, func(i int) int {
+		return normal[i].Weight
+	}, func(i int) int64 {
+		return normal[i].ID
+	}, weightSeed, func(i, j int) {
+		normal[i], normal[j] = normal[j], normal[i]
+	})
+	// 未配置权重的同层渠道按观测延迟从快到慢排序（latencyScore 为空表示未启用）。
+	if latencyScore != nil {
+		latencyTierOrder(len(normal), func(i, j int) bool {
+			return normal[i].Priority == normal[j].Priority && failScore(normal[i].ID) == failScore(normal[j].ID)
+		}, func(i int) int {
+			return normal[i].Weight
+		}, func(i int) (float64, bool) {
+			return latencyScore(normal[i].ID)
+		}, latencyExplore, func(i, j int) {
+			normal[i], normal[j] = normal[j], normal[i]
+		})
+	}
 
 	var out []store.UpstreamChannel
 	for _, c := range probed {
@@ -1054,6 +1270,10 @@ func orderChannels(chs []store.UpstreamChannel, affinityChannelID int64, affinit
 		out = append(out, c)
 		seen[c.ID] = struct{}{}
 	}
+	// 亲和渠道所在层配置了权重时不做 user 粘性，否则单个用户的流量会绕过加权分流。
+	if affinityOK && inWeightedTier(normal, affinityChannelID, failScore) {
+		affinityOK = false
+	}
 	if affinityOK {
 		for _, c := range chs {
 			if c.ID == affinityChannelID {
@@ -1073,3 +1293,32 @@ func orderChannels(chs []store.UpstreamChannel, affinityChannelID int64, affinit
 	}
 	return out
 }
+
+// inWeightedTier 判断 channelID 在 chs 中所处的同优先级、同失败分层是否参与加权分流
+// （层内至少两个渠道且存在显式权重，与 weightedTierOrder 的判定一致）。
+func inWeightedTier(chs []store.UpstreamChannel, channelID int64, failScore func(channelID int64) int) bool {
+	idx := -1
+	for i := range chs {
+		if chs[i].ID == channelID {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return false
+	}
+	target := chs[idx]
+	targetFail := failScore(target.ID)
+	peers := 0
+	configured := target.Weight > 0
+	for i, c := range chs {
+		if i == idx || c.Priority != target.Priority || failScore(c.ID) != targetFail {
+			continue
+		}
+		peers++
+		if c.Weight > 0 {
+			configured = true
+		}
+	}
+	return peers > 0 && configured
+}
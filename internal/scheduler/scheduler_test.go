@@ -16,6 +16,10 @@ type fakeStore struct {
 	endpoints      map[int64][]store.UpstreamEndpoint
 	creds          map[int64][]store.OpenAICompatibleCredential
 	anthropicCreds map[int64][]store.AnthropicCredential
+	geminiCreds    map[int64][]store.GeminiCredential
+	azureCreds     map[int64][]store.AzureOpenAICredential
+	bedrockCreds   map[int64][]store.BedrockCredential
+	vertexCreds    map[int64][]store.VertexCredential
 	accounts       map[int64][]store.CodexOAuthAccount
 	touchedCodex   []int64
 }
@@ -39,6 +43,34 @@ func (f *fakeStore) ListAnthropicCredentialsByEndpoint(_ context.Context, endpoi
 	return f.anthropicCreds[endpointID], nil
 }
 
+func (f *fakeStore) ListGeminiCredentialsByEndpoint(_ context.Context, endpointID int64) ([]store.GeminiCredential, error) {
+	if f.geminiCreds == nil {
+		return nil, nil
+	}
+	return f.geminiCreds[endpointID], nil
+}
+
+func (f *fakeStore) ListAzureOpenAICredentialsByEndpoint(_ context.Context, endpointID int64) ([]store.AzureOpenAICredential, error) {
+	if f.azureCreds == nil {
+		return nil, nil
+	}
+	return f.azureCreds[endpointID], nil
+}
+
+func (f *fakeStore) ListBedrockCredentialsByEndpoint(_ context.Context, endpointID int64) ([]store.BedrockCredential, error) {
+	if f.bedrockCreds == nil {
+		return nil, nil
+	}
+	return f.bedrockCreds[endpointID], nil
+}
+
+func (f *fakeStore) ListVertexCredentialsByEndpoint(_ context.Context, endpointID int64) ([]store.VertexCredential, error) {
+	if f.vertexCreds == nil {
+		return nil, nil
+	}
+	return f.vertexCreds[endpointID], nil
+}
+
 func (f *fakeStore) ListCodexOAuthAccountsByEndpoint(_ context.Context, endpointID int64) ([]store.CodexOAuthAccount, error) {
 	return f.accounts[endpointID], nil
 }
@@ -276,6 +308,7 @@ func TestSelectWithConstraints_RequireAPI_FiltersChannels(t *testing.T) {
 				Setting: store.UpstreamChannelSetting{
 					ChatCompletionsEnabled: false,
 					ResponsesEnabled:       true,
+					MessagesEnabled:        true,
 				},
 			},
 			{
@@ -285,6 +318,7 @@ func TestSelectWithConstraints_RequireAPI_FiltersChannels(t *testing.T) {
 				Setting: store.UpstreamChannelSetting{
 					ChatCompletionsEnabled: true,
 					ResponsesEnabled:       false,
+					EmbeddingsEnabled:      true,
 				},
 			},
 		},
@@ -314,6 +348,173 @@ func TestSelectWithConstraints_RequireAPI_FiltersChannels(t *testing.T) {
 	if chatSel.ChannelID != 2 {
 		t.Fatalf("expected chat channel=2, got=%d", chatSel.ChannelID)
 	}
+
+	embSel, err := s.SelectWithConstraints(context.Background(), 10, "", Constraints{RequireAPI: RequiredAPIEmbeddings})
+	if err != nil {
+		t.Fatalf("Select embeddings err: %v", err)
+	}
+	if embSel.ChannelID != 2 || !embSel.EmbeddingsEnabled {
+		t.Fatalf("expected embeddings channel=2, got=%d enabled=%v", embSel.ChannelID, embSel.EmbeddingsEnabled)
+	}
+
+	msgSel, err := s.SelectWithConstraints(context.Background(), 10, "", Constraints{RequireAPI: RequiredAPIMessages})
+	if err != nil {
+		t.Fatalf("Select messages err: %v", err)
+	}
+	if msgSel.ChannelID != 1 || !msgSel.MessagesEnabled {
+		t.Fatalf("expected messages channel=1, got=%d enabled=%v", msgSel.ChannelID, msgSel.MessagesEnabled)
+	}
+}
+
+func TestSelectWithConstraints_RequireAPIResponses_BridgesChatOnlyOpenAICompatible(t *testing.T) {
+	fs := &fakeStore{
+		channels: []store.UpstreamChannel{
+			{ID: 1, Type: store.UpstreamTypeOpenAICompatible, Status: 1, Setting: store.UpstreamChannelSetting{ChatCompletionsEnabled: true}},
+			{ID: 2, Type: store.UpstreamTypeAnthropic, Status: 1},
+		},
+		endpoints: map[int64][]store.UpstreamEndpoint{
+			1: {{ID: 11, ChannelID: 1, BaseURL: "https://a.example", Status: 1}},
+			2: {{ID: 21, ChannelID: 2, BaseURL: "https://api.anthropic.com", Status: 1}},
+		},
+		creds: map[int64][]store.OpenAICompatibleCredential{
+			11: {{ID: 111, EndpointID: 11, Status: 1}},
+		},
+		anthropicCreds: map[int64][]store.AnthropicCredential{
+			21: {{ID: 211, EndpointID: 21, Status: 1}},
+		},
+	}
+	s := New(fs)
+
+	sel, err := s.SelectWithConstraints(context.Background(), 10, "", Constraints{RequireAPI: RequiredAPIResponses})
+	if err != nil {
+		t.Fatalf("Select responses err: %v", err)
+	}
+	if sel.ChannelID != 1 || !ResponsesViaChatBridge(sel) {
+		t.Fatalf("expected chat-only channel to serve responses via bridge, got %+v", sel)
+	}
+	if _, err := s.SelectWithConstraints(context.Background(), 10, "", Constraints{RequireAPI: RequiredAPIResponsesNative}); err == nil {
+		t.Fatalf("expected chat-only channel to be excluded from native responses")
+	}
+}
+
+func TestSelectWithConstraints_RequireAPIGemini_SelectsGeminiCredential(t *testing.T) {
+	fs := &fakeStore{
+		channels: []store.UpstreamChannel{
+			{ID: 1, Type: store.UpstreamTypeGemini, Status: 1, Priority: 10},
+			{ID: 2, Type: store.UpstreamTypeAnthropic, Status: 1, Priority: 20},
+		},
+		endpoints: map[int64][]store.UpstreamEndpoint{
+			1: {{ID: 11, ChannelID: 1, BaseURL: "https://generativelanguage.googleapis.com", Status: 1}},
+			2: {{ID: 21, ChannelID: 2, BaseURL: "https://api.anthropic.com", Status: 1}},
+		},
+		geminiCreds: map[int64][]store.GeminiCredential{
+			11: {{ID: 111, EndpointID: 11, Status: 0}, {ID: 112, EndpointID: 11, Status: 1}},
+		},
+		anthropicCreds: map[int64][]store.AnthropicCredential{
+			21: {{ID: 211, EndpointID: 21, Status: 1}},
+		},
+	}
+	s := New(fs)
+
+	sel, err := s.SelectWithConstraints(context.Background(), 10, "", Constraints{RequireAPI: RequiredAPIGemini})
+	if err != nil {
+		t.Fatalf("Select gemini err: %v", err)
+	}
+	if sel.ChannelID != 1 || sel.CredentialType != CredentialTypeGemini || sel.CredentialID != 112 {
+		t.Fatalf("unexpected gemini selection: %+v", sel)
+	}
+
+	if _, err := s.SelectWithConstraints(context.Background(), 10, "", Constraints{RequireAPI: RequiredAPIChatCompletions, RequireChannelID: 1}); err == nil {
+		t.Fatalf("expected gemini channel to be excluded from chat_completions")
+	}
+}
+
+func TestSelectWithConstraints_AzureOpenAIEmbeddingsCarriesAPIVersion(t *testing.T) {
+	apiVersion := "2024-10-21"
+	fs := &fakeStore{
+		channels: []store.UpstreamChannel{
+			{ID: 1, Type: store.UpstreamTypeAzureOpenAI, Status: 1, Priority: 10, Setting: store.UpstreamChannelSetting{EmbeddingsEnabled: true}},
+			{ID: 2, Type: store.UpstreamTypeAnthropic, Status: 1, Priority: 20},
+		},
+		endpoints: map[int64][]store.UpstreamEndpoint{
+			1: {{ID: 11, ChannelID: 1, BaseURL: "https://res.openai.azure.com", APIVersion: &apiVersion, Status: 1}},
+			2: {{ID: 21, ChannelID: 2, BaseURL: "https://api.anthropic.com", Status: 1}},
+		},
+		azureCreds: map[int64][]store.AzureOpenAICredential{
+			11: {{ID: 111, EndpointID: 11, Status: 1}},
+		},
+		anthropicCreds: map[int64][]store.AnthropicCredential{
+			21: {{ID: 211, EndpointID: 21, Status: 1}},
+		},
+	}
+	s := New(fs)
+
+	sel, err := s.SelectWithConstraints(context.Background(), 10, "", Constraints{RequireAPI: RequiredAPIEmbeddings})
+	if err != nil {
+		t.Fatalf("Select embeddings err: %v", err)
+	}
+	if sel.ChannelID != 1 || sel.CredentialType != CredentialTypeAzure || sel.CredentialID != 111 || sel.APIVersion != apiVersion {
+		t.Fatalf("unexpected azure selection: %+v", sel)
+	}
+}
+
+func TestSelectWithConstraints_RequireAPIMessages_SelectsBedrockCredential(t *testing.T) {
+	fs := &fakeStore{
+		channels: []store.UpstreamChannel{
+			{ID: 1, Type: store.UpstreamTypeBedrock, Status: 1, Priority: 10},
+			{ID: 2, Type: store.UpstreamTypeGemini, Status: 1, Priority: 20},
+		},
+		endpoints: map[int64][]store.UpstreamEndpoint{
+			1: {{ID: 11, ChannelID: 1, BaseURL: "https://bedrock-runtime.us-east-1.amazonaws.com", Status: 1}},
+			2: {{ID: 21, ChannelID: 2, BaseURL: "https://generativelanguage.googleapis.com", Status: 1}},
+		},
+		bedrockCreds: map[int64][]store.BedrockCredential{
+			11: {{ID: 111, EndpointID: 11, Region: "us-east-1", Status: 1}},
+		},
+		geminiCreds: map[int64][]store.GeminiCredential{
+			21: {{ID: 211, EndpointID: 21, Status: 1}},
+		},
+	}
+	s := New(fs)
+
+	sel, err := s.SelectWithConstraints(context.Background(), 10, "", Constraints{RequireAPI: RequiredAPIMessages})
+	if err != nil {
+		t.Fatalf("Select messages err: %v", err)
+	}
+	if sel.ChannelID != 1 || sel.CredentialType != CredentialTypeBedrock || sel.CredentialID != 111 {
+		t.Fatalf("unexpected bedrock selection: %+v", sel)
+	}
+	if _, err := s.SelectWithConstraints(context.Background(), 10, "", Constraints{RequireAPI: RequiredAPIResponses, RequireChannelID: 1}); err == nil {
+		t.Fatalf("expected bedrock channel to be excluded from responses")
+	}
+}
+
+func TestSelectWithConstraints_VertexServesMessagesAndGemini(t *testing.T) {
+	fs := &fakeStore{
+		channels: []store.UpstreamChannel{
+			{ID: 1, Type: store.UpstreamTypeVertex, Status: 1, Priority: 10},
+		},
+		endpoints: map[int64][]store.UpstreamEndpoint{
+			1: {{ID: 11, ChannelID: 1, BaseURL: "https://us-east5-aiplatform.googleapis.com", Status: 1}},
+		},
+		vertexCreds: map[int64][]store.VertexCredential{
+			11: {{ID: 111, EndpointID: 11, ProjectID: "demo-project", Status: 1}},
+		},
+	}
+	s := New(fs)
+
+	for _, api := range []string{RequiredAPIMessages, RequiredAPIGemini} {
+		sel, err := s.SelectWithConstraints(context.Background(), 10, "", Constraints{RequireAPI: api})
+		if err != nil {
+			t.Fatalf("Select %s err: %v", api, err)
+		}
+		if sel.CredentialType != CredentialTypeVertex || sel.CredentialID != 111 {
+			t.Fatalf("unexpected vertex selection for %s: %+v", api, sel)
+		}
+	}
+	if _, err := s.SelectWithConstraints(context.Background(), 10, "", Constraints{RequireAPI: RequiredAPIResponses}); err == nil {
+		t.Fatalf("expected vertex channel to be excluded from responses")
+	}
 }
 
 func TestSelectWithConstraints_RequireCredentialKey_AllowsEndpointInCooldown(t *testing.T) {
@@ -414,12 +615,12 @@ func TestReport_CredentialScopedFailureSkipsEndpointAndChannelPenalty(t *testing
 		Scope:      FailureScopeCredential,
 	})
 
-	s.state.mu.Lock()
-	credCooling, credOK := s.state.credentialCooldown[sel.CredentialKey()]
-	_, endpointCooling := s.state.endpointCooldown[sel.EndpointID]
-	channelFails := s.state.channelFails[sel.ChannelID]
-	_, channelBanned := s.state.channelBanUntil[sel.ChannelID]
-	s.state.mu.Unlock()
+	s.state.(*State).mu.Lock()
+	credCooling, credOK := s.state.(*State).credentialCooldown[sel.CredentialKey()]
+	_, endpointCooling := s.state.(*State).endpointCooldown[sel.EndpointID]
+	channelFails := s.state.(*State).channelFails[sel.ChannelID]
+	_, channelBanned := s.state.(*State).channelBanUntil[sel.ChannelID]
+	s.state.(*State).mu.Unlock()
 
 	if !credOK || credCooling.IsZero() {
 		t.Fatalf("expected credential cooldown to be set")
@@ -451,10 +652,10 @@ func TestReport_RequestScopedFailureSkipsCredentialPenalty(t *testing.T) {
 		Scope:      FailureScopeRequest,
 	})
 
-	s.state.mu.Lock()
-	credFails := s.state.credFails[sel.CredentialKey()]
-	channelFails := s.state.channelFails[sel.ChannelID]
-	s.state.mu.Unlock()
+	s.state.(*State).mu.Lock()
+	credFails := s.state.(*State).credFails[sel.CredentialKey()]
+	channelFails := s.state.(*State).channelFails[sel.ChannelID]
+	s.state.(*State).mu.Unlock()
 
 	if credFails != 0 {
 		t.Fatalf("expected request-scoped failure not to increment credential fails, got=%d", credFails)
@@ -484,15 +685,15 @@ func TestReport_ChannelModelScopedFailureBansBindingOnly(t *testing.T) {
 		ChannelModelBindingID: 701,
 	})
 
-	s.state.mu.Lock()
-	credFails := s.state.credFails[sel.CredentialKey()]
-	_, credCooling := s.state.credentialCooldown[sel.CredentialKey()]
-	_, endpointCooling := s.state.endpointCooldown[sel.EndpointID]
-	channelFails := s.state.channelFails[sel.ChannelID]
-	_, channelBanned := s.state.channelBanUntil[sel.ChannelID]
-	modelFails := s.state.channelModelFails[701]
-	modelBanUntil, modelBanned := s.state.channelModelBanUntil[701]
-	s.state.mu.Unlock()
+	s.state.(*State).mu.Lock()
+	credFails := s.state.(*State).credFails[sel.CredentialKey()]
+	_, credCooling := s.state.(*State).credentialCooldown[sel.CredentialKey()]
+	_, endpointCooling := s.state.(*State).endpointCooldown[sel.EndpointID]
+	channelFails := s.state.(*State).channelFails[sel.ChannelID]
+	_, channelBanned := s.state.(*State).channelBanUntil[sel.ChannelID]
+	modelFails := s.state.(*State).channelModelFails[701]
+	modelBanUntil, modelBanned := s.state.(*State).channelModelBanUntil[701]
+	s.state.(*State).mu.Unlock()
 
 	if credFails != 0 {
 		t.Fatalf("expected credential fail score to stay 0, got=%d", credFails)
@@ -575,9 +776,9 @@ func TestRuntimeChannelStats_ExpiredBanMarksProbeDue(t *testing.T) {
 	s := New(&fakeStore{})
 	now := time.Now()
 
-	s.state.mu.Lock()
-	s.state.channelBanUntil[1] = now.Add(-1 * time.Second)
-	s.state.mu.Unlock()
+	s.state.(*State).mu.Lock()
+	s.state.(*State).channelBanUntil[1] = now.Add(-1 * time.Second)
+	s.state.(*State).mu.Unlock()
 
 	rt := s.RuntimeChannelStats(1)
 	if rt.BannedUntil != nil {
@@ -635,9 +836,9 @@ func TestSelect_ProbeChannelBeatsPromotionAndIsSingleFlight(t *testing.T) {
 	}
 	s := New(fs)
 
-	s.state.mu.Lock()
-	s.state.channelProbeDueAt[1] = time.Now()
-	s.state.mu.Unlock()
+	s.state.(*State).mu.Lock()
+	s.state.(*State).channelProbeDueAt[1] = time.Now()
+	s.state.(*State).mu.Unlock()
 
 	first, err := s.SelectWithConstraints(context.Background(), 10, "", Constraints{})
 	if err != nil {
@@ -660,10 +861,10 @@ func TestReport_SuccessResetsChannelFailScoreAndClearsProbe(t *testing.T) {
 	s := New(&fakeStore{})
 	sel := Selection{ChannelID: 1, CredentialType: CredentialTypeOpenAI, CredentialID: 1}
 
-	s.state.mu.Lock()
-	s.state.channelProbeDueAt[1] = time.Now()
-	s.state.channelProbeClaimUntil[1] = time.Now().Add(1 * time.Minute)
-	s.state.mu.Unlock()
+	s.state.(*State).mu.Lock()
+	s.state.(*State).channelProbeDueAt[1] = time.Now()
+	s.state.(*State).channelProbeClaimUntil[1] = time.Now().Add(1 * time.Minute)
+	s.state.(*State).mu.Unlock()
 
 	s.Report(sel, Result{Success: false, Retriable: false})
 	if got := s.state.ChannelFailScore(1); got == 0 {
@@ -718,9 +919,9 @@ func TestReport_Cooldown429LongerThanDefault(t *testing.T) {
 	start429 := time.Now()
 	s.Report(sel429, Result{Success: false, Retriable: true, StatusCode: http.StatusTooManyRequests})
 
-	s.state.mu.Lock()
-	until429, ok429 := s.state.credentialCooldown[sel429.CredentialKey()]
-	s.state.mu.Unlock()
+	s.state.(*State).mu.Lock()
+	until429, ok429 := s.state.(*State).credentialCooldown[sel429.CredentialKey()]
+	s.state.(*State).mu.Unlock()
 	if !ok429 {
 		t.Fatalf("expected credential cooldown for 429")
 	}
@@ -730,9 +931,9 @@ func TestReport_Cooldown429LongerThanDefault(t *testing.T) {
 	start500 := time.Now()
 	s.Report(sel500, Result{Success: false, Retriable: true, StatusCode: http.StatusBadGateway})
 
-	s.state.mu.Lock()
-	until500, ok500 := s.state.credentialCooldown[sel500.CredentialKey()]
-	s.state.mu.Unlock()
+	s.state.(*State).mu.Lock()
+	until500, ok500 := s.state.(*State).credentialCooldown[sel500.CredentialKey()]
+	s.state.(*State).mu.Unlock()
 	if !ok500 {
 		t.Fatalf("expected credential cooldown for 502")
 	}
@@ -769,10 +970,10 @@ func TestReport_UpstreamExhaustedUsesOverrideAndSkipsChannelBan(t *testing.T) {
 		CooldownUntil: &overrideUntil,
 	})
 
-	s.state.mu.Lock()
-	cooldownUntil, ok := s.state.credentialCooldown[sel.CredentialKey()]
-	_, banned := s.state.channelBanUntil[sel.ChannelID]
-	s.state.mu.Unlock()
+	s.state.(*State).mu.Lock()
+	cooldownUntil, ok := s.state.(*State).credentialCooldown[sel.CredentialKey()]
+	_, banned := s.state.(*State).channelBanUntil[sel.ChannelID]
+	s.state.(*State).mu.Unlock()
 	if !ok {
 		t.Fatalf("expected credential cooldown to be set")
 	}
@@ -974,3 +1175,131 @@ func TestSelectWithConstraints_AllowGroups(t *testing.T) {
 		t.Fatalf("expected err when allow-groups matches none")
 	}
 }
+
+func TestSelectWithConstraints_WeightSplitsTrafficWithinSamePriority(t *testing.T) {
+	fs := &fakeStore{
+		channels: []store.UpstreamChannel{
+			{ID: 1, Type: store.UpstreamTypeOpenAICompatible, Status: 1, Priority: 0, Weight: 3},
+			{ID: 2, Type: store.UpstreamTypeOpenAICompatible, Status: 1, Priority: 0, Weight: 1},
+		},
+		endpoints: map[int64][]store.UpstreamEndpoint{
+			1: {
+				{ID: 11, ChannelID: 1, BaseURL: "https://a.example", Status: 1},
+			},
+			2: {
+				{ID: 21, ChannelID: 2, BaseURL: "https://b.example", Status: 1},
+			},
+		},
+		creds: map[int64][]store.OpenAICompatibleCredential{
+			11: {
+				{ID: 111, EndpointID: 11, Status: 1},
+			},
+			21: {
+				{ID: 211, EndpointID: 21, Status: 1},
+			},
+		},
+	}
+	s := New(fs)
+	const total = 4000
+	counts := map[int64]int{}
+	for i := 0; i < total; i++ {
+		// 每次使用不同用户，避免 user affinity 把后续请求粘到首次选中的渠道。
+		sel, err := s.SelectWithConstraints(context.Background(), int64(i+1), "", Constraints{})
+		if err != nil {
+			t.Fatalf("Select err: %v", err)
+		}
+		counts[sel.ChannelID]++
+	}
+	share := float64(counts[1]) / total
+	if share < 0.70 || share > 0.80 {
+		t.Fatalf("expected channel=1 share≈0.75, got=%.3f (counts=%v)", share, counts)
+	}
+	if got := s.RuntimeChannelStats(1).Selections; got != int64(counts[1]) {
+		t.Fatalf("expected runtime selections=%d, got=%d", counts[1], got)
+	}
+}
+
+func TestSelectWithConstraints_WeightSplitsTrafficForSingleUser(t *testing.T) {
+	fs := &fakeStore{
+		channels: []store.UpstreamChannel{
+			{ID: 1, Type: store.UpstreamTypeOpenAICompatible, Status: 1, Priority: 0, Weight: 3},
+			{ID: 2, Type: store.UpstreamTypeOpenAICompatible, Status: 1, Priority: 0, Weight: 1},
+		},
+		endpoints: map[int64][]store.UpstreamEndpoint{
+			1: {{ID: 11, ChannelID: 1, BaseURL: "https://a.example", Status: 1}},
+			2: {{ID: 21, ChannelID: 2, BaseURL: "https://b.example", Status: 1}},
+		},
+		creds: map[int64][]store.OpenAICompatibleCredential{
+			11: {{ID: 111, EndpointID: 11, Status: 1}},
+			21: {{ID: 211, EndpointID: 21, Status: 1}},
+		},
+	}
+	s := New(fs)
+	const total = 4000
+	counts := map[int64]int{}
+	for i := 0; i < total; i++ {
+		// 同一用户：user affinity 不应覆盖加权分流。
+		sel, err := s.SelectWithConstraints(context.Background(), 10, "", Constraints{})
+		if err != nil {
+			t.Fatalf("Select err: %v", err)
+		}
+		counts[sel.ChannelID]++
+	}
+	share := float64(counts[1]) / total
+	if share < 0.70 || share > 0.80 {
+		t.Fatalf("expected channel=1 share≈0.75 for a single user, got=%.3f (counts=%v)", share, counts)
+	}
+}
+
+func TestSelectWithConstraints_WeightDoesNotOverridePriority(t *testing.T) {
+	fs := &fakeStore{
+		channels: []store.UpstreamChannel{
+			{ID: 1, Type: store.UpstreamTypeOpenAICompatible, Status: 1, Priority: 10, Weight: 1},
+			{ID: 2, Type: store.UpstreamTypeOpenAICompatible, Status: 1, Priority: 0, Weight: 100},
+		},
+		endpoints: map[int64][]store.UpstreamEndpoint{
+			1: {
+				{ID: 11, ChannelID: 1, BaseURL: "https://a.example", Status: 1},
+			},
+			2: {
+				{ID: 21, ChannelID: 2, BaseURL: "https://b.example", Status: 1},
+			},
+		},
+		creds: map[int64][]store.OpenAICompatibleCredential{
+			11: {
+				{ID: 111, EndpointID: 11, Status: 1},
+			},
+			21: {
+				{ID: 211, EndpointID: 21, Status: 1},
+			},
+		},
+	}
+	s := New(fs)
+	for i := 0; i < 50; i++ {
+		sel, err := s.SelectWithConstraints(context.Background(), 10, "", Constraints{})
+		if err != nil {
+			t.Fatalf("Select err: %v", err)
+		}
+		if sel.ChannelID != 1 {
+			t.Fatalf("expected higher priority channel=1, got=%d", sel.ChannelID)
+		}
+	}
+}
+
+func TestWeightedTierOrder_UnconfiguredKeepsOrder(t *testing.T) {
+	ids := []int64{5, 3, 9}
+	weights := []int{0, 0, 0}
+	weightedTierOrder(len(ids),
+		func(i, j int) bool { return true },
+		func(i int) int { return weights[i] },
+		func(i int) int64 { return ids[i] },
+		"seed",
+		func(i, j int) {
+			ids[i], ids[j] = ids[j], ids[i]
+			weights[i], weights[j] = weights[j], weights[i]
+		},
+	)
+	if ids[0] != 5 || ids[1] != 3 || ids[2] != 9 {
+		t.Fatalf("expected order unchanged, got=%v", ids)
+	}
+}
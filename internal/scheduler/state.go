@@ -1,7 +1,8 @@
-// Package scheduler 管理单实例运行态：亲和/RPM/冷却/失败统计（默认仅内存）。
+// Package scheduler 管理调度运行态：亲和/RPM/冷却/失败统计（默认仅内存，可选 Redis 共享，见 RedisState）。
 package scheduler
 
 import (
+	"strconv"
 	"sync"
 	"time"
 )
@@ -16,6 +17,7 @@ type tokenEvent struct {
 	tokens int
 }
 
+// State 是进程内的 StateBackend 实现（单实例运行态）。
 type State struct {
 	mu sync.Mutex
 
@@ -25,6 +27,10 @@ type State struct {
 
 	tokens map[string][]tokenEvent
 
+	// inflight 记录 credential 的并发占用：token -> lease 到期时间。
+	inflight    map[string]map[string]time.Time
+	inflightSeq uint64
+
 	credentialCooldown map[string]time.Time
 	endpointCooldown   map[int64]time.Time
 
@@ -39,6 +45,8 @@ type State struct {
 
 	channelProbeDueAt      map[int64]time.Time
 	channelProbeClaimUntil map[int64]time.Time
+
+	channelSelections map[int64]int64
 }
 
 func NewState() *State {
@@ -46,6 +54,7 @@ func NewState() *State {
 		affinity:               make(map[string]affinityEntry),
 		rpm:                    make(map[string][]time.Time),
 		tokens:                 make(map[string][]tokenEvent),
+		inflight:               make(map[string]map[string]time.Time),
 		credentialCooldown:     make(map[string]time.Time),
 		endpointCooldown:       make(map[int64]time.Time),
 		channelFails:           make(map[int64]int),
@@ -57,6 +66,7 @@ func NewState() *State {
 		channelModelBanStreak:  make(map[int64]int),
 		channelProbeDueAt:      make(map[int64]time.Time),
 		channelProbeClaimUntil: make(map[int64]time.Time),
+		channelSelections:      make(map[int64]int64),
 	}
 }
 
@@ -91,6 +101,13 @@ func (s *State) RecordRPM(credentialKey string, t time.Time) {
 	s.rpm[credentialKey] = append(s.rpm[credentialKey], t)
 }
 
+// RecordChannelSelection 累计渠道被选中的次数（进程内），用于对照 weight 观察实际分流比例。
+func (s *State) RecordChannelSelection(channelID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.channelSelections[channelID]++
+}
+
 func (s *State) RPM(credentialKey string, now time.Time, window time.Duration) int {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -118,6 +135,74 @@ func (s *State) RecordTokens(credentialKey string, t time.Time, tokens int) {
 	s.tokens[credentialKey] = append(s.tokens[credentialKey], tokenEvent{time: t, tokens: tokens})
 }
 
+// TPM 返回 credential 在 window 内累计的 token 数。
+func (s *State) TPM(credentialKey string, now time.Time, window time.Duration) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	events := s.tokens[credentialKey]
+	if len(events) == 0 {
+		return 0
+	}
+	cutoff := now.Add(-window)
+	var kept []tokenEvent
+	total := 0
+	for _, e := range events {
+		if e.time.After(cutoff) {
+			kept = append(kept, e)
+			total += e.tokens
+		}
+	}
+	s.tokens[credentialKey] = kept
+	return total
+}
+
+// AcquireCredentialInflight 在并发占用未达 max 时占用一个槽位，返回用于释放的 token。
+// lease 为占用的最长持有时间，到期后视为已释放。
+func (s *State) AcquireCredentialInflight(credentialKey string, max int, now time.Time, lease time.Duration) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	slots := s.inflight[credentialKey]
+	for token, until := range slots {
+		if !now.Before(until) {
+			delete(slots, token)
+		}
+	}
+	if max > 0 && len(slots) >= max {
+		return "", false
+	}
+	if slots == nil {
+		slots = make(map[string]time.Time)
+		s.inflight[credentialKey] = slots
+	}
+	s.inflightSeq++
+	token := strconv.FormatUint(s.inflightSeq, 36)
+	slots[token] = now.Add(lease)
+	return token, true
+}
+
+func (s *State) ReleaseCredentialInflight(credentialKey string, token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	slots := s.inflight[credentialKey]
+	delete(slots, token)
+	if len(slots) == 0 {
+		delete(s.inflight, credentialKey)
+	}
+}
+
+// CredentialInflight 返回 credential 当前未过期的并发占用数。
+func (s *State) CredentialInflight(credentialKey string, now time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, until := range s.inflight[credentialKey] {
+		if now.Before(until) {
+			n++
+		}
+	}
+	return n
+}
+
 func (s *State) SetCredentialCooling(credentialKey string, until time.Time) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -416,6 +501,34 @@ func (s *State) BanChannelImmediate(channelID int64, now time.Time, base time.Du
 	return newUntil
 }
 
+func (s *State) RestoreChannelBan(channelID int64, until time.Time, streak int) {
+	if channelID == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cur, ok := s.channelBanUntil[channelID]; !ok || until.After(cur) {
+		s.channelBanUntil[channelID] = until
+	}
+	if streak > s.channelBanStreak[channelID] {
+		s.channelBanStreak[channelID] = streak
+	}
+}
+
+func (s *State) RestoreChannelModelBan(bindingID int64, until time.Time, streak int) {
+	if bindingID == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cur, ok := s.channelModelBanUntil[bindingID]; !ok || until.After(cur) {
+		s.channelModelBanUntil[bindingID] = until
+	}
+	if streak > s.channelModelBanStreak[bindingID] {
+		s.channelModelBanStreak[bindingID] = streak
+	}
+}
+
 func (s *State) IsChannelProbePending(channelID int64, now time.Time) bool {
 	if s == nil || channelID == 0 {
 		return false
@@ -435,6 +548,37 @@ func (s *State) IsChannelProbePending(channelID int64, now time.Time) bool {
 	return true
 }
 
+func (s *State) ChannelRoutingSnapshot(channelIDs []int64, now time.Time) ChannelRoutingSnapshot {
+	out := ChannelRoutingSnapshot{
+		FailScores:   make(map[int64]int, len(channelIDs)),
+		ProbePending: make(map[int64]bool, len(channelIDs)),
+	}
+	if s == nil {
+		return out
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range channelIDs {
+		if id == 0 {
+			continue
+		}
+		if v := s.channelFails[id]; v != 0 {
+			out.FailScores[id] = v
+		}
+		if _, ok := s.channelProbeDueAt[id]; !ok {
+			continue
+		}
+		if until, ok := s.channelProbeClaimUntil[id]; ok {
+			if !now.After(until) {
+				continue
+			}
+			delete(s.channelProbeClaimUntil, id)
+		}
+		out.ProbePending[id] = true
+	}
+	return out
+}
+
 func (s *State) IsChannelProbeDue(channelID int64) bool {
 	if s == nil || channelID == 0 {
 		return false
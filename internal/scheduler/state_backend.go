@@ -0,0 +1,83 @@
+package scheduler
+
+import "time"
+
+// StateBackend 抽象调度运行态（亲和/RPM/冷却/失败计分/封禁/探测）的存储。
+// 默认使用进程内 State；多副本部署可使用 RedisState，使封禁、冷却与探测占用在集群内一致。
+type StateBackend interface {
+	SetAffinity(userID, channelID int64, expiresAt time.Time)
+	GetAffinity(userID int64, now time.Time) (int64, bool)
+
+	RecordRPM(credentialKey string, t time.Time)
+	RPM(credentialKey string, now time.Time, window time.Duration) int
+	RecordTokens(credentialKey string, t time.Time, tokens int)
+	TPM(credentialKey string, now time.Time, window time.Duration) int
+	RecordChannelSelection(channelID int64)
+
+	// AcquireCredentialInflight/ReleaseCredentialInflight 维护 credential 级并发占用；lease 到期自动释放，避免异常退出泄漏。
+	AcquireCredentialInflight(credentialKey string, max int, now time.Time, lease time.Duration) (string, bool)
+	ReleaseCredentialInflight(credentialKey string, token string)
+	CredentialInflight(credentialKey string, now time.Time) int
+
+	SetCredentialCooling(credentialKey string, until time.Time)
+	IsCredentialCooling(credentialKey string, now time.Time) bool
+	SetEndpointCooling(endpointID int64, until time.Time)
+	IsEndpointCooling(endpointID int64, now time.Time) bool
+	ClearEndpointCooldown(endpointID int64)
+
+	RecordChannelResult(channelID int64, success bool)
+	RecordCredentialResult(credentialKey string, success bool)
+	ChannelFailScore(channelID int64) int
+	ResetChannelFailScore(channelID int64)
+	RecordChannelModelResult(bindingID int64, success bool)
+	ChannelModelFailScore(bindingID int64) int
+	ResetChannelModelFailScore(bindingID int64)
+
+	IsChannelBanned(channelID int64, now time.Time) bool
+	ChannelBanUntil(channelID int64, now time.Time) (time.Time, bool)
+	ClearChannelBan(channelID int64)
+	BanChannel(channelID int64, now time.Time, base time.Duration) time.Time
+	BanChannelImmediate(channelID int64, now time.Time, base time.Duration) time.Time
+
+	IsChannelModelBanned(bindingID int64, now time.Time) bool
+	ChannelModelBanUntil(bindingID int64, now time.Time) (time.Time, bool)
+	ClearChannelModelBan(bindingID int64)
+	BanChannelModel(bindingID int64, now time.Time, base time.Duration, minUntil time.Time) time.Time
+
+	IsChannelProbePending(channelID int64, now time.Time) bool
+	// ChannelRoutingSnapshot 一次性读取一批渠道的失败分与探测待定标记，供单次选路排序使用。
+	ChannelRoutingSnapshot(channelIDs []int64, now time.Time) ChannelRoutingSnapshot
+	IsChannelProbeDue(channelID int64) bool
+	TryClaimChannelProbe(channelID int64, now time.Time, ttl time.Duration) bool
+	ReleaseChannelProbeClaim(channelID int64)
+	ClearChannelProbe(channelID int64)
+
+	SweepExpiredChannelBans(now time.Time)
+
+	// RestoreChannelBan/RestoreChannelModelBan 用于启动时从持久化记录恢复封禁（不累加 streak）。
+	RestoreChannelBan(channelID int64, until time.Time, streak int)
+	RestoreChannelModelBan(bindingID int64, until time.Time, streak int)
+
+	ChannelRuntime(channelID int64, now time.Time) RuntimeChannelStats
+	ChannelModelRuntime(bindingID int64, now time.Time) RuntimeChannelModelStats
+}
+
+// ChannelRoutingSnapshot 为单次选路排序所需的渠道运行态快照。
+// 排序比较器只读快照，避免每次比较都访问后端；同一快照内的数据来自同一数据源（Redis 或进程内 State）。
+type ChannelRoutingSnapshot struct {
+	FailScores   map[int64]int
+	ProbePending map[int64]bool
+}
+
+func (s ChannelRoutingSnapshot) FailScore(channelID int64) int {
+	return s.FailScores[channelID]
+}
+
+func (s ChannelRoutingSnapshot) IsProbePending(channelID int64) bool {
+	return s.ProbePending[channelID]
+}
+
+var (
+	_ StateBackend = (*State)(nil)
+	_ StateBackend = (*RedisState)(nil)
+)
@@ -0,0 +1,956 @@
+package scheduler
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStateOptions 复用 config.RedisConfig 的连接参数。
+type RedisStateOptions struct {
+	Addr      string
+	Password  string
+	DB        int
+	KeyPrefix string
+
+	// OpTimeout 为单次 Redis 操作超时；超时或出错时回退到进程内 State，避免阻塞转发链路。
+	OpTimeout time.Duration
+}
+
+// RedisState 是基于 Redis 的 StateBackend 实现：多副本共享封禁/冷却/探测占用/失败计分/RPM/亲和。
+//
+// 时间统一以毫秒时间戳存储；过期判定沿用调用方传入的 now，与进程内 State 的语义保持一致。
+// Redis 不可用时每个操作独立回退到 fallback（进程内 State），恢复后自动切回 Redis。
+type RedisState struct {
+	client    *redis.Client
+	prefix    string
+	opTimeout time.Duration
+	fallback  *State
+
+	seq atomic.Uint64
+}
+
+func NewRedisState(opts RedisStateOptions) *RedisState {
+	prefix := strings.TrimSpace(opts.KeyPrefix)
+	if prefix == "" {
+		prefix = "realms"
+	}
+	if opts.OpTimeout <= 0 {
+		opts.OpTimeout = 500 * time.Millisecond
+	}
+	client := redis.NewClient(&redis.Options{
+		Addr:     strings.TrimSpace(opts.Addr),
+		Password: opts.Password,
+		DB:       opts.DB,
+	})
+	return &RedisState{
+		client:    client,
+		prefix:    prefix + ":sched:",
+		opTimeout: opts.OpTimeout,
+		fallback:  NewState(),
+	}
+}
+
+func (r *RedisState) PingContext(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
+func (r *RedisState) Close() error {
+	return r.client.Close()
+}
+
+const (
+	redisHashCredentialCooldown = "cred_cooldown"
+	redisHashEndpointCooldown   = "endpoint_cooldown"
+	redisHashChannelFails       = "channel_fails"
+	redisHashCredentialFails    = "cred_fails"
+	redisHashChannelModelFails  = "channel_model_fails"
+	redisHashChannelBanUntil    = "channel_ban_until"
+	redisHashChannelBanStreak   = "channel_ban_streak"
+	redisHashModelBanUntil      = "channel_model_ban_until"
+	redisHashModelBanStreak     = "channel_model_ban_streak"
+	redisHashProbeDue           = "channel_probe_due"
+	redisHashProbeClaim         = "channel_probe_claim"
+	redisHashChannelSelections  = "channel_selections"
+
+	// redisMaxBanDuration 与进程内 State 的封禁上限保持一致。
+	redisMaxBanDuration = 10 * time.Minute
+	// redisEventRetention 为 RPM/TPM 事件的最长保留时间。
+	redisEventRetention = 10 * time.Minute
+)
+
+func (r *RedisState) key(suffix string) string {
+	return r.prefix + suffix
+}
+
+func (r *RedisState) ctx() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), r.opTimeout)
+}
+
+func (r *RedisState) member(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 36) + "-" + strconv.FormatUint(r.seq.Add(1), 36)
+}
+
+func msToTime(ms int64) time.Time {
+	return time.UnixMilli(ms)
+}
+
+func (r *RedisState) SetAffinity(userID, channelID int64, expiresAt time.Time) {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return
+	}
+	ctx, cancel := r.ctx()
+	defer cancel()
+	if err := r.client.Set(ctx, r.key("affinity:"+itoa64(userID)), itoa64(channelID), ttl).Err(); err != nil {
+		r.fallback.SetAffinity(userID, channelID, expiresAt)
+	}
+}
+
+func (r *RedisState) GetAffinity(userID int64, now time.Time) (int64, bool) {
+	ctx, cancel := r.ctx()
+	defer cancel()
+	v, err := r.client.Get(ctx, r.key("affinity:"+itoa64(userID))).Int64()
+	if err == redis.Nil {
+		return 0, false
+	}
+	if err != nil {
+		return r.fallback.GetAffinity(userID, now)
+	}
+	return v, true
+}
+
+func (r *RedisState) RecordRPM(credentialKey string, t time.Time) {
+	ctx, cancel := r.ctx()
+	defer cancel()
+	key := r.key("rpm:" + credentialKey)
+	pipe := r.client.Pipeline()
+	pipe.ZAdd(ctx, key, redis.Z{Score: float64(t.UnixMilli()), Member: r.member(t)})
+	pipe.PExpire(ctx, key, redisEventRetention)
+	if _, err := pipe.Exec(ctx); err != nil {
+		r.fallback.RecordRPM(credentialKey, t)
+	}
+}
+
+func (r *RedisState) RPM(credentialKey string, now time.Time, window time.Duration) int {
+	ctx, cancel := r.ctx()
+	defer cancel()
+	key := r.key("rpm:" + credentialKey)
+	cutoff := strconv.FormatInt(now.Add(-window).UnixMilli(), 10)
+	pipe := r.client.Pipeline()
+	pipe.ZRemRangeByScore(ctx, key, "-inf", cutoff)
+	card := pipe.ZCard(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return r.fallback.RPM(credentialKey, now, window)
+	}
+	return int(card.Val())
+}
+
+func (r *RedisState) RecordTokens(credentialKey string, t time.Time, tokens int) {
+	if credentialKey == "" || tokens <= 0 {
+		return
+	}
+	ctx, cancel := r.ctx()
+	defer cancel()
+	key := r.key("tokens:" + credentialKey)
+	pipe := r.client.Pipeline()
+	pipe.ZAdd(ctx, key, redis.Z{Score: float64(t.UnixMilli()), Member: r.member(t) + ":" + strconv.Itoa(tokens)})
+	pipe.ZRemRangeByScore(ctx, key, "-inf", strconv.FormatInt(t.Add(-redisEventRetention).UnixMilli(), 10))
+	pipe.PExpire(ctx, key, redisEventRetention)
+	if _, err := pipe.Exec(ctx); err != nil {
+		r.fallback.RecordTokens(credentialKey, t, tokens)
+	}
+}
+
+func (r *RedisState) TPM(credentialKey string, now time.Time, window time.Duration) int {
+	ctx, cancel := r.ctx()
+	defer cancel()
+	v, err := redisTPMScript.Run(ctx, r.client,
+		[]string{r.key("tokens:" + credentialKey)},
+		now.Add(-window).UnixMilli(),
+	).Int64()
+	if err != nil {
+		return r.fallback.TPM(credentialKey, now, window)
+	}
+	return int(v)
+}
+
+func (r *RedisState) AcquireCredentialInflight(credentialKey string, max int, now time.Time, lease time.Duration) (string, bool) {
+	ctx, cancel := r.ctx()
+	defer cancel()
+	token := r.member(now)
+	v, err := redisAcquireInflightScript.Run(ctx, r.client,
+		[]string{r.key("inflight:" + credentialKey)},
+		now.UnixMilli(), max, token, now.Add(lease).UnixMilli(), lease.Milliseconds(),
+	).Int64()
+	if err != nil {
+		return r.fallback.AcquireCredentialInflight(credentialKey, max, now, lease)
+	}
+	if v != 1 {
+		return "", false
+	}
+	return token, true
+}
+
+func (r *RedisState) ReleaseCredentialInflight(credentialKey string, token string) {
+	ctx, cancel := r.ctx()
+	defer cancel()
+	_ = r.client.ZRem(ctx, r.key("inflight:"+credentialKey), token).Err()
+	// 占用可能来自 Redis 不可用时的回退路径，一并尝试释放。
+	r.fallback.ReleaseCredentialInflight(credentialKey, token)
+}
+
+func (r *RedisState) CredentialInflight(credentialKey string, now time.Time) int {
+	ctx, cancel := r.ctx()
+	defer cancel()
+	n, err := r.client.ZCount(ctx, r.key("inflight:"+credentialKey), "("+strconv.FormatInt(now.UnixMilli(), 10), "+inf").Result()
+	if err != nil {
+		return r.fallback.CredentialInflight(credentialKey, now)
+	}
+	return int(n)
+}
+
+func (r *RedisState) RecordChannelSelection(channelID int64) {
+	ctx, cancel := r.ctx()
+	defer cancel()
+	if err := r.client.HIncrBy(ctx, r.key(redisHashChannelSelections), itoa64(channelID), 1).Err(); err != nil {
+		r.fallback.RecordChannelSelection(channelID)
+	}
+}
+
+func (r *RedisState) SetCredentialCooling(credentialKey string, until time.Time) {
+	ctx, cancel := r.ctx()
+	defer cancel()
+	if err := r.client.HSet(ctx, r.key(redisHashCredentialCooldown), credentialKey, until.UnixMilli()).Err(); err != nil {
+		r.fallback.SetCredentialCooling(credentialKey, until)
+	}
+}
+
+func (r *RedisState) IsCredentialCooling(credentialKey string, now time.Time) bool {
+	until, err := r.checkUntil(redisHashCredentialCooldown, credentialKey, now)
+	if err != nil {
+		return r.fallback.IsCredentialCooling(credentialKey, now)
+	}
+	return until > 0
+}
+
+func (r *RedisState) SetEndpointCooling(endpointID int64, until time.Time) {
+	if endpointID == 0 {
+		return
+	}
+	ctx, cancel := r.ctx()
+	defer cancel()
+	if err := r.client.HSet(ctx, r.key(redisHashEndpointCooldown), itoa64(endpointID), until.UnixMilli()).Err(); err != nil {
+		r.fallback.SetEndpointCooling(endpointID, until)
+	}
+}
+
+func (r *RedisState) IsEndpointCooling(endpointID int64, now time.Time) bool {
+	if endpointID == 0 {
+		return false
+	}
+	until, err := r.checkUntil(redisHashEndpointCooldown, itoa64(endpointID), now)
+	if err != nil {
+		return r.fallback.IsEndpointCooling(endpointID, now)
+	}
+	return until > 0
+}
+
+func (r *RedisState) ClearEndpointCooldown(endpointID int64) {
+	if endpointID == 0 {
+		return
+	}
+	r.fallback.ClearEndpointCooldown(endpointID)
+	r.hdel(redisHashEndpointCooldown, itoa64(endpointID))
+}
+
+func (r *RedisState) RecordChannelResult(channelID int64, success bool) {
+	if success {
+		return
+	}
+	if err := r.hincr(redisHashChannelFails, itoa64(channelID)); err != nil {
+		r.fallback.RecordChannelResult(channelID, success)
+	}
+}
+
+func (r *RedisState) RecordCredentialResult(credentialKey string, success bool) {
+	if success {
+		return
+	}
+	if err := r.hincr(redisHashCredentialFails, credentialKey); err != nil {
+		r.fallback.RecordCredentialResult(credentialKey, success)
+	}
+}
+
+func (r *RedisState) ChannelFailScore(channelID int64) int {
+	v, err := r.hgetInt(redisHashChannelFails, itoa64(channelID))
+	if err != nil {
+		return r.fallback.ChannelFailScore(channelID)
+	}
+	return int(v)
+}
+
+func (r *RedisState) ResetChannelFailScore(channelID int64) {
+	if channelID == 0 {
+		return
+	}
+	r.fallback.ResetChannelFailScore(channelID)
+	r.hdel(redisHashChannelFails, itoa64(channelID))
+}
+
+func (r *RedisState) RecordChannelModelResult(bindingID int64, success bool) {
+	if bindingID == 0 || success {
+		return
+	}
+	if err := r.hincr(redisHashChannelModelFails, itoa64(bindingID)); err != nil {
+		r.fallback.RecordChannelModelResult(bindingID, success)
+	}
+}
+
+func (r *RedisState) ChannelModelFailScore(bindingID int64) int {
+	if bindingID == 0 {
+		return 0
+	}
+	v, err := r.hgetInt(redisHashChannelModelFails, itoa64(bindingID))
+	if err != nil {
+		return r.fallback.ChannelModelFailScore(bindingID)
+	}
+	return int(v)
+}
+
+func (r *RedisState) ResetChannelModelFailScore(bindingID int64) {
+	if bindingID == 0 {
+		return
+	}
+	r.fallback.ResetChannelModelFailScore(bindingID)
+	r.hdel(redisHashChannelModelFails, itoa64(bindingID))
+}
+
+func (r *RedisState) IsChannelBanned(channelID int64, now time.Time) bool {
+	if channelID == 0 {
+		return false
+	}
+	until, err := r.checkChannelBan(channelID, now, false)
+	if err != nil {
+		return r.fallback.IsChannelBanned(channelID, now)
+	}
+	return until > 0
+}
+
+func (r *RedisState) ChannelBanUntil(channelID int64, now time.Time) (time.Time, bool) {
+	if channelID == 0 {
+		return time.Time{}, false
+	}
+	until, err := r.checkChannelBan(channelID, now, false)
+	if err != nil {
+		return r.fallback.ChannelBanUntil(channelID, now)
+	}
+	if until <= 0 {
+		return time.Time{}, false
+	}
+	return msToTime(until), true
+}
+
+func (r *RedisState) ClearChannelBan(channelID int64) {
+	if channelID == 0 {
+		return
+	}
+	r.fallback.ClearChannelBan(channelID)
+	ctx, cancel := r.ctx()
+	defer cancel()
+	field := itoa64(channelID)
+	pipe := r.client.TxPipeline()
+	pipe.HDel(ctx, r.key(redisHashChannelBanUntil), field)
+	pipe.HDel(ctx, r.key(redisHashChannelBanStreak), field)
+	pipe.HDel(ctx, r.key(redisHashProbeDue), field)
+	pipe.HDel(ctx, r.key(redisHashProbeClaim), field)
+	_, _ = pipe.Exec(ctx)
+}
+
+func (r *RedisState) BanChannel(channelID int64, now time.Time, base time.Duration) time.Time {
+	if channelID == 0 || base <= 0 {
+		return now
+	}
+	until, err := r.banChannel(channelID, now, base, false)
+	if err != nil {
+		return r.fallback.BanChannel(channelID, now, base)
+	}
+	return until
+}
+
+func (r *RedisState) BanChannelImmediate(channelID int64, now time.Time, base time.Duration) time.Time {
+	if channelID == 0 || base <= 0 {
+		return now
+	}
+	until, err := r.banChannel(channelID, now, base, true)
+	if err != nil {
+		return r.fallback.BanChannelImmediate(channelID, now, base)
+	}
+	return until
+}
+
+func (r *RedisState) IsChannelModelBanned(bindingID int64, now time.Time) bool {
+	if bindingID == 0 {
+		return false
+	}
+	until, err := r.checkChannelModelBan(bindingID, now)
+	if err != nil {
+		return r.fallback.IsChannelModelBanned(bindingID, now)
+	}
+	return until > 0
+}
+
+func (r *RedisState) ChannelModelBanUntil(bindingID int64, now time.Time) (time.Time, bool) {
+	if bindingID == 0 {
+		return time.Time{}, false
+	}
+	until, err := r.checkChannelModelBan(bindingID, now)
+	if err != nil {
+		return r.fallback.ChannelModelBanUntil(bindingID, now)
+	}
+	if until <= 0 {
+		return time.Time{}, false
+	}
+	return msToTime(until), true
+}
+
+func (r *RedisState) ClearChannelModelBan(bindingID int64) {
+	if bindingID == 0 {
+		return
+	}
+	r.fallback.ClearChannelModelBan(bindingID)
+	ctx, cancel := r.ctx()
+	defer cancel()
+	field := itoa64(bindingID)
+	pipe := r.client.TxPipeline()
+	pipe.HDel(ctx, r.key(redisHashModelBanUntil), field)
+	pipe.HDel(ctx, r.key(redisHashModelBanStreak), field)
+	_, _ = pipe.Exec(ctx)
+}
+
+func (r *RedisState) BanChannelModel(bindingID int64, now time.Time, base time.Duration, minUntil time.Time) time.Time {
+	if bindingID == 0 {
+		return now
+	}
+	if base <= 0 {
+		base = 30 * time.Second
+	}
+	var minMS int64
+	if !minUntil.IsZero() {
+		minMS = minUntil.UnixMilli()
+	}
+	ctx, cancel := r.ctx()
+	defer cancel()
+	v, err := redisBanChannelModelScript.Run(ctx, r.client,
+		[]string{r.key(redisHashModelBanUntil), r.key(redisHashModelBanStreak)},
+		itoa64(bindingID), now.UnixMilli(), base.Milliseconds(), minMS, redisMaxBanDuration.Milliseconds(),
+	).Int64()
+	if err != nil {
+		return r.fallback.BanChannelModel(bindingID, now, base, minUntil)
+	}
+	return msToTime(v)
+}
+
+func (r *RedisState) RestoreChannelBan(channelID int64, until time.Time, streak int) {
+	if channelID == 0 {
+		return
+	}
+	if err := r.restoreBan(redisHashChannelBanUntil, redisHashChannelBanStreak, itoa64(channelID), until, streak); err != nil {
+		r.fallback.RestoreChannelBan(channelID, until, streak)
+	}
+}
+
+func (r *RedisState) RestoreChannelModelBan(bindingID int64, until time.Time, streak int) {
+	if bindingID == 0 {
+		return
+	}
+	if err := r.restoreBan(redisHashModelBanUntil, redisHashModelBanStreak, itoa64(bindingID), until, streak); err != nil {
+		r.fallback.RestoreChannelModelBan(bindingID, until, streak)
+	}
+}
+
+func (r *RedisState) restoreBan(untilHash, streakHash, field string, until time.Time, streak int) error {
+	ctx, cancel := r.ctx()
+	defer cancel()
+	return redisRestoreBanScript.Run(ctx, r.client,
+		[]string{r.key(untilHash), r.key(streakHash)},
+		field, until.UnixMilli(), streak,
+	).Err()
+}
+
+func (r *RedisState) IsChannelProbePending(channelID int64, now time.Time) bool {
+	if r == nil || channelID == 0 {
+		return false
+	}
+	ctx, cancel := r.ctx()
+	defer cancel()
+	v, err := redisProbePendingScript.Run(ctx, r.client,
+		[]string{r.key(redisHashProbeDue), r.key(redisHashProbeClaim)},
+		itoa64(channelID), now.UnixMilli(),
+	).Int()
+	if err != nil {
+		return r.fallback.IsChannelProbePending(channelID, now)
+	}
+	return v == 1
+}
+
+// ChannelRoutingSnapshot 通过一次 pipeline（三个 HMGET）读取失败分、探测待定与探测认领；
+// 任一命令失败时整批回退到进程内 State，避免同一次排序混用两种数据源。
+// 过期的认领视为可认领，但不在此处删除（由 TryClaimChannelProbe 覆盖写入）。
+func (r *RedisState) ChannelRoutingSnapshot(channelIDs []int64, now time.Time) ChannelRoutingSnapshot {
+	fields := make([]string, 0, len(channelIDs))
+	ids := make([]int64, 0, len(channelIDs))
+	for _, id := range channelIDs {
+		if id == 0 {
+			continue
+		}
+		fields = append(fields, itoa64(id))
+		ids = append(ids, id)
+	}
+	out := ChannelRoutingSnapshot{
+		FailScores:   make(map[int64]int, len(ids)),
+		ProbePending: make(map[int64]bool, len(ids)),
+	}
+	if len(ids) == 0 {
+		return out
+	}
+	ctx, cancel := r.ctx()
+	defer cancel()
+	var fails, due, claims *redis.SliceCmd
+	_, err := r.client.Pipelined(ctx, func(p redis.Pipeliner) error {
+		fails = p.HMGet(ctx, r.key(redisHashChannelFails), fields...)
+		due = p.HMGet(ctx, r.key(redisHashProbeDue), fields...)
+		claims = p.HMGet(ctx, r.key(redisHashProbeClaim), fields...)
+		return nil
+	})
+	if err != nil {
+		return r.fallback.ChannelRoutingSnapshot(channelIDs, now)
+	}
+	nowMS := now.UnixMilli()
+	failVals, dueVals, claimVals := fails.Val(), due.Val(), claims.Val()
+	for i, id := range ids {
+		if v, ok := redisSliceInt(failVals, i); ok && v != 0 {
+			out.FailScores[id] = int(v)
+		}
+		if i >= len(dueVals) || dueVals[i] == nil {
+			continue
+		}
+		if claim, ok := redisSliceInt(claimVals, i); ok && nowMS <= claim {
+			continue
+		}
+		out.ProbePending[id] = true
+	}
+	return out
+}
+
+func redisSliceInt(vals []any, i int) (int64, bool) {
+	if i >= len(vals) || vals[i] == nil {
+		return 0, false
+	}
+	s, ok := vals[i].(string)
+	if !ok {
+		return 0, false
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
+
+func (r *RedisState) IsChannelProbeDue(channelID int64) bool {
+	if r == nil || channelID == 0 {
+		return false
+	}
+	ctx, cancel := r.ctx()
+	defer cancel()
+	ok, err := r.client.HExists(ctx, r.key(redisHashProbeDue), itoa64(channelID)).Result()
+	if err != nil {
+		return r.fallback.IsChannelProbeDue(channelID)
+	}
+	return ok
+}
+
+func (r *RedisState) TryClaimChannelProbe(channelID int64, now time.Time, ttl time.Duration) bool {
+	if r == nil || channelID == 0 {
+		return false
+	}
+	if ttl <= 0 {
+		ttl = 30 * time.Second
+	}
+	ctx, cancel := r.ctx()
+	defer cancel()
+	v, err := redisTryClaimProbeScript.Run(ctx, r.client,
+		[]string{r.key(redisHashProbeDue), r.key(redisHashProbeClaim)},
+		itoa64(channelID), now.UnixMilli(), ttl.Milliseconds(),
+	).Int()
+	if err != nil {
+		return r.fallback.TryClaimChannelProbe(channelID, now, ttl)
+	}
+	return v == 1
+}
+
+func (r *RedisState) ReleaseChannelProbeClaim(channelID int64) {
+	if r == nil || channelID == 0 {
+		return
+	}
+	r.fallback.ReleaseChannelProbeClaim(channelID)
+	r.hdel(redisHashProbeClaim, itoa64(channelID))
+}
+
+func (r *RedisState) ClearChannelProbe(channelID int64) {
+	if r == nil || channelID == 0 {
+		return
+	}
+	r.fallback.ClearChannelProbe(channelID)
+	ctx, cancel := r.ctx()
+	defer cancel()
+	field := itoa64(channelID)
+	pipe := r.client.TxPipeline()
+	pipe.HDel(ctx, r.key(redisHashProbeDue), field)
+	pipe.HDel(ctx, r.key(redisHashProbeClaim), field)
+	_, _ = pipe.Exec(ctx)
+}
+
+func (r *RedisState) SweepExpiredChannelBans(now time.Time) {
+	if r == nil {
+		return
+	}
+	r.fallback.SweepExpiredChannelBans(now)
+	ctx, cancel := r.ctx()
+	defer cancel()
+	_, _ = redisSweepBansScript.Run(ctx, r.client,
+		[]string{
+			r.key(redisHashChannelBanUntil),
+			r.key(redisHashProbeDue),
+			r.key(redisHashProbeClaim),
+			r.key(redisHashModelBanUntil),
+			r.key(redisHashModelBanStreak),
+		},
+		now.UnixMilli(),
+	).Result()
+}
+
+func (r *RedisState) ChannelRuntime(channelID int64, now time.Time) RuntimeChannelStats {
+	until, err := r.checkChannelBan(channelID, now, true)
+	if err != nil {
+		return r.fallback.ChannelRuntime(channelID, now)
+	}
+	ctx, cancel := r.ctx()
+	defer cancel()
+	field := itoa64(channelID)
+	pipe := r.client.Pipeline()
+	fails := pipe.HGet(ctx, r.key(redisHashChannelFails), field)
+	streak := pipe.HGet(ctx, r.key(redisHashChannelBanStreak), field)
+	selections := pipe.HGet(ctx, r.key(redisHashChannelSelections), field)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return r.fallback.ChannelRuntime(channelID, now)
+	}
+	failScore, _ := fails.Int()
+	banStreak, _ := streak.Int()
+	sel, _ := selections.Int64()
+	out := RuntimeChannelStats{
+		FailScore:  failScore,
+		BanStreak:  banStreak,
+		Selections: sel,
+	}
+	if until > 0 {
+		u := msToTime(until)
+		out.BannedUntil = &u
+	}
+	return out
+}
+
+func (r *RedisState) ChannelModelRuntime(bindingID int64, now time.Time) RuntimeChannelModelStats {
+	until, err := r.checkChannelModelBan(bindingID, now)
+	if err != nil {
+		return r.fallback.ChannelModelRuntime(bindingID, now)
+	}
+	ctx, cancel := r.ctx()
+	defer cancel()
+	field := itoa64(bindingID)
+	pipe := r.client.Pipeline()
+	fails := pipe.HGet(ctx, r.key(redisHashChannelModelFails), field)
+	streak := pipe.HGet(ctx, r.key(redisHashModelBanStreak), field)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return r.fallback.ChannelModelRuntime(bindingID, now)
+	}
+	failScore, _ := fails.Int()
+	banStreak, _ := streak.Int()
+	out := RuntimeChannelModelStats{
+		FailScore: failScore,
+		BanStreak: banStreak,
+	}
+	if until > 0 {
+		u := msToTime(until)
+		out.BannedUntil = &u
+	}
+	return out
+}
+
+func (r *RedisState) hincr(hash, field string) error {
+	ctx, cancel := r.ctx()
+	defer cancel()
+	return r.client.HIncrBy(ctx, r.key(hash), field, 1).Err()
+}
+
+func (r *RedisState) hgetInt(hash, field string) (int64, error) {
+	ctx, cancel := r.ctx()
+	defer cancel()
+	v, err := r.client.HGet(ctx, r.key(hash), field).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return v, err
+}
+
+func (r *RedisState) hdel(hash, field string) {
+	ctx, cancel := r.ctx()
+	defer cancel()
+	_ = r.client.HDel(ctx, r.key(hash), field).Err()
+}
+
+// checkUntil 返回 hash 中 field 的截止时间（毫秒）；已过期时删除并返回 0。
+func (r *RedisState) checkUntil(hash, field string, now time.Time) (int64, error) {
+	ctx, cancel := r.ctx()
+	defer cancel()
+	return redisCheckUntilScript.Run(ctx, r.client, []string{r.key(hash)}, field, now.UnixMilli()).Int64()
+}
+
+// checkChannelBan 返回渠道封禁截止时间（毫秒）；已过期时解除封禁并标记为待探测。
+func (r *RedisState) checkChannelBan(channelID int64, now time.Time, clearStreak bool) (int64, error) {
+	ctx, cancel := r.ctx()
+	defer cancel()
+	clear := 0
+	if clearStreak {
+		clear = 1
+	}
+	return redisCheckChannelBanScript.Run(ctx, r.client,
+		[]string{
+			r.key(redisHashChannelBanUntil),
+			r.key(redisHashChannelBanStreak),
+			r.key(redisHashProbeDue),
+			r.key(redisHashProbeClaim),
+		},
+		itoa64(channelID), now.UnixMilli(), clear,
+	).Int64()
+}
+
+func (r *RedisState) checkChannelModelBan(bindingID int64, now time.Time) (int64, error) {
+	ctx, cancel := r.ctx()
+	defer cancel()
+	return redisCheckModelBanScript.Run(ctx, r.client,
+		[]string{r.key(redisHashModelBanUntil), r.key(redisHashModelBanStreak)},
+		itoa64(bindingID), now.UnixMilli(),
+	).Int64()
+}
+
+func (r *RedisState) banChannel(channelID int64, now time.Time, base time.Duration, immediate bool) (time.Time, error) {
+	ctx, cancel := r.ctx()
+	defer cancel()
+	imm := 0
+	if immediate {
+		imm = 1
+	}
+	v, err := redisBanChannelScript.Run(ctx, r.client,
+		[]string{r.key(redisHashChannelBanUntil), r.key(redisHashChannelBanStreak)},
+		itoa64(channelID), now.UnixMilli(), base.Milliseconds(), imm, redisMaxBanDuration.Milliseconds(),
+	).Int64()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return msToTime(v), nil
+}
+
+var redisCheckUntilScript = redis.NewScript(`
+local v = redis.call("HGET", KEYS[1], ARGV[1])
+if not v then
+  return 0
+end
+local now = tonumber(ARGV[2])
+local untilMS = tonumber(v)
+if now > untilMS then
+  redis.call("HDEL", KEYS[1], ARGV[1])
+  return 0
+end
+return untilMS
+`)
+
+var redisCheckChannelBanScript = redis.NewScript(`
+local v = redis.call("HGET", KEYS[1], ARGV[1])
+if not v then
+  return 0
+end
+local now = tonumber(ARGV[2])
+local untilMS = tonumber(v)
+if now > untilMS then
+  redis.call("HDEL", KEYS[1], ARGV[1])
+  if ARGV[3] == "1" then
+    redis.call("HDEL", KEYS[2], ARGV[1])
+  end
+  redis.call("HSETNX", KEYS[3], ARGV[1], now)
+  redis.call("HDEL", KEYS[4], ARGV[1])
+  return 0
+end
+return untilMS
+`)
+
+var redisCheckModelBanScript = redis.NewScript(`
+local v = redis.call("HGET", KEYS[1], ARGV[1])
+if not v then
+  return 0
+end
+local now = tonumber(ARGV[2])
+local untilMS = tonumber(v)
+if now > untilMS then
+  redis.call("HDEL", KEYS[1], ARGV[1])
+  redis.call("HDEL", KEYS[2], ARGV[1])
+  return 0
+end
+return untilMS
+`)
+
+var redisBanChannelScript = redis.NewScript(`
+local field = ARGV[1]
+local now = tonumber(ARGV[2])
+local base = tonumber(ARGV[3])
+local immediate = ARGV[4] == "1"
+local maxDur = tonumber(ARGV[5])
+local streak = tonumber(redis.call("HGET", KEYS[2], field) or "0") + 1
+if streak > 20 then
+  streak = 20
+end
+redis.call("HSET", KEYS[2], field, streak)
+if (not immediate) and streak < 2 then
+  redis.call("HDEL", KEYS[1], field)
+  return now
+end
+local start = now
+local cur = redis.call("HGET", KEYS[1], field)
+if cur and tonumber(cur) > now then
+  start = tonumber(cur)
+end
+local newUntil = start + base * streak
+if newUntil > now + maxDur then
+  newUntil = now + maxDur
+end
+redis.call("HSET", KEYS[1], field, newUntil)
+return newUntil
+`)
+
+var redisBanChannelModelScript = redis.NewScript(`
+local field = ARGV[1]
+local now = tonumber(ARGV[2])
+local base = tonumber(ARGV[3])
+local minUntil = tonumber(ARGV[4])
+local maxDur = tonumber(ARGV[5])
+local streak = tonumber(redis.call("HGET", KEYS[2], field) or "0") + 1
+if streak > 20 then
+  streak = 20
+end
+redis.call("HSET", KEYS[2], field, streak)
+local start = now
+local cur = redis.call("HGET", KEYS[1], field)
+if cur and tonumber(cur) > now then
+  start = tonumber(cur)
+end
+local newUntil = start + base * streak
+if minUntil > 0 and minUntil > newUntil then
+  newUntil = minUntil
+end
+if newUntil > now + maxDur then
+  newUntil = now + maxDur
+end
+redis.call("HSET", KEYS[1], field, newUntil)
+return newUntil
+`)
+
+var redisProbePendingScript = redis.NewScript(`
+if redis.call("HEXISTS", KEYS[1], ARGV[1]) == 0 then
+  return 0
+end
+local claim = redis.call("HGET", KEYS[2], ARGV[1])
+if claim then
+  if tonumber(ARGV[2]) > tonumber(claim) then
+    redis.call("HDEL", KEYS[2], ARGV[1])
+  else
+    return 0
+  end
+end
+return 1
+`)
+
+var redisTryClaimProbeScript = redis.NewScript(`
+if redis.call("HEXISTS", KEYS[1], ARGV[1]) == 0 then
+  return 0
+end
+local now = tonumber(ARGV[2])
+local claim = redis.call("HGET", KEYS[2], ARGV[1])
+if claim and now <= tonumber(claim) then
+  return 0
+end
+redis.call("HSET", KEYS[2], ARGV[1], now + tonumber(ARGV[3]))
+return 1
+`)
+
+var redisSweepBansScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local bans = redis.call("HGETALL", KEYS[1])
+for i = 1, #bans, 2 do
+  if now > tonumber(bans[i+1]) then
+    redis.call("HDEL", KEYS[1], bans[i])
+    redis.call("HSETNX", KEYS[2], bans[i], now)
+    redis.call("HDEL", KEYS[3], bans[i])
+  end
+end
+local modelBans = redis.call("HGETALL", KEYS[4])
+for i = 1, #modelBans, 2 do
+  if now > tonumber(modelBans[i+1]) then
+    redis.call("HDEL", KEYS[4], modelBans[i])
+    redis.call("HDEL", KEYS[5], modelBans[i])
+  end
+end
+return 1
+`)
+
+var redisRestoreBanScript = redis.NewScript(`
+local untilMS = tonumber(ARGV[2])
+local streak = tonumber(ARGV[3])
+local cur = redis.call("HGET", KEYS[1], ARGV[1])
+if (not cur) or untilMS > tonumber(cur) then
+  redis.call("HSET", KEYS[1], ARGV[1], untilMS)
+end
+local curStreak = tonumber(redis.call("HGET", KEYS[2], ARGV[1]) or "0")
+if streak > curStreak then
+  redis.call("HSET", KEYS[2], ARGV[1], streak)
+end
+return 1
+`)
+
+var redisTPMScript = redis.NewScript(`
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", ARGV[1])
+local members = redis.call("ZRANGE", KEYS[1], 0, -1)
+local total = 0
+for _, m in ipairs(members) do
+  local n = tonumber(string.match(m, ":(%d+)$"))
+  if n then
+    total = total + n
+  end
+end
+return total
+`)
+
+var redisAcquireInflightScript = redis.NewScript(`
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", ARGV[1])
+local max = tonumber(ARGV[2])
+if max > 0 and redis.call("ZCARD", KEYS[1]) >= max then
+  return 0
+end
+redis.call("ZADD", KEYS[1], ARGV[4], ARGV[3])
+redis.call("PEXPIRE", KEYS[1], ARGV[5])
+return 1
+`)
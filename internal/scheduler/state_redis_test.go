@@ -0,0 +1,235 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"realms/internal/store"
+)
+
+func newTestRedisState(t *testing.T, mr *miniredis.Miniredis) *RedisState {
+	t.Helper()
+	rs := NewRedisState(RedisStateOptions{Addr: mr.Addr(), KeyPrefix: "test"})
+	t.Cleanup(func() { _ = rs.Close() })
+	return rs
+}
+
+func TestRedisState_BanSharedAcrossReplicas(t *testing.T) {
+	mr := miniredis.RunT(t)
+	a := newTestRedisState(t, mr)
+	b := newTestRedisState(t, mr)
+	now := time.Now()
+
+	// 首次失败仅累计 streak，不封禁。
+	if until := a.BanChannel(1, now, 30*time.Second); !until.Equal(msToTime(now.UnixMilli())) {
+		t.Fatalf("expected first failure not to ban, got until=%v", until)
+	}
+	if b.IsChannelBanned(1, now) {
+		t.Fatalf("expected channel not banned after first failure")
+	}
+	until := a.BanChannel(1, now, 30*time.Second)
+	if !b.IsChannelBanned(1, now) {
+		t.Fatalf("expected ban visible on replica b")
+	}
+	got, ok := b.ChannelBanUntil(1, now)
+	if !ok || got.UnixMilli() != until.UnixMilli() {
+		t.Fatalf("expected ban until=%v, got=%v ok=%v", until, got, ok)
+	}
+	if want := now.Add(60 * time.Second).UnixMilli(); until.UnixMilli() != want {
+		t.Fatalf("expected streak-scaled ban until=%d, got=%d", want, until.UnixMilli())
+	}
+
+	// 过期后由任一副本观测到：解除封禁并标记待探测，探测占用在集群内单飞。
+	later := until.Add(time.Second)
+	if a.IsChannelBanned(1, later) {
+		t.Fatalf("expected ban expired")
+	}
+	if !b.IsChannelProbeDue(1) || !b.IsChannelProbePending(1, later) {
+		t.Fatalf("expected probe due on replica b after expiry")
+	}
+	if !a.TryClaimChannelProbe(1, later, 30*time.Second) {
+		t.Fatalf("expected replica a to claim probe")
+	}
+	if b.TryClaimChannelProbe(1, later, 30*time.Second) {
+		t.Fatalf("expected replica b probe claim to be rejected")
+	}
+	if b.IsChannelProbePending(1, later) {
+		t.Fatalf("expected probe not pending while claimed")
+	}
+	a.ClearChannelBan(1)
+	if b.IsChannelProbeDue(1) {
+		t.Fatalf("expected probe cleared")
+	}
+}
+
+func TestRedisState_CooldownsAndFailScoresShared(t *testing.T) {
+	mr := miniredis.RunT(t)
+	a := newTestRedisState(t, mr)
+	b := newTestRedisState(t, mr)
+	now := time.Now()
+
+	a.SetCredentialCooling("openai_compatible:1", now.Add(time.Minute))
+	a.SetEndpointCooling(11, now.Add(time.Minute))
+	if !b.IsCredentialCooling("openai_compatible:1", now) || !b.IsEndpointCooling(11, now) {
+		t.Fatalf("expected cooldowns visible on replica b")
+	}
+	if b.IsCredentialCooling("openai_compatible:1", now.Add(2*time.Minute)) {
+		t.Fatalf("expected credential cooldown expired")
+	}
+	b.ClearEndpointCooldown(11)
+	if a.IsEndpointCooling(11, now) {
+		t.Fatalf("expected endpoint cooldown cleared")
+	}
+
+	a.RecordChannelResult(1, false)
+	b.RecordChannelResult(1, false)
+	if got := a.ChannelFailScore(1); got != 2 {
+		t.Fatalf("expected shared fail score=2, got=%d", got)
+	}
+	b.ResetChannelFailScore(1)
+	if got := a.ChannelFailScore(1); got != 0 {
+		t.Fatalf("expected fail score reset, got=%d", got)
+	}
+
+	a.RecordRPM("openai_compatible:1", now.Add(-90*time.Second))
+	a.RecordRPM("openai_compatible:1", now.Add(-10*time.Second))
+	b.RecordRPM("openai_compatible:1", now.Add(-5*time.Second))
+	if got := b.RPM("openai_compatible:1", now, time.Minute); got != 2 {
+		t.Fatalf("expected shared rpm=2, got=%d", got)
+	}
+
+	a.SetAffinity(7, 3, now.Add(time.Minute))
+	if ch, ok := b.GetAffinity(7, now); !ok || ch != 3 {
+		t.Fatalf("expected shared affinity=3, got=%d ok=%v", ch, ok)
+	}
+}
+
+func TestRedisState_ChannelModelBanAndRuntime(t *testing.T) {
+	mr := miniredis.RunT(t)
+	a := newTestRedisState(t, mr)
+	b := newTestRedisState(t, mr)
+	now := time.Now()
+
+	minUntil := now.Add(5 * time.Minute)
+	until := a.BanChannelModel(9, now, 30*time.Second, minUntil)
+	if until.UnixMilli() != minUntil.UnixMilli() {
+		t.Fatalf("expected minUntil respected, got=%v", until)
+	}
+	a.RecordChannelModelResult(9, false)
+	rt := b.ChannelModelRuntime(9, now)
+	if rt.BannedUntil == nil || rt.BanStreak != 1 || rt.FailScore != 1 {
+		t.Fatalf("unexpected channel model runtime: %+v", rt)
+	}
+	b.SweepExpiredChannelBans(until.Add(time.Second))
+	if a.IsChannelModelBanned(9, now) {
+		t.Fatalf("expected sweep to clear expired model ban")
+	}
+
+	a.BanChannelImmediate(2, now, 30*time.Second)
+	a.RecordChannelSelection(2)
+	b.RecordChannelSelection(2)
+	crt := b.ChannelRuntime(2, now)
+	if crt.BannedUntil == nil || crt.BanStreak != 1 || crt.Selections != 2 {
+		t.Fatalf("unexpected channel runtime: %+v", crt)
+	}
+}
+
+func TestRedisState_FallsBackToMemoryWhenRedisDown(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rs := newTestRedisState(t, mr)
+	mr.Close()
+	now := time.Now()
+
+	rs.SetCredentialCooling("openai_compatible:1", now.Add(time.Minute))
+	if !rs.IsCredentialCooling("openai_compatible:1", now) {
+		t.Fatalf("expected fallback cooldown while redis is down")
+	}
+	rs.BanChannelImmediate(1, now, 30*time.Second)
+	if !rs.IsChannelBanned(1, now) {
+		t.Fatalf("expected fallback ban while redis is down")
+	}
+}
+
+func TestScheduler_RedisStateBanSkipsChannelOnOtherReplica(t *testing.T) {
+	mr := miniredis.RunT(t)
+	fs := &fakeStore{
+		channels: []store.UpstreamChannel{
+			{ID: 1, Type: store.UpstreamTypeOpenAICompatible, Status: 1, Priority: 10},
+			{ID: 2, Type: store.UpstreamTypeOpenAICompatible, Status: 1, Priority: 0},
+		},
+		endpoints: map[int64][]store.UpstreamEndpoint{
+			1: {
+				{ID: 11, ChannelID: 1, BaseURL: "https://a.example", Status: 1},
+			},
+			2: {
+				{ID: 21, ChannelID: 2, BaseURL: "https://b.example", Status: 1},
+			},
+		},
+		creds: map[int64][]store.OpenAICompatibleCredential{
+			11: {
+				{ID: 111, EndpointID: 11, Status: 1},
+			},
+			21: {
+				{ID: 211, EndpointID: 21, Status: 1},
+			},
+		},
+	}
+	replicaA := NewWithOptions(fs, Options{State: newTestRedisState(t, mr)})
+	replicaB := NewWithOptions(fs, Options{State: newTestRedisState(t, mr)})
+
+	replicaA.state.BanChannelImmediate(1, time.Now(), time.Minute)
+
+	sel, err := replicaB.SelectWithConstraints(context.Background(), 10, "", Constraints{})
+	if err != nil {
+		t.Fatalf("Select err: %v", err)
+	}
+	if sel.ChannelID != 2 {
+		t.Fatalf("expected replica b to skip channel banned by replica a, got=%d", sel.ChannelID)
+	}
+	if rt := replicaA.RuntimeChannelStats(1); rt.BannedUntil == nil {
+		t.Fatalf("expected runtime stats to report shared ban")
+	}
+}
+
+func TestRedisState_ChannelRoutingSnapshot(t *testing.T) {
+	mr := miniredis.RunT(t)
+	a := newTestRedisState(t, mr)
+	b := newTestRedisState(t, mr)
+	now := time.Now()
+
+	a.RecordChannelResult(1, false)
+	a.RecordChannelResult(1, false)
+	a.BanChannelImmediate(2, now.Add(-time.Hour), 30*time.Second)
+	a.BanChannelImmediate(3, now.Add(-time.Hour), 30*time.Second)
+	a.SweepExpiredChannelBans(now)
+	if !a.TryClaimChannelProbe(3, now, time.Minute) {
+		t.Fatalf("expected probe claim on channel 3")
+	}
+
+	snap := b.ChannelRoutingSnapshot([]int64{1, 2, 3, 4}, now)
+	if got := snap.FailScore(1); got != 2 {
+		t.Fatalf("expected fail score=2, got=%d", got)
+	}
+	if !snap.IsProbePending(2) {
+		t.Fatalf("expected channel 2 probe pending")
+	}
+	if snap.IsProbePending(3) {
+		t.Fatalf("expected claimed channel 3 not pending")
+	}
+	if snap.IsProbePending(4) || snap.FailScore(4) != 0 {
+		t.Fatalf("expected channel 4 empty in snapshot")
+	}
+	if !b.ChannelRoutingSnapshot([]int64{3}, now.Add(2*time.Minute)).IsProbePending(3) {
+		t.Fatalf("expected expired claim to be pending again")
+	}
+
+	mr.Close()
+	b.fallback.RecordChannelResult(4, false)
+	snap = b.ChannelRoutingSnapshot([]int64{1, 4}, now)
+	if snap.FailScore(1) != 0 || snap.FailScore(4) != 1 {
+		t.Fatalf("expected whole snapshot from fallback, got=%+v", snap.FailScores)
+	}
+}
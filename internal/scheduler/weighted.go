@@ -0,0 +1,66 @@
+package scheduler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"math"
+)
+
+// effectiveChannelWeight 返回渠道参与加权分流的权重：未配置（<=0）按 1 计。
+func effectiveChannelWeight(weight int) float64 {
+	if weight <= 0 {
+		return 1
+	}
+	return float64(weight)
+}
+
+// weightedKey 计算加权随机排序键（Efraimidis–Spirakis：ln(u)/w，越大越靠前）。
+// u 由 seed 与 id 做哈希得到：seed 为 routeKeyHash 时同一会话结果稳定（加权 rendezvous），
+// seed 为随机值时等价于按权重随机抽样。权重相同时排序与 rendezvousScore64 一致。
+func weightedKey(seed string, id int64, weight int) float64 {
+	score := rendezvousScore64(seed, "channel", id)
+	// 映射到 (0,1)：避免 ln(0)。
+	u := (float64(score>>11) + 0.5) / float64(uint64(1)<<53)
+	return math.Log(u) / effectiveChannelWeight(weight)
+}
+
+// randomWeightSeed 为无 routeKey 的请求生成一次性随机 seed。
+func randomWeightSeed() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// weightedTierOrder 对 [0,n) 中相邻且 sameTier 的连续区间按加权键重新排序；
+// 仅当区间内存在显式配置权重（>0）的渠道时才重排，全部未配置时保持原有顺序。
+func weightedTierOrder(n int, sameTier func(i, j int) bool, weight func(i int) int, id func(i int) int64, seed string, swap func(i, j int)) {
+	for start := 0; start < n; {
+		end := start + 1
+		for end < n && sameTier(start, end) {
+			end++
+		}
+		if end-start > 1 {
+			configured := false
+			for i := start; i < end; i++ {
+				if weight(i) > 0 {
+					configured = true
+					break
+				}
+			}
+			if configured {
+				keys := make([]float64, end-start)
+				for i := start; i < end; i++ {
+					keys[i-start] = weightedKey(seed, id(i), weight(i))
+				}
+				// 区间通常很小，插入排序即可，同时保证 swap 与 keys 同步。
+				for i := 1; i < len(keys); i++ {
+					for j := i; j > 0 && keys[j] > keys[j-1]; j-- {
+						keys[j], keys[j-1] = keys[j-1], keys[j]
+						swap(start+j, start+j-1)
+					}
+				}
+			}
+		}
+		start = end
+	}
+}
@@ -43,22 +43,62 @@ func DeriveBaseURLFromRequest(r *http.Request, trustProxyHeaders bool, trustedPr
 	return scheme + "://" + host
 }
 
-func isTrustedProxyRequest(r *http.Request, trustedProxies []netip.Prefix) bool {
+// ClientIPFromRequest 推断请求的客户端 IP（用于 Token 来源限制等）。
+//
+// 安全约束：
+// - 仅当 trustProxyHeaders=true 且请求来源命中 trustedProxies 时，才信任 X-Forwarded-For / X-Real-IP。
+// - X-Forwarded-For 从右向左跳过可信代理，取第一个不可信地址，避免客户端自行伪造最左侧地址。
+func ClientIPFromRequest(r *http.Request, trustProxyHeaders bool, trustedProxies []netip.Prefix) (netip.Addr, bool) {
 	if r == nil {
-		return false
+		return netip.Addr{}, false
 	}
-	if len(trustedProxies) == 0 {
-		return false
+	remote, ok := remoteAddrIP(r)
+	if !ok {
+		return netip.Addr{}, false
+	}
+	if !trustProxyHeaders || !ipInPrefixes(remote, trustedProxies) {
+		return remote, true
 	}
+
+	if raw := strings.TrimSpace(r.Header.Get("X-Forwarded-For")); raw != "" {
+		parts := strings.Split(raw, ",")
+		var leftmost netip.Addr
+		for i := len(parts) - 1; i >= 0; i-- {
+			ip, err := netip.ParseAddr(strings.TrimSpace(parts[i]))
+			if err != nil {
+				// 链路中出现无法解析的地址时不再继续向左信任。
+				break
+			}
+			ip = ip.Unmap()
+			leftmost = ip
+			if !ipInPrefixes(ip, trustedProxies) {
+				return ip, true
+			}
+		}
+		if leftmost.IsValid() {
+			return leftmost, true
+		}
+	}
+	if ip, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+		return ip.Unmap(), true
+	}
+	return remote, true
+}
+
+func remoteAddrIP(r *http.Request) (netip.Addr, bool) {
 	host, _, err := net.SplitHostPort(strings.TrimSpace(r.RemoteAddr))
 	if err != nil {
 		host = strings.TrimSpace(r.RemoteAddr)
 	}
 	ip, err := netip.ParseAddr(host)
 	if err != nil {
-		return false
+		return netip.Addr{}, false
 	}
-	for _, pfx := range trustedProxies {
+	return ip.Unmap(), true
+}
+
+func ipInPrefixes(ip netip.Addr, prefixes []netip.Prefix) bool {
+	for _, pfx := range prefixes {
 		if pfx.Contains(ip) {
 			return true
 		}
@@ -66,6 +106,20 @@ func isTrustedProxyRequest(r *http.Request, trustedProxies []netip.Prefix) bool
 	return false
 }
 
+func isTrustedProxyRequest(r *http.Request, trustedProxies []netip.Prefix) bool {
+	if r == nil {
+		return false
+	}
+	if len(trustedProxies) == 0 {
+		return false
+	}
+	ip, ok := remoteAddrIP(r)
+	if !ok {
+		return false
+	}
+	return ipInPrefixes(ip, trustedProxies)
+}
+
 func forwardedProto(raw string) (string, bool) {
 	v := strings.ToLower(firstForwardedToken(raw))
 	switch v {
@@ -40,3 +40,39 @@ func TestDeriveBaseURLFromRequest_RejectsInvalidForwardedValues(t *testing.T) {
 		t.Fatalf("expected invalid forwarded values to be ignored, got %q", got)
 	}
 }
+
+func TestClientIPFromRequest_IgnoresForwardedWhenUntrusted(t *testing.T) {
+	r := httptest.NewRequest("GET", "http://example.com/", nil)
+	r.RemoteAddr = "203.0.113.10:1234"
+	r.Header.Set("X-Forwarded-For", "198.51.100.7")
+
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+	got, ok := ClientIPFromRequest(r, true, trusted)
+	if !ok || got.String() != "203.0.113.10" {
+		t.Fatalf("expected remote addr, got %v ok=%v", got, ok)
+	}
+}
+
+func TestClientIPFromRequest_SkipsTrustedProxiesFromRight(t *testing.T) {
+	r := httptest.NewRequest("GET", "http://example.com/", nil)
+	r.RemoteAddr = "10.1.2.3:1234"
+	r.Header.Set("X-Forwarded-For", "1.1.1.1, 2001:db8::1, 10.9.9.9")
+
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+	got, ok := ClientIPFromRequest(r, true, trusted)
+	if !ok || got.String() != "2001:db8::1" {
+		t.Fatalf("expected first untrusted hop from the right, got %v ok=%v", got, ok)
+	}
+}
+
+func TestClientIPFromRequest_FallsBackToRealIP(t *testing.T) {
+	r := httptest.NewRequest("GET", "http://example.com/", nil)
+	r.RemoteAddr = "[::1]:1234"
+	r.Header.Set("X-Real-IP", "198.51.100.7")
+
+	trusted := []netip.Prefix{netip.MustParsePrefix("::1/128")}
+	got, ok := ClientIPFromRequest(r, true, trusted)
+	if !ok || got.String() != "198.51.100.7" {
+		t.Fatalf("expected X-Real-IP, got %v ok=%v", got, ok)
+	}
+}
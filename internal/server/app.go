@@ -31,6 +31,7 @@ import (
 	"realms/internal/errorpassthrough"
 	"realms/internal/proxylog"
 	"realms/internal/quota"
+	"realms/internal/ratelimit"
 	"realms/internal/scheduler"
 	"realms/internal/store"
 	"realms/internal/tickets"
@@ -54,6 +55,8 @@ type App struct {
 	exec          *upstream.Executor
 	openai        *openaiapi.Handler
 	sched         *scheduler.Scheduler
+	schedState    *scheduler.RedisState
+	rateLimit     *ratelimit.RedisBackend
 	version       version.BuildInfo
 	ticketStorage *tickets.Storage
 	engine        *gin.Engine
@@ -87,15 +90,96 @@ func newConcurrencyManager(cfg config.Config) (*concurrency.Manager, error) {
 	return mgr, nil
 }
 
+// newSchedulerState 在 redis.scheduler_state 开启时返回共享的 Redis 运行态；否则返回 nil（使用进程内状态）。
+func newSchedulerState(cfg config.Config) (*scheduler.RedisState, error) {
+	if !cfg.Redis.SchedulerState || strings.TrimSpace(cfg.Redis.Addr) == "" {
+		return nil, nil
+	}
+	rs := scheduler.NewRedisState(scheduler.RedisStateOptions{
+		Addr:      cfg.Redis.Addr,
+		Password:  cfg.Redis.Password,
+		DB:        cfg.Redis.DB,
+		KeyPrefix: cfg.Redis.KeyPrefix,
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := rs.PingContext(ctx); err != nil {
+		_ = rs.Close()
+		return nil, fmt.Errorf("ping redis (scheduler_state): %w", err)
+	}
+	return rs, nil
+}
+
+// newRateLimitBackend 在配置 Redis 时返回多副本共享的限流计数；否则返回进程内计数。
+func newRateLimitBackend(cfg config.Config) (ratelimit.Backend, *ratelimit.RedisBackend, error) {
+	if strings.TrimSpace(cfg.Redis.Addr) == "" {
+		return ratelimit.NewMemoryBackend(), nil, nil
+	}
+	rb := ratelimit.NewRedisBackend(ratelimit.RedisBackendOptions{
+		Addr:      cfg.Redis.Addr,
+		Password:  cfg.Redis.Password,
+		DB:        cfg.Redis.DB,
+		KeyPrefix: cfg.Redis.KeyPrefix,
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := rb.PingContext(ctx); err != nil {
+		_ = rb.Close()
+		return nil, nil, fmt.Errorf("ping redis (rate_limit): %w", err)
+	}
+	return rb, rb, nil
+}
+
+// scheduleLocationFromSettings 返回解释渠道可用时间窗所用的时区：admin_time_zone 设置 > 配置默认值 > Asia/Shanghai。
+func scheduleLocationFromSettings(st *store.Store, defaultName string) func(ctx context.Context) *time.Location {
+	return func(ctx context.Context) *time.Location {
+		var names []string
+		if v, ok, err := st.GetStringAppSetting(ctx, store.SettingAdminTimeZone); err == nil && ok {
+			names = append(names, v)
+		}
+		names = append(names, defaultName, "Asia/Shanghai")
+		for _, n := range names {
+			n = strings.TrimSpace(n)
+			if n == "" {
+				continue
+			}
+			if strings.EqualFold(n, "utc") {
+				return time.UTC
+			}
+			if loc, err := time.LoadLocation(n); err == nil {
+				return loc
+			}
+		}
+		// 最小化镜像可能缺少 tzdata：回退到固定 +08:00。
+		return time.FixedZone("CST", 8*60*60)
+	}
+}
+
 func NewApp(opts AppOptions) (*App, error) {
 	st := store.New(opts.DB)
 	st.SetDialect(store.Dialect(opts.Config.DB.Driver))
 	st.SetAppSettingsDefaults(opts.Config.AppSettingsDefaults)
 
-	sched := scheduler.NewWithOptions(st, scheduler.Options{
+	schedState, err := newSchedulerState(opts.Config)
+	if err != nil {
+		return nil, err
+	}
+	schedOpts := scheduler.Options{
 		DisableCodexOAuth: false,
-	})
+		LatencyRouting: scheduler.LatencyRoutingOptions{
+			Enabled:          opts.Config.Gateway.LatencyRouting,
+			ExplorationRatio: opts.Config.Gateway.LatencyExplorationRatio,
+		},
+		BackgroundChannelProbe: opts.Config.Gateway.ChannelProbe,
+		RoutingTrace:           opts.Config.Gateway.RoutingTrace,
+	}
+	if schedState != nil {
+		schedOpts.State = schedState
+	}
+	sched := scheduler.NewWithOptions(st, schedOpts)
 	sched.SetGroupPointerStore(st)
+	sched.SetCooldownPersistStore(st)
+	sched.SetScheduleLocation(scheduleLocationFromSettings(st, opts.Config.AppSettingsDefaults.AdminTimeZone))
 	exec := upstream.NewExecutor(st, opts.Config)
 
 	sessionCookieName := SessionCookieName
@@ -111,7 +195,15 @@ func NewApp(opts AppOptions) (*App, error) {
 		Enable: opts.Config.Env == "dev" && opts.Config.Debug.ProxyLog.Enable,
 		Dir:    opts.Config.Debug.ProxyLog.Dir,
 	})
-	qp := quotaProvider(st, opts.Config)
+	rateLimitBackend, rateLimitRedis, err := newRateLimitBackend(opts.Config)
+	if err != nil {
+		if schedState != nil {
+			_ = schedState.Close()
+		}
+		return nil, err
+	}
+	rateLimiter := ratelimit.New(rateLimitBackend)
+	qp := quota.NewRateLimitProvider(quotaProvider(st, opts.Config), rateLimiter)
 	compactGateway := upstream.NewCompactGatewayClient(
 		opts.Config.CompactGateway.BaseURL,
 		opts.Config.CompactGateway.GatewayKey,
@@ -128,10 +220,17 @@ func NewApp(opts AppOptions) (*App, error) {
 		MaxFailoverSwitches:      opts.Config.Gateway.MaxFailoverSwitches,
 		UserMaxConcurrency:       opts.Config.Gateway.UserMaxConcurrency,
 		CredentialMaxConcurrency: opts.Config.Gateway.CredentialMaxConcurrency,
+		BatchConcurrency:         opts.Config.Gateway.BatchConcurrency,
 	})
 
 	concMgr, err := newConcurrencyManager(opts.Config)
 	if err != nil {
+		if schedState != nil {
+			_ = schedState.Close()
+		}
+		if rateLimitRedis != nil {
+			_ = rateLimitRedis.Close()
+		}
 		return nil, err
 	}
 	if concMgr != nil {
@@ -150,6 +249,8 @@ func NewApp(opts AppOptions) (*App, error) {
 		exec:          exec,
 		openai:        openaiHandler,
 		sched:         sched,
+		schedState:    schedState,
+		rateLimit:     rateLimitRedis,
 		version:       opts.Version,
 		ticketStorage: ticketStorage,
 	}
@@ -181,6 +282,9 @@ func NewApp(opts AppOptions) (*App, error) {
 	router.SetRouter(engine, router.Options{
 		Store:                           st,
 		AdminAPIKeyHash:                 adminAPIKeyHash,
+		TrustProxyHeaders:               opts.Config.Security.TrustProxyHeaders,
+		TrustedProxies:                  opts.Config.Security.TrustedProxyPrefixes(),
+		RateLimiter:                     rateLimiter,
 		EmailVerificationEnabledDefault: opts.Config.EmailVerif.Enable,
 		AdminTimeZoneDefault:            opts.Config.AppSettingsDefaults.AdminTimeZone,
 		BillingDefault:                  opts.Config.Billing,
@@ -275,8 +379,8 @@ func loadEmbeddedDist(rootFS fs.FS, distDir string) (fs.FS, []byte) {
 
 func quotaProvider(st *store.Store, cfg config.Config) quota.Provider {
 	reserveTTL := 2*time.Minute + 30*time.Second
-	business := quota.NewHybridProvider(st, reserveTTL, cfg.Billing.EnablePayAsYouGo)
-	free := quota.NewFreeProvider(st, reserveTTL)
+	business := quota.NewTokenLimitProvider(st, quota.NewHybridProvider(st, reserveTTL, cfg.Billing.EnablePayAsYouGo))
+	free := quota.NewTokenLimitProvider(st, quota.NewFreeProvider(st, reserveTTL))
 	return quota.NewFeatureProvider(st, business, free)
 }
 
@@ -288,6 +392,12 @@ func (a *App) Close() error {
 	if a == nil {
 		return nil
 	}
+	if a.schedState != nil {
+		_ = a.schedState.Close()
+	}
+	if a.rateLimit != nil {
+		_ = a.rateLimit.Close()
+	}
 	if a.concurrency != nil {
 		return a.concurrency.Close()
 	}
@@ -375,9 +485,15 @@ func (a *App) handleFaviconICO(w http.ResponseWriter, r *http.Request) {
 }
 
 func (a *App) bootstrap() error {
+	a.restoreSchedulerCooldowns()
 	go a.usageCleanupLoop()
+	go a.schedulerCooldownCleanupLoop()
+	go a.channelProbeLoop()
+	go a.channelCanaryLoop()
+	go a.balanceReconcileLoop()
 	go a.codexBalanceRefreshLoop()
 	go a.ticketAttachmentsCleanupLoop()
+	go a.batchWorkerLoop()
 	return nil
 }
 
@@ -391,6 +507,44 @@ func (a *App) usageCleanupLoop() {
 	}
 }
 
+// restoreSchedulerCooldowns 启动时恢复持久化的渠道封禁与 credential 冷却，避免重启后立刻打到已知故障的上游。
+func (a *App) restoreSchedulerCooldowns() {
+	if a.sched == nil || a.store == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, _ = a.store.DeleteExpiredSchedulerCooldowns(ctx, time.Now())
+	_, _ = a.sched.RestorePersistedCooldowns(ctx)
+}
+
+func (a *App) schedulerCooldownCleanupLoop() {
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		_, _ = a.store.DeleteExpiredSchedulerCooldowns(ctx, time.Now())
+		cancel()
+	}
+}
+
+// batchWorkerLoop 周期性认领并执行 Batch API 批任务；每轮把可认领的批任务依次跑完。
+func (a *App) batchWorkerLoop() {
+	if a.openai == nil {
+		return
+	}
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		for {
+			claimed, err := a.openai.ProcessNextBatch(context.Background())
+			if err != nil || !claimed {
+				break
+			}
+		}
+	}
+}
+
 func (a *App) codexBalanceRefreshLoop() {
 	if a.store == nil {
 		return
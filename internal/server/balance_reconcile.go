@@ -0,0 +1,41 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// balanceReconcileInterval 为余额流水对账的执行间隔。
+const balanceReconcileInterval = time.Hour
+
+// balanceReconcileLoop 周期性核对余额流水合计与 user_balances/organization_balances 是否一致；不一致时仅告警，不自动修正。
+func (a *App) balanceReconcileLoop() {
+	if a.store == nil {
+		return
+	}
+	ticker := time.NewTicker(balanceReconcileInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		a.reconcileBalanceLedger(ctx)
+		cancel()
+	}
+}
+
+func (a *App) reconcileBalanceLedger(ctx context.Context) {
+	mismatches, err := a.store.ReconcileBalanceLedger(ctx)
+	if err != nil {
+		slog.Warn("余额流水对账失败", "err", err)
+		return
+	}
+	for _, m := range mismatches {
+		slog.Warn("余额与流水合计不一致",
+			"user_id", m.UserID,
+			"org_id", m.OrgID,
+			"balance_usd", m.BalanceUSD.String(),
+			"ledger_usd", m.LedgerUSD.String(),
+			"diff_usd", m.DiffUSD().String(),
+		)
+	}
+}
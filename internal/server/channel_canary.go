@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"realms/internal/store"
+)
+
+// channelCanaryCheckInterval 为灰度成员自动回滚的检查间隔。
+const channelCanaryCheckInterval = time.Minute
+
+// channelCanaryLoop 周期性检查灰度中的渠道成员：自灰度开始以来经本组路由的错误率超过阈值、且明显差于同组其他渠道时自动回滚。
+// 多实例部署时每个成员每个检查周期只由认领成功的实例评估。
+func (a *App) channelCanaryLoop() {
+	if a.store == nil || a.cfg.Gateway.CanaryRollbackErrorRate <= 0 {
+		return
+	}
+	ticker := time.NewTicker(channelCanaryCheckInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		a.rollbackFailingCanaries(context.Background(), time.Now())
+	}
+}
+
+func (a *App) rollbackFailingCanaries(ctx context.Context, now time.Time) {
+	canaries, err := a.store.ListActiveChannelGroupCanaries(ctx)
+	if err != nil {
+		return
+	}
+	minRequests := int64(a.cfg.Gateway.CanaryRollbackMinRequests)
+	for _, c := range canaries {
+		if c.StartedAt.IsZero() {
+			continue
+		}
+		// 允许半个周期的 ticker 抖动；其他实例本周期已检查过时跳过。
+		claimed, err := a.store.ClaimChannelGroupCanaryCheck(ctx, c.MemberID, now.UTC(), now.UTC().Add(-channelCanaryCheckInterval/2))
+		if err != nil || !claimed {
+			continue
+		}
+		members, err := a.store.ListChannelGroupMembers(ctx, c.ParentGroupID)
+		if err != nil {
+			continue
+		}
+		ids := []int64{c.ChannelID}
+		var siblingIDs []int64
+		for _, m := range members {
+			if m.MemberID == c.MemberID || m.MemberChannelID == nil || m.CanaryStatus != "" {
+				continue
+			}
+			siblingIDs = append(siblingIDs, *m.MemberChannelID)
+			ids = append(ids, *m.MemberChannelID)
+		}
+		stats, err := a.store.GetChannelHealthStatsRange(ctx, c.ParentGroupID, ids, c.StartedAt.UTC(), now.UTC())
+		if err != nil {
+			continue
+		}
+		st := stats[c.ChannelID]
+		if st.Requests < minRequests || st.ErrorRate() <= a.cfg.Gateway.CanaryRollbackErrorRate {
+			continue
+		}
+		var siblings store.ChannelHealthStats
+		for _, id := range siblingIDs {
+			siblings = siblings.Merge(stats[id])
+		}
+		// 同组其他渠道错误率不低于灰度渠道时多为上游整体故障，不归咎于灰度渠道。
+		if siblings.Requests >= minRequests && siblings.ErrorRate() >= st.ErrorRate() {
+			continue
+		}
+		if err := a.store.RollbackChannelGroupMemberCanary(ctx, c.ParentGroupID, c.MemberID); err != nil {
+			continue
+		}
+		slog.Warn("灰度渠道错误率超过阈值，已自动回滚",
+			"channel_id", c.ChannelID,
+			"member_id", c.MemberID,
+			"group_id", c.ParentGroupID,
+			"requests", st.Requests,
+			"error_rate", st.ErrorRate(),
+			"sibling_error_rate", siblings.ErrorRate(),
+		)
+	}
+}
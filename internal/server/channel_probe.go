@@ -0,0 +1,158 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"realms/internal/store"
+)
+
+const (
+	// channelProbeTimeout 为单次合成探测的超时；需小于调度器的探测认领 TTL，避免探测未结束就被重复认领。
+	channelProbeTimeout = 20 * time.Second
+	// channelProbeConcurrency 为一轮扫描中并发探测的渠道数上限。
+	channelProbeConcurrency = 4
+)
+
+// channelProbeLoop 周期性对到期待探测的渠道发起合成请求，使封禁渠道的恢复不依赖用户流量。
+func (a *App) channelProbeLoop() {
+	if a.sched == nil || a.exec == nil || a.store == nil || !a.cfg.Gateway.ChannelProbe {
+		return
+	}
+	ticker := time.NewTicker(time.Duration(a.cfg.Gateway.ChannelProbeIntervalMS) * time.Millisecond)
+	defer ticker.Stop()
+	for range ticker.C {
+		a.probeDueChannels(context.Background())
+	}
+}
+
+// probeDueChannels 以有限并发探测到期渠道；整轮耗时不超过 channelProbeTimeout，
+// 截止时尚未开始的渠道留待下一轮（ticker 在本轮结束前不会重入）。
+func (a *App) probeDueChannels(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, channelProbeTimeout)
+	defer cancel()
+	chs, err := a.sched.DueChannelProbes(ctx, time.Now())
+	if err != nil {
+		return
+	}
+	sem := make(chan struct{}, channelProbeConcurrency)
+	var wg sync.WaitGroup
+dispatch:
+	for _, ch := range chs {
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case sem <- struct{}{}:
+		}
+		wg.Add(1)
+		go func(ch store.UpstreamChannel) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			a.probeChannel(ctx, ch)
+		}(ch)
+	}
+	wg.Wait()
+}
+
+func (a *App) probeChannel(ctx context.Context, ch store.UpstreamChannel) {
+	path, body, ok := channelProbeRequest(ch.Type, a.channelProbeModel(ctx, ch))
+	if !ok {
+		// 无法构造合成请求（未配置 test_model 且无启用的模型绑定）：不主动解封，
+		// 渠道保持待探测，待配置补全后由后续扫描探测。
+		return
+	}
+	sel, err := a.sched.SelectChannelProbe(ctx, ch.ID)
+	if err != nil {
+		return
+	}
+
+	probeCtx, cancel := context.WithTimeout(ctx, channelProbeTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(probeCtx, http.MethodPost, "http://realms.internal"+path, bytes.NewReader(body))
+	if err != nil {
+		a.sched.ReportChannelProbe(sel, false)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := a.exec.Do(probeCtx, sel, req, body)
+	success := err == nil && resp != nil && resp.StatusCode >= 200 && resp.StatusCode < 300
+	if resp != nil && resp.Body != nil {
+		_ = resp.Body.Close()
+	}
+	a.sched.ReportChannelProbe(sel, success)
+}
+
+// channelProbeModel 返回探测使用的上游模型：优先 test_model，否则取第一个启用的模型绑定。
+func (a *App) channelProbeModel(ctx context.Context, ch store.UpstreamChannel) string {
+	if ch.TestModel != nil && strings.TrimSpace(*ch.TestModel) != "" {
+		return strings.TrimSpace(*ch.TestModel)
+	}
+	bindings, err := a.store.ListChannelModelsByChannelID(ctx, ch.ID)
+	if err != nil {
+		return ""
+	}
+	for _, b := range bindings {
+		if b.Status != 1 {
+			continue
+		}
+		if m := strings.TrimSpace(b.UpstreamModel); m != "" {
+			return m
+		}
+		if m := strings.TrimSpace(b.PublicID); m != "" {
+			return m
+		}
+	}
+	return ""
+}
+
+// channelProbeRequest 按渠道类型构造最小的合成请求（单 token 输出）。
+func channelProbeRequest(channelType string, model string) (string, []byte, bool) {
+	if model == "" {
+		return "", nil, false
+	}
+	var (
+		path    string
+		payload map[string]any
+	)
+	switch channelType {
+	case store.UpstreamTypeOpenAICompatible, store.UpstreamTypeAzureOpenAI:
+		path = "/v1/chat/completions"
+		payload = map[string]any{
+			"model":      model,
+			"messages":   []map[string]any{{"role": "user", "content": "ping"}},
+			"max_tokens": 1,
+		}
+	case store.UpstreamTypeAnthropic, store.UpstreamTypeBedrock, store.UpstreamTypeVertex:
+		path = "/v1/messages"
+		payload = map[string]any{
+			"model":      model,
+			"messages":   []map[string]any{{"role": "user", "content": "ping"}},
+			"max_tokens": 1,
+		}
+	case store.UpstreamTypeGemini:
+		path = "/v1beta/models/" + url.PathEscape(model) + ":generateContent"
+		payload = map[string]any{
+			"contents":         []map[string]any{{"role": "user", "parts": []map[string]any{{"text": "ping"}}}},
+			"generationConfig": map[string]any{"maxOutputTokens": 1},
+		}
+	case store.UpstreamTypeCodexOAuth:
+		path = "/v1/responses"
+		payload = map[string]any{
+			"model": model,
+			"input": "ping",
+		}
+	default:
+		return "", nil, false
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", nil, false
+	}
+	return path, body, true
+}
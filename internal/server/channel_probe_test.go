@@ -0,0 +1,50 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+
+	"realms/internal/store"
+)
+
+func TestChannelProbeRequest_ByChannelType(t *testing.T) {
+	cases := []struct {
+		typ  string
+		path string
+	}{
+		{store.UpstreamTypeOpenAICompatible, "/v1/chat/completions"},
+		{store.UpstreamTypeAzureOpenAI, "/v1/chat/completions"},
+		{store.UpstreamTypeAnthropic, "/v1/messages"},
+		{store.UpstreamTypeBedrock, "/v1/messages"},
+		{store.UpstreamTypeVertex, "/v1/messages"},
+		{store.UpstreamTypeGemini, "/v1beta/models/gemini-2.0-flash:generateContent"},
+		{store.UpstreamTypeCodexOAuth, "/v1/responses"},
+	}
+	for _, tc := range cases {
+		model := "m-test"
+		if tc.typ == store.UpstreamTypeGemini {
+			model = "gemini-2.0-flash"
+		}
+		path, body, ok := channelProbeRequest(tc.typ, model)
+		if !ok {
+			t.Fatalf("%s: expected probe request", tc.typ)
+		}
+		if path != tc.path {
+			t.Fatalf("%s: unexpected path=%q", tc.typ, path)
+		}
+		var payload map[string]any
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Fatalf("%s: invalid body: %v", tc.typ, err)
+		}
+		if tc.typ != store.UpstreamTypeGemini && payload["model"] != model {
+			t.Fatalf("%s: unexpected model in body: %s", tc.typ, string(body))
+		}
+	}
+
+	if _, _, ok := channelProbeRequest(store.UpstreamTypeOpenAICompatible, ""); ok {
+		t.Fatalf("expected no probe request without model")
+	}
+	if _, _, ok := channelProbeRequest("unknown", "m-test"); ok {
+		t.Fatalf("expected no probe request for unknown channel type")
+	}
+}
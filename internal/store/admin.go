@@ -185,6 +185,13 @@ WHERE user_id=?
 	if _, err := tx.ExecContext(ctx, `DELETE FROM user_balances WHERE user_id=?`, userID); err != nil {
 		return fmt.Errorf("删除 user_balances 失败: %w", err)
 	}
+	// 组织余额流水属于组织账目，不随成员删除。
+	if _, err := tx.ExecContext(ctx, `DELETE FROM balance_transactions WHERE user_id=? AND org_id IS NULL`, userID); err != nil {
+		return fmt.Errorf("删除 balance_transactions 失败: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM organization_members WHERE user_id=?`, userID); err != nil {
+		return fmt.Errorf("删除 organization_members 失败: %w", err)
+	}
 	if _, err := tx.ExecContext(ctx, `DELETE FROM user_tokens WHERE user_id=?`, userID); err != nil {
 		return fmt.Errorf("删除 user_tokens 失败: %w", err)
 	}
@@ -12,7 +12,7 @@ import (
 	"github.com/shopspring/decimal"
 )
 
-const AdminConfigExportVersion = 9
+const AdminConfigExportVersion = 10
 
 type AdminConfigExport struct {
 	Version    int       `json:"version"`
@@ -43,8 +43,9 @@ type AdminConfigChannelGroupMember struct {
 	MemberChannelType *string `json:"member_channel_type,omitempty"`
 	MemberChannelName *string `json:"member_channel_name,omitempty"`
 
-	Priority  int  `json:"priority"`
-	Promotion bool `json:"promotion"`
+	Priority  int             `json:"priority"`
+	Promotion bool            `json:"promotion"`
+	Schedule  json.RawMessage `json:"schedule,omitempty"`
 }
 
 type AdminConfigUpstreamChannel struct {
@@ -74,15 +75,17 @@ type AdminConfigUpstreamChannel struct {
 	ModelSuffixPreserve   json.RawMessage `json:"model_suffix_preserve,omitempty"`
 	RequestBodyBlacklist  json.RawMessage `json:"request_body_blacklist,omitempty"`
 	RequestBodyWhitelist  json.RawMessage `json:"request_body_whitelist,omitempty"`
+	Schedule              json.RawMessage `json:"schedule,omitempty"`
 }
 
 type AdminConfigUpstreamEndpoint struct {
 	ChannelType string `json:"channel_type"`
 	ChannelName string `json:"channel_name"`
 
-	BaseURL  string `json:"base_url"`
-	Status   int    `json:"status"`
-	Priority int    `json:"priority"`
+	BaseURL    string  `json:"base_url"`
+	APIVersion *string `json:"api_version,omitempty"`
+	Status     int     `json:"status"`
+	Priority   int     `json:"priority"`
 }
 
 type AdminConfigManagedModel struct {
@@ -99,6 +102,7 @@ type AdminConfigManagedModel struct {
 	PriorityOutputUSDPer1M     *decimal.Decimal                `json:"priority_output_usd_per_1m,omitempty"`
 	PriorityCacheInputUSDPer1M *decimal.Decimal                `json:"priority_cache_input_usd_per_1m,omitempty"`
 	HighContextPricing         *ManagedModelHighContextPricing `json:"high_context_pricing,omitempty"`
+	BatchDiscount              *decimal.Decimal                `json:"batch_discount,omitempty"`
 	Status                     int                             `json:"status"`
 }
 
@@ -155,6 +159,9 @@ func (s *Store) ExportAdminConfig(ctx context.Context) (AdminConfigExport, error
 				Priority:    m.Priority,
 				Promotion:   m.Promotion,
 			}
+			if strings.TrimSpace(m.Schedule) != "" {
+				row.Schedule = json.RawMessage(strings.TrimSpace(m.Schedule))
+			}
 			if m.MemberGroupName != nil && strings.TrimSpace(*m.MemberGroupName) != "" {
 				v := strings.TrimSpace(*m.MemberGroupName)
 				row.MemberGroup = &v
@@ -229,6 +236,10 @@ func (s *Store) ExportAdminConfig(ctx context.Context) (AdminConfigExport, error
 		if strings.TrimSpace(ch.RequestBodyWhitelist) != "" {
 			requestBodyWhitelist = json.RawMessage(strings.TrimSpace(ch.RequestBodyWhitelist))
 		}
+		var schedule json.RawMessage
+		if strings.TrimSpace(ch.Schedule) != "" {
+			schedule = json.RawMessage(strings.TrimSpace(ch.Schedule))
+		}
 		fastMode := ch.FastMode
 		out.UpstreamChannels = append(out.UpstreamChannels, AdminConfigUpstreamChannel{
 			Type:                  strings.TrimSpace(ch.Type),
@@ -254,6 +265,7 @@ func (s *Store) ExportAdminConfig(ctx context.Context) (AdminConfigExport, error
 			ModelSuffixPreserve:   modelSuffixPreserve,
 			RequestBodyBlacklist:  requestBodyBlacklist,
 			RequestBodyWhitelist:  requestBodyWhitelist,
+			Schedule:              schedule,
 		})
 
 		ep, err := s.GetUpstreamEndpointByChannelID(ctx, ch.ID)
@@ -262,6 +274,7 @@ func (s *Store) ExportAdminConfig(ctx context.Context) (AdminConfigExport, error
 				ChannelType: strings.TrimSpace(ch.Type),
 				ChannelName: strings.TrimSpace(ch.Name),
 				BaseURL:     strings.TrimSpace(ep.BaseURL),
+				APIVersion:  trimNullableString(ep.APIVersion),
 				Status:      ep.Status,
 				Priority:    ep.Priority,
 			})
@@ -302,6 +315,7 @@ func (s *Store) ExportAdminConfig(ctx context.Context) (AdminConfigExport, error
 			PriorityOutputUSDPer1M:     m.PriorityOutputUSDPer1M,
 			PriorityCacheInputUSDPer1M: m.PriorityCacheInputUSDPer1M,
 			HighContextPricing:         m.HighContextPricing,
+			BatchDiscount:              m.BatchDiscount,
 			Status:                     m.Status,
 		})
 	}
@@ -581,6 +595,10 @@ ON CONFLICT(name) DO UPDATE SET
 			}
 			requestBodyWhitelist = normalized
 		}
+		schedule, err := NormalizeChannelSchedule(string(ch.Schedule))
+		if err != nil {
+			return 0, fmt.Errorf("upstream_channels[%s] schedule 无效: %w", name, err)
+		}
 
 		id, err := findChannelID(typ, name)
 		if err != nil {
@@ -777,6 +795,13 @@ WHERE id=?
 			}
 		}
 
+		// v10 起导出包含 schedule；旧版本导入不覆盖已有时间窗。
+		if in.Version >= 10 {
+			if _, err := tx.ExecContext(ctx, `UPDATE upstream_channels SET schedule=? WHERE id=?`, nullableString(&schedule), id); err != nil {
+				return 0, fmt.Errorf("更新 upstream_channel schedule 失败: %w", err)
+			}
+		}
+
 		key := channelKey(typ, name)
 		channelIDByKey[key] = id
 		return id, nil
@@ -803,20 +828,22 @@ WHERE id=?
 	}
 
 	stmtUpsertEndpoint := `
-INSERT INTO upstream_endpoints(channel_id, base_url, status, priority, created_at, updated_at)
-VALUES(?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+INSERT INTO upstream_endpoints(channel_id, base_url, api_version, status, priority, created_at, updated_at)
+VALUES(?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 ON DUPLICATE KEY UPDATE
   base_url=VALUES(base_url),
+  api_version=VALUES(api_version),
   status=VALUES(status),
   priority=VALUES(priority),
   updated_at=CURRENT_TIMESTAMP
 `
 	if s.dialect == DialectSQLite {
 		stmtUpsertEndpoint = `
-INSERT INTO upstream_endpoints(channel_id, base_url, status, priority, created_at, updated_at)
-VALUES(?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+INSERT INTO upstream_endpoints(channel_id, base_url, api_version, status, priority, created_at, updated_at)
+VALUES(?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 ON CONFLICT(channel_id) DO UPDATE SET
   base_url=excluded.base_url,
+  api_version=excluded.api_version,
   status=excluded.status,
   priority=excluded.priority,
   updated_at=CURRENT_TIMESTAMP
@@ -835,14 +862,14 @@ ON CONFLICT(channel_id) DO UPDATE SET
 			continue
 		}
 		p := ep.Priority
-		if _, err := tx.ExecContext(ctx, stmtUpsertEndpoint, channelID, baseURL, ep.Status, p); err != nil {
+		if _, err := tx.ExecContext(ctx, stmtUpsertEndpoint, channelID, baseURL, nullableString(trimNullableString(ep.APIVersion)), ep.Status, p); err != nil {
 			return AdminConfigImportReport{}, fmt.Errorf("导入 upstream_endpoints 失败: %w", err)
 		}
 	}
 
 	stmtUpsertManagedModel := `
-INSERT INTO managed_models(public_id, group_name, upstream_model, owned_by, input_usd_per_1m, output_usd_per_1m, cache_input_usd_per_1m, cache_output_usd_per_1m, priority_pricing_enabled, priority_input_usd_per_1m, priority_output_usd_per_1m, priority_cache_input_usd_per_1m, high_context_pricing_json, status, created_at)
-VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+INSERT INTO managed_models(public_id, group_name, upstream_model, owned_by, input_usd_per_1m, output_usd_per_1m, cache_input_usd_per_1m, cache_output_usd_per_1m, priority_pricing_enabled, priority_input_usd_per_1m, priority_output_usd_per_1m, priority_cache_input_usd_per_1m, high_context_pricing_json, batch_discount, status, created_at)
+VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
 ON DUPLICATE KEY UPDATE
   group_name=VALUES(group_name),
   upstream_model=VALUES(upstream_model),
@@ -856,12 +883,13 @@ ON DUPLICATE KEY UPDATE
   priority_output_usd_per_1m=VALUES(priority_output_usd_per_1m),
   priority_cache_input_usd_per_1m=VALUES(priority_cache_input_usd_per_1m),
   high_context_pricing_json=VALUES(high_context_pricing_json),
+  batch_discount=VALUES(batch_discount),
   status=VALUES(status)
 `
 	if s.dialect == DialectSQLite {
 		stmtUpsertManagedModel = `
-INSERT INTO managed_models(public_id, group_name, upstream_model, owned_by, input_usd_per_1m, output_usd_per_1m, cache_input_usd_per_1m, cache_output_usd_per_1m, priority_pricing_enabled, priority_input_usd_per_1m, priority_output_usd_per_1m, priority_cache_input_usd_per_1m, high_context_pricing_json, status, created_at)
-VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+INSERT INTO managed_models(public_id, group_name, upstream_model, owned_by, input_usd_per_1m, output_usd_per_1m, cache_input_usd_per_1m, cache_output_usd_per_1m, priority_pricing_enabled, priority_input_usd_per_1m, priority_output_usd_per_1m, priority_cache_input_usd_per_1m, high_context_pricing_json, batch_discount, status, created_at)
+VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
 ON CONFLICT(public_id) DO UPDATE SET
   group_name=excluded.group_name,
   upstream_model=excluded.upstream_model,
@@ -875,6 +903,7 @@ ON CONFLICT(public_id) DO UPDATE SET
   priority_output_usd_per_1m=excluded.priority_output_usd_per_1m,
   priority_cache_input_usd_per_1m=excluded.priority_cache_input_usd_per_1m,
   high_context_pricing_json=excluded.high_context_pricing_json,
+  batch_discount=excluded.batch_discount,
   status=excluded.status
 `
 	}
@@ -907,6 +936,10 @@ ON CONFLICT(public_id) DO UPDATE SET
 		if err != nil {
 			return AdminConfigImportReport{}, fmt.Errorf("managed_models[%s] 高上下文定价不合法", publicID)
 		}
+		batchDiscount, err := normalizeManagedModelBatchDiscount(m.BatchDiscount)
+		if err != nil {
+			return AdminConfigImportReport{}, fmt.Errorf("managed_models[%s] %w", publicID, err)
+		}
 		tmp := ManagedModel{
 			InputUSDPer1M:              inUSD,
 			OutputUSDPer1M:             outUSD,
@@ -938,7 +971,7 @@ ON CONFLICT(public_id) DO UPDATE SET
 			priorityEnabled = 1
 		}
 		groupName := normalizeManagedModelGroupName(m.GroupName)
-		if _, err := tx.ExecContext(ctx, stmtUpsertManagedModel, publicID, groupName, m.UpstreamModel, m.OwnedBy, inUSD, outUSD, cacheInUSD, cacheOutUSD, priorityEnabled, priorityInUSD, priorityOutUSD, priorityCacheInUSD, highContextPricingJSON, status); err != nil {
+		if _, err := tx.ExecContext(ctx, stmtUpsertManagedModel, publicID, groupName, m.UpstreamModel, m.OwnedBy, inUSD, outUSD, cacheInUSD, cacheOutUSD, priorityEnabled, priorityInUSD, priorityOutUSD, priorityCacheInUSD, highContextPricingJSON, batchDiscount, status); err != nil {
 			return AdminConfigImportReport{}, fmt.Errorf("导入 managed_models 失败: %w", err)
 		}
 	}
@@ -1037,6 +1070,10 @@ ON CONFLICT(parent_group_id, member_channel_id) DO UPDATE SET
 		if m.Promotion {
 			p = 1
 		}
+		schedule, err := NormalizeChannelSchedule(string(m.Schedule))
+		if err != nil {
+			return AdminConfigImportReport{}, fmt.Errorf("channel_group_members[%s] schedule 无效: %w", parentName, err)
+		}
 
 		switch {
 		case m.MemberGroup != nil && strings.TrimSpace(*m.MemberGroup) != "":
@@ -1048,6 +1085,11 @@ ON CONFLICT(parent_group_id, member_channel_id) DO UPDATE SET
 			if _, err := tx.ExecContext(ctx, stmtUpsertMemberGroup, parentID, childID, m.Priority, p); err != nil {
 				return AdminConfigImportReport{}, fmt.Errorf("导入 channel_group_members(group) 失败: %w", err)
 			}
+			if in.Version >= 10 {
+				if _, err := tx.ExecContext(ctx, `UPDATE channel_group_members SET schedule=? WHERE parent_group_id=? AND member_group_id=?`, nullableString(&schedule), parentID, childID); err != nil {
+					return AdminConfigImportReport{}, fmt.Errorf("导入 channel_group_members(group) schedule 失败: %w", err)
+				}
+			}
 		case m.MemberChannelType != nil && m.MemberChannelName != nil && strings.TrimSpace(*m.MemberChannelType) != "" && strings.TrimSpace(*m.MemberChannelName) != "":
 			chType := strings.TrimSpace(*m.MemberChannelType)
 			chName := strings.TrimSpace(*m.MemberChannelName)
@@ -1058,6 +1100,11 @@ ON CONFLICT(parent_group_id, member_channel_id) DO UPDATE SET
 			if _, err := tx.ExecContext(ctx, stmtUpsertMemberChannel, parentID, channelID, m.Priority, p); err != nil {
 				return AdminConfigImportReport{}, fmt.Errorf("导入 channel_group_members(channel) 失败: %w", err)
 			}
+			if in.Version >= 10 {
+				if _, err := tx.ExecContext(ctx, `UPDATE channel_group_members SET schedule=? WHERE parent_group_id=? AND member_channel_id=?`, nullableString(&schedule), parentID, channelID); err != nil {
+					return AdminConfigImportReport{}, fmt.Errorf("导入 channel_group_members(channel) schedule 失败: %w", err)
+				}
+			}
 		default:
 			continue
 		}
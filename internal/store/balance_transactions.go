@@ -0,0 +1,236 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// 余额流水类型（balance_transactions.type）。
+const (
+	// BalanceTxOpening 为启用流水前已存在余额的期初记录。
+	BalanceTxOpening = "opening"
+	BalanceTxTopup   = "topup"
+	// BalanceTxRedemption 为兑换码入账。
+	BalanceTxRedemption = "redemption"
+	// BalanceTxAdminCredit 为管理员手动入账。
+	BalanceTxAdminCredit = "admin_credit"
+	// BalanceTxUsageReserve 为按量计费请求的预扣。
+	BalanceTxUsageReserve = "usage_reserve"
+	// BalanceTxUsageDebit 为结算时预留不足的补扣。
+	BalanceTxUsageDebit = "usage_debit"
+	// BalanceTxUsageRefund 为结算时预留多余部分的返还。
+	BalanceTxUsageRefund = "usage_refund"
+	// BalanceTxUsageVoid 为请求失败作废时的全额返还。
+	BalanceTxUsageVoid = "usage_void"
+	// BalanceTxUsageExpire 为预留过期清理时的全额返还。
+	BalanceTxUsageExpire = "usage_expire"
+)
+
+// BalanceTransaction 为一条余额流水：AmountUSD 入账为正、扣减为负，BalanceAfterUSD 为变动后的余额。
+// 流水只追加不修改；同一用户全部流水的 AmountUSD 之和应等于 user_balances.usd。
+// OrgID 非空时为组织余额流水（UserID 为触发变动的成员或操作人），合计应等于 organization_balances.usd。
+type BalanceTransaction struct {
+	ID               int64
+	UserID           int64
+	OrgID            *int64
+	Type             string
+	AmountUSD        decimal.Decimal
+	BalanceAfterUSD  decimal.Decimal
+	UsageEventID     *int64
+	TopupOrderID     *int64
+	RedemptionCodeID *int64
+	ActorUserID      *int64
+	Note             *string
+	CreatedAt        time.Time
+}
+
+// balanceTxInput 为写入流水所需的字段；余额变动后在同一事务内调用 recordBalanceTransactionTx。
+type balanceTxInput struct {
+	UserID           int64
+	OrgID            *int64
+	Type             string
+	AmountUSD        decimal.Decimal
+	UsageEventID     *int64
+	TopupOrderID     *int64
+	RedemptionCodeID *int64
+	ActorUserID      *int64
+	Note             *string
+}
+
+// recordBalanceTransactionTx 在余额已变动的事务内追加一条流水，返回变动后的余额。
+func recordBalanceTransactionTx(ctx context.Context, tx *sql.Tx, in balanceTxInput) (decimal.Decimal, error) {
+	var after decimal.Decimal
+	table, keyCol, key := balanceAccount(in)
+	if err := tx.QueryRowContext(ctx, `SELECT usd FROM `+table+` WHERE `+keyCol+`=?`, key).Scan(&after); err != nil {
+		return decimal.Zero, fmt.Errorf("查询余额失败: %w", err)
+	}
+	after = after.Truncate(USDScale)
+	var note any
+	if in.Note != nil && strings.TrimSpace(*in.Note) != "" {
+		v := strings.TrimSpace(*in.Note)
+		if len(v) > 255 {
+			v = v[:255]
+		}
+		note = v
+	}
+	if _, err := tx.ExecContext(ctx, `
+INSERT INTO balance_transactions(
+  user_id, org_id, type, amount_usd, balance_after_usd, usage_event_id, topup_order_id, redemption_code_id, actor_user_id, note, created_at
+) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+`, in.UserID, in.OrgID, in.Type, in.AmountUSD.Truncate(USDScale), after, in.UsageEventID, in.TopupOrderID, in.RedemptionCodeID, in.ActorUserID, note); err != nil {
+		return decimal.Zero, fmt.Errorf("写入余额流水失败: %w", err)
+	}
+	return after, nil
+}
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
+
+const balanceTransactionColumns = `id, user_id, org_id, type, amount_usd, balance_after_usd, usage_event_id, topup_order_id, redemption_code_id, actor_user_id, note, created_at`
+
+func scanBalanceTransaction(row interface{ Scan(dest ...any) error }) (BalanceTransaction, error) {
+	var t BalanceTransaction
+	var orgID, usageEventID, topupOrderID, redemptionCodeID, actorUserID sql.NullInt64
+	var note sql.NullString
+	if err := row.Scan(&t.ID, &t.UserID, &orgID, &t.Type, &t.AmountUSD, &t.BalanceAfterUSD, &usageEventID, &topupOrderID, &redemptionCodeID, &actorUserID, &note, &t.CreatedAt); err != nil {
+		return BalanceTransaction{}, err
+	}
+	t.AmountUSD = t.AmountUSD.Truncate(USDScale)
+	t.BalanceAfterUSD = t.BalanceAfterUSD.Truncate(USDScale)
+	if orgID.Valid {
+		t.OrgID = int64Ptr(orgID.Int64)
+	}
+	if usageEventID.Valid {
+		t.UsageEventID = int64Ptr(usageEventID.Int64)
+	}
+	if topupOrderID.Valid {
+		t.TopupOrderID = int64Ptr(topupOrderID.Int64)
+	}
+	if redemptionCodeID.Valid {
+		t.RedemptionCodeID = int64Ptr(redemptionCodeID.Int64)
+	}
+	if actorUserID.Valid {
+		t.ActorUserID = int64Ptr(actorUserID.Int64)
+	}
+	if note.Valid {
+		v := note.String
+		t.Note = &v
+	}
+	return t, nil
+}
+
+// ListBalanceTransactionsByUser 按 id 倒序分页返回用户个人余额的流水（不含组织余额流水）；beforeID 非空时仅返回 id 更小的记录。
+func (s *Store) ListBalanceTransactionsByUser(ctx context.Context, userID int64, limit int, beforeID *int64) ([]BalanceTransaction, error) {
+	if userID <= 0 {
+		return nil, errors.New("user_id 不能为空")
+	}
+	return s.listBalanceTransactions(ctx, `user_id=? AND org_id IS NULL`, userID, limit, beforeID)
+}
+
+// ListBalanceTransactionsByOrg 按 id 倒序分页返回组织余额的流水。
+func (s *Store) ListBalanceTransactionsByOrg(ctx context.Context, orgID int64, limit int, beforeID *int64) ([]BalanceTransaction, error) {
+	if orgID <= 0 {
+		return nil, errors.New("org_id 不能为空")
+	}
+	return s.listBalanceTransactions(ctx, `org_id=?`, orgID, limit, beforeID)
+}
+
+func (s *Store) listBalanceTransactions(ctx context.Context, where string, key int64, limit int, beforeID *int64) ([]BalanceTransaction, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > 500 {
+		limit = 500
+	}
+	q := `SELECT ` + balanceTransactionColumns + ` FROM balance_transactions WHERE ` + where
+	args := []any{key}
+	if beforeID != nil && *beforeID > 0 {
+		q += ` AND id<?`
+		args = append(args, *beforeID)
+	}
+	q += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询余额流水失败: %w", err)
+	}
+	defer rows.Close()
+
+	var out []BalanceTransaction
+	for rows.Next() {
+		t, err := scanBalanceTransaction(rows)
+		if err != nil {
+			return nil, fmt.Errorf("扫描余额流水失败: %w", err)
+		}
+		out = append(out, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历余额流水失败: %w", err)
+	}
+	return out, nil
+}
+
+// BalanceLedgerMismatch 为对账发现的不一致：流水合计与 user_balances.usd 不相等。
+// OrgID 非 0 时为组织余额（organization_balances）的不一致，此时 UserID 为 0。
+type BalanceLedgerMismatch struct {
+	UserID     int64
+	OrgID      int64
+	BalanceUSD decimal.Decimal
+	LedgerUSD  decimal.Decimal
+}
+
+// DiffUSD 返回余额减去流水合计的差额。
+func (m BalanceLedgerMismatch) DiffUSD() decimal.Decimal {
+	return m.BalanceUSD.Sub(m.LedgerUSD)
+}
+
+// ReconcileBalanceLedger 核对每个用户/组织的流水合计是否等于余额（含仅有流水而无余额行的账户）。
+func (s *Store) ReconcileBalanceLedger(ctx context.Context) ([]BalanceLedgerMismatch, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT b.user_id, 0, b.usd, COALESCE(t.total, 0)
+FROM user_balances b
+LEFT JOIN (SELECT user_id, SUM(amount_usd) AS total FROM balance_transactions WHERE org_id IS NULL GROUP BY user_id) t ON t.user_id=b.user_id
+UNION ALL
+SELECT t.user_id, 0, 0, t.total
+FROM (SELECT user_id, SUM(amount_usd) AS total FROM balance_transactions WHERE org_id IS NULL GROUP BY user_id) t
+WHERE NOT EXISTS (SELECT 1 FROM user_balances b WHERE b.user_id=t.user_id)
+UNION ALL
+SELECT 0, b.org_id, b.usd, COALESCE(t.total, 0)
+FROM organization_balances b
+LEFT JOIN (SELECT org_id, SUM(amount_usd) AS total FROM balance_transactions WHERE org_id IS NOT NULL GROUP BY org_id) t ON t.org_id=b.org_id
+UNION ALL
+SELECT 0, t.org_id, 0, t.total
+FROM (SELECT org_id, SUM(amount_usd) AS total FROM balance_transactions WHERE org_id IS NOT NULL GROUP BY org_id) t
+WHERE NOT EXISTS (SELECT 1 FROM organization_balances b WHERE b.org_id=t.org_id)
+`)
+	if err != nil {
+		return nil, fmt.Errorf("余额对账查询失败: %w", err)
+	}
+	defer rows.Close()
+
+	var out []BalanceLedgerMismatch
+	for rows.Next() {
+		var m BalanceLedgerMismatch
+		if err := rows.Scan(&m.UserID, &m.OrgID, &m.BalanceUSD, &m.LedgerUSD); err != nil {
+			return nil, fmt.Errorf("扫描余额对账结果失败: %w", err)
+		}
+		// SQLite 的 SUM 以浮点累加，按 USD 精度取整后再比较。
+		m.BalanceUSD = m.BalanceUSD.Round(USDScale)
+		m.LedgerUSD = m.LedgerUSD.Round(USDScale)
+		if !m.BalanceUSD.Equal(m.LedgerUSD) {
+			out = append(out, m)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历余额对账结果失败: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,593 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	BatchFilePurposeBatch       = "batch"
+	BatchFilePurposeBatchOutput = "batch_output"
+
+	BatchStatusValidating = "validating"
+	BatchStatusInProgress = "in_progress"
+	BatchStatusFinalizing = "finalizing"
+	BatchStatusCompleted  = "completed"
+	BatchStatusFailed     = "failed"
+	BatchStatusExpired    = "expired"
+	BatchStatusCancelling = "cancelling"
+	BatchStatusCancelled  = "cancelled"
+)
+
+// BatchFile 为 /v1/files 上传或批任务产出的 JSONL 文件元信息（内容单独按需读取）。
+type BatchFile struct {
+	ID        int64
+	PublicID  string
+	UserID    int64
+	TokenID   int64
+	Purpose   string
+	Filename  string
+	Bytes     int64
+	CreatedAt time.Time
+}
+
+type Batch struct {
+	ID               int64
+	PublicID         string
+	UserID           int64
+	TokenID          int64
+	Endpoint         string
+	CompletionWindow string
+	InputFileID      string
+	OutputFileID     *string
+	ErrorFileID      *string
+	Status           string
+	MetadataJSON     *string
+	ErrorsJSON       *string
+	RequestTotal     int
+	RequestCompleted int
+	RequestFailed    int
+	LeaseUntil       *time.Time
+	InProgressAt     *time.Time
+	FinalizingAt     *time.Time
+	CompletedAt      *time.Time
+	FailedAt         *time.Time
+	ExpiredAt        *time.Time
+	CancellingAt     *time.Time
+	CancelledAt      *time.Time
+	ExpiresAt        time.Time
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+// BatchRequestResult 为批任务单行的执行结果；批任务结束写出文件后即清理。
+type BatchRequestResult struct {
+	BatchID      int64
+	LineIndex    int
+	CustomID     string
+	RequestID    string
+	StatusCode   int
+	Body         []byte
+	ErrorCode    *string
+	ErrorMessage *string
+}
+
+type BatchCreate struct {
+	PublicID         string
+	UserID           int64
+	TokenID          int64
+	Endpoint         string
+	CompletionWindow string
+	InputFileID      string
+	MetadataJSON     *string
+	ExpiresAt        time.Time
+}
+
+type BatchFinalize struct {
+	ID           int64
+	Status       string
+	OutputFileID *string
+	ErrorFileID  *string
+	ErrorsJSON   *string
+	Now          time.Time
+}
+
+// IsTerminalBatchStatus 判断批任务是否已结束（不会再被 worker 认领）。
+func IsTerminalBatchStatus(status string) bool {
+	switch status {
+	case BatchStatusCompleted, BatchStatusFailed, BatchStatusExpired, BatchStatusCancelled:
+		return true
+	default:
+		return false
+	}
+}
+
+const batchFileSelectColumns = `id, public_id, user_id, token_id, purpose, filename, bytes, created_at`
+
+const batchSelectColumns = `id, public_id, user_id, token_id, endpoint, completion_window, input_file_id, output_file_id, error_file_id,
+       status, metadata_json, errors_json, request_total, request_completed, request_failed, lease_until,
+       in_progress_at, finalizing_at, completed_at, failed_at, expired_at, cancelling_at, cancelled_at,
+       expires_at, created_at, updated_at`
+
+type batchScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanBatchFileRow(scanner batchScanner) (BatchFile, error) {
+	var f BatchFile
+	if err := scanner.Scan(&f.ID, &f.PublicID, &f.UserID, &f.TokenID, &f.Purpose, &f.Filename, &f.Bytes, &f.CreatedAt); err != nil {
+		return BatchFile{}, err
+	}
+	return f, nil
+}
+
+func scanBatchRow(scanner batchScanner) (Batch, error) {
+	var b Batch
+	var outputFileID, errorFileID, metadataJSON, errorsJSON sql.NullString
+	var leaseUntil, inProgressAt, finalizingAt, completedAt, failedAt, expiredAt, cancellingAt, cancelledAt sql.NullTime
+	if err := scanner.Scan(
+		&b.ID, &b.PublicID, &b.UserID, &b.TokenID, &b.Endpoint, &b.CompletionWindow, &b.InputFileID, &outputFileID, &errorFileID,
+		&b.Status, &metadataJSON, &errorsJSON, &b.RequestTotal, &b.RequestCompleted, &b.RequestFailed, &leaseUntil,
+		&inProgressAt, &finalizingAt, &completedAt, &failedAt, &expiredAt, &cancellingAt, &cancelledAt,
+		&b.ExpiresAt, &b.CreatedAt, &b.UpdatedAt,
+	); err != nil {
+		return Batch{}, err
+	}
+	b.OutputFileID = nullStringPtr(outputFileID)
+	b.ErrorFileID = nullStringPtr(errorFileID)
+	b.MetadataJSON = nullStringPtr(metadataJSON)
+	b.ErrorsJSON = nullStringPtr(errorsJSON)
+	b.LeaseUntil = nullTimePtr(leaseUntil)
+	b.InProgressAt = nullTimePtr(inProgressAt)
+	b.FinalizingAt = nullTimePtr(finalizingAt)
+	b.CompletedAt = nullTimePtr(completedAt)
+	b.FailedAt = nullTimePtr(failedAt)
+	b.ExpiredAt = nullTimePtr(expiredAt)
+	b.CancellingAt = nullTimePtr(cancellingAt)
+	b.CancelledAt = nullTimePtr(cancelledAt)
+	return b, nil
+}
+
+func nullStringPtr(v sql.NullString) *string {
+	if !v.Valid {
+		return nil
+	}
+	s := v.String
+	return &s
+}
+
+func nullTimePtr(v sql.NullTime) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	t := v.Time
+	return &t
+}
+
+func (s *Store) CreateBatchFile(ctx context.Context, f BatchFile, content []byte) (BatchFile, error) {
+	if s == nil || s.db == nil {
+		return BatchFile{}, errors.New("store 未初始化")
+	}
+	f.PublicID = strings.TrimSpace(f.PublicID)
+	f.Purpose = strings.TrimSpace(f.Purpose)
+	f.Filename = strings.TrimSpace(f.Filename)
+	if f.PublicID == "" || f.UserID <= 0 {
+		return BatchFile{}, errors.New("文件参数不合法")
+	}
+	if content == nil {
+		content = []byte{}
+	}
+	f.Bytes = int64(len(content))
+	res, err := s.db.ExecContext(ctx, `
+INSERT INTO batch_files(public_id, user_id, token_id, purpose, filename, bytes, content, created_at)
+VALUES(?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+`, f.PublicID, f.UserID, f.TokenID, f.Purpose, f.Filename, f.Bytes, content)
+	if err != nil {
+		return BatchFile{}, fmt.Errorf("创建 batch_file 失败: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return BatchFile{}, fmt.Errorf("获取 batch_file id 失败: %w", err)
+	}
+	out, ok, err := s.GetBatchFile(ctx, f.UserID, f.PublicID)
+	if err != nil {
+		return BatchFile{}, err
+	}
+	if !ok {
+		return BatchFile{}, fmt.Errorf("batch_file %d 写入后不可见", id)
+	}
+	return out, nil
+}
+
+func (s *Store) GetBatchFile(ctx context.Context, userID int64, publicID string) (BatchFile, bool, error) {
+	if s == nil || s.db == nil {
+		return BatchFile{}, false, errors.New("store 未初始化")
+	}
+	publicID = strings.TrimSpace(publicID)
+	if userID <= 0 || publicID == "" {
+		return BatchFile{}, false, nil
+	}
+	f, err := scanBatchFileRow(s.db.QueryRowContext(ctx, `
+SELECT `+batchFileSelectColumns+`
+FROM batch_files
+WHERE public_id=? AND user_id=?
+`, publicID, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return BatchFile{}, false, nil
+		}
+		return BatchFile{}, false, fmt.Errorf("查询 batch_file 失败: %w", err)
+	}
+	return f, true, nil
+}
+
+func (s *Store) GetBatchFileContent(ctx context.Context, userID int64, publicID string) ([]byte, bool, error) {
+	if s == nil || s.db == nil {
+		return nil, false, errors.New("store 未初始化")
+	}
+	publicID = strings.TrimSpace(publicID)
+	if userID <= 0 || publicID == "" {
+		return nil, false, nil
+	}
+	var content []byte
+	err := s.db.QueryRowContext(ctx, `SELECT content FROM batch_files WHERE public_id=? AND user_id=?`, publicID, userID).Scan(&content)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("读取 batch_file 内容失败: %w", err)
+	}
+	return content, true, nil
+}
+
+// ListBatchFiles 按创建倒序列出用户文件；purpose 为空表示不过滤。
+func (s *Store) ListBatchFiles(ctx context.Context, userID int64, purpose string, limit int) ([]BatchFile, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("store 未初始化")
+	}
+	if userID <= 0 {
+		return nil, nil
+	}
+	if limit <= 0 || limit > 10000 {
+		limit = 10000
+	}
+	q := `SELECT ` + batchFileSelectColumns + ` FROM batch_files WHERE user_id=?`
+	args := []any{userID}
+	if purpose = strings.TrimSpace(purpose); purpose != "" {
+		q += ` AND purpose=?`
+		args = append(args, purpose)
+	}
+	q += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, limit)
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询 batch_files 失败: %w", err)
+	}
+	defer rows.Close()
+	var out []BatchFile
+	for rows.Next() {
+		f, err := scanBatchFileRow(rows)
+		if err != nil {
+			return nil, fmt.Errorf("扫描 batch_files 失败: %w", err)
+		}
+		out = append(out, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历 batch_files 失败: %w", err)
+	}
+	return out, nil
+}
+
+func (s *Store) CreateBatch(ctx context.Context, in BatchCreate) (Batch, error) {
+	if s == nil || s.db == nil {
+		return Batch{}, errors.New("store 未初始化")
+	}
+	in.PublicID = strings.TrimSpace(in.PublicID)
+	in.InputFileID = strings.TrimSpace(in.InputFileID)
+	if in.PublicID == "" || in.UserID <= 0 || in.InputFileID == "" {
+		return Batch{}, errors.New("批任务参数不合法")
+	}
+	if _, err := s.db.ExecContext(ctx, `
+INSERT INTO batches(public_id, user_id, token_id, endpoint, completion_window, input_file_id, status, metadata_json, expires_at, created_at, updated_at)
+VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+`, in.PublicID, in.UserID, in.TokenID, in.Endpoint, in.CompletionWindow, in.InputFileID, BatchStatusValidating, in.MetadataJSON, in.ExpiresAt); err != nil {
+		return Batch{}, fmt.Errorf("创建 batch 失败: %w", err)
+	}
+	b, ok, err := s.GetBatch(ctx, in.UserID, in.PublicID)
+	if err != nil {
+		return Batch{}, err
+	}
+	if !ok {
+		return Batch{}, errors.New("batch 写入后不可见")
+	}
+	return b, nil
+}
+
+func (s *Store) GetBatch(ctx context.Context, userID int64, publicID string) (Batch, bool, error) {
+	if s == nil || s.db == nil {
+		return Batch{}, false, errors.New("store 未初始化")
+	}
+	publicID = strings.TrimSpace(publicID)
+	if userID <= 0 || publicID == "" {
+		return Batch{}, false, nil
+	}
+	b, err := scanBatchRow(s.db.QueryRowContext(ctx, `
+SELECT `+batchSelectColumns+`
+FROM batches
+WHERE public_id=? AND user_id=?
+`, publicID, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Batch{}, false, nil
+		}
+		return Batch{}, false, fmt.Errorf("查询 batch 失败: %w", err)
+	}
+	return b, true, nil
+}
+
+func (s *Store) GetBatchByID(ctx context.Context, id int64) (Batch, error) {
+	if s == nil || s.db == nil {
+		return Batch{}, errors.New("store 未初始化")
+	}
+	b, err := scanBatchRow(s.db.QueryRowContext(ctx, `SELECT `+batchSelectColumns+` FROM batches WHERE id=?`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Batch{}, sql.ErrNoRows
+		}
+		return Batch{}, fmt.Errorf("查询 batch 失败: %w", err)
+	}
+	return b, nil
+}
+
+// ListBatches 按创建倒序分页列出用户批任务；after 为上一页最后一个 batch 的 public_id。
+func (s *Store) ListBatches(ctx context.Context, userID int64, after string, limit int) ([]Batch, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("store 未初始化")
+	}
+	if userID <= 0 {
+		return nil, nil
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	q := `SELECT ` + batchSelectColumns + ` FROM batches WHERE user_id=?`
+	args := []any{userID}
+	if after = strings.TrimSpace(after); after != "" {
+		q += ` AND id < (SELECT id FROM batches WHERE public_id=? AND user_id=?)`
+		args = append(args, after, userID)
+	}
+	q += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, limit)
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询 batches 失败: %w", err)
+	}
+	defer rows.Close()
+	var out []Batch
+	for rows.Next() {
+		b, err := scanBatchRow(rows)
+		if err != nil {
+			return nil, fmt.Errorf("扫描 batches 失败: %w", err)
+		}
+		out = append(out, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历 batches 失败: %w", err)
+	}
+	return out, nil
+}
+
+// CancelBatch 将未结束的批任务置为 cancelling，由 worker 停止派发并写出已完成部分。
+// 返回值 changed=false 表示当前状态不允许取消（或已在取消中）。
+func (s *Store) CancelBatch(ctx context.Context, userID int64, publicID string, now time.Time) (Batch, bool, error) {
+	if s == nil || s.db == nil {
+		return Batch{}, false, errors.New("store 未初始化")
+	}
+	res, err := s.db.ExecContext(ctx, `
+UPDATE batches
+SET status=?, cancelling_at=?, updated_at=CURRENT_TIMESTAMP
+WHERE public_id=? AND user_id=? AND status IN (?, ?)
+`, BatchStatusCancelling, now, strings.TrimSpace(publicID), userID, BatchStatusValidating, BatchStatusInProgress)
+	if err != nil {
+		return Batch{}, false, fmt.Errorf("取消 batch 失败: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	b, ok, err := s.GetBatch(ctx, userID, publicID)
+	if err != nil {
+		return Batch{}, false, err
+	}
+	if !ok {
+		return Batch{}, false, sql.ErrNoRows
+	}
+	return b, n > 0, nil
+}
+
+// ClaimNextBatch 认领一个未结束且租约已过期的批任务；多实例部署下依赖条件 UPDATE 保证同一时刻只有一个 worker 持有。
+func (s *Store) ClaimNextBatch(ctx context.Context, now time.Time, leaseUntil time.Time) (Batch, bool, error) {
+	if s == nil || s.db == nil {
+		return Batch{}, false, errors.New("store 未初始化")
+	}
+	rows, err := s.db.QueryContext(ctx, `
+SELECT id
+FROM batches
+WHERE status IN (?, ?, ?, ?) AND (lease_until IS NULL OR lease_until < ?)
+ORDER BY id ASC
+LIMIT 8
+`, BatchStatusValidating, BatchStatusInProgress, BatchStatusFinalizing, BatchStatusCancelling, now)
+	if err != nil {
+		return Batch{}, false, fmt.Errorf("查询待执行 batch 失败: %w", err)
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return Batch{}, false, fmt.Errorf("扫描待执行 batch 失败: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return Batch{}, false, fmt.Errorf("遍历待执行 batch 失败: %w", err)
+	}
+	rows.Close()
+
+	for _, id := range ids {
+		res, err := s.db.ExecContext(ctx, `
+UPDATE batches
+SET lease_until=?, updated_at=CURRENT_TIMESTAMP
+WHERE id=? AND (lease_until IS NULL OR lease_until < ?) AND status IN (?, ?, ?, ?)
+`, leaseUntil, id, now, BatchStatusValidating, BatchStatusInProgress, BatchStatusFinalizing, BatchStatusCancelling)
+		if err != nil {
+			return Batch{}, false, fmt.Errorf("认领 batch 失败: %w", err)
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			continue
+		}
+		b, err := s.GetBatchByID(ctx, id)
+		if err != nil {
+			return Batch{}, false, err
+		}
+		return b, true, nil
+	}
+	return Batch{}, false, nil
+}
+
+func (s *Store) RenewBatchLease(ctx context.Context, id int64, leaseUntil time.Time) error {
+	if _, err := s.db.ExecContext(ctx, `UPDATE batches SET lease_until=? WHERE id=?`, leaseUntil, id); err != nil {
+		return fmt.Errorf("续期 batch 租约失败: %w", err)
+	}
+	return nil
+}
+
+// MarkBatchInProgress 在输入文件校验通过后切换到 in_progress；若期间已被取消则不改变状态。
+func (s *Store) MarkBatchInProgress(ctx context.Context, id int64, total int, now time.Time) error {
+	if _, err := s.db.ExecContext(ctx, `
+UPDATE batches
+SET status=?, request_total=?, in_progress_at=?, updated_at=CURRENT_TIMESTAMP
+WHERE id=? AND status=?
+`, BatchStatusInProgress, total, now, id, BatchStatusValidating); err != nil {
+		return fmt.Errorf("更新 batch 状态失败: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) MarkBatchFinalizing(ctx context.Context, id int64, now time.Time) error {
+	if _, err := s.db.ExecContext(ctx, `
+UPDATE batches
+SET status=?, finalizing_at=?, updated_at=CURRENT_TIMESTAMP
+WHERE id=? AND status=?
+`, BatchStatusFinalizing, now, id, BatchStatusInProgress); err != nil {
+		return fmt.Errorf("更新 batch 状态失败: %w", err)
+	}
+	return nil
+}
+
+// RecordBatchRequestResult 记录单行结果并累加计数；同一行重复写入会被忽略（用于 worker 重启后续跑）。
+func (s *Store) RecordBatchRequestResult(ctx context.Context, in BatchRequestResult) error {
+	if s == nil || s.db == nil {
+		return errors.New("store 未初始化")
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开始事务失败: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx, insertIgnoreVerb(s.dialect)+` INTO batch_request_results(batch_id, line_index, custom_id, request_id, status_code, body, error_code, error_message, created_at)
+VALUES(?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+`, in.BatchID, in.LineIndex, in.CustomID, in.RequestID, in.StatusCode, in.Body, in.ErrorCode, in.ErrorMessage)
+	if err != nil {
+		return fmt.Errorf("写入 batch_request_results 失败: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		counter := "request_failed"
+		if in.ErrorCode == nil && in.StatusCode >= 200 && in.StatusCode < 300 {
+			counter = "request_completed"
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE batches SET `+counter+`=`+counter+`+1, updated_at=CURRENT_TIMESTAMP WHERE id=?`, in.BatchID); err != nil {
+			return fmt.Errorf("更新 batch 计数失败: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %w", err)
+	}
+	return nil
+}
+
+// ListBatchRequestResults 按行号顺序返回批任务已记录的结果。
+func (s *Store) ListBatchRequestResults(ctx context.Context, batchID int64) ([]BatchRequestResult, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("store 未初始化")
+	}
+	rows, err := s.db.QueryContext(ctx, `
+SELECT batch_id, line_index, custom_id, request_id, status_code, body, error_code, error_message
+FROM batch_request_results
+WHERE batch_id=?
+ORDER BY line_index ASC
+`, batchID)
+	if err != nil {
+		return nil, fmt.Errorf("查询 batch_request_results 失败: %w", err)
+	}
+	defer rows.Close()
+	var out []BatchRequestResult
+	for rows.Next() {
+		var r BatchRequestResult
+		var errorCode, errorMessage sql.NullString
+		if err := rows.Scan(&r.BatchID, &r.LineIndex, &r.CustomID, &r.RequestID, &r.StatusCode, &r.Body, &errorCode, &errorMessage); err != nil {
+			return nil, fmt.Errorf("扫描 batch_request_results 失败: %w", err)
+		}
+		r.ErrorCode = nullStringPtr(errorCode)
+		r.ErrorMessage = nullStringPtr(errorMessage)
+		out = append(out, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历 batch_request_results 失败: %w", err)
+	}
+	return out, nil
+}
+
+// FinalizeBatch 写入终态与产出文件，释放租约并清理逐行结果（结果已落入输出/错误文件）。
+func (s *Store) FinalizeBatch(ctx context.Context, in BatchFinalize) error {
+	if s == nil || s.db == nil {
+		return errors.New("store 未初始化")
+	}
+	var tsColumn string
+	switch in.Status {
+	case BatchStatusCompleted:
+		tsColumn = "completed_at"
+	case BatchStatusFailed:
+		tsColumn = "failed_at"
+	case BatchStatusExpired:
+		tsColumn = "expired_at"
+	case BatchStatusCancelled:
+		tsColumn = "cancelled_at"
+	default:
+		return fmt.Errorf("batch 终态不合法: %s", in.Status)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开始事务失败: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `
+UPDATE batches
+SET status=?, `+tsColumn+`=?, output_file_id=?, error_file_id=?, errors_json=?, lease_until=NULL, updated_at=CURRENT_TIMESTAMP
+WHERE id=?
+`, in.Status, in.Now, in.OutputFileID, in.ErrorFileID, in.ErrorsJSON, in.ID); err != nil {
+		return fmt.Errorf("更新 batch 终态失败: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM batch_request_results WHERE batch_id=?`, in.ID); err != nil {
+		return fmt.Errorf("清理 batch_request_results 失败: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,265 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 渠道组成员灰度状态。
+const (
+	ChannelGroupCanaryActive     = "active"
+	ChannelGroupCanaryRolledBack = "rolled_back"
+)
+
+// ChannelGroupCanary 为一个处于灰度中的渠道成员。
+type ChannelGroupCanary struct {
+	MemberID      int64
+	ParentGroupID int64
+	ChannelID     int64
+	Percent       int
+	StartedAt     time.Time
+}
+
+// ChannelHealthStats 为渠道在时间窗内已结算请求的错误率与延迟统计（用于灰度对比与自动回滚）。
+type ChannelHealthStats struct {
+	ChannelID         int64
+	Requests          int64
+	Failures          int64
+	AvgLatencyMS      float64
+	FirstTokenSamples int64
+	AvgFirstTokenMS   float64
+}
+
+// ErrorRate 返回失败请求占比；无请求时为 0。
+func (s ChannelHealthStats) ErrorRate() float64 {
+	if s.Requests <= 0 {
+		return 0
+	}
+	return float64(s.Failures) / float64(s.Requests)
+}
+
+// Merge 把多个渠道的统计按请求数加权合并（用于汇总灰度成员的同组对照）。
+func (s ChannelHealthStats) Merge(o ChannelHealthStats) ChannelHealthStats {
+	out := ChannelHealthStats{
+		Requests:          s.Requests + o.Requests,
+		Failures:          s.Failures + o.Failures,
+		FirstTokenSamples: s.FirstTokenSamples + o.FirstTokenSamples,
+	}
+	if out.Requests > 0 {
+		out.AvgLatencyMS = (s.AvgLatencyMS*float64(s.Requests) + o.AvgLatencyMS*float64(o.Requests)) / float64(out.Requests)
+	}
+	if out.FirstTokenSamples > 0 {
+		out.AvgFirstTokenMS = (s.AvgFirstTokenMS*float64(s.FirstTokenSamples) + o.AvgFirstTokenMS*float64(o.FirstTokenSamples)) / float64(out.FirstTokenSamples)
+	}
+	return out
+}
+
+func normalizeCanaryPercent(percent int) (int, error) {
+	if percent < 1 || percent > 100 {
+		return 0, errors.New("canary_percent 取值应为 1-100")
+	}
+	return percent, nil
+}
+
+func (s *Store) getChannelGroupMemberChannelID(ctx context.Context, parentGroupID int64, memberID int64) (int64, error) {
+	if parentGroupID == 0 || memberID == 0 {
+		return 0, errors.New("参数不能为空")
+	}
+	var channelID sql.NullInt64
+	if err := s.db.QueryRowContext(ctx, `SELECT member_channel_id FROM channel_group_members WHERE id=? AND parent_group_id=?`, memberID, parentGroupID).Scan(&channelID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, sql.ErrNoRows
+		}
+		return 0, fmt.Errorf("查询 channel_group_member 失败: %w", err)
+	}
+	if !channelID.Valid || channelID.Int64 <= 0 {
+		return 0, errors.New("仅渠道成员支持灰度")
+	}
+	return channelID.Int64, nil
+}
+
+// GetChannelGroupChannelMemberID 返回渠道在指定父组下的成员 ID。
+func (s *Store) GetChannelGroupChannelMemberID(ctx context.Context, parentGroupID int64, channelID int64) (int64, error) {
+	var id int64
+	if err := s.db.QueryRowContext(ctx, `SELECT id FROM channel_group_members WHERE parent_group_id=? AND member_channel_id=?`, parentGroupID, channelID).Scan(&id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, sql.ErrNoRows
+		}
+		return 0, fmt.Errorf("查询 channel_group_member 失败: %w", err)
+	}
+	return id, nil
+}
+
+// StartChannelGroupMemberCanary 将渠道成员置为灰度（或调整灰度百分比）；已在灰度中时保留开始时间，统计窗口不重置。
+func (s *Store) StartChannelGroupMemberCanary(ctx context.Context, parentGroupID int64, memberID int64, percent int) error {
+	percent, err := normalizeCanaryPercent(percent)
+	if err != nil {
+		return err
+	}
+	if _, err := s.getChannelGroupMemberChannelID(ctx, parentGroupID, memberID); err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `
+UPDATE channel_group_members
+SET canary_percent=?,
+    canary_started_at=CASE WHEN canary_status=? AND canary_started_at IS NOT NULL THEN canary_started_at ELSE CURRENT_TIMESTAMP END,
+    canary_status=?,
+    updated_at=CURRENT_TIMESTAMP
+WHERE id=? AND parent_group_id=?
+`, percent, ChannelGroupCanaryActive, ChannelGroupCanaryActive, memberID, parentGroupID)
+	if err != nil {
+		return fmt.Errorf("更新 channel_group_member 灰度失败: %w", err)
+	}
+	return nil
+}
+
+// PromoteChannelGroupMemberCanary 结束灰度，成员按 priority/promotion 正常参与路由。
+func (s *Store) PromoteChannelGroupMemberCanary(ctx context.Context, parentGroupID int64, memberID int64) error {
+	if _, err := s.getChannelGroupMemberChannelID(ctx, parentGroupID, memberID); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, `
+UPDATE channel_group_members
+SET canary_status='', canary_percent=0, canary_started_at=NULL, updated_at=CURRENT_TIMESTAMP
+WHERE id=? AND parent_group_id=?
+`, memberID, parentGroupID)
+	if err != nil {
+		return fmt.Errorf("转正 channel_group_member 灰度失败: %w", err)
+	}
+	return nil
+}
+
+// RollbackChannelGroupMemberCanary 回滚灰度：成员保留在组内但不再接收流量，可再次开启灰度或直接转正。
+func (s *Store) RollbackChannelGroupMemberCanary(ctx context.Context, parentGroupID int64, memberID int64) error {
+	if _, err := s.getChannelGroupMemberChannelID(ctx, parentGroupID, memberID); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, `
+UPDATE channel_group_members
+SET canary_status=?, updated_at=CURRENT_TIMESTAMP
+WHERE id=? AND parent_group_id=?
+`, ChannelGroupCanaryRolledBack, memberID, parentGroupID)
+	if err != nil {
+		return fmt.Errorf("回滚 channel_group_member 灰度失败: %w", err)
+	}
+	return nil
+}
+
+// ClaimChannelGroupCanaryCheck 认领一次灰度成员的自动回滚检查：仅当上次检查早于 notAfter 时成功，
+// 多实例部署下依赖条件 UPDATE 保证同一检查周期只有一个实例执行。
+func (s *Store) ClaimChannelGroupCanaryCheck(ctx context.Context, memberID int64, now time.Time, notAfter time.Time) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `
+UPDATE channel_group_members
+SET canary_checked_at=?
+WHERE id=? AND canary_status=? AND (canary_checked_at IS NULL OR canary_checked_at < ?)
+`, now, memberID, ChannelGroupCanaryActive, notAfter)
+	if err != nil {
+		return false, fmt.Errorf("认领灰度检查失败: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// ListActiveChannelGroupCanaries 返回所有灰度中的渠道成员。
+func (s *Store) ListActiveChannelGroupCanaries(ctx context.Context) ([]ChannelGroupCanary, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT id, parent_group_id, member_channel_id, canary_percent, canary_started_at
+FROM channel_group_members
+WHERE canary_status=? AND member_channel_id IS NOT NULL
+ORDER BY id ASC
+`, ChannelGroupCanaryActive)
+	if err != nil {
+		return nil, fmt.Errorf("查询灰度成员失败: %w", err)
+	}
+	defer rows.Close()
+
+	var out []ChannelGroupCanary
+	for rows.Next() {
+		var row ChannelGroupCanary
+		var startedAt sql.NullTime
+		if err := rows.Scan(&row.MemberID, &row.ParentGroupID, &row.ChannelID, &row.Percent, &startedAt); err != nil {
+			return nil, fmt.Errorf("扫描灰度成员失败: %w", err)
+		}
+		if startedAt.Valid {
+			row.StartedAt = startedAt.Time
+		}
+		out = append(out, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历灰度成员失败: %w", err)
+	}
+	return out, nil
+}
+
+// GetChannelHealthStatsRange 统计指定渠道在 [since, until) 内已结算请求的失败数与平均延迟；
+// 非 2xx 的最终状态码计为失败，预留中的请求不计入。routeGroupID>0 时仅统计经该渠道组路由的请求。
+func (s *Store) GetChannelHealthStatsRange(ctx context.Context, routeGroupID int64, channelIDs []int64, since, until time.Time) (map[int64]ChannelHealthStats, error) {
+	out := make(map[int64]ChannelHealthStats, len(channelIDs))
+	if len(channelIDs) == 0 {
+		return out, nil
+	}
+	args := make([]any, 0, len(channelIDs)+4)
+	for _, id := range channelIDs {
+		args = append(args, id)
+	}
+	groupFilter := ""
+	var sinceArg, untilArg any = since, until
+	if s.dialect == DialectSQLite {
+		// SQLite 以文本比较 CURRENT_TIMESTAMP 写入的时间，需使用相同格式，避免同一秒内的记录被排除。
+		sinceArg = since.UTC().Format("2006-01-02 15:04:05")
+		untilArg = until.UTC().Format("2006-01-02 15:04:05")
+	}
+	args = append(args, sinceArg, untilArg, UsageStateReserved)
+	if routeGroupID > 0 {
+		groupFilter = " AND route_group_id=?"
+		args = append(args, routeGroupID)
+	}
+	rows, err := s.db.QueryContext(ctx, `
+SELECT
+  upstream_channel_id,
+  COUNT(1),
+  SUM(CASE WHEN status_code >= 200 AND status_code < 300 THEN 0 ELSE 1 END),
+  SUM(latency_ms),
+  SUM(CASE WHEN first_token_latency_ms > 0 THEN first_token_latency_ms ELSE 0 END),
+  SUM(CASE WHEN first_token_latency_ms > 0 THEN 1 ELSE 0 END)
+FROM usage_events
+WHERE upstream_channel_id IN (`+strings.TrimSuffix(strings.Repeat("?,", len(channelIDs)), ",")+`) AND time >= ? AND time < ? AND state<>?`+groupFilter+`
+GROUP BY upstream_channel_id
+`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("统计渠道健康度失败: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row ChannelHealthStats
+		var failures sql.NullInt64
+		var latencySum sql.NullInt64
+		var firstTokenSum sql.NullInt64
+		var firstTokenSamples sql.NullInt64
+		if err := rows.Scan(&row.ChannelID, &row.Requests, &failures, &latencySum, &firstTokenSum, &firstTokenSamples); err != nil {
+			return nil, fmt.Errorf("扫描渠道健康度失败: %w", err)
+		}
+		if failures.Valid {
+			row.Failures = failures.Int64
+		}
+		if latencySum.Valid && row.Requests > 0 {
+			row.AvgLatencyMS = float64(latencySum.Int64) / float64(row.Requests)
+		}
+		if firstTokenSamples.Valid {
+			row.FirstTokenSamples = firstTokenSamples.Int64
+		}
+		if firstTokenSum.Valid && row.FirstTokenSamples > 0 {
+			row.AvgFirstTokenMS = float64(firstTokenSum.Int64) / float64(row.FirstTokenSamples)
+		}
+		out[row.ChannelID] = row
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历渠道健康度失败: %w", err)
+	}
+	return out, nil
+}
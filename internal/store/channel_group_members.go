@@ -22,9 +22,19 @@ type ChannelGroupMemberDetail struct {
 	MemberChannelType   *string
 	MemberChannelGroups *string
 	MemberChannelStatus *int
+	MemberChannelWeight *int
+	// MemberChannelSchedule 为成员渠道自身的可用时间窗 JSON（upstream_channels.schedule）。
+	MemberChannelSchedule *string
 
 	Priority  int
 	Promotion bool
+	// Schedule 为该成员关系的可用时间窗 JSON；为空表示不限制。
+	Schedule string
+	// CanaryStatus 为灰度状态（空/active/rolled_back）；active 时仅 CanaryPercent% 的请求路由到该成员。
+	CanaryStatus    string
+	CanaryPercent   int
+	CanaryStartedAt *time.Time
+
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
@@ -36,8 +46,9 @@ func (s *Store) ListChannelGroupMembers(ctx context.Context, parentGroupID int64
 	rows, err := s.db.QueryContext(ctx,
 		"SELECT\n"+
 			"  m.id, m.parent_group_id, m.member_group_id, cg.name, cg.status,\n"+
-			"  m.member_channel_id, uc.name, uc.type, uc.`groups`, uc.status,\n"+
-			"  m.priority, m.promotion, m.created_at, m.updated_at\n"+
+			"  m.member_channel_id, uc.name, uc.type, uc.`groups`, uc.status, uc.weight, uc.schedule,\n"+
+			"  m.priority, m.promotion, m.schedule,\n"+
+			"  m.canary_status, m.canary_percent, m.canary_started_at, m.created_at, m.updated_at\n"+
 			"FROM channel_group_members m\n"+
 			"LEFT JOIN channel_groups cg ON cg.id=m.member_group_id\n"+
 			"LEFT JOIN upstream_channels uc ON uc.id=m.member_channel_id\n"+
@@ -61,7 +72,12 @@ func (s *Store) ListChannelGroupMembers(ctx context.Context, parentGroupID int64
 		var memberChannelType sql.NullString
 		var memberChannelGroups sql.NullString
 		var memberChannelStatus sql.NullInt64
+		var memberChannelWeight sql.NullInt64
+		var memberChannelSchedule sql.NullString
 		var promotion int
+		var schedule sql.NullString
+		var canaryStatus sql.NullString
+		var canaryStartedAt sql.NullTime
 		if err := rows.Scan(
 			&row.MemberID,
 			&row.ParentGroupID,
@@ -73,8 +89,14 @@ func (s *Store) ListChannelGroupMembers(ctx context.Context, parentGroupID int64
 			&memberChannelType,
 			&memberChannelGroups,
 			&memberChannelStatus,
+			&memberChannelWeight,
+			&memberChannelSchedule,
 			&row.Priority,
 			&promotion,
+			&schedule,
+			&canaryStatus,
+			&row.CanaryPercent,
+			&canaryStartedAt,
 			&row.CreatedAt,
 			&row.UpdatedAt,
 		); err != nil {
@@ -113,6 +135,21 @@ func (s *Store) ListChannelGroupMembers(ctx context.Context, parentGroupID int64
 			v := int(memberChannelStatus.Int64)
 			row.MemberChannelStatus = &v
 		}
+		if memberChannelWeight.Valid {
+			v := int(memberChannelWeight.Int64)
+			row.MemberChannelWeight = &v
+		}
+		if memberChannelSchedule.Valid && strings.TrimSpace(memberChannelSchedule.String) != "" {
+			v := strings.TrimSpace(memberChannelSchedule.String)
+			row.MemberChannelSchedule = &v
+		}
+		if schedule.Valid {
+			row.Schedule = strings.TrimSpace(schedule.String)
+		}
+		if canaryStatus.Valid {
+			row.CanaryStatus = strings.TrimSpace(canaryStatus.String)
+		}
+		row.CanaryStartedAt = nullTimePtr(canaryStartedAt)
 		out = append(out, row)
 	}
 	if err := rows.Err(); err != nil {
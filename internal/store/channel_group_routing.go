@@ -0,0 +1,53 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// ChannelGroupRoutingPriority 为默认路由：按 promotion/priority/失败分（及权重、延迟）排序。
+	ChannelGroupRoutingPriority = "priority"
+	// ChannelGroupRoutingCost 在不改变 priority 与封禁规则的前提下，同优先级内按 channel_model 成本价从低到高排序。
+	ChannelGroupRoutingCost = "cost"
+)
+
+// NormalizeChannelGroupRoutingMode 将空值/未知值归一为 priority。
+func NormalizeChannelGroupRoutingMode(mode string) string {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case ChannelGroupRoutingCost:
+		return ChannelGroupRoutingCost
+	default:
+		return ChannelGroupRoutingPriority
+	}
+}
+
+// ValidChannelGroupRoutingMode 校验管理端提交的路由方式（空值视为 priority）。
+func ValidChannelGroupRoutingMode(mode string) bool {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", ChannelGroupRoutingPriority, ChannelGroupRoutingCost:
+		return true
+	default:
+		return false
+	}
+}
+
+// UpdateChannelGroupRoutingMode 更新渠道组的组内路由方式。
+func (s *Store) UpdateChannelGroupRoutingMode(ctx context.Context, id int64, mode string) error {
+	if id <= 0 {
+		return errors.New("id 不合法")
+	}
+	if !ValidChannelGroupRoutingMode(mode) {
+		return fmt.Errorf("routing_mode 不合法: %s", mode)
+	}
+	if _, err := s.db.ExecContext(ctx, `
+UPDATE channel_groups
+SET routing_mode=?, updated_at=CURRENT_TIMESTAMP
+WHERE id=?
+`, NormalizeChannelGroupRoutingMode(mode), id); err != nil {
+		return fmt.Errorf("更新 channel_group 路由方式失败: %w", err)
+	}
+	return nil
+}
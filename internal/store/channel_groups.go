@@ -19,7 +19,7 @@ type ChannelGroupDeleteSummary struct {
 
 func (s *Store) ListChannelGroups(ctx context.Context) ([]ChannelGroup, error) {
 	rows, err := s.db.QueryContext(ctx, `
-	SELECT id, name, description, price_multiplier, status, created_at, updated_at
+	SELECT id, name, description, price_multiplier, routing_mode, status, created_at, updated_at
 	FROM channel_groups
 	ORDER BY status DESC, name ASC, id DESC
 	`)
@@ -32,7 +32,7 @@ func (s *Store) ListChannelGroups(ctx context.Context) ([]ChannelGroup, error) {
 	for rows.Next() {
 		var g ChannelGroup
 		var desc sql.NullString
-		if err := rows.Scan(&g.ID, &g.Name, &desc, &g.PriceMultiplier, &g.Status, &g.CreatedAt, &g.UpdatedAt); err != nil {
+		if err := rows.Scan(&g.ID, &g.Name, &desc, &g.PriceMultiplier, &g.RoutingMode, &g.Status, &g.CreatedAt, &g.UpdatedAt); err != nil {
 			return nil, fmt.Errorf("扫描 channel_groups 失败: %w", err)
 		}
 		if desc.Valid {
@@ -43,6 +43,7 @@ func (s *Store) ListChannelGroups(ctx context.Context) ([]ChannelGroup, error) {
 			g.PriceMultiplier = DefaultGroupPriceMultiplier
 		}
 		g.PriceMultiplier = g.PriceMultiplier.Truncate(PriceMultiplierScale)
+		g.RoutingMode = NormalizeChannelGroupRoutingMode(g.RoutingMode)
 		out = append(out, g)
 	}
 	if err := rows.Err(); err != nil {
@@ -55,10 +56,10 @@ func (s *Store) GetChannelGroupByID(ctx context.Context, id int64) (ChannelGroup
 	var g ChannelGroup
 	var desc sql.NullString
 	err := s.db.QueryRowContext(ctx, `
-	SELECT id, name, description, price_multiplier, status, created_at, updated_at
+	SELECT id, name, description, price_multiplier, routing_mode, status, created_at, updated_at
 	FROM channel_groups
 	WHERE id=?
-	`, id).Scan(&g.ID, &g.Name, &desc, &g.PriceMultiplier, &g.Status, &g.CreatedAt, &g.UpdatedAt)
+	`, id).Scan(&g.ID, &g.Name, &desc, &g.PriceMultiplier, &g.RoutingMode, &g.Status, &g.CreatedAt, &g.UpdatedAt)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return ChannelGroup{}, sql.ErrNoRows
@@ -73,6 +74,7 @@ func (s *Store) GetChannelGroupByID(ctx context.Context, id int64) (ChannelGroup
 		g.PriceMultiplier = DefaultGroupPriceMultiplier
 	}
 	g.PriceMultiplier = g.PriceMultiplier.Truncate(PriceMultiplierScale)
+	g.RoutingMode = NormalizeChannelGroupRoutingMode(g.RoutingMode)
 	return g, nil
 }
 
@@ -84,11 +86,11 @@ func (s *Store) GetChannelGroupByName(ctx context.Context, name string) (Channel
 	var g ChannelGroup
 	var desc sql.NullString
 	err := s.db.QueryRowContext(ctx, `
-	SELECT id, name, description, price_multiplier, status, created_at, updated_at
+	SELECT id, name, description, price_multiplier, routing_mode, status, created_at, updated_at
 	FROM channel_groups
 	WHERE name=?
 	LIMIT 1
-	`, name).Scan(&g.ID, &g.Name, &desc, &g.PriceMultiplier, &g.Status, &g.CreatedAt, &g.UpdatedAt)
+	`, name).Scan(&g.ID, &g.Name, &desc, &g.PriceMultiplier, &g.RoutingMode, &g.Status, &g.CreatedAt, &g.UpdatedAt)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return ChannelGroup{}, sql.ErrNoRows
@@ -103,6 +105,7 @@ func (s *Store) GetChannelGroupByName(ctx context.Context, name string) (Channel
 		g.PriceMultiplier = DefaultGroupPriceMultiplier
 	}
 	g.PriceMultiplier = g.PriceMultiplier.Truncate(PriceMultiplierScale)
+	g.RoutingMode = NormalizeChannelGroupRoutingMode(g.RoutingMode)
 	return g, nil
 }
 
@@ -0,0 +1,113 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// ChannelModelCost 为 channel_model 绑定的上游成本价（USD/1M tokens）；nil 表示未配置。
+// 用于按成本路由，并在 usage_event 上记录成本基准以便事后核算毛利。
+type ChannelModelCost struct {
+	InputUSDPer1M       *decimal.Decimal
+	OutputUSDPer1M      *decimal.Decimal
+	CacheInputUSDPer1M  *decimal.Decimal
+	CacheOutputUSDPer1M *decimal.Decimal
+}
+
+// Configured 表示是否配置了可用于成本排序的输入/输出价。
+func (c ChannelModelCost) Configured() bool {
+	return c.InputUSDPer1M != nil || c.OutputUSDPer1M != nil
+}
+
+// ExpectedUSDPer1M 按输入/输出 token 1:1 估算单位成本（缺失的一侧按另一侧计）；
+// 缓存价不参与估算（命中率不可预知）。未配置时 ok=false。
+func (c ChannelModelCost) ExpectedUSDPer1M() (decimal.Decimal, bool) {
+	switch {
+	case c.InputUSDPer1M != nil && c.OutputUSDPer1M != nil:
+		return c.InputUSDPer1M.Add(*c.OutputUSDPer1M).Div(decimal.NewFromInt(2)), true
+	case c.InputUSDPer1M != nil:
+		return *c.InputUSDPer1M, true
+	case c.OutputUSDPer1M != nil:
+		return *c.OutputUSDPer1M, true
+	default:
+		return decimal.Zero, false
+	}
+}
+
+// channelModelCostScan 用于从 cost_*_usd_per_1m 四列扫描 ChannelModelCost。
+type channelModelCostScan struct {
+	input       sql.NullString
+	output      sql.NullString
+	cacheInput  sql.NullString
+	cacheOutput sql.NullString
+}
+
+func (c *channelModelCostScan) dest() []any {
+	return []any{&c.input, &c.output, &c.cacheInput, &c.cacheOutput}
+}
+
+func (c channelModelCostScan) cost() (ChannelModelCost, error) {
+	var out ChannelModelCost
+	var err error
+	if out.InputUSDPer1M, err = parseOptionalManagedModelPrice(c.input); err != nil {
+		return ChannelModelCost{}, err
+	}
+	if out.OutputUSDPer1M, err = parseOptionalManagedModelPrice(c.output); err != nil {
+		return ChannelModelCost{}, err
+	}
+	if out.CacheInputUSDPer1M, err = parseOptionalManagedModelPrice(c.cacheInput); err != nil {
+		return ChannelModelCost{}, err
+	}
+	if out.CacheOutputUSDPer1M, err = parseOptionalManagedModelPrice(c.cacheOutput); err != nil {
+		return ChannelModelCost{}, err
+	}
+	return out, nil
+}
+
+func normalizeChannelModelCost(in ChannelModelCost) (ChannelModelCost, error) {
+	var out ChannelModelCost
+	var err error
+	if out.InputUSDPer1M, err = normalizeOptionalManagedModelPrice(in.InputUSDPer1M); err != nil {
+		return ChannelModelCost{}, err
+	}
+	if out.OutputUSDPer1M, err = normalizeOptionalManagedModelPrice(in.OutputUSDPer1M); err != nil {
+		return ChannelModelCost{}, err
+	}
+	if out.CacheInputUSDPer1M, err = normalizeOptionalManagedModelPrice(in.CacheInputUSDPer1M); err != nil {
+		return ChannelModelCost{}, err
+	}
+	if out.CacheOutputUSDPer1M, err = normalizeOptionalManagedModelPrice(in.CacheOutputUSDPer1M); err != nil {
+		return ChannelModelCost{}, err
+	}
+	return out, nil
+}
+
+func optionalDecimalArg(v *decimal.Decimal) any {
+	if v == nil {
+		return nil
+	}
+	return v.StringFixed(USDScale)
+}
+
+// UpdateChannelModelCost 更新 channel_model 的上游成本价；nil 字段表示清空（未配置）。
+func (s *Store) UpdateChannelModelCost(ctx context.Context, id int64, in ChannelModelCost) error {
+	if id <= 0 {
+		return errors.New("id 不合法")
+	}
+	cost, err := normalizeChannelModelCost(in)
+	if err != nil {
+		return err
+	}
+	if _, err := s.db.ExecContext(ctx, `
+UPDATE channel_models
+SET cost_input_usd_per_1m=?, cost_output_usd_per_1m=?, cost_cache_input_usd_per_1m=?, cost_cache_output_usd_per_1m=?, updated_at=CURRENT_TIMESTAMP
+WHERE id=?
+`, optionalDecimalArg(cost.InputUSDPer1M), optionalDecimalArg(cost.OutputUSDPer1M), optionalDecimalArg(cost.CacheInputUSDPer1M), optionalDecimalArg(cost.CacheOutputUSDPer1M), id); err != nil {
+		return fmt.Errorf("更新 channel_model 成本价失败: %w", err)
+	}
+	return nil
+}
@@ -25,7 +25,9 @@ type ChannelModelUpdate struct {
 
 func (s *Store) ListChannelModelsByChannelID(ctx context.Context, channelID int64) ([]ChannelModel, error) {
 	rows, err := s.db.QueryContext(ctx, `
-SELECT id, channel_id, public_id, upstream_model, status, created_at, updated_at
+SELECT id, channel_id, public_id, upstream_model, status,
+       cost_input_usd_per_1m, cost_output_usd_per_1m, cost_cache_input_usd_per_1m, cost_cache_output_usd_per_1m,
+       created_at, updated_at
 FROM channel_models
 WHERE channel_id=?
 ORDER BY status DESC, id DESC
@@ -38,9 +40,14 @@ ORDER BY status DESC, id DESC
 	var out []ChannelModel
 	for rows.Next() {
 		var m ChannelModel
-		if err := rows.Scan(&m.ID, &m.ChannelID, &m.PublicID, &m.UpstreamModel, &m.Status, &m.CreatedAt, &m.UpdatedAt); err != nil {
+		var cost channelModelCostScan
+		dest := append([]any{&m.ID, &m.ChannelID, &m.PublicID, &m.UpstreamModel, &m.Status}, cost.dest()...)
+		if err := rows.Scan(append(dest, &m.CreatedAt, &m.UpdatedAt)...); err != nil {
 			return nil, fmt.Errorf("扫描 channel_models 失败: %w", err)
 		}
+		if m.Cost, err = cost.cost(); err != nil {
+			return nil, fmt.Errorf("解析 channel_models 成本价失败: %w", err)
+		}
 		out = append(out, m)
 	}
 	if err := rows.Err(); err != nil {
@@ -51,17 +58,24 @@ ORDER BY status DESC, id DESC
 
 func (s *Store) GetChannelModelByID(ctx context.Context, id int64) (ChannelModel, error) {
 	var m ChannelModel
+	var cost channelModelCostScan
+	dest := append([]any{&m.ID, &m.ChannelID, &m.PublicID, &m.UpstreamModel, &m.Status}, cost.dest()...)
 	err := s.db.QueryRowContext(ctx, `
-SELECT id, channel_id, public_id, upstream_model, status, created_at, updated_at
+SELECT id, channel_id, public_id, upstream_model, status,
+       cost_input_usd_per_1m, cost_output_usd_per_1m, cost_cache_input_usd_per_1m, cost_cache_output_usd_per_1m,
+       created_at, updated_at
 FROM channel_models
 WHERE id=?
-`, id).Scan(&m.ID, &m.ChannelID, &m.PublicID, &m.UpstreamModel, &m.Status, &m.CreatedAt, &m.UpdatedAt)
+`, id).Scan(append(dest, &m.CreatedAt, &m.UpdatedAt)...)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return ChannelModel{}, sql.ErrNoRows
 		}
 		return ChannelModel{}, fmt.Errorf("查询 channel_model 失败: %w", err)
 	}
+	if m.Cost, err = cost.cost(); err != nil {
+		return ChannelModel{}, fmt.Errorf("解析 channel_model 成本价失败: %w", err)
+	}
 	return m, nil
 }
 
@@ -71,7 +85,9 @@ WHERE id=?
 func (s *Store) ListEnabledChannelModelBindingsByPublicID(ctx context.Context, publicID string) ([]ChannelModelBinding, error) {
 	groupsCol := "`groups`"
 	query := fmt.Sprintf(`
-SELECT cm.id, cm.channel_id, ch.type, ch.%s, cm.public_id, cm.upstream_model, cm.status, cm.created_at, cm.updated_at
+SELECT cm.id, cm.channel_id, ch.type, ch.%s, cm.public_id, cm.upstream_model, cm.status,
+       cm.cost_input_usd_per_1m, cm.cost_output_usd_per_1m, cm.cost_cache_input_usd_per_1m, cm.cost_cache_output_usd_per_1m,
+       cm.created_at, cm.updated_at
 FROM channel_models cm
 JOIN upstream_channels ch ON ch.id=cm.channel_id
 WHERE cm.public_id=? AND cm.status=1 AND ch.status=1
@@ -86,9 +102,14 @@ ORDER BY cm.id DESC
 	var out []ChannelModelBinding
 	for rows.Next() {
 		var b ChannelModelBinding
-		if err := rows.Scan(&b.ID, &b.ChannelID, &b.ChannelType, &b.ChannelGroups, &b.PublicID, &b.UpstreamModel, &b.Status, &b.CreatedAt, &b.UpdatedAt); err != nil {
+		var cost channelModelCostScan
+		dest := append([]any{&b.ID, &b.ChannelID, &b.ChannelType, &b.ChannelGroups, &b.PublicID, &b.UpstreamModel, &b.Status}, cost.dest()...)
+		if err := rows.Scan(append(dest, &b.CreatedAt, &b.UpdatedAt)...); err != nil {
 			return nil, fmt.Errorf("扫描 channel_models 失败: %w", err)
 		}
+		if b.Cost, err = cost.cost(); err != nil {
+			return nil, fmt.Errorf("解析 channel_models 成本价失败: %w", err)
+		}
 		out = append(out, b)
 	}
 	if err := rows.Err(); err != nil {
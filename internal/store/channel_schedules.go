@@ -0,0 +1,226 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ChannelSchedule 描述渠道/渠道组成员的可用时间窗；Windows 为空表示不限制（全天可用）。
+// 时间按管理后台时区（admin_time_zone）解释。
+type ChannelSchedule struct {
+	Windows []ChannelScheduleWindow `json:"windows"`
+}
+
+// ChannelScheduleWindow 为一个可用时间段。
+//   - Days：星期几（0=周日 … 6=周六），为空表示每天。
+//   - Start/End："HH:MM"，End 允许 "24:00"；End < Start 表示跨午夜（凌晨部分归属前一天的 Days），
+//     End == Start 表示全天。
+type ChannelScheduleWindow struct {
+	Days  []int  `json:"days,omitempty"`
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+type parsedScheduleWindow struct {
+	days  uint8
+	start int
+	end   int
+}
+
+func parseScheduleClock(raw string, allowEndOfDay bool) (int, error) {
+	raw = strings.TrimSpace(raw)
+	hh, mm, ok := strings.Cut(raw, ":")
+	if !ok {
+		return 0, fmt.Errorf("时间格式应为 HH:MM: %q", raw)
+	}
+	h, err := strconv.Atoi(hh)
+	if err != nil {
+		return 0, fmt.Errorf("时间格式应为 HH:MM: %q", raw)
+	}
+	m, err := strconv.Atoi(mm)
+	if err != nil || len(mm) != 2 {
+		return 0, fmt.Errorf("时间格式应为 HH:MM: %q", raw)
+	}
+	if allowEndOfDay && h == 24 && m == 0 {
+		return 24 * 60, nil
+	}
+	if h < 0 || h > 23 || m < 0 || m > 59 {
+		return 0, fmt.Errorf("时间超出范围: %q", raw)
+	}
+	return h*60 + m, nil
+}
+
+func (w ChannelScheduleWindow) parse() (parsedScheduleWindow, error) {
+	var out parsedScheduleWindow
+	for _, d := range w.Days {
+		if d < 0 || d > 6 {
+			return parsedScheduleWindow{}, fmt.Errorf("days 取值应为 0-6（0=周日）: %d", d)
+		}
+		out.days |= 1 << uint(d)
+	}
+	if out.days == 0 {
+		out.days = 0x7f
+	}
+	start, err := parseScheduleClock(w.Start, false)
+	if err != nil {
+		return parsedScheduleWindow{}, fmt.Errorf("start 无效: %w", err)
+	}
+	end, err := parseScheduleClock(w.End, true)
+	if err != nil {
+		return parsedScheduleWindow{}, fmt.Errorf("end 无效: %w", err)
+	}
+	out.start = start
+	out.end = end
+	return out, nil
+}
+
+func (w parsedScheduleWindow) hasDay(d time.Weekday) bool {
+	return w.days&(1<<uint(d)) != 0
+}
+
+func (w parsedScheduleWindow) active(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	day := t.Weekday()
+	switch {
+	case w.end == w.start:
+		return w.hasDay(day)
+	case w.end > w.start:
+		return w.hasDay(day) && minute >= w.start && minute < w.end
+	default:
+		if minute >= w.start {
+			return w.hasDay(day)
+		}
+		return minute < w.end && w.hasDay((day+6)%7)
+	}
+}
+
+// ParseChannelSchedule 解析并校验 schedule JSON；空串/空 windows 返回零值（不限制）。
+func ParseChannelSchedule(raw string) (ChannelSchedule, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || raw == "null" {
+		return ChannelSchedule{}, nil
+	}
+	var s ChannelSchedule
+	dec := json.NewDecoder(strings.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&s); err != nil {
+		return ChannelSchedule{}, fmt.Errorf("schedule 不是有效 JSON: %w", err)
+	}
+	for i, w := range s.Windows {
+		if _, err := w.parse(); err != nil {
+			return ChannelSchedule{}, fmt.Errorf("windows[%d] %w", i, err)
+		}
+	}
+	return s, nil
+}
+
+// NormalizeChannelSchedule 校验并规范化 schedule JSON（days 去重排序、时间补零）；不限制时返回空串。
+func NormalizeChannelSchedule(raw string) (string, error) {
+	s, err := ParseChannelSchedule(raw)
+	if err != nil {
+		return "", err
+	}
+	if len(s.Windows) == 0 {
+		return "", nil
+	}
+	out := ChannelSchedule{Windows: make([]ChannelScheduleWindow, 0, len(s.Windows))}
+	for _, w := range s.Windows {
+		p, _ := w.parse()
+		var days []int
+		if p.days != 0x7f {
+			for d := 0; d < 7; d++ {
+				if p.days&(1<<uint(d)) != 0 {
+					days = append(days, d)
+				}
+			}
+			sort.Ints(days)
+		}
+		out.Windows = append(out.Windows, ChannelScheduleWindow{
+			Days:  days,
+			Start: fmt.Sprintf("%02d:%02d", p.start/60, p.start%60),
+			End:   fmt.Sprintf("%02d:%02d", p.end/60, p.end%60),
+		})
+	}
+	b, err := json.Marshal(out)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// ActiveAt 判断时刻 t（需已转换到管理后台时区）是否落在任一时间窗内；未配置时间窗时恒为 true。
+func (s ChannelSchedule) ActiveAt(t time.Time) bool {
+	if len(s.Windows) == 0 {
+		return true
+	}
+	for _, w := range s.Windows {
+		p, err := w.parse()
+		if err != nil {
+			continue
+		}
+		if p.active(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Store) UpdateUpstreamChannelSchedule(ctx context.Context, channelID int64, schedule string) error {
+	if channelID == 0 {
+		return errors.New("channelID 不能为空")
+	}
+	normalized, err := NormalizeChannelSchedule(schedule)
+	if err != nil {
+		return err
+	}
+	var v any
+	if normalized != "" {
+		v = normalized
+	}
+	_, err = s.db.ExecContext(ctx, `
+UPDATE upstream_channels
+SET schedule=?, updated_at=CURRENT_TIMESTAMP
+WHERE id=?
+`, v, channelID)
+	if err != nil {
+		return fmt.Errorf("更新 upstream_channel schedule 失败: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) UpdateChannelGroupMemberSchedule(ctx context.Context, parentGroupID int64, memberID int64, schedule string) error {
+	if parentGroupID == 0 || memberID == 0 {
+		return errors.New("参数不能为空")
+	}
+	normalized, err := NormalizeChannelSchedule(schedule)
+	if err != nil {
+		return err
+	}
+	var v any
+	if normalized != "" {
+		v = normalized
+	}
+	var exists int
+	if err := s.db.QueryRowContext(ctx, `SELECT 1 FROM channel_group_members WHERE id=? AND parent_group_id=?`, memberID, parentGroupID).Scan(&exists); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sql.ErrNoRows
+		}
+		return fmt.Errorf("查询 channel_group_member 失败: %w", err)
+	}
+	_, err = s.db.ExecContext(ctx, `
+UPDATE channel_group_members
+SET schedule=?, updated_at=CURRENT_TIMESTAMP
+WHERE id=? AND parent_group_id=?
+`, v, memberID, parentGroupID)
+	if err != nil {
+		return fmt.Errorf("更新 channel_group_member schedule 失败: %w", err)
+	}
+	return nil
+}
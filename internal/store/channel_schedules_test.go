@@ -0,0 +1,68 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChannelSchedule_ActiveAt(t *testing.T) {
+	// 工作日 20:00 至次日 08:00（周五晚延续到周六早）。
+	s, err := ParseChannelSchedule(`{"windows":[{"days":[1,2,3,4,5],"start":"20:00","end":"08:00"}]}`)
+	if err != nil {
+		t.Fatalf("ParseChannelSchedule err: %v", err)
+	}
+	// 2026-01-05 为周一。
+	at := func(day int, hh int, mm int) time.Time {
+		return time.Date(2026, 1, 4+day, hh, mm, 0, 0, time.UTC)
+	}
+	cases := []struct {
+		name string
+		t    time.Time
+		want bool
+	}{
+		{name: "mon evening", t: at(1, 21, 0), want: true},
+		{name: "mon noon", t: at(1, 12, 0), want: false},
+		{name: "tue early morning", t: at(2, 7, 59), want: true},
+		{name: "tue 08:00", t: at(2, 8, 0), want: false},
+		{name: "sat early morning from fri", t: at(6, 3, 0), want: true},
+		{name: "sat evening", t: at(6, 21, 0), want: false},
+		{name: "mon early morning from sun", t: at(1, 3, 0), want: false},
+	}
+	for _, tc := range cases {
+		if got := s.ActiveAt(tc.t); got != tc.want {
+			t.Fatalf("%s: ActiveAt=%v, want=%v", tc.name, got, tc.want)
+		}
+	}
+
+	if !(ChannelSchedule{}).ActiveAt(at(1, 12, 0)) {
+		t.Fatalf("expected empty schedule to be always active")
+	}
+}
+
+func TestNormalizeChannelSchedule(t *testing.T) {
+	got, err := NormalizeChannelSchedule(`{"windows":[{"days":[5,1,1],"start":"9:00","end":"24:00"},{"days":[0,1,2,3,4,5,6],"start":"00:00","end":"00:00"}]}`)
+	if err != nil {
+		t.Fatalf("NormalizeChannelSchedule err: %v", err)
+	}
+	want := `{"windows":[{"days":[1,5],"start":"09:00","end":"24:00"},{"start":"00:00","end":"00:00"}]}`
+	if got != want {
+		t.Fatalf("normalized=%s, want=%s", got, want)
+	}
+
+	for _, raw := range []string{"", "null", `{"windows":[]}`} {
+		if got, err := NormalizeChannelSchedule(raw); err != nil || got != "" {
+			t.Fatalf("NormalizeChannelSchedule(%q)=%q,%v; want empty", raw, got, err)
+		}
+	}
+	for _, raw := range []string{
+		`{"windows":[{"days":[7],"start":"00:00","end":"01:00"}]}`,
+		`{"windows":[{"start":"24:00","end":"01:00"}]}`,
+		`{"windows":[{"start":"08:60","end":"09:00"}]}`,
+		`{"windows":[{"start":"08:00"}]}`,
+		`{"window":[]}`,
+	} {
+		if _, err := NormalizeChannelSchedule(raw); err == nil {
+			t.Fatalf("expected invalid schedule error for %s", raw)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// CredentialLimits 为上游 credential/account 的限流配置：RPM、TPM 与最大并发（nil 表示不限制）。
+// upstream_endpoints 上的同名配置作为该 endpoint 下 credential 的默认值。
+type CredentialLimits struct {
+	RPM            *int
+	TPM            *int
+	MaxConcurrency *int
+}
+
+// credentialLimitsScan 用于从 rpm_limit/tpm_limit/max_concurrency 三列扫描 CredentialLimits。
+type credentialLimitsScan struct {
+	rpm  sql.NullInt64
+	tpm  sql.NullInt64
+	conc sql.NullInt64
+}
+
+func (c credentialLimitsScan) limits() CredentialLimits {
+	return CredentialLimits{
+		RPM:            nullPositiveInt(c.rpm),
+		TPM:            nullPositiveInt(c.tpm),
+		MaxConcurrency: nullPositiveInt(c.conc),
+	}
+}
+
+func nullPositiveInt(v sql.NullInt64) *int {
+	if !v.Valid || v.Int64 <= 0 {
+		return nil
+	}
+	n := int(v.Int64)
+	return &n
+}
+
+func positiveIntArg(v *int) any {
+	if v == nil || *v <= 0 {
+		return nil
+	}
+	return *v
+}
+
+// credentialLimitTableByType 将上游 credential 类型映射到存储表。
+var credentialLimitTableByType = map[string]string{
+	UpstreamTypeOpenAICompatible: "openai_compatible_credentials",
+	UpstreamTypeCodexOAuth:       "codex_oauth_accounts",
+	UpstreamTypeAnthropic:        "anthropic_credentials",
+	UpstreamTypeGemini:           "gemini_credentials",
+	UpstreamTypeAzureOpenAI:      "azure_openai_credentials",
+	UpstreamTypeBedrock:          "bedrock_credentials",
+	UpstreamTypeVertex:           "vertex_credentials",
+}
+
+// UpdateCredentialLimits 更新指定类型 credential/account 的限流配置；<=0 的字段按不限制（继承 endpoint 默认值）存储。
+func (s *Store) UpdateCredentialLimits(ctx context.Context, credentialType string, id int64, in CredentialLimits) error {
+	table, ok := credentialLimitTableByType[credentialType]
+	if !ok {
+		return fmt.Errorf("不支持的 credential 类型: %s", credentialType)
+	}
+	if id <= 0 {
+		return errors.New("id 不合法")
+	}
+	if _, err := s.db.ExecContext(ctx, `
+UPDATE `+table+`
+SET rpm_limit=?, tpm_limit=?, max_concurrency=?, updated_at=CURRENT_TIMESTAMP
+WHERE id=?
+`, positiveIntArg(in.RPM), positiveIntArg(in.TPM), positiveIntArg(in.MaxConcurrency), id); err != nil {
+		return fmt.Errorf("更新 %s 限流配置失败: %w", table, err)
+	}
+	return nil
+}
+
+// UpdateUpstreamEndpointLimits 更新 endpoint 级默认限流配置（作用于未单独配置的 credential）。
+func (s *Store) UpdateUpstreamEndpointLimits(ctx context.Context, endpointID int64, in CredentialLimits) error {
+	if endpointID <= 0 {
+		return errors.New("endpoint_id 不合法")
+	}
+	if _, err := s.db.ExecContext(ctx, `
+UPDATE upstream_endpoints
+SET rpm_limit=?, tpm_limit=?, max_concurrency=?, updated_at=CURRENT_TIMESTAMP
+WHERE id=?
+`, positiveIntArg(in.RPM), positiveIntArg(in.TPM), positiveIntArg(in.MaxConcurrency), endpointID); err != nil {
+		return fmt.Errorf("更新 upstream_endpoints 限流配置失败: %w", err)
+	}
+	return nil
+}
+
+// GetCredentialEndpointID 返回指定类型 credential/account 所属的 endpoint_id。
+func (s *Store) GetCredentialEndpointID(ctx context.Context, credentialType string, id int64) (int64, error) {
+	table, ok := credentialLimitTableByType[credentialType]
+	if !ok {
+		return 0, fmt.Errorf("不支持的 credential 类型: %s", credentialType)
+	}
+	var endpointID int64
+	if err := s.db.QueryRowContext(ctx, `SELECT endpoint_id FROM `+table+` WHERE id=?`, id).Scan(&endpointID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, sql.ErrNoRows
+		}
+		return 0, fmt.Errorf("查询 %s 失败: %w", table, err)
+	}
+	return endpointID, nil
+}
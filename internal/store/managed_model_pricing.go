@@ -1,6 +1,7 @@
 package store
 
 import (
+	"database/sql"
 	"errors"
 	"strings"
 
@@ -15,6 +16,9 @@ var (
 	defaultFastModePriceMultiplier        = decimal.RequireFromString("2")
 )
 
+// ServiceTierBatch 仅由网关内部的 Batch 执行器写入 usage_events，下游请求无法直接指定。
+const ServiceTierBatch = "batch"
+
 type ManagedModelPricing struct {
 	ServiceTier                   string
 	PricingKind                   string
@@ -22,6 +26,7 @@ type ManagedModelPricing struct {
 	HighContextApplied            bool
 	HighContextThresholdTokens    int64
 	HighContextTriggerInputTokens int64
+	BatchDiscountApplied          bool
 	InputUSDPer1M                 decimal.Decimal
 	OutputUSDPer1M                decimal.Decimal
 	CacheInputUSDPer1M            decimal.Decimal
@@ -102,7 +107,7 @@ func ResolveManagedModelPricing(m ManagedModel, serviceTier string, inputTokensT
 	forceStandardTriggered := triggered && hc != nil && hc.ServiceTierPolicy == ManagedModelHighContextServiceTierPolicyForceStandard
 
 	switch requestedTier {
-	case "", "default", "auto", "flex":
+	case "", "default", "auto", "flex", ServiceTierBatch:
 		pricing.EffectiveServiceTier = requestedTier
 	case "priority":
 		if forceStandardTriggered {
@@ -124,13 +129,10 @@ func ResolveManagedModelPricing(m ManagedModel, serviceTier string, inputTokensT
 	default:
 		pricing.EffectiveServiceTier = requestedTier
 	}
-	if !triggered {
-		return pricing, nil
+	if !triggered || hc == nil {
+		return applyManagedModelBatchDiscount(m, pricing), nil
 	}
 
-	if hc == nil {
-		return pricing, nil
-	}
 	pricing.HighContextApplied = true
 	pricing.PricingKind = "high_context"
 	if forceStandardTriggered {
@@ -146,5 +148,47 @@ func ResolveManagedModelPricing(m ManagedModel, serviceTier string, inputTokensT
 	if hc.CacheOutputUSDPer1M != nil {
 		pricing.CacheOutputUSDPer1M = hc.CacheOutputUSDPer1M.Truncate(USDScale)
 	}
-	return pricing, nil
+	return applyManagedModelBatchDiscount(m, pricing), nil
+}
+
+// applyManagedModelBatchDiscount 在分档/高上下文定价确定后叠加 Batch 折扣（单价 × (1 - discount)）。
+func applyManagedModelBatchDiscount(m ManagedModel, pricing ManagedModelPricing) ManagedModelPricing {
+	if pricing.ServiceTier != ServiceTierBatch || m.BatchDiscount == nil || !m.BatchDiscount.IsPositive() {
+		return pricing
+	}
+	factor := decimal.NewFromInt(1).Sub(*m.BatchDiscount)
+	pricing.BatchDiscountApplied = true
+	if !pricing.HighContextApplied {
+		pricing.PricingKind = ServiceTierBatch
+	}
+	pricing.InputUSDPer1M = pricing.InputUSDPer1M.Mul(factor).Truncate(USDScale)
+	pricing.OutputUSDPer1M = pricing.OutputUSDPer1M.Mul(factor).Truncate(USDScale)
+	pricing.CacheInputUSDPer1M = pricing.CacheInputUSDPer1M.Mul(factor).Truncate(USDScale)
+	pricing.CacheOutputUSDPer1M = pricing.CacheOutputUSDPer1M.Mul(factor).Truncate(USDScale)
+	return pricing
+}
+
+func normalizeManagedModelBatchDiscount(v *decimal.Decimal) (*decimal.Decimal, error) {
+	if v == nil {
+		return nil, nil
+	}
+	n := v.Truncate(USDScale)
+	if n.IsNegative() || n.GreaterThan(decimal.NewFromInt(1)) {
+		return nil, errors.New("batch 折扣不合法")
+	}
+	if n.IsZero() {
+		return nil, nil
+	}
+	return &n, nil
+}
+
+func parseManagedModelBatchDiscount(v sql.NullString) (*decimal.Decimal, error) {
+	if !v.Valid || strings.TrimSpace(v.String) == "" {
+		return nil, nil
+	}
+	d, err := decimal.NewFromString(strings.TrimSpace(v.String))
+	if err != nil {
+		return nil, errors.New("batch 折扣不合法")
+	}
+	return normalizeManagedModelBatchDiscount(&d)
 }
@@ -242,3 +242,54 @@ func TestResolveManagedModelPricingHighContextForceStandardSkipsPriorityValidati
 		t.Fatalf("input=%s, want 5", pricing.InputUSDPer1M)
 	}
 }
+
+func TestResolveManagedModelPricingBatchDiscount(t *testing.T) {
+	m := ManagedModel{
+		InputUSDPer1M:       testDecimal("2"),
+		OutputUSDPer1M:      testDecimal("8"),
+		CacheInputUSDPer1M:  testDecimal("0.5"),
+		CacheOutputUSDPer1M: testDecimal("0"),
+		BatchDiscount:       testDecimalPtr("0.5"),
+	}
+
+	pricing, err := ResolveManagedModelPricing(m, ServiceTierBatch, nil)
+	if err != nil {
+		t.Fatalf("ResolveManagedModelPricing: %v", err)
+	}
+	if !pricing.BatchDiscountApplied {
+		t.Fatal("expected batch discount applied")
+	}
+	if pricing.PricingKind != ServiceTierBatch {
+		t.Fatalf("pricing_kind=%q, want batch", pricing.PricingKind)
+	}
+	if !pricing.InputUSDPer1M.Equal(testDecimal("1")) {
+		t.Fatalf("input=%s, want 1", pricing.InputUSDPer1M)
+	}
+	if !pricing.OutputUSDPer1M.Equal(testDecimal("4")) {
+		t.Fatalf("output=%s, want 4", pricing.OutputUSDPer1M)
+	}
+	if !pricing.CacheInputUSDPer1M.Equal(testDecimal("0.25")) {
+		t.Fatalf("cache_input=%s, want 0.25", pricing.CacheInputUSDPer1M)
+	}
+
+	standard, err := ResolveManagedModelPricing(m, "", nil)
+	if err != nil {
+		t.Fatalf("ResolveManagedModelPricing(standard): %v", err)
+	}
+	if standard.BatchDiscountApplied || !standard.InputUSDPer1M.Equal(testDecimal("2")) {
+		t.Fatalf("standard pricing unexpectedly discounted: %+v", standard)
+	}
+}
+
+func TestNormalizeManagedModelBatchDiscount(t *testing.T) {
+	if _, err := normalizeManagedModelBatchDiscount(testDecimalPtr("1.5")); err == nil {
+		t.Fatal("expected error for discount > 1")
+	}
+	if _, err := normalizeManagedModelBatchDiscount(testDecimalPtr("-0.1")); err == nil {
+		t.Fatal("expected error for negative discount")
+	}
+	got, err := normalizeManagedModelBatchDiscount(testDecimalPtr("0"))
+	if err != nil || got != nil {
+		t.Fatalf("zero discount: got=%v err=%v, want nil", got, err)
+	}
+}
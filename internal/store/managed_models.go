@@ -25,6 +25,7 @@ type ManagedModelCreate struct {
 	PriorityOutputUSDPer1M     *decimal.Decimal
 	PriorityCacheInputUSDPer1M *decimal.Decimal
 	HighContextPricing         *ManagedModelHighContextPricing
+	BatchDiscount              *decimal.Decimal
 	Status                     int
 }
 
@@ -42,18 +43,19 @@ type ManagedModelUpdate struct {
 	PriorityOutputUSDPer1M     *decimal.Decimal
 	PriorityCacheInputUSDPer1M *decimal.Decimal
 	HighContextPricing         *ManagedModelHighContextPricing
+	BatchDiscount              *decimal.Decimal
 	Status                     int
 }
 
 const managedModelSelectColumns = `id, public_id, group_name, upstream_model, owned_by,
        input_usd_per_1m, output_usd_per_1m, cache_input_usd_per_1m, cache_output_usd_per_1m,
        priority_pricing_enabled, priority_input_usd_per_1m, priority_output_usd_per_1m, priority_cache_input_usd_per_1m,
-       high_context_pricing_json, status, created_at`
+       high_context_pricing_json, batch_discount, status, created_at`
 
 const managedModelSelectColumnsWithAliasM = `m.id, m.public_id, m.group_name, m.upstream_model, m.owned_by,
        m.input_usd_per_1m, m.output_usd_per_1m, m.cache_input_usd_per_1m, m.cache_output_usd_per_1m,
        m.priority_pricing_enabled, m.priority_input_usd_per_1m, m.priority_output_usd_per_1m, m.priority_cache_input_usd_per_1m,
-       m.high_context_pricing_json, m.status, m.created_at`
+       m.high_context_pricing_json, m.batch_discount, m.status, m.created_at`
 
 type managedModelScanner interface {
 	Scan(dest ...any) error
@@ -109,11 +111,12 @@ func scanManagedModelRow(scanner managedModelScanner) (ManagedModel, error) {
 	var priorityOutputUSD sql.NullString
 	var priorityCacheInputUSD sql.NullString
 	var highContextPricingJSON sql.NullString
+	var batchDiscount sql.NullString
 	if err := scanner.Scan(
 		&m.ID, &m.PublicID, &groupName, &upstreamModel, &ownedBy,
 		&m.InputUSDPer1M, &m.OutputUSDPer1M, &m.CacheInputUSDPer1M, &m.CacheOutputUSDPer1M,
 		&priorityPricingEnabled, &priorityInputUSD, &priorityOutputUSD, &priorityCacheInputUSD,
-		&highContextPricingJSON, &m.Status, &m.CreatedAt,
+		&highContextPricingJSON, &batchDiscount, &m.Status, &m.CreatedAt,
 	); err != nil {
 		return ManagedModel{}, err
 	}
@@ -144,6 +147,10 @@ func scanManagedModelRow(scanner managedModelScanner) (ManagedModel, error) {
 	if err != nil {
 		return ManagedModel{}, err
 	}
+	m.BatchDiscount, err = parseManagedModelBatchDiscount(batchDiscount)
+	if err != nil {
+		return ManagedModel{}, err
+	}
 	m.GroupName = normalizeManagedModelGroupName(groupName.String)
 	if upstreamModel.Valid {
 		v := upstreamModel.String
@@ -181,6 +188,10 @@ func normalizeManagedModelPricing(m *ManagedModel) error {
 	if err != nil {
 		return err
 	}
+	m.BatchDiscount, err = normalizeManagedModelBatchDiscount(m.BatchDiscount)
+	if err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -492,6 +503,10 @@ func (s *Store) CreateManagedModel(ctx context.Context, in ManagedModelCreate) (
 	if err != nil {
 		return 0, err
 	}
+	batchDiscount, err := normalizeManagedModelBatchDiscount(in.BatchDiscount)
+	if err != nil {
+		return 0, err
+	}
 	if inUSD.IsNegative() || outUSD.IsNegative() || cacheInUSD.IsNegative() || cacheOutUSD.IsNegative() {
 		return 0, errors.New("模型定价不合法")
 	}
@@ -524,18 +539,18 @@ INSERT INTO managed_models(
   public_id, group_name, owned_by,
   input_usd_per_1m, output_usd_per_1m, cache_input_usd_per_1m, cache_output_usd_per_1m,
   priority_pricing_enabled, priority_input_usd_per_1m, priority_output_usd_per_1m, priority_cache_input_usd_per_1m,
-  high_context_pricing_json,
+  high_context_pricing_json, batch_discount,
   status, created_at
 ) VALUES(
   ?, ?, ?,
   ?, ?, ?, ?,
   ?, ?, ?, ?,
-  ?,
+  ?, ?,
   ?, CURRENT_TIMESTAMP
 )
 `, in.PublicID, in.GroupName, in.OwnedBy, inUSD, outUSD, cacheInUSD, cacheOutUSD,
 		priorityEnabled, priorityInUSD, priorityOutUSD, priorityCacheInUSD,
-		highContextPricingJSON,
+		highContextPricingJSON, batchDiscount,
 		in.Status)
 	if err != nil {
 		return 0, fmt.Errorf("创建 managed_model 失败: %w", err)
@@ -572,6 +587,10 @@ func (s *Store) UpdateManagedModel(ctx context.Context, in ManagedModelUpdate) e
 	if err != nil {
 		return err
 	}
+	batchDiscount, err := normalizeManagedModelBatchDiscount(in.BatchDiscount)
+	if err != nil {
+		return err
+	}
 	if inUSD.IsNegative() || outUSD.IsNegative() || cacheInUSD.IsNegative() || cacheOutUSD.IsNegative() {
 		return errors.New("模型定价不合法")
 	}
@@ -619,12 +638,12 @@ UPDATE managed_models
 SET public_id=?, group_name=?, owned_by=?,
     input_usd_per_1m=?, output_usd_per_1m=?, cache_input_usd_per_1m=?, cache_output_usd_per_1m=?,
     priority_pricing_enabled=?, priority_input_usd_per_1m=?, priority_output_usd_per_1m=?, priority_cache_input_usd_per_1m=?,
-    high_context_pricing_json=?,
+    high_context_pricing_json=?, batch_discount=?,
     status=?
 WHERE id=?
 `, in.PublicID, in.GroupName, in.OwnedBy, inUSD, outUSD, cacheInUSD, cacheOutUSD,
 		priorityEnabled, priorityInUSD, priorityOutUSD, priorityCacheInUSD,
-		highContextPricingJSON,
+		highContextPricingJSON, batchDiscount,
 		in.Status, in.ID); err != nil {
 		return fmt.Errorf("更新 managed_model 失败: %w", err)
 	}
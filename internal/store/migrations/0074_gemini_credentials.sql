@@ -0,0 +1,14 @@
+-- 0074_gemini_credentials.sql: 新增 gemini_credentials，用于存储 Gemini 原生 API（generativelanguage）上游 API key。
+
+CREATE TABLE IF NOT EXISTS `gemini_credentials` (
+  `id` BIGINT PRIMARY KEY AUTO_INCREMENT,
+  `endpoint_id` BIGINT NOT NULL,
+  `name` VARCHAR(128) NULL,
+  `api_key_enc` BLOB NOT NULL,
+  `api_key_hint` VARCHAR(32) NULL,
+  `status` TINYINT NOT NULL DEFAULT 1,
+  `last_used_at` DATETIME NULL,
+  `created_at` DATETIME NOT NULL,
+  `updated_at` DATETIME NOT NULL,
+  KEY `idx_gemini_credentials_endpoint_id` (`endpoint_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,17 @@
+-- 0075_azure_openai.sql: 支持 azure_openai 渠道：upstream_endpoints 增加 api_version，新增 azure_openai_credentials 存储 Azure OpenAI api-key。
+
+ALTER TABLE `upstream_endpoints`
+  ADD COLUMN `api_version` VARCHAR(64) NULL AFTER `base_url`;
+
+CREATE TABLE IF NOT EXISTS `azure_openai_credentials` (
+  `id` BIGINT PRIMARY KEY AUTO_INCREMENT,
+  `endpoint_id` BIGINT NOT NULL,
+  `name` VARCHAR(128) NULL,
+  `api_key_enc` BLOB NOT NULL,
+  `api_key_hint` VARCHAR(32) NULL,
+  `status` TINYINT NOT NULL DEFAULT 1,
+  `last_used_at` DATETIME NULL,
+  `created_at` DATETIME NOT NULL,
+  `updated_at` DATETIME NOT NULL,
+  KEY `idx_azure_openai_credentials_endpoint_id` (`endpoint_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,17 @@
+-- 0076_bedrock_credentials.sql: 新增 bedrock_credentials，用于存储 AWS Bedrock 上游的 access key / secret / region（SigV4 签名）。
+
+CREATE TABLE IF NOT EXISTS `bedrock_credentials` (
+  `id` BIGINT PRIMARY KEY AUTO_INCREMENT,
+  `endpoint_id` BIGINT NOT NULL,
+  `name` VARCHAR(128) NULL,
+  `access_key_id` VARCHAR(128) NOT NULL,
+  `secret_access_key_enc` BLOB NOT NULL,
+  `session_token_enc` BLOB NULL,
+  `region` VARCHAR(64) NOT NULL,
+  `access_key_hint` VARCHAR(32) NULL,
+  `status` TINYINT NOT NULL DEFAULT 1,
+  `last_used_at` DATETIME NULL,
+  `created_at` DATETIME NOT NULL,
+  `updated_at` DATETIME NOT NULL,
+  KEY `idx_bedrock_credentials_endpoint_id` (`endpoint_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,17 @@
+-- 0077_vertex_credentials.sql: 新增 vertex_credentials，用于存储 Google Vertex AI 上游的 service account JSON（JWT-bearer 换取 access token）。
+
+CREATE TABLE IF NOT EXISTS `vertex_credentials` (
+  `id` BIGINT PRIMARY KEY AUTO_INCREMENT,
+  `endpoint_id` BIGINT NOT NULL,
+  `name` VARCHAR(128) NULL,
+  `project_id` VARCHAR(128) NOT NULL,
+  `client_email` VARCHAR(255) NOT NULL,
+  `location` VARCHAR(64) NOT NULL DEFAULT '',
+  `service_account_json_enc` BLOB NOT NULL,
+  `key_hint` VARCHAR(32) NULL,
+  `status` TINYINT NOT NULL DEFAULT 1,
+  `last_used_at` DATETIME NULL,
+  `created_at` DATETIME NOT NULL,
+  `updated_at` DATETIME NOT NULL,
+  KEY `idx_vertex_credentials_endpoint_id` (`endpoint_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,17 @@
+-- 0078_managed_models_batch_discount.sql: managed_models 增加 Batch API 折扣比例字段。
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'managed_models'
+    AND column_name = 'batch_discount'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `managed_models` ADD COLUMN `batch_discount` DECIMAL(10,6) NULL AFTER `high_context_pricing_json`',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
@@ -0,0 +1,61 @@
+-- 0079_batches.sql: 新增 Batch API 所需的文件、批任务与逐行结果表（由网关自身执行批任务）。
+
+CREATE TABLE IF NOT EXISTS `batch_files` (
+  `id` BIGINT PRIMARY KEY AUTO_INCREMENT,
+  `public_id` VARCHAR(64) NOT NULL,
+  `user_id` BIGINT NOT NULL,
+  `token_id` BIGINT NOT NULL DEFAULT 0,
+  `purpose` VARCHAR(32) NOT NULL,
+  `filename` VARCHAR(255) NOT NULL,
+  `bytes` BIGINT NOT NULL DEFAULT 0,
+  `content` LONGBLOB NOT NULL,
+  `created_at` DATETIME NOT NULL,
+  UNIQUE KEY `uk_batch_files_public_id` (`public_id`),
+  KEY `idx_batch_files_user_id_id` (`user_id`, `id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `batches` (
+  `id` BIGINT PRIMARY KEY AUTO_INCREMENT,
+  `public_id` VARCHAR(64) NOT NULL,
+  `user_id` BIGINT NOT NULL,
+  `token_id` BIGINT NOT NULL,
+  `endpoint` VARCHAR(64) NOT NULL,
+  `completion_window` VARCHAR(16) NOT NULL,
+  `input_file_id` VARCHAR(64) NOT NULL,
+  `output_file_id` VARCHAR(64) NULL,
+  `error_file_id` VARCHAR(64) NULL,
+  `status` VARCHAR(32) NOT NULL,
+  `metadata_json` JSON NULL,
+  `errors_json` JSON NULL,
+  `request_total` INT NOT NULL DEFAULT 0,
+  `request_completed` INT NOT NULL DEFAULT 0,
+  `request_failed` INT NOT NULL DEFAULT 0,
+  `lease_until` DATETIME NULL,
+  `in_progress_at` DATETIME NULL,
+  `finalizing_at` DATETIME NULL,
+  `completed_at` DATETIME NULL,
+  `failed_at` DATETIME NULL,
+  `expired_at` DATETIME NULL,
+  `cancelling_at` DATETIME NULL,
+  `cancelled_at` DATETIME NULL,
+  `expires_at` DATETIME NOT NULL,
+  `created_at` DATETIME NOT NULL,
+  `updated_at` DATETIME NOT NULL,
+  UNIQUE KEY `uk_batches_public_id` (`public_id`),
+  KEY `idx_batches_user_id_id` (`user_id`, `id`),
+  KEY `idx_batches_status_lease_until` (`status`, `lease_until`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `batch_request_results` (
+  `id` BIGINT PRIMARY KEY AUTO_INCREMENT,
+  `batch_id` BIGINT NOT NULL,
+  `line_index` INT NOT NULL,
+  `custom_id` VARCHAR(255) NOT NULL,
+  `request_id` VARCHAR(64) NOT NULL DEFAULT '',
+  `status_code` INT NOT NULL DEFAULT 0,
+  `body` LONGBLOB NULL,
+  `error_code` VARCHAR(64) NULL,
+  `error_message` TEXT NULL,
+  `created_at` DATETIME NOT NULL,
+  UNIQUE KEY `uk_batch_request_results_batch_line` (`batch_id`, `line_index`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,11 @@
+-- 0080_scheduler_cooldowns.sql: 持久化调度器的渠道封禁、channel_model 封禁与 credential 冷却，重启后恢复。
+
+CREATE TABLE IF NOT EXISTS `scheduler_cooldowns` (
+  `kind` VARCHAR(32) NOT NULL,
+  `subject` VARCHAR(191) NOT NULL,
+  `until_unix_ms` BIGINT NOT NULL,
+  `streak` INT NOT NULL DEFAULT 0,
+  `updated_at` DATETIME NOT NULL,
+  PRIMARY KEY (`kind`, `subject`),
+  KEY `idx_scheduler_cooldowns_until` (`until_unix_ms`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
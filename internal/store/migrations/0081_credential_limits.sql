@@ -0,0 +1,385 @@
+-- 0081_credential_limits.sql: 上游 credential/account 增加 RPM/TPM/并发上限；upstream_endpoints 上的同名字段作为默认值。
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'upstream_endpoints'
+    AND column_name = 'rpm_limit'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `upstream_endpoints` ADD COLUMN `rpm_limit` INT NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'upstream_endpoints'
+    AND column_name = 'tpm_limit'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `upstream_endpoints` ADD COLUMN `tpm_limit` INT NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'upstream_endpoints'
+    AND column_name = 'max_concurrency'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `upstream_endpoints` ADD COLUMN `max_concurrency` INT NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'openai_compatible_credentials'
+    AND column_name = 'rpm_limit'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `openai_compatible_credentials` ADD COLUMN `rpm_limit` INT NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'openai_compatible_credentials'
+    AND column_name = 'tpm_limit'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `openai_compatible_credentials` ADD COLUMN `tpm_limit` INT NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'openai_compatible_credentials'
+    AND column_name = 'max_concurrency'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `openai_compatible_credentials` ADD COLUMN `max_concurrency` INT NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'anthropic_credentials'
+    AND column_name = 'rpm_limit'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `anthropic_credentials` ADD COLUMN `rpm_limit` INT NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'anthropic_credentials'
+    AND column_name = 'tpm_limit'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `anthropic_credentials` ADD COLUMN `tpm_limit` INT NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'anthropic_credentials'
+    AND column_name = 'max_concurrency'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `anthropic_credentials` ADD COLUMN `max_concurrency` INT NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'gemini_credentials'
+    AND column_name = 'rpm_limit'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `gemini_credentials` ADD COLUMN `rpm_limit` INT NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'gemini_credentials'
+    AND column_name = 'tpm_limit'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `gemini_credentials` ADD COLUMN `tpm_limit` INT NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'gemini_credentials'
+    AND column_name = 'max_concurrency'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `gemini_credentials` ADD COLUMN `max_concurrency` INT NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'azure_openai_credentials'
+    AND column_name = 'rpm_limit'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `azure_openai_credentials` ADD COLUMN `rpm_limit` INT NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'azure_openai_credentials'
+    AND column_name = 'tpm_limit'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `azure_openai_credentials` ADD COLUMN `tpm_limit` INT NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'azure_openai_credentials'
+    AND column_name = 'max_concurrency'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `azure_openai_credentials` ADD COLUMN `max_concurrency` INT NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'bedrock_credentials'
+    AND column_name = 'rpm_limit'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `bedrock_credentials` ADD COLUMN `rpm_limit` INT NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'bedrock_credentials'
+    AND column_name = 'tpm_limit'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `bedrock_credentials` ADD COLUMN `tpm_limit` INT NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'bedrock_credentials'
+    AND column_name = 'max_concurrency'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `bedrock_credentials` ADD COLUMN `max_concurrency` INT NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'vertex_credentials'
+    AND column_name = 'rpm_limit'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `vertex_credentials` ADD COLUMN `rpm_limit` INT NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'vertex_credentials'
+    AND column_name = 'tpm_limit'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `vertex_credentials` ADD COLUMN `tpm_limit` INT NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'vertex_credentials'
+    AND column_name = 'max_concurrency'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `vertex_credentials` ADD COLUMN `max_concurrency` INT NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'codex_oauth_accounts'
+    AND column_name = 'rpm_limit'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `codex_oauth_accounts` ADD COLUMN `rpm_limit` INT NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'codex_oauth_accounts'
+    AND column_name = 'tpm_limit'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `codex_oauth_accounts` ADD COLUMN `tpm_limit` INT NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'codex_oauth_accounts'
+    AND column_name = 'max_concurrency'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `codex_oauth_accounts` ADD COLUMN `max_concurrency` INT NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
@@ -0,0 +1,161 @@
+-- 0082_cost_routing.sql: channel_models 增加上游成本价；channel_groups 增加 routing_mode（priority/cost）；usage_events 记录成本基准。
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'channel_models'
+    AND column_name = 'cost_input_usd_per_1m'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `channel_models` ADD COLUMN `cost_input_usd_per_1m` DECIMAL(20,6) NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'channel_models'
+    AND column_name = 'cost_output_usd_per_1m'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `channel_models` ADD COLUMN `cost_output_usd_per_1m` DECIMAL(20,6) NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'channel_models'
+    AND column_name = 'cost_cache_input_usd_per_1m'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `channel_models` ADD COLUMN `cost_cache_input_usd_per_1m` DECIMAL(20,6) NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'channel_models'
+    AND column_name = 'cost_cache_output_usd_per_1m'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `channel_models` ADD COLUMN `cost_cache_output_usd_per_1m` DECIMAL(20,6) NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'channel_groups'
+    AND column_name = 'routing_mode'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `channel_groups` ADD COLUMN `routing_mode` VARCHAR(16) NOT NULL DEFAULT ''priority''',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'usage_events'
+    AND column_name = 'cost_channel_model_id'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `usage_events` ADD COLUMN `cost_channel_model_id` BIGINT NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'usage_events'
+    AND column_name = 'cost_input_usd_per_1m'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `usage_events` ADD COLUMN `cost_input_usd_per_1m` DECIMAL(20,6) NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'usage_events'
+    AND column_name = 'cost_output_usd_per_1m'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `usage_events` ADD COLUMN `cost_output_usd_per_1m` DECIMAL(20,6) NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'usage_events'
+    AND column_name = 'cost_cache_input_usd_per_1m'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `usage_events` ADD COLUMN `cost_cache_input_usd_per_1m` DECIMAL(20,6) NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'usage_events'
+    AND column_name = 'cost_cache_output_usd_per_1m'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `usage_events` ADD COLUMN `cost_cache_output_usd_per_1m` DECIMAL(20,6) NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
@@ -0,0 +1,17 @@
+-- 0083_usage_events_routing_trace.sql: usage_events 增加 routing_trace（路由决策追踪 JSON，开启 gateway.routing_trace 时写入）。
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'usage_events'
+    AND column_name = 'routing_trace'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `usage_events` ADD COLUMN `routing_trace` MEDIUMTEXT NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
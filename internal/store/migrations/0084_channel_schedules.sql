@@ -0,0 +1,33 @@
+-- 0084_channel_schedules.sql: upstream_channels / channel_group_members 增加 schedule（可用时间窗 JSON，按 admin_time_zone 解释）。
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'upstream_channels'
+    AND column_name = 'schedule'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `upstream_channels` ADD COLUMN `schedule` TEXT NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'channel_group_members'
+    AND column_name = 'schedule'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `channel_group_members` ADD COLUMN `schedule` TEXT NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
@@ -0,0 +1,82 @@
+-- 0085_channel_group_canary.sql: channel_group_members 增加灰度（canary）状态、流量百分比、开始时间与最近检查时间；
+-- usage_events 增加 route_group_id（直接包含所选渠道的渠道组，用于按组统计灰度健康度）。
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'channel_group_members'
+    AND column_name = 'canary_status'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `channel_group_members` ADD COLUMN `canary_status` VARCHAR(16) NOT NULL DEFAULT ''''',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'channel_group_members'
+    AND column_name = 'canary_percent'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `channel_group_members` ADD COLUMN `canary_percent` INT NOT NULL DEFAULT 0',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'channel_group_members'
+    AND column_name = 'canary_started_at'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `channel_group_members` ADD COLUMN `canary_started_at` DATETIME NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'channel_group_members'
+    AND column_name = 'canary_checked_at'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `channel_group_members` ADD COLUMN `canary_checked_at` DATETIME NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'usage_events'
+    AND column_name = 'route_group_id'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `usage_events` ADD COLUMN `route_group_id` BIGINT NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
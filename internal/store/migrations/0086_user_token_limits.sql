@@ -0,0 +1,97 @@
+-- 0086_user_token_limits.sql: user_tokens 增加过期时间、消费上限（累计/1d/7d/30d）与模型白名单。
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'user_tokens'
+    AND column_name = 'expires_at'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `user_tokens` ADD COLUMN `expires_at` DATETIME NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'user_tokens'
+    AND column_name = 'budget_usd'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `user_tokens` ADD COLUMN `budget_usd` DECIMAL(20,6) NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'user_tokens'
+    AND column_name = 'budget_1d_usd'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `user_tokens` ADD COLUMN `budget_1d_usd` DECIMAL(20,6) NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'user_tokens'
+    AND column_name = 'budget_7d_usd'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `user_tokens` ADD COLUMN `budget_7d_usd` DECIMAL(20,6) NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'user_tokens'
+    AND column_name = 'budget_30d_usd'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `user_tokens` ADD COLUMN `budget_30d_usd` DECIMAL(20,6) NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'user_tokens'
+    AND column_name = 'allowed_models'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `user_tokens` ADD COLUMN `allowed_models` TEXT NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
@@ -0,0 +1,81 @@
+-- 0087_user_token_access.sql: user_tokens 增加来源限制（IP/CIDR 与 Referer 白名单）、违规计数与最近客户端 IP。
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'user_tokens'
+    AND column_name = 'allowed_ips'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `user_tokens` ADD COLUMN `allowed_ips` TEXT NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'user_tokens'
+    AND column_name = 'allowed_referers'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `user_tokens` ADD COLUMN `allowed_referers` TEXT NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'user_tokens'
+    AND column_name = 'access_violation_limit'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `user_tokens` ADD COLUMN `access_violation_limit` INT NOT NULL DEFAULT 0',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'user_tokens'
+    AND column_name = 'access_violations'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `user_tokens` ADD COLUMN `access_violations` INT NOT NULL DEFAULT 0',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'user_tokens'
+    AND column_name = 'last_client_ip'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `user_tokens` ADD COLUMN `last_client_ip` VARCHAR(64) NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
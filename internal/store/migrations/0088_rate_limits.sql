@@ -0,0 +1,97 @@
+-- 0088_rate_limits.sql: main_groups/subscription_plans/user_tokens 增加 RPM/TPM 限流上限（0 表示不限制）。
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'main_groups'
+    AND column_name = 'rpm_limit'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `main_groups` ADD COLUMN `rpm_limit` BIGINT NOT NULL DEFAULT 0',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'main_groups'
+    AND column_name = 'tpm_limit'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `main_groups` ADD COLUMN `tpm_limit` BIGINT NOT NULL DEFAULT 0',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'subscription_plans'
+    AND column_name = 'rpm_limit'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `subscription_plans` ADD COLUMN `rpm_limit` BIGINT NOT NULL DEFAULT 0',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'subscription_plans'
+    AND column_name = 'tpm_limit'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `subscription_plans` ADD COLUMN `tpm_limit` BIGINT NOT NULL DEFAULT 0',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'user_tokens'
+    AND column_name = 'rpm_limit'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `user_tokens` ADD COLUMN `rpm_limit` BIGINT NOT NULL DEFAULT 0',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'user_tokens'
+    AND column_name = 'tpm_limit'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `user_tokens` ADD COLUMN `tpm_limit` BIGINT NOT NULL DEFAULT 0',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
@@ -0,0 +1,24 @@
+-- 0089_balance_transactions.sql: 余额流水（只追加）；每次余额变动在同一事务内写入一条，并为已有余额补写期初记录。
+
+CREATE TABLE IF NOT EXISTS `balance_transactions` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT,
+  `user_id` BIGINT NOT NULL,
+  `type` VARCHAR(32) NOT NULL,
+  `amount_usd` DECIMAL(20,6) NOT NULL,
+  `balance_after_usd` DECIMAL(20,6) NOT NULL,
+  `usage_event_id` BIGINT NULL,
+  `topup_order_id` BIGINT NULL,
+  `redemption_code_id` BIGINT NULL,
+  `actor_user_id` BIGINT NULL,
+  `note` VARCHAR(255) NULL,
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_balance_transactions_user_id` (`user_id`, `id`),
+  KEY `idx_balance_transactions_usage_event_id` (`usage_event_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+INSERT INTO `balance_transactions`(`user_id`, `type`, `amount_usd`, `balance_after_usd`, `created_at`)
+SELECT b.`user_id`, 'opening', b.`usd`, b.`usd`, CURRENT_TIMESTAMP
+FROM `user_balances` b
+WHERE b.`usd` <> 0
+  AND NOT EXISTS (SELECT 1 FROM `balance_transactions` t WHERE t.`user_id` = b.`user_id`);
@@ -0,0 +1,174 @@
+-- 0090_organizations.sql: 组织（成员/角色、组织余额）；user_tokens/user_subscriptions/usage_events/balance_transactions 增加 org_id。
+
+CREATE TABLE IF NOT EXISTS `organizations` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT,
+  `name` VARCHAR(64) NOT NULL,
+  `status` TINYINT NOT NULL DEFAULT 1,
+  `created_at` DATETIME NOT NULL,
+  `updated_at` DATETIME NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_organizations_name` (`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `organization_members` (
+  `org_id` BIGINT NOT NULL,
+  `user_id` BIGINT NOT NULL,
+  `role` VARCHAR(16) NOT NULL DEFAULT 'member',
+  `spend_limit_30d_usd` DECIMAL(20,6) NULL,
+  `created_at` DATETIME NOT NULL,
+  `updated_at` DATETIME NOT NULL,
+  PRIMARY KEY (`org_id`, `user_id`),
+  UNIQUE KEY `uk_organization_members_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `organization_balances` (
+  `org_id` BIGINT NOT NULL,
+  `usd` DECIMAL(20,6) NOT NULL DEFAULT 0,
+  `created_at` DATETIME NOT NULL,
+  `updated_at` DATETIME NOT NULL,
+  PRIMARY KEY (`org_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'user_tokens'
+    AND column_name = 'org_id'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `user_tokens` ADD COLUMN `org_id` BIGINT NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'user_subscriptions'
+    AND column_name = 'org_id'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `user_subscriptions` ADD COLUMN `org_id` BIGINT NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'usage_events'
+    AND column_name = 'org_id'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `usage_events` ADD COLUMN `org_id` BIGINT NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @col_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.COLUMNS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'balance_transactions'
+    AND column_name = 'org_id'
+);
+SET @ddl := IF(
+  @col_exists = 0,
+  'ALTER TABLE `balance_transactions` ADD COLUMN `org_id` BIGINT NULL',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @idx_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.STATISTICS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'user_tokens'
+    AND index_name = 'idx_user_tokens_org_id'
+);
+SET @ddl := IF(
+  @idx_exists = 0,
+  'CREATE INDEX `idx_user_tokens_org_id` ON `user_tokens` (`org_id`)',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @idx_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.STATISTICS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'user_subscriptions'
+    AND index_name = 'idx_user_subscriptions_org_status_end_at'
+);
+SET @ddl := IF(
+  @idx_exists = 0,
+  'CREATE INDEX `idx_user_subscriptions_org_status_end_at` ON `user_subscriptions` (`org_id`, `status`, `end_at`)',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @idx_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.STATISTICS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'usage_events'
+    AND index_name = 'idx_usage_events_org_subscription_state_time'
+);
+SET @ddl := IF(
+  @idx_exists = 0,
+  'CREATE INDEX `idx_usage_events_org_subscription_state_time` ON `usage_events` (`org_id`, `subscription_id`, `state`, `time`)',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @idx_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.STATISTICS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'usage_events'
+    AND index_name = 'idx_usage_events_org_user_state_time'
+);
+SET @ddl := IF(
+  @idx_exists = 0,
+  'CREATE INDEX `idx_usage_events_org_user_state_time` ON `usage_events` (`org_id`, `user_id`, `state`, `time`)',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @idx_exists := (
+  SELECT COUNT(*)
+  FROM information_schema.STATISTICS
+  WHERE table_schema = DATABASE()
+    AND table_name = 'balance_transactions'
+    AND index_name = 'idx_balance_transactions_org_id'
+);
+SET @ddl := IF(
+  @idx_exists = 0,
+  'CREATE INDEX `idx_balance_transactions_org_id` ON `balance_transactions` (`org_id`, `id`)',
+  'SELECT 1'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
@@ -49,9 +49,11 @@ type ChannelGroup struct {
 	Name            string
 	Description     *string
 	PriceMultiplier decimal.Decimal
-	Status          int
-	CreatedAt       time.Time
-	UpdatedAt       time.Time
+	// RoutingMode 为组内渠道的路由方式：priority（默认）或 cost（同优先级内按成本价从低到高）。
+	RoutingMode string
+	Status      int
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
 }
 
 type ChannelGroupMember struct {
@@ -75,6 +77,12 @@ type UserToken struct {
 	CreatedAt  time.Time
 	RevokedAt  *time.Time
 	LastUsedAt *time.Time
+
+	Limits UserTokenLimits
+
+	Access           UserTokenAccess
+	AccessViolations int
+	LastClientIP     *string
 }
 
 type UserSession struct {
@@ -123,6 +131,7 @@ type UpstreamChannel struct {
 	ModelSuffixPreserve   string
 	RequestBodyBlacklist  string
 	RequestBodyWhitelist  string
+	Schedule              string
 
 	LastTestAt        *time.Time
 	LastTestLatencyMS int
@@ -136,6 +145,8 @@ type UpstreamChannelSetting struct {
 	ThinkingToContent      bool   `json:"thinking_to_content,omitempty"`
 	ChatCompletionsEnabled bool   `json:"chat_completions_enabled"`
 	ResponsesEnabled       bool   `json:"responses_enabled"`
+	EmbeddingsEnabled      bool   `json:"embeddings_enabled,omitempty"`
+	MessagesEnabled        bool   `json:"messages_enabled,omitempty"`
 	Proxy                  string `json:"proxy,omitempty"`
 	PassThroughBodyEnabled bool   `json:"pass_through_body_enabled,omitempty"`
 	SystemPrompt           string `json:"system_prompt,omitempty"`
@@ -147,10 +158,15 @@ type UpstreamEndpoint struct {
 	ID        int64
 	ChannelID int64
 	BaseURL   string
-	Status    int
-	Priority  int
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	// APIVersion 为上游 api-version（目前仅 azure_openai 使用）；为空时使用默认版本。
+	APIVersion *string
+	Status     int
+	Priority   int
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+
+	// Limits 为该 endpoint 下 credential 的默认限流配置（credential 自身配置优先）。
+	Limits CredentialLimits
 }
 
 type OpenAICompatibleCredential struct {
@@ -163,6 +179,8 @@ type OpenAICompatibleCredential struct {
 	LastUsedAt *time.Time
 	CreatedAt  time.Time
 	UpdatedAt  time.Time
+
+	Limits CredentialLimits
 }
 
 type AnthropicCredential struct {
@@ -175,6 +193,72 @@ type AnthropicCredential struct {
 	LastUsedAt *time.Time
 	CreatedAt  time.Time
 	UpdatedAt  time.Time
+
+	Limits CredentialLimits
+}
+
+type GeminiCredential struct {
+	ID         int64
+	EndpointID int64
+	Name       *string
+	APIKeyEnc  []byte
+	APIKeyHint *string
+	Status     int
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+
+	Limits CredentialLimits
+}
+
+type AzureOpenAICredential struct {
+	ID         int64
+	EndpointID int64
+	Name       *string
+	APIKeyEnc  []byte
+	APIKeyHint *string
+	Status     int
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+
+	Limits CredentialLimits
+}
+
+// BedrockCredential 为 AWS Bedrock 上游凭证：access key + secret（+ 可选 session token）+ region，用于 SigV4 签名。
+type BedrockCredential struct {
+	ID                 int64
+	EndpointID         int64
+	Name               *string
+	AccessKeyID        string
+	SecretAccessKeyEnc []byte
+	SessionTokenEnc    []byte
+	Region             string
+	AccessKeyHint      *string
+	Status             int
+	LastUsedAt         *time.Time
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+
+	Limits CredentialLimits
+}
+
+// VertexCredential 为 Google Vertex AI 上游凭证：service account JSON（JWT-bearer 换取 access token）+ project/location。
+type VertexCredential struct {
+	ID                    int64
+	EndpointID            int64
+	Name                  *string
+	ProjectID             string
+	ClientEmail           string
+	Location              string
+	ServiceAccountJSONEnc []byte
+	KeyHint               *string
+	Status                int
+	LastUsedAt            *time.Time
+	CreatedAt             time.Time
+	UpdatedAt             time.Time
+
+	Limits CredentialLimits
 }
 
 type CodexOAuthPending struct {
@@ -216,6 +300,8 @@ type CodexOAuthAccount struct {
 	QuotaError                *string
 	CreatedAt                 time.Time
 	UpdatedAt                 time.Time
+
+	Limits CredentialLimits
 }
 
 type OAuthApp struct {
@@ -282,6 +368,7 @@ type ManagedModel struct {
 	PriorityOutputUSDPer1M     *decimal.Decimal
 	PriorityCacheInputUSDPer1M *decimal.Decimal
 	HighContextPricing         *ManagedModelHighContextPricing
+	BatchDiscount              *decimal.Decimal
 	Status                     int
 	CreatedAt                  time.Time
 }
@@ -292,6 +379,7 @@ type ChannelModel struct {
 	PublicID      string
 	UpstreamModel string
 	Status        int
+	Cost          ChannelModelCost
 	CreatedAt     time.Time
 	UpdatedAt     time.Time
 }
@@ -304,6 +392,7 @@ type ChannelModelBinding struct {
 	PublicID      string
 	UpstreamModel string
 	Status        int
+	Cost          ChannelModelCost
 	CreatedAt     time.Time
 	UpdatedAt     time.Time
 }
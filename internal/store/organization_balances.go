@@ -0,0 +1,111 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// balanceAccount 返回流水所属余额账户的表、主键列与主键值：OrgID 非空时为组织余额，否则为用户余额。
+func balanceAccount(in balanceTxInput) (table string, keyCol string, key int64) {
+	if in.OrgID != nil {
+		return "organization_balances", "org_id", *in.OrgID
+	}
+	return "user_balances", "user_id", in.UserID
+}
+
+func balanceAccountAddSQL(d Dialect, table, keyCol string) string {
+	if d == DialectSQLite {
+		return `UPDATE ` + table + ` SET usd=ROUND(usd+?, 6), updated_at=CURRENT_TIMESTAMP WHERE ` + keyCol + `=?`
+	}
+	return `UPDATE ` + table + ` SET usd=usd+?, updated_at=CURRENT_TIMESTAMP WHERE ` + keyCol + `=?`
+}
+
+// lockBalanceAccountTx 初始化（如不存在）并锁定余额账户，返回当前余额。
+func lockBalanceAccountTx(ctx context.Context, tx *sql.Tx, dialect Dialect, in balanceTxInput) (decimal.Decimal, error) {
+	table, keyCol, key := balanceAccount(in)
+	stmtInit := fmt.Sprintf(`
+%s INTO %s(%s, usd, created_at, updated_at)
+VALUES(?, 0, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+`, insertIgnoreVerb(dialect), table, keyCol)
+	if _, err := tx.ExecContext(ctx, stmtInit, key); err != nil {
+		return decimal.Zero, fmt.Errorf("初始化余额失败: %w", err)
+	}
+	var bal decimal.Decimal
+	q := `SELECT usd FROM ` + table + ` WHERE ` + keyCol + `=?` + forUpdateClause(dialect)
+	if err := tx.QueryRowContext(ctx, q, key).Scan(&bal); err != nil {
+		return decimal.Zero, fmt.Errorf("查询余额失败: %w", err)
+	}
+	return bal, nil
+}
+
+// changeBalanceAccountTx 按 in.AmountUSD（入账为正、扣减为负）变动余额账户并追加流水，返回变动后的余额。
+// 调用方需先通过 lockBalanceAccountTx 确保账户存在。
+func changeBalanceAccountTx(ctx context.Context, tx *sql.Tx, dialect Dialect, in balanceTxInput) (decimal.Decimal, error) {
+	in.AmountUSD = in.AmountUSD.Truncate(USDScale)
+	table, keyCol, key := balanceAccount(in)
+	if _, err := tx.ExecContext(ctx, balanceAccountAddSQL(dialect, table, keyCol), in.AmountUSD, key); err != nil {
+		return decimal.Zero, fmt.Errorf("变动余额失败: %w", err)
+	}
+	return recordBalanceTransactionTx(ctx, tx, in)
+}
+
+func (s *Store) GetOrganizationBalanceUSD(ctx context.Context, orgID int64) (decimal.Decimal, error) {
+	if orgID <= 0 {
+		return decimal.Zero, errors.New("org_id 不能为空")
+	}
+	var usd decimal.Decimal
+	if err := s.db.QueryRowContext(ctx, `SELECT usd FROM organization_balances WHERE org_id=?`, orgID).Scan(&usd); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return decimal.Zero, nil
+		}
+		return decimal.Zero, fmt.Errorf("查询组织余额失败: %w", err)
+	}
+	return usd.Truncate(USDScale), nil
+}
+
+// AddOrganizationBalanceUSDByAdmin 由管理员为组织余额入账，并记录操作人与备注。
+func (s *Store) AddOrganizationBalanceUSDByAdmin(ctx context.Context, orgID int64, actorUserID int64, deltaUSD decimal.Decimal, note string) (decimal.Decimal, error) {
+	if orgID <= 0 {
+		return decimal.Zero, errors.New("org_id 不能为空")
+	}
+	if deltaUSD.Truncate(USDScale).LessThanOrEqual(decimal.Zero) {
+		return decimal.Zero, errors.New("delta_usd 不合法")
+	}
+	in := balanceTxInput{UserID: actorUserID, OrgID: &orgID, Type: BalanceTxAdminCredit, AmountUSD: deltaUSD}
+	if actorUserID > 0 {
+		in.ActorUserID = int64Ptr(actorUserID)
+	}
+	if note = strings.TrimSpace(note); note != "" {
+		in.Note = &note
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("开始事务失败: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var exists int
+	if err := tx.QueryRowContext(ctx, `SELECT 1 FROM organizations WHERE id=?`, orgID).Scan(&exists); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return decimal.Zero, sql.ErrNoRows
+		}
+		return decimal.Zero, fmt.Errorf("查询组织失败: %w", err)
+	}
+	if _, err := lockBalanceAccountTx(ctx, tx, s.dialect, in); err != nil {
+		return decimal.Zero, err
+	}
+	newBal, err := changeBalanceAccountTx(ctx, tx, s.dialect, in)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if err := tx.Commit(); err != nil {
+		return decimal.Zero, fmt.Errorf("提交事务失败: %w", err)
+	}
+	return newBal, nil
+}
@@ -0,0 +1,768 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"realms/internal/crypto"
+)
+
+// 组织成员角色：owner 可管理一切（含其它 owner）；admin 可管理成员、额度与组织 Token；member 仅可创建/使用自己的组织 Token。
+const (
+	OrgRoleOwner  = "owner"
+	OrgRoleAdmin  = "admin"
+	OrgRoleMember = "member"
+)
+
+var (
+	ErrOrganizationMemberExists = errors.New("该用户已属于某个组织")
+	ErrOrganizationLastOwner    = errors.New("组织至少需要保留一名 owner")
+	// ErrOrganizationMemberSpendLimitExceeded 表示本次预留后成员 30 天消费将超过其上限。
+	ErrOrganizationMemberSpendLimitExceeded = errors.New("组织成员消费额度已用尽")
+)
+
+// Organization 为组织：成员共享组织余额与组织订阅，通过组织 Token 使用时由组织计费。
+type Organization struct {
+	ID        int64
+	Name      string
+	Status    int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// OrganizationSummary 为组织列表项（附成员数与组织余额）。
+type OrganizationSummary struct {
+	Organization
+	MemberCount int64
+	BalanceUSD  decimal.Decimal
+}
+
+// OrganizationMember 为组织成员。SpendLimit30DUSD 为该成员最近 30 天由组织计费的消费上限（nil 表示不限制）。
+type OrganizationMember struct {
+	OrgID            int64
+	UserID           int64
+	Email            string
+	Username         string
+	Role             string
+	SpendLimit30DUSD *decimal.Decimal
+	CreatedAt        time.Time
+}
+
+// CanManage 判断成员是否可管理组织（成员、额度与组织 Token）。
+func (m OrganizationMember) CanManage() bool {
+	return m.Role == OrgRoleOwner || m.Role == OrgRoleAdmin
+}
+
+// OrganizationBilling 为组织 Token 的计费归属：MemberRole 为空表示 Token 创建者已不在组织内。
+type OrganizationBilling struct {
+	OrgID            int64
+	OrgStatus        int
+	MemberRole       string
+	SpendLimit30DUSD *decimal.Decimal
+}
+
+// OrganizationMemberSpendWindow 为成员消费上限的滚动窗口。
+const OrganizationMemberSpendWindow = 30 * 24 * time.Hour
+
+func NormalizeOrgRole(role string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(role)) {
+	case OrgRoleOwner:
+		return OrgRoleOwner, nil
+	case OrgRoleAdmin:
+		return OrgRoleAdmin, nil
+	case "", OrgRoleMember:
+		return OrgRoleMember, nil
+	default:
+		return "", errors.New("role 不合法")
+	}
+}
+
+func normalizeOrganizationName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.New("组织名称不能为空")
+	}
+	if len(name) > 64 {
+		return "", errors.New("组织名称过长")
+	}
+	return name, nil
+}
+
+// NormalizeOrgSpendLimit 校验成员消费上限：nil 或 0 表示不限制，不得为负。
+func NormalizeOrgSpendLimit(v *decimal.Decimal) (*decimal.Decimal, error) {
+	if v == nil {
+		return nil, nil
+	}
+	d := v.Truncate(USDScale)
+	if d.IsNegative() {
+		return nil, errors.New("spend_limit_30d_usd 不能为负数")
+	}
+	if d.IsZero() {
+		return nil, nil
+	}
+	return &d, nil
+}
+
+// CreateOrganization 创建组织，并将 ownerUserID 设为 owner。
+func (s *Store) CreateOrganization(ctx context.Context, name string, ownerUserID int64) (int64, error) {
+	name, err := normalizeOrganizationName(name)
+	if err != nil {
+		return 0, err
+	}
+	if ownerUserID <= 0 {
+		return 0, errors.New("owner_user_id 不能为空")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("开始事务失败: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx, `
+INSERT INTO organizations(name, status, created_at, updated_at)
+VALUES(?, 1, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+`, name)
+	if err != nil {
+		if isUniqueConstraintError(err) {
+			return 0, errors.New("组织名称已存在")
+		}
+		return 0, fmt.Errorf("创建组织失败: %w", err)
+	}
+	orgID, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("获取组织 id 失败: %w", err)
+	}
+	if err := insertOrganizationMemberTx(ctx, tx, orgID, ownerUserID, OrgRoleOwner); err != nil {
+		return 0, err
+	}
+	if _, err := tx.ExecContext(ctx, `
+INSERT INTO organization_balances(org_id, usd, created_at, updated_at)
+VALUES(?, 0, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+`, orgID); err != nil {
+		return 0, fmt.Errorf("初始化组织余额失败: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("提交事务失败: %w", err)
+	}
+	return orgID, nil
+}
+
+func insertOrganizationMemberTx(ctx context.Context, tx *sql.Tx, orgID, userID int64, role string) error {
+	var exists int
+	if err := tx.QueryRowContext(ctx, `SELECT 1 FROM users WHERE id=?`, userID).Scan(&exists); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("用户不存在")
+		}
+		return fmt.Errorf("查询用户失败: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+INSERT INTO organization_members(org_id, user_id, role, spend_limit_30d_usd, created_at, updated_at)
+VALUES(?, ?, ?, NULL, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+`, orgID, userID, role); err != nil {
+		if isUniqueConstraintError(err) {
+			return ErrOrganizationMemberExists
+		}
+		return fmt.Errorf("添加组织成员失败: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) GetOrganization(ctx context.Context, orgID int64) (Organization, error) {
+	var o Organization
+	err := s.db.QueryRowContext(ctx, `
+SELECT id, name, status, created_at, updated_at
+FROM organizations
+WHERE id=?
+`, orgID).Scan(&o.ID, &o.Name, &o.Status, &o.CreatedAt, &o.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Organization{}, sql.ErrNoRows
+		}
+		return Organization{}, fmt.Errorf("查询组织失败: %w", err)
+	}
+	return o, nil
+}
+
+func (s *Store) ListOrganizations(ctx context.Context) ([]OrganizationSummary, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT o.id, o.name, o.status, o.created_at, o.updated_at,
+       (SELECT COUNT(*) FROM organization_members m WHERE m.org_id=o.id),
+       COALESCE(b.usd, 0)
+FROM organizations o
+LEFT JOIN organization_balances b ON b.org_id=o.id
+ORDER BY o.id DESC
+`)
+	if err != nil {
+		return nil, fmt.Errorf("查询组织列表失败: %w", err)
+	}
+	defer rows.Close()
+
+	var out []OrganizationSummary
+	for rows.Next() {
+		var o OrganizationSummary
+		if err := rows.Scan(&o.ID, &o.Name, &o.Status, &o.CreatedAt, &o.UpdatedAt, &o.MemberCount, &o.BalanceUSD); err != nil {
+			return nil, fmt.Errorf("扫描组织失败: %w", err)
+		}
+		o.BalanceUSD = o.BalanceUSD.Truncate(USDScale)
+		out = append(out, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历组织失败: %w", err)
+	}
+	return out, nil
+}
+
+func (s *Store) UpdateOrganization(ctx context.Context, orgID int64, name string, status int) error {
+	name, err := normalizeOrganizationName(name)
+	if err != nil {
+		return err
+	}
+	if status != 0 && status != 1 {
+		return errors.New("status 不合法")
+	}
+	res, err := s.db.ExecContext(ctx, `
+UPDATE organizations
+SET name=?, status=?, updated_at=CURRENT_TIMESTAMP
+WHERE id=?
+`, name, status, orgID)
+	if err != nil {
+		if isUniqueConstraintError(err) {
+			return errors.New("组织名称已存在")
+		}
+		return fmt.Errorf("更新组织失败: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+const organizationMemberColumns = `m.org_id, m.user_id, COALESCE(u.email, ''), COALESCE(u.username, ''), m.role, m.spend_limit_30d_usd, m.created_at`
+
+func scanOrganizationMember(row interface{ Scan(dest ...any) error }) (OrganizationMember, error) {
+	var m OrganizationMember
+	var limit decimal.NullDecimal
+	if err := row.Scan(&m.OrgID, &m.UserID, &m.Email, &m.Username, &m.Role, &limit, &m.CreatedAt); err != nil {
+		return OrganizationMember{}, err
+	}
+	if limit.Valid {
+		v := limit.Decimal.Truncate(USDScale)
+		m.SpendLimit30DUSD = &v
+	}
+	return m, nil
+}
+
+// GetOrganizationMembership 返回用户所属的组织与成员信息；用户不属于任何组织时返回 sql.ErrNoRows。
+func (s *Store) GetOrganizationMembership(ctx context.Context, userID int64) (Organization, OrganizationMember, error) {
+	row := s.db.QueryRowContext(ctx, `
+SELECT `+organizationMemberColumns+`
+FROM organization_members m
+LEFT JOIN users u ON u.id=m.user_id
+WHERE m.user_id=?
+`, userID)
+	m, err := scanOrganizationMember(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Organization{}, OrganizationMember{}, sql.ErrNoRows
+		}
+		return Organization{}, OrganizationMember{}, fmt.Errorf("查询组织成员失败: %w", err)
+	}
+	o, err := s.GetOrganization(ctx, m.OrgID)
+	if err != nil {
+		return Organization{}, OrganizationMember{}, err
+	}
+	return o, m, nil
+}
+
+func (s *Store) GetOrganizationMember(ctx context.Context, orgID, userID int64) (OrganizationMember, error) {
+	row := s.db.QueryRowContext(ctx, `
+SELECT `+organizationMemberColumns+`
+FROM organization_members m
+LEFT JOIN users u ON u.id=m.user_id
+WHERE m.org_id=? AND m.user_id=?
+`, orgID, userID)
+	m, err := scanOrganizationMember(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return OrganizationMember{}, sql.ErrNoRows
+		}
+		return OrganizationMember{}, fmt.Errorf("查询组织成员失败: %w", err)
+	}
+	return m, nil
+}
+
+func (s *Store) ListOrganizationMembers(ctx context.Context, orgID int64) ([]OrganizationMember, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT `+organizationMemberColumns+`
+FROM organization_members m
+LEFT JOIN users u ON u.id=m.user_id
+WHERE m.org_id=?
+ORDER BY CASE m.role WHEN 'owner' THEN 0 WHEN 'admin' THEN 1 ELSE 2 END, m.user_id ASC
+`, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("查询组织成员失败: %w", err)
+	}
+	defer rows.Close()
+
+	var out []OrganizationMember
+	for rows.Next() {
+		m, err := scanOrganizationMember(rows)
+		if err != nil {
+			return nil, fmt.Errorf("扫描组织成员失败: %w", err)
+		}
+		out = append(out, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历组织成员失败: %w", err)
+	}
+	return out, nil
+}
+
+func (s *Store) AddOrganizationMember(ctx context.Context, orgID, userID int64, role string) error {
+	role, err := NormalizeOrgRole(role)
+	if err != nil {
+		return err
+	}
+	if orgID <= 0 || userID <= 0 {
+		return errors.New("参数不合法")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开始事务失败: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var exists int
+	if err := tx.QueryRowContext(ctx, `SELECT 1 FROM organizations WHERE id=?`, orgID).Scan(&exists); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sql.ErrNoRows
+		}
+		return fmt.Errorf("查询组织失败: %w", err)
+	}
+	if err := insertOrganizationMemberTx(ctx, tx, orgID, userID, role); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %w", err)
+	}
+	return nil
+}
+
+func countOrganizationOwnersTx(ctx context.Context, tx *sql.Tx, dialect Dialect, orgID int64) (int64, error) {
+	var n int64
+	// 锁定 owner 行，避免并发降级/移除导致组织无 owner。
+	q := `SELECT COUNT(*) FROM organization_members WHERE org_id=? AND role=?` + forUpdateClause(dialect)
+	if err := tx.QueryRowContext(ctx, q, orgID, OrgRoleOwner).Scan(&n); err != nil {
+		return 0, fmt.Errorf("查询组织 owner 失败: %w", err)
+	}
+	return n, nil
+}
+
+// UpdateOrganizationMember 更新成员角色与消费上限；不允许降级最后一名 owner。
+func (s *Store) UpdateOrganizationMember(ctx context.Context, orgID, userID int64, role string, spendLimit30DUSD *decimal.Decimal) error {
+	role, err := NormalizeOrgRole(role)
+	if err != nil {
+		return err
+	}
+	spendLimit30DUSD, err = NormalizeOrgSpendLimit(spendLimit30DUSD)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开始事务失败: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var current string
+	if err := tx.QueryRowContext(ctx, `SELECT role FROM organization_members WHERE org_id=? AND user_id=?`+forUpdateClause(s.dialect), orgID, userID).Scan(&current); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sql.ErrNoRows
+		}
+		return fmt.Errorf("查询组织成员失败: %w", err)
+	}
+	if current == OrgRoleOwner && role != OrgRoleOwner {
+		n, err := countOrganizationOwnersTx(ctx, tx, s.dialect, orgID)
+		if err != nil {
+			return err
+		}
+		if n <= 1 {
+			return ErrOrganizationLastOwner
+		}
+	}
+	var limit any
+	if spendLimit30DUSD != nil {
+		limit = *spendLimit30DUSD
+	}
+	if _, err := tx.ExecContext(ctx, `
+UPDATE organization_members
+SET role=?, spend_limit_30d_usd=?, updated_at=CURRENT_TIMESTAMP
+WHERE org_id=? AND user_id=?
+`, role, limit, orgID, userID); err != nil {
+		return fmt.Errorf("更新组织成员失败: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %w", err)
+	}
+	return nil
+}
+
+// RemoveOrganizationMember 移除成员，并撤销其创建的组织 Token；不允许移除最后一名 owner。
+func (s *Store) RemoveOrganizationMember(ctx context.Context, orgID, userID int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开始事务失败: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var current string
+	if err := tx.QueryRowContext(ctx, `SELECT role FROM organization_members WHERE org_id=? AND user_id=?`+forUpdateClause(s.dialect), orgID, userID).Scan(&current); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sql.ErrNoRows
+		}
+		return fmt.Errorf("查询组织成员失败: %w", err)
+	}
+	if current == OrgRoleOwner {
+		n, err := countOrganizationOwnersTx(ctx, tx, s.dialect, orgID)
+		if err != nil {
+			return err
+		}
+		if n <= 1 {
+			return ErrOrganizationLastOwner
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM organization_members WHERE org_id=? AND user_id=?`, orgID, userID); err != nil {
+		return fmt.Errorf("移除组织成员失败: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+UPDATE user_tokens
+SET status=0, revoked_at=CURRENT_TIMESTAMP, token_plain=NULL
+WHERE org_id=? AND user_id=? AND status=1
+`, orgID, userID); err != nil {
+		return fmt.Errorf("撤销组织 Token 失败: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %w", err)
+	}
+	return nil
+}
+
+// CreateOrganizationToken 为组织成员创建组织 Token：通过该 Token 的请求由组织计费，用量归属创建者。
+func (s *Store) CreateOrganizationToken(ctx context.Context, orgID, userID int64, name *string, rawToken string) (int64, *string, error) {
+	if orgID <= 0 || userID <= 0 {
+		return 0, nil, errors.New("参数不合法")
+	}
+	tokenHash := crypto.TokenHash(rawToken)
+	hint := tokenHint(rawToken)
+	res, err := s.db.ExecContext(ctx, `
+INSERT INTO user_tokens(user_id, org_id, name, token_hash, token_plain, token_hint, status, created_at)
+VALUES(?, ?, ?, ?, ?, ?, 1, CURRENT_TIMESTAMP)
+`, userID, orgID, name, tokenHash, rawToken, hint)
+	if err != nil {
+		return 0, nil, fmt.Errorf("创建组织 Token 失败: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, nil, fmt.Errorf("获取 Token id 失败: %w", err)
+	}
+	return id, hint, nil
+}
+
+// OrganizationToken 为组织 Token 列表项。
+type OrganizationToken struct {
+	ID         int64
+	UserID     int64
+	UserEmail  string
+	Name       *string
+	TokenHint  *string
+	Status     int
+	CreatedAt  time.Time
+	RevokedAt  *time.Time
+	LastUsedAt *time.Time
+}
+
+func (s *Store) ListOrganizationTokens(ctx context.Context, orgID int64) ([]OrganizationToken, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT t.id, t.user_id, COALESCE(u.email, ''), t.name, t.token_hint, t.status, t.created_at, t.revoked_at, t.last_used_at
+FROM user_tokens t
+LEFT JOIN users u ON u.id=t.user_id
+WHERE t.org_id=?
+ORDER BY t.id DESC
+`, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("查询组织 Token 失败: %w", err)
+	}
+	defer rows.Close()
+
+	var out []OrganizationToken
+	for rows.Next() {
+		var t OrganizationToken
+		if err := rows.Scan(&t.ID, &t.UserID, &t.UserEmail, &t.Name, &t.TokenHint, &t.Status, &t.CreatedAt, &t.RevokedAt, &t.LastUsedAt); err != nil {
+			return nil, fmt.Errorf("扫描组织 Token 失败: %w", err)
+		}
+		out = append(out, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历组织 Token 失败: %w", err)
+	}
+	return out, nil
+}
+
+// RevokeOrganizationToken 撤销组织 Token；userID 非 0 时仅允许撤销该成员创建的 Token。
+func (s *Store) RevokeOrganizationToken(ctx context.Context, orgID, tokenID, userID int64) error {
+	q := `
+UPDATE user_tokens
+SET status=0, revoked_at=CURRENT_TIMESTAMP, token_plain=NULL
+WHERE id=? AND org_id=? AND status=1`
+	args := []any{tokenID, orgID}
+	if userID > 0 {
+		q += ` AND user_id=?`
+		args = append(args, userID)
+	}
+	res, err := s.db.ExecContext(ctx, q, args...)
+	if err != nil {
+		return fmt.Errorf("撤销组织 Token 失败: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetOrganizationBillingByToken 返回组织 Token 的计费归属；个人 Token 返回 sql.ErrNoRows。
+func (s *Store) GetOrganizationBillingByToken(ctx context.Context, tokenID int64) (OrganizationBilling, error) {
+	var out OrganizationBilling
+	var role sql.NullString
+	var limit decimal.NullDecimal
+	err := s.db.QueryRowContext(ctx, `
+SELECT o.id, o.status, m.role, m.spend_limit_30d_usd
+FROM user_tokens t
+JOIN organizations o ON o.id=t.org_id
+LEFT JOIN organization_members m ON m.org_id=t.org_id AND m.user_id=t.user_id
+WHERE t.id=?
+`, tokenID).Scan(&out.OrgID, &out.OrgStatus, &role, &limit)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return OrganizationBilling{}, sql.ErrNoRows
+		}
+		return OrganizationBilling{}, fmt.Errorf("查询组织计费归属失败: %w", err)
+	}
+	out.MemberRole = role.String
+	if limit.Valid {
+		v := limit.Decimal.Truncate(USDScale)
+		out.SpendLimit30DUSD = &v
+	}
+	return out, nil
+}
+
+const sumOrganizationMemberUSDSQL = `
+SELECT
+  SUM(CASE WHEN state=? THEN committed_usd ELSE 0 END) AS committed_sum,
+  SUM(CASE WHEN state=? AND reserve_expires_at >= ? THEN reserved_usd ELSE 0 END) AS reserved_sum
+FROM usage_events
+WHERE org_id=? AND user_id=? AND time >= ? AND (state=? OR state=?)
+`
+
+// SumOrganizationMemberUSD 汇总成员自 since 起由组织计费的已结算与未过期预留金额。
+func (s *Store) SumOrganizationMemberUSD(ctx context.Context, orgID, userID int64, since, now time.Time) (committedUSD decimal.Decimal, reservedUSD decimal.Decimal, err error) {
+	committedUSD, reservedUSD, err = scanCommittedAndReservedUSD(s.db.QueryRowContext(ctx, sumOrganizationMemberUSDSQL,
+		UsageStateCommitted, UsageStateReserved, now, orgID, userID, since, UsageStateCommitted, UsageStateReserved))
+	if err != nil {
+		return decimal.Zero, decimal.Zero, fmt.Errorf("汇总组织成员用量失败: %w", err)
+	}
+	return committedUSD, reservedUSD, nil
+}
+
+// checkOrganizationMemberSpendTx 在预留事务内锁定成员行并校验 30 天消费上限：
+// 已结算 + 未过期预留 + 本次预留超过上限（或已达上限）时返回 ErrOrganizationMemberSpendLimitExceeded。
+// 非成员或未设上限时不做限制（成员资格由调用方在预留前校验）。
+func checkOrganizationMemberSpendTx(ctx context.Context, tx *sql.Tx, dialect Dialect, orgID, userID int64, reservedUSD decimal.Decimal, now time.Time) error {
+	var limit decimal.NullDecimal
+	q := `SELECT spend_limit_30d_usd FROM organization_members WHERE org_id=? AND user_id=?` + forUpdateClause(dialect)
+	if err := tx.QueryRowContext(ctx, q, orgID, userID).Scan(&limit); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return fmt.Errorf("查询组织成员失败: %w", err)
+	}
+	if !limit.Valid {
+		return nil
+	}
+	committed, reserved, err := scanCommittedAndReservedUSD(tx.QueryRowContext(ctx, sumOrganizationMemberUSDSQL,
+		UsageStateCommitted, UsageStateReserved, now, orgID, userID, now.Add(-OrganizationMemberSpendWindow), UsageStateCommitted, UsageStateReserved))
+	if err != nil {
+		return fmt.Errorf("汇总组织成员用量失败: %w", err)
+	}
+	used := committed.Add(reserved)
+	if used.GreaterThanOrEqual(limit.Decimal) || used.Add(reservedUSD).GreaterThan(limit.Decimal) {
+		return ErrOrganizationMemberSpendLimitExceeded
+	}
+	return nil
+}
+
+// OrganizationMemberUsage 为组织内单个成员在统计区间内由组织计费的用量。
+type OrganizationMemberUsage struct {
+	UserID       int64
+	Requests     int64
+	CommittedUSD decimal.Decimal
+	ReservedUSD  decimal.Decimal
+}
+
+// ListOrganizationMemberUsage 按成员汇总组织在 [since, until) 内的用量。
+func (s *Store) ListOrganizationMemberUsage(ctx context.Context, orgID int64, since, until, now time.Time) ([]OrganizationMemberUsage, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT user_id,
+       SUM(CASE WHEN state=? THEN 1 ELSE 0 END),
+       SUM(CASE WHEN state=? THEN committed_usd ELSE 0 END),
+       SUM(CASE WHEN state=? AND reserve_expires_at >= ? THEN reserved_usd ELSE 0 END)
+FROM usage_events
+WHERE org_id=? AND time >= ? AND time < ? AND (state=? OR state=?)
+GROUP BY user_id
+`, UsageStateCommitted, UsageStateCommitted, UsageStateReserved, now, orgID, since, until, UsageStateCommitted, UsageStateReserved)
+	if err != nil {
+		return nil, fmt.Errorf("查询组织成员用量失败: %w", err)
+	}
+	defer rows.Close()
+
+	var out []OrganizationMemberUsage
+	for rows.Next() {
+		var row OrganizationMemberUsage
+		var requests sql.NullInt64
+		var committedSum, reservedSum decimal.NullDecimal
+		if err := rows.Scan(&row.UserID, &requests, &committedSum, &reservedSum); err != nil {
+			return nil, fmt.Errorf("扫描组织成员用量失败: %w", err)
+		}
+		row.Requests = requests.Int64
+		if committedSum.Valid {
+			row.CommittedUSD = committedSum.Decimal.Truncate(USDScale)
+		}
+		if reservedSum.Valid {
+			row.ReservedUSD = reservedSum.Decimal.Truncate(USDScale)
+		}
+		out = append(out, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历组织成员用量失败: %w", err)
+	}
+	return out, nil
+}
+
+// ListActiveOrganizationSubscriptionsWithPlans 返回组织当前生效的订阅（按到期时间升序，先到期者优先扣费）。
+func (s *Store) ListActiveOrganizationSubscriptionsWithPlans(ctx context.Context, orgID int64, now time.Time) ([]SubscriptionWithPlan, error) {
+	return s.listOrganizationSubscriptionsWithPlans(ctx, `us.org_id=? AND us.status=1 AND us.start_at <= ? AND us.end_at > ? AND sp.status=1`, orgID, now, now)
+}
+
+// ListNonExpiredOrganizationSubscriptionsWithPlans 返回组织未过期（含尚未开始）的订阅。
+func (s *Store) ListNonExpiredOrganizationSubscriptionsWithPlans(ctx context.Context, orgID int64, now time.Time) ([]SubscriptionWithPlan, error) {
+	return s.listOrganizationSubscriptionsWithPlans(ctx, `us.org_id=? AND us.status=1 AND us.end_at > ?`, orgID, now)
+}
+
+func (s *Store) listOrganizationSubscriptionsWithPlans(ctx context.Context, where string, args ...any) ([]SubscriptionWithPlan, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT
+  us.id, us.user_id, us.plan_id, us.start_at, us.end_at, us.status, us.created_at, us.updated_at,
+  sp.id, sp.code, sp.name, sp.group_name, sp.price_multiplier, sp.price_cny, sp.limit_5h_usd, sp.limit_1d_usd, sp.limit_7d_usd, sp.limit_30d_usd, sp.duration_days, sp.status, sp.created_at, sp.updated_at
+FROM user_subscriptions us
+JOIN subscription_plans sp ON sp.id=us.plan_id
+WHERE `+where+`
+ORDER BY us.end_at ASC, us.id ASC
+`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询组织订阅失败: %w", err)
+	}
+	defer rows.Close()
+
+	var out []SubscriptionWithPlan
+	for rows.Next() {
+		var row SubscriptionWithPlan
+		if err := rows.Scan(
+			&row.Subscription.ID, &row.Subscription.UserID, &row.Subscription.PlanID, &row.Subscription.StartAt, &row.Subscription.EndAt, &row.Subscription.Status, &row.Subscription.CreatedAt, &row.Subscription.UpdatedAt,
+			&row.Plan.ID, &row.Plan.Code, &row.Plan.Name, &row.Plan.GroupName, &row.Plan.PriceMultiplier, &row.Plan.PriceCNY, &row.Plan.Limit5HUSD, &row.Plan.Limit1DUSD, &row.Plan.Limit7DUSD, &row.Plan.Limit30DUSD, &row.Plan.DurationDays, &row.Plan.Status, &row.Plan.CreatedAt, &row.Plan.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("扫描组织订阅失败: %w", err)
+		}
+		truncateSubscriptionPlanMoney(&row.Plan)
+		out = append(out, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历组织订阅失败: %w", err)
+	}
+	return out, nil
+}
+
+// GrantOrganizationSubscription 为组织开通订阅套餐；已有同套餐未过期订阅时顺延到其结束后生效。
+// 组织订阅记录在 user_subscriptions 中，user_id 为 0、org_id 为组织，因而不会出现在任何个人订阅查询中。
+func (s *Store) GrantOrganizationSubscription(ctx context.Context, orgID, planID int64, now time.Time) (UserSubscription, error) {
+	if orgID <= 0 || planID <= 0 {
+		return UserSubscription{}, errors.New("参数不合法")
+	}
+	if now.IsZero() {
+		now = time.Now()
+	}
+	plan, err := s.GetSubscriptionPlanByID(ctx, planID)
+	if err != nil {
+		return UserSubscription{}, err
+	}
+	if plan.Status != 1 {
+		return UserSubscription{}, errors.New("订阅套餐不可用")
+	}
+	if plan.DurationDays <= 0 {
+		plan.DurationDays = 30
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return UserSubscription{}, fmt.Errorf("开始事务失败: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var exists int
+	if err := tx.QueryRowContext(ctx, `SELECT 1 FROM organizations WHERE id=?`, orgID).Scan(&exists); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return UserSubscription{}, sql.ErrNoRows
+		}
+		return UserSubscription{}, fmt.Errorf("查询组织失败: %w", err)
+	}
+
+	startAt := now
+	var latestEnd sql.NullTime
+	if err := tx.QueryRowContext(ctx, `
+SELECT MAX(end_at)
+FROM user_subscriptions
+WHERE org_id=? AND plan_id=? AND status=1 AND end_at > ?
+`, orgID, planID, now).Scan(&latestEnd); err != nil {
+		return UserSubscription{}, fmt.Errorf("查询组织订阅结束时间失败: %w", err)
+	}
+	if latestEnd.Valid && latestEnd.Time.After(startAt) {
+		startAt = latestEnd.Time
+	}
+	us := UserSubscription{
+		PlanID:  planID,
+		StartAt: startAt,
+		EndAt:   startAt.Add(time.Duration(plan.DurationDays) * 24 * time.Hour),
+		Status:  1,
+	}
+	res, err := tx.ExecContext(ctx, `
+INSERT INTO user_subscriptions(user_id, org_id, plan_id, start_at, end_at, status, created_at, updated_at)
+VALUES(0, ?, ?, ?, ?, 1, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+`, orgID, us.PlanID, us.StartAt, us.EndAt)
+	if err != nil {
+		return UserSubscription{}, fmt.Errorf("创建组织订阅失败: %w", err)
+	}
+	if us.ID, err = res.LastInsertId(); err != nil {
+		return UserSubscription{}, fmt.Errorf("获取组织订阅 id 失败: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return UserSubscription{}, fmt.Errorf("提交事务失败: %w", err)
+	}
+	return us, nil
+}
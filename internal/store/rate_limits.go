@@ -0,0 +1,165 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RateLimit 为每分钟请求数（RPM）与每分钟 Token 数（TPM）上限；0 表示不限制。
+type RateLimit struct {
+	RPM int64
+	TPM int64
+}
+
+// Empty 判断是否未配置任何上限。
+func (l RateLimit) Empty() bool {
+	return l.RPM <= 0 && l.TPM <= 0
+}
+
+// RateLimitPolicy 为一次数据面请求适用的限流策略：
+//   - User：按用户聚合（订阅套餐优先，未配置的维度回落到用户主分组）。
+//   - Token：按单个 Token 聚合。
+type RateLimitPolicy struct {
+	User  RateLimit
+	Token RateLimit
+}
+
+// Empty 判断策略是否未配置任何上限。
+func (p RateLimitPolicy) Empty() bool {
+	return p.User.Empty() && p.Token.Empty()
+}
+
+// NormalizeRateLimit 校验 RPM/TPM 上限不得为负。
+func NormalizeRateLimit(in RateLimit) (RateLimit, error) {
+	if in.RPM < 0 {
+		return RateLimit{}, errors.New("rpm_limit 不能为负数")
+	}
+	if in.TPM < 0 {
+		return RateLimit{}, errors.New("tpm_limit 不能为负数")
+	}
+	return in, nil
+}
+
+func (s *Store) GetMainGroupRateLimit(ctx context.Context, name string) (RateLimit, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return RateLimit{}, errors.New("name 不能为空")
+	}
+	var out RateLimit
+	if err := s.db.QueryRowContext(ctx, `SELECT rpm_limit, tpm_limit FROM main_groups WHERE name=?`, name).Scan(&out.RPM, &out.TPM); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return RateLimit{}, sql.ErrNoRows
+		}
+		return RateLimit{}, fmt.Errorf("查询 main_group 限流失败: %w", err)
+	}
+	return out, nil
+}
+
+func (s *Store) UpdateMainGroupRateLimit(ctx context.Context, name string, limit RateLimit) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.New("name 不能为空")
+	}
+	limit, err := NormalizeRateLimit(limit)
+	if err != nil {
+		return err
+	}
+	res, err := s.db.ExecContext(ctx, `
+UPDATE main_groups
+SET rpm_limit=?, tpm_limit=?, updated_at=CURRENT_TIMESTAMP
+WHERE name=?
+`, limit.RPM, limit.TPM, name)
+	if err != nil {
+		return fmt.Errorf("更新 main_group 限流失败: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (s *Store) GetSubscriptionPlanRateLimit(ctx context.Context, planID int64) (RateLimit, error) {
+	if planID <= 0 {
+		return RateLimit{}, errors.New("planID 不合法")
+	}
+	var out RateLimit
+	if err := s.db.QueryRowContext(ctx, `SELECT rpm_limit, tpm_limit FROM subscription_plans WHERE id=?`, planID).Scan(&out.RPM, &out.TPM); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return RateLimit{}, sql.ErrNoRows
+		}
+		return RateLimit{}, fmt.Errorf("查询 subscription_plan 限流失败: %w", err)
+	}
+	return out, nil
+}
+
+func (s *Store) UpdateSubscriptionPlanRateLimit(ctx context.Context, planID int64, limit RateLimit) error {
+	if planID <= 0 {
+		return errors.New("planID 不合法")
+	}
+	limit, err := NormalizeRateLimit(limit)
+	if err != nil {
+		return err
+	}
+	res, err := s.db.ExecContext(ctx, `
+UPDATE subscription_plans
+SET rpm_limit=?, tpm_limit=?, updated_at=CURRENT_TIMESTAMP
+WHERE id=?
+`, limit.RPM, limit.TPM, planID)
+	if err != nil {
+		return fmt.Errorf("更新 subscription_plan 限流失败: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetRateLimitPolicy 解析用户与 Token 的限流策略。
+// 用户维度：生效中的订阅套餐配置了该维度时取其中最大值（多个订阅叠加时更宽松者生效），否则使用主分组配置。
+func (s *Store) GetRateLimitPolicy(ctx context.Context, userID, tokenID int64, now time.Time) (RateLimitPolicy, error) {
+	var out RateLimitPolicy
+	if userID <= 0 {
+		return out, errors.New("userID 不合法")
+	}
+
+	var groupRPM, groupTPM sql.NullInt64
+	if err := s.db.QueryRowContext(ctx, `
+SELECT g.rpm_limit, g.tpm_limit
+FROM users u
+LEFT JOIN main_groups g ON g.name=u.main_group AND g.status=1
+WHERE u.id=?
+`, userID).Scan(&groupRPM, &groupTPM); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return RateLimitPolicy{}, sql.ErrNoRows
+		}
+		return RateLimitPolicy{}, fmt.Errorf("查询主分组限流失败: %w", err)
+	}
+	out.User = RateLimit{RPM: groupRPM.Int64, TPM: groupTPM.Int64}
+
+	var planRPM, planTPM sql.NullInt64
+	if err := s.db.QueryRowContext(ctx, `
+SELECT MAX(sp.rpm_limit), MAX(sp.tpm_limit)
+FROM user_subscriptions us
+JOIN subscription_plans sp ON sp.id=us.plan_id
+WHERE us.user_id=? AND us.status=1 AND us.start_at <= ? AND us.end_at > ? AND sp.status=1
+`, userID, now, now).Scan(&planRPM, &planTPM); err != nil {
+		return RateLimitPolicy{}, fmt.Errorf("查询订阅套餐限流失败: %w", err)
+	}
+	if planRPM.Int64 > 0 {
+		out.User.RPM = planRPM.Int64
+	}
+	if planTPM.Int64 > 0 {
+		out.User.TPM = planTPM.Int64
+	}
+
+	if tokenID > 0 {
+		if err := s.db.QueryRowContext(ctx, `SELECT rpm_limit, tpm_limit FROM user_tokens WHERE id=?`, tokenID).Scan(&out.Token.RPM, &out.Token.TPM); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return RateLimitPolicy{}, fmt.Errorf("查询 Token 限流失败: %w", err)
+		}
+	}
+	return out, nil
+}
@@ -714,7 +714,12 @@ WHERE code=?
 		if code.BalanceUSD.LessThanOrEqual(decimal.Zero) {
 			return RedeemCodeResult{}, ErrRedemptionCodeInvalidReward
 		}
-		balance, err := addUserBalanceUSDTx(ctx, tx, s.dialect, in.UserID, code.BalanceUSD)
+		balance, err := addUserBalanceUSDTx(ctx, tx, s.dialect, balanceTxInput{
+			UserID:           in.UserID,
+			Type:             BalanceTxRedemption,
+			AmountUSD:        code.BalanceUSD,
+			RedemptionCodeID: int64Ptr(code.ID),
+		})
 		if err != nil {
 			return RedeemCodeResult{}, err
 		}
@@ -776,27 +781,24 @@ VALUES(?, ?, ?, 0, ?, ?, CURRENT_TIMESTAMP)
 	return result, nil
 }
 
-func addUserBalanceUSDTx(ctx context.Context, tx *sql.Tx, dialect Dialect, userID int64, deltaUSD decimal.Decimal) (decimal.Decimal, error) {
-	if userID <= 0 {
+// addUserBalanceUSDTx 在事务内为用户入账 in.AmountUSD 并追加余额流水，返回入账后的余额。
+func addUserBalanceUSDTx(ctx context.Context, tx *sql.Tx, dialect Dialect, in balanceTxInput) (decimal.Decimal, error) {
+	if in.UserID <= 0 {
 		return decimal.Zero, errors.New("user_id 不能为空")
 	}
-	deltaUSD = deltaUSD.Truncate(USDScale)
-	if deltaUSD.LessThanOrEqual(decimal.Zero) {
+	in.AmountUSD = in.AmountUSD.Truncate(USDScale)
+	if in.AmountUSD.LessThanOrEqual(decimal.Zero) {
 		return decimal.Zero, errors.New("delta_usd 不合法")
 	}
 	stmtInitBalance := fmt.Sprintf(`
 %s INTO user_balances(user_id, usd, created_at, updated_at)
 VALUES(?, 0, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 `, insertIgnoreVerb(dialect))
-	if _, err := tx.ExecContext(ctx, stmtInitBalance, userID); err != nil {
+	if _, err := tx.ExecContext(ctx, stmtInitBalance, in.UserID); err != nil {
 		return decimal.Zero, fmt.Errorf("初始化余额失败: %w", err)
 	}
-	if _, err := tx.ExecContext(ctx, userBalancesAddSQL(dialect), deltaUSD, userID); err != nil {
+	if _, err := tx.ExecContext(ctx, userBalancesAddSQL(dialect), in.AmountUSD, in.UserID); err != nil {
 		return decimal.Zero, fmt.Errorf("入账失败: %w", err)
 	}
-	var newBal decimal.Decimal
-	if err := tx.QueryRowContext(ctx, `SELECT usd FROM user_balances WHERE user_id=?`, userID).Scan(&newBal); err != nil {
-		return decimal.Zero, fmt.Errorf("查询余额失败: %w", err)
-	}
-	return newBal.Truncate(USDScale), nil
+	return recordBalanceTransactionTx(ctx, tx, in)
 }
@@ -0,0 +1,119 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 调度器持久化运行态的类型。
+const (
+	SchedulerCooldownChannelBan         = "channel_ban"
+	SchedulerCooldownChannelModelBan    = "channel_model_ban"
+	SchedulerCooldownCredentialCooldown = "credential_cooldown"
+)
+
+// SchedulerCooldown 持久化调度器的封禁/冷却（写穿），用于重启后恢复运行态。
+//
+// 约束：
+// - subject 对 channel_ban 为 channel id，对 channel_model_ban 为 binding id，对 credential_cooldown 为 credential key。
+// - until_unix_ms 为截止时间（毫秒）；过期记录在启动恢复时忽略并清理。
+type SchedulerCooldown struct {
+	Kind        string
+	Subject     string
+	UntilUnixMS int64
+	Streak      int
+}
+
+func (c SchedulerCooldown) Until() time.Time {
+	return time.UnixMilli(c.UntilUnixMS)
+}
+
+func (s *Store) UpsertSchedulerCooldown(ctx context.Context, in SchedulerCooldown) error {
+	if s.db == nil {
+		return errors.New("db 为空")
+	}
+	kind := strings.TrimSpace(in.Kind)
+	subject := strings.TrimSpace(in.Subject)
+	if kind == "" || subject == "" {
+		return errors.New("kind/subject 不能为空")
+	}
+
+	stmt := `
+INSERT INTO scheduler_cooldowns(kind, subject, until_unix_ms, streak, updated_at)
+VALUES(?, ?, ?, ?, CURRENT_TIMESTAMP)
+ON DUPLICATE KEY UPDATE
+  until_unix_ms=VALUES(until_unix_ms),
+  streak=VALUES(streak),
+  updated_at=CURRENT_TIMESTAMP
+`
+	if s.dialect == DialectSQLite {
+		stmt = `
+INSERT INTO scheduler_cooldowns(kind, subject, until_unix_ms, streak, updated_at)
+VALUES(?, ?, ?, ?, CURRENT_TIMESTAMP)
+ON CONFLICT(kind, subject) DO UPDATE SET
+  until_unix_ms=excluded.until_unix_ms,
+  streak=excluded.streak,
+  updated_at=CURRENT_TIMESTAMP
+`
+	}
+	if _, err := s.db.ExecContext(ctx, stmt, kind, subject, in.UntilUnixMS, in.Streak); err != nil {
+		return fmt.Errorf("写入 scheduler_cooldowns 失败: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) DeleteSchedulerCooldown(ctx context.Context, kind string, subject string) error {
+	if s.db == nil {
+		return errors.New("db 为空")
+	}
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM scheduler_cooldowns WHERE kind=? AND subject=?`, strings.TrimSpace(kind), strings.TrimSpace(subject)); err != nil {
+		return fmt.Errorf("删除 scheduler_cooldowns 失败: %w", err)
+	}
+	return nil
+}
+
+// ListActiveSchedulerCooldowns 返回截止时间晚于 now 的记录。
+func (s *Store) ListActiveSchedulerCooldowns(ctx context.Context, now time.Time) ([]SchedulerCooldown, error) {
+	if s.db == nil {
+		return nil, nil
+	}
+	rows, err := s.db.QueryContext(ctx, `
+SELECT kind, subject, until_unix_ms, streak
+FROM scheduler_cooldowns
+WHERE until_unix_ms>?
+ORDER BY kind, subject
+`, now.UnixMilli())
+	if err != nil {
+		return nil, fmt.Errorf("查询 scheduler_cooldowns 失败: %w", err)
+	}
+	defer rows.Close()
+
+	var out []SchedulerCooldown
+	for rows.Next() {
+		var c SchedulerCooldown
+		if err := rows.Scan(&c.Kind, &c.Subject, &c.UntilUnixMS, &c.Streak); err != nil {
+			return nil, fmt.Errorf("扫描 scheduler_cooldowns 失败: %w", err)
+		}
+		out = append(out, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历 scheduler_cooldowns 失败: %w", err)
+	}
+	return out, nil
+}
+
+// DeleteExpiredSchedulerCooldowns 清理截止时间不晚于 now 的记录，返回删除行数。
+func (s *Store) DeleteExpiredSchedulerCooldowns(ctx context.Context, now time.Time) (int64, error) {
+	if s.db == nil {
+		return 0, nil
+	}
+	res, err := s.db.ExecContext(ctx, `DELETE FROM scheduler_cooldowns WHERE until_unix_ms<=?`, now.UnixMilli())
+	if err != nil {
+		return 0, fmt.Errorf("清理 scheduler_cooldowns 失败: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n, nil
+}
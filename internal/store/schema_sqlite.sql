@@ -21,6 +21,8 @@ CREATE TABLE IF NOT EXISTS `main_groups` (
   `name` TEXT PRIMARY KEY,
   `description` TEXT NULL,
   `status` INTEGER NOT NULL DEFAULT 1,
+  `rpm_limit` INTEGER NOT NULL DEFAULT 0,
+  `tpm_limit` INTEGER NOT NULL DEFAULT 0,
   `created_at` DATETIME NOT NULL,
   `updated_at` DATETIME NOT NULL
 );
@@ -47,11 +49,26 @@ CREATE TABLE IF NOT EXISTS `user_tokens` (
   `status` INTEGER NOT NULL DEFAULT 1,
   `created_at` DATETIME NOT NULL,
   `revoked_at` DATETIME NULL,
-  `last_used_at` DATETIME NULL
+  `last_used_at` DATETIME NULL,
+  `expires_at` DATETIME NULL,
+  `budget_usd` DECIMAL(20,6) NULL,
+  `budget_1d_usd` DECIMAL(20,6) NULL,
+  `budget_7d_usd` DECIMAL(20,6) NULL,
+  `budget_30d_usd` DECIMAL(20,6) NULL,
+  `allowed_models` TEXT NULL,
+  `allowed_ips` TEXT NULL,
+  `allowed_referers` TEXT NULL,
+  `access_violation_limit` INTEGER NOT NULL DEFAULT 0,
+  `access_violations` INTEGER NOT NULL DEFAULT 0,
+  `last_client_ip` TEXT NULL,
+  `rpm_limit` INTEGER NOT NULL DEFAULT 0,
+  `tpm_limit` INTEGER NOT NULL DEFAULT 0,
+  `org_id` INTEGER NULL
 );
 CREATE UNIQUE INDEX IF NOT EXISTS `uk_user_tokens_hash` ON `user_tokens` (`token_hash`);
 CREATE INDEX IF NOT EXISTS `idx_user_tokens_user_id` ON `user_tokens` (`user_id`);
 CREATE INDEX IF NOT EXISTS `idx_user_tokens_user_id_name` ON `user_tokens` (`user_id`, `name`);
+CREATE INDEX IF NOT EXISTS `idx_user_tokens_org_id` ON `user_tokens` (`org_id`);
 
 
 CREATE TABLE IF NOT EXISTS `token_channel_groups` (
@@ -137,6 +154,7 @@ CREATE TABLE IF NOT EXISTS `upstream_channels` (
   `model_suffix_preserve` TEXT NULL,
   `request_body_blacklist` TEXT NULL,
   `request_body_whitelist` TEXT NULL,
+  `schedule` TEXT NULL,
   `last_test_at` DATETIME NULL,
   `last_test_latency_ms` INTEGER NOT NULL DEFAULT 0,
   `last_test_ok` INTEGER NOT NULL DEFAULT 0,
@@ -149,8 +167,12 @@ CREATE TABLE IF NOT EXISTS `upstream_endpoints` (
   `id` INTEGER PRIMARY KEY AUTOINCREMENT,
   `channel_id` INTEGER NOT NULL,
   `base_url` TEXT NOT NULL,
+  `api_version` TEXT NULL,
   `status` INTEGER NOT NULL DEFAULT 1,
   `priority` INTEGER NOT NULL DEFAULT 0,
+  `rpm_limit` INTEGER NULL,
+  `tpm_limit` INTEGER NULL,
+  `max_concurrency` INTEGER NULL,
   `created_at` DATETIME NOT NULL,
   `updated_at` DATETIME NOT NULL
 );
@@ -164,6 +186,9 @@ CREATE TABLE IF NOT EXISTS `openai_compatible_credentials` (
   `api_key_hint` TEXT NULL,
   `status` INTEGER NOT NULL DEFAULT 1,
   `last_used_at` DATETIME NULL,
+  `rpm_limit` INTEGER NULL,
+  `tpm_limit` INTEGER NULL,
+  `max_concurrency` INTEGER NULL,
   `created_at` DATETIME NOT NULL,
   `updated_at` DATETIME NOT NULL
 );
@@ -177,11 +202,144 @@ CREATE TABLE IF NOT EXISTS `anthropic_credentials` (
   `api_key_hint` TEXT NULL,
   `status` INTEGER NOT NULL DEFAULT 1,
   `last_used_at` DATETIME NULL,
+  `rpm_limit` INTEGER NULL,
+  `tpm_limit` INTEGER NULL,
+  `max_concurrency` INTEGER NULL,
   `created_at` DATETIME NOT NULL,
   `updated_at` DATETIME NOT NULL
 );
 CREATE INDEX IF NOT EXISTS `idx_anthropic_credentials_endpoint_id` ON `anthropic_credentials` (`endpoint_id`);
 
+CREATE TABLE IF NOT EXISTS `gemini_credentials` (
+  `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+  `endpoint_id` INTEGER NOT NULL,
+  `name` TEXT NULL,
+  `api_key_enc` BLOB NOT NULL,
+  `api_key_hint` TEXT NULL,
+  `status` INTEGER NOT NULL DEFAULT 1,
+  `last_used_at` DATETIME NULL,
+  `rpm_limit` INTEGER NULL,
+  `tpm_limit` INTEGER NULL,
+  `max_concurrency` INTEGER NULL,
+  `created_at` DATETIME NOT NULL,
+  `updated_at` DATETIME NOT NULL
+);
+CREATE INDEX IF NOT EXISTS `idx_gemini_credentials_endpoint_id` ON `gemini_credentials` (`endpoint_id`);
+
+CREATE TABLE IF NOT EXISTS `azure_openai_credentials` (
+  `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+  `endpoint_id` INTEGER NOT NULL,
+  `name` TEXT NULL,
+  `api_key_enc` BLOB NOT NULL,
+  `api_key_hint` TEXT NULL,
+  `status` INTEGER NOT NULL DEFAULT 1,
+  `last_used_at` DATETIME NULL,
+  `rpm_limit` INTEGER NULL,
+  `tpm_limit` INTEGER NULL,
+  `max_concurrency` INTEGER NULL,
+  `created_at` DATETIME NOT NULL,
+  `updated_at` DATETIME NOT NULL
+);
+CREATE INDEX IF NOT EXISTS `idx_azure_openai_credentials_endpoint_id` ON `azure_openai_credentials` (`endpoint_id`);
+
+CREATE TABLE IF NOT EXISTS `bedrock_credentials` (
+  `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+  `endpoint_id` INTEGER NOT NULL,
+  `name` TEXT NULL,
+  `access_key_id` TEXT NOT NULL,
+  `secret_access_key_enc` BLOB NOT NULL,
+  `session_token_enc` BLOB NULL,
+  `region` TEXT NOT NULL,
+  `access_key_hint` TEXT NULL,
+  `status` INTEGER NOT NULL DEFAULT 1,
+  `last_used_at` DATETIME NULL,
+  `rpm_limit` INTEGER NULL,
+  `tpm_limit` INTEGER NULL,
+  `max_concurrency` INTEGER NULL,
+  `created_at` DATETIME NOT NULL,
+  `updated_at` DATETIME NOT NULL
+);
+CREATE INDEX IF NOT EXISTS `idx_bedrock_credentials_endpoint_id` ON `bedrock_credentials` (`endpoint_id`);
+
+CREATE TABLE IF NOT EXISTS `vertex_credentials` (
+  `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+  `endpoint_id` INTEGER NOT NULL,
+  `name` TEXT NULL,
+  `project_id` TEXT NOT NULL,
+  `client_email` TEXT NOT NULL,
+  `location` TEXT NOT NULL DEFAULT '',
+  `service_account_json_enc` BLOB NOT NULL,
+  `key_hint` TEXT NULL,
+  `status` INTEGER NOT NULL DEFAULT 1,
+  `last_used_at` DATETIME NULL,
+  `rpm_limit` INTEGER NULL,
+  `tpm_limit` INTEGER NULL,
+  `max_concurrency` INTEGER NULL,
+  `created_at` DATETIME NOT NULL,
+  `updated_at` DATETIME NOT NULL
+);
+CREATE INDEX IF NOT EXISTS `idx_vertex_credentials_endpoint_id` ON `vertex_credentials` (`endpoint_id`);
+
+CREATE TABLE IF NOT EXISTS `batch_files` (
+  `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+  `public_id` TEXT NOT NULL,
+  `user_id` INTEGER NOT NULL,
+  `token_id` INTEGER NOT NULL DEFAULT 0,
+  `purpose` TEXT NOT NULL,
+  `filename` TEXT NOT NULL,
+  `bytes` INTEGER NOT NULL DEFAULT 0,
+  `content` BLOB NOT NULL,
+  `created_at` DATETIME NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `uk_batch_files_public_id` ON `batch_files` (`public_id`);
+CREATE INDEX IF NOT EXISTS `idx_batch_files_user_id_id` ON `batch_files` (`user_id`, `id`);
+
+CREATE TABLE IF NOT EXISTS `batches` (
+  `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+  `public_id` TEXT NOT NULL,
+  `user_id` INTEGER NOT NULL,
+  `token_id` INTEGER NOT NULL,
+  `endpoint` TEXT NOT NULL,
+  `completion_window` TEXT NOT NULL,
+  `input_file_id` TEXT NOT NULL,
+  `output_file_id` TEXT NULL,
+  `error_file_id` TEXT NULL,
+  `status` TEXT NOT NULL,
+  `metadata_json` TEXT NULL,
+  `errors_json` TEXT NULL,
+  `request_total` INTEGER NOT NULL DEFAULT 0,
+  `request_completed` INTEGER NOT NULL DEFAULT 0,
+  `request_failed` INTEGER NOT NULL DEFAULT 0,
+  `lease_until` DATETIME NULL,
+  `in_progress_at` DATETIME NULL,
+  `finalizing_at` DATETIME NULL,
+  `completed_at` DATETIME NULL,
+  `failed_at` DATETIME NULL,
+  `expired_at` DATETIME NULL,
+  `cancelling_at` DATETIME NULL,
+  `cancelled_at` DATETIME NULL,
+  `expires_at` DATETIME NOT NULL,
+  `created_at` DATETIME NOT NULL,
+  `updated_at` DATETIME NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `uk_batches_public_id` ON `batches` (`public_id`);
+CREATE INDEX IF NOT EXISTS `idx_batches_user_id_id` ON `batches` (`user_id`, `id`);
+CREATE INDEX IF NOT EXISTS `idx_batches_status_lease_until` ON `batches` (`status`, `lease_until`);
+
+CREATE TABLE IF NOT EXISTS `batch_request_results` (
+  `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+  `batch_id` INTEGER NOT NULL,
+  `line_index` INTEGER NOT NULL,
+  `custom_id` TEXT NOT NULL,
+  `request_id` TEXT NOT NULL DEFAULT '',
+  `status_code` INTEGER NOT NULL DEFAULT 0,
+  `body` BLOB NULL,
+  `error_code` TEXT NULL,
+  `error_message` TEXT NULL,
+  `created_at` DATETIME NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `uk_batch_request_results_batch_line` ON `batch_request_results` (`batch_id`, `line_index`);
+
 CREATE TABLE IF NOT EXISTS `codex_oauth_accounts` (
   `id` INTEGER PRIMARY KEY AUTOINCREMENT,
   `endpoint_id` INTEGER NOT NULL,
@@ -212,6 +370,10 @@ CREATE TABLE IF NOT EXISTS `codex_oauth_accounts` (
   `quota_updated_at` DATETIME NULL,
   `quota_error` TEXT NULL,
 
+  `rpm_limit` INTEGER NULL,
+  `tpm_limit` INTEGER NULL,
+  `max_concurrency` INTEGER NULL,
+
   `created_at` DATETIME NOT NULL,
   `updated_at` DATETIME NOT NULL
 );
@@ -257,6 +419,7 @@ CREATE TABLE IF NOT EXISTS `usage_events` (
   `endpoint` TEXT NULL,
   `method` TEXT NULL,
   `user_id` INTEGER NOT NULL,
+  `org_id` INTEGER NULL,
   `subscription_id` INTEGER NULL,
   `token_id` INTEGER NOT NULL,
   `upstream_channel_id` INTEGER NULL,
@@ -281,6 +444,13 @@ CREATE TABLE IF NOT EXISTS `usage_events` (
   `status_code` INTEGER NOT NULL DEFAULT 0,
   `latency_ms` INTEGER NOT NULL DEFAULT 0,
   `first_token_latency_ms` INTEGER NOT NULL DEFAULT 0,
+  `cost_channel_model_id` INTEGER NULL,
+  `cost_input_usd_per_1m` DECIMAL(20,6) NULL,
+  `cost_output_usd_per_1m` DECIMAL(20,6) NULL,
+  `cost_cache_input_usd_per_1m` DECIMAL(20,6) NULL,
+  `cost_cache_output_usd_per_1m` DECIMAL(20,6) NULL,
+  `routing_trace` TEXT NULL,
+  `route_group_id` INTEGER NULL,
   `error_class` TEXT NULL,
   `error_message` TEXT NULL,
   `is_stream` INTEGER NOT NULL DEFAULT 0,
@@ -297,6 +467,8 @@ CREATE INDEX IF NOT EXISTS `idx_usage_events_state` ON `usage_events` (`state`);
 CREATE INDEX IF NOT EXISTS `idx_usage_events_user_state_time` ON `usage_events` (`user_id`, `state`, `time`);
 CREATE INDEX IF NOT EXISTS `idx_usage_events_state_reserve_expires` ON `usage_events` (`state`, `reserve_expires_at`);
 CREATE INDEX IF NOT EXISTS `idx_usage_events_user_subscription_state_time` ON `usage_events` (`user_id`, `subscription_id`, `state`, `time`);
+CREATE INDEX IF NOT EXISTS `idx_usage_events_org_subscription_state_time` ON `usage_events` (`org_id`, `subscription_id`, `state`, `time`);
+CREATE INDEX IF NOT EXISTS `idx_usage_events_org_user_state_time` ON `usage_events` (`org_id`, `user_id`, `state`, `time`);
 CREATE INDEX IF NOT EXISTS `idx_usage_events_state_time_upstream_channel` ON `usage_events` (`state`, `time`, `upstream_channel_id`);
 CREATE INDEX IF NOT EXISTS `idx_usage_events_user_id_id` ON `usage_events` (`user_id`, `id`);
 CREATE INDEX IF NOT EXISTS `idx_usage_events_time_id` ON `usage_events` (`time`, `id`);
@@ -319,6 +491,8 @@ CREATE TABLE IF NOT EXISTS `subscription_plans` (
   `limit_30d_usd` DECIMAL(20,6) NOT NULL,
   `duration_days` INTEGER NOT NULL DEFAULT 30,
   `status` INTEGER NOT NULL DEFAULT 1,
+  `rpm_limit` INTEGER NOT NULL DEFAULT 0,
+  `tpm_limit` INTEGER NOT NULL DEFAULT 0,
   `created_at` DATETIME NOT NULL,
   `updated_at` DATETIME NOT NULL
 );
@@ -327,6 +501,7 @@ CREATE UNIQUE INDEX IF NOT EXISTS `uk_subscription_plans_code` ON `subscription_
 CREATE TABLE IF NOT EXISTS `user_subscriptions` (
   `id` INTEGER PRIMARY KEY AUTOINCREMENT,
   `user_id` INTEGER NOT NULL,
+  `org_id` INTEGER NULL,
   `plan_id` INTEGER NOT NULL,
   `start_at` DATETIME NOT NULL,
   `end_at` DATETIME NOT NULL,
@@ -336,6 +511,7 @@ CREATE TABLE IF NOT EXISTS `user_subscriptions` (
 );
 CREATE INDEX IF NOT EXISTS `idx_user_subscriptions_end_at` ON `user_subscriptions` (`end_at`);
 CREATE INDEX IF NOT EXISTS `idx_user_subscriptions_user_status_end_at` ON `user_subscriptions` (`user_id`, `status`, `end_at`);
+CREATE INDEX IF NOT EXISTS `idx_user_subscriptions_org_status_end_at` ON `user_subscriptions` (`org_id`, `status`, `end_at`);
 
 CREATE TABLE IF NOT EXISTS `user_balances` (
   `user_id` INTEGER PRIMARY KEY,
@@ -344,6 +520,51 @@ CREATE TABLE IF NOT EXISTS `user_balances` (
   `updated_at` DATETIME NOT NULL
 );
 
+CREATE TABLE IF NOT EXISTS `balance_transactions` (
+  `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+  `user_id` INTEGER NOT NULL,
+  `org_id` INTEGER NULL,
+  `type` TEXT NOT NULL,
+  `amount_usd` DECIMAL(20,6) NOT NULL,
+  `balance_after_usd` DECIMAL(20,6) NOT NULL,
+  `usage_event_id` INTEGER NULL,
+  `topup_order_id` INTEGER NULL,
+  `redemption_code_id` INTEGER NULL,
+  `actor_user_id` INTEGER NULL,
+  `note` TEXT NULL,
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS `idx_balance_transactions_user_id` ON `balance_transactions` (`user_id`, `id`);
+CREATE INDEX IF NOT EXISTS `idx_balance_transactions_usage_event_id` ON `balance_transactions` (`usage_event_id`);
+CREATE INDEX IF NOT EXISTS `idx_balance_transactions_org_id` ON `balance_transactions` (`org_id`, `id`);
+
+CREATE TABLE IF NOT EXISTS `organizations` (
+  `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+  `name` TEXT NOT NULL,
+  `status` INTEGER NOT NULL DEFAULT 1,
+  `created_at` DATETIME NOT NULL,
+  `updated_at` DATETIME NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `uk_organizations_name` ON `organizations` (`name`);
+
+CREATE TABLE IF NOT EXISTS `organization_members` (
+  `org_id` INTEGER NOT NULL,
+  `user_id` INTEGER NOT NULL,
+  `role` TEXT NOT NULL DEFAULT 'member',
+  `spend_limit_30d_usd` DECIMAL(20,6) NULL,
+  `created_at` DATETIME NOT NULL,
+  `updated_at` DATETIME NOT NULL,
+  PRIMARY KEY (`org_id`, `user_id`)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `uk_organization_members_user_id` ON `organization_members` (`user_id`);
+
+CREATE TABLE IF NOT EXISTS `organization_balances` (
+  `org_id` INTEGER PRIMARY KEY,
+  `usd` DECIMAL(20,6) NOT NULL DEFAULT 0,
+  `created_at` DATETIME NOT NULL,
+  `updated_at` DATETIME NOT NULL
+);
+
 CREATE TABLE IF NOT EXISTS `payment_channels` (
   `id` INTEGER PRIMARY KEY AUTOINCREMENT,
   `type` TEXT NOT NULL,
@@ -462,6 +683,7 @@ CREATE TABLE IF NOT EXISTS `channel_groups` (
   `name` TEXT NOT NULL,
   `description` TEXT NULL,
   `price_multiplier` DECIMAL(25,6) NOT NULL DEFAULT 1.000000,
+  `routing_mode` TEXT NOT NULL DEFAULT 'priority',
   `status` INTEGER NOT NULL DEFAULT 1,
   `created_at` DATETIME NOT NULL,
   `updated_at` DATETIME NOT NULL
@@ -475,6 +697,11 @@ CREATE TABLE IF NOT EXISTS `channel_group_members` (
   `member_channel_id` INTEGER NULL,
   `priority` INTEGER NOT NULL DEFAULT 0,
   `promotion` INTEGER NOT NULL DEFAULT 0,
+  `schedule` TEXT NULL,
+  `canary_status` TEXT NOT NULL DEFAULT '',
+  `canary_percent` INTEGER NOT NULL DEFAULT 0,
+  `canary_started_at` DATETIME NULL,
+  `canary_checked_at` DATETIME NULL,
   `created_at` DATETIME NOT NULL,
   `updated_at` DATETIME NOT NULL
 );
@@ -483,6 +710,16 @@ CREATE UNIQUE INDEX IF NOT EXISTS `uk_parent_member_channel` ON `channel_group_m
 CREATE UNIQUE INDEX IF NOT EXISTS `uk_group_single_parent` ON `channel_group_members` (`member_group_id`);
 CREATE INDEX IF NOT EXISTS `idx_parent_order` ON `channel_group_members` (`parent_group_id`, `promotion`, `priority`, `id`);
 
+CREATE TABLE IF NOT EXISTS `scheduler_cooldowns` (
+  `kind` TEXT NOT NULL,
+  `subject` TEXT NOT NULL,
+  `until_unix_ms` INTEGER NOT NULL,
+  `streak` INTEGER NOT NULL DEFAULT 0,
+  `updated_at` DATETIME NOT NULL,
+  PRIMARY KEY (`kind`, `subject`)
+);
+CREATE INDEX IF NOT EXISTS `idx_scheduler_cooldowns_until` ON `scheduler_cooldowns` (`until_unix_ms`);
+
 CREATE TABLE IF NOT EXISTS `channel_group_pointers` (
   `group_id` INTEGER NOT NULL,
   `channel_id` INTEGER NOT NULL DEFAULT 0,
@@ -511,6 +748,7 @@ CREATE TABLE IF NOT EXISTS `managed_models` (
   `priority_output_usd_per_1m` DECIMAL(20,6) NULL,
   `priority_cache_input_usd_per_1m` DECIMAL(20,6) NULL,
   `high_context_pricing_json` TEXT NULL,
+  `batch_discount` DECIMAL(10,6) NULL,
   `status` INTEGER NOT NULL DEFAULT 1,
   `created_at` DATETIME NOT NULL
 );
@@ -524,6 +762,10 @@ CREATE TABLE IF NOT EXISTS `channel_models` (
   `public_id` TEXT NOT NULL,
   `upstream_model` TEXT NOT NULL,
   `status` INTEGER NOT NULL DEFAULT 1,
+  `cost_input_usd_per_1m` DECIMAL(20,6) NULL,
+  `cost_output_usd_per_1m` DECIMAL(20,6) NULL,
+  `cost_cache_input_usd_per_1m` DECIMAL(20,6) NULL,
+  `cost_cache_output_usd_per_1m` DECIMAL(20,6) NULL,
   `created_at` DATETIME NOT NULL,
   `updated_at` DATETIME NOT NULL
 );
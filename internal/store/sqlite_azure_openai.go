@@ -0,0 +1,79 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ensureSQLiteAzureOpenAISchema 为 azure_openai 渠道补齐 upstream_endpoints.api_version 列与 azure_openai_credentials 表。
+func ensureSQLiteAzureOpenAISchema(db *sql.DB) error {
+	if db == nil {
+		return errors.New("db 为空")
+	}
+
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开始 SQLite schema 修补事务失败: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	rows, err := tx.QueryContext(ctx, `PRAGMA table_info(upstream_endpoints)`)
+	if err != nil {
+		return fmt.Errorf("查询 upstream_endpoints 列信息失败: %w", err)
+	}
+	defer rows.Close()
+
+	cols := make(map[string]struct{})
+	for rows.Next() {
+		var (
+			cid        int
+			name       string
+			typ        string
+			notNull    int
+			dfltValue  sql.NullString
+			primaryKey int
+		)
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &dfltValue, &primaryKey); err != nil {
+			return fmt.Errorf("扫描 upstream_endpoints 列信息失败: %w", err)
+		}
+		if name != "" {
+			cols[name] = struct{}{}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("遍历 upstream_endpoints 列信息失败: %w", err)
+	}
+
+	if _, ok := cols["api_version"]; !ok {
+		if _, err := tx.ExecContext(ctx, `ALTER TABLE upstream_endpoints ADD COLUMN api_version TEXT NULL`); err != nil {
+			return fmt.Errorf("添加 upstream_endpoints 列 api_version 失败: %w", err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+CREATE TABLE IF NOT EXISTS azure_openai_credentials (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  endpoint_id INTEGER NOT NULL,
+  name TEXT NULL,
+  api_key_enc BLOB NOT NULL,
+  api_key_hint TEXT NULL,
+  status INTEGER NOT NULL DEFAULT 1,
+  last_used_at DATETIME NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+)
+`); err != nil {
+		return fmt.Errorf("创建 azure_openai_credentials 表失败: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `CREATE INDEX IF NOT EXISTS idx_azure_openai_credentials_endpoint_id ON azure_openai_credentials (endpoint_id)`); err != nil {
+		return fmt.Errorf("创建 azure_openai_credentials endpoint_id 索引失败: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交 SQLite schema 修补事务失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ensureSQLiteBalanceTransactionsTable 创建余额流水表，并为已有余额但尚无流水的用户补写期初记录。
+func ensureSQLiteBalanceTransactionsTable(db *sql.DB) error {
+	if db == nil {
+		return errors.New("db 为空")
+	}
+	if _, err := db.Exec(`
+CREATE TABLE IF NOT EXISTS balance_transactions (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL,
+  type TEXT NOT NULL,
+  amount_usd DECIMAL(20,6) NOT NULL,
+  balance_after_usd DECIMAL(20,6) NOT NULL,
+  usage_event_id INTEGER NULL,
+  topup_order_id INTEGER NULL,
+  redemption_code_id INTEGER NULL,
+  actor_user_id INTEGER NULL,
+  note TEXT NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+)
+`); err != nil {
+		return fmt.Errorf("创建 balance_transactions 表失败: %w", err)
+	}
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_balance_transactions_user_id ON balance_transactions (user_id, id)`); err != nil {
+		return fmt.Errorf("创建 balance_transactions user_id 索引失败: %w", err)
+	}
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_balance_transactions_usage_event_id ON balance_transactions (usage_event_id)`); err != nil {
+		return fmt.Errorf("创建 balance_transactions usage_event_id 索引失败: %w", err)
+	}
+	if _, err := db.Exec(`
+INSERT INTO balance_transactions(user_id, type, amount_usd, balance_after_usd, created_at)
+SELECT b.user_id, ?, b.usd, b.usd, CURRENT_TIMESTAMP
+FROM user_balances b
+WHERE b.usd <> 0 AND NOT EXISTS (SELECT 1 FROM balance_transactions t WHERE t.user_id=b.user_id)
+`, BalanceTxOpening); err != nil {
+		return fmt.Errorf("补写余额期初流水失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+func ensureSQLiteBatchTables(db *sql.DB) error {
+	if db == nil {
+		return errors.New("db 为空")
+	}
+	if _, err := db.Exec(`
+CREATE TABLE IF NOT EXISTS batch_files (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  public_id TEXT NOT NULL,
+  user_id INTEGER NOT NULL,
+  token_id INTEGER NOT NULL DEFAULT 0,
+  purpose TEXT NOT NULL,
+  filename TEXT NOT NULL,
+  bytes INTEGER NOT NULL DEFAULT 0,
+  content BLOB NOT NULL,
+  created_at DATETIME NOT NULL
+)
+`); err != nil {
+		return fmt.Errorf("创建 batch_files 表失败: %w", err)
+	}
+	if _, err := db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS uk_batch_files_public_id ON batch_files (public_id)`); err != nil {
+		return fmt.Errorf("创建 batch_files public_id 索引失败: %w", err)
+	}
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_batch_files_user_id_id ON batch_files (user_id, id)`); err != nil {
+		return fmt.Errorf("创建 batch_files user_id/id 索引失败: %w", err)
+	}
+	if _, err := db.Exec(`
+CREATE TABLE IF NOT EXISTS batches (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  public_id TEXT NOT NULL,
+  user_id INTEGER NOT NULL,
+  token_id INTEGER NOT NULL,
+  endpoint TEXT NOT NULL,
+  completion_window TEXT NOT NULL,
+  input_file_id TEXT NOT NULL,
+  output_file_id TEXT NULL,
+  error_file_id TEXT NULL,
+  status TEXT NOT NULL,
+  metadata_json TEXT NULL,
+  errors_json TEXT NULL,
+  request_total INTEGER NOT NULL DEFAULT 0,
+  request_completed INTEGER NOT NULL DEFAULT 0,
+  request_failed INTEGER NOT NULL DEFAULT 0,
+  lease_until DATETIME NULL,
+  in_progress_at DATETIME NULL,
+  finalizing_at DATETIME NULL,
+  completed_at DATETIME NULL,
+  failed_at DATETIME NULL,
+  expired_at DATETIME NULL,
+  cancelling_at DATETIME NULL,
+  cancelled_at DATETIME NULL,
+  expires_at DATETIME NOT NULL,
+  created_at DATETIME NOT NULL,
+  updated_at DATETIME NOT NULL
+)
+`); err != nil {
+		return fmt.Errorf("创建 batches 表失败: %w", err)
+	}
+	if _, err := db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS uk_batches_public_id ON batches (public_id)`); err != nil {
+		return fmt.Errorf("创建 batches public_id 索引失败: %w", err)
+	}
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_batches_user_id_id ON batches (user_id, id)`); err != nil {
+		return fmt.Errorf("创建 batches user_id/id 索引失败: %w", err)
+	}
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_batches_status_lease_until ON batches (status, lease_until)`); err != nil {
+		return fmt.Errorf("创建 batches status/lease_until 索引失败: %w", err)
+	}
+	if _, err := db.Exec(`
+CREATE TABLE IF NOT EXISTS batch_request_results (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  batch_id INTEGER NOT NULL,
+  line_index INTEGER NOT NULL,
+  custom_id TEXT NOT NULL,
+  request_id TEXT NOT NULL DEFAULT '',
+  status_code INTEGER NOT NULL DEFAULT 0,
+  body BLOB NULL,
+  error_code TEXT NULL,
+  error_message TEXT NULL,
+  created_at DATETIME NOT NULL
+)
+`); err != nil {
+		return fmt.Errorf("创建 batch_request_results 表失败: %w", err)
+	}
+	if _, err := db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS uk_batch_request_results_batch_line ON batch_request_results (batch_id, line_index)`); err != nil {
+		return fmt.Errorf("创建 batch_request_results batch_id/line_index 索引失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ensureSQLiteChannelGroupCanaryColumns 补齐渠道组成员的灰度（canary）相关列，以及 usage_events.route_group_id（按组统计灰度健康度）。
+func ensureSQLiteChannelGroupCanaryColumns(db *sql.DB) error {
+	if db == nil {
+		return errors.New("db 为空")
+	}
+
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开始 SQLite schema 修补事务失败: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	existing, err := sqliteTableColumns(ctx, tx, "channel_group_members")
+	if err != nil {
+		return err
+	}
+	for _, col := range [][2]string{
+		{"canary_status", "TEXT NOT NULL DEFAULT ''"},
+		{"canary_percent", "INTEGER NOT NULL DEFAULT 0"},
+		{"canary_started_at", "DATETIME NULL"},
+		{"canary_checked_at", "DATETIME NULL"},
+	} {
+		if _, ok := existing[col[0]]; ok {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `ALTER TABLE channel_group_members ADD COLUMN `+col[0]+` `+col[1]); err != nil {
+			return fmt.Errorf("添加 channel_group_members 列 %s 失败: %w", col[0], err)
+		}
+	}
+
+	usageCols, err := sqliteTableColumns(ctx, tx, "usage_events")
+	if err != nil {
+		return err
+	}
+	if _, ok := usageCols["route_group_id"]; !ok {
+		if _, err := tx.ExecContext(ctx, "ALTER TABLE usage_events ADD COLUMN route_group_id INTEGER NULL"); err != nil {
+			return fmt.Errorf("添加 usage_events 列 route_group_id 失败: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交 SQLite schema 修补事务失败: %w", err)
+	}
+	return nil
+}
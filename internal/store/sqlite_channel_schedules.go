@@ -0,0 +1,40 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ensureSQLiteChannelScheduleColumns 补齐渠道与渠道组成员的可用时间窗列（schedule JSON）。
+func ensureSQLiteChannelScheduleColumns(db *sql.DB) error {
+	if db == nil {
+		return errors.New("db 为空")
+	}
+
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开始 SQLite schema 修补事务失败: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, table := range []string{"upstream_channels", "channel_group_members"} {
+		cols, err := sqliteTableColumns(ctx, tx, table)
+		if err != nil {
+			return err
+		}
+		if _, ok := cols["schedule"]; ok {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `ALTER TABLE `+table+` ADD COLUMN schedule TEXT NULL`); err != nil {
+			return fmt.Errorf("添加 %s 列 schedule 失败: %w", table, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交 SQLite schema 修补事务失败: %w", err)
+	}
+	return nil
+}
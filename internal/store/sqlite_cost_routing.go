@@ -0,0 +1,71 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ensureSQLiteCostRoutingColumns 补齐按成本路由相关列：channel_models 成本价、channel_groups.routing_mode、usage_events 成本基准。
+func ensureSQLiteCostRoutingColumns(db *sql.DB) error {
+	if db == nil {
+		return errors.New("db 为空")
+	}
+
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开始 SQLite schema 修补事务失败: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, item := range []struct {
+		table string
+		cols  [][2]string
+	}{
+		{
+			table: "channel_models",
+			cols: [][2]string{
+				{"cost_input_usd_per_1m", "DECIMAL(20,6) NULL"},
+				{"cost_output_usd_per_1m", "DECIMAL(20,6) NULL"},
+				{"cost_cache_input_usd_per_1m", "DECIMAL(20,6) NULL"},
+				{"cost_cache_output_usd_per_1m", "DECIMAL(20,6) NULL"},
+			},
+		},
+		{
+			table: "channel_groups",
+			cols: [][2]string{
+				{"routing_mode", "TEXT NOT NULL DEFAULT 'priority'"},
+			},
+		},
+		{
+			table: "usage_events",
+			cols: [][2]string{
+				{"cost_channel_model_id", "INTEGER NULL"},
+				{"cost_input_usd_per_1m", "DECIMAL(20,6) NULL"},
+				{"cost_output_usd_per_1m", "DECIMAL(20,6) NULL"},
+				{"cost_cache_input_usd_per_1m", "DECIMAL(20,6) NULL"},
+				{"cost_cache_output_usd_per_1m", "DECIMAL(20,6) NULL"},
+			},
+		},
+	} {
+		existing, err := sqliteTableColumns(ctx, tx, item.table)
+		if err != nil {
+			return err
+		}
+		for _, col := range item.cols {
+			if _, ok := existing[col[0]]; ok {
+				continue
+			}
+			if _, err := tx.ExecContext(ctx, `ALTER TABLE `+item.table+` ADD COLUMN `+col[0]+` `+col[1]); err != nil {
+				return fmt.Errorf("添加 %s 列 %s 失败: %w", item.table, col[0], err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交 SQLite schema 修补事务失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// credentialLimitTables 为带有 rpm_limit/tpm_limit/max_concurrency 列的上游表。
+var credentialLimitTables = []string{
+	"upstream_endpoints",
+	"openai_compatible_credentials",
+	"anthropic_credentials",
+	"gemini_credentials",
+	"azure_openai_credentials",
+	"bedrock_credentials",
+	"vertex_credentials",
+	"codex_oauth_accounts",
+}
+
+func ensureSQLiteCredentialLimitColumns(db *sql.DB) error {
+	if db == nil {
+		return errors.New("db 为空")
+	}
+
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开始 SQLite schema 修补事务失败: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, table := range credentialLimitTables {
+		cols, err := sqliteTableColumns(ctx, tx, table)
+		if err != nil {
+			return err
+		}
+		for _, col := range []string{"rpm_limit", "tpm_limit", "max_concurrency"} {
+			if _, ok := cols[col]; ok {
+				continue
+			}
+			if _, err := tx.ExecContext(ctx, `ALTER TABLE `+table+` ADD COLUMN `+col+` INTEGER NULL`); err != nil {
+				return fmt.Errorf("添加 %s 列 %s 失败: %w", table, col, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交 SQLite schema 修补事务失败: %w", err)
+	}
+	return nil
+}
+
+func sqliteTableColumns(ctx context.Context, tx *sql.Tx, table string) (map[string]struct{}, error) {
+	rows, err := tx.QueryContext(ctx, `PRAGMA table_info(`+table+`)`)
+	if err != nil {
+		return nil, fmt.Errorf("查询 %s 列信息失败: %w", table, err)
+	}
+	defer rows.Close()
+
+	cols := make(map[string]struct{})
+	for rows.Next() {
+		var (
+			cid        int
+			name       string
+			typ        string
+			notNull    int
+			dfltValue  sql.NullString
+			primaryKey int
+		)
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &dfltValue, &primaryKey); err != nil {
+			return nil, fmt.Errorf("扫描 %s 列信息失败: %w", table, err)
+		}
+		if name != "" {
+			cols[name] = struct{}{}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历 %s 列信息失败: %w", table, err)
+	}
+	return cols, nil
+}
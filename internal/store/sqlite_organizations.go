@@ -0,0 +1,77 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ensureSQLiteOrganizations 创建组织相关表，并为 user_tokens/user_subscriptions/usage_events/balance_transactions 补齐 org_id 列。
+func ensureSQLiteOrganizations(db *sql.DB) error {
+	if db == nil {
+		return errors.New("db 为空")
+	}
+
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开始 SQLite schema 修补事务失败: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, table := range []string{"user_tokens", "user_subscriptions", "usage_events", "balance_transactions"} {
+		existing, err := sqliteTableColumns(ctx, tx, table)
+		if err != nil {
+			return err
+		}
+		if _, ok := existing["org_id"]; ok {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `ALTER TABLE `+table+` ADD COLUMN org_id INTEGER NULL`); err != nil {
+			return fmt.Errorf("添加 %s 列 org_id 失败: %w", table, err)
+		}
+	}
+
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS organizations (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name TEXT NOT NULL,
+  status INTEGER NOT NULL DEFAULT 1,
+  created_at DATETIME NOT NULL,
+  updated_at DATETIME NOT NULL
+)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS uk_organizations_name ON organizations (name)`,
+		`CREATE TABLE IF NOT EXISTS organization_members (
+  org_id INTEGER NOT NULL,
+  user_id INTEGER NOT NULL,
+  role TEXT NOT NULL DEFAULT 'member',
+  spend_limit_30d_usd DECIMAL(20,6) NULL,
+  created_at DATETIME NOT NULL,
+  updated_at DATETIME NOT NULL,
+  PRIMARY KEY (org_id, user_id)
+)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS uk_organization_members_user_id ON organization_members (user_id)`,
+		`CREATE TABLE IF NOT EXISTS organization_balances (
+  org_id INTEGER PRIMARY KEY,
+  usd DECIMAL(20,6) NOT NULL DEFAULT 0,
+  created_at DATETIME NOT NULL,
+  updated_at DATETIME NOT NULL
+)`,
+		`CREATE INDEX IF NOT EXISTS idx_user_tokens_org_id ON user_tokens (org_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_user_subscriptions_org_status_end_at ON user_subscriptions (org_id, status, end_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_usage_events_org_subscription_state_time ON usage_events (org_id, subscription_id, state, time)`,
+		`CREATE INDEX IF NOT EXISTS idx_usage_events_org_user_state_time ON usage_events (org_id, user_id, state, time)`,
+		`CREATE INDEX IF NOT EXISTS idx_balance_transactions_org_id ON balance_transactions (org_id, id)`,
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("创建组织相关表/索引失败: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交 SQLite schema 修补事务失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ensureSQLiteRateLimitColumns 补齐 main_groups/subscription_plans/user_tokens 的 RPM/TPM 限流列。
+func ensureSQLiteRateLimitColumns(db *sql.DB) error {
+	if db == nil {
+		return errors.New("db 为空")
+	}
+
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开始 SQLite schema 修补事务失败: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, table := range []string{"main_groups", "subscription_plans", "user_tokens"} {
+		existing, err := sqliteTableColumns(ctx, tx, table)
+		if err != nil {
+			return err
+		}
+		for _, col := range []string{"rpm_limit", "tpm_limit"} {
+			if _, ok := existing[col]; ok {
+				continue
+			}
+			if _, err := tx.ExecContext(ctx, `ALTER TABLE `+table+` ADD COLUMN `+col+` INTEGER NOT NULL DEFAULT 0`); err != nil {
+				return fmt.Errorf("添加 %s 列 %s 失败: %w", table, col, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交 SQLite schema 修补事务失败: %w", err)
+	}
+	return nil
+}
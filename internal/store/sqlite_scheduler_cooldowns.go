@@ -0,0 +1,29 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+func ensureSQLiteSchedulerCooldownsTable(db *sql.DB) error {
+	if db == nil {
+		return errors.New("db 为空")
+	}
+	if _, err := db.Exec(`
+CREATE TABLE IF NOT EXISTS scheduler_cooldowns (
+  kind TEXT NOT NULL,
+  subject TEXT NOT NULL,
+  until_unix_ms INTEGER NOT NULL,
+  streak INTEGER NOT NULL DEFAULT 0,
+  updated_at DATETIME NOT NULL,
+  PRIMARY KEY (kind, subject)
+)
+`); err != nil {
+		return fmt.Errorf("创建 scheduler_cooldowns 表失败: %w", err)
+	}
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_scheduler_cooldowns_until ON scheduler_cooldowns (until_unix_ms)`); err != nil {
+		return fmt.Errorf("创建 scheduler_cooldowns until 索引失败: %w", err)
+	}
+	return nil
+}
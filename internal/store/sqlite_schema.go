@@ -80,6 +80,54 @@ func EnsureSQLiteSchema(db *sql.DB) error {
 		if err := ensureSQLiteAnthropicCredentialsTable(db); err != nil {
 			return err
 		}
+		if err := ensureSQLiteGeminiCredentialsTable(db); err != nil {
+			return err
+		}
+		if err := ensureSQLiteAzureOpenAISchema(db); err != nil {
+			return err
+		}
+		if err := ensureSQLiteBedrockCredentialsTable(db); err != nil {
+			return err
+		}
+		if err := ensureSQLiteVertexCredentialsTable(db); err != nil {
+			return err
+		}
+		if err := ensureSQLiteManagedModelBatchDiscountColumn(db); err != nil {
+			return err
+		}
+		if err := ensureSQLiteBatchTables(db); err != nil {
+			return err
+		}
+		if err := ensureSQLiteSchedulerCooldownsTable(db); err != nil {
+			return err
+		}
+		if err := ensureSQLiteCredentialLimitColumns(db); err != nil {
+			return err
+		}
+		if err := ensureSQLiteCostRoutingColumns(db); err != nil {
+			return err
+		}
+		if err := ensureSQLiteUsageEventsRoutingTraceColumn(db); err != nil {
+			return err
+		}
+		if err := ensureSQLiteChannelScheduleColumns(db); err != nil {
+			return err
+		}
+		if err := ensureSQLiteChannelGroupCanaryColumns(db); err != nil {
+			return err
+		}
+		if err := ensureSQLiteUserTokenLimitColumns(db); err != nil {
+			return err
+		}
+		if err := ensureSQLiteRateLimitColumns(db); err != nil {
+			return err
+		}
+		if err := ensureSQLiteBalanceTransactionsTable(db); err != nil {
+			return err
+		}
+		if err := ensureSQLiteOrganizations(db); err != nil {
+			return err
+		}
 		if err := ensureSQLiteChannelGroupPointers(db); err != nil {
 			return err
 		}
@@ -160,6 +208,54 @@ func EnsureSQLiteSchema(db *sql.DB) error {
 	if err := ensureSQLiteAnthropicCredentialsTable(db); err != nil {
 		return err
 	}
+	if err := ensureSQLiteGeminiCredentialsTable(db); err != nil {
+		return err
+	}
+	if err := ensureSQLiteAzureOpenAISchema(db); err != nil {
+		return err
+	}
+	if err := ensureSQLiteBedrockCredentialsTable(db); err != nil {
+		return err
+	}
+	if err := ensureSQLiteVertexCredentialsTable(db); err != nil {
+		return err
+	}
+	if err := ensureSQLiteManagedModelBatchDiscountColumn(db); err != nil {
+		return err
+	}
+	if err := ensureSQLiteBatchTables(db); err != nil {
+		return err
+	}
+	if err := ensureSQLiteSchedulerCooldownsTable(db); err != nil {
+		return err
+	}
+	if err := ensureSQLiteCredentialLimitColumns(db); err != nil {
+		return err
+	}
+	if err := ensureSQLiteCostRoutingColumns(db); err != nil {
+		return err
+	}
+	if err := ensureSQLiteUsageEventsRoutingTraceColumn(db); err != nil {
+		return err
+	}
+	if err := ensureSQLiteChannelScheduleColumns(db); err != nil {
+		return err
+	}
+	if err := ensureSQLiteChannelGroupCanaryColumns(db); err != nil {
+		return err
+	}
+	if err := ensureSQLiteUserTokenLimitColumns(db); err != nil {
+		return err
+	}
+	if err := ensureSQLiteRateLimitColumns(db); err != nil {
+		return err
+	}
+	if err := ensureSQLiteBalanceTransactionsTable(db); err != nil {
+		return err
+	}
+	if err := ensureSQLiteOrganizations(db); err != nil {
+		return err
+	}
 	if err := ensureSQLiteChannelGroupPointers(db); err != nil {
 		return err
 	}
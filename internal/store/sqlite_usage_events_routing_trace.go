@@ -0,0 +1,37 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ensureSQLiteUsageEventsRoutingTraceColumn 补齐 usage_events.routing_trace（路由决策追踪 JSON）。
+func ensureSQLiteUsageEventsRoutingTraceColumn(db *sql.DB) error {
+	if db == nil {
+		return errors.New("db 为空")
+	}
+
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开始 SQLite schema 修补事务失败: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	cols, err := sqliteTableColumns(ctx, tx, "usage_events")
+	if err != nil {
+		return err
+	}
+	if _, ok := cols["routing_trace"]; !ok {
+		if _, err := tx.ExecContext(ctx, "ALTER TABLE usage_events ADD COLUMN routing_trace TEXT NULL"); err != nil {
+			return fmt.Errorf("添加 usage_events 列 routing_trace 失败: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交 SQLite schema 修补事务失败: %w", err)
+	}
+	return nil
+}
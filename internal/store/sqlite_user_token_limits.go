@@ -0,0 +1,52 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ensureSQLiteUserTokenLimitColumns 补齐 user_tokens 的过期时间、消费上限、模型白名单与来源限制列。
+func ensureSQLiteUserTokenLimitColumns(db *sql.DB) error {
+	if db == nil {
+		return errors.New("db 为空")
+	}
+
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开始 SQLite schema 修补事务失败: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	existing, err := sqliteTableColumns(ctx, tx, "user_tokens")
+	if err != nil {
+		return err
+	}
+	for _, col := range [][2]string{
+		{"expires_at", "DATETIME NULL"},
+		{"budget_usd", "DECIMAL(20,6) NULL"},
+		{"budget_1d_usd", "DECIMAL(20,6) NULL"},
+		{"budget_7d_usd", "DECIMAL(20,6) NULL"},
+		{"budget_30d_usd", "DECIMAL(20,6) NULL"},
+		{"allowed_models", "TEXT NULL"},
+		{"allowed_ips", "TEXT NULL"},
+		{"allowed_referers", "TEXT NULL"},
+		{"access_violation_limit", "INTEGER NOT NULL DEFAULT 0"},
+		{"access_violations", "INTEGER NOT NULL DEFAULT 0"},
+		{"last_client_ip", "TEXT NULL"},
+	} {
+		if _, ok := existing[col[0]]; ok {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `ALTER TABLE user_tokens ADD COLUMN `+col[0]+` `+col[1]); err != nil {
+			return fmt.Errorf("添加 user_tokens 列 %s 失败: %w", col[0], err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交 SQLite schema 修补事务失败: %w", err)
+	}
+	return nil
+}
@@ -199,7 +199,7 @@ func (s *Store) RotateUserToken(ctx context.Context, userID, tokenID int64, rawT
 
 	res, err := s.db.ExecContext(ctx, `
 UPDATE user_tokens
-SET token_hash=?, token_plain=?, token_hint=?, status=1, revoked_at=NULL, last_used_at=NULL
+SET token_hash=?, token_plain=?, token_hint=?, status=1, revoked_at=NULL, last_used_at=NULL, access_violations=0
 WHERE id=? AND user_id=?
 `, tokenHash, rawToken, hint, tokenID, userID)
 	if err != nil {
@@ -213,9 +213,9 @@ WHERE id=? AND user_id=?
 
 func (s *Store) ListUserTokens(ctx context.Context, userID int64) ([]UserToken, error) {
 	rows, err := s.db.QueryContext(ctx, `
-SELECT id, user_id, name, token_hash, token_hint, status, created_at, revoked_at, last_used_at
+SELECT id, user_id, name, token_hash, token_hint, status, created_at, revoked_at, last_used_at, `+userTokenLimitsColumns+`, `+userTokenAccessColumns+`
 FROM user_tokens
-WHERE user_id=?
+WHERE user_id=? AND org_id IS NULL
 ORDER BY id DESC
 `, userID)
 	if err != nil {
@@ -225,9 +225,17 @@ ORDER BY id DESC
 	var out []UserToken
 	for rows.Next() {
 		var t UserToken
-		if err := rows.Scan(&t.ID, &t.UserID, &t.Name, &t.TokenHash, &t.TokenHint, &t.Status, &t.CreatedAt, &t.RevokedAt, &t.LastUsedAt); err != nil {
+		var limits userTokenLimitsScan
+		var access userTokenAccessScan
+		if err := rows.Scan(append(append([]any{&t.ID, &t.UserID, &t.Name, &t.TokenHash, &t.TokenHint, &t.Status, &t.CreatedAt, &t.RevokedAt, &t.LastUsedAt}, limits.dest()...), access.dest()...)...); err != nil {
 			return nil, fmt.Errorf("扫描 Token 失败: %w", err)
 		}
+		if t.Limits, err = limits.limits(); err != nil {
+			return nil, err
+		}
+		if err := access.applyTo(&t); err != nil {
+			return nil, err
+		}
 		out = append(out, t)
 	}
 	if err := rows.Err(); err != nil {
@@ -245,17 +253,25 @@ func (s *Store) GetUserTokenByID(ctx context.Context, userID, tokenID int64) (Us
 	}
 
 	var t UserToken
+	var limits userTokenLimitsScan
+	var access userTokenAccessScan
 	err := s.db.QueryRowContext(ctx, `
-SELECT id, user_id, name, token_hash, token_hint, status, created_at, revoked_at, last_used_at
+SELECT id, user_id, name, token_hash, token_hint, status, created_at, revoked_at, last_used_at, `+userTokenLimitsColumns+`, `+userTokenAccessColumns+`
 FROM user_tokens
 WHERE id=? AND user_id=?
-`, tokenID, userID).Scan(&t.ID, &t.UserID, &t.Name, &t.TokenHash, &t.TokenHint, &t.Status, &t.CreatedAt, &t.RevokedAt, &t.LastUsedAt)
+`, tokenID, userID).Scan(append(append([]any{&t.ID, &t.UserID, &t.Name, &t.TokenHash, &t.TokenHint, &t.Status, &t.CreatedAt, &t.RevokedAt, &t.LastUsedAt}, limits.dest()...), access.dest()...)...)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return UserToken{}, sql.ErrNoRows
 		}
 		return UserToken{}, fmt.Errorf("查询 Token 失败: %w", err)
 	}
+	if t.Limits, err = limits.limits(); err != nil {
+		return UserToken{}, err
+	}
+	if err := access.applyTo(&t); err != nil {
+		return UserToken{}, err
+	}
 	return t, nil
 }
 
@@ -348,6 +364,10 @@ type TokenAuth struct {
 	TokenID int64
 	Role    string
 	Groups  []string
+
+	// Access 为 Token 的来源限制；LastClientIP 为最近一次通过鉴权的客户端 IP（仅 GetTokenAuthByTokenHash 填充）。
+	Access       UserTokenAccess
+	LastClientIP string
 }
 
 func (s *Store) GetTokenAuthByRawToken(ctx context.Context, rawToken string) (TokenAuth, error) {
@@ -357,24 +377,57 @@ func (s *Store) GetTokenAuthByRawToken(ctx context.Context, rawToken string) (To
 
 func (s *Store) GetTokenAuthByTokenHash(ctx context.Context, tokenHash []byte) (TokenAuth, error) {
 	var auth TokenAuth
+	var expiresAt sql.NullTime
+	var access userTokenAccessScan
 	err := s.db.QueryRowContext(ctx, `
 SELECT
-  u.id, t.id, u.role
+  u.id, t.id, u.role, t.expires_at, t.allowed_ips, t.allowed_referers, t.access_violation_limit, t.access_violations, t.last_client_ip
 FROM user_tokens t
 JOIN users u ON u.id=t.user_id
 WHERE t.token_hash=? AND t.status=1 AND u.status=1
-`, tokenHash).Scan(&auth.UserID, &auth.TokenID, &auth.Role)
+`, tokenHash).Scan(append([]any{&auth.UserID, &auth.TokenID, &auth.Role, &expiresAt}, access.dest()...)...)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return TokenAuth{}, sql.ErrNoRows
 		}
 		return TokenAuth{}, fmt.Errorf("查询 Token 鉴权失败: %w", err)
 	}
+	if expiresAt.Valid && !time.Now().Before(expiresAt.Time) {
+		return TokenAuth{}, ErrUserTokenExpired
+	}
+	if auth.Access, err = access.access(); err != nil {
+		return TokenAuth{}, err
+	}
+	auth.LastClientIP = access.lastClientIP.String
 	auth.Groups, _ = s.ListEffectiveTokenChannelGroups(ctx, auth.TokenID)
 	_, _ = s.db.ExecContext(ctx, `UPDATE user_tokens SET last_used_at=CURRENT_TIMESTAMP WHERE id=?`, auth.TokenID)
 	return auth, nil
 }
 
+// GetTokenAuthByTokenID 按 token id 还原鉴权信息（用于后台代替下游执行请求，如 Batch 任务）。
+func (s *Store) GetTokenAuthByTokenID(ctx context.Context, tokenID int64) (TokenAuth, error) {
+	var auth TokenAuth
+	var expiresAt sql.NullTime
+	err := s.db.QueryRowContext(ctx, `
+SELECT
+  u.id, t.id, u.role, t.expires_at
+FROM user_tokens t
+JOIN users u ON u.id=t.user_id
+WHERE t.id=? AND t.status=1 AND u.status=1
+`, tokenID).Scan(&auth.UserID, &auth.TokenID, &auth.Role, &expiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return TokenAuth{}, sql.ErrNoRows
+		}
+		return TokenAuth{}, fmt.Errorf("查询 Token 鉴权失败: %w", err)
+	}
+	if expiresAt.Valid && !time.Now().Before(expiresAt.Time) {
+		return TokenAuth{}, ErrUserTokenExpired
+	}
+	auth.Groups, _ = s.ListEffectiveTokenChannelGroups(ctx, auth.TokenID)
+	return auth, nil
+}
+
 func (s *Store) CreateSession(ctx context.Context, userID int64, rawSession string, csrfToken string, expiresAt time.Time) (int64, error) {
 	sessionHash := crypto.TokenHash(rawSession)
 	res, err := s.db.ExecContext(ctx, `
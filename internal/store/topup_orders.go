@@ -308,15 +308,13 @@ WHERE id=?
 		return fmt.Errorf("更新订单失败: %w", err)
 	}
 
-	stmtInitBalance := fmt.Sprintf(`
-%s INTO user_balances(user_id, usd, created_at, updated_at)
-VALUES(?, 0, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
-`, insertIgnoreVerb(s.dialect))
-	if _, err := tx.ExecContext(ctx, stmtInitBalance, o.UserID); err != nil {
-		return fmt.Errorf("初始化余额失败: %w", err)
-	}
-	if _, err := tx.ExecContext(ctx, userBalancesAddSQL(s.dialect), creditUSD, o.UserID); err != nil {
-		return fmt.Errorf("入账失败: %w", err)
+	if _, err := addUserBalanceUSDTx(ctx, tx, s.dialect, balanceTxInput{
+		UserID:       o.UserID,
+		Type:         BalanceTxTopup,
+		AmountUSD:    creditUSD,
+		TopupOrderID: int64Ptr(o.ID),
+	}); err != nil {
+		return err
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -10,7 +10,7 @@ import (
 
 func defaultAPISettingsForChannelType(channelType string) (chatEnabled bool, responsesEnabled bool) {
 	switch strings.TrimSpace(channelType) {
-	case UpstreamTypeOpenAICompatible:
+	case UpstreamTypeOpenAICompatible, UpstreamTypeAzureOpenAI:
 		return true, true
 	case UpstreamTypeCodexOAuth:
 		return false, true
@@ -34,7 +34,21 @@ func sanitizeUpstreamChannelSetting(setting UpstreamChannelSetting) UpstreamChan
 func normalizeUpstreamChannelSettingForRead(channelType string, setting UpstreamChannelSetting) UpstreamChannelSetting {
 	setting = sanitizeUpstreamChannelSetting(setting)
 	channelType = strings.TrimSpace(channelType)
-	if channelType == UpstreamTypeAnthropic {
+	if !channelSupportsEmbeddings(channelType) {
+		// embeddings 仅由 openai_compatible / azure_openai 渠道提供（上游 embeddings 接口）。
+		setting.EmbeddingsEnabled = false
+	}
+	if channelType != UpstreamTypeOpenAICompatible {
+		// messages 转换仅对 openai_compatible 渠道有意义（anthropic 渠道原生支持）。
+		setting.MessagesEnabled = false
+	}
+	if channelType == UpstreamTypeAnthropic || channelType == UpstreamTypeBedrock || channelType == UpstreamTypeVertex {
+		// anthropic / bedrock / vertex 渠道可选开启 chat/completions（经协议转换），默认关闭；不支持 responses。
+		setting.ResponsesEnabled = false
+		return setting
+	}
+	if channelType == UpstreamTypeGemini {
+		// gemini 渠道仅承接原生 /v1beta/models/* 请求，不参与 OpenAI 协议接口。
 		setting.ChatCompletionsEnabled = false
 		setting.ResponsesEnabled = false
 		return setting
@@ -54,17 +68,38 @@ func normalizeUpstreamChannelSetting(channelType string, setting UpstreamChannel
 	if channelType == UpstreamTypeCodexOAuth && setting.ChatCompletionsEnabled {
 		return setting, errors.New("codex_oauth 渠道不支持 chat/completions")
 	}
-	if channelType == UpstreamTypeAnthropic {
-		setting.ChatCompletionsEnabled = false
+	if !channelSupportsEmbeddings(channelType) && setting.EmbeddingsEnabled {
+		return setting, errors.New("仅 openai_compatible / azure_openai 渠道支持 embeddings")
+	}
+	if channelType != UpstreamTypeOpenAICompatible && setting.MessagesEnabled {
+		return setting, errors.New("仅 openai_compatible 渠道支持 messages 协议转换")
+	}
+	if channelType == UpstreamTypeAnthropic || channelType == UpstreamTypeBedrock || channelType == UpstreamTypeVertex {
+		// chat/completions 经协议转换支持；responses 不支持。
 		setting.ResponsesEnabled = false
 		return setting, nil
 	}
+	if channelType == UpstreamTypeGemini {
+		if setting.ChatCompletionsEnabled || setting.ResponsesEnabled {
+			return setting, errors.New("gemini 渠道不支持 chat/completions 与 responses")
+		}
+		return setting, nil
+	}
 	if !setting.ChatCompletionsEnabled && !setting.ResponsesEnabled {
 		return setting, errors.New("至少启用一个接口能力")
 	}
 	return setting, nil
 }
 
+func channelSupportsEmbeddings(channelType string) bool {
+	switch strings.TrimSpace(channelType) {
+	case UpstreamTypeOpenAICompatible, UpstreamTypeAzureOpenAI:
+		return true
+	default:
+		return false
+	}
+}
+
 func applyDefaultAPISettingsForChannelType(channelType string, setting UpstreamChannelSetting) UpstreamChannelSetting {
 	if setting.ChatCompletionsEnabled || setting.ResponsesEnabled {
 		return setting
@@ -29,6 +29,9 @@ type ReserveUsageInput struct {
 	ServiceTier      *string
 	ReservedUSD      decimal.Decimal
 	ReserveExpiresAt time.Time
+
+	// OrgID 非空表示该请求由组织计费（组织 Token）：订阅为组织订阅，按量计费从组织余额扣减。
+	OrgID *int64
 }
 
 func (s *Store) ReserveUsage(ctx context.Context, in ReserveUsageInput) (int64, error) {
@@ -46,15 +49,31 @@ func (s *Store) ReserveUsage(ctx context.Context, in ReserveUsageInput) (int64,
 	}
 	reservedUSD := in.ReservedUSD.Truncate(USDScale)
 	serviceTier := NormalizeOptionalServiceTier(in.ServiceTier)
-	res, err := s.db.ExecContext(ctx, `
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("开始事务失败: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	now := time.Now()
+	if err := checkUserTokenBudgetsTx(ctx, tx, s.dialect, in.TokenID, reservedUSD, now); err != nil {
+		return 0, err
+	}
+	if in.OrgID != nil {
+		if err := checkOrganizationMemberSpendTx(ctx, tx, s.dialect, *in.OrgID, in.UserID, reservedUSD, now); err != nil {
+			return 0, err
+		}
+	}
+	res, err := tx.ExecContext(ctx, `
 INSERT INTO usage_events(
-  time, request_id, user_id, subscription_id, token_id, state, model, service_tier,
+  time, request_id, user_id, org_id, subscription_id, token_id, state, model, service_tier,
   reserved_usd, committed_usd, reserve_expires_at, created_at, updated_at
 ) VALUES(
-  CURRENT_TIMESTAMP, ?, ?, ?, ?, ?, ?, ?,
+  CURRENT_TIMESTAMP, ?, ?, ?, ?, ?, ?, ?, ?,
   ?, 0, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP
 )
-`, in.RequestID, in.UserID, in.SubscriptionID, in.TokenID, UsageStateReserved, in.Model, serviceTier, reservedUSD, in.ReserveExpiresAt)
+`, in.RequestID, in.UserID, in.OrgID, in.SubscriptionID, in.TokenID, UsageStateReserved, in.Model, serviceTier, reservedUSD, in.ReserveExpiresAt)
 	if err != nil {
 		return 0, fmt.Errorf("写入 usage_events(reserved) 失败: %w", err)
 	}
@@ -62,6 +81,9 @@ INSERT INTO usage_events(
 	if err != nil {
 		return 0, fmt.Errorf("获取 usage_event id 失败: %w", err)
 	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("提交事务失败: %w", err)
+	}
 	return id, nil
 }
 
@@ -137,9 +159,21 @@ type FinalizeUsageEventInput struct {
 	UpstreamChannelID     *int64
 	UpstreamEndpointID    *int64
 	UpstreamCredID        *int64
-	IsStream              bool
-	RequestBytes          int64
-	ResponseBytes         int64
+	// RouteGroupID 为直接包含所选渠道的渠道组 ID；nil 表示未经渠道组路由。
+	RouteGroupID  *int64
+	IsStream      bool
+	RequestBytes  int64
+	ResponseBytes int64
+	// UpstreamCost 为本次命中 channel_model 的成本基准；nil 表示未配置成本价（对应列写为 NULL）。
+	UpstreamCost *UsageCostBasis
+	// RoutingTrace 为路由决策追踪 JSON；nil 表示未开启追踪（保留原值）。
+	RoutingTrace *string
+}
+
+// UsageCostBasis 记录 usage_event 计费时所依据的上游成本价，用于事后核算毛利。
+type UsageCostBasis struct {
+	ChannelModelID int64
+	Cost           ChannelModelCost
 }
 
 func (s *Store) FinalizeUsageEvent(ctx context.Context, in FinalizeUsageEventInput) error {
@@ -235,16 +269,35 @@ func (s *Store) FinalizeUsageEvent(ctx context.Context, in FinalizeUsageEventInp
 		respBytes = 0
 	}
 
+	var costModelID, costIn, costOut, costCacheIn, costCacheOut any
+	if in.UpstreamCost != nil && in.UpstreamCost.Cost.Configured() {
+		cost, err := normalizeChannelModelCost(in.UpstreamCost.Cost)
+		if err != nil {
+			return err
+		}
+		if in.UpstreamCost.ChannelModelID > 0 {
+			costModelID = in.UpstreamCost.ChannelModelID
+		}
+		costIn = optionalDecimalArg(cost.InputUSDPer1M)
+		costOut = optionalDecimalArg(cost.OutputUSDPer1M)
+		costCacheIn = optionalDecimalArg(cost.CacheInputUSDPer1M)
+		costCacheOut = optionalDecimalArg(cost.CacheOutputUSDPer1M)
+	}
+
 	_, err := s.db.ExecContext(ctx, `
 UPDATE usage_events
 SET endpoint=?, method=?, status_code=?, latency_ms=?, first_token_latency_ms=?, error_class=?, error_message=?,
     forwarded_model=COALESCE(?, forwarded_model), upstream_response_model=COALESCE(?, upstream_response_model),
-    upstream_channel_id=?, upstream_endpoint_id=?, upstream_credential_id=?,
+    upstream_channel_id=?, upstream_endpoint_id=?, upstream_credential_id=?, route_group_id=?,
+    cost_channel_model_id=?, cost_input_usd_per_1m=?, cost_output_usd_per_1m=?, cost_cache_input_usd_per_1m=?, cost_cache_output_usd_per_1m=?,
+    routing_trace=COALESCE(?, routing_trace),
     is_stream=?, request_bytes=?, response_bytes=?, updated_at=CURRENT_TIMESTAMP
 WHERE id=?
 `, endpointAny, methodAny, statusCode, latencyMS, firstTokenLatencyMS, errClassPtr, errMsgPtr,
 		forwardedModelPtr, upstreamResponseModelPtr,
-		in.UpstreamChannelID, in.UpstreamEndpointID, in.UpstreamCredID,
+		in.UpstreamChannelID, in.UpstreamEndpointID, in.UpstreamCredID, in.RouteGroupID,
+		costModelID, costIn, costOut, costCacheIn, costCacheOut,
+		in.RoutingTrace,
 		stream, reqBytes, respBytes, in.UsageEventID)
 	if err != nil {
 		return fmt.Errorf("更新 usage_event 明细失败: %w", err)
@@ -350,18 +403,25 @@ type UsageSumWithReservedBySubscriptionInput struct {
 	SubscriptionID int64
 	Since          time.Time
 	Now            time.Time
+
+	// OrgID 非 0 时汇总组织订阅（由多个成员共用），忽略 UserID。
+	OrgID int64
 }
 
 func (s *Store) SumCommittedAndReservedUSDBySubscription(ctx context.Context, in UsageSumWithReservedBySubscriptionInput) (committedUSD decimal.Decimal, reservedUSD decimal.Decimal, err error) {
 	var committedSum decimal.NullDecimal
 	var reservedSum decimal.NullDecimal
+	ownerCol, ownerID := "user_id", in.UserID
+	if in.OrgID > 0 {
+		ownerCol, ownerID = "org_id", in.OrgID
+	}
 	err = s.db.QueryRowContext(ctx, `
 SELECT
   SUM(CASE WHEN state=? THEN committed_usd ELSE 0 END) AS committed_sum,
   SUM(CASE WHEN state=? AND reserve_expires_at >= ? THEN reserved_usd ELSE 0 END) AS reserved_sum
 FROM usage_events
-WHERE user_id=? AND subscription_id=? AND time >= ? AND (state=? OR state=?)
-	`, UsageStateCommitted, UsageStateReserved, in.Now, in.UserID, in.SubscriptionID, in.Since, UsageStateCommitted, UsageStateReserved).Scan(&committedSum, &reservedSum)
+WHERE `+ownerCol+`=? AND subscription_id=? AND time >= ? AND (state=? OR state=?)
+	`, UsageStateCommitted, UsageStateReserved, in.Now, ownerID, in.SubscriptionID, in.Since, UsageStateCommitted, UsageStateReserved).Scan(&committedSum, &reservedSum)
 	if err != nil {
 		return decimal.Zero, decimal.Zero, fmt.Errorf("汇总用量失败: %w", err)
 	}
@@ -410,19 +470,30 @@ type UsageSumWithReservedRangeByTokenInput struct {
 	Now     time.Time
 }
 
-func (s *Store) SumCommittedAndReservedUSDRangeByToken(ctx context.Context, in UsageSumWithReservedRangeByTokenInput) (committedUSD decimal.Decimal, reservedUSD decimal.Decimal, err error) {
-	var committedSum decimal.NullDecimal
-	var reservedSum decimal.NullDecimal
-	err = s.db.QueryRowContext(ctx, `
+const sumCommittedAndReservedUSDRangeByTokenSQL = `
 SELECT
   SUM(CASE WHEN state=? THEN committed_usd ELSE 0 END) AS committed_sum,
   SUM(CASE WHEN state=? AND reserve_expires_at >= ? THEN reserved_usd ELSE 0 END) AS reserved_sum
 FROM usage_events
 WHERE token_id=? AND time >= ? AND time < ? AND (state=? OR state=?)
-	`, UsageStateCommitted, UsageStateReserved, in.Now, in.TokenID, in.Since, in.Until, UsageStateCommitted, UsageStateReserved).Scan(&committedSum, &reservedSum)
+`
+
+func (s *Store) SumCommittedAndReservedUSDRangeByToken(ctx context.Context, in UsageSumWithReservedRangeByTokenInput) (committedUSD decimal.Decimal, reservedUSD decimal.Decimal, err error) {
+	committedUSD, reservedUSD, err = scanCommittedAndReservedUSD(s.db.QueryRowContext(ctx, sumCommittedAndReservedUSDRangeByTokenSQL,
+		UsageStateCommitted, UsageStateReserved, in.Now, in.TokenID, in.Since, in.Until, UsageStateCommitted, UsageStateReserved))
 	if err != nil {
 		return decimal.Zero, decimal.Zero, fmt.Errorf("汇总用量失败: %w", err)
 	}
+	return committedUSD, reservedUSD, nil
+}
+
+// scanCommittedAndReservedUSD 扫描 (committed_sum, reserved_sum) 两列汇总结果，NULL 视为 0。
+func scanCommittedAndReservedUSD(row rowScanner) (committedUSD decimal.Decimal, reservedUSD decimal.Decimal, err error) {
+	var committedSum decimal.NullDecimal
+	var reservedSum decimal.NullDecimal
+	if err := row.Scan(&committedSum, &reservedSum); err != nil {
+		return decimal.Zero, decimal.Zero, err
+	}
 	if committedSum.Valid {
 		committedUSD = committedSum.Decimal.Truncate(USDScale)
 	}
@@ -498,6 +569,18 @@ func (s *Store) GetFirstUsageEventTimeByChannel(ctx context.Context, channelID i
 	return s.firstUsageEventTime(ctx, firstUsageEventWhereUpstreamChannelID, channelID)
 }
 
+// GetUsageEventRoutingTrace 返回 usage_event 记录的路由决策追踪 JSON；未记录时返回空串。
+func (s *Store) GetUsageEventRoutingTrace(ctx context.Context, id int64) (string, error) {
+	var trace sql.NullString
+	if err := s.db.QueryRowContext(ctx, `SELECT routing_trace FROM usage_events WHERE id=?`, id).Scan(&trace); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", sql.ErrNoRows
+		}
+		return "", fmt.Errorf("查询 usage_event 路由追踪失败: %w", err)
+	}
+	return trace.String, nil
+}
+
 func (s *Store) GetUsageEvent(ctx context.Context, id int64) (UsageEvent, error) {
 	var e UsageEvent
 	var model sql.NullString
@@ -1409,6 +1492,69 @@ LIMIT ?
 	return out, nil
 }
 
+type UsageOrgSum struct {
+	OrgID        int64
+	Name         string
+	Requests     int64
+	CommittedUSD decimal.Decimal
+	ReservedUSD  decimal.Decimal
+}
+
+// ListUsageTopOrganizations 按组织汇总区间内由组织计费的用量（仅含组织 Token 产生的请求）。
+func (s *Store) ListUsageTopOrganizations(ctx context.Context, in UsageTopUsersInput) ([]UsageOrgSum, error) {
+	if in.Limit <= 0 || in.Limit > 200 {
+		in.Limit = 50
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+SELECT x.org_id,
+       COALESCE(o.name, ''),
+       x.requests, x.committed_sum, x.reserved_sum
+FROM (
+  SELECT org_id,
+         SUM(CASE WHEN state=? THEN 1 ELSE 0 END) AS requests,
+         SUM(CASE WHEN state=? THEN committed_usd ELSE 0 END) AS committed_sum,
+         SUM(CASE WHEN state=? AND reserve_expires_at >= ? THEN reserved_usd ELSE 0 END) AS reserved_sum
+  FROM usage_events
+  WHERE org_id IS NOT NULL AND time >= ? AND time < ? AND (state=? OR state=?)
+  GROUP BY org_id
+) x
+LEFT JOIN organizations o ON o.id=x.org_id
+ORDER BY x.committed_sum DESC
+LIMIT ?
+`, UsageStateCommitted, UsageStateCommitted, UsageStateReserved, in.Now, in.Since, in.Until, UsageStateCommitted, UsageStateReserved, in.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("查询组织用量汇总失败: %w", err)
+	}
+	defer rows.Close()
+
+	var out []UsageOrgSum
+	for rows.Next() {
+		var row UsageOrgSum
+		var requests sql.NullInt64
+		var committedSum decimal.NullDecimal
+		var reservedSum decimal.NullDecimal
+		if err := rows.Scan(&row.OrgID, &row.Name, &requests, &committedSum, &reservedSum); err != nil {
+			return nil, fmt.Errorf("扫描组织用量汇总失败: %w", err)
+		}
+		row.Requests = requests.Int64
+		if committedSum.Valid {
+			row.CommittedUSD = committedSum.Decimal.Truncate(USDScale)
+		}
+		if reservedSum.Valid {
+			row.ReservedUSD = reservedSum.Decimal.Truncate(USDScale)
+		}
+		if row.Name = strings.TrimSpace(row.Name); row.Name == "" {
+			row.Name = fmt.Sprintf("#%d", row.OrgID)
+		}
+		out = append(out, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历组织用量汇总失败: %w", err)
+	}
+	return out, nil
+}
+
 func computeOutputTokensPerSecond(outputTokens int64, decodeLatencyMS int64) float64 {
 	if outputTokens <= 0 || decodeLatencyMS <= 0 {
 		return 0
@@ -37,35 +37,35 @@ func (s *Store) ReserveUsageAndDebitBalance(ctx context.Context, in ReserveUsage
 	}
 	defer func() { _ = tx.Rollback() }()
 
-	stmtInitBalance := fmt.Sprintf(`
-%s INTO user_balances(user_id, usd, created_at, updated_at)
-VALUES(?, 0, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
-`, insertIgnoreVerb(s.dialect))
-	if _, err := tx.ExecContext(ctx, stmtInitBalance, in.UserID); err != nil {
-		return 0, fmt.Errorf("初始化余额失败: %w", err)
+	now := time.Now()
+	if err := checkUserTokenBudgetsTx(ctx, tx, s.dialect, in.TokenID, reservedUSD, now); err != nil {
+		return 0, err
+	}
+	if in.OrgID != nil {
+		if err := checkOrganizationMemberSpendTx(ctx, tx, s.dialect, *in.OrgID, in.UserID, reservedUSD, now); err != nil {
+			return 0, err
+		}
 	}
 
-	var bal decimal.Decimal
-	qBalance := "SELECT usd FROM user_balances WHERE user_id=?" + forUpdateClause(s.dialect)
-	if err := tx.QueryRowContext(ctx, qBalance, in.UserID).Scan(&bal); err != nil {
-		return 0, fmt.Errorf("查询余额失败: %w", err)
+	// OrgID 非空时从组织余额预扣，否则从用户余额预扣。
+	account := balanceTxInput{UserID: in.UserID, OrgID: in.OrgID}
+	bal, err := lockBalanceAccountTx(ctx, tx, s.dialect, account)
+	if err != nil {
+		return 0, err
 	}
 	if bal.LessThan(reservedUSD) {
 		return 0, ErrInsufficientBalance
 	}
-	if _, err := tx.ExecContext(ctx, userBalancesSubSQL(s.dialect), reservedUSD, in.UserID); err != nil {
-		return 0, fmt.Errorf("扣减余额失败: %w", err)
-	}
 
 	res, err := tx.ExecContext(ctx, `
 INSERT INTO usage_events(
-  time, request_id, user_id, subscription_id, token_id, state, model, service_tier,
+  time, request_id, user_id, org_id, subscription_id, token_id, state, model, service_tier,
   reserved_usd, committed_usd, reserve_expires_at, created_at, updated_at
 ) VALUES(
-  CURRENT_TIMESTAMP, ?, ?, NULL, ?, ?, ?, ?,
+  CURRENT_TIMESTAMP, ?, ?, ?, NULL, ?, ?, ?, ?,
   ?, 0, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP
 )
-`, in.RequestID, in.UserID, in.TokenID, UsageStateReserved, in.Model, serviceTier, reservedUSD, in.ReserveExpiresAt)
+`, in.RequestID, in.UserID, in.OrgID, in.TokenID, UsageStateReserved, in.Model, serviceTier, reservedUSD, in.ReserveExpiresAt)
 	if err != nil {
 		return 0, fmt.Errorf("写入 usage_events(reserved) 失败: %w", err)
 	}
@@ -73,6 +73,12 @@ INSERT INTO usage_events(
 	if err != nil {
 		return 0, fmt.Errorf("获取 usage_event id 失败: %w", err)
 	}
+	account.Type = BalanceTxUsageReserve
+	account.AmountUSD = reservedUSD.Neg()
+	account.UsageEventID = &id
+	if _, err := changeBalanceAccountTx(ctx, tx, s.dialect, account); err != nil {
+		return 0, err
+	}
 
 	if err := tx.Commit(); err != nil {
 		return 0, fmt.Errorf("提交事务失败: %w", err)
@@ -95,15 +101,15 @@ func (s *Store) CommitUsageAndRefundBalance(ctx context.Context, in CommitUsageI
 	defer func() { _ = tx.Rollback() }()
 
 	var userID int64
+	var orgID, subID sql.NullInt64
 	var state string
 	var reserved decimal.Decimal
-	var subID sql.NullInt64
 	qUsage := `
-SELECT user_id, subscription_id, state, reserved_usd
+SELECT user_id, org_id, subscription_id, state, reserved_usd
 FROM usage_events
 WHERE id=?
 ` + forUpdateClause(s.dialect)
-	if err := tx.QueryRowContext(ctx, qUsage, in.UsageEventID).Scan(&userID, &subID, &state, &reserved); err != nil {
+	if err := tx.QueryRowContext(ctx, qUsage, in.UsageEventID).Scan(&userID, &orgID, &subID, &state, &reserved); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return sql.ErrNoRows
 		}
@@ -116,6 +122,8 @@ WHERE id=?
 		return nil
 	}
 
+	account := usageBalanceAccount(userID, orgID, in.UsageEventID)
+
 	committed := in.CommittedUSD.Truncate(USDScale)
 	if committed.Equal(decimal.Zero) {
 		committed = reserved
@@ -128,18 +136,9 @@ WHERE id=?
 		// 预留不足：尝试补扣差额（余额不足时最多扣到 0）。
 		extra := committed.Sub(reserved)
 
-		stmtInitBalance := fmt.Sprintf(`
-%s INTO user_balances(user_id, usd, created_at, updated_at)
-VALUES(?, 0, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
-`, insertIgnoreVerb(s.dialect))
-		if _, err := tx.ExecContext(ctx, stmtInitBalance, userID); err != nil {
-			return fmt.Errorf("初始化余额失败: %w", err)
-		}
-
-		var bal decimal.Decimal
-		qBalance := "SELECT usd FROM user_balances WHERE user_id=?" + forUpdateClause(s.dialect)
-		if err := tx.QueryRowContext(ctx, qBalance, userID).Scan(&bal); err != nil {
-			return fmt.Errorf("查询余额失败: %w", err)
+		bal, err := lockBalanceAccountTx(ctx, tx, s.dialect, account)
+		if err != nil {
+			return err
 		}
 
 		debit := extra
@@ -148,8 +147,10 @@ VALUES(?, 0, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 		}
 		debit = debit.Truncate(USDScale)
 		if debit.GreaterThan(decimal.Zero) {
-			if _, err := tx.ExecContext(ctx, userBalancesSubSQL(s.dialect), debit, userID); err != nil {
-				return fmt.Errorf("补扣余额失败: %w", err)
+			account.Type = BalanceTxUsageDebit
+			account.AmountUSD = debit.Neg()
+			if _, err := changeBalanceAccountTx(ctx, tx, s.dialect, account); err != nil {
+				return err
 			}
 		}
 
@@ -188,8 +189,10 @@ WHERE id=? AND state=?
 	}
 
 	if refund.GreaterThan(decimal.Zero) {
-		if _, err := tx.ExecContext(ctx, userBalancesAddSQL(s.dialect), refund, userID); err != nil {
-			return fmt.Errorf("返还余额失败: %w", err)
+		account.Type = BalanceTxUsageRefund
+		account.AmountUSD = refund
+		if _, err := changeBalanceAccountTx(ctx, tx, s.dialect, account); err != nil {
+			return err
 		}
 	}
 
@@ -211,15 +214,15 @@ func (s *Store) VoidUsageAndRefundBalance(ctx context.Context, usageEventID int6
 	defer func() { _ = tx.Rollback() }()
 
 	var userID int64
+	var orgID, subID sql.NullInt64
 	var state string
 	var reserved decimal.Decimal
-	var subID sql.NullInt64
 	qUsage := `
-SELECT user_id, subscription_id, state, reserved_usd
+SELECT user_id, org_id, subscription_id, state, reserved_usd
 FROM usage_events
 WHERE id=?
 ` + forUpdateClause(s.dialect)
-	if err := tx.QueryRowContext(ctx, qUsage, usageEventID).Scan(&userID, &subID, &state, &reserved); err != nil {
+	if err := tx.QueryRowContext(ctx, qUsage, usageEventID).Scan(&userID, &orgID, &subID, &state, &reserved); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil
 		}
@@ -251,8 +254,14 @@ WHERE id=? AND state=?
 		return fmt.Errorf("作废 usage_event 失败: %w", err)
 	}
 	if reserved.GreaterThan(decimal.Zero) {
-		if _, err := tx.ExecContext(ctx, userBalancesAddSQL(s.dialect), reserved, userID); err != nil {
-			return fmt.Errorf("返还余额失败: %w", err)
+		account := usageBalanceAccount(userID, orgID, usageEventID)
+		if _, err := lockBalanceAccountTx(ctx, tx, s.dialect, account); err != nil {
+			return err
+		}
+		account.Type = BalanceTxUsageVoid
+		account.AmountUSD = reserved
+		if _, err := changeBalanceAccountTx(ctx, tx, s.dialect, account); err != nil {
+			return err
 		}
 	}
 
@@ -267,11 +276,12 @@ func (s *Store) expireReservedUsageRefundBalances(ctx context.Context, tx *sql.T
 	type row struct {
 		id     int64
 		userID int64
+		orgID  sql.NullInt64
 		amt    decimal.Decimal
 	}
 
 	q := `
-SELECT id, user_id, reserved_usd
+SELECT id, user_id, org_id, reserved_usd
 FROM usage_events
 WHERE state=? AND reserve_expires_at < ? AND subscription_id IS NULL
 ` + forUpdateClause(s.dialect)
@@ -282,19 +292,15 @@ WHERE state=? AND reserve_expires_at < ? AND subscription_id IS NULL
 	defer rows.Close()
 
 	var ids []int64
-	refundByUser := make(map[int64]decimal.Decimal)
+	var expired []row
 	for rows.Next() {
 		var r row
-		if err := rows.Scan(&r.id, &r.userID, &r.amt); err != nil {
+		if err := rows.Scan(&r.id, &r.userID, &r.orgID, &r.amt); err != nil {
 			return 0, fmt.Errorf("扫描过期 usage_events 失败: %w", err)
 		}
 		ids = append(ids, r.id)
-		amt := r.amt.Truncate(USDScale)
-		prev, ok := refundByUser[r.userID]
-		if !ok {
-			prev = decimal.Zero
-		}
-		refundByUser[r.userID] = prev.Add(amt)
+		r.amt = r.amt.Truncate(USDScale)
+		expired = append(expired, r)
 	}
 	if err := rows.Err(); err != nil {
 		return 0, fmt.Errorf("遍历过期 usage_events 失败: %w", err)
@@ -320,22 +326,29 @@ WHERE state=? AND reserve_expires_at < ? AND subscription_id IS NULL
 		return 0, fmt.Errorf("过期清理 usage_events 失败: %w", err)
 	}
 
-	// 返还余额（逐用户聚合写入）
-	for userID, refundUSD := range refundByUser {
-		refundUSD = refundUSD.Truncate(USDScale)
-		if refundUSD.LessThanOrEqual(decimal.Zero) {
+	// 返还余额（逐条写入，便于流水与 usage_event 一一对应）
+	for _, r := range expired {
+		if r.amt.LessThanOrEqual(decimal.Zero) {
 			continue
 		}
-		stmtInitBalance := fmt.Sprintf(`
-%s INTO user_balances(user_id, usd, created_at, updated_at)
-VALUES(?, 0, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
-`, insertIgnoreVerb(s.dialect))
-		if _, err := tx.ExecContext(ctx, stmtInitBalance, userID); err != nil {
-			return 0, fmt.Errorf("初始化余额失败: %w", err)
+		account := usageBalanceAccount(r.userID, r.orgID, r.id)
+		if _, err := lockBalanceAccountTx(ctx, tx, s.dialect, account); err != nil {
+			return 0, err
 		}
-		if _, err := tx.ExecContext(ctx, userBalancesAddSQL(s.dialect), refundUSD, userID); err != nil {
-			return 0, fmt.Errorf("返还余额失败: %w", err)
+		account.Type = BalanceTxUsageExpire
+		account.AmountUSD = r.amt
+		if _, err := changeBalanceAccountTx(ctx, tx, s.dialect, account); err != nil {
+			return 0, err
 		}
 	}
 	return int64(len(ids)), nil
 }
+
+// usageBalanceAccount 返回按量计费 usage_event 所扣余额的账户（组织余额或用户余额）。
+func usageBalanceAccount(userID int64, orgID sql.NullInt64, usageEventID int64) balanceTxInput {
+	in := balanceTxInput{UserID: userID, UsageEventID: &usageEventID}
+	if orgID.Valid {
+		in.OrgID = int64Ptr(orgID.Int64)
+	}
+	return in
+}
@@ -64,11 +64,26 @@ func (s *Store) GetUserBalancesUSD(ctx context.Context, userIDs []int64) (map[in
 }
 
 func (s *Store) AddUserBalanceUSD(ctx context.Context, userID int64, deltaUSD decimal.Decimal) (decimal.Decimal, error) {
-	if userID <= 0 {
+	return s.creditUserBalanceUSD(ctx, balanceTxInput{UserID: userID, Type: BalanceTxAdminCredit, AmountUSD: deltaUSD})
+}
+
+// AddUserBalanceUSDByAdmin 为管理员手动入账，流水记录操作人与备注。
+func (s *Store) AddUserBalanceUSDByAdmin(ctx context.Context, userID int64, actorUserID int64, deltaUSD decimal.Decimal, note string) (decimal.Decimal, error) {
+	in := balanceTxInput{UserID: userID, Type: BalanceTxAdminCredit, AmountUSD: deltaUSD}
+	if actorUserID > 0 {
+		in.ActorUserID = int64Ptr(actorUserID)
+	}
+	if note = strings.TrimSpace(note); note != "" {
+		in.Note = &note
+	}
+	return s.creditUserBalanceUSD(ctx, in)
+}
+
+func (s *Store) creditUserBalanceUSD(ctx context.Context, in balanceTxInput) (decimal.Decimal, error) {
+	if in.UserID <= 0 {
 		return decimal.Zero, errors.New("user_id 不能为空")
 	}
-	deltaUSD = deltaUSD.Truncate(USDScale)
-	if deltaUSD.LessThanOrEqual(decimal.Zero) {
+	if in.AmountUSD.Truncate(USDScale).LessThanOrEqual(decimal.Zero) {
 		return decimal.Zero, errors.New("delta_usd 不合法")
 	}
 
@@ -78,25 +93,14 @@ func (s *Store) AddUserBalanceUSD(ctx context.Context, userID int64, deltaUSD de
 	}
 	defer func() { _ = tx.Rollback() }()
 
-	stmtInitBalance := fmt.Sprintf(`
-%s INTO user_balances(user_id, usd, created_at, updated_at)
-VALUES(?, 0, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
-`, insertIgnoreVerb(s.dialect))
-	if _, err := tx.ExecContext(ctx, stmtInitBalance, userID); err != nil {
-		return decimal.Zero, fmt.Errorf("初始化余额失败: %w", err)
-	}
-	if _, err := tx.ExecContext(ctx, userBalancesAddSQL(s.dialect), deltaUSD, userID); err != nil {
-		return decimal.Zero, fmt.Errorf("入账失败: %w", err)
-	}
-
-	var newBal decimal.Decimal
-	if err := tx.QueryRowContext(ctx, `SELECT usd FROM user_balances WHERE user_id=?`, userID).Scan(&newBal); err != nil {
-		return decimal.Zero, fmt.Errorf("查询余额失败: %w", err)
+	newBal, err := addUserBalanceUSDTx(ctx, tx, s.dialect, in)
+	if err != nil {
+		return decimal.Zero, err
 	}
 	if err := tx.Commit(); err != nil {
 		return decimal.Zero, fmt.Errorf("提交事务失败: %w", err)
 	}
-	return newBal.Truncate(USDScale), nil
+	return newBal, nil
 }
 
 func userBalancesAddSQL(d Dialect) string {
@@ -113,18 +117,3 @@ SET usd=usd+?, updated_at=CURRENT_TIMESTAMP
 WHERE user_id=?
 `
 }
-
-func userBalancesSubSQL(d Dialect) string {
-	if d == DialectSQLite {
-		return `
-UPDATE user_balances
-SET usd=ROUND(usd-?, 6), updated_at=CURRENT_TIMESTAMP
-WHERE user_id=?
-`
-	}
-	return `
-UPDATE user_balances
-SET usd=usd-?, updated_at=CURRENT_TIMESTAMP
-WHERE user_id=?
-`
-}
@@ -0,0 +1,295 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"sort"
+	"strings"
+)
+
+// UserToken 访问限制被拒绝的原因（同时作为审计事件 error_class 与数据面错误 code）。
+const (
+	UserTokenAccessDeniedIP      = "ip_not_allowed"
+	UserTokenAccessDeniedReferer = "referer_not_allowed"
+)
+
+// UserTokenAccess 为单个 Token 的来源限制；字段为空表示不限制。
+//   - AllowedIPs：客户端 IP 白名单（CIDR，IPv4/IPv6；单个 IP 视为 /32 或 /128）。
+//   - AllowedReferers：Referer/Origin 主机名白名单；"*.example.com" 匹配任意子域名（不含 example.com 本身）。
+//   - ViolationLimit：累计违规次数达到该值时自动撤销 Token；0 表示不自动撤销。
+type UserTokenAccess struct {
+	AllowedIPs      []string
+	AllowedReferers []string
+	ViolationLimit  int
+}
+
+// Restricted 判断是否配置了任一来源限制。
+func (a UserTokenAccess) Restricted() bool {
+	return len(a.AllowedIPs) > 0 || len(a.AllowedReferers) > 0
+}
+
+// IPAllowed 判断客户端 IP 是否命中白名单；未配置白名单时恒为 true，无法识别客户端 IP 时为 false。
+func (a UserTokenAccess) IPAllowed(ip netip.Addr) bool {
+	if len(a.AllowedIPs) == 0 {
+		return true
+	}
+	if !ip.IsValid() {
+		return false
+	}
+	ip = ip.Unmap()
+	for _, raw := range a.AllowedIPs {
+		pfx, err := parseUserTokenCIDR(raw)
+		if err != nil {
+			continue
+		}
+		if pfx.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// RefererAllowed 判断请求来源主机名（已从 Referer/Origin 解析，不含端口）是否命中白名单；
+// 未配置白名单时恒为 true，来源为空时为 false。
+func (a UserTokenAccess) RefererAllowed(host string) bool {
+	if len(a.AllowedReferers) == 0 {
+		return true
+	}
+	host = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+	if host == "" {
+		return false
+	}
+	for _, pattern := range a.AllowedReferers {
+		if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+			continue
+		}
+		if host == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+func parseUserTokenCIDR(raw string) (netip.Prefix, error) {
+	raw = strings.TrimSpace(raw)
+	if strings.Contains(raw, "/") {
+		pfx, err := netip.ParsePrefix(raw)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		if pfx.Addr().Is4In6() {
+			return netip.Prefix{}, fmt.Errorf("不支持 IPv4-mapped IPv6 前缀: %q", raw)
+		}
+		return pfx.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(raw)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+func normalizeUserTokenReferer(raw string) (string, error) {
+	v := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(raw)), ".")
+	if v == "" {
+		return "", nil
+	}
+	host := strings.TrimPrefix(v, "*.")
+	if host == "" || strings.ContainsAny(host, "*/:@ \t") || strings.HasPrefix(host, ".") || strings.Contains(host, "..") {
+		return "", fmt.Errorf("Referer 主机名不合法: %q", raw)
+	}
+	if len(v) > 253 {
+		return "", fmt.Errorf("Referer 主机名过长: %q", raw)
+	}
+	return v, nil
+}
+
+// NormalizeUserTokenAccess 校验并规范化来源限制：CIDR 转为网络地址形式，主机名转小写，均去重排序。
+func NormalizeUserTokenAccess(in UserTokenAccess) (UserTokenAccess, error) {
+	if in.ViolationLimit < 0 {
+		return UserTokenAccess{}, errors.New("违规次数上限不能为负数")
+	}
+	out := UserTokenAccess{ViolationLimit: in.ViolationLimit}
+
+	seenIP := make(map[string]struct{}, len(in.AllowedIPs))
+	for _, raw := range in.AllowedIPs {
+		if strings.TrimSpace(raw) == "" {
+			continue
+		}
+		pfx, err := parseUserTokenCIDR(raw)
+		if err != nil {
+			return UserTokenAccess{}, fmt.Errorf("IP/CIDR 不合法: %q", strings.TrimSpace(raw))
+		}
+		v := pfx.String()
+		if _, ok := seenIP[v]; ok {
+			continue
+		}
+		seenIP[v] = struct{}{}
+		out.AllowedIPs = append(out.AllowedIPs, v)
+	}
+	if len(out.AllowedIPs) > 100 {
+		return UserTokenAccess{}, errors.New("IP 白名单最多 100 条")
+	}
+	sort.Strings(out.AllowedIPs)
+
+	seenRef := make(map[string]struct{}, len(in.AllowedReferers))
+	for _, raw := range in.AllowedReferers {
+		v, err := normalizeUserTokenReferer(raw)
+		if err != nil {
+			return UserTokenAccess{}, err
+		}
+		if v == "" {
+			continue
+		}
+		if _, ok := seenRef[v]; ok {
+			continue
+		}
+		seenRef[v] = struct{}{}
+		out.AllowedReferers = append(out.AllowedReferers, v)
+	}
+	if len(out.AllowedReferers) > 100 {
+		return UserTokenAccess{}, errors.New("Referer 白名单最多 100 条")
+	}
+	sort.Strings(out.AllowedReferers)
+	return out, nil
+}
+
+// userTokenAccessScan 用于从 allowed_ips/allowed_referers/access_* /last_client_ip 列扫描来源限制与最近客户端 IP。
+type userTokenAccessScan struct {
+	allowedIPs      sql.NullString
+	allowedReferers sql.NullString
+	violationLimit  int
+	violations      int
+	lastClientIP    sql.NullString
+}
+
+const userTokenAccessColumns = `allowed_ips, allowed_referers, access_violation_limit, access_violations, last_client_ip`
+
+func (c *userTokenAccessScan) dest() []any {
+	return []any{&c.allowedIPs, &c.allowedReferers, &c.violationLimit, &c.violations, &c.lastClientIP}
+}
+
+func (c userTokenAccessScan) access() (UserTokenAccess, error) {
+	out := UserTokenAccess{ViolationLimit: c.violationLimit}
+	if c.allowedIPs.Valid && strings.TrimSpace(c.allowedIPs.String) != "" {
+		if err := json.Unmarshal([]byte(c.allowedIPs.String), &out.AllowedIPs); err != nil {
+			return UserTokenAccess{}, errors.New("Token IP 白名单不合法")
+		}
+	}
+	if c.allowedReferers.Valid && strings.TrimSpace(c.allowedReferers.String) != "" {
+		if err := json.Unmarshal([]byte(c.allowedReferers.String), &out.AllowedReferers); err != nil {
+			return UserTokenAccess{}, errors.New("Token Referer 白名单不合法")
+		}
+	}
+	return out, nil
+}
+
+func (c userTokenAccessScan) applyTo(t *UserToken) error {
+	access, err := c.access()
+	if err != nil {
+		return err
+	}
+	t.Access = access
+	t.AccessViolations = c.violations
+	if c.lastClientIP.Valid && strings.TrimSpace(c.lastClientIP.String) != "" {
+		ip := c.lastClientIP.String
+		t.LastClientIP = &ip
+	}
+	return nil
+}
+
+func optionalJSONStringList(list []string) (any, error) {
+	if len(list) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(list)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// UpdateUserTokenAccess 覆盖写入 Token 的来源限制，并清零已累计的违规次数。
+func (s *Store) UpdateUserTokenAccess(ctx context.Context, userID, tokenID int64, access UserTokenAccess) error {
+	if userID == 0 {
+		return errors.New("userID 不能为空")
+	}
+	if tokenID == 0 {
+		return errors.New("tokenID 不能为空")
+	}
+	access, err := NormalizeUserTokenAccess(access)
+	if err != nil {
+		return err
+	}
+	ips, err := optionalJSONStringList(access.AllowedIPs)
+	if err != nil {
+		return fmt.Errorf("序列化 IP 白名单失败: %w", err)
+	}
+	referers, err := optionalJSONStringList(access.AllowedReferers)
+	if err != nil {
+		return fmt.Errorf("序列化 Referer 白名单失败: %w", err)
+	}
+	if _, err := s.GetUserTokenByID(ctx, userID, tokenID); err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `
+UPDATE user_tokens
+SET allowed_ips=?, allowed_referers=?, access_violation_limit=?, access_violations=0
+WHERE id=? AND user_id=?
+`, ips, referers, access.ViolationLimit, tokenID, userID)
+	if err != nil {
+		return fmt.Errorf("更新 Token 来源限制失败: %w", err)
+	}
+	return nil
+}
+
+// TouchUserTokenClientIP 记录 Token 最近一次通过鉴权的客户端 IP。
+func (s *Store) TouchUserTokenClientIP(ctx context.Context, tokenID int64, ip string) error {
+	ip = strings.TrimSpace(ip)
+	if tokenID == 0 || ip == "" {
+		return nil
+	}
+	if _, err := s.db.ExecContext(ctx, `UPDATE user_tokens SET last_client_ip=? WHERE id=?`, ip, tokenID); err != nil {
+		return fmt.Errorf("更新 Token 客户端 IP 失败: %w", err)
+	}
+	return nil
+}
+
+// RecordUserTokenAccessViolation 累加 Token 的来源违规次数；配置了违规上限且达到上限时自动撤销 Token。
+// 返回累加后的违规次数与本次是否触发撤销。
+func (s *Store) RecordUserTokenAccessViolation(ctx context.Context, tokenID int64) (int, bool, error) {
+	if tokenID == 0 {
+		return 0, false, errors.New("tokenID 不能为空")
+	}
+	if _, err := s.db.ExecContext(ctx, `UPDATE user_tokens SET access_violations=access_violations+1 WHERE id=?`, tokenID); err != nil {
+		return 0, false, fmt.Errorf("更新 Token 违规次数失败: %w", err)
+	}
+	var violations, limit int
+	if err := s.db.QueryRowContext(ctx, `SELECT access_violations, access_violation_limit FROM user_tokens WHERE id=?`, tokenID).Scan(&violations, &limit); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, false, sql.ErrNoRows
+		}
+		return 0, false, fmt.Errorf("查询 Token 违规次数失败: %w", err)
+	}
+	if limit <= 0 || violations < limit {
+		return violations, false, nil
+	}
+	res, err := s.db.ExecContext(ctx, `
+UPDATE user_tokens
+SET status=0, revoked_at=CURRENT_TIMESTAMP, token_plain=NULL
+WHERE id=? AND status=1
+`, tokenID)
+	if err != nil {
+		return violations, false, fmt.Errorf("自动撤销 Token 失败: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return violations, n > 0, nil
+}
@@ -0,0 +1,257 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	// ErrUserTokenExpired 表示 Token 已超过 expires_at。
+	ErrUserTokenExpired = errors.New("user token expired")
+	// ErrUserTokenBudgetExceeded 表示本次预留后 Token 的累计/滚动窗口消费将超过上限。
+	ErrUserTokenBudgetExceeded = errors.New("Token 消费额度已用尽")
+)
+
+// UserTokenLimits 为单个 Token 的使用限制；字段为 nil/空表示不限制。
+//   - BudgetUSD：累计消费上限；Budget1dUSD/Budget7dUSD/Budget30dUSD：滚动窗口消费上限。
+//     消费额按 usage_events 已结算 + 未过期预留计算（与 SumCommittedAndReservedUSDRangeByToken 一致）。
+//   - AllowedModels：允许调用的对外模型 ID 白名单。
+//   - RPMLimit/TPMLimit：Token 维度的每分钟请求数/Token 数上限（0 表示不限制）。
+type UserTokenLimits struct {
+	ExpiresAt     *time.Time
+	BudgetUSD     *decimal.Decimal
+	Budget1dUSD   *decimal.Decimal
+	Budget7dUSD   *decimal.Decimal
+	Budget30dUSD  *decimal.Decimal
+	AllowedModels []string
+	RPMLimit      int64
+	TPMLimit      int64
+}
+
+// Expired 判断 Token 在 now 时刻是否已过期。
+func (l UserTokenLimits) Expired(now time.Time) bool {
+	return l.ExpiresAt != nil && !now.Before(*l.ExpiresAt)
+}
+
+// ModelAllowed 判断模型是否在白名单内；未配置白名单时恒为 true。
+func (l UserTokenLimits) ModelAllowed(model string) bool {
+	if len(l.AllowedModels) == 0 {
+		return true
+	}
+	model = strings.TrimSpace(model)
+	for _, m := range l.AllowedModels {
+		if strings.EqualFold(m, model) {
+			return true
+		}
+	}
+	return false
+}
+
+// UserTokenBudgetWindow 为一个消费上限及其统计窗口；Window 为 0 表示累计（自 Token 创建起）。
+type UserTokenBudgetWindow struct {
+	Name      string
+	Window    time.Duration
+	BudgetUSD decimal.Decimal
+}
+
+// BudgetWindows 返回已配置的消费上限窗口（累计在前，其余按窗口从短到长）。
+func (l UserTokenLimits) BudgetWindows() []UserTokenBudgetWindow {
+	var out []UserTokenBudgetWindow
+	add := func(name string, window time.Duration, v *decimal.Decimal) {
+		if v != nil {
+			out = append(out, UserTokenBudgetWindow{Name: name, Window: window, BudgetUSD: *v})
+		}
+	}
+	add("total", 0, l.BudgetUSD)
+	add("1d", 24*time.Hour, l.Budget1dUSD)
+	add("7d", 7*24*time.Hour, l.Budget7dUSD)
+	add("30d", 30*24*time.Hour, l.Budget30dUSD)
+	return out
+}
+
+// userTokenLimitsScan 用于从 expires_at/budget_*/allowed_models 列扫描 UserTokenLimits。
+type userTokenLimitsScan struct {
+	expiresAt     sql.NullTime
+	budget        sql.NullString
+	budget1d      sql.NullString
+	budget7d      sql.NullString
+	budget30d     sql.NullString
+	allowedModels sql.NullString
+	rpmLimit      int64
+	tpmLimit      int64
+}
+
+const userTokenLimitsColumns = `expires_at, budget_usd, budget_1d_usd, budget_7d_usd, budget_30d_usd, allowed_models, rpm_limit, tpm_limit`
+
+func (c *userTokenLimitsScan) dest() []any {
+	return []any{&c.expiresAt, &c.budget, &c.budget1d, &c.budget7d, &c.budget30d, &c.allowedModels, &c.rpmLimit, &c.tpmLimit}
+}
+
+func (c userTokenLimitsScan) limits() (UserTokenLimits, error) {
+	out := UserTokenLimits{RPMLimit: c.rpmLimit, TPMLimit: c.tpmLimit}
+	var err error
+	if c.expiresAt.Valid {
+		t := c.expiresAt.Time
+		out.ExpiresAt = &t
+	}
+	for _, f := range []struct {
+		dst **decimal.Decimal
+		src sql.NullString
+	}{
+		{&out.BudgetUSD, c.budget},
+		{&out.Budget1dUSD, c.budget1d},
+		{&out.Budget7dUSD, c.budget7d},
+		{&out.Budget30dUSD, c.budget30d},
+	} {
+		if *f.dst, err = parseOptionalManagedModelPrice(f.src); err != nil {
+			return UserTokenLimits{}, errors.New("Token 消费上限不合法")
+		}
+	}
+	if c.allowedModels.Valid && strings.TrimSpace(c.allowedModels.String) != "" {
+		if err := json.Unmarshal([]byte(c.allowedModels.String), &out.AllowedModels); err != nil {
+			return UserTokenLimits{}, errors.New("Token 模型白名单不合法")
+		}
+	}
+	return out, nil
+}
+
+// NormalizeUserTokenLimits 校验并规范化 Token 限制：上限截断到 USDScale 且不得为负，模型白名单去空去重排序。
+func NormalizeUserTokenLimits(in UserTokenLimits) (UserTokenLimits, error) {
+	out := UserTokenLimits{ExpiresAt: in.ExpiresAt}
+	rate, err := NormalizeRateLimit(RateLimit{RPM: in.RPMLimit, TPM: in.TPMLimit})
+	if err != nil {
+		return UserTokenLimits{}, err
+	}
+	out.RPMLimit, out.TPMLimit = rate.RPM, rate.TPM
+	for _, f := range []struct {
+		dst **decimal.Decimal
+		src *decimal.Decimal
+	}{
+		{&out.BudgetUSD, in.BudgetUSD},
+		{&out.Budget1dUSD, in.Budget1dUSD},
+		{&out.Budget7dUSD, in.Budget7dUSD},
+		{&out.Budget30dUSD, in.Budget30dUSD},
+	} {
+		if f.src == nil {
+			continue
+		}
+		v := f.src.Truncate(USDScale)
+		if v.IsNegative() {
+			return UserTokenLimits{}, errors.New("消费上限不能为负数")
+		}
+		*f.dst = &v
+	}
+	seen := make(map[string]struct{}, len(in.AllowedModels))
+	for _, m := range in.AllowedModels {
+		m = strings.TrimSpace(m)
+		if m == "" {
+			continue
+		}
+		if len(m) > 128 {
+			return UserTokenLimits{}, fmt.Errorf("模型 ID 过长: %q", m)
+		}
+		key := strings.ToLower(m)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		out.AllowedModels = append(out.AllowedModels, m)
+	}
+	sort.Strings(out.AllowedModels)
+	return out, nil
+}
+
+// GetUserTokenLimits 返回 Token 的使用限制。
+func (s *Store) GetUserTokenLimits(ctx context.Context, tokenID int64) (UserTokenLimits, error) {
+	if tokenID == 0 {
+		return UserTokenLimits{}, errors.New("tokenID 不能为空")
+	}
+	var scan userTokenLimitsScan
+	if err := s.db.QueryRowContext(ctx, `SELECT `+userTokenLimitsColumns+` FROM user_tokens WHERE id=?`, tokenID).Scan(scan.dest()...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return UserTokenLimits{}, sql.ErrNoRows
+		}
+		return UserTokenLimits{}, fmt.Errorf("查询 Token 限制失败: %w", err)
+	}
+	return scan.limits()
+}
+
+// UpdateUserTokenLimits 覆盖写入 Token 的使用限制（nil/空字段表示取消对应限制）。
+func (s *Store) UpdateUserTokenLimits(ctx context.Context, userID, tokenID int64, limits UserTokenLimits) error {
+	if userID == 0 {
+		return errors.New("userID 不能为空")
+	}
+	if tokenID == 0 {
+		return errors.New("tokenID 不能为空")
+	}
+	limits, err := NormalizeUserTokenLimits(limits)
+	if err != nil {
+		return err
+	}
+	var allowed any
+	if len(limits.AllowedModels) > 0 {
+		b, err := json.Marshal(limits.AllowedModels)
+		if err != nil {
+			return fmt.Errorf("序列化模型白名单失败: %w", err)
+		}
+		allowed = string(b)
+	}
+	var expiresAt any
+	if limits.ExpiresAt != nil {
+		expiresAt = limits.ExpiresAt.UTC()
+	}
+	if _, err := s.GetUserTokenByID(ctx, userID, tokenID); err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `
+UPDATE user_tokens
+SET expires_at=?, budget_usd=?, budget_1d_usd=?, budget_7d_usd=?, budget_30d_usd=?, allowed_models=?, rpm_limit=?, tpm_limit=?
+WHERE id=? AND user_id=?
+`, expiresAt, optionalDecimalArg(limits.BudgetUSD), optionalDecimalArg(limits.Budget1dUSD), optionalDecimalArg(limits.Budget7dUSD), optionalDecimalArg(limits.Budget30dUSD), allowed, limits.RPMLimit, limits.TPMLimit, tokenID, userID)
+	if err != nil {
+		return fmt.Errorf("更新 Token 限制失败: %w", err)
+	}
+	return nil
+}
+
+// checkUserTokenBudgetsTx 在预留事务内锁定 Token 行并校验消费上限：
+// 已结算 + 未过期预留 + 本次预留超过上限（或已达上限）时返回 ErrUserTokenBudgetExceeded。
+// 同一 Token 的并发预留在行锁上串行，避免先查后写导致超额。
+func checkUserTokenBudgetsTx(ctx context.Context, tx *sql.Tx, dialect Dialect, tokenID int64, reservedUSD decimal.Decimal, now time.Time) error {
+	var scan userTokenLimitsScan
+	q := `SELECT ` + userTokenLimitsColumns + ` FROM user_tokens WHERE id=?` + forUpdateClause(dialect)
+	if err := tx.QueryRowContext(ctx, q, tokenID).Scan(scan.dest()...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return fmt.Errorf("查询 Token 限制失败: %w", err)
+	}
+	limits, err := scan.limits()
+	if err != nil {
+		return err
+	}
+	for _, b := range limits.BudgetWindows() {
+		since := time.Unix(0, 0).UTC()
+		if b.Window > 0 {
+			since = now.Add(-b.Window)
+		}
+		committed, reserved, err := scanCommittedAndReservedUSD(tx.QueryRowContext(ctx, sumCommittedAndReservedUSDRangeByTokenSQL,
+			UsageStateCommitted, UsageStateReserved, now, tokenID, since, now.Add(time.Minute), UsageStateCommitted, UsageStateReserved))
+		if err != nil {
+			return fmt.Errorf("汇总用量失败: %w", err)
+		}
+		used := committed.Add(reserved)
+		if used.GreaterThanOrEqual(b.BudgetUSD) || used.Add(reservedUSD).GreaterThan(b.BudgetUSD) {
+			return ErrUserTokenBudgetExceeded
+		}
+	}
+	return nil
+}
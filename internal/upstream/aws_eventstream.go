@@ -0,0 +1,221 @@
+package upstream
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// AWS event-stream（application/vnd.amazon.eventstream）二进制帧：
+//
+//	[total_len u32][headers_len u32][prelude_crc u32][headers...][payload...][message_crc u32]
+//
+// 其中 CRC 为 CRC32(IEEE)；headers 为 name_len(u8) name type(u8) value 的序列。
+const (
+	awsEventStreamPreludeLen = 12
+	awsEventStreamTrailerLen = 4
+	// awsEventStreamMaxMessageLen 为单帧上限（AWS 规范为 16MB），防止异常长度导致大内存分配。
+	awsEventStreamMaxMessageLen = 16 << 20
+)
+
+type awsEventStreamMessage struct {
+	Headers map[string]string
+	Payload []byte
+}
+
+// readAWSEventStreamMessage 从 r 读取一帧并校验 CRC；流结束时返回 io.EOF。
+func readAWSEventStreamMessage(r io.Reader) (awsEventStreamMessage, error) {
+	var prelude [awsEventStreamPreludeLen]byte
+	if _, err := io.ReadFull(r, prelude[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return awsEventStreamMessage{}, errors.New("event-stream 帧头不完整")
+		}
+		return awsEventStreamMessage{}, err
+	}
+	totalLen := binary.BigEndian.Uint32(prelude[0:4])
+	headersLen := binary.BigEndian.Uint32(prelude[4:8])
+	if crc32.ChecksumIEEE(prelude[0:8]) != binary.BigEndian.Uint32(prelude[8:12]) {
+		return awsEventStreamMessage{}, errors.New("event-stream prelude CRC 校验失败")
+	}
+	if totalLen < awsEventStreamPreludeLen+awsEventStreamTrailerLen || totalLen > awsEventStreamMaxMessageLen {
+		return awsEventStreamMessage{}, fmt.Errorf("event-stream 帧长度非法: %d", totalLen)
+	}
+	if uint64(headersLen) > uint64(totalLen)-awsEventStreamPreludeLen-awsEventStreamTrailerLen {
+		return awsEventStreamMessage{}, fmt.Errorf("event-stream headers 长度非法: %d", headersLen)
+	}
+
+	rest := make([]byte, totalLen-awsEventStreamPreludeLen)
+	if _, err := io.ReadFull(r, rest); err != nil {
+		return awsEventStreamMessage{}, errors.New("event-stream 帧体不完整")
+	}
+	bodyLen := len(rest) - awsEventStreamTrailerLen
+	crc := crc32.NewIEEE()
+	_, _ = crc.Write(prelude[:])
+	_, _ = crc.Write(rest[:bodyLen])
+	if crc.Sum32() != binary.BigEndian.Uint32(rest[bodyLen:]) {
+		return awsEventStreamMessage{}, errors.New("event-stream message CRC 校验失败")
+	}
+
+	headers, err := parseAWSEventStreamHeaders(rest[:headersLen])
+	if err != nil {
+		return awsEventStreamMessage{}, err
+	}
+	return awsEventStreamMessage{
+		Headers: headers,
+		Payload: rest[headersLen:bodyLen],
+	}, nil
+}
+
+// parseAWSEventStreamHeaders 解析帧头；仅保留 string 类型的值（Bedrock 只使用 string 头），其余类型跳过。
+func parseAWSEventStreamHeaders(b []byte) (map[string]string, error) {
+	out := make(map[string]string, 4)
+	errBad := errors.New("event-stream headers 格式非法")
+	for len(b) > 0 {
+		nameLen := int(b[0])
+		b = b[1:]
+		if len(b) < nameLen+1 {
+			return nil, errBad
+		}
+		name := string(b[:nameLen])
+		typ := b[nameLen]
+		b = b[nameLen+1:]
+
+		var skip int
+		switch typ {
+		case 0, 1: // bool true / false
+			skip = 0
+		case 2: // byte
+			skip = 1
+		case 3: // short
+			skip = 2
+		case 4: // int
+			skip = 4
+		case 5, 8: // long / timestamp
+			skip = 8
+		case 9: // uuid
+			skip = 16
+		case 6, 7: // bytes / string
+			if len(b) < 2 {
+				return nil, errBad
+			}
+			n := int(binary.BigEndian.Uint16(b[:2]))
+			if len(b) < 2+n {
+				return nil, errBad
+			}
+			if typ == 7 {
+				out[name] = string(b[2 : 2+n])
+			}
+			b = b[2+n:]
+			continue
+		default:
+			return nil, errBad
+		}
+		if len(b) < skip {
+			return nil, errBad
+		}
+		b = b[skip:]
+	}
+	return out, nil
+}
+
+// bedrockEventStreamSSEReader 把 Bedrock InvokeModelWithResponseStream 的 event-stream 转为 Anthropic SSE：
+// - chunk 事件：payload 为 {"bytes":"<base64 Anthropic 事件 JSON>"}，输出 `event: <type>` + `data: <json>`；
+// - exception / error 消息：输出 Anthropic 风格的 `event: error`，随后结束流。
+type bedrockEventStreamSSEReader struct {
+	src  io.ReadCloser
+	buf  bytes.Buffer
+	done bool
+}
+
+func newBedrockEventStreamSSEReader(src io.ReadCloser) io.ReadCloser {
+	return &bedrockEventStreamSSEReader{src: src}
+}
+
+func (r *bedrockEventStreamSSEReader) Read(p []byte) (int, error) {
+	for r.buf.Len() == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.next(); err != nil {
+			r.done = true
+			if !errors.Is(err, io.EOF) {
+				writeBedrockSSEError(&r.buf, "stream_error", err.Error())
+			}
+		}
+	}
+	return r.buf.Read(p)
+}
+
+func (r *bedrockEventStreamSSEReader) Close() error {
+	return r.src.Close()
+}
+
+func (r *bedrockEventStreamSSEReader) next() error {
+	msg, err := readAWSEventStreamMessage(r.src)
+	if err != nil {
+		return err
+	}
+	switch msg.Headers[":message-type"] {
+	case "event":
+		if msg.Headers[":event-type"] != "chunk" {
+			return nil
+		}
+		raw := gjson.GetBytes(msg.Payload, "bytes").String()
+		if raw == "" {
+			return nil
+		}
+		data, err := base64.StdEncoding.DecodeString(raw)
+		if err != nil {
+			return errors.New("event-stream chunk 解码失败")
+		}
+		typ := gjson.GetBytes(data, "type").String()
+		if typ != "" {
+			r.buf.WriteString("event: ")
+			r.buf.WriteString(typ)
+			r.buf.WriteByte('\n')
+		}
+		r.buf.WriteString("data: ")
+		r.buf.Write(bytes.TrimSpace(data))
+		r.buf.WriteString("\n\n")
+		return nil
+	case "exception", "error":
+		typ := msg.Headers[":exception-type"]
+		if typ == "" {
+			typ = msg.Headers[":error-code"]
+		}
+		message := gjson.GetBytes(msg.Payload, "message").String()
+		if message == "" {
+			message = msg.Headers[":error-message"]
+		}
+		writeBedrockSSEError(&r.buf, typ, message)
+		r.done = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+func writeBedrockSSEError(buf *bytes.Buffer, typ string, message string) {
+	typ = strings.TrimSpace(typ)
+	if typ == "" {
+		typ = "api_error"
+	}
+	b, _ := json.Marshal(map[string]any{
+		"type": "error",
+		"error": map[string]any{
+			"type":    typ,
+			"message": message,
+		},
+	})
+	buf.WriteString("event: error\ndata: ")
+	buf.Write(b)
+	buf.WriteString("\n\n")
+}
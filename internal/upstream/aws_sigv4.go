@@ -0,0 +1,167 @@
+package upstream
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	awsSigV4Algorithm  = "AWS4-HMAC-SHA256"
+	awsSigV4TimeFormat = "20060102T150405Z"
+	awsSigV4DateFormat = "20060102"
+)
+
+// awsCredentials 为 SigV4 签名所需的 AWS 凭证。
+type awsCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// signAWSRequestV4 对请求做 AWS Signature Version 4 签名（写入 X-Amz-Date / X-Amz-Security-Token / Authorization）。
+//
+// 约定：
+// - 参与签名的头为 host、content-type 与全部 x-amz-*（其余头可能被代理改写，不参与签名）；
+// - 非 S3 服务的 canonical URI 需对已编码路径再做一次 URI 编码（AWS 规范）；
+// - body 必须与实际发送的字节完全一致。
+func signAWSRequestV4(req *http.Request, body []byte, creds awsCredentials, region string, service string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format(awsSigV4TimeFormat)
+	dateStamp := now.Format(awsSigV4DateFormat)
+
+	req.Header.Del("Authorization")
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	} else {
+		req.Header.Del("X-Amz-Security-Token")
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	canonicalHeaders, signedHeaders := awsCanonicalHeaders(req.Header, host)
+
+	payloadHash := sha256Hex(body)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		awsCanonicalURI(req.URL),
+		awsCanonicalQuery(req.URL),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := dateStamp + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := strings.Join([]string{
+		awsSigV4Algorithm,
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	kDate := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), dateStamp)
+	kRegion := hmacSHA256(kDate, region)
+	kService := hmacSHA256(kRegion, service)
+	kSigning := hmacSHA256(kService, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(kSigning, stringToSign))
+
+	req.Header.Set("Authorization", awsSigV4Algorithm+" Credential="+creds.AccessKeyID+"/"+scope+", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func awsCanonicalHeaders(h http.Header, host string) (string, string) {
+	values := map[string]string{"host": strings.TrimSpace(host)}
+	for k, vs := range h {
+		lk := strings.ToLower(k)
+		if lk != "content-type" && !strings.HasPrefix(lk, "x-amz-") {
+			continue
+		}
+		trimmed := make([]string, 0, len(vs))
+		for _, v := range vs {
+			trimmed = append(trimmed, strings.Join(strings.Fields(v), " "))
+		}
+		values[lk] = strings.Join(trimmed, ",")
+	}
+	names := make([]string, 0, len(values))
+	for k := range values {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, k := range names {
+		b.WriteString(k)
+		b.WriteByte(':')
+		b.WriteString(values[k])
+		b.WriteByte('\n')
+	}
+	return b.String(), strings.Join(names, ";")
+}
+
+func awsCanonicalURI(u *url.URL) string {
+	p := u.EscapedPath()
+	if p == "" {
+		return "/"
+	}
+	segs := strings.Split(p, "/")
+	for i, seg := range segs {
+		segs[i] = awsURIEncode(seg)
+	}
+	return strings.Join(segs, "/")
+}
+
+func awsCanonicalQuery(u *url.URL) string {
+	if u.RawQuery == "" {
+		return ""
+	}
+	q := u.Query()
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		vs := append([]string(nil), q[k]...)
+		sort.Strings(vs)
+		for _, v := range vs {
+			parts = append(parts, awsURIEncode(k)+"="+awsURIEncode(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// awsURIEncode 按 SigV4 规则编码：仅保留 A-Z a-z 0-9 - _ . ~，其余字节一律 %XX（大写）。
+func awsURIEncode(s string) string {
+	const hexUpper = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hexUpper[c>>4])
+		b.WriteByte(hexUpper[c&0x0f])
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	m := hmac.New(sha256.New, key)
+	_, _ = m.Write([]byte(data))
+	return m.Sum(nil)
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
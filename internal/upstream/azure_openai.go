@@ -0,0 +1,76 @@
+package upstream
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// defaultAzureOpenAIAPIVersion 为 endpoint 未配置 api_version 时使用的 Azure OpenAI 数据面版本。
+const defaultAzureOpenAIAPIVersion = "2024-10-21"
+
+// azureOpenAIV1APIVersion 表示使用 Azure OpenAI v1 GA 接口（/openai/v1/*，无需 api-version 查询参数）。
+const azureOpenAIV1APIVersion = "v1"
+
+// azureOpenAIUpstreamPath 将下游 OpenAI 路径映射为 Azure OpenAI 路径。
+//
+// 约定：
+// - chat/completions 与 embeddings 走 deployment 路由，deployment 名取请求体 model（即渠道绑定的上游模型）；
+// - responses 不区分 deployment，走 /openai/responses；
+// - api_version=v1 时统一走 /openai/v1/*（model 保留在请求体中）。
+func azureOpenAIUpstreamPath(targetPath string, body []byte, apiVersion string) (string, error) {
+	if strings.TrimSpace(apiVersion) == azureOpenAIV1APIVersion {
+		switch {
+		case targetPath == "/v1/chat/completions", targetPath == "/v1/embeddings", isResponsesPath(targetPath):
+			return "/openai" + targetPath, nil
+		default:
+			return "", errors.New("azure_openai 上游仅支持 chat/completions、responses 与 embeddings")
+		}
+	}
+
+	switch {
+	case targetPath == "/v1/chat/completions", targetPath == "/v1/embeddings":
+		deployment := azureOpenAIDeployment(body)
+		if deployment == "" {
+			return "", errors.New("azure_openai 请求缺少 model（deployment）")
+		}
+		return "/openai/deployments/" + url.PathEscape(deployment) + strings.TrimPrefix(targetPath, "/v1"), nil
+	case isResponsesPath(targetPath):
+		return "/openai" + strings.TrimPrefix(targetPath, "/v1"), nil
+	default:
+		return "", errors.New("azure_openai 上游仅支持 chat/completions、responses 与 embeddings")
+	}
+}
+
+// applyAzureOpenAIAPIVersion 设置 api-version 查询参数（v1 接口不需要）。
+func applyAzureOpenAIAPIVersion(u *url.URL, apiVersion string) {
+	apiVersion = strings.TrimSpace(apiVersion)
+	q := u.Query()
+	if apiVersion == azureOpenAIV1APIVersion {
+		q.Del("api-version")
+	} else {
+		if apiVersion == "" {
+			apiVersion = defaultAzureOpenAIAPIVersion
+		}
+		q.Set("api-version", apiVersion)
+	}
+	u.RawQuery = q.Encode()
+}
+
+func azureOpenAIDeployment(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	var payload struct {
+		Model string `json:"model"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(payload.Model)
+}
+
+func isResponsesPath(p string) bool {
+	return p == "/v1/responses" || strings.HasPrefix(p, "/v1/responses/")
+}
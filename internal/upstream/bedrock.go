@@ -0,0 +1,113 @@
+package upstream
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// bedrockAnthropicVersion 为 Bedrock 上 Anthropic Messages 请求体要求的 anthropic_version。
+	bedrockAnthropicVersion = "bedrock-2023-05-31"
+	bedrockSigningService   = "bedrock"
+	bedrockEventStreamType  = "application/vnd.amazon.eventstream"
+	// bedrockMaxErrorBodyBytes 限制改写错误响应时读取的上游 body 大小。
+	bedrockMaxErrorBodyBytes = 64 << 10
+)
+
+// buildBedrockInvokeRequest 将 Anthropic Messages 请求体改写为 Bedrock InvokeModel 请求：
+// - model 移至路径（/model/{modelId}/invoke 或 /invoke-with-response-stream），body 中删除 model/stream；
+// - 补齐 anthropic_version，并把下游 anthropic-beta 头转为 body.anthropic_beta。
+//
+// 返回 (upstreamRawPath, upstreamPath, body, stream)，其中 upstreamRawPath 为已按 AWS 规则编码的路径。
+func buildBedrockInvokeRequest(body []byte, anthropicBeta string) (string, string, []byte, bool, error) {
+	var payload map[string]any
+	if err := json.Unmarshal(body, &payload); err != nil || payload == nil {
+		return "", "", nil, false, errors.New("bedrock 请求体不是有效 JSON")
+	}
+	modelID, _ := payload["model"].(string)
+	modelID = strings.TrimSpace(modelID)
+	if modelID == "" {
+		return "", "", nil, false, errors.New("bedrock 请求缺少 model（modelId）")
+	}
+	stream, _ := payload["stream"].(bool)
+	delete(payload, "model")
+	delete(payload, "stream")
+	if v, ok := payload["anthropic_version"].(string); !ok || strings.TrimSpace(v) == "" {
+		payload["anthropic_version"] = bedrockAnthropicVersion
+	}
+	if _, ok := payload["anthropic_beta"]; !ok {
+		var betas []string
+		for _, part := range strings.Split(anthropicBeta, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				betas = append(betas, part)
+			}
+		}
+		if len(betas) > 0 {
+			payload["anthropic_beta"] = betas
+		}
+	}
+	out, err := json.Marshal(payload)
+	if err != nil {
+		return "", "", nil, false, fmt.Errorf("bedrock 请求体序列化失败: %w", err)
+	}
+
+	action := "invoke"
+	if stream {
+		action = "invoke-with-response-stream"
+	}
+	return "/model/" + awsURIEncode(modelID) + "/" + action, "/model/" + modelID + "/" + action, out, stream, nil
+}
+
+// adaptBedrockResponse 把 Bedrock 响应适配为 Anthropic 形态：
+// - 成功的 event-stream 转为 SSE（text/event-stream）；
+// - 错误响应（{"message":...} + x-amzn-ErrorType）改写为 Anthropic error JSON。
+func adaptBedrockResponse(resp *http.Response) *http.Response {
+	if resp == nil || resp.Body == nil {
+		return resp
+	}
+	ct := strings.ToLower(resp.Header.Get("Content-Type"))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		if strings.HasPrefix(ct, bedrockEventStreamType) {
+			resp.Body = newBedrockEventStreamSSEReader(resp.Body)
+			resp.Header.Set("Content-Type", "text/event-stream")
+			resp.Header.Del("Content-Length")
+			resp.ContentLength = -1
+		}
+		return resp
+	}
+
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, bedrockMaxErrorBodyBytes))
+	_ = resp.Body.Close()
+	message := gjson.GetBytes(raw, "message").String()
+	if message == "" {
+		message = gjson.GetBytes(raw, "Message").String()
+	}
+	if message == "" {
+		message = strings.TrimSpace(string(raw))
+	}
+	typ := strings.TrimSpace(resp.Header.Get("X-Amzn-Errortype"))
+	if i := strings.IndexByte(typ, ':'); i >= 0 {
+		typ = typ[:i]
+	}
+	if typ == "" {
+		typ = "api_error"
+	}
+	out, _ := json.Marshal(map[string]any{
+		"type": "error",
+		"error": map[string]any{
+			"type":    typ,
+			"message": message,
+		},
+	})
+	resp.Body = io.NopCloser(strings.NewReader(string(out)))
+	resp.Header.Set("Content-Type", "application/json")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = int64(len(out))
+	return resp
+}
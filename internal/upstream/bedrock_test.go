@@ -0,0 +1,261 @@
+package upstream
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"realms/internal/scheduler"
+	"realms/internal/store"
+)
+
+func TestSignAWSRequestV4_GetVanillaVector(t *testing.T) {
+	// AWS SigV4 test suite: get-vanilla。
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	signAWSRequestV4(req, nil, awsCredentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}, "us-east-1", "service", now)
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Fatalf("unexpected Authorization:\n got=%s\nwant=%s", got, want)
+	}
+}
+
+func encodeAWSEventStreamMessage(headers map[string]string, payload []byte) []byte {
+	var hb bytes.Buffer
+	for k, v := range headers {
+		hb.WriteByte(byte(len(k)))
+		hb.WriteString(k)
+		hb.WriteByte(7)
+		_ = binary.Write(&hb, binary.BigEndian, uint16(len(v)))
+		hb.WriteString(v)
+	}
+	total := uint32(awsEventStreamPreludeLen + hb.Len() + len(payload) + awsEventStreamTrailerLen)
+	var out bytes.Buffer
+	_ = binary.Write(&out, binary.BigEndian, total)
+	_ = binary.Write(&out, binary.BigEndian, uint32(hb.Len()))
+	_ = binary.Write(&out, binary.BigEndian, crc32.ChecksumIEEE(out.Bytes()))
+	out.Write(hb.Bytes())
+	out.Write(payload)
+	_ = binary.Write(&out, binary.BigEndian, crc32.ChecksumIEEE(out.Bytes()))
+	return out.Bytes()
+}
+
+func bedrockChunk(event string) []byte {
+	payload, _ := json.Marshal(map[string]string{"bytes": base64.StdEncoding.EncodeToString([]byte(event))})
+	return encodeAWSEventStreamMessage(map[string]string{
+		":message-type": "event",
+		":event-type":   "chunk",
+		":content-type": "application/json",
+	}, payload)
+}
+
+func TestBedrockEventStreamSSEReader_ConvertsChunksAndExceptions(t *testing.T) {
+	var stream bytes.Buffer
+	stream.Write(bedrockChunk(`{"type":"message_start","message":{"usage":{"input_tokens":7,"output_tokens":1}}}`))
+	stream.Write(bedrockChunk(`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"hi"}}`))
+	stream.Write(encodeAWSEventStreamMessage(map[string]string{
+		":message-type":   "exception",
+		":exception-type": "throttlingException",
+	}, []byte(`{"message":"Too many requests"}`)))
+
+	out, err := io.ReadAll(newBedrockEventStreamSSEReader(io.NopCloser(&stream)))
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	got := string(out)
+	if !strings.Contains(got, "event: message_start\ndata: {\"type\":\"message_start\"") {
+		t.Fatalf("expected message_start SSE event, got=%s", got)
+	}
+	if !strings.Contains(got, "event: content_block_delta\n") {
+		t.Fatalf("expected content_block_delta SSE event, got=%s", got)
+	}
+	if !strings.Contains(got, `event: error`) || !strings.Contains(got, `"type":"throttlingException"`) || !strings.Contains(got, "Too many requests") {
+		t.Fatalf("expected exception converted to error event, got=%s", got)
+	}
+}
+
+func TestBedrockEventStreamSSEReader_RejectsCorruptedFrame(t *testing.T) {
+	frame := bedrockChunk(`{"type":"message_stop"}`)
+	frame[len(frame)-1] ^= 0xff
+
+	out, err := io.ReadAll(newBedrockEventStreamSSEReader(io.NopCloser(bytes.NewReader(frame))))
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if !strings.Contains(string(out), "event: error") || !strings.Contains(string(out), "CRC") {
+		t.Fatalf("expected CRC error event, got=%s", out)
+	}
+}
+
+// bedrockStandIn 模拟 Bedrock Runtime：校验 SigV4 签名后返回 InvokeModel / InvokeModelWithResponseStream 响应。
+func bedrockStandIn(t *testing.T, creds awsCredentials, region string, gotPath *string, gotBody *map[string]any) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		// 以服务端视角重算签名：同一 secret、同一 X-Amz-Date 下 Authorization 必须一致。
+		amzDate, err := time.Parse(awsSigV4TimeFormat, r.Header.Get("X-Amz-Date"))
+		if err != nil {
+			http.Error(w, `{"message":"missing x-amz-date"}`, http.StatusForbidden)
+			return
+		}
+		check, _ := http.NewRequest(r.Method, "http://"+r.Host+r.URL.RequestURI(), bytes.NewReader(body))
+		for k, vs := range r.Header {
+			if strings.EqualFold(k, "Authorization") {
+				continue
+			}
+			check.Header[k] = vs
+		}
+		signAWSRequestV4(check, body, creds, region, bedrockSigningService, amzDate)
+		if check.Header.Get("Authorization") != r.Header.Get("Authorization") {
+			w.Header().Set("X-Amzn-Errortype", "InvalidSignatureException:http://internal.amazon.com/coral/com.amazon.coral.service/")
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"message":"The request signature we calculated does not match the signature you provided."}`))
+			return
+		}
+
+		*gotPath = r.URL.EscapedPath()
+		_ = json.Unmarshal(body, gotBody)
+		if strings.HasSuffix(r.URL.Path, "/invoke-with-response-stream") {
+			w.Header().Set("Content-Type", bedrockEventStreamType)
+			_, _ = w.Write(bedrockChunk(`{"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","usage":{"input_tokens":11,"output_tokens":1}}}`))
+			_, _ = w.Write(bedrockChunk(`{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":4}}`))
+			_, _ = w.Write(bedrockChunk(`{"type":"message_stop","amazon-bedrock-invocationMetrics":{"inputTokenCount":11,"outputTokenCount":4}}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"text","text":"pong"}],"usage":{"input_tokens":11,"output_tokens":4}}`))
+	}))
+}
+
+func TestExecutor_Do_BedrockSignsAndConvertsEventStream(t *testing.T) {
+	creds := awsCredentials{AccessKeyID: "AKIDLOCAL", SecretAccessKey: "local-secret", SessionToken: "local-session"}
+	var gotPath string
+	var gotBody map[string]any
+	srv := bedrockStandIn(t, creds, "us-west-2", &gotPath, &gotBody)
+	defer srv.Close()
+
+	exec := &Executor{
+		st: &fakeUpstreamStore{
+			bedrockSecret: store.BedrockCredentialSecret{
+				AccessKeyID:     creds.AccessKeyID,
+				SecretAccessKey: creds.SecretAccessKey,
+				SessionToken:    creds.SessionToken,
+				Region:          "us-west-2",
+			},
+		},
+		client:          srv.Client(),
+		upstreamTimeout: 2 * time.Minute,
+	}
+	sel := scheduler.Selection{
+		ChannelType:    store.UpstreamTypeBedrock,
+		BaseURL:        srv.URL,
+		CredentialType: scheduler.CredentialTypeBedrock,
+		CredentialID:   1,
+	}
+
+	body := []byte(`{"model":"anthropic.claude-3-5-sonnet-20240620-v1:0","max_tokens":64,"stream":true,"messages":[{"role":"user","content":"ping"}]}`)
+	r := httptest.NewRequest(http.MethodPost, "http://example.com/v1/messages", bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("x-api-key", "rlm_downstream")
+	r.Header.Set("anthropic-version", "2023-06-01")
+	r.Header.Set("anthropic-beta", "prompt-caching-2024-07-31")
+
+	resp, err := exec.Do(context.Background(), sel, r, body)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	defer resp.Body.Close()
+	out, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status=%d body=%s", resp.StatusCode, out)
+	}
+	if gotPath != "/model/anthropic.claude-3-5-sonnet-20240620-v1%3A0/invoke-with-response-stream" {
+		t.Fatalf("unexpected upstream path: %q", gotPath)
+	}
+	if _, ok := gotBody["model"]; ok {
+		t.Fatalf("expected model to be moved to path, body=%v", gotBody)
+	}
+	if _, ok := gotBody["stream"]; ok {
+		t.Fatalf("expected stream to be removed, body=%v", gotBody)
+	}
+	if gotBody["anthropic_version"] != bedrockAnthropicVersion {
+		t.Fatalf("unexpected anthropic_version: %v", gotBody["anthropic_version"])
+	}
+	if betas, _ := gotBody["anthropic_beta"].([]any); len(betas) != 1 || betas[0] != "prompt-caching-2024-07-31" {
+		t.Fatalf("unexpected anthropic_beta: %v", gotBody["anthropic_beta"])
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("expected SSE content-type, got %q", ct)
+	}
+	if !strings.Contains(string(out), "event: message_delta\n") || !strings.Contains(string(out), `"outputTokenCount":4`) {
+		t.Fatalf("unexpected converted stream: %s", out)
+	}
+
+	nonStream := []byte(`{"model":"anthropic.claude-3-5-sonnet-20240620-v1:0","max_tokens":64,"messages":[{"role":"user","content":"ping"}]}`)
+	r2 := httptest.NewRequest(http.MethodPost, "http://example.com/v1/messages", bytes.NewReader(nonStream))
+	resp2, err := exec.Do(context.Background(), sel, r2, nonStream)
+	if err != nil {
+		t.Fatalf("Do non-stream: %v", err)
+	}
+	defer resp2.Body.Close()
+	out2, _ := io.ReadAll(resp2.Body)
+	if resp2.StatusCode != http.StatusOK || !strings.Contains(string(out2), `"input_tokens":11`) {
+		t.Fatalf("unexpected non-stream response: status=%d body=%s", resp2.StatusCode, out2)
+	}
+	if !strings.HasSuffix(gotPath, "/invoke") {
+		t.Fatalf("expected invoke path, got %q", gotPath)
+	}
+}
+
+func TestExecutor_Do_BedrockSignatureMismatchBecomesAnthropicError(t *testing.T) {
+	var gotPath string
+	var gotBody map[string]any
+	srv := bedrockStandIn(t, awsCredentials{AccessKeyID: "AKIDLOCAL", SecretAccessKey: "server-secret"}, "us-east-1", &gotPath, &gotBody)
+	defer srv.Close()
+
+	exec := &Executor{
+		st: &fakeUpstreamStore{
+			bedrockSecret: store.BedrockCredentialSecret{AccessKeyID: "AKIDLOCAL", SecretAccessKey: "wrong-secret", Region: "us-east-1"},
+		},
+		client:          srv.Client(),
+		upstreamTimeout: 2 * time.Minute,
+	}
+	sel := scheduler.Selection{BaseURL: srv.URL, CredentialType: scheduler.CredentialTypeBedrock, CredentialID: 1}
+	body := []byte(`{"model":"anthropic.claude-3-haiku-20240307-v1:0","max_tokens":8,"messages":[{"role":"user","content":"ping"}]}`)
+	r := httptest.NewRequest(http.MethodPost, "http://example.com/v1/messages", bytes.NewReader(body))
+
+	resp, err := exec.Do(context.Background(), sel, r, body)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	defer resp.Body.Close()
+	out, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403, got=%d body=%s", resp.StatusCode, out)
+	}
+	if !strings.Contains(string(out), `"type":"InvalidSignatureException"`) || !strings.Contains(string(out), `"type":"error"`) {
+		t.Fatalf("expected anthropic error shape, got=%s", out)
+	}
+
+	r2 := httptest.NewRequest(http.MethodPost, "http://example.com/v1/chat/completions", bytes.NewReader(body))
+	if _, err := exec.buildRequest(context.Background(), sel, r2, body); err == nil {
+		t.Fatalf("expected bedrock credential to reject non-messages path")
+	}
+}
@@ -40,11 +40,17 @@ type Executor struct {
 
 	quotaMu          sync.Mutex
 	lastQuotaUpdated map[int64]time.Time
+
+	vertexTokens vertexTokenCache
 }
 
 type upstreamStore interface {
 	GetOpenAICompatibleCredentialSecret(ctx context.Context, credentialID int64) (store.OpenAICredentialSecret, error)
 	GetAnthropicCredentialSecret(ctx context.Context, credentialID int64) (store.AnthropicCredentialSecret, error)
+	GetGeminiCredentialSecret(ctx context.Context, credentialID int64) (store.GeminiCredentialSecret, error)
+	GetAzureOpenAICredentialSecret(ctx context.Context, credentialID int64) (store.AzureOpenAICredentialSecret, error)
+	GetBedrockCredentialSecret(ctx context.Context, credentialID int64) (store.BedrockCredentialSecret, error)
+	GetVertexCredentialSecret(ctx context.Context, credentialID int64) (store.VertexCredentialSecret, error)
 	GetCodexOAuthSecret(ctx context.Context, accountID int64) (store.CodexOAuthSecret, error)
 	UpdateCodexOAuthAccountTokens(ctx context.Context, accountID int64, accessToken, refreshToken string, idToken *string, expiresAt *time.Time) error
 	SetCodexOAuthAccountStatus(ctx context.Context, accountID int64, status int) error
@@ -202,6 +208,12 @@ func (e *Executor) Do(ctx context.Context, sel scheduler.Selection, downstream *
 			}
 		}
 	}
+	if sel.CredentialType == scheduler.CredentialTypeBedrock {
+		resp = adaptBedrockResponse(resp)
+	}
+	if sel.CredentialType == scheduler.CredentialTypeVertex && downstream.URL.Path == "/v1/messages" {
+		resp = adaptVertexMessagesResponse(resp)
+	}
 	if cancel != nil && resp != nil && resp.Body != nil {
 		resp.Body = cancelOnClose(resp.Body, cancel)
 	}
@@ -573,6 +585,9 @@ func isStreamRequest(downstream *http.Request, body []byte) bool {
 		return false
 	}
 	path := downstream.URL.Path
+	if strings.HasPrefix(path, "/v1beta/models/") {
+		return strings.HasSuffix(path, ":streamGenerateContent")
+	}
 	switch {
 	case path == "/v1/responses", path == "/v1/messages":
 	case strings.HasPrefix(path, "/v1/responses/"):
@@ -640,8 +655,24 @@ func (e *Executor) buildRequest(ctx context.Context, sel scheduler.Selection, do
 	case scheduler.CredentialTypeOpenAI:
 		// 直接透传 /v1/*。
 	case scheduler.CredentialTypeAnthropic:
+		if targetPath != "/v1/messages" && targetPath != "/v1/messages/count_tokens" {
+			return nil, errors.New("anthropic 上游仅支持 /v1/messages 与 /v1/messages/count_tokens")
+		}
+	case scheduler.CredentialTypeGemini:
+		if !strings.HasPrefix(targetPath, "/v1beta/models/") {
+			return nil, errors.New("gemini 上游仅支持 /v1beta/models/*")
+		}
+	case scheduler.CredentialTypeAzure:
+		if _, err := azureOpenAIUpstreamPath(targetPath, body, sel.APIVersion); err != nil {
+			return nil, err
+		}
+	case scheduler.CredentialTypeBedrock:
 		if targetPath != "/v1/messages" {
-			return nil, errors.New("anthropic 上游仅支持 /v1/messages")
+			return nil, errors.New("bedrock 上游仅支持 /v1/messages")
+		}
+	case scheduler.CredentialTypeVertex:
+		if targetPath != "/v1/messages" && !strings.HasPrefix(targetPath, "/v1beta/models/") {
+			return nil, errors.New("vertex 上游仅支持 /v1/messages 与 /v1beta/models/*")
 		}
 	case scheduler.CredentialTypeCodex:
 		if targetPath != "/v1/responses" {
@@ -653,6 +684,7 @@ func (e *Executor) buildRequest(ctx context.Context, sel scheduler.Selection, do
 
 	var codexPromptCacheKey string
 	codexIsCLI := false
+	bedrockStream := false
 	if sel.CredentialType == scheduler.CredentialTypeCodex && len(body) > 0 {
 		codexIsCLI = isCodexCLIUserAgent(downstream.Header.Get("User-Agent"))
 		var reqBody map[string]any
@@ -674,6 +706,12 @@ func (e *Executor) buildRequest(ctx context.Context, sel scheduler.Selection, do
 	if sel.CredentialType == scheduler.CredentialTypeCodex && targetPath == "/v1/responses" {
 		upstreamPath = "/responses"
 	}
+	if sel.CredentialType == scheduler.CredentialTypeAzure {
+		upstreamPath, err = azureOpenAIUpstreamPath(targetPath, body, sel.APIVersion)
+		if err != nil {
+			return nil, err
+		}
+	}
 	joined, err := url.JoinPath(base.String(), upstreamPath)
 	if err != nil {
 		return nil, fmt.Errorf("拼接上游 URL 失败: %w", err)
@@ -684,6 +722,50 @@ func (e *Executor) buildRequest(ctx context.Context, sel scheduler.Selection, do
 	}
 	u = *uu
 	u.RawQuery = downstream.URL.RawQuery
+	if strings.HasPrefix(targetPath, "/v1beta/") && u.RawQuery != "" {
+		// 下游可能以 ?key= 携带 Realms Token，禁止透传到上游。
+		q := u.Query()
+		if _, ok := q["key"]; ok {
+			q.Del("key")
+			u.RawQuery = q.Encode()
+		}
+	}
+	if sel.CredentialType == scheduler.CredentialTypeAzure {
+		applyAzureOpenAIAPIVersion(&u, sel.APIVersion)
+	}
+	if sel.CredentialType == scheduler.CredentialTypeBedrock {
+		rawPath, invokePath, invokeBody, stream, err := buildBedrockInvokeRequest(body, downstream.Header.Get("anthropic-beta"))
+		if err != nil {
+			return nil, err
+		}
+		body = invokeBody
+		u = *base
+		u.Path = strings.TrimRight(base.Path, "/") + invokePath
+		u.RawPath = strings.TrimRight(base.EscapedPath(), "/") + rawPath
+		u.RawQuery = ""
+		bedrockStream = stream
+	}
+	var vertexSec store.VertexCredentialSecret
+	if sel.CredentialType == scheduler.CredentialTypeVertex {
+		vertexSec, err = e.st.GetVertexCredentialSecret(ctx, sel.CredentialID)
+		if err != nil {
+			return nil, err
+		}
+		vertexPath, vertexBody, err := buildVertexRequest(targetPath, body, vertexSec.ProjectID, vertexLocation(vertexSec.Location, base))
+		if err != nil {
+			return nil, err
+		}
+		body = vertexBody
+		query := u.RawQuery
+		u = *base
+		u.Path = strings.TrimRight(base.Path, "/") + vertexPath
+		u.RawPath = ""
+		u.RawQuery = ""
+		if strings.HasPrefix(targetPath, "/v1beta/") {
+			// 保留 alt=sse 等 Gemini 查询参数（key 已在上方移除）。
+			u.RawQuery = query
+		}
+	}
 	if sel.CredentialType != scheduler.CredentialTypeCodex && u.RawQuery != "" && (targetPath == "/v1/responses" || targetPath == "/v1/messages") {
 		q := u.Query()
 		changed := false
@@ -712,8 +794,32 @@ func (e *Executor) buildRequest(ctx context.Context, sel scheduler.Selection, do
 		req.Header.Del("Authorization")
 		req.Header.Del("X-Api-Key")
 		req.Header.Del("x-api-key")
+		req.Header.Del("X-Goog-Api-Key")
+		req.Header.Del("Api-Key")
 		req.Header.Del("Accept-Encoding")
 	}
+	if sel.CredentialType == scheduler.CredentialTypeBedrock {
+		// Bedrock 以 body.anthropic_version / anthropic_beta 表达协议版本；下游的 AWS 签名头一律丢弃。
+		req.Header.Del("anthropic-version")
+		req.Header.Del("anthropic-beta")
+		for k := range req.Header {
+			if strings.HasPrefix(strings.ToLower(k), "x-amz-") {
+				req.Header.Del(k)
+			}
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if bedrockStream {
+			req.Header.Set("Accept", bedrockEventStreamType)
+		} else {
+			req.Header.Set("Accept", "application/json")
+		}
+	}
+
+	if sel.CredentialType == scheduler.CredentialTypeVertex {
+		// Vertex 以 body.anthropic_version 表达协议版本，且仅接受 OAuth Bearer 鉴权。
+		req.Header.Del("anthropic-version")
+		req.Header.Set("Content-Type", "application/json")
+	}
 
 	if sel.CredentialType == scheduler.CredentialTypeOpenAI && sel.OpenAIOrganization != nil && strings.TrimSpace(*sel.OpenAIOrganization) != "" {
 		req.Header.Set("OpenAI-Organization", strings.TrimSpace(*sel.OpenAIOrganization))
@@ -744,6 +850,50 @@ func (e *Executor) buildRequest(ctx context.Context, sel scheduler.Selection, do
 		}
 		applyAnthropicBetaHeader(req.Header, sel.CacheTTLPreference)
 		req.Header.Set("x-api-key", sec.APIKey)
+	case scheduler.CredentialTypeGemini:
+		sec, err := e.st.GetGeminiCredentialSecret(ctx, sel.CredentialID)
+		if err != nil {
+			return nil, err
+		}
+		if err := applyHeaderOverride(req.Header, sel.HeaderOverride, sec.APIKey); err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept-Encoding", "identity")
+		req.Header.Set("x-goog-api-key", sec.APIKey)
+	case scheduler.CredentialTypeAzure:
+		sec, err := e.st.GetAzureOpenAICredentialSecret(ctx, sel.CredentialID)
+		if err != nil {
+			return nil, err
+		}
+		if err := applyHeaderOverride(req.Header, sel.HeaderOverride, sec.APIKey); err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept-Encoding", "identity")
+		req.Header.Set("api-key", sec.APIKey)
+	case scheduler.CredentialTypeBedrock:
+		sec, err := e.st.GetBedrockCredentialSecret(ctx, sel.CredentialID)
+		if err != nil {
+			return nil, err
+		}
+		if err := applyHeaderOverride(req.Header, sel.HeaderOverride, sec.AccessKeyID); err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept-Encoding", "identity")
+		signAWSRequestV4(req, body, awsCredentials{
+			AccessKeyID:     sec.AccessKeyID,
+			SecretAccessKey: sec.SecretAccessKey,
+			SessionToken:    sec.SessionToken,
+		}, sec.Region, bedrockSigningService, time.Now())
+	case scheduler.CredentialTypeVertex:
+		accessToken, err := e.vertexAccessToken(ctx, e.clientForSelection(sel), vertexSec.ID, vertexSec.ServiceAccountJSON, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		if err := applyHeaderOverride(req.Header, sel.HeaderOverride, accessToken); err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept-Encoding", "identity")
+		req.Header.Set("Authorization", "Bearer "+accessToken)
 	case scheduler.CredentialTypeCodex:
 		sec, err := e.st.GetCodexOAuthSecret(ctx, sel.CredentialID)
 		if err != nil {
@@ -826,6 +976,9 @@ func applyHeaderOverride(headers http.Header, headerOverride string, apiKey stri
 
 func normalizeUpstreamPath(base *url.URL, targetPath string) string {
 	basePath := strings.TrimRight(base.Path, "/")
+	if strings.HasSuffix(basePath, "/v1beta") && strings.HasPrefix(targetPath, "/v1beta/") {
+		return strings.TrimPrefix(targetPath, "/v1beta")
+	}
 	if strings.HasSuffix(basePath, "/v1") && strings.HasPrefix(targetPath, "/v1/") {
 		out := strings.TrimPrefix(targetPath, "/v1")
 		if out == "" {
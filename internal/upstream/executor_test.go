@@ -100,6 +100,10 @@ type fakeUpstreamStore struct {
 	codexSecret     store.CodexOAuthSecret
 	openaiSecret    store.OpenAICredentialSecret
 	anthropicSecret store.AnthropicCredentialSecret
+	geminiSecret    store.GeminiCredentialSecret
+	azureSecret     store.AzureOpenAICredentialSecret
+	bedrockSecret   store.BedrockCredentialSecret
+	vertexSecret    store.VertexCredentialSecret
 	channel         store.UpstreamChannel
 
 	updateTokensCalls int
@@ -121,6 +125,30 @@ func (f *fakeUpstreamStore) GetAnthropicCredentialSecret(_ context.Context, cred
 	return sec, nil
 }
 
+func (f *fakeUpstreamStore) GetGeminiCredentialSecret(_ context.Context, credentialID int64) (store.GeminiCredentialSecret, error) {
+	sec := f.geminiSecret
+	sec.ID = credentialID
+	return sec, nil
+}
+
+func (f *fakeUpstreamStore) GetAzureOpenAICredentialSecret(_ context.Context, credentialID int64) (store.AzureOpenAICredentialSecret, error) {
+	sec := f.azureSecret
+	sec.ID = credentialID
+	return sec, nil
+}
+
+func (f *fakeUpstreamStore) GetBedrockCredentialSecret(_ context.Context, credentialID int64) (store.BedrockCredentialSecret, error) {
+	sec := f.bedrockSecret
+	sec.ID = credentialID
+	return sec, nil
+}
+
+func (f *fakeUpstreamStore) GetVertexCredentialSecret(_ context.Context, credentialID int64) (store.VertexCredentialSecret, error) {
+	sec := f.vertexSecret
+	sec.ID = credentialID
+	return sec, nil
+}
+
 func (f *fakeUpstreamStore) GetUpstreamChannelByID(_ context.Context, channelID int64) (store.UpstreamChannel, error) {
 	ch := f.channel
 	ch.ID = channelID
@@ -448,6 +476,121 @@ func TestExecutor_BuildRequest_AnthropicTTL1hAddsBetaHeaderAndBodyTTL(t *testing
 	}
 }
 
+func TestExecutor_BuildRequest_GeminiInjectsGoogAPIKeyAndStripsDownstreamKey(t *testing.T) {
+	exec := &Executor{
+		st: &fakeUpstreamStore{
+			geminiSecret: store.GeminiCredentialSecret{
+				APIKey: "AIza-upstream",
+			},
+		},
+		upstreamTimeout: 2 * time.Minute,
+	}
+
+	body := []byte(`{"contents":[{"role":"user","parts":[{"text":"hi"}]}]}`)
+	r := httptest.NewRequest(http.MethodPost, "http://example.com/v1beta/models/gemini-2.5-flash:streamGenerateContent?alt=sse&key=rlm_downstream", bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("x-goog-api-key", "rlm_downstream")
+
+	sel := scheduler.Selection{
+		BaseURL:        "https://127.0.0.1/v1beta",
+		CredentialType: scheduler.CredentialTypeGemini,
+		CredentialID:   1,
+	}
+	req, err := exec.buildRequest(context.Background(), sel, r, body)
+	if err != nil {
+		t.Fatalf("buildRequest returned error: %v", err)
+	}
+	if got := req.Header.Get("x-goog-api-key"); got != "AIza-upstream" {
+		t.Fatalf("expected upstream x-goog-api-key, got %q", got)
+	}
+	if got := req.Header.Get("Authorization"); got != "" {
+		t.Fatalf("expected no Authorization header, got %q", got)
+	}
+	if got := req.URL.Path; got != "/v1beta/models/gemini-2.5-flash:streamGenerateContent" {
+		t.Fatalf("unexpected upstream path: %q", got)
+	}
+	if got := req.URL.Query().Get("alt"); got != "sse" {
+		t.Fatalf("expected alt=sse to be kept, got %q", got)
+	}
+	if req.URL.Query().Has("key") {
+		t.Fatalf("expected downstream key query to be stripped, got %q", req.URL.RawQuery)
+	}
+	if !isStreamRequest(r, body) {
+		t.Fatalf("expected streamGenerateContent to be treated as stream request")
+	}
+
+	r2 := httptest.NewRequest(http.MethodPost, "http://example.com/v1/chat/completions", bytes.NewReader(body))
+	if _, err := exec.buildRequest(context.Background(), sel, r2, body); err == nil {
+		t.Fatalf("expected gemini credential to reject non-gemini path")
+	}
+}
+
+func TestExecutor_BuildRequest_AzureOpenAIMapsDeploymentAndAPIVersion(t *testing.T) {
+	exec := &Executor{
+		st: &fakeUpstreamStore{
+			azureSecret: store.AzureOpenAICredentialSecret{
+				APIKey: "azure-upstream",
+			},
+		},
+		upstreamTimeout: 2 * time.Minute,
+	}
+
+	body := []byte(`{"model":"gpt-4o-prod","messages":[{"role":"user","content":"hi"}]}`)
+	r := httptest.NewRequest(http.MethodPost, "http://example.com/v1/chat/completions", bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Authorization", "Bearer rlm_downstream")
+	r.Header.Set("api-key", "rlm_downstream")
+
+	sel := scheduler.Selection{
+		BaseURL:        "https://127.0.0.1",
+		CredentialType: scheduler.CredentialTypeAzure,
+		CredentialID:   1,
+	}
+	req, err := exec.buildRequest(context.Background(), sel, r, body)
+	if err != nil {
+		t.Fatalf("buildRequest returned error: %v", err)
+	}
+	if got := req.Header.Get("api-key"); got != "azure-upstream" {
+		t.Fatalf("expected upstream api-key, got %q", got)
+	}
+	if got := req.Header.Get("Authorization"); got != "" {
+		t.Fatalf("expected no Authorization header, got %q", got)
+	}
+	if got := req.URL.Path; got != "/openai/deployments/gpt-4o-prod/chat/completions" {
+		t.Fatalf("unexpected upstream path: %q", got)
+	}
+	if got := req.URL.Query().Get("api-version"); got != defaultAzureOpenAIAPIVersion {
+		t.Fatalf("expected default api-version, got %q", got)
+	}
+
+	apiVersion := "2025-01-01-preview"
+	sel.APIVersion = apiVersion
+	r2 := httptest.NewRequest(http.MethodPost, "http://example.com/v1/responses", bytes.NewReader(body))
+	req2, err := exec.buildRequest(context.Background(), sel, r2, body)
+	if err != nil {
+		t.Fatalf("buildRequest responses returned error: %v", err)
+	}
+	if req2.URL.Path != "/openai/responses" || req2.URL.Query().Get("api-version") != apiVersion {
+		t.Fatalf("unexpected responses url: %s", req2.URL.String())
+	}
+
+	sel.APIVersion = "v1"
+	embBody := []byte(`{"model":"text-embedding-3-small","input":"hi"}`)
+	r3 := httptest.NewRequest(http.MethodPost, "http://example.com/v1/embeddings", bytes.NewReader(embBody))
+	req3, err := exec.buildRequest(context.Background(), sel, r3, embBody)
+	if err != nil {
+		t.Fatalf("buildRequest embeddings returned error: %v", err)
+	}
+	if req3.URL.Path != "/openai/v1/embeddings" || req3.URL.Query().Has("api-version") {
+		t.Fatalf("unexpected v1 embeddings url: %s", req3.URL.String())
+	}
+
+	r4 := httptest.NewRequest(http.MethodPost, "http://example.com/v1/messages", bytes.NewReader(body))
+	if _, err := exec.buildRequest(context.Background(), sel, r4, body); err == nil {
+		t.Fatalf("expected azure credential to reject /v1/messages")
+	}
+}
+
 func TestExecutor_Do_OpenAICompat_UnsupportedMaxOutputTokens_RewritesToMaxTokens(t *testing.T) {
 	var bodies []map[string]any
 
@@ -30,11 +30,27 @@ type SSEPumpOptions struct {
 type SSEPumpHooks struct {
 	// OnData 在遇到 `data:` 行时触发（已剥离 `data:` 前缀并 trim 空白）。
 	OnData func(data string)
-	// TransformData 用于按“事件边界（空行）”对 data payload 做变换：
-	// - 入参 data 为聚合后的 event payload（多行 data: 以 "\n" 拼接）
-	// - 返回非空 slice 表示用返回值替换该事件（每个元素会被写为一个独立的 data: 事件）
-	// - 返回 nil/空 slice 表示不替换（透传原始事件）
-	TransformData func(data string) ([]string, error)
+	// TransformData 用于按“事件边界（空行）”对事件做变换（内容改写 / 协议转换）：
+	// - 入参 event 为 `event:` 字段（可能为空），data 为聚合后的 payload（多行 data: 以 "\n" 拼接；`data: [DONE]` 以 "[DONE]" 传入）
+	// - 返回 handled=false 表示不处理（透传原始事件）
+	// - 返回 handled=true 时用 outs 替换整个事件（含 event:/id: 等字段）；outs 为空表示丢弃该事件；
+	//   outs 仅含一个与原事件 event/data 相同的事件时视为透传，按原始行写回（保留 id:/retry: 等字段）
+	// - 上游正常结束但未发送 [DONE] 时，会在 EOF 以 data="[DONE]" 再调用一次，保证转换后的流总有结束事件
+	TransformData func(event string, data string) (outs []SSEEvent, handled bool, err error)
+}
+
+// isPassthroughEvent 判断转换结果是否与原事件相同。
+func isPassthroughEvent(outs []SSEEvent, event string, data string) bool {
+	return len(outs) == 1 && !outs[0].Internal && outs[0].Event == event && outs[0].Data == data
+}
+
+// SSEEvent 表示一个写回下游的 SSE 事件。
+type SSEEvent struct {
+	// Event 为空时不写 `event:` 行。
+	Event string
+	Data  string
+	// Internal 为 true 时只交给 OnData（例如供计费提取 usage），不写回下游。
+	Internal bool
 }
 
 type SSEPumpResult struct {
@@ -239,31 +255,50 @@ func PumpSSE(ctx context.Context, w http.ResponseWriter, upstreamBody io.ReadClo
 	// SSE 事件允许多行 data:，规范要求将多行按 "\n" 连接后视为一个事件 payload。
 	// 这里按事件边界（空行）聚合，避免上游把 JSON 拆成多行导致下游解析/计费统计丢失。
 	var (
-		eventLines     []string
-		eventData      strings.Builder
-		hasData        bool
-		hasNonDataLine bool
+		eventLines    []string
+		eventData     strings.Builder
+		eventName     string
+		hasData       bool
+		hasDone       bool
+		transformDone bool
 	)
 
-	isTransformSafeLine := func(line string) bool {
-		trimmed := strings.TrimSpace(line)
-		if trimmed == "" {
-			return true
-		}
-		if strings.HasPrefix(trimmed, "data:") {
-			return true
-		}
-		if strings.HasPrefix(trimmed, ":") {
-			return true
-		}
-		return false
-	}
-
 	resetEventBuf := func() {
 		eventLines = eventLines[:0]
 		eventData.Reset()
+		eventName = ""
 		hasData = false
-		hasNonDataLine = false
+		hasDone = false
+	}
+
+	// writeEvents 写出 TransformData 的输出；Internal 事件只交给 OnData。
+	writeEvents := func(outs []SSEEvent) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		for _, out := range outs {
+			if out.Internal {
+				if hooks.OnData != nil {
+					hooks.OnData(out.Data)
+				}
+				continue
+			}
+			if out.Data == "[DONE]" {
+				sawDone = true
+			} else if hooks.OnData != nil {
+				hooks.OnData(out.Data)
+			}
+			frame := "data: " + out.Data + "\n\n"
+			if out.Event != "" {
+				frame = "event: " + out.Event + "\n" + frame
+			}
+			n, werr := io.WriteString(w, frame)
+			if werr != nil {
+				return werr
+			}
+			recordWrite(n)
+			flusher.Flush()
+		}
+		return nil
 	}
 
 	flushEvent := func() error {
@@ -273,25 +308,16 @@ func PumpSSE(ctx context.Context, w http.ResponseWriter, upstreamBody io.ReadClo
 			agg = eventData.String()
 		}
 
-		// 可变换事件：仅对“纯 data/注释行事件”生效，避免丢失 event:/id:/retry: 等字段。
-		if agg != "" && hooks.TransformData != nil && !hasNonDataLine {
-			outs, err := hooks.TransformData(agg)
-			if err == nil && len(outs) > 0 {
-				writeMu.Lock()
-				defer writeMu.Unlock()
-				for _, out := range outs {
-					if hooks.OnData != nil {
-						hooks.OnData(out)
-					}
-					if n, werr := io.WriteString(w, "data: "+out+"\n\n"); werr != nil {
-						return werr
-					} else {
-						recordWrite(n)
-					}
-					flusher.Flush()
-				}
+		if hooks.TransformData != nil && (agg != "" || hasDone) {
+			data := agg
+			if data == "" {
+				data = "[DONE]"
+				transformDone = true
+			}
+			outs, handled, err := hooks.TransformData(eventName, data)
+			if err == nil && handled && !isPassthroughEvent(outs, eventName, data) {
 				resetEventBuf()
-				return nil
+				return writeEvents(outs)
 			}
 		}
 
@@ -339,6 +365,19 @@ func PumpSSE(ctx context.Context, w http.ResponseWriter, upstreamBody io.ReadClo
 							return res, werr
 						}
 					}
+					// 上游未发送 [DONE] 就正常结束：仍让转换器输出结束事件（转换器需保证结束事件幂等）。
+					if hooks.TransformData != nil && !transformDone {
+						transformDone = true
+						if outs, handled, terr := hooks.TransformData("", "[DONE]"); terr == nil && handled {
+							if werr := writeEvents(outs); werr != nil {
+								res.ErrorClass = "client_disconnect"
+								stop()
+								wg.Wait()
+								finish()
+								return res, werr
+							}
+						}
+					}
 					stop()
 					wg.Wait()
 					finish()
@@ -392,12 +431,13 @@ func PumpSSE(ctx context.Context, w http.ResponseWriter, upstreamBody io.ReadClo
 
 			data := strings.TrimSuffix(line, "\r")
 			eventLines = append(eventLines, line)
-			if !isTransformSafeLine(data) {
-				hasNonDataLine = true
+			if v := parseSSEEventLine(data); v != "" {
+				eventName = v
 			}
 			if v := parseSSEDataLine(data); v != "" {
 				if v == "[DONE]" {
 					sawDone = true
+					hasDone = true
 				} else {
 					if hasData {
 						eventData.WriteByte('\n')
@@ -441,6 +481,14 @@ func parseSSEDataLine(line string) string {
 	return data
 }
 
+func parseSSEEventLine(line string) string {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "event:") {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+}
+
 func idleTimerC(t *time.Timer) <-chan time.Time {
 	if t == nil {
 		return nil
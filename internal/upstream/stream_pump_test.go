@@ -171,3 +171,152 @@ func TestPumpSSE_OnDataSeparatesEvents(t *testing.T) {
 		t.Fatalf("unexpected OnData payloads: %v", got)
 	}
 }
+
+func TestPumpSSE_TransformDataHandlesNamedEventsAndDone(t *testing.T) {
+	t.Parallel()
+
+	in := strings.Join([]string{
+		"event: ping",
+		"data: {\"type\":\"ping\"}",
+		"",
+		"event: delta",
+		"data: {\"text\":\"hi\"}",
+		"",
+		"data: [DONE]",
+		"",
+		"",
+	}, "\n")
+
+	var gotEvents []string
+	var onData []string
+	w := &flushWriter{}
+	res, err := PumpSSE(context.Background(), w, io.NopCloser(strings.NewReader(in)), SSEPumpOptions{
+		MaxLineBytes:     64 << 10,
+		InitialLineBytes: 64 << 10,
+	}, SSEPumpHooks{
+		OnData: func(data string) {
+			onData = append(onData, data)
+		},
+		TransformData: func(event string, data string) ([]SSEEvent, bool, error) {
+			gotEvents = append(gotEvents, event+"|"+data)
+			switch event {
+			case "ping":
+				return nil, true, nil
+			case "delta":
+				return []SSEEvent{{Event: "out", Data: "x"}}, true, nil
+			default:
+				return []SSEEvent{{Data: "y"}, {Data: "[DONE]"}}, true, nil
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("PumpSSE err: %v (class=%s)", err, res.ErrorClass)
+	}
+	want := "event: out\ndata: x\n\ndata: y\n\ndata: [DONE]\n\n"
+	if got := w.buf.String(); got != want {
+		t.Fatalf("unexpected output: %q", got)
+	}
+	if len(gotEvents) != 3 || gotEvents[0] != "ping|{\"type\":\"ping\"}" || gotEvents[2] != "|[DONE]" {
+		t.Fatalf("unexpected TransformData inputs: %v", gotEvents)
+	}
+	if len(onData) != 2 || onData[0] != "x" || onData[1] != "y" {
+		t.Fatalf("unexpected OnData payloads: %v", onData)
+	}
+	if !res.SawDone {
+		t.Fatalf("expected SawDone")
+	}
+}
+
+func TestPumpSSE_InternalEventsOnlyReachOnData(t *testing.T) {
+	t.Parallel()
+
+	in := "data: {\"a\":1}\n\n"
+	var onData []string
+	w := &flushWriter{}
+	res, err := PumpSSE(context.Background(), w, io.NopCloser(strings.NewReader(in)), SSEPumpOptions{
+		MaxLineBytes:     64 << 10,
+		InitialLineBytes: 64 << 10,
+	}, SSEPumpHooks{
+		OnData: func(data string) {
+			onData = append(onData, data)
+		},
+		TransformData: func(event string, data string) ([]SSEEvent, bool, error) {
+			if data == "[DONE]" {
+				return nil, true, nil
+			}
+			return []SSEEvent{{Data: "x"}, {Data: "usage", Internal: true}}, true, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("PumpSSE err: %v (class=%s)", err, res.ErrorClass)
+	}
+	if got := w.buf.String(); got != "data: x\n\n" {
+		t.Fatalf("unexpected output: %q", got)
+	}
+	if len(onData) != 2 || onData[1] != "usage" {
+		t.Fatalf("unexpected OnData payloads: %v", onData)
+	}
+}
+
+func TestPumpSSE_TransformDataEmitsFinishOnEOFWithoutDone(t *testing.T) {
+	t.Parallel()
+
+	in := "event: delta\ndata: {\"text\":\"hi\"}\n\n"
+	var gotEvents []string
+	w := &flushWriter{}
+	res, err := PumpSSE(context.Background(), w, io.NopCloser(strings.NewReader(in)), SSEPumpOptions{
+		MaxLineBytes:     64 << 10,
+		InitialLineBytes: 64 << 10,
+	}, SSEPumpHooks{
+		TransformData: func(event string, data string) ([]SSEEvent, bool, error) {
+			gotEvents = append(gotEvents, event+"|"+data)
+			if data == "[DONE]" {
+				return []SSEEvent{{Event: "stop", Data: "{}"}}, true, nil
+			}
+			return []SSEEvent{{Data: "x"}}, true, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("PumpSSE err: %v (class=%s)", err, res.ErrorClass)
+	}
+	if got, want := w.buf.String(), "data: x\n\nevent: stop\ndata: {}\n\n"; got != want {
+		t.Fatalf("unexpected output: %q", got)
+	}
+	if len(gotEvents) != 2 || gotEvents[1] != "|[DONE]" {
+		t.Fatalf("expected a synthesized [DONE] on EOF, got=%v", gotEvents)
+	}
+}
+
+func TestPumpSSE_TransformDataPassthroughKeepsIDAndRetry(t *testing.T) {
+	t.Parallel()
+
+	in := "id: 7\nretry: 1000\nevent: delta\ndata: {\"a\":1}\n\nid: 8\ndata: {\"b\":2}\n\n"
+	var onData []string
+	w := &flushWriter{}
+	res, err := PumpSSE(context.Background(), w, io.NopCloser(strings.NewReader(in)), SSEPumpOptions{
+		MaxLineBytes:     64 << 10,
+		InitialLineBytes: 64 << 10,
+	}, SSEPumpHooks{
+		OnData: func(data string) {
+			onData = append(onData, data)
+		},
+		TransformData: func(event string, data string) ([]SSEEvent, bool, error) {
+			if data == "[DONE]" {
+				return nil, true, nil
+			}
+			if event == "delta" {
+				return []SSEEvent{{Event: event, Data: data}}, true, nil
+			}
+			return []SSEEvent{{Data: "x"}}, true, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("PumpSSE err: %v (class=%s)", err, res.ErrorClass)
+	}
+	if got, want := w.buf.String(), "id: 7\nretry: 1000\nevent: delta\ndata: {\"a\":1}\n\ndata: x\n\n"; got != want {
+		t.Fatalf("unexpected output: %q", got)
+	}
+	if len(onData) != 2 || onData[0] != `{"a":1}` || onData[1] != "x" {
+		t.Fatalf("unexpected OnData payloads: %v", onData)
+	}
+}
@@ -0,0 +1,300 @@
+package upstream
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// vertexAnthropicVersion 为 Vertex AI 上 Anthropic rawPredict 请求体要求的 anthropic_version。
+	vertexAnthropicVersion = "vertex-2023-10-16"
+	vertexDefaultTokenURI  = "https://oauth2.googleapis.com/token"
+	vertexTokenScope       = "https://www.googleapis.com/auth/cloud-platform"
+	vertexJWTBearerGrant   = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+	vertexDefaultLocation  = "global"
+	// vertexTokenRefreshSkew 为 access token 到期前的提前刷新窗口，避免请求途中过期。
+	vertexTokenRefreshSkew = 5 * time.Minute
+	vertexAssertionTTL     = time.Hour
+	// vertexMaxErrorBodyBytes 限制改写错误响应 / 读取 token 响应时的 body 大小。
+	vertexMaxErrorBodyBytes = 64 << 10
+)
+
+// vertexServiceAccount 为 service account JSON 中换取 access token 所需的字段。
+type vertexServiceAccount struct {
+	ClientEmail  string `json:"client_email"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// vertexTokenCache 按 credential 缓存 access token；同一 credential 的并发刷新串行化，避免击穿 token 端点。
+// 零值可用。
+type vertexTokenCache struct {
+	mu      sync.Mutex
+	entries map[int64]*vertexTokenEntry
+}
+
+type vertexTokenEntry struct {
+	mu          sync.Mutex
+	fingerprint string
+	accessToken string
+	expiresAt   time.Time
+}
+
+func (c *vertexTokenCache) entry(credentialID int64) *vertexTokenEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[int64]*vertexTokenEntry)
+	}
+	ent, ok := c.entries[credentialID]
+	if !ok {
+		ent = &vertexTokenEntry{}
+		c.entries[credentialID] = ent
+	}
+	return ent
+}
+
+// vertexAccessToken 返回 credential 的有效 access token：缓存未命中或临近过期时通过 JWT-bearer 流程重新换取。
+func (e *Executor) vertexAccessToken(ctx context.Context, client *http.Client, credentialID int64, serviceAccountJSON string, now time.Time) (string, error) {
+	sum := sha256.Sum256([]byte(serviceAccountJSON))
+	fingerprint := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	ent := e.vertexTokens.entry(credentialID)
+	ent.mu.Lock()
+	defer ent.mu.Unlock()
+	if ent.fingerprint == fingerprint && ent.accessToken != "" && now.Add(vertexTokenRefreshSkew).Before(ent.expiresAt) {
+		return ent.accessToken, nil
+	}
+
+	var sa vertexServiceAccount
+	if err := json.Unmarshal([]byte(serviceAccountJSON), &sa); err != nil {
+		return "", errors.New("vertex service account JSON 无效")
+	}
+	token, expiresAt, err := mintVertexAccessToken(ctx, client, sa, now)
+	if err != nil {
+		return "", err
+	}
+	ent.fingerprint = fingerprint
+	ent.accessToken = token
+	ent.expiresAt = expiresAt
+	return token, nil
+}
+
+// mintVertexAccessToken 以 service account 私钥签发 RS256 JWT assertion，并向 token_uri 换取 access token。
+func mintVertexAccessToken(ctx context.Context, client *http.Client, sa vertexServiceAccount, now time.Time) (string, time.Time, error) {
+	tokenURI := strings.TrimSpace(sa.TokenURI)
+	if tokenURI == "" {
+		tokenURI = vertexDefaultTokenURI
+	}
+	assertion, err := signVertexJWTAssertion(sa, tokenURI, now)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", vertexJWTBearerGrant)
+	form.Set("assertion", assertion)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("创建 vertex token 请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("请求 vertex token 失败: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, vertexMaxErrorBodyBytes))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		desc := strings.TrimSpace(gjson.GetBytes(body, "error_description").String())
+		if desc == "" {
+			desc = strings.TrimSpace(gjson.GetBytes(body, "error").String())
+		}
+		return "", time.Time{}, fmt.Errorf("vertex token 换取失败: %d %s", resp.StatusCode, desc)
+	}
+	accessToken := strings.TrimSpace(gjson.GetBytes(body, "access_token").String())
+	if accessToken == "" {
+		return "", time.Time{}, errors.New("vertex token 响应缺少 access_token")
+	}
+	expiresIn := gjson.GetBytes(body, "expires_in").Int()
+	if expiresIn <= 0 {
+		expiresIn = int64(vertexAssertionTTL / time.Second)
+	}
+	return accessToken, now.Add(time.Duration(expiresIn) * time.Second), nil
+}
+
+func signVertexJWTAssertion(sa vertexServiceAccount, audience string, now time.Time) (string, error) {
+	if strings.TrimSpace(sa.ClientEmail) == "" {
+		return "", errors.New("vertex service account 缺少 client_email")
+	}
+	key, err := parseVertexPrivateKey(sa.PrivateKey)
+	if err != nil {
+		return "", err
+	}
+	header := map[string]any{"alg": "RS256", "typ": "JWT"}
+	if kid := strings.TrimSpace(sa.PrivateKeyID); kid != "" {
+		header["kid"] = kid
+	}
+	claims := map[string]any{
+		"iss":   sa.ClientEmail,
+		"scope": vertexTokenScope,
+		"aud":   audience,
+		"iat":   now.Unix(),
+		"exp":   now.Add(vertexAssertionTTL).Unix(),
+	}
+	hb, _ := json.Marshal(header)
+	cb, _ := json.Marshal(claims)
+	signingInput := base64.RawURLEncoding.EncodeToString(hb) + "." + base64.RawURLEncoding.EncodeToString(cb)
+	digest := sha256.Sum256([]byte(signingInput))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("vertex JWT 签名失败: %w", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+func parseVertexPrivateKey(raw string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(raw))
+	if block == nil {
+		return nil, errors.New("vertex service account private_key 不是有效 PEM")
+	}
+	if k, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		if rk, ok := k.(*rsa.PrivateKey); ok {
+			return rk, nil
+		}
+		return nil, errors.New("vertex service account private_key 不是 RSA 私钥")
+	}
+	if rk, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return rk, nil
+	}
+	return nil, errors.New("vertex service account private_key 解析失败")
+}
+
+// vertexLocation 返回请求使用的 location：credential 显式配置优先；否则由 {location}-aiplatform.googleapis.com 推断；
+// 仍无法推断时使用 global。
+func vertexLocation(configured string, base *url.URL) string {
+	if loc := strings.TrimSpace(configured); loc != "" {
+		return loc
+	}
+	if base != nil {
+		host := strings.ToLower(base.Hostname())
+		if loc, ok := strings.CutSuffix(host, "-aiplatform.googleapis.com"); ok && loc != "" {
+			return loc
+		}
+	}
+	return vertexDefaultLocation
+}
+
+// buildVertexRequest 将下游请求映射为 Vertex AI publisher model 接口：
+// - /v1/messages → publishers/anthropic/models/{model}:rawPredict / streamRawPredict，body 删除 model 并补齐 anthropic_version；
+// - /v1beta/models/{model}:{action} → publishers/google/models/{model}:{action}（generateContent 等，body 原样透传）。
+//
+// 返回 (upstreamPath, body)；upstreamPath 为未编码路径，形如 /v1/projects/{project}/locations/{location}/publishers/...。
+func buildVertexRequest(targetPath string, body []byte, projectID string, location string) (string, []byte, error) {
+	projectID = strings.TrimSpace(projectID)
+	if projectID == "" || strings.Contains(projectID, "/") || strings.Contains(location, "/") {
+		return "", nil, errors.New("vertex credential 的 project_id / location 不合法")
+	}
+	prefix := "/v1/projects/" + projectID + "/locations/" + location + "/publishers"
+
+	switch {
+	case targetPath == "/v1/messages":
+		var payload map[string]any
+		if err := json.Unmarshal(body, &payload); err != nil || payload == nil {
+			return "", nil, errors.New("vertex 请求体不是有效 JSON")
+		}
+		modelID, _ := payload["model"].(string)
+		modelID = strings.TrimSpace(modelID)
+		if modelID == "" || strings.Contains(modelID, "/") {
+			return "", nil, errors.New("vertex 请求缺少 model 或 model 不合法")
+		}
+		stream, _ := payload["stream"].(bool)
+		delete(payload, "model")
+		if v, ok := payload["anthropic_version"].(string); !ok || strings.TrimSpace(v) == "" {
+			payload["anthropic_version"] = vertexAnthropicVersion
+		}
+		out, err := json.Marshal(payload)
+		if err != nil {
+			return "", nil, fmt.Errorf("vertex 请求体序列化失败: %w", err)
+		}
+		action := "rawPredict"
+		if stream {
+			action = "streamRawPredict"
+		}
+		return prefix + "/anthropic/models/" + modelID + ":" + action, out, nil
+	case strings.HasPrefix(targetPath, "/v1beta/models/"):
+		rest := strings.TrimPrefix(targetPath, "/v1beta/models/")
+		if rest == "" || !strings.Contains(rest, ":") {
+			return "", nil, errors.New("vertex gemini 请求路径应为 /v1beta/models/{model}:{action}")
+		}
+		return prefix + "/google/models/" + rest, body, nil
+	default:
+		return "", nil, errors.New("vertex 上游仅支持 /v1/messages 与 /v1beta/models/*")
+	}
+}
+
+// adaptVertexMessagesResponse 把 Vertex 平台层错误（{"error":{"code","message","status"}}）改写为 Anthropic error JSON，
+// 以便 /v1/messages 下游按 Anthropic 协议解析；模型本身返回的 Anthropic 错误保持不变。
+func adaptVertexMessagesResponse(resp *http.Response) *http.Response {
+	if resp == nil || resp.Body == nil || resp.StatusCode < 400 {
+		return resp
+	}
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, vertexMaxErrorBodyBytes))
+	_ = resp.Body.Close()
+	status := gjson.GetBytes(raw, "error.status").String()
+	if gjson.GetBytes(raw, "type").String() == "error" || status == "" {
+		resp.Body = io.NopCloser(strings.NewReader(string(raw)))
+		return resp
+	}
+	out, _ := json.Marshal(map[string]any{
+		"type": "error",
+		"error": map[string]any{
+			"type":    vertexAnthropicErrorType(status),
+			"message": gjson.GetBytes(raw, "error.message").String(),
+		},
+	})
+	resp.Body = io.NopCloser(strings.NewReader(string(out)))
+	resp.Header.Set("Content-Type", "application/json")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = int64(len(out))
+	return resp
+}
+
+func vertexAnthropicErrorType(status string) string {
+	switch strings.ToUpper(strings.TrimSpace(status)) {
+	case "INVALID_ARGUMENT", "FAILED_PRECONDITION", "OUT_OF_RANGE":
+		return "invalid_request_error"
+	case "UNAUTHENTICATED":
+		return "authentication_error"
+	case "PERMISSION_DENIED":
+		return "permission_error"
+	case "NOT_FOUND":
+		return "not_found_error"
+	case "RESOURCE_EXHAUSTED":
+		return "rate_limit_error"
+	case "UNAVAILABLE":
+		return "overloaded_error"
+	default:
+		return "api_error"
+	}
+}
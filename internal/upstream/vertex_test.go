@@ -0,0 +1,250 @@
+package upstream
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"realms/internal/scheduler"
+	"realms/internal/store"
+)
+
+// vertexStandIn 模拟 Google token 端点与 Vertex AI publisher model 接口：
+// - POST /token 校验 JWT-bearer assertion 的 RS256 签名后签发 access token；
+// - 其余路径要求 Bearer access token，并记录请求路径 / 查询 / body。
+type vertexStandIn struct {
+	srv        *httptest.Server
+	tokenCalls atomic.Int32
+	gotPath    string
+	gotQuery   string
+	gotBody    map[string]any
+}
+
+func newVertexStandIn(t *testing.T, pub *rsa.PublicKey) *vertexStandIn {
+	t.Helper()
+	s := &vertexStandIn{}
+	s.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			s.tokenCalls.Add(1)
+			_ = r.ParseForm()
+			if r.Form.Get("grant_type") != vertexJWTBearerGrant {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":"unsupported_grant_type"}`))
+				return
+			}
+			parts := strings.Split(r.Form.Get("assertion"), ".")
+			if len(parts) != 3 {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+				return
+			}
+			sig, _ := base64.RawURLEncoding.DecodeString(parts[2])
+			digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+			if rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig) != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":"invalid_grant","error_description":"Invalid JWT Signature."}`))
+				return
+			}
+			claims, _ := base64.RawURLEncoding.DecodeString(parts[1])
+			if !strings.Contains(string(claims), `"scope":"`+vertexTokenScope+`"`) {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":"invalid_scope"}`))
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"access_token":"ya29.local","expires_in":3599,"token_type":"Bearer"}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer ya29.local" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":{"code":401,"message":"Request had invalid authentication credentials.","status":"UNAUTHENTICATED"}}`))
+			return
+		}
+		s.gotPath = r.URL.Path
+		s.gotQuery = r.URL.RawQuery
+		raw, _ := io.ReadAll(r.Body)
+		s.gotBody = nil
+		_ = json.Unmarshal(raw, &s.gotBody)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	return s
+}
+
+func vertexTestServiceAccountJSON(t *testing.T, key *rsa.PrivateKey, tokenURI string) string {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey: %v", err)
+	}
+	raw, _ := json.Marshal(map[string]any{
+		"type":           "service_account",
+		"project_id":     "demo-project",
+		"private_key_id": "kid-1",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"client_email":   "realms@demo-project.iam.gserviceaccount.com",
+		"token_uri":      tokenURI,
+	})
+	return string(raw)
+}
+
+func TestExecutor_Do_VertexMintsTokenAndMapsPublisherPaths(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	standIn := newVertexStandIn(t, &key.PublicKey)
+	defer standIn.srv.Close()
+	saJSON := vertexTestServiceAccountJSON(t, key, standIn.srv.URL+"/token")
+
+	exec := &Executor{
+		st: &fakeUpstreamStore{
+			vertexSecret: store.VertexCredentialSecret{
+				ProjectID:          "demo-project",
+				Location:           "us-east5",
+				ServiceAccountJSON: saJSON,
+			},
+		},
+		client:          standIn.srv.Client(),
+		upstreamTimeout: 2 * time.Minute,
+	}
+	sel := scheduler.Selection{
+		ChannelType:    store.UpstreamTypeVertex,
+		BaseURL:        standIn.srv.URL,
+		CredentialType: scheduler.CredentialTypeVertex,
+		CredentialID:   7,
+	}
+
+	body := []byte(`{"model":"claude-sonnet-4@20250514","max_tokens":64,"stream":true,"messages":[{"role":"user","content":"ping"}]}`)
+	r := httptest.NewRequest(http.MethodPost, "http://example.com/v1/messages", bytes.NewReader(body))
+	r.Header.Set("x-api-key", "rlm_downstream")
+	r.Header.Set("anthropic-version", "2023-06-01")
+	resp, err := exec.Do(context.Background(), sel, r, body)
+	if err != nil {
+		t.Fatalf("Do messages: %v", err)
+	}
+	out, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status=%d body=%s", resp.StatusCode, out)
+	}
+	if standIn.gotPath != "/v1/projects/demo-project/locations/us-east5/publishers/anthropic/models/claude-sonnet-4@20250514:streamRawPredict" {
+		t.Fatalf("unexpected messages path: %q", standIn.gotPath)
+	}
+	if _, ok := standIn.gotBody["model"]; ok {
+		t.Fatalf("expected model to be moved to path, body=%v", standIn.gotBody)
+	}
+	if standIn.gotBody["anthropic_version"] != vertexAnthropicVersion || standIn.gotBody["stream"] != true {
+		t.Fatalf("unexpected rawPredict body: %v", standIn.gotBody)
+	}
+
+	gemini := []byte(`{"contents":[{"role":"user","parts":[{"text":"ping"}]}]}`)
+	r2 := httptest.NewRequest(http.MethodPost, "http://example.com/v1beta/models/gemini-2.5-pro:streamGenerateContent?alt=sse&key=rlm_downstream", bytes.NewReader(gemini))
+	resp2, err := exec.Do(context.Background(), sel, r2, gemini)
+	if err != nil {
+		t.Fatalf("Do gemini: %v", err)
+	}
+	_ = resp2.Body.Close()
+	if resp2.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected gemini status=%d", resp2.StatusCode)
+	}
+	if standIn.gotPath != "/v1/projects/demo-project/locations/us-east5/publishers/google/models/gemini-2.5-pro:streamGenerateContent" {
+		t.Fatalf("unexpected gemini path: %q", standIn.gotPath)
+	}
+	if standIn.gotQuery != "alt=sse" {
+		t.Fatalf("expected alt=sse only, got %q", standIn.gotQuery)
+	}
+
+	if n := standIn.tokenCalls.Load(); n != 1 {
+		t.Fatalf("expected cached access token (1 token call), got %d", n)
+	}
+}
+
+func TestExecutor_Do_VertexPlatformErrorBecomesAnthropicError(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	saJSON := vertexTestServiceAccountJSON(t, key, "http://127.0.0.1:1/token")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"error":{"code":429,"message":"Quota exceeded for aiplatform.googleapis.com/online_prediction_requests_per_base_model.","status":"RESOURCE_EXHAUSTED"}}`))
+	}))
+	defer srv.Close()
+
+	exec := &Executor{
+		st: &fakeUpstreamStore{
+			vertexSecret: store.VertexCredentialSecret{ProjectID: "demo-project", ServiceAccountJSON: saJSON},
+		},
+		client:          srv.Client(),
+		upstreamTimeout: 2 * time.Minute,
+	}
+	// 预置缓存，避免测试依赖真实 token 端点。
+	ent := exec.vertexTokens.entry(3)
+	sum := sha256.Sum256([]byte(saJSON))
+	ent.fingerprint = base64.RawURLEncoding.EncodeToString(sum[:])
+	ent.accessToken = "ya29.cached"
+	ent.expiresAt = time.Now().Add(time.Hour)
+
+	sel := scheduler.Selection{BaseURL: srv.URL, CredentialType: scheduler.CredentialTypeVertex, CredentialID: 3}
+	body := []byte(`{"model":"claude-3-5-haiku@20241022","max_tokens":8,"messages":[{"role":"user","content":"ping"}]}`)
+	r := httptest.NewRequest(http.MethodPost, "http://example.com/v1/messages", bytes.NewReader(body))
+	resp, err := exec.Do(context.Background(), sel, r, body)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	defer resp.Body.Close()
+	out, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got=%d body=%s", resp.StatusCode, out)
+	}
+	if !strings.Contains(string(out), `"type":"rate_limit_error"`) || !strings.Contains(string(out), `"type":"error"`) {
+		t.Fatalf("expected anthropic error shape, got=%s", out)
+	}
+
+	req, err := exec.buildRequest(context.Background(), sel, r, body)
+	if err != nil {
+		t.Fatalf("buildRequest: %v", err)
+	}
+	if !strings.Contains(req.URL.Path, "/locations/global/") {
+		t.Fatalf("expected global location fallback, got %q", req.URL.Path)
+	}
+	if req.Header.Get("Authorization") != "Bearer ya29.cached" {
+		t.Fatalf("expected cached token, got %q", req.Header.Get("Authorization"))
+	}
+
+	r2 := httptest.NewRequest(http.MethodPost, "http://example.com/v1/chat/completions", bytes.NewReader(body))
+	if _, err := exec.buildRequest(context.Background(), sel, r2, body); err == nil {
+		t.Fatalf("expected vertex credential to reject chat/completions path")
+	}
+}
+
+func TestVertexLocation_InfersFromRegionalHost(t *testing.T) {
+	base, _ := http.NewRequest(http.MethodGet, "https://europe-west1-aiplatform.googleapis.com", nil)
+	if got := vertexLocation("", base.URL); got != "europe-west1" {
+		t.Fatalf("expected europe-west1, got %q", got)
+	}
+	if got := vertexLocation("us-east5", base.URL); got != "us-east5" {
+		t.Fatalf("expected configured location to win, got %q", got)
+	}
+	global, _ := http.NewRequest(http.MethodGet, "https://aiplatform.googleapis.com", nil)
+	if got := vertexLocation("", global.URL); got != vertexDefaultLocation {
+		t.Fatalf("expected global, got %q", got)
+	}
+}
@@ -103,7 +103,7 @@ func accountUpdateEmailHandler(opts Options) gin.HandlerFunc {
 		}
 
 		sess := sessions.Default(c)
-		applySessionCookieOptions(sess, c.Request)
+		applySessionCookieOptions(sess, c.Request, opts)
 		sess.Clear()
 		_ = sess.Save()
 		c.JSON(http.StatusOK, gin.H{
@@ -162,7 +162,7 @@ func accountUpdatePasswordHandler(opts Options) gin.HandlerFunc {
 		}
 
 		sess := sessions.Default(c)
-		applySessionCookieOptions(sess, c.Request)
+		applySessionCookieOptions(sess, c.Request, opts)
 		sess.Clear()
 		_ = sess.Save()
 		c.JSON(http.StatusOK, gin.H{
@@ -10,12 +10,15 @@ func setAdminAPIRoutes(r gin.IRoutes, opts Options) {
 	setAdminChannelGroupAPIRoutes(admin, opts)
 	setAdminMainGroupAPIRoutes(admin, opts)
 	setAdminUserAPIRoutes(admin, opts)
+	setAdminOrganizationAPIRoutes(admin, opts)
 	setAdminAnnouncementAPIRoutes(admin, opts)
 	setAdminBillingAPIRoutes(admin, opts)
+	setAdminBalanceTransactionAPIRoutes(admin, opts)
 	setAdminRedemptionCodeAPIRoutes(admin, opts)
 	setAdminUsageAPIRoutes(admin, opts)
 	setAdminTicketAPIRoutes(admin, opts)
 	setAdminOAuthAppAPIRoutes(admin, opts)
 	setAdminSettingsAPIRoutes(admin, opts)
 	setAdminPaymentChannelAPIRoutes(admin, opts)
+	setAdminRoutingAPIRoutes(admin, opts)
 }
@@ -55,6 +55,8 @@ func setAdminBillingAPIRoutes(r gin.IRoutes, opts Options) {
 	r.GET("/subscriptions/:plan_id", adminGetSubscriptionPlanHandler(opts))
 	r.PUT("/subscriptions/:plan_id", adminUpdateSubscriptionPlanHandler(opts))
 	r.DELETE("/subscriptions/:plan_id", adminDeleteSubscriptionPlanHandler(opts))
+	r.GET("/subscriptions/:plan_id/rate-limit", adminGetSubscriptionPlanRateLimitHandler(opts))
+	r.PUT("/subscriptions/:plan_id/rate-limit", adminUpdateSubscriptionPlanRateLimitHandler(opts))
 
 	r.GET("/orders", adminListSubscriptionOrdersHandler(opts))
 	r.POST("/orders/:order_id/approve", adminApproveSubscriptionOrderHandler(opts))
@@ -0,0 +1,210 @@
+package router
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"realms/internal/store"
+)
+
+type adminChannelHealthStatsView struct {
+	Requests        int64   `json:"requests"`
+	Failures        int64   `json:"failures"`
+	ErrorRate       float64 `json:"error_rate"`
+	AvgLatencyMS    float64 `json:"avg_latency_ms"`
+	AvgFirstTokenMS float64 `json:"avg_first_token_ms"`
+}
+
+func channelHealthStatsView(s store.ChannelHealthStats) adminChannelHealthStatsView {
+	return adminChannelHealthStatsView{
+		Requests:        s.Requests,
+		Failures:        s.Failures,
+		ErrorRate:       s.ErrorRate(),
+		AvgLatencyMS:    s.AvgLatencyMS,
+		AvgFirstTokenMS: s.AvgFirstTokenMS,
+	}
+}
+
+type adminChannelGroupCanaryView struct {
+	MemberID          int64                       `json:"member_id"`
+	ChannelID         int64                       `json:"channel_id"`
+	CanaryStatus      string                      `json:"canary_status"`
+	CanaryPercent     int                         `json:"canary_percent"`
+	CanaryStartedAt   string                      `json:"canary_started_at,omitempty"`
+	Canary            adminChannelHealthStatsView `json:"canary"`
+	Siblings          adminChannelHealthStatsView `json:"siblings"`
+	SiblingChannelIDs []int64                     `json:"sibling_channel_ids"`
+}
+
+func formatOptionalTimeRFC3339(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func parseChannelGroupMemberParams(c *gin.Context) (int64, int64, bool) {
+	groupID, err := strconv.ParseInt(strings.TrimSpace(c.Param("group_id")), 10, 64)
+	if err != nil || groupID <= 0 {
+		c.JSON(http.StatusOK, gin.H{"success": false, "message": "group_id 不合法"})
+		return 0, 0, false
+	}
+	memberID, err := strconv.ParseInt(strings.TrimSpace(c.Param("member_id")), 10, 64)
+	if err != nil || memberID <= 0 {
+		c.JSON(http.StatusOK, gin.H{"success": false, "message": "member_id 不合法"})
+		return 0, 0, false
+	}
+	return groupID, memberID, true
+}
+
+func writeChannelGroupCanaryError(c *gin.Context, err error, fallback string) {
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusOK, gin.H{"success": false, "message": "成员不存在"})
+		return
+	}
+	msg := fallback
+	if strings.Contains(err.Error(), "canary_percent") || strings.Contains(err.Error(), "仅渠道成员") {
+		msg = err.Error()
+	}
+	c.JSON(http.StatusOK, gin.H{"success": false, "message": msg})
+}
+
+// adminGetChannelGroupMemberCanaryHandler 返回灰度成员自灰度开始以来经本组路由的错误率/延迟，并与同组其他直接渠道成员的汇总对比。
+func adminGetChannelGroupMemberCanaryHandler(opts Options) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if opts.Store == nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "store 未初始化"})
+			return
+		}
+		groupID, memberID, ok := parseChannelGroupMemberParams(c)
+		if !ok {
+			return
+		}
+		members, err := opts.Store.ListChannelGroupMembers(c.Request.Context(), groupID)
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "查询失败"})
+			return
+		}
+		var target *store.ChannelGroupMemberDetail
+		for i := range members {
+			if members[i].MemberID == memberID {
+				target = &members[i]
+				break
+			}
+		}
+		if target == nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "成员不存在"})
+			return
+		}
+		if target.MemberChannelID == nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "仅渠道成员支持灰度"})
+			return
+		}
+		channelID := *target.MemberChannelID
+
+		view := adminChannelGroupCanaryView{
+			MemberID:          memberID,
+			ChannelID:         channelID,
+			CanaryStatus:      target.CanaryStatus,
+			CanaryPercent:     target.CanaryPercent,
+			SiblingChannelIDs: make([]int64, 0, len(members)),
+		}
+		ids := []int64{channelID}
+		for _, m := range members {
+			if m.MemberID == memberID || m.MemberChannelID == nil || m.CanaryStatus != "" {
+				continue
+			}
+			view.SiblingChannelIDs = append(view.SiblingChannelIDs, *m.MemberChannelID)
+			ids = append(ids, *m.MemberChannelID)
+		}
+
+		// 未在灰度中时以最近 24 小时作为对比窗口。
+		now := time.Now().UTC()
+		since := now.Add(-24 * time.Hour)
+		if target.CanaryStartedAt != nil && !target.CanaryStartedAt.IsZero() {
+			since = target.CanaryStartedAt.UTC()
+			view.CanaryStartedAt = formatOptionalTimeRFC3339(target.CanaryStartedAt)
+		}
+		stats, err := opts.Store.GetChannelHealthStatsRange(c.Request.Context(), groupID, ids, since, now.Add(time.Second))
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "统计失败"})
+			return
+		}
+		view.Canary = channelHealthStatsView(stats[channelID])
+		var siblings store.ChannelHealthStats
+		for _, id := range view.SiblingChannelIDs {
+			siblings = siblings.Merge(stats[id])
+		}
+		view.Siblings = channelHealthStatsView(siblings)
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "", "data": view})
+	}
+}
+
+func adminUpdateChannelGroupMemberCanaryHandler(opts Options) gin.HandlerFunc {
+	type reqBody struct {
+		CanaryPercent int `json:"canary_percent"`
+	}
+	return func(c *gin.Context) {
+		if opts.Store == nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "store 未初始化"})
+			return
+		}
+		groupID, memberID, ok := parseChannelGroupMemberParams(c)
+		if !ok {
+			return
+		}
+		var req reqBody
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "无效的参数"})
+			return
+		}
+		if err := opts.Store.StartChannelGroupMemberCanary(c.Request.Context(), groupID, memberID, req.CanaryPercent); err != nil {
+			writeChannelGroupCanaryError(c, err, "保存失败")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "已保存"})
+	}
+}
+
+func adminPromoteChannelGroupMemberCanaryHandler(opts Options) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if opts.Store == nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "store 未初始化"})
+			return
+		}
+		groupID, memberID, ok := parseChannelGroupMemberParams(c)
+		if !ok {
+			return
+		}
+		if err := opts.Store.PromoteChannelGroupMemberCanary(c.Request.Context(), groupID, memberID); err != nil {
+			writeChannelGroupCanaryError(c, err, "转正失败")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "已转正"})
+	}
+}
+
+func adminRollbackChannelGroupMemberCanaryHandler(opts Options) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if opts.Store == nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "store 未初始化"})
+			return
+		}
+		groupID, memberID, ok := parseChannelGroupMemberParams(c)
+		if !ok {
+			return
+		}
+		if err := opts.Store.RollbackChannelGroupMemberCanary(c.Request.Context(), groupID, memberID); err != nil {
+			writeChannelGroupCanaryError(c, err, "回滚失败")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "已回滚"})
+	}
+}
@@ -3,6 +3,7 @@ package router
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"strconv"
@@ -20,6 +21,7 @@ type adminChannelGroupView struct {
 	Description        *string `json:"description,omitempty"`
 	PriceMultiplier    string  `json:"price_multiplier"`
 	Status             int     `json:"status"`
+	RoutingMode        string  `json:"routing_mode"`
 	CreatedAt          string  `json:"created_at"`
 	UpdatedAt          string  `json:"updated_at"`
 	IsDefault          bool    `json:"is_default"`
@@ -45,6 +47,11 @@ func setAdminChannelGroupAPIRoutes(r gin.IRoutes, opts Options) {
 	r.DELETE("/channel-groups/:group_id/children/groups/:child_group_id", adminDeleteChannelGroupGroupMemberHandler(opts))
 	r.DELETE("/channel-groups/:group_id/children/channels/:channel_id", adminDeleteChannelGroupChannelMemberHandler(opts))
 	r.POST("/channel-groups/:group_id/children/reorder", adminReorderChannelGroupMembersHandler(opts))
+	r.PUT("/channel-groups/:group_id/members/:member_id/schedule", adminUpdateChannelGroupMemberScheduleHandler(opts))
+	r.GET("/channel-groups/:group_id/members/:member_id/canary", adminGetChannelGroupMemberCanaryHandler(opts))
+	r.PUT("/channel-groups/:group_id/members/:member_id/canary", adminUpdateChannelGroupMemberCanaryHandler(opts))
+	r.POST("/channel-groups/:group_id/members/:member_id/canary/promote", adminPromoteChannelGroupMemberCanaryHandler(opts))
+	r.POST("/channel-groups/:group_id/members/:member_id/canary/rollback", adminRollbackChannelGroupMemberCanaryHandler(opts))
 }
 
 func adminListChannelGroupsHandler(opts Options) gin.HandlerFunc {
@@ -96,6 +103,7 @@ func adminListChannelGroupsHandler(opts Options) gin.HandlerFunc {
 				Description:        g.Description,
 				PriceMultiplier:    formatDecimalPlain(g.PriceMultiplier, store.PriceMultiplierScale),
 				Status:             g.Status,
+				RoutingMode:        g.RoutingMode,
 				CreatedAt:          g.CreatedAt.Format("2006-01-02 15:04"),
 				UpdatedAt:          g.UpdatedAt.Format("2006-01-02 15:04"),
 				IsDefault:          defaultID > 0 && g.ID == defaultID,
@@ -138,6 +146,7 @@ func adminGetChannelGroupHandler(opts Options) gin.HandlerFunc {
 				Description:     g.Description,
 				PriceMultiplier: formatDecimalPlain(g.PriceMultiplier, store.PriceMultiplierScale),
 				Status:          g.Status,
+				RoutingMode:     g.RoutingMode,
 				CreatedAt:       g.CreatedAt.Format("2006-01-02 15:04"),
 				UpdatedAt:       g.UpdatedAt.Format("2006-01-02 15:04"),
 				IsDefault:       defaultID > 0 && g.ID == defaultID,
@@ -171,6 +180,7 @@ func adminCreateChannelGroupHandler(opts Options) gin.HandlerFunc {
 		Description     *string `json:"description,omitempty"`
 		PriceMultiplier string  `json:"price_multiplier"`
 		Status          int     `json:"status"`
+		RoutingMode     *string `json:"routing_mode,omitempty"`
 	}
 	return func(c *gin.Context) {
 		if opts.Store == nil {
@@ -199,6 +209,11 @@ func adminCreateChannelGroupHandler(opts Options) gin.HandlerFunc {
 			priceMult = m
 		}
 
+		if req.RoutingMode != nil && !store.ValidChannelGroupRoutingMode(*req.RoutingMode) {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "routing_mode 不合法"})
+			return
+		}
+
 		status := req.Status
 		if status != 0 && status != 1 {
 			status = 1
@@ -208,6 +223,12 @@ func adminCreateChannelGroupHandler(opts Options) gin.HandlerFunc {
 			c.JSON(http.StatusOK, gin.H{"success": false, "message": "创建失败（可能渠道组已存在）"})
 			return
 		}
+		if req.RoutingMode != nil {
+			if err := opts.Store.UpdateChannelGroupRoutingMode(c.Request.Context(), id, *req.RoutingMode); err != nil {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": err.Error()})
+				return
+			}
+		}
 
 		c.JSON(http.StatusOK, gin.H{"success": true, "message": "已创建", "data": gin.H{"id": id}})
 	}
@@ -219,6 +240,7 @@ func adminUpdateChannelGroupHandler(opts Options) gin.HandlerFunc {
 		Description     *string `json:"description,omitempty"`
 		PriceMultiplier string  `json:"price_multiplier"`
 		Status          int     `json:"status"`
+		RoutingMode     *string `json:"routing_mode,omitempty"`
 	}
 	return func(c *gin.Context) {
 		if opts.Store == nil {
@@ -260,6 +282,10 @@ func adminUpdateChannelGroupHandler(opts Options) gin.HandlerFunc {
 			}
 			priceMult = m
 		}
+		if req.RoutingMode != nil && !store.ValidChannelGroupRoutingMode(*req.RoutingMode) {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "routing_mode 不合法"})
+			return
+		}
 		if _, err := opts.Store.UpdateChannelGroupWithRename(c.Request.Context(), g.ID, req.Name, req.Description, status, priceMult); err != nil {
 			if errors.Is(err, sql.ErrNoRows) {
 				c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Not Found"})
@@ -268,6 +294,12 @@ func adminUpdateChannelGroupHandler(opts Options) gin.HandlerFunc {
 			c.JSON(http.StatusOK, gin.H{"success": false, "message": err.Error()})
 			return
 		}
+		if req.RoutingMode != nil {
+			if err := opts.Store.UpdateChannelGroupRoutingMode(c.Request.Context(), g.ID, *req.RoutingMode); err != nil {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": err.Error()})
+				return
+			}
+		}
 		// best-effort: refresh updated_at for response message consistency.
 		_, _ = opts.Store.GetChannelGroupByID(c.Request.Context(), g.ID)
 
@@ -372,8 +404,13 @@ type adminChannelGroupMemberView struct {
 	MemberChannelGroups *string `json:"member_channel_groups,omitempty"`
 	MemberChannelStatus *int    `json:"member_channel_status,omitempty"`
 
-	Priority  int  `json:"priority"`
-	Promotion bool `json:"promotion"`
+	Priority  int             `json:"priority"`
+	Promotion bool            `json:"promotion"`
+	Schedule  json.RawMessage `json:"schedule,omitempty"`
+
+	CanaryStatus    string `json:"canary_status,omitempty"`
+	CanaryPercent   int    `json:"canary_percent,omitempty"`
+	CanaryStartedAt string `json:"canary_started_at,omitempty"`
 }
 
 type adminChannelRefView struct {
@@ -462,6 +499,11 @@ func adminGetChannelGroupDetailHandler(opts Options) gin.HandlerFunc {
 
 				Priority:  m.Priority,
 				Promotion: m.Promotion,
+				Schedule:  scheduleJSON(m.Schedule),
+
+				CanaryStatus:    m.CanaryStatus,
+				CanaryPercent:   m.CanaryPercent,
+				CanaryStartedAt: formatOptionalTimeRFC3339(m.CanaryStartedAt),
 			})
 		}
 
@@ -541,6 +583,8 @@ func adminCreateChildChannelGroupHandler(opts Options) gin.HandlerFunc {
 func adminAddChannelGroupChannelMemberHandler(opts Options) gin.HandlerFunc {
 	type reqBody struct {
 		ChannelID int64 `json:"channel_id"`
+		// CanaryPercent 非 0 时以灰度方式加入：仅该百分比的请求会路由到新渠道。
+		CanaryPercent int `json:"canary_percent,omitempty"`
 	}
 	return func(c *gin.Context) {
 		if opts.Store == nil {
@@ -566,10 +610,24 @@ func adminAddChannelGroupChannelMemberHandler(opts Options) gin.HandlerFunc {
 			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "channel 不存在"})
 			return
 		}
+		if req.CanaryPercent < 0 || req.CanaryPercent > 100 {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "canary_percent 取值应为 1-100"})
+			return
+		}
 		if err := opts.Store.AddChannelGroupMemberChannel(c.Request.Context(), parentID, req.ChannelID, 0, ch.Promotion); err != nil {
 			c.JSON(http.StatusOK, gin.H{"success": false, "message": err.Error()})
 			return
 		}
+		if req.CanaryPercent > 0 {
+			memberID, err := opts.Store.GetChannelGroupChannelMemberID(c.Request.Context(), parentID, req.ChannelID)
+			if err == nil {
+				err = opts.Store.StartChannelGroupMemberCanary(c.Request.Context(), parentID, memberID, req.CanaryPercent)
+			}
+			if err != nil {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": "已添加，但开启灰度失败"})
+				return
+			}
+		}
 		c.JSON(http.StatusOK, gin.H{"success": true, "message": "已添加"})
 	}
 }
@@ -648,6 +706,47 @@ func adminReorderChannelGroupMembersHandler(opts Options) gin.HandlerFunc {
 	}
 }
 
+func adminUpdateChannelGroupMemberScheduleHandler(opts Options) gin.HandlerFunc {
+	type reqBody struct {
+		Schedule json.RawMessage `json:"schedule"`
+	}
+	return func(c *gin.Context) {
+		if opts.Store == nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "store 未初始化"})
+			return
+		}
+		groupID, err := strconv.ParseInt(strings.TrimSpace(c.Param("group_id")), 10, 64)
+		if err != nil || groupID <= 0 {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "group_id 不合法"})
+			return
+		}
+		memberID, err := strconv.ParseInt(strings.TrimSpace(c.Param("member_id")), 10, 64)
+		if err != nil || memberID <= 0 {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "member_id 不合法"})
+			return
+		}
+		var req reqBody
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "无效的参数"})
+			return
+		}
+		schedule, err := scheduleFromRequest(req.Schedule)
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "schedule 不合法: " + err.Error()})
+			return
+		}
+		if err := opts.Store.UpdateChannelGroupMemberSchedule(c.Request.Context(), groupID, memberID, schedule); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": "成员不存在"})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "保存失败"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "已保存"})
+	}
+}
+
 func channelGroupBreadcrumb(ctx context.Context, st *store.Store, groupID int64) ([]adminChannelGroupView, error) {
 	if groupID == 0 {
 		return nil, errors.New("groupID 不能为空")
@@ -33,6 +33,9 @@ func setAdminMainGroupAPIRoutes(r gin.IRoutes, opts Options) {
 
 	r.GET("/main-groups/:group_name/subgroups", adminListMainGroupSubgroupsHandler(opts))
 	r.PUT("/main-groups/:group_name/subgroups", adminReplaceMainGroupSubgroupsHandler(opts))
+
+	r.GET("/main-groups/:group_name/rate-limit", adminGetMainGroupRateLimitHandler(opts))
+	r.PUT("/main-groups/:group_name/rate-limit", adminUpdateMainGroupRateLimitHandler(opts))
 }
 
 func adminListMainGroupsHandler(opts Options) gin.HandlerFunc {
@@ -0,0 +1,390 @@
+package router
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+
+	"realms/internal/store"
+)
+
+type adminOrganizationView struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Status      int    `json:"status"`
+	MemberCount int64  `json:"member_count"`
+	BalanceUSD  string `json:"balance_usd"`
+	CreatedAt   string `json:"created_at"`
+}
+
+type adminOrganizationDetailResponse struct {
+	Organization  organizationView               `json:"organization"`
+	Members       []organizationMemberView       `json:"members"`
+	Subscriptions []organizationSubscriptionView `json:"subscriptions"`
+}
+
+func setAdminOrganizationAPIRoutes(r gin.IRoutes, opts Options) {
+	r.GET("/orgs", adminListOrganizationsHandler(opts))
+	r.POST("/orgs", adminCreateOrganizationHandler(opts))
+	r.GET("/orgs/:org_id", adminGetOrganizationHandler(opts))
+	r.PUT("/orgs/:org_id", adminUpdateOrganizationHandler(opts))
+	r.POST("/orgs/:org_id/members", adminAddOrganizationMemberHandler(opts))
+	r.PUT("/orgs/:org_id/members/:user_id", adminUpdateOrganizationMemberHandler(opts))
+	r.DELETE("/orgs/:org_id/members/:user_id", adminRemoveOrganizationMemberHandler(opts))
+	r.POST("/orgs/:org_id/balance", adminAddOrganizationBalanceHandler(opts))
+	r.POST("/orgs/:org_id/subscriptions", adminGrantOrganizationSubscriptionHandler(opts))
+	r.GET("/orgs/:org_id/balance-transactions", adminOrganizationBalanceTransactionsHandler(opts))
+}
+
+// adminOrganizationFromParam 解析 :org_id 并加载组织；失败时已写入响应。
+func adminOrganizationFromParam(c *gin.Context, opts Options) (store.Organization, bool) {
+	if opts.Store == nil {
+		c.JSON(http.StatusOK, gin.H{"success": false, "message": "store 未初始化"})
+		return store.Organization{}, false
+	}
+	if adminUsersFeatureDisabled(c, opts) {
+		return store.Organization{}, false
+	}
+	orgID, err := strconv.ParseInt(strings.TrimSpace(c.Param("org_id")), 10, 64)
+	if err != nil || orgID <= 0 {
+		c.JSON(http.StatusOK, gin.H{"success": false, "message": "org_id 不合法"})
+		return store.Organization{}, false
+	}
+	org, err := opts.Store.GetOrganization(c.Request.Context(), orgID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "组织不存在"})
+			return store.Organization{}, false
+		}
+		c.JSON(http.StatusOK, gin.H{"success": false, "message": "查询组织失败"})
+		return store.Organization{}, false
+	}
+	return org, true
+}
+
+// adminLookupUser 按 user_id 或 email 查找用户；失败时已写入响应。
+func adminLookupUser(c *gin.Context, opts Options, userID int64, email string) (store.User, bool) {
+	var (
+		u   store.User
+		err error
+	)
+	switch {
+	case userID > 0:
+		u, err = opts.Store.GetUserByID(c.Request.Context(), userID)
+	case strings.TrimSpace(email) != "":
+		u, err = opts.Store.GetUserByEmail(c.Request.Context(), strings.TrimSpace(email))
+	default:
+		c.JSON(http.StatusOK, gin.H{"success": false, "message": "user_id 或 email 不能为空"})
+		return store.User{}, false
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "用户不存在"})
+			return store.User{}, false
+		}
+		c.JSON(http.StatusOK, gin.H{"success": false, "message": "查询用户失败"})
+		return store.User{}, false
+	}
+	return u, true
+}
+
+func adminListOrganizationsHandler(opts Options) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if opts.Store == nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "store 未初始化"})
+			return
+		}
+		if adminUsersFeatureDisabled(c, opts) {
+			return
+		}
+		orgs, err := opts.Store.ListOrganizations(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "查询失败"})
+			return
+		}
+		out := make([]adminOrganizationView, 0, len(orgs))
+		for _, o := range orgs {
+			out = append(out, adminOrganizationView{
+				ID:          o.ID,
+				Name:        o.Name,
+				Status:      o.Status,
+				MemberCount: o.MemberCount,
+				BalanceUSD:  formatUSDPlain(o.BalanceUSD),
+				CreatedAt:   o.CreatedAt.Format("2006-01-02 15:04"),
+			})
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "", "data": out})
+	}
+}
+
+func adminCreateOrganizationHandler(opts Options) gin.HandlerFunc {
+	type reqBody struct {
+		Name        string `json:"name"`
+		OwnerUserID int64  `json:"owner_user_id"`
+		OwnerEmail  string `json:"owner_email"`
+	}
+	return func(c *gin.Context) {
+		if opts.Store == nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "store 未初始化"})
+			return
+		}
+		if adminUsersFeatureDisabled(c, opts) {
+			return
+		}
+		var req reqBody
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "无效的参数"})
+			return
+		}
+		owner, ok := adminLookupUser(c, opts, req.OwnerUserID, req.OwnerEmail)
+		if !ok {
+			return
+		}
+		orgID, err := opts.Store.CreateOrganization(c.Request.Context(), req.Name, owner.ID)
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "创建失败：" + err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "已创建", "data": gin.H{"id": orgID}})
+	}
+}
+
+func adminGetOrganizationHandler(opts Options) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		org, ok := adminOrganizationFromParam(c, opts)
+		if !ok {
+			return
+		}
+		ctx := c.Request.Context()
+		view, err := newOrganizationView(ctx, opts, org)
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "查询组织余额失败"})
+			return
+		}
+		members, err := listOrganizationMemberViews(ctx, opts, org.ID)
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "查询组织成员失败"})
+			return
+		}
+		subs, err := listOrganizationSubscriptionViews(ctx, opts, org.ID)
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "查询组织订阅失败"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "", "data": adminOrganizationDetailResponse{
+			Organization:  view,
+			Members:       members,
+			Subscriptions: subs,
+		}})
+	}
+}
+
+func adminUpdateOrganizationHandler(opts Options) gin.HandlerFunc {
+	type reqBody struct {
+		Name   *string `json:"name,omitempty"`
+		Status *int    `json:"status,omitempty"`
+	}
+	return func(c *gin.Context) {
+		org, ok := adminOrganizationFromParam(c, opts)
+		if !ok {
+			return
+		}
+		var req reqBody
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "无效的参数"})
+			return
+		}
+		name := org.Name
+		if req.Name != nil {
+			name = *req.Name
+		}
+		status := org.Status
+		if req.Status != nil {
+			status = *req.Status
+		}
+		if err := opts.Store.UpdateOrganization(c.Request.Context(), org.ID, name, status); err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "保存失败：" + err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "已保存"})
+	}
+}
+
+func adminAddOrganizationMemberHandler(opts Options) gin.HandlerFunc {
+	type reqBody struct {
+		UserID int64  `json:"user_id"`
+		Email  string `json:"email"`
+		Role   string `json:"role"`
+	}
+	return func(c *gin.Context) {
+		org, ok := adminOrganizationFromParam(c, opts)
+		if !ok {
+			return
+		}
+		var req reqBody
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "无效的参数"})
+			return
+		}
+		u, ok := adminLookupUser(c, opts, req.UserID, req.Email)
+		if !ok {
+			return
+		}
+		if err := opts.Store.AddOrganizationMember(c.Request.Context(), org.ID, u.ID, req.Role); err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": organizationMemberErrorMessage(err, "添加成员失败："+err.Error())})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "已添加"})
+	}
+}
+
+func adminUpdateOrganizationMemberHandler(opts Options) gin.HandlerFunc {
+	type reqBody struct {
+		Role             *string `json:"role,omitempty"`
+		SpendLimit30DUSD *string `json:"spend_limit_30d_usd,omitempty"`
+	}
+	return func(c *gin.Context) {
+		org, ok := adminOrganizationFromParam(c, opts)
+		if !ok {
+			return
+		}
+		userID, err := strconv.ParseInt(strings.TrimSpace(c.Param("user_id")), 10, 64)
+		if err != nil || userID <= 0 {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "user_id 不合法"})
+			return
+		}
+		var req reqBody
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "无效的参数"})
+			return
+		}
+		target, err := opts.Store.GetOrganizationMember(c.Request.Context(), org.ID, userID)
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": organizationMemberErrorMessage(err, "查询成员失败")})
+			return
+		}
+		role := target.Role
+		if req.Role != nil {
+			role = *req.Role
+		}
+		limit := target.SpendLimit30DUSD
+		if req.SpendLimit30DUSD != nil {
+			limit, err = parseOrganizationSpendLimit(*req.SpendLimit30DUSD)
+			if err != nil {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": err.Error()})
+				return
+			}
+		}
+		if err := opts.Store.UpdateOrganizationMember(c.Request.Context(), org.ID, userID, role, limit); err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": organizationMemberErrorMessage(err, "保存失败："+err.Error())})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "已保存"})
+	}
+}
+
+func adminRemoveOrganizationMemberHandler(opts Options) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		org, ok := adminOrganizationFromParam(c, opts)
+		if !ok {
+			return
+		}
+		userID, err := strconv.ParseInt(strings.TrimSpace(c.Param("user_id")), 10, 64)
+		if err != nil || userID <= 0 {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "user_id 不合法"})
+			return
+		}
+		if err := opts.Store.RemoveOrganizationMember(c.Request.Context(), org.ID, userID); err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": organizationMemberErrorMessage(err, "移除失败")})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "已移除"})
+	}
+}
+
+func adminAddOrganizationBalanceHandler(opts Options) gin.HandlerFunc {
+	type reqBody struct {
+		AmountUSD string `json:"amount_usd"`
+		Note      string `json:"note"`
+	}
+	return func(c *gin.Context) {
+		org, ok := adminOrganizationFromParam(c, opts)
+		if !ok {
+			return
+		}
+		var req reqBody
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "无效的参数"})
+			return
+		}
+		amountUSD, err := parseUSD(req.AmountUSD)
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		if amountUSD.LessThanOrEqual(decimal.Zero) {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "金额必须大于 0"})
+			return
+		}
+		actorID, _ := userIDFromContext(c)
+		newBal, err := opts.Store.AddOrganizationBalanceUSDByAdmin(c.Request.Context(), org.ID, actorID, amountUSD, req.Note)
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "入账失败：" + err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "已入账",
+			"data": gin.H{
+				"balance_usd": formatUSDPlain(newBal),
+			},
+		})
+	}
+}
+
+func adminGrantOrganizationSubscriptionHandler(opts Options) gin.HandlerFunc {
+	type reqBody struct {
+		PlanID int64 `json:"plan_id"`
+	}
+	return func(c *gin.Context) {
+		org, ok := adminOrganizationFromParam(c, opts)
+		if !ok {
+			return
+		}
+		var req reqBody
+		if err := c.ShouldBindJSON(&req); err != nil || req.PlanID <= 0 {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "plan_id 不合法"})
+			return
+		}
+		sub, err := opts.Store.GrantOrganizationSubscription(c.Request.Context(), org.ID, req.PlanID, time.Now())
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": "订阅套餐不存在"})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "开通失败：" + err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "已开通", "data": gin.H{
+			"id":       sub.ID,
+			"start_at": sub.StartAt.Format("2006-01-02 15:04"),
+			"end_at":   sub.EndAt.Format("2006-01-02 15:04"),
+		}})
+	}
+}
+
+func adminOrganizationBalanceTransactionsHandler(opts Options) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		org, ok := adminOrganizationFromParam(c, opts)
+		if !ok {
+			return
+		}
+		writeBalanceTransactions(c, orgBalanceTransactionsLister(opts, org.ID))
+	}
+}
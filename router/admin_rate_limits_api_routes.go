@@ -0,0 +1,133 @@
+package router
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"realms/internal/store"
+)
+
+type adminRateLimitView struct {
+	RPMLimit int64 `json:"rpm_limit"`
+	TPMLimit int64 `json:"tpm_limit"`
+}
+
+type adminRateLimitRequest struct {
+	RPMLimit int64 `json:"rpm_limit"`
+	TPMLimit int64 `json:"tpm_limit"`
+}
+
+func writeAdminRateLimitError(c *gin.Context, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Not Found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": false, "message": err.Error()})
+}
+
+// adminGetMainGroupRateLimitHandler 返回用户分组的用户级 RPM/TPM 上限（0 表示不限制）。
+func adminGetMainGroupRateLimitHandler(opts Options) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if opts.Store == nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "store 未初始化"})
+			return
+		}
+		if adminUsersFeatureDisabled(c, opts) {
+			return
+		}
+		name := strings.TrimSpace(c.Param("group_name"))
+		if name == "" {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "group_name 不能为空"})
+			return
+		}
+		limit, err := opts.Store.GetMainGroupRateLimit(c.Request.Context(), name)
+		if err != nil {
+			writeAdminRateLimitError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "", "data": adminRateLimitView{RPMLimit: limit.RPM, TPMLimit: limit.TPM}})
+	}
+}
+
+func adminUpdateMainGroupRateLimitHandler(opts Options) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if opts.Store == nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "store 未初始化"})
+			return
+		}
+		if adminUsersFeatureDisabled(c, opts) {
+			return
+		}
+		name := strings.TrimSpace(c.Param("group_name"))
+		if name == "" {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "group_name 不能为空"})
+			return
+		}
+		var req adminRateLimitRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "无效的参数"})
+			return
+		}
+		if err := opts.Store.UpdateMainGroupRateLimit(c.Request.Context(), name, store.RateLimit{RPM: req.RPMLimit, TPM: req.TPMLimit}); err != nil {
+			writeAdminRateLimitError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "已保存"})
+	}
+}
+
+// adminGetSubscriptionPlanRateLimitHandler 返回订阅套餐的用户级 RPM/TPM 上限；套餐生效期间覆盖用户分组的同名配置。
+func adminGetSubscriptionPlanRateLimitHandler(opts Options) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if opts.Store == nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "store 未初始化"})
+			return
+		}
+		if adminBillingFeatureDisabled(c, opts) {
+			return
+		}
+		planID, err := strconv.ParseInt(strings.TrimSpace(c.Param("plan_id")), 10, 64)
+		if err != nil || planID <= 0 {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "plan_id 不合法"})
+			return
+		}
+		limit, err := opts.Store.GetSubscriptionPlanRateLimit(c.Request.Context(), planID)
+		if err != nil {
+			writeAdminRateLimitError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "", "data": adminRateLimitView{RPMLimit: limit.RPM, TPMLimit: limit.TPM}})
+	}
+}
+
+func adminUpdateSubscriptionPlanRateLimitHandler(opts Options) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if opts.Store == nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "store 未初始化"})
+			return
+		}
+		if adminBillingFeatureDisabled(c, opts) {
+			return
+		}
+		planID, err := strconv.ParseInt(strings.TrimSpace(c.Param("plan_id")), 10, 64)
+		if err != nil || planID <= 0 {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "plan_id 不合法"})
+			return
+		}
+		var req adminRateLimitRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "无效的参数"})
+			return
+		}
+		if err := opts.Store.UpdateSubscriptionPlanRateLimit(c.Request.Context(), planID, store.RateLimit{RPM: req.RPMLimit, TPM: req.TPMLimit}); err != nil {
+			writeAdminRateLimitError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "已保存"})
+	}
+}
@@ -0,0 +1,72 @@
+package router
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	openaiapi "realms/internal/api/openai"
+)
+
+func setAdminRoutingAPIRoutes(r gin.IRoutes, opts Options) {
+	r.POST("/routing/explain", adminRoutingExplainHandler(opts))
+}
+
+// adminRoutingExplainHandler 以指定 token 的身份对 model/endpoint 做一次 dry-run 路由，
+// 返回候选顺序、各渠道/endpoint/credential 的跳过原因与最终选择；不会改变任何调度运行态。
+func adminRoutingExplainHandler(opts Options) gin.HandlerFunc {
+	type reqBody struct {
+		TokenID  int64  `json:"token_id"`
+		Model    string `json:"model"`
+		Endpoint string `json:"endpoint"`
+		RouteKey string `json:"route_key"`
+	}
+	return func(c *gin.Context) {
+		if opts.Store == nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "store 未初始化"})
+			return
+		}
+		if opts.OpenAI == nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "数据面未初始化"})
+			return
+		}
+		var req reqBody
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "无效的参数"})
+			return
+		}
+		if req.TokenID <= 0 {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "token_id 不合法"})
+			return
+		}
+		endpoint := strings.TrimSpace(req.Endpoint)
+		if endpoint == "" {
+			endpoint = "/v1/responses"
+		}
+
+		ta, err := opts.Store.GetTokenAuthByTokenID(c.Request.Context(), req.TokenID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": "Token 不存在或已禁用"})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "查询失败"})
+			return
+		}
+		trace, err := opts.OpenAI.ExplainRoute(c.Request.Context(), openaiapi.RouteExplainInput{
+			UserID:   ta.UserID,
+			Groups:   ta.Groups,
+			Model:    req.Model,
+			Endpoint: endpoint,
+			RouteKey: req.RouteKey,
+		})
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "", "data": trace})
+	}
+}
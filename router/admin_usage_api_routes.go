@@ -3,6 +3,7 @@ package router
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/url"
@@ -45,6 +46,14 @@ type adminUsageUserView struct {
 	ReservedUSD  string `json:"reserved_usd"`
 }
 
+type adminUsageOrgView struct {
+	OrgID        int64  `json:"org_id"`
+	Name         string `json:"name"`
+	Requests     int64  `json:"requests"`
+	CommittedUSD string `json:"committed_usd"`
+	ReservedUSD  string `json:"reserved_usd"`
+}
+
 type adminUsageEventView struct {
 	ID                  int64  `json:"id"`
 	Time                string `json:"time"`
@@ -86,6 +95,7 @@ type adminUsagePageResponse struct {
 
 	Window   adminUsageWindowView  `json:"window"`
 	TopUsers []adminUsageUserView  `json:"top_users"`
+	TopOrgs  []adminUsageOrgView   `json:"top_orgs"`
 	Events   []adminUsageEventView `json:"events"`
 
 	NextBeforeID *int64 `json:"next_before_id,omitempty"`
@@ -483,6 +493,27 @@ func adminUsagePageHandler(opts Options) gin.HandlerFunc {
 			})
 		}
 
+		topOrgs, err := opts.Store.ListUsageTopOrganizations(c.Request.Context(), store.UsageTopUsersInput{
+			Since: since,
+			Until: until,
+			Now:   now,
+			Limit: 50,
+		})
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "组织用量汇总失败"})
+			return
+		}
+		topOrgViews := make([]adminUsageOrgView, 0, len(topOrgs))
+		for _, row := range topOrgs {
+			topOrgViews = append(topOrgViews, adminUsageOrgView{
+				OrgID:        row.OrgID,
+				Name:         row.Name,
+				Requests:     row.Requests,
+				CommittedUSD: formatUSDPlain(row.CommittedUSD),
+				ReservedUSD:  formatUSDPlain(row.ReservedUSD),
+			})
+		}
+
 		var beforeID *int64
 		if v := strings.TrimSpace(q.Get("before_id")); v != "" {
 			id, err := strconv.ParseInt(v, 10, 64)
@@ -781,6 +812,7 @@ func adminUsagePageHandler(opts Options) gin.HandlerFunc {
 			Limit:         limit,
 			Window:        window,
 			TopUsers:      topViews,
+			TopOrgs:       topOrgViews,
 			Events:        eventViews,
 			NextBeforeID:  nextBeforeID,
 			PrevAfterID:   prevAfterID,
@@ -822,10 +854,16 @@ func adminUsageEventDetailHandler(opts Options) gin.HandlerFunc {
 			return
 		}
 
+		var routingTrace json.RawMessage
+		if raw, err := opts.Store.GetUsageEventRoutingTrace(c.Request.Context(), id); err == nil && json.Valid([]byte(raw)) {
+			routingTrace = json.RawMessage(raw)
+		}
+
 		c.JSON(http.StatusOK, gin.H{"success": true, "message": "", "data": usageEventDetailAPIResponse{
 			EventID:          id,
 			PricingBreakdown: &pricingBreakdown,
 			ModelCheck:       buildUsageEventModelCheck(ev),
+			RoutingTrace:     routingTrace,
 		}})
 	}
 }
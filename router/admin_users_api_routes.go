@@ -6,7 +6,6 @@ import (
 	"net/mail"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/shopspring/decimal"
@@ -383,15 +382,12 @@ func adminAddUserBalanceHandler(opts Options) gin.HandlerFunc {
 			return
 		}
 
-		start := time.Now()
-		newBal, err := opts.Store.AddUserBalanceUSD(c.Request.Context(), userID, amountUSD)
-		_ = start // reserved for audit latency in the future.
+		actorID, _ := userIDFromContext(c)
+		newBal, err := opts.Store.AddUserBalanceUSDByAdmin(c.Request.Context(), userID, actorID, amountUSD, req.Note)
 		if err != nil {
 			c.JSON(http.StatusOK, gin.H{"success": false, "message": "入账失败：" + err.Error()})
 			return
 		}
-		_ = strings.TrimSpace(req.Note) // note currently best-effort: reserved for audit.
-
 		c.JSON(http.StatusOK, gin.H{
 			"success": true,
 			"message": "已入账",
@@ -85,7 +85,7 @@ func requireUserSession(opts Options) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID, ok := sessionUserID(c)
 		if !ok {
-			clearSession(c)
+			clearSession(c, opts)
 			c.JSON(http.StatusOK, gin.H{"success": false, "message": "未登录"})
 			c.Abort()
 			return
@@ -101,7 +101,7 @@ func requireUserSession(opts Options) gin.HandlerFunc {
 		}
 
 		if opts.Store == nil {
-			clearSession(c)
+			clearSession(c, opts)
 			c.JSON(http.StatusOK, gin.H{"success": false, "message": "store 未初始化"})
 			c.Abort()
 			return
@@ -109,7 +109,7 @@ func requireUserSession(opts Options) gin.HandlerFunc {
 
 		u, err := opts.Store.GetUserByID(c.Request.Context(), userID)
 		if err != nil || u.ID <= 0 || u.Status != 1 {
-			clearSession(c)
+			clearSession(c, opts)
 			c.JSON(http.StatusOK, gin.H{"success": false, "message": "未登录"})
 			c.Abort()
 			return
@@ -117,7 +117,7 @@ func requireUserSession(opts Options) gin.HandlerFunc {
 
 		// 当用户关键字段更新（例如邮箱/密码/角色/状态）后，强制旧会话失效，避免“已登出但 cookie 仍有效”。
 		if staleSession(c, u) {
-			clearSession(c)
+			clearSession(c, opts)
 			c.JSON(http.StatusOK, gin.H{"success": false, "message": "会话已失效，请重新登录"})
 			c.Abort()
 			return
@@ -138,7 +138,7 @@ func requireRootSession(opts Options) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID, ok := sessionUserID(c)
 		if !ok {
-			clearSession(c)
+			clearSession(c, opts)
 			c.JSON(http.StatusOK, gin.H{"success": false, "message": "未登录"})
 			c.Abort()
 			return
@@ -153,7 +153,7 @@ func requireRootSession(opts Options) gin.HandlerFunc {
 		}
 
 		if opts.Store == nil {
-			clearSession(c)
+			clearSession(c, opts)
 			c.JSON(http.StatusOK, gin.H{"success": false, "message": "store 未初始化"})
 			c.Abort()
 			return
@@ -161,7 +161,7 @@ func requireRootSession(opts Options) gin.HandlerFunc {
 
 		u, err := opts.Store.GetUserByID(c.Request.Context(), userID)
 		if err != nil || u.ID <= 0 || u.Status != 1 {
-			clearSession(c)
+			clearSession(c, opts)
 			c.JSON(http.StatusOK, gin.H{"success": false, "message": "未登录"})
 			c.Abort()
 			return
@@ -172,7 +172,7 @@ func requireRootSession(opts Options) gin.HandlerFunc {
 			return
 		}
 		if staleSession(c, u) {
-			clearSession(c)
+			clearSession(c, opts)
 			c.JSON(http.StatusOK, gin.H{"success": false, "message": "会话已失效，请重新登录"})
 			c.Abort()
 			return
@@ -241,12 +241,12 @@ func staleSession(c *gin.Context, u store.User) bool {
 	return u.UpdatedAt.UTC().Unix() > unix
 }
 
-func clearSession(c *gin.Context) {
+func clearSession(c *gin.Context, opts Options) {
 	if c == nil {
 		return
 	}
 	sess := sessions.Default(c)
-	applySessionCookieOptions(sess, c.Request)
+	applySessionCookieOptions(sess, c.Request, opts)
 	sess.Clear()
 	_ = sess.Save()
 }
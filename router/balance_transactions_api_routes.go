@@ -0,0 +1,188 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"realms/internal/store"
+)
+
+type balanceTransactionView struct {
+	ID               int64     `json:"id"`
+	Type             string    `json:"type"`
+	AmountUSD        string    `json:"amount_usd"`
+	BalanceAfterUSD  string    `json:"balance_after_usd"`
+	UsageEventID     *int64    `json:"usage_event_id,omitempty"`
+	TopupOrderID     *int64    `json:"topup_order_id,omitempty"`
+	RedemptionCodeID *int64    `json:"redemption_code_id,omitempty"`
+	ActorUserID      *int64    `json:"actor_user_id,omitempty"`
+	Note             *string   `json:"note,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+type balanceTransactionsResponse struct {
+	Transactions []balanceTransactionView `json:"transactions"`
+	NextBeforeID *int64                   `json:"next_before_id,omitempty"`
+}
+
+type adminBalanceLedgerMismatchView struct {
+	UserID     int64  `json:"user_id"`
+	OrgID      int64  `json:"org_id,omitempty"`
+	BalanceUSD string `json:"balance_usd"`
+	LedgerUSD  string `json:"ledger_usd"`
+	DiffUSD    string `json:"diff_usd"`
+}
+
+func setBalanceTransactionAPIRoutes(r gin.IRoutes, opts Options) {
+	authn := requireUserSession(opts)
+	r.GET("/billing/balance/transactions", authn, balanceTransactionsHandler(opts))
+}
+
+func setAdminBalanceTransactionAPIRoutes(r gin.IRoutes, opts Options) {
+	r.GET("/users/:user_id/balance-transactions", adminUserBalanceTransactionsHandler(opts))
+	r.GET("/billing/balance-reconciliation", adminBalanceReconciliationHandler(opts))
+}
+
+// parseBalanceTransactionsPage 解析 limit/before_id 分页参数；解析失败时已写入响应。
+func parseBalanceTransactionsPage(c *gin.Context) (int, *int64, bool) {
+	limit := 50
+	if v := strings.TrimSpace(c.Query("limit")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "limit 不合法"})
+			return 0, nil, false
+		}
+		limit = n
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > 200 {
+		limit = 200
+	}
+	var beforeID *int64
+	if v := strings.TrimSpace(c.Query("before_id")); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "before_id 不合法"})
+			return 0, nil, false
+		}
+		beforeID = &id
+	}
+	return limit, beforeID, true
+}
+
+// balanceTransactionsLister 按 limit/before_id 分页查询某个余额账户（用户或组织）的流水。
+type balanceTransactionsLister func(ctx context.Context, limit int, beforeID *int64) ([]store.BalanceTransaction, error)
+
+func userBalanceTransactionsLister(opts Options, userID int64) balanceTransactionsLister {
+	return func(ctx context.Context, limit int, beforeID *int64) ([]store.BalanceTransaction, error) {
+		return opts.Store.ListBalanceTransactionsByUser(ctx, userID, limit, beforeID)
+	}
+}
+
+func writeBalanceTransactions(c *gin.Context, list balanceTransactionsLister) {
+	limit, beforeID, ok := parseBalanceTransactionsPage(c)
+	if !ok {
+		return
+	}
+	txs, err := list(c.Request.Context(), limit, beforeID)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"success": false, "message": "查询失败"})
+		return
+	}
+	out := balanceTransactionsResponse{Transactions: make([]balanceTransactionView, 0, len(txs))}
+	for _, t := range txs {
+		out.Transactions = append(out.Transactions, balanceTransactionView{
+			ID:               t.ID,
+			Type:             t.Type,
+			AmountUSD:        formatUSDPlain(t.AmountUSD),
+			BalanceAfterUSD:  formatUSDPlain(t.BalanceAfterUSD),
+			UsageEventID:     t.UsageEventID,
+			TopupOrderID:     t.TopupOrderID,
+			RedemptionCodeID: t.RedemptionCodeID,
+			ActorUserID:      t.ActorUserID,
+			Note:             t.Note,
+			CreatedAt:        t.CreatedAt,
+		})
+	}
+	if len(txs) == limit {
+		next := txs[len(txs)-1].ID
+		out.NextBeforeID = &next
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "", "data": out})
+}
+
+// balanceTransactionsHandler 返回当前用户的余额流水（按时间倒序，before_id 翻页）。
+func balanceTransactionsHandler(opts Options) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if opts.Store == nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "store 未初始化"})
+			return
+		}
+		if billingFeatureDisabled(c, opts) {
+			return
+		}
+		userID, ok := userIDFromContext(c)
+		if !ok {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "未登录"})
+			return
+		}
+		writeBalanceTransactions(c, userBalanceTransactionsLister(opts, userID))
+	}
+}
+
+func adminUserBalanceTransactionsHandler(opts Options) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if opts.Store == nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "store 未初始化"})
+			return
+		}
+		if adminUsersFeatureDisabled(c, opts) {
+			return
+		}
+		userID, err := strconv.ParseInt(strings.TrimSpace(c.Param("user_id")), 10, 64)
+		if err != nil || userID <= 0 {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "user_id 不合法"})
+			return
+		}
+		writeBalanceTransactions(c, userBalanceTransactionsLister(opts, userID))
+	}
+}
+
+// adminBalanceReconciliationHandler 立即执行一次余额对账，返回流水合计与余额不一致的用户/组织。
+func adminBalanceReconciliationHandler(opts Options) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if opts.Store == nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "store 未初始化"})
+			return
+		}
+		if adminBillingFeatureDisabled(c, opts) {
+			return
+		}
+		mismatches, err := opts.Store.ReconcileBalanceLedger(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "对账失败"})
+			return
+		}
+		out := make([]adminBalanceLedgerMismatchView, 0, len(mismatches))
+		for _, m := range mismatches {
+			out = append(out, adminBalanceLedgerMismatchView{
+				UserID:     m.UserID,
+				OrgID:      m.OrgID,
+				BalanceUSD: formatUSDPlain(m.BalanceUSD),
+				LedgerUSD:  formatUSDPlain(m.LedgerUSD),
+				DiffUSD:    formatUSDPlain(m.DiffUSD()),
+			})
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "", "data": gin.H{
+			"checked_at": time.Now().UTC(),
+			"mismatches": out,
+		}})
+	}
+}
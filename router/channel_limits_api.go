@@ -0,0 +1,125 @@
+package router
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"realms/internal/store"
+)
+
+// credentialLimitsView 为 credential/endpoint 限流配置的展示与提交结构；缺省或 <=0 表示不限制。
+type credentialLimitsView struct {
+	RPMLimit       *int `json:"rpm_limit,omitempty"`
+	TPMLimit       *int `json:"tpm_limit,omitempty"`
+	MaxConcurrency *int `json:"max_concurrency,omitempty"`
+}
+
+func credentialLimitsViewFrom(l store.CredentialLimits) credentialLimitsView {
+	return credentialLimitsView{
+		RPMLimit:       l.RPM,
+		TPMLimit:       l.TPM,
+		MaxConcurrency: l.MaxConcurrency,
+	}
+}
+
+func (v credentialLimitsView) toStore() store.CredentialLimits {
+	return store.CredentialLimits{
+		RPM:            v.RPMLimit,
+		TPM:            v.TPMLimit,
+		MaxConcurrency: v.MaxConcurrency,
+	}
+}
+
+func (v credentialLimitsView) validate() bool {
+	for _, p := range []*int{v.RPMLimit, v.TPMLimit, v.MaxConcurrency} {
+		if p != nil && *p < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func loadChannelLimitsTarget(c *gin.Context, opts Options) (store.UpstreamChannel, store.UpstreamEndpoint, credentialLimitsView, bool) {
+	if opts.Store == nil {
+		c.JSON(http.StatusOK, gin.H{"success": false, "message": "store 未初始化"})
+		return store.UpstreamChannel{}, store.UpstreamEndpoint{}, credentialLimitsView{}, false
+	}
+	channelID, err := strconv.ParseInt(strings.TrimSpace(c.Param("channel_id")), 10, 64)
+	if err != nil || channelID <= 0 {
+		c.JSON(http.StatusOK, gin.H{"success": false, "message": "channel_id 不合法"})
+		return store.UpstreamChannel{}, store.UpstreamEndpoint{}, credentialLimitsView{}, false
+	}
+	ch, err := opts.Store.GetUpstreamChannelByID(c.Request.Context(), channelID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "channel 不存在"})
+			return store.UpstreamChannel{}, store.UpstreamEndpoint{}, credentialLimitsView{}, false
+		}
+		c.JSON(http.StatusOK, gin.H{"success": false, "message": "查询 channel 失败"})
+		return store.UpstreamChannel{}, store.UpstreamEndpoint{}, credentialLimitsView{}, false
+	}
+	ep, err := opts.Store.GetUpstreamEndpointByChannelID(c.Request.Context(), ch.ID)
+	if err != nil || ep.ID <= 0 {
+		c.JSON(http.StatusOK, gin.H{"success": false, "message": "endpoint 不存在"})
+		return store.UpstreamChannel{}, store.UpstreamEndpoint{}, credentialLimitsView{}, false
+	}
+	var req credentialLimitsView
+	if err := json.NewDecoder(c.Request.Body).Decode(&req); err != nil {
+		c.JSON(http.StatusOK, gin.H{"success": false, "message": "无效的参数"})
+		return store.UpstreamChannel{}, store.UpstreamEndpoint{}, credentialLimitsView{}, false
+	}
+	if !req.validate() {
+		c.JSON(http.StatusOK, gin.H{"success": false, "message": "限额不能为负数"})
+		return store.UpstreamChannel{}, store.UpstreamEndpoint{}, credentialLimitsView{}, false
+	}
+	return ch, ep, req, true
+}
+
+// updateChannelLimitsHandler 设置渠道 endpoint 的默认限额，作用于未单独配置限额的 credential/account。
+func updateChannelLimitsHandler(opts Options) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		_, ep, req, ok := loadChannelLimitsTarget(c, opts)
+		if !ok {
+			return
+		}
+		if err := opts.Store.UpdateUpstreamEndpointLimits(c.Request.Context(), ep.ID, req.toStore()); err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "保存失败"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": ""})
+	}
+}
+
+// updateChannelCredentialLimitsHandler 设置单个 credential/account 的限额；未设置的字段继承 endpoint 默认值。
+func updateChannelCredentialLimitsHandler(opts Options, idParam string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ch, ep, req, ok := loadChannelLimitsTarget(c, opts)
+		if !ok {
+			return
+		}
+		credentialID, err := strconv.ParseInt(strings.TrimSpace(c.Param(idParam)), 10, 64)
+		if err != nil || credentialID <= 0 {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": idParam + " 不合法"})
+			return
+		}
+		endpointID, err := opts.Store.GetCredentialEndpointID(c.Request.Context(), ch.Type, credentialID)
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "credential 不存在"})
+			return
+		}
+		if endpointID != ep.ID {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "credential 不属于该渠道"})
+			return
+		}
+		if err := opts.Store.UpdateCredentialLimits(c.Request.Context(), ch.Type, credentialID, req.toStore()); err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "保存失败"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": ""})
+	}
+}
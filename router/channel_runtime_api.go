@@ -2,6 +2,8 @@ package router
 
 import (
 	"context"
+	"fmt"
+	"math"
 	"time"
 )
 
@@ -14,6 +16,16 @@ type channelRuntimeInfo struct {
 	BannedRemaining string `json:"banned_remaining,omitempty"`
 	BanStreak       int    `json:"ban_streak"`
 	BannedActive    bool   `json:"banned_active"`
+
+	// Selections 为进程启动以来调度器选中该渠道的次数；
+	// WeightShare/TrafficShare 为同优先级启用渠道内的配置权重占比与实际选中占比。
+	Selections   int64  `json:"selections"`
+	WeightShare  string `json:"weight_share,omitempty"`
+	TrafficShare string `json:"traffic_share,omitempty"`
+
+	// TTFTEWMAMS/ErrorRateEWMA 为调度器在本进程内观测的首字延迟与错误率 EWMA（延迟感知路由依据）。
+	TTFTEWMAMS    *int64   `json:"ttft_ewma_ms,omitempty"`
+	ErrorRateEWMA *float64 `json:"error_rate_ewma,omitempty"`
 }
 
 type channelModelRuntimeInfo struct {
@@ -25,6 +37,23 @@ type channelModelRuntimeInfo struct {
 	BannedRemaining string `json:"banned_remaining,omitempty"`
 	BanStreak       int    `json:"ban_streak"`
 	BannedActive    bool   `json:"banned_active"`
+
+	TTFTEWMAMS    *int64   `json:"ttft_ewma_ms,omitempty"`
+	ErrorRateEWMA *float64 `json:"error_rate_ewma,omitempty"`
+}
+
+// runtimeLatencyForAPI 将 EWMA 观测转为 API 字段；无观测时均为 nil。
+func runtimeLatencyForAPI(observed bool, ttftMS float64, errorRate float64) (*int64, *float64) {
+	if !observed {
+		return nil, nil
+	}
+	var ttft *int64
+	if ttftMS > 0 {
+		v := int64(math.Round(ttftMS))
+		ttft = &v
+	}
+	rate := math.Round(errorRate*1000) / 1000
+	return ttft, &rate
 }
 
 func channelRuntimeForAPI(ctx context.Context, opts Options, channelID int64, loc *time.Location) channelRuntimeInfo {
@@ -38,6 +67,8 @@ func channelRuntimeForAPI(ctx context.Context, opts Options, channelID int64, lo
 
 	out.FailScore = rt.FailScore
 	out.BanStreak = rt.BanStreak
+	out.Selections = rt.Selections
+	out.TTFTEWMAMS, out.ErrorRateEWMA = runtimeLatencyForAPI(rt.LatencyObserved, rt.TTFTEWMAMS, rt.ErrorRateEWMA)
 	if rt.BannedUntil != nil {
 		out.BannedActive = true
 		out.BannedUntil = formatTimeIn(*rt.BannedUntil, time.RFC3339, loc)
@@ -47,6 +78,49 @@ func channelRuntimeForAPI(ctx context.Context, opts Options, channelID int64, lo
 	return out
 }
 
+// fillChannelTrafficShares 按优先级分组计算启用渠道的配置权重占比与实际分流占比（未配置权重按 1 计）。
+func fillChannelTrafficShares(items []channelAdminListItem) {
+	type tierTotals struct {
+		weight     int64
+		selections int64
+	}
+	effectiveWeight := func(w int) int64 {
+		if w <= 0 {
+			return 1
+		}
+		return int64(w)
+	}
+	totals := make(map[int]*tierTotals)
+	for _, it := range items {
+		if it.Status != 1 {
+			continue
+		}
+		t := totals[it.Priority]
+		if t == nil {
+			t = &tierTotals{}
+			totals[it.Priority] = t
+		}
+		t.weight += effectiveWeight(it.Weight)
+		t.selections += it.Runtime.Selections
+	}
+	for i := range items {
+		it := &items[i]
+		if it.Status != 1 {
+			continue
+		}
+		t := totals[it.Priority]
+		if t == nil {
+			continue
+		}
+		if t.weight > 0 {
+			it.Runtime.WeightShare = fmt.Sprintf("%.1f%%", float64(effectiveWeight(it.Weight))*100/float64(t.weight))
+		}
+		if t.selections > 0 {
+			it.Runtime.TrafficShare = fmt.Sprintf("%.1f%%", float64(it.Runtime.Selections)*100/float64(t.selections))
+		}
+	}
+}
+
 func channelModelRuntimeForAPI(ctx context.Context, opts Options, bindingID int64, loc *time.Location) channelModelRuntimeInfo {
 	out := channelModelRuntimeInfo{Available: opts.Sched != nil}
 	if opts.Sched == nil || bindingID <= 0 {
@@ -58,6 +132,7 @@ func channelModelRuntimeForAPI(ctx context.Context, opts Options, bindingID int6
 
 	out.FailScore = rt.FailScore
 	out.BanStreak = rt.BanStreak
+	out.TTFTEWMAMS, out.ErrorRateEWMA = runtimeLatencyForAPI(rt.LatencyObserved, rt.TTFTEWMAMS, rt.ErrorRateEWMA)
 	if rt.BannedUntil != nil {
 		out.BannedActive = true
 		out.BannedUntil = formatTimeIn(*rt.BannedUntil, time.RFC3339, loc)
@@ -5,6 +5,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -23,7 +24,7 @@ import (
 
 func defaultChannelAPISettings(channelType string) (chatEnabled bool, responsesEnabled bool) {
 	switch strings.TrimSpace(channelType) {
-	case store.UpstreamTypeOpenAICompatible:
+	case store.UpstreamTypeOpenAICompatible, store.UpstreamTypeAzureOpenAI:
 		return true, true
 	case store.UpstreamTypeCodexOAuth:
 		return false, true
@@ -61,6 +62,7 @@ type channelDetailView struct {
 	channelView
 
 	OpenAIOrganization   *string                      `json:"openai_organization,omitempty"`
+	APIVersion           *string                      `json:"api_version,omitempty"`
 	TestModel            *string                      `json:"test_model,omitempty"`
 	Remark               *string                      `json:"remark,omitempty"`
 	AutoBan              bool                         `json:"auto_ban"`
@@ -71,6 +73,10 @@ type channelDetailView struct {
 	ModelSuffixPreserve  string                       `json:"model_suffix_preserve,omitempty"`
 	RequestBodyBlacklist string                       `json:"request_body_blacklist,omitempty"`
 	RequestBodyWhitelist string                       `json:"request_body_whitelist,omitempty"`
+	Schedule             json.RawMessage              `json:"schedule,omitempty"`
+
+	// Limits 为 endpoint 级默认限额（未单独配置的 credential/account 继承）。
+	Limits credentialLimitsView `json:"limits"`
 }
 
 func setChannelAPIRoutes(r gin.IRoutes, opts Options) {
@@ -101,6 +107,7 @@ func setChannelAPIRoutes(r gin.IRoutes, opts Options) {
 	r.GET("/channel/:channel_id/credentials", admin, listChannelCredentialsHandler(opts))
 	r.POST("/channel/:channel_id/credentials", admin, createChannelCredentialHandler(opts))
 	r.DELETE("/channel/:channel_id/credentials/:credential_id", admin, deleteChannelCredentialHandler(opts))
+	r.PUT("/channel/:channel_id/credentials/:credential_id/limits", admin, updateChannelCredentialLimitsHandler(opts, "credential_id"))
 	r.GET("/channel/:channel_id/codex-accounts", admin, listChannelCodexAccountsHandler(opts))
 	r.POST("/channel/:channel_id/codex-oauth/start", admin, startChannelCodexOAuthHandler(opts))
 	r.POST("/channel/:channel_id/codex-oauth/complete", admin, completeChannelCodexOAuthHandler(opts))
@@ -108,6 +115,7 @@ func setChannelAPIRoutes(r gin.IRoutes, opts Options) {
 	r.POST("/channel/:channel_id/codex-accounts/refresh", admin, refreshChannelCodexAccountsHandler(opts))
 	r.POST("/channel/:channel_id/codex-accounts/:account_id/refresh", admin, refreshChannelCodexAccountHandler(opts))
 	r.DELETE("/channel/:channel_id/codex-accounts/:account_id", admin, deleteChannelCodexAccountHandler(opts))
+	r.PUT("/channel/:channel_id/codex-accounts/:account_id/limits", admin, updateChannelCredentialLimitsHandler(opts, "account_id"))
 
 	r.PUT("/channel/:channel_id/meta", admin, updateChannelMetaHandler(opts))
 	r.PUT("/channel/:channel_id/setting", admin, updateChannelSettingHandler(opts))
@@ -117,6 +125,8 @@ func setChannelAPIRoutes(r gin.IRoutes, opts Options) {
 	r.PUT("/channel/:channel_id/request_body_whitelist", admin, updateChannelRequestBodyWhitelistHandler(opts))
 	r.PUT("/channel/:channel_id/request_body_blacklist", admin, updateChannelRequestBodyBlacklistHandler(opts))
 	r.PUT("/channel/:channel_id/status_code_mapping", admin, updateChannelStatusCodeMappingHandler(opts))
+	r.PUT("/channel/:channel_id/schedule", admin, updateChannelScheduleHandler(opts))
+	r.PUT("/channel/:channel_id/limits", admin, updateChannelLimitsHandler(opts))
 
 	r.GET("/channel/test/:channel_id", admin, testChannelHandler(opts))
 }
@@ -167,6 +177,22 @@ func listChannelsHandler(opts Options) gin.HandlerFunc {
 					if creds, err := opts.Store.ListAnthropicCredentialsByEndpoint(c.Request.Context(), ep.ID); err == nil && len(creds) > 0 {
 						view.KeyHint = creds[0].APIKeyHint
 					}
+				case store.UpstreamTypeGemini:
+					if creds, err := opts.Store.ListGeminiCredentialsByEndpoint(c.Request.Context(), ep.ID); err == nil && len(creds) > 0 {
+						view.KeyHint = creds[0].APIKeyHint
+					}
+				case store.UpstreamTypeAzureOpenAI:
+					if creds, err := opts.Store.ListAzureOpenAICredentialsByEndpoint(c.Request.Context(), ep.ID); err == nil && len(creds) > 0 {
+						view.KeyHint = creds[0].APIKeyHint
+					}
+				case store.UpstreamTypeBedrock:
+					if creds, err := opts.Store.ListBedrockCredentialsByEndpoint(c.Request.Context(), ep.ID); err == nil && len(creds) > 0 {
+						view.KeyHint = creds[0].AccessKeyHint
+					}
+				case store.UpstreamTypeVertex:
+					if creds, err := opts.Store.ListVertexCredentialsByEndpoint(c.Request.Context(), ep.ID); err == nil && len(creds) > 0 {
+						view.KeyHint = creds[0].KeyHint
+					}
 				}
 			}
 			out = append(out, view)
@@ -381,6 +407,22 @@ func channelsPageHandler(opts Options) gin.HandlerFunc {
 					if creds, err := opts.Store.ListAnthropicCredentialsByEndpoint(c.Request.Context(), ep.ID); err == nil && len(creds) > 0 {
 						view.KeyHint = creds[0].APIKeyHint
 					}
+				case store.UpstreamTypeGemini:
+					if creds, err := opts.Store.ListGeminiCredentialsByEndpoint(c.Request.Context(), ep.ID); err == nil && len(creds) > 0 {
+						view.KeyHint = creds[0].APIKeyHint
+					}
+				case store.UpstreamTypeAzureOpenAI:
+					if creds, err := opts.Store.ListAzureOpenAICredentialsByEndpoint(c.Request.Context(), ep.ID); err == nil && len(creds) > 0 {
+						view.KeyHint = creds[0].APIKeyHint
+					}
+				case store.UpstreamTypeBedrock:
+					if creds, err := opts.Store.ListBedrockCredentialsByEndpoint(c.Request.Context(), ep.ID); err == nil && len(creds) > 0 {
+						view.KeyHint = creds[0].AccessKeyHint
+					}
+				case store.UpstreamTypeVertex:
+					if creds, err := opts.Store.ListVertexCredentialsByEndpoint(c.Request.Context(), ep.ID); err == nil && len(creds) > 0 {
+						view.KeyHint = creds[0].KeyHint
+					}
 				}
 			}
 
@@ -409,6 +451,7 @@ func channelsPageHandler(opts Options) gin.HandlerFunc {
 				Runtime:     runtime,
 			})
 		}
+		fillChannelTrafficShares(out)
 
 		c.JSON(http.StatusOK, gin.H{
 			"success": true,
@@ -583,8 +626,12 @@ func getChannelHandler(opts Options) gin.HandlerFunc {
 			LastTestOK:        ch.LastTestOK,
 		}
 		ep, err := opts.Store.GetUpstreamEndpointByChannelID(c.Request.Context(), ch.ID)
+		var apiVersion *string
+		var limits credentialLimitsView
 		if err == nil && ep.ID > 0 {
 			view.BaseURL = ep.BaseURL
+			apiVersion = ep.APIVersion
+			limits = credentialLimitsViewFrom(ep.Limits)
 			switch ch.Type {
 			case store.UpstreamTypeOpenAICompatible:
 				if creds, err := opts.Store.ListOpenAICompatibleCredentialsByEndpoint(c.Request.Context(), ep.ID); err == nil && len(creds) > 0 {
@@ -594,12 +641,29 @@ func getChannelHandler(opts Options) gin.HandlerFunc {
 				if creds, err := opts.Store.ListAnthropicCredentialsByEndpoint(c.Request.Context(), ep.ID); err == nil && len(creds) > 0 {
 					view.KeyHint = creds[0].APIKeyHint
 				}
+			case store.UpstreamTypeGemini:
+				if creds, err := opts.Store.ListGeminiCredentialsByEndpoint(c.Request.Context(), ep.ID); err == nil && len(creds) > 0 {
+					view.KeyHint = creds[0].APIKeyHint
+				}
+			case store.UpstreamTypeAzureOpenAI:
+				if creds, err := opts.Store.ListAzureOpenAICredentialsByEndpoint(c.Request.Context(), ep.ID); err == nil && len(creds) > 0 {
+					view.KeyHint = creds[0].APIKeyHint
+				}
+			case store.UpstreamTypeBedrock:
+				if creds, err := opts.Store.ListBedrockCredentialsByEndpoint(c.Request.Context(), ep.ID); err == nil && len(creds) > 0 {
+					view.KeyHint = creds[0].AccessKeyHint
+				}
+			case store.UpstreamTypeVertex:
+				if creds, err := opts.Store.ListVertexCredentialsByEndpoint(c.Request.Context(), ep.ID); err == nil && len(creds) > 0 {
+					view.KeyHint = creds[0].KeyHint
+				}
 			}
 		}
 
 		detail := channelDetailView{
 			channelView:          view,
 			OpenAIOrganization:   ch.OpenAIOrganization,
+			APIVersion:           apiVersion,
 			TestModel:            ch.TestModel,
 			Remark:               ch.Remark,
 			AutoBan:              ch.AutoBan,
@@ -610,6 +674,8 @@ func getChannelHandler(opts Options) gin.HandlerFunc {
 			ModelSuffixPreserve:  ch.ModelSuffixPreserve,
 			RequestBodyBlacklist: ch.RequestBodyBlacklist,
 			RequestBodyWhitelist: ch.RequestBodyWhitelist,
+			Schedule:             scheduleJSON(ch.Schedule),
+			Limits:               limits,
 		}
 		c.JSON(http.StatusOK, gin.H{"success": true, "message": "", "data": detail})
 	}
@@ -620,6 +686,7 @@ type createChannelRequest struct {
 	Name                   string  `json:"name"`
 	Groups                 string  `json:"groups"`
 	BaseURL                string  `json:"base_url"`
+	APIVersion             *string `json:"api_version,omitempty"`
 	Key                    *string `json:"key,omitempty"`
 	Priority               int     `json:"priority"`
 	Promotion              bool    `json:"promotion"`
@@ -629,6 +696,8 @@ type createChannelRequest struct {
 	AllowSafetyIdentifier  bool    `json:"allow_safety_identifier"`
 	ChatCompletionsEnabled *bool   `json:"chat_completions_enabled,omitempty"`
 	ResponsesEnabled       *bool   `json:"responses_enabled,omitempty"`
+	EmbeddingsEnabled      *bool   `json:"embeddings_enabled,omitempty"`
+	MessagesEnabled        *bool   `json:"messages_enabled,omitempty"`
 }
 
 func createChannelHandler(opts Options) gin.HandlerFunc {
@@ -660,7 +729,7 @@ func createChannelHandler(opts Options) gin.HandlerFunc {
 			return
 		}
 		switch req.Type {
-		case store.UpstreamTypeOpenAICompatible, store.UpstreamTypeAnthropic, store.UpstreamTypeCodexOAuth:
+		case store.UpstreamTypeOpenAICompatible, store.UpstreamTypeAnthropic, store.UpstreamTypeGemini, store.UpstreamTypeAzureOpenAI, store.UpstreamTypeBedrock, store.UpstreamTypeVertex, store.UpstreamTypeCodexOAuth:
 		default:
 			c.JSON(http.StatusOK, gin.H{"success": false, "message": "不支持的渠道类型"})
 			return
@@ -669,6 +738,18 @@ func createChannelHandler(opts Options) gin.HandlerFunc {
 			c.JSON(http.StatusOK, gin.H{"success": false, "message": "codex_oauth Channel 不支持 key"})
 			return
 		}
+		if req.Type == store.UpstreamTypeBedrock && req.Key != nil {
+			if _, err := store.ParseBedrockCredentialKey(*req.Key); err != nil {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": err.Error()})
+				return
+			}
+		}
+		if req.Type == store.UpstreamTypeVertex && req.Key != nil {
+			if _, err := store.ParseVertexCredentialKey(*req.Key); err != nil {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": err.Error()})
+				return
+			}
+		}
 		if _, err := security.ValidateBaseURL(req.BaseURL); err != nil {
 			c.JSON(http.StatusOK, gin.H{"success": false, "message": "base_url 不合法"})
 			return
@@ -693,6 +774,13 @@ func createChannelHandler(opts Options) gin.HandlerFunc {
 			c.JSON(http.StatusOK, gin.H{"success": false, "message": "创建 Endpoint 失败"})
 			return
 		}
+		if req.APIVersion != nil && strings.TrimSpace(*req.APIVersion) != "" {
+			if err := opts.Store.SetUpstreamEndpointAPIVersion(c.Request.Context(), ep.ID, req.APIVersion); err != nil {
+				_ = opts.Store.DeleteUpstreamChannel(c.Request.Context(), id)
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": "保存 api_version 失败"})
+				return
+			}
+		}
 		chatEnabled, responsesEnabled := defaultChannelAPISettings(req.Type)
 		if req.ChatCompletionsEnabled != nil {
 			chatEnabled = *req.ChatCompletionsEnabled
@@ -700,9 +788,19 @@ func createChannelHandler(opts Options) gin.HandlerFunc {
 		if req.ResponsesEnabled != nil {
 			responsesEnabled = *req.ResponsesEnabled
 		}
+		embeddingsEnabled := false
+		if req.EmbeddingsEnabled != nil {
+			embeddingsEnabled = *req.EmbeddingsEnabled
+		}
+		messagesEnabled := false
+		if req.MessagesEnabled != nil {
+			messagesEnabled = *req.MessagesEnabled
+		}
 		if err := opts.Store.UpdateUpstreamChannelNewAPISetting(c.Request.Context(), id, store.UpstreamChannelSetting{
 			ChatCompletionsEnabled: chatEnabled,
 			ResponsesEnabled:       responsesEnabled,
+			EmbeddingsEnabled:      embeddingsEnabled,
+			MessagesEnabled:        messagesEnabled,
 		}); err != nil {
 			_ = opts.Store.DeleteUpstreamChannel(c.Request.Context(), id)
 			c.JSON(http.StatusOK, gin.H{"success": false, "message": err.Error()})
@@ -722,6 +820,30 @@ func createChannelHandler(opts Options) gin.HandlerFunc {
 					c.JSON(http.StatusOK, gin.H{"success": false, "message": "创建 Credential 失败"})
 					return
 				}
+			case store.UpstreamTypeGemini:
+				if _, _, err := opts.Store.CreateGeminiCredential(c.Request.Context(), ep.ID, nil, *req.Key); err != nil {
+					_ = opts.Store.DeleteUpstreamChannel(c.Request.Context(), id)
+					c.JSON(http.StatusOK, gin.H{"success": false, "message": "创建 Credential 失败"})
+					return
+				}
+			case store.UpstreamTypeAzureOpenAI:
+				if _, _, err := opts.Store.CreateAzureOpenAICredential(c.Request.Context(), ep.ID, nil, *req.Key); err != nil {
+					_ = opts.Store.DeleteUpstreamChannel(c.Request.Context(), id)
+					c.JSON(http.StatusOK, gin.H{"success": false, "message": "创建 Credential 失败"})
+					return
+				}
+			case store.UpstreamTypeBedrock:
+				if _, _, err := createBedrockCredential(c.Request.Context(), opts.Store, ep.ID, nil, *req.Key); err != nil {
+					_ = opts.Store.DeleteUpstreamChannel(c.Request.Context(), id)
+					c.JSON(http.StatusOK, gin.H{"success": false, "message": "创建 Credential 失败"})
+					return
+				}
+			case store.UpstreamTypeVertex:
+				if _, _, err := createVertexCredential(c.Request.Context(), opts.Store, ep.ID, nil, *req.Key); err != nil {
+					_ = opts.Store.DeleteUpstreamChannel(c.Request.Context(), id)
+					c.JSON(http.StatusOK, gin.H{"success": false, "message": "创建 Credential 失败"})
+					return
+				}
 			}
 		}
 		c.JSON(http.StatusOK, gin.H{"success": true, "message": "", "data": gin.H{"id": id}})
@@ -733,6 +855,7 @@ type updateChannelRequest struct {
 	Name                  *string `json:"name,omitempty"`
 	Groups                *string `json:"groups,omitempty"`
 	BaseURL               *string `json:"base_url,omitempty"`
+	APIVersion            *string `json:"api_version,omitempty"`
 	Key                   *string `json:"key,omitempty"`
 	Status                *int    `json:"status,omitempty"`
 	Priority              *int    `json:"priority,omitempty"`
@@ -874,6 +997,13 @@ func updateChannelHandler(opts Options) gin.HandlerFunc {
 			ep, _ = opts.Store.GetUpstreamEndpointByChannelID(c.Request.Context(), ch.ID)
 		}
 
+		if req.APIVersion != nil && ep.ID > 0 {
+			if err := opts.Store.SetUpstreamEndpointAPIVersion(c.Request.Context(), ep.ID, req.APIVersion); err != nil {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": "更新 api_version 失败"})
+				return
+			}
+		}
+
 		if req.Key != nil && ep.ID > 0 {
 			key := strings.TrimSpace(*req.Key)
 			if key != "" {
@@ -888,6 +1018,26 @@ func updateChannelHandler(opts Options) gin.HandlerFunc {
 						c.JSON(http.StatusOK, gin.H{"success": false, "message": "更新 Credential 失败"})
 						return
 					}
+				case store.UpstreamTypeGemini:
+					if _, _, err := opts.Store.CreateGeminiCredential(c.Request.Context(), ep.ID, nil, key); err != nil {
+						c.JSON(http.StatusOK, gin.H{"success": false, "message": "更新 Credential 失败"})
+						return
+					}
+				case store.UpstreamTypeAzureOpenAI:
+					if _, _, err := opts.Store.CreateAzureOpenAICredential(c.Request.Context(), ep.ID, nil, key); err != nil {
+						c.JSON(http.StatusOK, gin.H{"success": false, "message": "更新 Credential 失败"})
+						return
+					}
+				case store.UpstreamTypeBedrock:
+					if _, _, err := createBedrockCredential(c.Request.Context(), opts.Store, ep.ID, nil, key); err != nil {
+						c.JSON(http.StatusOK, gin.H{"success": false, "message": credentialKeyErrorMessage(err, "更新 Credential 失败")})
+						return
+					}
+				case store.UpstreamTypeVertex:
+					if _, _, err := createVertexCredential(c.Request.Context(), opts.Store, ep.ID, nil, key); err != nil {
+						c.JSON(http.StatusOK, gin.H{"success": false, "message": credentialKeyErrorMessage(err, "更新 Credential 失败")})
+						return
+					}
 				}
 			}
 		}
@@ -902,6 +1052,8 @@ type channelCredentialView struct {
 	APIKeyHint *string `json:"api_key_hint,omitempty"`
 	MaskedKey  string  `json:"masked_key"`
 	Status     int     `json:"status"`
+
+	Limits credentialLimitsView `json:"limits"`
 }
 
 func maskAPIKeyHint(hint *string) string {
@@ -953,6 +1105,8 @@ type channelCodexAccountView struct {
 	QuotaUpdatedAt            *time.Time `json:"quota_updated_at,omitempty"`
 	QuotaError                *string    `json:"quota_error,omitempty"`
 
+	Limits credentialLimitsView `json:"limits"`
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -996,6 +1150,8 @@ func codexAccountView(a store.CodexOAuthAccount) channelCodexAccountView {
 		QuotaUpdatedAt:            a.QuotaUpdatedAt,
 		QuotaError:                a.QuotaError,
 
+		Limits: credentialLimitsViewFrom(a.Limits),
+
 		CreatedAt: a.CreatedAt,
 		UpdatedAt: a.UpdatedAt,
 	}
@@ -1384,6 +1540,7 @@ func listChannelCredentialsHandler(opts Options) gin.HandlerFunc {
 					APIKeyHint: cred.APIKeyHint,
 					MaskedKey:  maskAPIKeyHint(cred.APIKeyHint),
 					Status:     cred.Status,
+					Limits:     credentialLimitsViewFrom(cred.Limits),
 				})
 			}
 		case store.UpstreamTypeAnthropic:
@@ -1400,6 +1557,75 @@ func listChannelCredentialsHandler(opts Options) gin.HandlerFunc {
 					APIKeyHint: cred.APIKeyHint,
 					MaskedKey:  maskAPIKeyHint(cred.APIKeyHint),
 					Status:     cred.Status,
+					Limits:     credentialLimitsViewFrom(cred.Limits),
+				})
+			}
+		case store.UpstreamTypeGemini:
+			creds, err := opts.Store.ListGeminiCredentialsByEndpoint(c.Request.Context(), ep.ID)
+			if err != nil {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": "查询失败"})
+				return
+			}
+			out = make([]channelCredentialView, 0, len(creds))
+			for _, cred := range creds {
+				out = append(out, channelCredentialView{
+					ID:         cred.ID,
+					Name:       cred.Name,
+					APIKeyHint: cred.APIKeyHint,
+					MaskedKey:  maskAPIKeyHint(cred.APIKeyHint),
+					Status:     cred.Status,
+					Limits:     credentialLimitsViewFrom(cred.Limits),
+				})
+			}
+		case store.UpstreamTypeAzureOpenAI:
+			creds, err := opts.Store.ListAzureOpenAICredentialsByEndpoint(c.Request.Context(), ep.ID)
+			if err != nil {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": "查询失败"})
+				return
+			}
+			out = make([]channelCredentialView, 0, len(creds))
+			for _, cred := range creds {
+				out = append(out, channelCredentialView{
+					ID:         cred.ID,
+					Name:       cred.Name,
+					APIKeyHint: cred.APIKeyHint,
+					MaskedKey:  maskAPIKeyHint(cred.APIKeyHint),
+					Status:     cred.Status,
+					Limits:     credentialLimitsViewFrom(cred.Limits),
+				})
+			}
+		case store.UpstreamTypeBedrock:
+			creds, err := opts.Store.ListBedrockCredentialsByEndpoint(c.Request.Context(), ep.ID)
+			if err != nil {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": "查询失败"})
+				return
+			}
+			out = make([]channelCredentialView, 0, len(creds))
+			for _, cred := range creds {
+				out = append(out, channelCredentialView{
+					ID:         cred.ID,
+					Name:       cred.Name,
+					APIKeyHint: cred.AccessKeyHint,
+					MaskedKey:  maskAPIKeyHint(cred.AccessKeyHint),
+					Status:     cred.Status,
+					Limits:     credentialLimitsViewFrom(cred.Limits),
+				})
+			}
+		case store.UpstreamTypeVertex:
+			creds, err := opts.Store.ListVertexCredentialsByEndpoint(c.Request.Context(), ep.ID)
+			if err != nil {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": "查询失败"})
+				return
+			}
+			out = make([]channelCredentialView, 0, len(creds))
+			for _, cred := range creds {
+				out = append(out, channelCredentialView{
+					ID:         cred.ID,
+					Name:       cred.Name,
+					APIKeyHint: cred.KeyHint,
+					MaskedKey:  maskAPIKeyHint(cred.KeyHint),
+					Status:     cred.Status,
+					Limits:     credentialLimitsViewFrom(cred.Limits),
 				})
 			}
 		default:
@@ -1472,6 +1698,34 @@ func createChannelCredentialHandler(opts Options) gin.HandlerFunc {
 				return
 			}
 			c.JSON(http.StatusOK, gin.H{"success": true, "message": "已添加", "data": gin.H{"id": id, "api_key_hint": hint}})
+		case store.UpstreamTypeGemini:
+			id, hint, err := opts.Store.CreateGeminiCredential(c.Request.Context(), ep.ID, name, apiKey)
+			if err != nil {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": "创建失败"})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"success": true, "message": "已添加", "data": gin.H{"id": id, "api_key_hint": hint}})
+		case store.UpstreamTypeAzureOpenAI:
+			id, hint, err := opts.Store.CreateAzureOpenAICredential(c.Request.Context(), ep.ID, name, apiKey)
+			if err != nil {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": "创建失败"})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"success": true, "message": "已添加", "data": gin.H{"id": id, "api_key_hint": hint}})
+		case store.UpstreamTypeBedrock:
+			id, hint, err := createBedrockCredential(c.Request.Context(), opts.Store, ep.ID, name, apiKey)
+			if err != nil {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": credentialKeyErrorMessage(err, "创建失败")})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"success": true, "message": "已添加", "data": gin.H{"id": id, "api_key_hint": hint}})
+		case store.UpstreamTypeVertex:
+			id, hint, err := createVertexCredential(c.Request.Context(), opts.Store, ep.ID, name, apiKey)
+			if err != nil {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": credentialKeyErrorMessage(err, "创建失败")})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"success": true, "message": "已添加", "data": gin.H{"id": id, "api_key_hint": hint}})
 		default:
 			c.JSON(http.StatusOK, gin.H{"success": false, "message": "不支持的渠道类型"})
 			return
@@ -1543,6 +1797,62 @@ func deleteChannelCredentialHandler(opts Options) gin.HandlerFunc {
 				c.JSON(http.StatusOK, gin.H{"success": false, "message": "删除失败"})
 				return
 			}
+		case store.UpstreamTypeGemini:
+			cred, err := opts.Store.GetGeminiCredentialByID(c.Request.Context(), credentialID)
+			if err != nil {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": "credential 不存在"})
+				return
+			}
+			if cred.EndpointID != ep.ID {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": "credential 不属于该渠道"})
+				return
+			}
+			if err := opts.Store.DeleteGeminiCredential(c.Request.Context(), credentialID); err != nil {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": "删除失败"})
+				return
+			}
+		case store.UpstreamTypeAzureOpenAI:
+			cred, err := opts.Store.GetAzureOpenAICredentialByID(c.Request.Context(), credentialID)
+			if err != nil {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": "credential 不存在"})
+				return
+			}
+			if cred.EndpointID != ep.ID {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": "credential 不属于该渠道"})
+				return
+			}
+			if err := opts.Store.DeleteAzureOpenAICredential(c.Request.Context(), credentialID); err != nil {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": "删除失败"})
+				return
+			}
+		case store.UpstreamTypeBedrock:
+			cred, err := opts.Store.GetBedrockCredentialByID(c.Request.Context(), credentialID)
+			if err != nil {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": "credential 不存在"})
+				return
+			}
+			if cred.EndpointID != ep.ID {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": "credential 不属于该渠道"})
+				return
+			}
+			if err := opts.Store.DeleteBedrockCredential(c.Request.Context(), credentialID); err != nil {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": "删除失败"})
+				return
+			}
+		case store.UpstreamTypeVertex:
+			cred, err := opts.Store.GetVertexCredentialByID(c.Request.Context(), credentialID)
+			if err != nil {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": "credential 不存在"})
+				return
+			}
+			if cred.EndpointID != ep.ID {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": "credential 不属于该渠道"})
+				return
+			}
+			if err := opts.Store.DeleteVertexCredential(c.Request.Context(), credentialID); err != nil {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": "删除失败"})
+				return
+			}
 		default:
 			c.JSON(http.StatusOK, gin.H{"success": false, "message": "不支持的渠道类型"})
 			return
@@ -1626,6 +1936,8 @@ func updateChannelSettingHandler(opts Options) gin.HandlerFunc {
 		ThinkingToContent      *bool   `json:"thinking_to_content,omitempty"`
 		ChatCompletionsEnabled *bool   `json:"chat_completions_enabled,omitempty"`
 		ResponsesEnabled       *bool   `json:"responses_enabled,omitempty"`
+		EmbeddingsEnabled      *bool   `json:"embeddings_enabled,omitempty"`
+		MessagesEnabled        *bool   `json:"messages_enabled,omitempty"`
 		Proxy                  *string `json:"proxy,omitempty"`
 		PassThroughBodyEnabled *bool   `json:"pass_through_body_enabled,omitempty"`
 		SystemPrompt           *string `json:"system_prompt,omitempty"`
@@ -1671,6 +1983,12 @@ func updateChannelSettingHandler(opts Options) gin.HandlerFunc {
 		if req.ResponsesEnabled != nil {
 			next.ResponsesEnabled = *req.ResponsesEnabled
 		}
+		if req.EmbeddingsEnabled != nil {
+			next.EmbeddingsEnabled = *req.EmbeddingsEnabled
+		}
+		if req.MessagesEnabled != nil {
+			next.MessagesEnabled = *req.MessagesEnabled
+		}
 		if req.Proxy != nil {
 			next.Proxy = *req.Proxy
 		}
@@ -1942,6 +2260,69 @@ func updateChannelStatusCodeMappingHandler(opts Options) gin.HandlerFunc {
 	}
 }
 
+// scheduleJSON 把已规范化的 schedule 原样作为 JSON 对象输出；未配置时省略。
+func scheduleJSON(raw string) json.RawMessage {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil
+	}
+	return json.RawMessage(raw)
+}
+
+// scheduleFromRequest 兼容 schedule 以 JSON 对象或 JSON 字符串提交；null/空表示清除时间窗。
+func scheduleFromRequest(raw json.RawMessage) (string, error) {
+	v := strings.TrimSpace(string(raw))
+	if strings.HasPrefix(v, `"`) {
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return "", err
+		}
+		v = s
+	}
+	return store.NormalizeChannelSchedule(v)
+}
+
+func updateChannelScheduleHandler(opts Options) gin.HandlerFunc {
+	type reqBody struct {
+		Schedule json.RawMessage `json:"schedule"`
+	}
+	return func(c *gin.Context) {
+		if opts.Store == nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "store 未初始化"})
+			return
+		}
+		channelID, err := strconv.ParseInt(strings.TrimSpace(c.Param("channel_id")), 10, 64)
+		if err != nil || channelID <= 0 {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "channel_id 不合法"})
+			return
+		}
+		ch, err := opts.Store.GetUpstreamChannelByID(c.Request.Context(), channelID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": "channel 不存在"})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "查询 channel 失败"})
+			return
+		}
+		var req reqBody
+		if err := json.NewDecoder(c.Request.Body).Decode(&req); err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "无效的参数"})
+			return
+		}
+		schedule, err := scheduleFromRequest(req.Schedule)
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "schedule 不合法: " + err.Error()})
+			return
+		}
+		if err := opts.Store.UpdateUpstreamChannelSchedule(c.Request.Context(), ch.ID, schedule); err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "保存失败"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "已保存"})
+	}
+}
+
 func deleteChannelHandler(opts Options) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if opts.Store == nil {
@@ -2024,6 +2405,54 @@ func getChannelKeyHandler(opts Options) gin.HandlerFunc {
 				return
 			}
 			c.JSON(http.StatusOK, gin.H{"success": true, "message": "获取成功", "data": gin.H{"key": sec.APIKey}})
+		case store.UpstreamTypeGemini:
+			creds, err := opts.Store.ListGeminiCredentialsByEndpoint(c.Request.Context(), ep.ID)
+			if err != nil || len(creds) == 0 {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": "暂无可用 key"})
+				return
+			}
+			sec, err := opts.Store.GetGeminiCredentialSecret(c.Request.Context(), creds[0].ID)
+			if err != nil {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": "读取 key 失败"})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"success": true, "message": "获取成功", "data": gin.H{"key": sec.APIKey}})
+		case store.UpstreamTypeAzureOpenAI:
+			creds, err := opts.Store.ListAzureOpenAICredentialsByEndpoint(c.Request.Context(), ep.ID)
+			if err != nil || len(creds) == 0 {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": "暂无可用 key"})
+				return
+			}
+			sec, err := opts.Store.GetAzureOpenAICredentialSecret(c.Request.Context(), creds[0].ID)
+			if err != nil {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": "读取 key 失败"})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"success": true, "message": "获取成功", "data": gin.H{"key": sec.APIKey}})
+		case store.UpstreamTypeBedrock:
+			creds, err := opts.Store.ListBedrockCredentialsByEndpoint(c.Request.Context(), ep.ID)
+			if err != nil || len(creds) == 0 {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": "暂无可用 key"})
+				return
+			}
+			sec, err := opts.Store.GetBedrockCredentialSecret(c.Request.Context(), creds[0].ID)
+			if err != nil {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": "读取 key 失败"})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"success": true, "message": "获取成功", "data": gin.H{"key": formatBedrockCredentialKey(sec)}})
+		case store.UpstreamTypeVertex:
+			creds, err := opts.Store.ListVertexCredentialsByEndpoint(c.Request.Context(), ep.ID)
+			if err != nil || len(creds) == 0 {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": "暂无可用 key"})
+				return
+			}
+			sec, err := opts.Store.GetVertexCredentialSecret(c.Request.Context(), creds[0].ID)
+			if err != nil {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": "读取 key 失败"})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"success": true, "message": "获取成功", "data": gin.H{"key": sec.ServiceAccountJSON}})
 		default:
 			c.JSON(http.StatusOK, gin.H{"success": false, "message": "不支持的渠道类型"})
 		}
@@ -2061,12 +2490,54 @@ func buildChannelTestResponse(ok bool, latencyMS int, message string, probe chan
 // CLI Runner 委派（仅返回最终 JSON，不写数据库、不影响调度）
 // ---------------------------------------------------------------------------
 
+// createBedrockCredential 解析 AccessKeyID|SecretAccessKey|Region[|SessionToken] 后写入 bedrock_credentials。
+func createBedrockCredential(ctx context.Context, st *store.Store, endpointID int64, name *string, rawKey string) (int64, *string, error) {
+	in, err := store.ParseBedrockCredentialKey(rawKey)
+	if err != nil {
+		return 0, nil, errCredentialKey{err: err}
+	}
+	return st.CreateBedrockCredential(ctx, endpointID, name, in)
+}
+
+// createVertexCredential 解析 service account JSON 后写入 vertex_credentials。
+func createVertexCredential(ctx context.Context, st *store.Store, endpointID int64, name *string, rawKey string) (int64, *string, error) {
+	in, err := store.ParseVertexCredentialKey(rawKey)
+	if err != nil {
+		return 0, nil, errCredentialKey{err: err}
+	}
+	return st.CreateVertexCredential(ctx, endpointID, name, in)
+}
+
+// errCredentialKey 标记管理端录入的 key 格式错误（bedrock / vertex 等结构化凭证）。
+type errCredentialKey struct{ err error }
+
+func (e errCredentialKey) Error() string { return e.err.Error() }
+
+// credentialKeyErrorMessage 对 key 格式错误返回具体原因，其余错误使用通用文案（避免泄露存储层细节）。
+func credentialKeyErrorMessage(err error, fallback string) string {
+	var keyErr errCredentialKey
+	if errors.As(err, &keyErr) {
+		return keyErr.Error()
+	}
+	return fallback
+}
+
+func formatBedrockCredentialKey(sec store.BedrockCredentialSecret) string {
+	parts := []string{sec.AccessKeyID, sec.SecretAccessKey, sec.Region}
+	if sec.SessionToken != "" {
+		parts = append(parts, sec.SessionToken)
+	}
+	return strings.Join(parts, "|")
+}
+
 func channelTypeToCLIType(chType string) string {
 	switch chType {
 	case store.UpstreamTypeOpenAICompatible:
 		return "codex"
 	case store.UpstreamTypeAnthropic:
 		return "claude"
+	case store.UpstreamTypeGemini:
+		return "gemini"
 	case store.UpstreamTypeCodexOAuth:
 		return "codex_oauth"
 	default:
@@ -2233,6 +2704,16 @@ func runChannelCLITest(ctx context.Context, opts Options, channelID int64) (bool
 			summary := channelTestFailureSummary("未找到可用凭证", total)
 			return finish(false, summary.Message, summary)
 		}
+	case store.UpstreamTypeGemini:
+		if creds, err := st.ListGeminiCredentialsByEndpoint(ctx, ep.ID); err == nil && len(creds) > 0 {
+			if sec, err := st.GetGeminiCredentialSecret(ctx, creds[0].ID); err == nil {
+				apiKey = sec.APIKey
+			}
+		}
+		if apiKey == "" {
+			summary := channelTestFailureSummary("未找到可用凭证", total)
+			return finish(false, summary.Message, summary)
+		}
 	case store.UpstreamTypeCodexOAuth:
 		accounts, err := st.ListCodexOAuthAccountsByEndpoint(ctx, ep.ID)
 		if err != nil {
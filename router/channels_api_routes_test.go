@@ -1366,3 +1366,26 @@ func TestChannels_CodexOAuthAccounts_RootFlow(t *testing.T) {
 		t.Fatalf("expected remaining manual account, got %#v", listResp)
 	}
 }
+
+func TestFillChannelTrafficShares_GroupsByPriorityAndSkipsDisabled(t *testing.T) {
+	items := []channelAdminListItem{
+		{channelView: channelView{ID: 1, Status: 1, Priority: 0, Weight: 3}, Runtime: channelRuntimeInfo{Selections: 30}},
+		{channelView: channelView{ID: 2, Status: 1, Priority: 0, Weight: 0}, Runtime: channelRuntimeInfo{Selections: 10}},
+		{channelView: channelView{ID: 3, Status: 0, Priority: 0, Weight: 5}, Runtime: channelRuntimeInfo{Selections: 99}},
+		{channelView: channelView{ID: 4, Status: 1, Priority: 10, Weight: 2}},
+	}
+	fillChannelTrafficShares(items)
+
+	if items[0].Runtime.WeightShare != "75.0%" || items[0].Runtime.TrafficShare != "75.0%" {
+		t.Fatalf("unexpected shares for channel 1: %+v", items[0].Runtime)
+	}
+	if items[1].Runtime.WeightShare != "25.0%" || items[1].Runtime.TrafficShare != "25.0%" {
+		t.Fatalf("unexpected shares for channel 2: %+v", items[1].Runtime)
+	}
+	if items[2].Runtime.WeightShare != "" || items[2].Runtime.TrafficShare != "" {
+		t.Fatalf("expected disabled channel without shares, got %+v", items[2].Runtime)
+	}
+	if items[3].Runtime.WeightShare != "100.0%" || items[3].Runtime.TrafficShare != "" {
+		t.Fatalf("unexpected shares for channel 4: %+v", items[3].Runtime)
+	}
+}
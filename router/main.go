@@ -23,6 +23,8 @@ func SetRouter(r *gin.Engine, opts Options) {
 	setAccountAPIRoutes(api, opts)
 	setBillingAPIRoutes(api, opts)
 	setRedemptionCodeAPIRoutes(api, opts)
+	setBalanceTransactionAPIRoutes(api, opts)
+	setOrganizationAPIRoutes(api, opts)
 	setTicketAPIRoutes(api, opts)
 	setAdminAPIRoutes(api, opts)
 
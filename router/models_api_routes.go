@@ -31,6 +31,7 @@ type managedModelView struct {
 	PriorityOutputUSDPer1M     *decimal.Decimal                      `json:"priority_output_usd_per_1m,omitempty"`
 	PriorityCacheInputUSDPer1M *decimal.Decimal                      `json:"priority_cache_input_usd_per_1m,omitempty"`
 	HighContextPricing         *store.ManagedModelHighContextPricing `json:"high_context_pricing,omitempty"`
+	BatchDiscount              *decimal.Decimal                      `json:"batch_discount,omitempty"`
 	Status                     int                                   `json:"status"`
 	IconURL                    *string                               `json:"icon_url,omitempty"`
 }
@@ -61,6 +62,25 @@ func (o *optionalManagedModelHighContextPricing) UnmarshalJSON(data []byte) erro
 	return nil
 }
 
+type optionalManagedModelBatchDiscount struct {
+	Specified bool
+	Value     *decimal.Decimal
+}
+
+func (o *optionalManagedModelBatchDiscount) UnmarshalJSON(data []byte) error {
+	o.Specified = true
+	if strings.TrimSpace(string(data)) == "null" {
+		o.Value = nil
+		return nil
+	}
+	var out decimal.Decimal
+	if err := json.Unmarshal(data, &out); err != nil {
+		return err
+	}
+	o.Value = &out
+	return nil
+}
+
 func setModelAPIRoutes(r gin.IRoutes, opts Options) {
 	userAuthn := requireUserSession(opts)
 
@@ -96,6 +116,7 @@ func setModelAPIRoutes(r gin.IRoutes, opts Options) {
 		ch.POST("/:channel_id/models", adminCreateChannelModelHandler(opts))
 		ch.PUT("/:channel_id/models", adminUpdateChannelModelHandler(opts))
 		ch.DELETE("/:channel_id/models/:binding_id", adminDeleteChannelModelHandler(opts))
+		ch.PUT("/:channel_id/models/:binding_id/cost", adminUpdateChannelModelCostHandler(opts))
 	}
 }
 
@@ -142,6 +163,7 @@ type userManagedModelView struct {
 	PriorityOutputUSDPer1M     *decimal.Decimal                      `json:"priority_output_usd_per_1m,omitempty"`
 	PriorityCacheInputUSDPer1M *decimal.Decimal                      `json:"priority_cache_input_usd_per_1m,omitempty"`
 	HighContextPricing         *store.ManagedModelHighContextPricing `json:"high_context_pricing,omitempty"`
+	BatchDiscount              *decimal.Decimal                      `json:"batch_discount,omitempty"`
 	Status                     int                                   `json:"status"`
 	IconURL                    *string                               `json:"icon_url,omitempty"`
 }
@@ -281,6 +303,7 @@ func userModelsDetailHandler(opts Options) gin.HandlerFunc {
 				PriorityOutputUSDPer1M:     m.PriorityOutputUSDPer1M,
 				PriorityCacheInputUSDPer1M: m.PriorityCacheInputUSDPer1M,
 				HighContextPricing:         m.HighContextPricing,
+				BatchDiscount:              m.BatchDiscount,
 				Status:                     m.Status,
 				IconURL:                    iconPtr,
 			})
@@ -370,6 +393,7 @@ func adminListManagedModelsHandler(opts Options) gin.HandlerFunc {
 				PriorityOutputUSDPer1M:     m.PriorityOutputUSDPer1M,
 				PriorityCacheInputUSDPer1M: m.PriorityCacheInputUSDPer1M,
 				HighContextPricing:         m.HighContextPricing,
+				BatchDiscount:              m.BatchDiscount,
 				Status:                     m.Status,
 				IconURL:                    iconPtr,
 			})
@@ -422,6 +446,7 @@ func adminGetManagedModelHandler(opts Options) gin.HandlerFunc {
 			PriorityOutputUSDPer1M:     m.PriorityOutputUSDPer1M,
 			PriorityCacheInputUSDPer1M: m.PriorityCacheInputUSDPer1M,
 			HighContextPricing:         m.HighContextPricing,
+			BatchDiscount:              m.BatchDiscount,
 			Status:                     m.Status,
 			IconURL: func() *string {
 				icon := strings.TrimSpace(icons.ModelIconURL(m.PublicID, derefString(m.OwnedBy)))
@@ -448,6 +473,7 @@ func adminCreateManagedModelHandler(opts Options) gin.HandlerFunc {
 		PriorityOutputUSDPer1M     *decimal.Decimal                      `json:"priority_output_usd_per_1m"`
 		PriorityCacheInputUSDPer1M *decimal.Decimal                      `json:"priority_cache_input_usd_per_1m"`
 		HighContextPricing         *store.ManagedModelHighContextPricing `json:"high_context_pricing"`
+		BatchDiscount              *decimal.Decimal                      `json:"batch_discount"`
 		Status                     int                                   `json:"status"`
 	}
 
@@ -483,6 +509,7 @@ func adminCreateManagedModelHandler(opts Options) gin.HandlerFunc {
 			PriorityOutputUSDPer1M:     req.PriorityOutputUSDPer1M,
 			PriorityCacheInputUSDPer1M: req.PriorityCacheInputUSDPer1M,
 			HighContextPricing:         req.HighContextPricing,
+			BatchDiscount:              req.BatchDiscount,
 			Status:                     req.Status,
 		})
 		if err != nil {
@@ -508,6 +535,7 @@ func adminUpdateManagedModelHandler(opts Options) gin.HandlerFunc {
 		PriorityOutputUSDPer1M     *decimal.Decimal                       `json:"priority_output_usd_per_1m"`
 		PriorityCacheInputUSDPer1M *decimal.Decimal                       `json:"priority_cache_input_usd_per_1m"`
 		HighContextPricing         optionalManagedModelHighContextPricing `json:"high_context_pricing"`
+		BatchDiscount              optionalManagedModelBatchDiscount      `json:"batch_discount"`
 		Status                     int                                    `json:"status"`
 	}
 
@@ -547,6 +575,10 @@ func adminUpdateManagedModelHandler(opts Options) gin.HandlerFunc {
 		if req.HighContextPricing.Specified {
 			highContextPricing = req.HighContextPricing.Value
 		}
+		batchDiscount := current.BatchDiscount
+		if req.BatchDiscount.Specified {
+			batchDiscount = req.BatchDiscount.Value
+		}
 
 		up := store.ManagedModelUpdate{
 			ID:                         req.ID,
@@ -562,6 +594,7 @@ func adminUpdateManagedModelHandler(opts Options) gin.HandlerFunc {
 			PriorityOutputUSDPer1M:     req.PriorityOutputUSDPer1M,
 			PriorityCacheInputUSDPer1M: req.PriorityCacheInputUSDPer1M,
 			HighContextPricing:         highContextPricing,
+			BatchDiscount:              batchDiscount,
 			Status:                     req.Status,
 		}
 		if statusOnly {
@@ -577,6 +610,7 @@ func adminUpdateManagedModelHandler(opts Options) gin.HandlerFunc {
 			up.PriorityOutputUSDPer1M = current.PriorityOutputUSDPer1M
 			up.PriorityCacheInputUSDPer1M = current.PriorityCacheInputUSDPer1M
 			up.HighContextPricing = current.HighContextPricing
+			up.BatchDiscount = current.BatchDiscount
 		}
 		if up.PublicID == "" {
 			up.PublicID = current.PublicID
@@ -613,12 +647,39 @@ func adminDeleteManagedModelHandler(opts Options) gin.HandlerFunc {
 }
 
 type channelModelView struct {
-	ID            int64                   `json:"id"`
-	ChannelID     int64                   `json:"channel_id"`
-	PublicID      string                  `json:"public_id"`
-	UpstreamModel string                  `json:"upstream_model"`
-	Status        int                     `json:"status"`
-	Runtime       channelModelRuntimeInfo `json:"runtime"`
+	ID            int64  `json:"id"`
+	ChannelID     int64  `json:"channel_id"`
+	PublicID      string `json:"public_id"`
+	UpstreamModel string `json:"upstream_model"`
+	Status        int    `json:"status"`
+	channelModelCostView
+	Runtime channelModelRuntimeInfo `json:"runtime"`
+}
+
+// channelModelCostView 为 channel_model 上游成本价（USD/1M tokens）；缺省表示未配置。
+type channelModelCostView struct {
+	CostInputUSDPer1M       *decimal.Decimal `json:"cost_input_usd_per_1m,omitempty"`
+	CostOutputUSDPer1M      *decimal.Decimal `json:"cost_output_usd_per_1m,omitempty"`
+	CostCacheInputUSDPer1M  *decimal.Decimal `json:"cost_cache_input_usd_per_1m,omitempty"`
+	CostCacheOutputUSDPer1M *decimal.Decimal `json:"cost_cache_output_usd_per_1m,omitempty"`
+}
+
+func channelModelCostViewFrom(c store.ChannelModelCost) channelModelCostView {
+	return channelModelCostView{
+		CostInputUSDPer1M:       c.InputUSDPer1M,
+		CostOutputUSDPer1M:      c.OutputUSDPer1M,
+		CostCacheInputUSDPer1M:  c.CacheInputUSDPer1M,
+		CostCacheOutputUSDPer1M: c.CacheOutputUSDPer1M,
+	}
+}
+
+func (v channelModelCostView) toStore() store.ChannelModelCost {
+	return store.ChannelModelCost{
+		InputUSDPer1M:       v.CostInputUSDPer1M,
+		OutputUSDPer1M:      v.CostOutputUSDPer1M,
+		CacheInputUSDPer1M:  v.CostCacheInputUSDPer1M,
+		CacheOutputUSDPer1M: v.CostCacheOutputUSDPer1M,
+	}
 }
 
 func adminListChannelModelsHandler(opts Options) gin.HandlerFunc {
@@ -641,12 +702,13 @@ func adminListChannelModelsHandler(opts Options) gin.HandlerFunc {
 		out := make([]channelModelView, 0, len(ms))
 		for _, m := range ms {
 			out = append(out, channelModelView{
-				ID:            m.ID,
-				ChannelID:     m.ChannelID,
-				PublicID:      m.PublicID,
-				UpstreamModel: m.UpstreamModel,
-				Status:        m.Status,
-				Runtime:       channelModelRuntimeForAPI(c.Request.Context(), opts, m.ID, loc),
+				ID:                   m.ID,
+				ChannelID:            m.ChannelID,
+				PublicID:             m.PublicID,
+				UpstreamModel:        m.UpstreamModel,
+				Status:               m.Status,
+				channelModelCostView: channelModelCostViewFrom(m.Cost),
+				Runtime:              channelModelRuntimeForAPI(c.Request.Context(), opts, m.ID, loc),
 			})
 		}
 		c.JSON(http.StatusOK, gin.H{"success": true, "message": "", "data": out})
@@ -759,6 +821,41 @@ func adminUpdateChannelModelHandler(opts Options) gin.HandlerFunc {
 	}
 }
 
+// adminUpdateChannelModelCostHandler 整体覆盖 channel_model 的上游成本价；未提交的字段视为清空。
+func adminUpdateChannelModelCostHandler(opts Options) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if opts.Store == nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "store 未初始化"})
+			return
+		}
+		channelID, err := strconv.ParseInt(strings.TrimSpace(c.Param("channel_id")), 10, 64)
+		if err != nil || channelID <= 0 {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "channel_id 不合法"})
+			return
+		}
+		bindingID, err := strconv.ParseInt(strings.TrimSpace(c.Param("binding_id")), 10, 64)
+		if err != nil || bindingID <= 0 {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "binding_id 不合法"})
+			return
+		}
+		m, err := opts.Store.GetChannelModelByID(c.Request.Context(), bindingID)
+		if err != nil || m.ChannelID != channelID {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "channel model 不存在"})
+			return
+		}
+		var req channelModelCostView
+		if err := json.NewDecoder(c.Request.Body).Decode(&req); err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "无效的参数"})
+			return
+		}
+		if err := opts.Store.UpdateChannelModelCost(c.Request.Context(), bindingID, req.toStore()); err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": ""})
+	}
+}
+
 func validateCreateChannelModelPublicID(ctx context.Context, st *store.Store, publicID string, status int) error {
 	return validateChannelModelPublicID(ctx, st, publicID, status, false)
 }
@@ -5,16 +5,19 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	openaiapi "realms/internal/api/openai"
 	"realms/internal/middleware"
 	"realms/internal/store"
 )
 
 func setOpenAIRoutes(r *gin.Engine, opts Options) {
+	rateLimit := middleware.RateLimit(opts.Store, opts.RateLimiter)
 	apiChain := func(h http.Handler) gin.HandlerFunc {
 		return wrapHTTP(middleware.Chain(h,
 			middleware.RequestID,
 			middleware.AccessLog,
-			middleware.TokenAuth(opts.Store),
+			middleware.TokenAuth(opts.Store, opts.TrustProxyHeaders, opts.TrustedProxies),
+			rateLimit,
 			middleware.BodyCache(0),
 		))
 	}
@@ -23,11 +26,22 @@ func setOpenAIRoutes(r *gin.Engine, opts Options) {
 			middleware.RequestID,
 			middleware.AccessLog,
 			middleware.FeatureGateEffective(opts.Store, featureKey),
-			middleware.TokenAuth(opts.Store),
+			middleware.TokenAuth(opts.Store, opts.TrustProxyHeaders, opts.TrustedProxies),
+			rateLimit,
 			middleware.BodyCache(0),
 		))
 	}
 
+	uploadChain := func(h http.Handler) gin.HandlerFunc {
+		return wrapHTTP(middleware.Chain(h,
+			middleware.RequestID,
+			middleware.AccessLog,
+			middleware.TokenAuth(opts.Store, opts.TrustProxyHeaders, opts.TrustedProxies),
+			rateLimit,
+			middleware.BodyCache(openaiapi.BatchFileMaxBytes+(1<<20)),
+		))
+	}
+
 	// 数据面扩展：按当前 API key 查询用量（仅单个 key）。
 	r.GET("/v1/usage/windows", apiChain(http.HandlerFunc(v1UsageWindowsHTTPHandler(opts))))
 	r.GET("/v1/usage/events", apiChain(http.HandlerFunc(v1UsageEventsHTTPHandler(opts))))
@@ -51,6 +65,17 @@ func setOpenAIRoutes(r *gin.Engine, opts Options) {
 		r.GET("/v1/chat/completions/:completion_id/messages", apiChain(http.HandlerFunc(opts.OpenAI.ChatCompletionMessages)))
 
 		r.POST("/v1/messages", apiChain(http.HandlerFunc(opts.OpenAI.Messages)))
+		r.POST("/v1/messages/count_tokens", apiChain(http.HandlerFunc(opts.OpenAI.MessagesCountTokens)))
+		r.POST("/v1/embeddings", apiChain(http.HandlerFunc(opts.OpenAI.Embeddings)))
+
+		r.POST("/v1/files", uploadChain(http.HandlerFunc(opts.OpenAI.FileUpload)))
+		r.GET("/v1/files", apiChain(http.HandlerFunc(opts.OpenAI.FilesList)))
+		r.GET("/v1/files/:file_id", apiChain(http.HandlerFunc(opts.OpenAI.FileRetrieve)))
+		r.GET("/v1/files/:file_id/content", apiChain(http.HandlerFunc(opts.OpenAI.FileContent)))
+		r.POST("/v1/batches", apiChain(http.HandlerFunc(opts.OpenAI.BatchCreate)))
+		r.GET("/v1/batches", apiChain(http.HandlerFunc(opts.OpenAI.BatchesList)))
+		r.GET("/v1/batches/:batch_id", apiChain(http.HandlerFunc(opts.OpenAI.BatchRetrieve)))
+		r.POST("/v1/batches/:batch_id/cancel", apiChain(http.HandlerFunc(opts.OpenAI.BatchCancel)))
 		r.GET("/v1/models", apiFeatureChain(store.SettingFeatureDisableModels, http.HandlerFunc(opts.OpenAI.Models)))
 		r.GET("/v1/models/:model", apiFeatureChain(store.SettingFeatureDisableModels, http.HandlerFunc(opts.OpenAI.ModelRetrieve)))
 
@@ -4,9 +4,11 @@ import (
 	"context"
 	"io/fs"
 	"net/http"
+	"net/netip"
 
 	openaiapi "realms/internal/api/openai"
 	"realms/internal/config"
+	"realms/internal/ratelimit"
 	"realms/internal/scheduler"
 	"realms/internal/store"
 	"realms/internal/tickets"
@@ -17,6 +19,13 @@ type Options struct {
 	Sched           *scheduler.Scheduler
 	AdminAPIKeyHash []byte
 
+	// TrustProxyHeaders 为 true 时才信任 TrustedProxies 转发的 X-Forwarded-* / X-Real-IP 头。
+	TrustProxyHeaders bool
+	// TrustedProxies 为可信反向代理地址段（Token 来源 IP 限制与会话 Cookie 的 HTTPS 判断均据此解析请求头）。
+	TrustedProxies []netip.Prefix
+	// RateLimiter 为数据面 RPM/TPM 限流器；nil 表示不限流。
+	RateLimiter *ratelimit.Limiter
+
 	EmailVerificationEnabledDefault bool
 	AdminTimeZoneDefault            string
 
@@ -0,0 +1,523 @@
+package router
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+
+	"realms/internal/auth"
+	"realms/internal/store"
+)
+
+type organizationView struct {
+	ID         int64  `json:"id"`
+	Name       string `json:"name"`
+	Status     int    `json:"status"`
+	BalanceUSD string `json:"balance_usd"`
+	CreatedAt  string `json:"created_at"`
+}
+
+type organizationMemberView struct {
+	UserID           int64   `json:"user_id"`
+	Email            string  `json:"email"`
+	Username         string  `json:"username"`
+	Role             string  `json:"role"`
+	SpendLimit30DUSD *string `json:"spend_limit_30d_usd,omitempty"`
+	// Spent30DUSD 为最近 30 天由组织计费的消费（含未过期预留）。
+	Spent30DUSD string `json:"spent_30d_usd"`
+	Requests30D int64  `json:"requests_30d"`
+	CreatedAt   string `json:"created_at"`
+}
+
+type organizationTokenView struct {
+	ID         int64   `json:"id"`
+	UserID     int64   `json:"user_id"`
+	UserEmail  string  `json:"user_email"`
+	Name       *string `json:"name,omitempty"`
+	TokenHint  *string `json:"token_hint,omitempty"`
+	Status     int     `json:"status"`
+	CreatedAt  string  `json:"created_at"`
+	RevokedAt  *string `json:"revoked_at,omitempty"`
+	LastUsedAt *string `json:"last_used_at,omitempty"`
+}
+
+type organizationSubscriptionView struct {
+	ID       int64  `json:"id"`
+	PlanID   int64  `json:"plan_id"`
+	PlanName string `json:"plan_name"`
+	Active   bool   `json:"active"`
+	StartAt  string `json:"start_at"`
+	EndAt    string `json:"end_at"`
+}
+
+type organizationPageResponse struct {
+	Organization  organizationView               `json:"organization"`
+	Role          string                         `json:"role"`
+	Members       []organizationMemberView       `json:"members"`
+	Subscriptions []organizationSubscriptionView `json:"subscriptions"`
+}
+
+func setOrganizationAPIRoutes(r gin.IRoutes, opts Options) {
+	authn := requireUserSession(opts)
+
+	r.GET("/org", authn, organizationPageHandler(opts))
+	r.POST("/org/members", authn, organizationAddMemberHandler(opts))
+	r.PUT("/org/members/:user_id", authn, organizationUpdateMemberHandler(opts))
+	r.DELETE("/org/members/:user_id", authn, organizationRemoveMemberHandler(opts))
+	r.GET("/org/tokens", authn, organizationListTokensHandler(opts))
+	r.POST("/org/tokens", authn, organizationCreateTokenHandler(opts))
+	r.POST("/org/tokens/:token_id/revoke", authn, organizationRevokeTokenHandler(opts))
+	r.GET("/org/balance/transactions", authn, organizationBalanceTransactionsHandler(opts))
+}
+
+// currentOrganizationMember 返回当前用户所属组织及其成员信息；未登录或未加入组织时已写入响应。
+func currentOrganizationMember(c *gin.Context, opts Options) (store.Organization, store.OrganizationMember, bool) {
+	if opts.Store == nil {
+		c.JSON(http.StatusOK, gin.H{"success": false, "message": "store 未初始化"})
+		return store.Organization{}, store.OrganizationMember{}, false
+	}
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		c.JSON(http.StatusOK, gin.H{"success": false, "message": "未登录"})
+		return store.Organization{}, store.OrganizationMember{}, false
+	}
+	org, member, err := opts.Store.GetOrganizationMembership(c.Request.Context(), userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "未加入组织"})
+			return store.Organization{}, store.OrganizationMember{}, false
+		}
+		c.JSON(http.StatusOK, gin.H{"success": false, "message": "查询组织失败"})
+		return store.Organization{}, store.OrganizationMember{}, false
+	}
+	return org, member, true
+}
+
+// currentOrganizationManager 在 currentOrganizationMember 基础上要求 owner/admin 角色。
+func currentOrganizationManager(c *gin.Context, opts Options) (store.Organization, store.OrganizationMember, bool) {
+	org, member, ok := currentOrganizationMember(c, opts)
+	if !ok {
+		return org, member, false
+	}
+	if !member.CanManage() {
+		c.JSON(http.StatusOK, gin.H{"success": false, "message": "仅组织 owner/admin 可执行该操作"})
+		return org, member, false
+	}
+	return org, member, true
+}
+
+func newOrganizationView(ctx context.Context, opts Options, org store.Organization) (organizationView, error) {
+	bal, err := opts.Store.GetOrganizationBalanceUSD(ctx, org.ID)
+	if err != nil {
+		return organizationView{}, err
+	}
+	return organizationView{
+		ID:         org.ID,
+		Name:       org.Name,
+		Status:     org.Status,
+		BalanceUSD: formatUSDPlain(bal),
+		CreatedAt:  org.CreatedAt.Format("2006-01-02 15:04"),
+	}, nil
+}
+
+// listOrganizationMemberViews 返回成员列表，附最近 30 天由组织计费的消费。
+func listOrganizationMemberViews(ctx context.Context, opts Options, orgID int64) ([]organizationMemberView, error) {
+	members, err := opts.Store.ListOrganizationMembers(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	usage, err := opts.Store.ListOrganizationMemberUsage(ctx, orgID, now.Add(-store.OrganizationMemberSpendWindow), now.Add(time.Second), now)
+	if err != nil {
+		return nil, err
+	}
+	byUser := make(map[int64]store.OrganizationMemberUsage, len(usage))
+	for _, u := range usage {
+		byUser[u.UserID] = u
+	}
+	out := make([]organizationMemberView, 0, len(members))
+	for _, m := range members {
+		u := byUser[m.UserID]
+		out = append(out, organizationMemberView{
+			UserID:           m.UserID,
+			Email:            m.Email,
+			Username:         m.Username,
+			Role:             m.Role,
+			SpendLimit30DUSD: optionalUSDString(m.SpendLimit30DUSD),
+			Spent30DUSD:      formatUSDPlain(u.CommittedUSD.Add(u.ReservedUSD)),
+			Requests30D:      u.Requests,
+			CreatedAt:        m.CreatedAt.Format("2006-01-02 15:04"),
+		})
+	}
+	return out, nil
+}
+
+func listOrganizationSubscriptionViews(ctx context.Context, opts Options, orgID int64) ([]organizationSubscriptionView, error) {
+	now := time.Now()
+	subs, err := opts.Store.ListNonExpiredOrganizationSubscriptionsWithPlans(ctx, orgID, now)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]organizationSubscriptionView, 0, len(subs))
+	for _, row := range subs {
+		out = append(out, organizationSubscriptionView{
+			ID:       row.Subscription.ID,
+			PlanID:   row.Plan.ID,
+			PlanName: row.Plan.Name,
+			Active:   !now.Before(row.Subscription.StartAt) && now.Before(row.Subscription.EndAt),
+			StartAt:  row.Subscription.StartAt.Format("2006-01-02 15:04"),
+			EndAt:    row.Subscription.EndAt.Format("2006-01-02 15:04"),
+		})
+	}
+	return out, nil
+}
+
+func newOrganizationTokenView(t store.OrganizationToken) organizationTokenView {
+	formatTime := func(v *time.Time) *string {
+		if v == nil {
+			return nil
+		}
+		s := v.Format("2006-01-02 15:04")
+		return &s
+	}
+	return organizationTokenView{
+		ID:         t.ID,
+		UserID:     t.UserID,
+		UserEmail:  t.UserEmail,
+		Name:       t.Name,
+		TokenHint:  t.TokenHint,
+		Status:     t.Status,
+		CreatedAt:  t.CreatedAt.Format("2006-01-02 15:04"),
+		RevokedAt:  formatTime(t.RevokedAt),
+		LastUsedAt: formatTime(t.LastUsedAt),
+	}
+}
+
+// parseOrganizationSpendLimit 解析成员消费上限：空字符串表示不限制。
+func parseOrganizationSpendLimit(raw string) (*decimal.Decimal, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	v, err := parseUSD(raw)
+	if err != nil {
+		return nil, err
+	}
+	return store.NormalizeOrgSpendLimit(&v)
+}
+
+// organizationMemberErrorMessage 将成员管理的 store 错误转换为提示文案。
+func organizationMemberErrorMessage(err error, fallback string) string {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return "成员不存在"
+	case errors.Is(err, store.ErrOrganizationMemberExists), errors.Is(err, store.ErrOrganizationLastOwner):
+		return err.Error()
+	default:
+		return fallback
+	}
+}
+
+// organizationPageHandler 返回当前用户所属组织、角色、余额与订阅；owner/admin 额外可见全部成员及其 30 天消费。
+func organizationPageHandler(opts Options) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		org, member, ok := currentOrganizationMember(c, opts)
+		if !ok {
+			return
+		}
+		ctx := c.Request.Context()
+		view, err := newOrganizationView(ctx, opts, org)
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "查询组织余额失败"})
+			return
+		}
+		members, err := listOrganizationMemberViews(ctx, opts, org.ID)
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "查询组织成员失败"})
+			return
+		}
+		if !member.CanManage() {
+			self := members[:0]
+			for _, m := range members {
+				if m.UserID == member.UserID {
+					self = append(self, m)
+				}
+			}
+			members = self
+		}
+		subs, err := listOrganizationSubscriptionViews(ctx, opts, org.ID)
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "查询组织订阅失败"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "", "data": organizationPageResponse{
+			Organization:  view,
+			Role:          member.Role,
+			Members:       members,
+			Subscriptions: subs,
+		}})
+	}
+}
+
+func organizationAddMemberHandler(opts Options) gin.HandlerFunc {
+	type reqBody struct {
+		Email string `json:"email"`
+		Role  string `json:"role"`
+	}
+	return func(c *gin.Context) {
+		org, actor, ok := currentOrganizationManager(c, opts)
+		if !ok {
+			return
+		}
+		var req reqBody
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "无效的参数"})
+			return
+		}
+		role, err := store.NormalizeOrgRole(req.Role)
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		if role == store.OrgRoleOwner && actor.Role != store.OrgRoleOwner {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "仅 owner 可添加 owner"})
+			return
+		}
+		email := strings.TrimSpace(req.Email)
+		if email == "" {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "email 不能为空"})
+			return
+		}
+		u, err := opts.Store.GetUserByEmail(c.Request.Context(), email)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": "用户不存在"})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "查询用户失败"})
+			return
+		}
+		if err := opts.Store.AddOrganizationMember(c.Request.Context(), org.ID, u.ID, role); err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": organizationMemberErrorMessage(err, "添加成员失败")})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "已添加"})
+	}
+}
+
+// organizationUpdateMemberHandler 修改成员角色与 30 天消费上限；admin 不能修改 owner，也不能授予 owner。
+func organizationUpdateMemberHandler(opts Options) gin.HandlerFunc {
+	type reqBody struct {
+		Role             *string `json:"role,omitempty"`
+		SpendLimit30DUSD *string `json:"spend_limit_30d_usd,omitempty"`
+	}
+	return func(c *gin.Context) {
+		org, actor, ok := currentOrganizationManager(c, opts)
+		if !ok {
+			return
+		}
+		userID, err := strconv.ParseInt(strings.TrimSpace(c.Param("user_id")), 10, 64)
+		if err != nil || userID <= 0 {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "user_id 不合法"})
+			return
+		}
+		var req reqBody
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "无效的参数"})
+			return
+		}
+		target, err := opts.Store.GetOrganizationMember(c.Request.Context(), org.ID, userID)
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": organizationMemberErrorMessage(err, "查询成员失败")})
+			return
+		}
+
+		role := target.Role
+		if req.Role != nil {
+			role, err = store.NormalizeOrgRole(*req.Role)
+			if err != nil {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": err.Error()})
+				return
+			}
+		}
+		if actor.Role != store.OrgRoleOwner && (target.Role == store.OrgRoleOwner || role == store.OrgRoleOwner) {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "仅 owner 可修改或授予 owner"})
+			return
+		}
+		limit := target.SpendLimit30DUSD
+		if req.SpendLimit30DUSD != nil {
+			limit, err = parseOrganizationSpendLimit(*req.SpendLimit30DUSD)
+			if err != nil {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": err.Error()})
+				return
+			}
+		}
+		if err := opts.Store.UpdateOrganizationMember(c.Request.Context(), org.ID, userID, role, limit); err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": organizationMemberErrorMessage(err, "保存失败")})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "已保存"})
+	}
+}
+
+// organizationRemoveMemberHandler 移除成员（成员可移除自己以退出组织）；admin 不能移除 owner。
+func organizationRemoveMemberHandler(opts Options) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		org, actor, ok := currentOrganizationMember(c, opts)
+		if !ok {
+			return
+		}
+		userID, err := strconv.ParseInt(strings.TrimSpace(c.Param("user_id")), 10, 64)
+		if err != nil || userID <= 0 {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "user_id 不合法"})
+			return
+		}
+		if userID != actor.UserID {
+			if !actor.CanManage() {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": "仅组织 owner/admin 可执行该操作"})
+				return
+			}
+			target, err := opts.Store.GetOrganizationMember(c.Request.Context(), org.ID, userID)
+			if err != nil {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": organizationMemberErrorMessage(err, "查询成员失败")})
+				return
+			}
+			if target.Role == store.OrgRoleOwner && actor.Role != store.OrgRoleOwner {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": "仅 owner 可移除 owner"})
+				return
+			}
+		}
+		if err := opts.Store.RemoveOrganizationMember(c.Request.Context(), org.ID, userID); err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": organizationMemberErrorMessage(err, "移除失败")})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "已移除"})
+	}
+}
+
+// organizationListTokensHandler 返回组织 Token：owner/admin 可见全部，成员仅可见自己创建的。
+func organizationListTokensHandler(opts Options) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		org, member, ok := currentOrganizationMember(c, opts)
+		if !ok {
+			return
+		}
+		tokens, err := opts.Store.ListOrganizationTokens(c.Request.Context(), org.ID)
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "查询 Token 列表失败"})
+			return
+		}
+		out := make([]organizationTokenView, 0, len(tokens))
+		for _, t := range tokens {
+			if !member.CanManage() && t.UserID != member.UserID {
+				continue
+			}
+			out = append(out, newOrganizationTokenView(t))
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "", "data": out})
+	}
+}
+
+// organizationCreateTokenHandler 为当前成员创建组织 Token（由组织计费）。
+func organizationCreateTokenHandler(opts Options) gin.HandlerFunc {
+	type reqBody struct {
+		Name *string `json:"name,omitempty"`
+	}
+	return func(c *gin.Context) {
+		org, member, ok := currentOrganizationMember(c, opts)
+		if !ok {
+			return
+		}
+		if org.Status != 1 {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "组织已停用"})
+			return
+		}
+		var req reqBody
+		if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "无效的参数"})
+			return
+		}
+		if req.Name != nil {
+			name := strings.TrimSpace(*req.Name)
+			if name == "" {
+				req.Name = nil
+			} else {
+				req.Name = &name
+			}
+		}
+
+		raw, err := auth.NewRandomToken("sk_", 32)
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "生成令牌失败"})
+			return
+		}
+		tokenID, hint, err := opts.Store.CreateOrganizationToken(c.Request.Context(), org.ID, member.UserID, req.Name, raw)
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "创建令牌失败"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "",
+			"data": gin.H{
+				"token_id":   tokenID,
+				"token":      raw,
+				"token_hint": hint,
+			},
+		})
+	}
+}
+
+// organizationRevokeTokenHandler 撤销组织 Token：owner/admin 可撤销任意组织 Token，成员仅可撤销自己创建的。
+func organizationRevokeTokenHandler(opts Options) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		org, member, ok := currentOrganizationMember(c, opts)
+		if !ok {
+			return
+		}
+		tokenID, err := strconv.ParseInt(strings.TrimSpace(c.Param("token_id")), 10, 64)
+		if err != nil || tokenID <= 0 {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "token_id 不合法"})
+			return
+		}
+		ownerID := member.UserID
+		if member.CanManage() {
+			ownerID = 0
+		}
+		if err := opts.Store.RevokeOrganizationToken(c.Request.Context(), org.ID, tokenID, ownerID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": "令牌不存在"})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "撤销失败"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": ""})
+	}
+}
+
+func organizationBalanceTransactionsHandler(opts Options) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		org, _, ok := currentOrganizationManager(c, opts)
+		if !ok {
+			return
+		}
+		if billingFeatureDisabled(c, opts) {
+			return
+		}
+		writeBalanceTransactions(c, orgBalanceTransactionsLister(opts, org.ID))
+	}
+}
+
+func orgBalanceTransactionsLister(opts Options, orgID int64) balanceTransactionsLister {
+	return func(ctx context.Context, limit int, beforeID *int64) ([]store.BalanceTransaction, error) {
+		return opts.Store.ListBalanceTransactionsByOrg(ctx, orgID, limit, beforeID)
+	}
+}
@@ -11,12 +11,7 @@ import (
 	"realms/internal/security"
 )
 
-var trustedSessionCookieProxyCIDRs = []netip.Prefix{
-	netip.MustParsePrefix("127.0.0.1/32"),
-	netip.MustParsePrefix("::1/128"),
-}
-
-func applySessionCookieOptions(sess sessions.Session, r *http.Request) {
+func applySessionCookieOptions(sess sessions.Session, r *http.Request, opts Options) {
 	if sess == nil {
 		return
 	}
@@ -24,12 +19,12 @@ func applySessionCookieOptions(sess sessions.Session, r *http.Request) {
 		Path:     "/",
 		MaxAge:   2592000, // 30 days
 		HttpOnly: true,
-		Secure:   requestUsesHTTPS(r),
+		Secure:   requestUsesHTTPS(r, opts.TrustProxyHeaders, opts.TrustedProxies),
 		SameSite: http.SameSiteStrictMode,
 	})
 }
 
-func requestUsesHTTPS(r *http.Request) bool {
+func requestUsesHTTPS(r *http.Request, trustProxyHeaders bool, trustedProxies []netip.Prefix) bool {
 	if r == nil {
 		return false
 	}
@@ -39,7 +34,7 @@ func requestUsesHTTPS(r *http.Request) bool {
 	if headerURLUsesHTTPS(r, "Origin") || headerURLUsesHTTPS(r, "Referer") {
 		return true
 	}
-	baseURL := security.DeriveBaseURLFromRequest(r, true, trustedSessionCookieProxyCIDRs)
+	baseURL := security.DeriveBaseURLFromRequest(r, trustProxyHeaders, trustedProxies)
 	return strings.HasPrefix(baseURL, "https://")
 }
 
@@ -2,10 +2,12 @@ package router
 
 import (
 	"net/http/httptest"
+	"net/netip"
 	"testing"
 )
 
 func TestRequestUsesHTTPS(t *testing.T) {
+	loopback := []netip.Prefix{netip.MustParsePrefix("127.0.0.1/32"), netip.MustParsePrefix("::1/128")}
 	tests := []struct {
 		name       string
 		targetURL  string
@@ -57,7 +59,7 @@ func TestRequestUsesHTTPS(t *testing.T) {
 			if tc.name == "https origin on same host implies secure cookie" {
 				req.Header.Set("Origin", "https://example.com")
 			}
-			if got := requestUsesHTTPS(req); got != tc.want {
+			if got := requestUsesHTTPS(req, true, loopback); got != tc.want {
 				t.Fatalf("requestUsesHTTPS() = %v, want %v", got, tc.want)
 			}
 		})
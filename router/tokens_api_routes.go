@@ -24,6 +24,106 @@ type userTokenView struct {
 	CreatedAt  time.Time  `json:"created_at"`
 	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
 	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	BudgetUSD     *string    `json:"budget_usd,omitempty"`
+	Budget1dUSD   *string    `json:"budget_1d_usd,omitempty"`
+	Budget7dUSD   *string    `json:"budget_7d_usd,omitempty"`
+	Budget30dUSD  *string    `json:"budget_30d_usd,omitempty"`
+	AllowedModels []string   `json:"allowed_models,omitempty"`
+	RPMLimit      int64      `json:"rpm_limit"`
+	TPMLimit      int64      `json:"tpm_limit"`
+
+	AllowedIPs           []string `json:"allowed_ips,omitempty"`
+	AllowedReferers      []string `json:"allowed_referers,omitempty"`
+	AccessViolationLimit int      `json:"access_violation_limit"`
+	AccessViolations     int      `json:"access_violations"`
+	LastClientIP         *string  `json:"last_client_ip,omitempty"`
+}
+
+// userTokenLimitsRequest 为 Token 使用限制的请求体；字段为空表示不限制。
+type userTokenLimitsRequest struct {
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	BudgetUSD     *string    `json:"budget_usd,omitempty"`
+	Budget1dUSD   *string    `json:"budget_1d_usd,omitempty"`
+	Budget7dUSD   *string    `json:"budget_7d_usd,omitempty"`
+	Budget30dUSD  *string    `json:"budget_30d_usd,omitempty"`
+	AllowedModels []string   `json:"allowed_models,omitempty"`
+	RPMLimit      int64      `json:"rpm_limit,omitempty"`
+	TPMLimit      int64      `json:"tpm_limit,omitempty"`
+}
+
+func (r userTokenLimitsRequest) empty() bool {
+	return r.ExpiresAt == nil && r.BudgetUSD == nil && r.Budget1dUSD == nil && r.Budget7dUSD == nil && r.Budget30dUSD == nil && len(r.AllowedModels) == 0 && r.RPMLimit == 0 && r.TPMLimit == 0
+}
+
+func (r userTokenLimitsRequest) limits(now time.Time) (store.UserTokenLimits, error) {
+	var out store.UserTokenLimits
+	if r.ExpiresAt != nil {
+		if !r.ExpiresAt.After(now) {
+			return store.UserTokenLimits{}, errors.New("expires_at 必须晚于当前时间")
+		}
+		t := r.ExpiresAt.UTC()
+		out.ExpiresAt = &t
+	}
+	for _, f := range []struct {
+		field string
+		raw   *string
+		dst   **decimal.Decimal
+	}{
+		{"budget_usd", r.BudgetUSD, &out.BudgetUSD},
+		{"budget_1d_usd", r.Budget1dUSD, &out.Budget1dUSD},
+		{"budget_7d_usd", r.Budget7dUSD, &out.Budget7dUSD},
+		{"budget_30d_usd", r.Budget30dUSD, &out.Budget30dUSD},
+	} {
+		if f.raw == nil || strings.TrimSpace(*f.raw) == "" {
+			continue
+		}
+		v, err := parseUSD(*f.raw)
+		if err != nil {
+			return store.UserTokenLimits{}, errors.New(f.field + " 不合法: " + err.Error())
+		}
+		*f.dst = &v
+	}
+	out.AllowedModels = r.AllowedModels
+	out.RPMLimit = r.RPMLimit
+	out.TPMLimit = r.TPMLimit
+	return store.NormalizeUserTokenLimits(out)
+}
+
+func optionalUSDString(v *decimal.Decimal) *string {
+	if v == nil {
+		return nil
+	}
+	s := formatUSDPlain(*v)
+	return &s
+}
+
+func newUserTokenView(t store.UserToken) userTokenView {
+	return userTokenView{
+		ID:         t.ID,
+		Name:       t.Name,
+		TokenHint:  t.TokenHint,
+		Status:     t.Status,
+		CreatedAt:  t.CreatedAt,
+		RevokedAt:  t.RevokedAt,
+		LastUsedAt: t.LastUsedAt,
+
+		ExpiresAt:     t.Limits.ExpiresAt,
+		BudgetUSD:     optionalUSDString(t.Limits.BudgetUSD),
+		Budget1dUSD:   optionalUSDString(t.Limits.Budget1dUSD),
+		Budget7dUSD:   optionalUSDString(t.Limits.Budget7dUSD),
+		Budget30dUSD:  optionalUSDString(t.Limits.Budget30dUSD),
+		AllowedModels: t.Limits.AllowedModels,
+		RPMLimit:      t.Limits.RPMLimit,
+		TPMLimit:      t.Limits.TPMLimit,
+
+		AllowedIPs:           t.Access.AllowedIPs,
+		AllowedReferers:      t.Access.AllowedReferers,
+		AccessViolationLimit: t.Access.ViolationLimit,
+		AccessViolations:     t.AccessViolations,
+		LastClientIP:         t.LastClientIP,
+	}
 }
 
 func setTokenAPIRoutes(r gin.IRoutes, opts Options) {
@@ -40,6 +140,8 @@ func setTokenAPIRoutes(r gin.IRoutes, opts Options) {
 	r.POST("/token/:token_id/revoke", authn, revokeUserTokenHandler(opts))
 	r.GET("/token/:token_id/channel-groups", authn, getUserTokenChannelGroupsHandler(opts))
 	r.PUT("/token/:token_id/channel-groups", authn, replaceUserTokenChannelGroupsHandler(opts))
+	r.PUT("/token/:token_id/limits", authn, updateUserTokenLimitsHandler(opts))
+	r.PUT("/token/:token_id/access", authn, updateUserTokenAccessHandler(opts))
 	r.DELETE("/token/:token_id", authn, deleteUserTokenHandler(opts))
 }
 
@@ -57,15 +159,7 @@ func listUserTokensHandler(opts Options) gin.HandlerFunc {
 		}
 		out := make([]userTokenView, 0, len(tokens))
 		for _, t := range tokens {
-			out = append(out, userTokenView{
-				ID:         t.ID,
-				Name:       t.Name,
-				TokenHint:  t.TokenHint,
-				Status:     t.Status,
-				CreatedAt:  t.CreatedAt,
-				RevokedAt:  t.RevokedAt,
-				LastUsedAt: t.LastUsedAt,
-			})
+			out = append(out, newUserTokenView(t))
 		}
 		c.JSON(http.StatusOK, gin.H{"success": true, "message": "", "data": out})
 	}
@@ -74,6 +168,7 @@ func listUserTokensHandler(opts Options) gin.HandlerFunc {
 func createUserTokenHandler(opts Options) gin.HandlerFunc {
 	type reqBody struct {
 		Name *string `json:"name,omitempty"`
+		userTokenLimitsRequest
 	}
 	return func(c *gin.Context) {
 		userID, ok := userIDFromContext(c)
@@ -95,6 +190,12 @@ func createUserTokenHandler(opts Options) gin.HandlerFunc {
 			}
 		}
 
+		limits, err := req.limits(time.Now())
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+
 		raw, err := auth.NewRandomToken("sk_", 32)
 		if err != nil {
 			c.JSON(http.StatusOK, gin.H{"success": false, "message": "生成令牌失败"})
@@ -105,6 +206,13 @@ func createUserTokenHandler(opts Options) gin.HandlerFunc {
 			c.JSON(http.StatusOK, gin.H{"success": false, "message": "创建令牌失败"})
 			return
 		}
+		if !req.userTokenLimitsRequest.empty() {
+			if err := opts.Store.UpdateUserTokenLimits(c.Request.Context(), userID, tokenID, limits); err != nil {
+				_ = opts.Store.DeleteUserToken(c.Request.Context(), userID, tokenID)
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": "创建令牌失败"})
+				return
+			}
+		}
 		c.JSON(http.StatusOK, gin.H{
 			"success": true,
 			"message": "",
@@ -442,3 +550,90 @@ func replaceUserTokenChannelGroupsHandler(opts Options) gin.HandlerFunc {
 		c.JSON(http.StatusOK, gin.H{"success": true, "message": "已保存"})
 	}
 }
+
+func updateUserTokenLimitsHandler(opts Options) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := userIDFromContext(c)
+		if !ok {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "未登录"})
+			return
+		}
+		tokenID, err := strconv.ParseInt(strings.TrimSpace(c.Param("token_id")), 10, 64)
+		if err != nil || tokenID <= 0 {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "token_id 不合法"})
+			return
+		}
+		var req userTokenLimitsRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "无效的参数"})
+			return
+		}
+		limits, err := req.limits(time.Now())
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		if err := opts.Store.UpdateUserTokenLimits(c.Request.Context(), userID, tokenID, limits); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": "令牌不存在"})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "保存失败"})
+			return
+		}
+		t, err := opts.Store.GetUserTokenByID(c.Request.Context(), userID, tokenID)
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "查询 Token 失败"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "已保存", "data": newUserTokenView(t)})
+	}
+}
+
+func updateUserTokenAccessHandler(opts Options) gin.HandlerFunc {
+	type reqBody struct {
+		AllowedIPs           []string `json:"allowed_ips"`
+		AllowedReferers      []string `json:"allowed_referers"`
+		AccessViolationLimit int      `json:"access_violation_limit"`
+	}
+	return func(c *gin.Context) {
+		userID, ok := userIDFromContext(c)
+		if !ok {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "未登录"})
+			return
+		}
+		tokenID, err := strconv.ParseInt(strings.TrimSpace(c.Param("token_id")), 10, 64)
+		if err != nil || tokenID <= 0 {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "token_id 不合法"})
+			return
+		}
+		var req reqBody
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "无效的参数"})
+			return
+		}
+		access, err := store.NormalizeUserTokenAccess(store.UserTokenAccess{
+			AllowedIPs:      req.AllowedIPs,
+			AllowedReferers: req.AllowedReferers,
+			ViolationLimit:  req.AccessViolationLimit,
+		})
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		if err := opts.Store.UpdateUserTokenAccess(c.Request.Context(), userID, tokenID, access); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				c.JSON(http.StatusOK, gin.H{"success": false, "message": "令牌不存在"})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "保存失败"})
+			return
+		}
+		t, err := opts.Store.GetUserTokenByID(c.Request.Context(), userID, tokenID)
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{"success": false, "message": "查询 Token 失败"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "已保存", "data": newUserTokenView(t)})
+	}
+}
@@ -2,6 +2,7 @@ package router
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"strconv"
@@ -473,6 +474,8 @@ type usageEventDetailAPIResponse struct {
 	EventID          int64                          `json:"event_id"`
 	PricingBreakdown *usageEventPricingBreakdownAPI `json:"pricing_breakdown,omitempty"`
 	ModelCheck       *usageEventModelCheckAPI       `json:"model_check,omitempty"`
+	// RoutingTrace 为路由决策追踪（仅管理员视图且开启 gateway.routing_trace 时返回）。
+	RoutingTrace json.RawMessage `json:"routing_trace,omitempty"`
 }
 
 func usageEventDetailHandler(opts Options) gin.HandlerFunc {
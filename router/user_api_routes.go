@@ -31,7 +31,7 @@ type userRegisterRequest struct {
 func setUserAPIRoutes(r gin.IRoutes, opts Options) {
 	r.POST("/user/register", userRegisterHandler(opts))
 	r.POST("/user/login", userLoginHandler(opts))
-	r.GET("/user/logout", userLogoutHandler())
+	r.GET("/user/logout", userLogoutHandler(opts))
 	r.GET("/user/self", userSelfHandler(opts))
 }
 
@@ -71,7 +71,7 @@ func userLoginHandler(opts Options) gin.HandlerFunc {
 		}
 
 		sess := sessions.Default(c)
-		applySessionCookieOptions(sess, c.Request)
+		applySessionCookieOptions(sess, c.Request, opts)
 		sess.Set("id", u.ID)
 		sess.Set("username", u.Username)
 		sess.Set("role", u.Role)
@@ -178,7 +178,7 @@ func userRegisterHandler(opts Options) gin.HandlerFunc {
 		}
 
 		sess := sessions.Default(c)
-		applySessionCookieOptions(sess, c.Request)
+		applySessionCookieOptions(sess, c.Request, opts)
 		sess.Set("id", userID)
 		sess.Set("username", username)
 		sess.Set("role", role)
@@ -205,10 +205,10 @@ func userRegisterHandler(opts Options) gin.HandlerFunc {
 	}
 }
 
-func userLogoutHandler() gin.HandlerFunc {
+func userLogoutHandler(opts Options) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		sess := sessions.Default(c)
-		applySessionCookieOptions(sess, c.Request)
+		applySessionCookieOptions(sess, c.Request, opts)
 		sess.Clear()
 		if err := sess.Save(); err != nil {
 			c.JSON(http.StatusOK, gin.H{"success": false, "message": "无法清理会话，请重试"})
@@ -0,0 +1,243 @@
+package e2e_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"realms/internal/auth"
+	"realms/internal/config"
+	"realms/internal/server"
+	"realms/internal/store"
+	"realms/internal/version"
+)
+
+func TestBatchAPI_ResponsesBatchCompletesWithDiscount_E2E(t *testing.T) {
+	const model = "gpt-batch-e2e"
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/responses" {
+			http.NotFound(w, r)
+			return
+		}
+		_ = r.Body.Close()
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"id":     "resp_batch_1",
+			"object": "response",
+			"model":  model,
+			"output": []any{},
+			"usage": map[string]any{
+				"input_tokens":  1000,
+				"output_tokens": 1000,
+			},
+			"status": "completed",
+		})
+	}))
+	t.Cleanup(upstream.Close)
+
+	dbPath := filepath.Join(t.TempDir(), "realms.db") + "?_busy_timeout=1000"
+	db, err := store.OpenSQLite(dbPath)
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	if err := store.EnsureSQLiteSchema(db); err != nil {
+		t.Fatalf("EnsureSQLiteSchema: %v", err)
+	}
+	st := store.New(db)
+	st.SetDialect(store.DialectSQLite)
+	ctx := context.Background()
+
+	const userGroup = "ug1"
+	const routeGroup = "rg1"
+	if _, err := st.CreateChannelGroup(ctx, routeGroup, nil, 1, store.DefaultGroupPriceMultiplier); err != nil {
+		t.Fatalf("CreateChannelGroup: %v", err)
+	}
+	if err := st.CreateMainGroup(ctx, userGroup, nil, 1); err != nil {
+		t.Fatalf("CreateMainGroup: %v", err)
+	}
+	if err := st.ReplaceMainGroupSubgroups(ctx, userGroup, []string{routeGroup}); err != nil {
+		t.Fatalf("ReplaceMainGroupSubgroups: %v", err)
+	}
+	channelID, err := st.CreateUpstreamChannel(ctx, store.UpstreamTypeOpenAICompatible, "ci-upstream", routeGroup, 0, false, false, false, false)
+	if err != nil {
+		t.Fatalf("CreateUpstreamChannel: %v", err)
+	}
+	epID, err := st.CreateUpstreamEndpoint(ctx, channelID, strings.TrimRight(upstream.URL, "/")+"/v1", 0)
+	if err != nil {
+		t.Fatalf("CreateUpstreamEndpoint: %v", err)
+	}
+	if _, _, err := st.CreateOpenAICompatibleCredential(ctx, epID, strPtr("ci"), "sk-upstream-test"); err != nil {
+		t.Fatalf("CreateOpenAICompatibleCredential: %v", err)
+	}
+	if _, err := st.CreateManagedModel(ctx, store.ManagedModelCreate{
+		PublicID:       model,
+		GroupName:      routeGroup,
+		InputUSDPer1M:  decimal.RequireFromString("2"),
+		OutputUSDPer1M: decimal.RequireFromString("8"),
+		BatchDiscount:  e2eDecimalPtr("0.5"),
+		Status:         1,
+	}); err != nil {
+		t.Fatalf("CreateManagedModel: %v", err)
+	}
+	if _, err := st.CreateChannelModel(ctx, store.ChannelModelCreate{
+		ChannelID:     channelID,
+		PublicID:      model,
+		UpstreamModel: model,
+		Status:        1,
+	}); err != nil {
+		t.Fatalf("CreateChannelModel: %v", err)
+	}
+
+	userID, err := st.CreateUser(ctx, "ci-batch@example.com", "cibatch", []byte("x"), store.UserRoleUser)
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if err := st.SetUserMainGroup(ctx, userID, userGroup); err != nil {
+		t.Fatalf("SetUserMainGroup: %v", err)
+	}
+	if _, err := st.AddUserBalanceUSD(ctx, userID, decimal.RequireFromString("20")); err != nil {
+		t.Fatalf("AddUserBalanceUSD: %v", err)
+	}
+	rawToken, err := auth.NewRandomToken("sk_", 32)
+	if err != nil {
+		t.Fatalf("NewRandomToken: %v", err)
+	}
+	tokenID, _, err := st.CreateUserToken(ctx, userID, strPtr("ci-token"), rawToken)
+	if err != nil {
+		t.Fatalf("CreateUserToken: %v", err)
+	}
+	if err := st.ReplaceTokenChannelGroups(ctx, tokenID, []string{routeGroup}); err != nil {
+		t.Fatalf("ReplaceTokenChannelGroups: %v", err)
+	}
+
+	t.Setenv("REALMS_DB_DRIVER", "")
+	t.Setenv("REALMS_DB_DSN", "")
+	t.Setenv("REALMS_SQLITE_PATH", "")
+	appCfg, err := config.LoadFromEnv()
+	if err != nil {
+		t.Fatalf("LoadFromEnv: %v", err)
+	}
+	appCfg.Env = "dev"
+	appCfg.Mode = config.ModeBusiness
+	appCfg.DB.Driver = "sqlite"
+	appCfg.DB.DSN = ""
+	appCfg.DB.SQLitePath = dbPath
+	appCfg.Billing.EnablePayAsYouGo = true
+
+	app, err := server.NewApp(server.AppOptions{Config: appCfg, DB: db, Version: version.Info()})
+	if err != nil {
+		t.Fatalf("NewApp: %v", err)
+	}
+	ts := httptest.NewServer(app.Handler())
+	t.Cleanup(ts.Close)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	do := func(req *http.Request) map[string]any {
+		t.Helper()
+		req.Header.Set("Authorization", "Bearer "+rawToken)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Do %s: %v", req.URL.Path, err)
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s %s status=%d body=%s", req.Method, req.URL.Path, resp.StatusCode, string(body))
+		}
+		var out map[string]any
+		if err := json.Unmarshal(body, &out); err != nil {
+			t.Fatalf("json.Unmarshal(%s): %v body=%s", req.URL.Path, err, string(body))
+		}
+		return out
+	}
+
+	input := `{"custom_id":"req-1","method":"POST","url":"/v1/responses","body":{"model":"` + model + `","input":"hi"}}` + "\n"
+	var form bytes.Buffer
+	mw := multipart.NewWriter(&form)
+	_ = mw.WriteField("purpose", "batch")
+	fw, err := mw.CreateFormFile("file", "input.jsonl")
+	if err != nil {
+		t.Fatalf("CreateFormFile: %v", err)
+	}
+	_, _ = fw.Write([]byte(input))
+	_ = mw.Close()
+	uploadReq, _ := http.NewRequest(http.MethodPost, ts.URL+"/v1/files", &form)
+	uploadReq.Header.Set("Content-Type", mw.FormDataContentType())
+	fileObj := do(uploadReq)
+	inputFileID, _ := fileObj["id"].(string)
+	if inputFileID == "" {
+		t.Fatalf("missing file id: %v", fileObj)
+	}
+
+	createReq, _ := http.NewRequest(http.MethodPost, ts.URL+"/v1/batches", strings.NewReader(`{"input_file_id":"`+inputFileID+`","endpoint":"/v1/responses","completion_window":"24h"}`))
+	createReq.Header.Set("Content-Type", "application/json")
+	batchObj := do(createReq)
+	batchID, _ := batchObj["id"].(string)
+	if batchID == "" || batchObj["status"] != "validating" {
+		t.Fatalf("unexpected batch: %v", batchObj)
+	}
+
+	deadline := time.Now().Add(20 * time.Second)
+	for {
+		getReq, _ := http.NewRequest(http.MethodGet, ts.URL+"/v1/batches/"+batchID, nil)
+		batchObj = do(getReq)
+		if batchObj["status"] == "completed" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("batch did not complete in time: %v", batchObj)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	counts, _ := batchObj["request_counts"].(map[string]any)
+	if counts["total"] != float64(1) || counts["completed"] != float64(1) || counts["failed"] != float64(0) {
+		t.Fatalf("unexpected request_counts: %v", counts)
+	}
+	outputFileID, _ := batchObj["output_file_id"].(string)
+	if outputFileID == "" {
+		t.Fatalf("missing output_file_id: %v", batchObj)
+	}
+
+	contentReq, _ := http.NewRequest(http.MethodGet, ts.URL+"/v1/files/"+outputFileID+"/content", nil)
+	contentReq.Header.Set("Authorization", "Bearer "+rawToken)
+	resp, err := client.Do(contentReq)
+	if err != nil {
+		t.Fatalf("Do(content): %v", err)
+	}
+	outBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	_ = resp.Body.Close()
+	var line struct {
+		CustomID string `json:"custom_id"`
+		Response struct {
+			StatusCode int `json:"status_code"`
+		} `json:"response"`
+	}
+	if err := json.Unmarshal(bytes.TrimSpace(outBody), &line); err != nil {
+		t.Fatalf("json.Unmarshal(output): %v body=%s", err, string(outBody))
+	}
+	if line.CustomID != "req-1" || line.Response.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected output line: %s", string(outBody))
+	}
+
+	events := waitUsageEventsByUser(t, st, ctx, userID, 1)
+	ev := events[0]
+	if ev.ServiceTier == nil || *ev.ServiceTier != store.ServiceTierBatch {
+		t.Fatalf("service_tier mismatch: got=%v want=batch", ev.ServiceTier)
+	}
+	wantCommitted := decimal.RequireFromString("0.005")
+	if !ev.CommittedUSD.Equal(wantCommitted) {
+		t.Fatalf("committed_usd mismatch: got=%s want=%s", ev.CommittedUSD.String(), wantCommitted.String())
+	}
+}
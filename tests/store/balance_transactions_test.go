@@ -0,0 +1,127 @@
+package store_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"realms/internal/store"
+)
+
+func TestBalanceTransactions_LedgerMatchesBalance(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "realms.db") + "?_busy_timeout=1000"
+	db, err := store.OpenSQLite(path)
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	defer db.Close()
+	if err := store.EnsureSQLiteSchema(db); err != nil {
+		t.Fatalf("EnsureSQLiteSchema: %v", err)
+	}
+	st := store.New(db)
+	st.SetDialect(store.DialectSQLite)
+	ctx := context.Background()
+
+	adminID, err := st.CreateUser(ctx, "root@example.com", "root", []byte("hash"), store.UserRoleRoot)
+	if err != nil {
+		t.Fatalf("CreateUser(root): %v", err)
+	}
+	userID, err := st.CreateUser(ctx, "u1@example.com", "u1", []byte("hash"), store.UserRoleUser)
+	if err != nil {
+		t.Fatalf("CreateUser(u1): %v", err)
+	}
+	tokenID, _, err := st.CreateUserToken(ctx, userID, nil, "sk-ledger")
+	if err != nil {
+		t.Fatalf("CreateUserToken: %v", err)
+	}
+
+	if _, err := st.AddUserBalanceUSDByAdmin(ctx, userID, adminID, decimal.RequireFromString("10"), "  manual credit "); err != nil {
+		t.Fatalf("AddUserBalanceUSDByAdmin: %v", err)
+	}
+
+	reserve := func(reqID string) int64 {
+		t.Helper()
+		id, err := st.ReserveUsageAndDebitBalance(ctx, store.ReserveUsageInput{
+			RequestID:        reqID,
+			UserID:           userID,
+			TokenID:          tokenID,
+			ReservedUSD:      decimal.RequireFromString("2"),
+			ReserveExpiresAt: time.Now().Add(time.Hour),
+		})
+		if err != nil {
+			t.Fatalf("ReserveUsageAndDebitBalance(%s): %v", reqID, err)
+		}
+		return id
+	}
+	committedID := reserve("req-1")
+	if err := st.CommitUsageAndRefundBalance(ctx, store.CommitUsageInput{UsageEventID: committedID, CommittedUSD: decimal.RequireFromString("0.5")}); err != nil {
+		t.Fatalf("CommitUsageAndRefundBalance: %v", err)
+	}
+	voidedID := reserve("req-2")
+	if err := st.VoidUsageAndRefundBalance(ctx, voidedID); err != nil {
+		t.Fatalf("VoidUsageAndRefundBalance: %v", err)
+	}
+
+	txs, err := st.ListBalanceTransactionsByUser(ctx, userID, 0, nil)
+	if err != nil {
+		t.Fatalf("ListBalanceTransactionsByUser: %v", err)
+	}
+	want := []struct {
+		typ    string
+		amount string
+		after  string
+	}{
+		{store.BalanceTxUsageVoid, "2", "9.5"},
+		{store.BalanceTxUsageReserve, "-2", "7.5"},
+		{store.BalanceTxUsageRefund, "1.5", "9.5"},
+		{store.BalanceTxUsageReserve, "-2", "8"},
+		{store.BalanceTxAdminCredit, "10", "10"},
+	}
+	if len(txs) != len(want) {
+		t.Fatalf("expected %d transactions, got %d: %+v", len(want), len(txs), txs)
+	}
+	for i, w := range want {
+		got := txs[i]
+		if got.Type != w.typ || !got.AmountUSD.Equal(decimal.RequireFromString(w.amount)) || !got.BalanceAfterUSD.Equal(decimal.RequireFromString(w.after)) {
+			t.Fatalf("tx[%d] = %s %s -> %s; want %s %s -> %s", i, got.Type, got.AmountUSD, got.BalanceAfterUSD, w.typ, w.amount, w.after)
+		}
+	}
+	credit := txs[len(txs)-1]
+	if credit.ActorUserID == nil || *credit.ActorUserID != adminID || credit.Note == nil || *credit.Note != "manual credit" {
+		t.Fatalf("unexpected admin credit metadata: actor=%v note=%v", credit.ActorUserID, credit.Note)
+	}
+	if txs[0].UsageEventID == nil || *txs[0].UsageEventID != voidedID {
+		t.Fatalf("expected void to reference usage_event %d, got %v", voidedID, txs[0].UsageEventID)
+	}
+
+	page, err := st.ListBalanceTransactionsByUser(ctx, userID, 2, &txs[1].ID)
+	if err != nil {
+		t.Fatalf("ListBalanceTransactionsByUser(before_id): %v", err)
+	}
+	if len(page) != 2 || page[0].ID != txs[2].ID || page[1].ID != txs[3].ID {
+		t.Fatalf("unexpected page: %+v", page)
+	}
+
+	mismatches, err := st.ReconcileBalanceLedger(ctx)
+	if err != nil {
+		t.Fatalf("ReconcileBalanceLedger: %v", err)
+	}
+	if len(mismatches) != 0 {
+		t.Fatalf("expected ledger to reconcile, got %+v", mismatches)
+	}
+
+	// 绕过流水直接改余额，对账应发现差额。
+	if _, err := db.ExecContext(ctx, `UPDATE user_balances SET usd=usd+1 WHERE user_id=?`, userID); err != nil {
+		t.Fatalf("tamper balance: %v", err)
+	}
+	mismatches, err = st.ReconcileBalanceLedger(ctx)
+	if err != nil {
+		t.Fatalf("ReconcileBalanceLedger (2): %v", err)
+	}
+	if len(mismatches) != 1 || mismatches[0].UserID != userID || !mismatches[0].DiffUSD().Equal(decimal.NewFromInt(1)) {
+		t.Fatalf("expected one mismatch of 1 USD, got %+v", mismatches)
+	}
+}
@@ -0,0 +1,163 @@
+package store_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"realms/internal/store"
+)
+
+func openBatchTestStore(t *testing.T) *store.Store {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "realms.db") + "?_busy_timeout=1000"
+	db, err := store.OpenSQLite(path)
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	if err := store.EnsureSQLiteSchema(db); err != nil {
+		t.Fatalf("EnsureSQLiteSchema: %v", err)
+	}
+	st := store.New(db)
+	st.SetDialect(store.DialectSQLite)
+	return st
+}
+
+func TestBatches_SQLite_Lifecycle(t *testing.T) {
+	st := openBatchTestStore(t)
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	content := []byte(`{"custom_id":"a","method":"POST","url":"/v1/responses","body":{"model":"m"}}` + "\n")
+	f, err := st.CreateBatchFile(ctx, store.BatchFile{
+		PublicID: "file-in",
+		UserID:   1,
+		TokenID:  2,
+		Purpose:  store.BatchFilePurposeBatch,
+		Filename: "in.jsonl",
+	}, content)
+	if err != nil {
+		t.Fatalf("CreateBatchFile: %v", err)
+	}
+	if f.Bytes != int64(len(content)) {
+		t.Fatalf("bytes=%d, want %d", f.Bytes, len(content))
+	}
+	if _, ok, err := st.GetBatchFile(ctx, 99, "file-in"); err != nil || ok {
+		t.Fatalf("GetBatchFile(other user): ok=%v err=%v, want not found", ok, err)
+	}
+	got, ok, err := st.GetBatchFileContent(ctx, 1, "file-in")
+	if err != nil || !ok || string(got) != string(content) {
+		t.Fatalf("GetBatchFileContent: ok=%v err=%v content=%q", ok, err, got)
+	}
+
+	b, err := st.CreateBatch(ctx, store.BatchCreate{
+		PublicID:         "batch_1",
+		UserID:           1,
+		TokenID:          2,
+		Endpoint:         "/v1/responses",
+		CompletionWindow: "24h",
+		InputFileID:      "file-in",
+		ExpiresAt:        now.Add(24 * time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("CreateBatch: %v", err)
+	}
+	if b.Status != store.BatchStatusValidating {
+		t.Fatalf("status=%q, want validating", b.Status)
+	}
+
+	claimed, ok, err := st.ClaimNextBatch(ctx, now, now.Add(time.Minute))
+	if err != nil || !ok || claimed.ID != b.ID {
+		t.Fatalf("ClaimNextBatch: ok=%v err=%v id=%d", ok, err, claimed.ID)
+	}
+	if _, ok, err := st.ClaimNextBatch(ctx, now, now.Add(time.Minute)); err != nil || ok {
+		t.Fatalf("ClaimNextBatch(leased): ok=%v err=%v, want none", ok, err)
+	}
+
+	if err := st.MarkBatchInProgress(ctx, b.ID, 2, now); err != nil {
+		t.Fatalf("MarkBatchInProgress: %v", err)
+	}
+	res := store.BatchRequestResult{BatchID: b.ID, LineIndex: 0, CustomID: "a", RequestID: "r1", StatusCode: 200, Body: []byte(`{}`)}
+	for i := 0; i < 2; i++ {
+		if err := st.RecordBatchRequestResult(ctx, res); err != nil {
+			t.Fatalf("RecordBatchRequestResult: %v", err)
+		}
+	}
+	if err := st.RecordBatchRequestResult(ctx, store.BatchRequestResult{BatchID: b.ID, LineIndex: 1, CustomID: "b", RequestID: "r2", StatusCode: 429, Body: []byte(`{}`)}); err != nil {
+		t.Fatalf("RecordBatchRequestResult(failed): %v", err)
+	}
+	b, err = st.GetBatchByID(ctx, b.ID)
+	if err != nil {
+		t.Fatalf("GetBatchByID: %v", err)
+	}
+	if b.Status != store.BatchStatusInProgress || b.RequestTotal != 2 || b.RequestCompleted != 1 || b.RequestFailed != 1 {
+		t.Fatalf("unexpected batch counters: status=%s total=%d completed=%d failed=%d", b.Status, b.RequestTotal, b.RequestCompleted, b.RequestFailed)
+	}
+
+	b, changed, err := st.CancelBatch(ctx, 1, "batch_1", now)
+	if err != nil || !changed || b.Status != store.BatchStatusCancelling {
+		t.Fatalf("CancelBatch: changed=%v err=%v status=%s", changed, err, b.Status)
+	}
+
+	outID := "file-out"
+	if err := st.FinalizeBatch(ctx, store.BatchFinalize{ID: b.ID, Status: store.BatchStatusCancelled, OutputFileID: &outID, Now: now}); err != nil {
+		t.Fatalf("FinalizeBatch: %v", err)
+	}
+	b, err = st.GetBatchByID(ctx, b.ID)
+	if err != nil {
+		t.Fatalf("GetBatchByID: %v", err)
+	}
+	if b.Status != store.BatchStatusCancelled || b.CancelledAt == nil || b.LeaseUntil != nil || b.OutputFileID == nil || *b.OutputFileID != outID {
+		t.Fatalf("unexpected finalized batch: %+v", b)
+	}
+	results, err := st.ListBatchRequestResults(ctx, b.ID)
+	if err != nil || len(results) != 0 {
+		t.Fatalf("ListBatchRequestResults: len=%d err=%v, want cleared", len(results), err)
+	}
+	if _, changed, err := st.CancelBatch(ctx, 1, "batch_1", now); err != nil || changed {
+		t.Fatalf("CancelBatch(terminal): changed=%v err=%v, want unchanged", changed, err)
+	}
+	if _, ok, err := st.ClaimNextBatch(ctx, now.Add(time.Hour), now.Add(2*time.Hour)); err != nil || ok {
+		t.Fatalf("ClaimNextBatch(terminal): ok=%v err=%v, want none", ok, err)
+	}
+}
+
+func TestManagedModelBatchDiscount_SQLite_RoundTrip(t *testing.T) {
+	st := openBatchTestStore(t)
+	ctx := context.Background()
+
+	discount := decimal.RequireFromString("0.5")
+	id, err := st.CreateManagedModel(ctx, store.ManagedModelCreate{
+		PublicID:       "gpt-batch",
+		InputUSDPer1M:  decimal.RequireFromString("1"),
+		OutputUSDPer1M: decimal.RequireFromString("2"),
+		BatchDiscount:  &discount,
+		Status:         1,
+	})
+	if err != nil {
+		t.Fatalf("CreateManagedModel: %v", err)
+	}
+	m, err := st.GetManagedModelByID(ctx, id)
+	if err != nil {
+		t.Fatalf("GetManagedModelByID: %v", err)
+	}
+	if m.BatchDiscount == nil || !m.BatchDiscount.Equal(discount) {
+		t.Fatalf("batch_discount=%v, want 0.5", m.BatchDiscount)
+	}
+
+	bad := decimal.RequireFromString("1.5")
+	if _, err := st.CreateManagedModel(ctx, store.ManagedModelCreate{
+		PublicID:       "gpt-batch-bad",
+		InputUSDPer1M:  decimal.RequireFromString("1"),
+		OutputUSDPer1M: decimal.RequireFromString("2"),
+		BatchDiscount:  &bad,
+		Status:         1,
+	}); err == nil {
+		t.Fatal("expected invalid batch_discount error")
+	}
+}
@@ -0,0 +1,161 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"realms/internal/store"
+)
+
+func TestChannelGroupCanary_SQLite_LifecycleAndHealthStats(t *testing.T) {
+	st := openBatchTestStore(t)
+	ctx := context.Background()
+
+	userID, err := st.CreateUser(ctx, "canary@example.com", "canary", []byte("pw-hash"), store.UserRoleUser)
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	tokenID, _, err := st.CreateUserToken(ctx, userID, nil, "tok_canary_123")
+	if err != nil {
+		t.Fatalf("CreateUserToken: %v", err)
+	}
+	groupID, err := st.CreateChannelGroup(ctx, "canary", nil, 1, decimal.NewFromInt(1))
+	if err != nil {
+		t.Fatalf("CreateChannelGroup: %v", err)
+	}
+	var channelIDs []int64
+	for _, name := range []string{"ch-stable", "ch-canary"} {
+		id, err := st.CreateUpstreamChannel(ctx, store.UpstreamTypeOpenAICompatible, name, "", 0, false, false, false, false)
+		if err != nil {
+			t.Fatalf("CreateUpstreamChannel: %v", err)
+		}
+		if err := st.AddChannelGroupMemberChannel(ctx, groupID, id, 0, false); err != nil {
+			t.Fatalf("AddChannelGroupMemberChannel: %v", err)
+		}
+		channelIDs = append(channelIDs, id)
+	}
+	stableID, canaryID := channelIDs[0], channelIDs[1]
+	memberID, err := st.GetChannelGroupChannelMemberID(ctx, groupID, canaryID)
+	if err != nil {
+		t.Fatalf("GetChannelGroupChannelMemberID: %v", err)
+	}
+
+	if err := st.StartChannelGroupMemberCanary(ctx, groupID, memberID, 0); err == nil {
+		t.Fatalf("expected canary_percent=0 to be rejected")
+	}
+	if err := st.StartChannelGroupMemberCanary(ctx, groupID, memberID, 10); err != nil {
+		t.Fatalf("StartChannelGroupMemberCanary: %v", err)
+	}
+	canaries, err := st.ListActiveChannelGroupCanaries(ctx)
+	if err != nil || len(canaries) != 1 {
+		t.Fatalf("ListActiveChannelGroupCanaries: %+v err=%v", canaries, err)
+	}
+	c := canaries[0]
+	if c.MemberID != memberID || c.ChannelID != canaryID || c.ParentGroupID != groupID || c.Percent != 10 || c.StartedAt.IsZero() {
+		t.Fatalf("unexpected canary: %+v", c)
+	}
+	// 调整百分比不重置统计窗口。
+	if err := st.StartChannelGroupMemberCanary(ctx, groupID, memberID, 25); err != nil {
+		t.Fatalf("StartChannelGroupMemberCanary(25): %v", err)
+	}
+	canaries, _ = st.ListActiveChannelGroupCanaries(ctx)
+	if len(canaries) != 1 || canaries[0].Percent != 25 || !canaries[0].StartedAt.Equal(c.StartedAt) {
+		t.Fatalf("expected percent update to keep started_at, got=%+v want started_at=%v", canaries, c.StartedAt)
+	}
+
+	// 多实例：同一检查周期只能认领一次。
+	checkAt := time.Now().UTC()
+	if ok, err := st.ClaimChannelGroupCanaryCheck(ctx, memberID, checkAt, checkAt.Add(-30*time.Second)); err != nil || !ok {
+		t.Fatalf("expected first canary check claim to succeed, ok=%v err=%v", ok, err)
+	}
+	if ok, err := st.ClaimChannelGroupCanaryCheck(ctx, memberID, checkAt.Add(time.Second), checkAt.Add(-29*time.Second)); err != nil || ok {
+		t.Fatalf("expected second claim within the same period to fail, ok=%v err=%v", ok, err)
+	}
+	if ok, err := st.ClaimChannelGroupCanaryCheck(ctx, memberID, checkAt.Add(time.Minute), checkAt.Add(30*time.Second)); err != nil || !ok {
+		t.Fatalf("expected claim in the next period to succeed, ok=%v err=%v", ok, err)
+	}
+
+	newUsageEvent := func(reqID string, channelID int64, routeGroupID int64, status int, latencyMS int) {
+		t.Helper()
+		usageID, err := st.ReserveUsage(ctx, store.ReserveUsageInput{
+			RequestID:        reqID,
+			UserID:           userID,
+			TokenID:          tokenID,
+			ReservedUSD:      decimal.Zero,
+			ReserveExpiresAt: time.Now().Add(time.Hour),
+		})
+		if err != nil {
+			t.Fatalf("ReserveUsage(%s): %v", reqID, err)
+		}
+		if err := st.CommitUsage(ctx, store.CommitUsageInput{
+			UsageEventID:      usageID,
+			UpstreamChannelID: &channelID,
+			CommittedUSD:      decimal.Zero,
+		}); err != nil {
+			t.Fatalf("CommitUsage(%s): %v", reqID, err)
+		}
+		if err := st.FinalizeUsageEvent(ctx, store.FinalizeUsageEventInput{
+			UsageEventID:        usageID,
+			Endpoint:            "/v1/responses",
+			Method:              "POST",
+			StatusCode:          status,
+			LatencyMS:           latencyMS,
+			FirstTokenLatencyMS: latencyMS / 2,
+			UpstreamChannelID:   &channelID,
+			RouteGroupID:        &routeGroupID,
+		}); err != nil {
+			t.Fatalf("FinalizeUsageEvent(%s): %v", reqID, err)
+		}
+	}
+	newUsageEvent("req_c1", canaryID, groupID, 200, 100)
+	newUsageEvent("req_c2", canaryID, groupID, 502, 300)
+	newUsageEvent("req_s1", stableID, groupID, 200, 50)
+	newUsageEvent("req_s2", stableID, groupID, 200, 150)
+	// 经其他渠道组路由的请求不计入本组灰度统计。
+	newUsageEvent("req_other", canaryID, groupID+100, 502, 900)
+
+	if all, err := st.GetChannelHealthStatsRange(ctx, 0, channelIDs, c.StartedAt.UTC(), time.Now().UTC().Add(time.Minute)); err != nil || all[canaryID].Requests != 3 {
+		t.Fatalf("expected unscoped stats to include all groups, got=%+v err=%v", all[canaryID], err)
+	}
+	stats, err := st.GetChannelHealthStatsRange(ctx, groupID, channelIDs, c.StartedAt.UTC(), time.Now().UTC().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("GetChannelHealthStatsRange: %v", err)
+	}
+	cs := stats[canaryID]
+	if cs.Requests != 2 || cs.Failures != 1 || cs.ErrorRate() != 0.5 || cs.AvgLatencyMS != 200 || cs.AvgFirstTokenMS != 100 {
+		t.Fatalf("unexpected canary stats: %+v", cs)
+	}
+	ss := stats[stableID]
+	if ss.Requests != 2 || ss.Failures != 0 || ss.AvgLatencyMS != 100 {
+		t.Fatalf("unexpected sibling stats: %+v", ss)
+	}
+
+	if err := st.RollbackChannelGroupMemberCanary(ctx, groupID, memberID); err != nil {
+		t.Fatalf("RollbackChannelGroupMemberCanary: %v", err)
+	}
+	members, err := st.ListChannelGroupMembers(ctx, groupID)
+	if err != nil {
+		t.Fatalf("ListChannelGroupMembers: %v", err)
+	}
+	for _, m := range members {
+		if m.MemberID == memberID && m.CanaryStatus != store.ChannelGroupCanaryRolledBack {
+			t.Fatalf("expected rolled_back status, got=%+v", m)
+		}
+	}
+	if canaries, _ := st.ListActiveChannelGroupCanaries(ctx); len(canaries) != 0 {
+		t.Fatalf("expected no active canaries after rollback, got=%+v", canaries)
+	}
+
+	if err := st.PromoteChannelGroupMemberCanary(ctx, groupID, memberID); err != nil {
+		t.Fatalf("PromoteChannelGroupMemberCanary: %v", err)
+	}
+	members, _ = st.ListChannelGroupMembers(ctx, groupID)
+	for _, m := range members {
+		if m.MemberID == memberID && (m.CanaryStatus != "" || m.CanaryPercent != 0 || m.CanaryStartedAt != nil) {
+			t.Fatalf("expected promote to clear canary fields, got=%+v", m)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package store_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"realms/internal/store"
+)
+
+func TestChannelSchedule_SQLite_UpdateAndExportImportRoundTrip(t *testing.T) {
+	st := openBatchTestStore(t)
+	ctx := context.Background()
+
+	channelID, err := st.CreateUpstreamChannel(ctx, store.UpstreamTypeOpenAICompatible, "ch-sched", "", 0, false, false, false, false)
+	if err != nil {
+		t.Fatalf("CreateUpstreamChannel: %v", err)
+	}
+	groupID, err := st.CreateChannelGroup(ctx, "night", nil, 1, decimal.NewFromInt(1))
+	if err != nil {
+		t.Fatalf("CreateChannelGroup: %v", err)
+	}
+	if err := st.AddChannelGroupMemberChannel(ctx, groupID, channelID, 0, false); err != nil {
+		t.Fatalf("AddChannelGroupMemberChannel: %v", err)
+	}
+	members, err := st.ListChannelGroupMembers(ctx, groupID)
+	if err != nil || len(members) != 1 {
+		t.Fatalf("ListChannelGroupMembers: members=%d err=%v", len(members), err)
+	}
+	memberID := members[0].MemberID
+
+	const channelSchedule = `{"windows":[{"start":"20:00","end":"08:00"}]}`
+	const memberSchedule = `{"windows":[{"days":[0,6],"start":"00:00","end":"00:00"}]}`
+	if err := st.UpdateUpstreamChannelSchedule(ctx, channelID, `{"windows":[{"start":"25:00","end":"08:00"}]}`); err == nil {
+		t.Fatalf("expected invalid schedule to be rejected")
+	}
+	if err := st.UpdateUpstreamChannelSchedule(ctx, channelID, channelSchedule); err != nil {
+		t.Fatalf("UpdateUpstreamChannelSchedule: %v", err)
+	}
+	if err := st.UpdateChannelGroupMemberSchedule(ctx, groupID, memberID, memberSchedule); err != nil {
+		t.Fatalf("UpdateChannelGroupMemberSchedule: %v", err)
+	}
+
+	ch, err := st.GetUpstreamChannelByID(ctx, channelID)
+	if err != nil || ch.Schedule != channelSchedule {
+		t.Fatalf("GetUpstreamChannelByID: schedule=%q err=%v", ch.Schedule, err)
+	}
+	members, err = st.ListChannelGroupMembers(ctx, groupID)
+	if err != nil || members[0].Schedule != memberSchedule || members[0].MemberChannelSchedule == nil || *members[0].MemberChannelSchedule != channelSchedule {
+		t.Fatalf("unexpected member schedules: %+v err=%v", members, err)
+	}
+
+	exp, err := st.ExportAdminConfig(ctx)
+	if err != nil {
+		t.Fatalf("ExportAdminConfig: %v", err)
+	}
+
+	// 清空后重新导入，时间窗应恢复。
+	if err := st.UpdateUpstreamChannelSchedule(ctx, channelID, ""); err != nil {
+		t.Fatalf("UpdateUpstreamChannelSchedule(clear): %v", err)
+	}
+	if err := st.UpdateChannelGroupMemberSchedule(ctx, groupID, memberID, "null"); err != nil {
+		t.Fatalf("UpdateChannelGroupMemberSchedule(clear): %v", err)
+	}
+	if _, err := st.ImportAdminConfig(ctx, exp); err != nil {
+		t.Fatalf("ImportAdminConfig: %v", err)
+	}
+	ch, err = st.GetUpstreamChannelByID(ctx, channelID)
+	if err != nil || ch.Schedule != channelSchedule {
+		t.Fatalf("channel schedule after import=%q err=%v", ch.Schedule, err)
+	}
+	members, err = st.ListChannelGroupMembers(ctx, groupID)
+	if err != nil || len(members) != 1 || members[0].Schedule != memberSchedule {
+		t.Fatalf("member schedule after import: %+v err=%v", members, err)
+	}
+
+	// 旧版本导出不包含 schedule，导入时不覆盖已有时间窗。
+	exp.Version = 9
+	for i := range exp.UpstreamChannels {
+		exp.UpstreamChannels[i].Schedule = nil
+	}
+	if _, err := st.ImportAdminConfig(ctx, exp); err != nil {
+		t.Fatalf("ImportAdminConfig(v9): %v", err)
+	}
+	ch, err = st.GetUpstreamChannelByID(ctx, channelID)
+	if err != nil || !strings.Contains(ch.Schedule, "20:00") {
+		t.Fatalf("expected v9 import to keep schedule, got=%q err=%v", ch.Schedule, err)
+	}
+}
@@ -0,0 +1,110 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"realms/internal/store"
+)
+
+func decPtr(v string) *decimal.Decimal {
+	d := decimal.RequireFromString(v)
+	return &d
+}
+
+func TestChannelModelCost_SQLite_RoundTripAndClear(t *testing.T) {
+	st := openBatchTestStore(t)
+	ctx := context.Background()
+
+	channelID, err := st.CreateUpstreamChannel(ctx, store.UpstreamTypeOpenAICompatible, "ch-cost", "", 0, false, false, false, false)
+	if err != nil {
+		t.Fatalf("CreateUpstreamChannel: %v", err)
+	}
+	bindingID, err := st.CreateChannelModel(ctx, store.ChannelModelCreate{
+		ChannelID:     channelID,
+		PublicID:      "m-cost",
+		UpstreamModel: "m-cost",
+		Status:        1,
+	})
+	if err != nil {
+		t.Fatalf("CreateChannelModel: %v", err)
+	}
+
+	m, err := st.GetChannelModelByID(ctx, bindingID)
+	if err != nil {
+		t.Fatalf("GetChannelModelByID: %v", err)
+	}
+	if m.Cost.Configured() {
+		t.Fatalf("expected no cost by default, got %+v", m.Cost)
+	}
+
+	if err := st.UpdateChannelModelCost(ctx, bindingID, store.ChannelModelCost{
+		InputUSDPer1M:      decPtr("1.25"),
+		OutputUSDPer1M:     decPtr("10"),
+		CacheInputUSDPer1M: decPtr("0.125"),
+	}); err != nil {
+		t.Fatalf("UpdateChannelModelCost: %v", err)
+	}
+	if err := st.UpdateChannelModelCost(ctx, bindingID, store.ChannelModelCost{InputUSDPer1M: decPtr("-1")}); err == nil {
+		t.Fatalf("expected negative cost to be rejected")
+	}
+
+	bindings, err := st.ListEnabledChannelModelBindingsByPublicID(ctx, "m-cost")
+	if err != nil || len(bindings) != 1 {
+		t.Fatalf("ListEnabledChannelModelBindingsByPublicID: bindings=%d err=%v", len(bindings), err)
+	}
+	c := bindings[0].Cost
+	if c.InputUSDPer1M == nil || !c.InputUSDPer1M.Equal(decimal.RequireFromString("1.25")) ||
+		c.OutputUSDPer1M == nil || !c.OutputUSDPer1M.Equal(decimal.NewFromInt(10)) ||
+		c.CacheInputUSDPer1M == nil || !c.CacheInputUSDPer1M.Equal(decimal.RequireFromString("0.125")) ||
+		c.CacheOutputUSDPer1M != nil {
+		t.Fatalf("unexpected binding cost: %+v", c)
+	}
+	if v, ok := c.ExpectedUSDPer1M(); !ok || !v.Equal(decimal.RequireFromString("5.625")) {
+		t.Fatalf("unexpected expected cost: %s ok=%v", v, ok)
+	}
+
+	if err := st.UpdateChannelModelCost(ctx, bindingID, store.ChannelModelCost{}); err != nil {
+		t.Fatalf("UpdateChannelModelCost(clear): %v", err)
+	}
+	ms, err := st.ListChannelModelsByChannelID(ctx, channelID)
+	if err != nil || len(ms) != 1 {
+		t.Fatalf("ListChannelModelsByChannelID: models=%d err=%v", len(ms), err)
+	}
+	if ms[0].Cost.Configured() || ms[0].Cost.CacheInputUSDPer1M != nil {
+		t.Fatalf("expected cost cleared, got %+v", ms[0].Cost)
+	}
+}
+
+func TestChannelGroupRoutingMode_SQLite_DefaultAndUpdate(t *testing.T) {
+	st := openBatchTestStore(t)
+	ctx := context.Background()
+
+	groupID, err := st.CreateChannelGroup(ctx, "g-cost", nil, 1, store.DefaultGroupPriceMultiplier)
+	if err != nil {
+		t.Fatalf("CreateChannelGroup: %v", err)
+	}
+	g, err := st.GetChannelGroupByID(ctx, groupID)
+	if err != nil {
+		t.Fatalf("GetChannelGroupByID: %v", err)
+	}
+	if g.RoutingMode != store.ChannelGroupRoutingPriority {
+		t.Fatalf("expected default routing_mode=priority, got=%q", g.RoutingMode)
+	}
+
+	if err := st.UpdateChannelGroupRoutingMode(ctx, groupID, "Cost"); err != nil {
+		t.Fatalf("UpdateChannelGroupRoutingMode: %v", err)
+	}
+	if err := st.UpdateChannelGroupRoutingMode(ctx, groupID, "cheapest"); err == nil {
+		t.Fatalf("expected unknown routing_mode to be rejected")
+	}
+	g, err = st.GetChannelGroupByName(ctx, "g-cost")
+	if err != nil {
+		t.Fatalf("GetChannelGroupByName: %v", err)
+	}
+	if g.RoutingMode != store.ChannelGroupRoutingCost {
+		t.Fatalf("expected routing_mode=cost, got=%q", g.RoutingMode)
+	}
+}
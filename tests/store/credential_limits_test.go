@@ -0,0 +1,67 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"realms/internal/store"
+)
+
+func intPtr(v int) *int { return &v }
+
+func TestCredentialLimits_SQLite_EndpointDefaultAndCredentialOverride(t *testing.T) {
+	st := openBatchTestStore(t)
+	ctx := context.Background()
+
+	channelID, err := st.CreateUpstreamChannel(ctx, store.UpstreamTypeOpenAICompatible, "ch-limits", "", 0, false, false, false, false)
+	if err != nil {
+		t.Fatalf("CreateUpstreamChannel: %v", err)
+	}
+	ep, err := st.SetUpstreamEndpointBaseURL(ctx, channelID, "https://api.openai.com")
+	if err != nil {
+		t.Fatalf("SetUpstreamEndpointBaseURL: %v", err)
+	}
+	credID, _, err := st.CreateOpenAICompatibleCredential(ctx, ep.ID, nil, "sk-test-limits")
+	if err != nil {
+		t.Fatalf("CreateOpenAICompatibleCredential: %v", err)
+	}
+
+	creds, err := st.ListOpenAICompatibleCredentialsByEndpoint(ctx, ep.ID)
+	if err != nil || len(creds) != 1 {
+		t.Fatalf("ListOpenAICompatibleCredentialsByEndpoint: creds=%d err=%v", len(creds), err)
+	}
+	if l := creds[0].Limits; l.RPM != nil || l.TPM != nil || l.MaxConcurrency != nil {
+		t.Fatalf("expected no limits by default, got %+v", l)
+	}
+
+	if err := st.UpdateUpstreamEndpointLimits(ctx, ep.ID, store.CredentialLimits{RPM: intPtr(60), MaxConcurrency: intPtr(4)}); err != nil {
+		t.Fatalf("UpdateUpstreamEndpointLimits: %v", err)
+	}
+	if err := st.UpdateCredentialLimits(ctx, store.UpstreamTypeOpenAICompatible, credID, store.CredentialLimits{TPM: intPtr(100000), MaxConcurrency: intPtr(0)}); err != nil {
+		t.Fatalf("UpdateCredentialLimits: %v", err)
+	}
+
+	gotEp, err := st.GetUpstreamEndpointByID(ctx, ep.ID)
+	if err != nil {
+		t.Fatalf("GetUpstreamEndpointByID: %v", err)
+	}
+	if l := gotEp.Limits; l.RPM == nil || *l.RPM != 60 || l.TPM != nil || l.MaxConcurrency == nil || *l.MaxConcurrency != 4 {
+		t.Fatalf("unexpected endpoint limits: %+v", l)
+	}
+	creds, err = st.ListOpenAICompatibleCredentialsByEndpoint(ctx, ep.ID)
+	if err != nil || len(creds) != 1 {
+		t.Fatalf("ListOpenAICompatibleCredentialsByEndpoint: creds=%d err=%v", len(creds), err)
+	}
+	// 0 按“不限制/继承 endpoint”存储为 NULL。
+	if l := creds[0].Limits; l.RPM != nil || l.TPM == nil || *l.TPM != 100000 || l.MaxConcurrency != nil {
+		t.Fatalf("unexpected credential limits: %+v", l)
+	}
+
+	endpointID, err := st.GetCredentialEndpointID(ctx, store.UpstreamTypeOpenAICompatible, credID)
+	if err != nil || endpointID != ep.ID {
+		t.Fatalf("GetCredentialEndpointID: got=%d err=%v", endpointID, err)
+	}
+	if err := st.UpdateCredentialLimits(ctx, "unknown", credID, store.CredentialLimits{}); err == nil {
+		t.Fatalf("expected unknown credential type to be rejected")
+	}
+}
@@ -0,0 +1,179 @@
+package store_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"realms/internal/store"
+)
+
+func TestOrganizations_MembershipAndSharedBalance(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "realms.db") + "?_busy_timeout=1000"
+	db, err := store.OpenSQLite(path)
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	defer db.Close()
+	if err := store.EnsureSQLiteSchema(db); err != nil {
+		t.Fatalf("EnsureSQLiteSchema: %v", err)
+	}
+	st := store.New(db)
+	st.SetDialect(store.DialectSQLite)
+	ctx := context.Background()
+
+	adminID, err := st.CreateUser(ctx, "root@example.com", "root", []byte("hash"), store.UserRoleRoot)
+	if err != nil {
+		t.Fatalf("CreateUser(root): %v", err)
+	}
+	ownerID, err := st.CreateUser(ctx, "owner@example.com", "owner", []byte("hash"), store.UserRoleUser)
+	if err != nil {
+		t.Fatalf("CreateUser(owner): %v", err)
+	}
+	memberID, err := st.CreateUser(ctx, "member@example.com", "member", []byte("hash"), store.UserRoleUser)
+	if err != nil {
+		t.Fatalf("CreateUser(member): %v", err)
+	}
+
+	orgID, err := st.CreateOrganization(ctx, "acme", ownerID)
+	if err != nil {
+		t.Fatalf("CreateOrganization: %v", err)
+	}
+	if err := st.AddOrganizationMember(ctx, orgID, memberID, ""); err != nil {
+		t.Fatalf("AddOrganizationMember: %v", err)
+	}
+	otherOrgID, err := st.CreateOrganization(ctx, "other", adminID)
+	if err != nil {
+		t.Fatalf("CreateOrganization(other): %v", err)
+	}
+	if err := st.AddOrganizationMember(ctx, otherOrgID, memberID, store.OrgRoleMember); !errors.Is(err, store.ErrOrganizationMemberExists) {
+		t.Fatalf("expected ErrOrganizationMemberExists, got %v", err)
+	}
+
+	org, m, err := st.GetOrganizationMembership(ctx, memberID)
+	if err != nil {
+		t.Fatalf("GetOrganizationMembership: %v", err)
+	}
+	if org.ID != orgID || m.Role != store.OrgRoleMember || m.CanManage() {
+		t.Fatalf("unexpected membership: org=%+v member=%+v", org, m)
+	}
+	if err := st.UpdateOrganizationMember(ctx, orgID, ownerID, store.OrgRoleAdmin, nil); !errors.Is(err, store.ErrOrganizationLastOwner) {
+		t.Fatalf("expected ErrOrganizationLastOwner on demote, got %v", err)
+	}
+	if err := st.RemoveOrganizationMember(ctx, orgID, ownerID); !errors.Is(err, store.ErrOrganizationLastOwner) {
+		t.Fatalf("expected ErrOrganizationLastOwner on remove, got %v", err)
+	}
+	limit := decimal.RequireFromString("3")
+	if err := st.UpdateOrganizationMember(ctx, orgID, memberID, store.OrgRoleMember, &limit); err != nil {
+		t.Fatalf("UpdateOrganizationMember: %v", err)
+	}
+
+	tokenID, _, err := st.CreateOrganizationToken(ctx, orgID, memberID, nil, "sk-org-member")
+	if err != nil {
+		t.Fatalf("CreateOrganizationToken: %v", err)
+	}
+	personal, err := st.ListUserTokens(ctx, memberID)
+	if err != nil {
+		t.Fatalf("ListUserTokens: %v", err)
+	}
+	if len(personal) != 0 {
+		t.Fatalf("expected org token to be excluded from personal tokens, got %+v", personal)
+	}
+	billing, err := st.GetOrganizationBillingByToken(ctx, tokenID)
+	if err != nil {
+		t.Fatalf("GetOrganizationBillingByToken: %v", err)
+	}
+	if billing.OrgID != orgID || billing.MemberRole != store.OrgRoleMember || billing.SpendLimit30DUSD == nil || !billing.SpendLimit30DUSD.Equal(limit) {
+		t.Fatalf("unexpected billing: %+v", billing)
+	}
+
+	if _, err := st.AddOrganizationBalanceUSDByAdmin(ctx, orgID, adminID, decimal.RequireFromString("10"), "seed"); err != nil {
+		t.Fatalf("AddOrganizationBalanceUSDByAdmin: %v", err)
+	}
+	usageID, err := st.ReserveUsageAndDebitBalance(ctx, store.ReserveUsageInput{
+		RequestID:        "org-req-1",
+		UserID:           memberID,
+		TokenID:          tokenID,
+		ReservedUSD:      decimal.RequireFromString("2"),
+		ReserveExpiresAt: time.Now().Add(time.Hour),
+		OrgID:            &orgID,
+	})
+	if err != nil {
+		t.Fatalf("ReserveUsageAndDebitBalance(org): %v", err)
+	}
+	if err := st.CommitUsageAndRefundBalance(ctx, store.CommitUsageInput{UsageEventID: usageID, CommittedUSD: decimal.RequireFromString("1.25")}); err != nil {
+		t.Fatalf("CommitUsageAndRefundBalance: %v", err)
+	}
+
+	orgBal, err := st.GetOrganizationBalanceUSD(ctx, orgID)
+	if err != nil {
+		t.Fatalf("GetOrganizationBalanceUSD: %v", err)
+	}
+	if !orgBal.Equal(decimal.RequireFromString("8.75")) {
+		t.Fatalf("expected org balance 8.75, got %s", orgBal)
+	}
+	userBal, err := st.GetUserBalanceUSD(ctx, memberID)
+	if err != nil {
+		t.Fatalf("GetUserBalanceUSD: %v", err)
+	}
+	if !userBal.IsZero() {
+		t.Fatalf("expected member personal balance untouched, got %s", userBal)
+	}
+	orgTxs, err := st.ListBalanceTransactionsByOrg(ctx, orgID, 0, nil)
+	if err != nil {
+		t.Fatalf("ListBalanceTransactionsByOrg: %v", err)
+	}
+	if len(orgTxs) != 3 {
+		t.Fatalf("expected 3 org transactions, got %+v", orgTxs)
+	}
+	memberTxs, err := st.ListBalanceTransactionsByUser(ctx, memberID, 0, nil)
+	if err != nil {
+		t.Fatalf("ListBalanceTransactionsByUser: %v", err)
+	}
+	if len(memberTxs) != 0 {
+		t.Fatalf("expected org transactions to be excluded from personal statement, got %+v", memberTxs)
+	}
+
+	now := time.Now()
+	committed, reserved, err := st.SumOrganizationMemberUSD(ctx, orgID, memberID, now.Add(-store.OrganizationMemberSpendWindow), now)
+	if err != nil {
+		t.Fatalf("SumOrganizationMemberUSD: %v", err)
+	}
+	if !committed.Equal(decimal.RequireFromString("1.25")) || !reserved.IsZero() {
+		t.Fatalf("unexpected member spend: committed=%s reserved=%s", committed, reserved)
+	}
+	top, err := st.ListUsageTopOrganizations(ctx, store.UsageTopUsersInput{Since: now.Add(-time.Hour), Until: now.Add(time.Hour), Now: now})
+	if err != nil {
+		t.Fatalf("ListUsageTopOrganizations: %v", err)
+	}
+	if len(top) != 1 || top[0].OrgID != orgID || top[0].Name != "acme" || top[0].Requests != 1 || !top[0].CommittedUSD.Equal(decimal.RequireFromString("1.25")) {
+		t.Fatalf("unexpected org usage: %+v", top)
+	}
+
+	mismatches, err := st.ReconcileBalanceLedger(ctx)
+	if err != nil {
+		t.Fatalf("ReconcileBalanceLedger: %v", err)
+	}
+	if len(mismatches) != 0 {
+		t.Fatalf("expected ledger to reconcile, got %+v", mismatches)
+	}
+
+	if err := st.RemoveOrganizationMember(ctx, orgID, memberID); err != nil {
+		t.Fatalf("RemoveOrganizationMember: %v", err)
+	}
+	if _, _, err := st.GetOrganizationMembership(ctx, memberID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected no membership after removal, got %v", err)
+	}
+	tokens, err := st.ListOrganizationTokens(ctx, orgID)
+	if err != nil {
+		t.Fatalf("ListOrganizationTokens: %v", err)
+	}
+	if len(tokens) != 1 || tokens[0].Status != 0 {
+		t.Fatalf("expected removed member's org token to be revoked, got %+v", tokens)
+	}
+}
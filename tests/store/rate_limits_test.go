@@ -0,0 +1,112 @@
+package store_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"realms/internal/store"
+)
+
+func TestRateLimitPolicy_SQLite(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "realms.db") + "?_busy_timeout=1000"
+
+	db, err := store.OpenSQLite(path)
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	defer db.Close()
+	if err := store.EnsureSQLiteSchema(db); err != nil {
+		t.Fatalf("EnsureSQLiteSchema: %v", err)
+	}
+
+	st := store.New(db)
+	st.SetDialect(store.DialectSQLite)
+
+	ctx := context.Background()
+	if _, err := st.CreateChannelGroup(ctx, "vip", nil, 1, store.DefaultGroupPriceMultiplier); err != nil {
+		t.Fatalf("CreateChannelGroup: %v", err)
+	}
+	if err := st.CreateMainGroup(ctx, "team", nil, 1); err != nil {
+		t.Fatalf("CreateMainGroup: %v", err)
+	}
+	if err := st.ReplaceMainGroupSubgroups(ctx, "team", []string{"vip"}); err != nil {
+		t.Fatalf("ReplaceMainGroupSubgroups: %v", err)
+	}
+	userID, err := st.CreateUser(ctx, "carol@example.com", "carol", []byte("pw-hash"), store.UserRoleUser)
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if err := st.SetUserMainGroup(ctx, userID, "team"); err != nil {
+		t.Fatalf("SetUserMainGroup: %v", err)
+	}
+	tokenID, _, err := st.CreateUserToken(ctx, userID, nil, "sk-test-rate-limit")
+	if err != nil {
+		t.Fatalf("CreateUserToken: %v", err)
+	}
+
+	now := time.Now().UTC()
+	policy, err := st.GetRateLimitPolicy(ctx, userID, tokenID, now)
+	if err != nil {
+		t.Fatalf("GetRateLimitPolicy: %v", err)
+	}
+	if !policy.Empty() {
+		t.Fatalf("expected empty policy by default, got %+v", policy)
+	}
+
+	if err := st.UpdateMainGroupRateLimit(ctx, "team", store.RateLimit{RPM: 60, TPM: 100000}); err != nil {
+		t.Fatalf("UpdateMainGroupRateLimit: %v", err)
+	}
+	if err := st.UpdateMainGroupRateLimit(ctx, "team", store.RateLimit{RPM: -1}); err == nil {
+		t.Fatalf("expected negative rpm to be rejected")
+	}
+	if err := st.UpdateUserTokenLimits(ctx, userID, tokenID, store.UserTokenLimits{RPMLimit: 10}); err != nil {
+		t.Fatalf("UpdateUserTokenLimits: %v", err)
+	}
+	policy, err = st.GetRateLimitPolicy(ctx, userID, tokenID, now)
+	if err != nil {
+		t.Fatalf("GetRateLimitPolicy: %v", err)
+	}
+	if policy.User != (store.RateLimit{RPM: 60, TPM: 100000}) || policy.Token != (store.RateLimit{RPM: 10}) {
+		t.Fatalf("unexpected policy from main group/token: %+v", policy)
+	}
+
+	planID, err := st.CreateSubscriptionPlan(ctx, store.SubscriptionPlanCreate{
+		Code:            "rl",
+		Name:            "rate-limited",
+		GroupName:       "vip",
+		PriceMultiplier: store.DefaultGroupPriceMultiplier,
+		PriceCNY:        decimal.Zero,
+		Limit5HUSD:      decimal.Zero,
+		Limit1DUSD:      decimal.Zero,
+		Limit7DUSD:      decimal.Zero,
+		Limit30DUSD:     decimal.Zero,
+		DurationDays:    30,
+		Status:          1,
+	})
+	if err != nil {
+		t.Fatalf("CreateSubscriptionPlan: %v", err)
+	}
+	if err := st.UpdateSubscriptionPlanRateLimit(ctx, planID, store.RateLimit{RPM: 600}); err != nil {
+		t.Fatalf("UpdateSubscriptionPlanRateLimit: %v", err)
+	}
+	if _, _, err := st.PurchaseSubscriptionByPlanID(ctx, userID, planID, now.Add(-time.Hour)); err != nil {
+		t.Fatalf("PurchaseSubscriptionByPlanID: %v", err)
+	}
+
+	// 订阅套餐覆盖已配置的 RPM；未配置的 TPM 回落到主分组。
+	policy, err = st.GetRateLimitPolicy(ctx, userID, tokenID, now)
+	if err != nil {
+		t.Fatalf("GetRateLimitPolicy: %v", err)
+	}
+	if policy.User != (store.RateLimit{RPM: 600, TPM: 100000}) {
+		t.Fatalf("unexpected user policy with subscription: %+v", policy.User)
+	}
+	if got, err := st.GetSubscriptionPlanRateLimit(ctx, planID); err != nil || got.RPM != 600 {
+		t.Fatalf("GetSubscriptionPlanRateLimit: %+v err=%v", got, err)
+	}
+}
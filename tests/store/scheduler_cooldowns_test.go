@@ -0,0 +1,67 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"realms/internal/store"
+)
+
+func TestSchedulerCooldowns_SQLite_UpsertListAndExpire(t *testing.T) {
+	st := openBatchTestStore(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	if err := st.UpsertSchedulerCooldown(ctx, store.SchedulerCooldown{
+		Kind:        store.SchedulerCooldownChannelBan,
+		Subject:     "1",
+		UntilUnixMS: now.Add(time.Minute).UnixMilli(),
+		Streak:      2,
+	}); err != nil {
+		t.Fatalf("UpsertSchedulerCooldown: %v", err)
+	}
+	// 同一 kind/subject 再次写入应覆盖。
+	if err := st.UpsertSchedulerCooldown(ctx, store.SchedulerCooldown{
+		Kind:        store.SchedulerCooldownChannelBan,
+		Subject:     "1",
+		UntilUnixMS: now.Add(2 * time.Minute).UnixMilli(),
+		Streak:      3,
+	}); err != nil {
+		t.Fatalf("UpsertSchedulerCooldown overwrite: %v", err)
+	}
+	if err := st.UpsertSchedulerCooldown(ctx, store.SchedulerCooldown{
+		Kind:        store.SchedulerCooldownCredentialCooldown,
+		Subject:     "openai_compatible:5",
+		UntilUnixMS: now.Add(-time.Minute).UnixMilli(),
+	}); err != nil {
+		t.Fatalf("UpsertSchedulerCooldown expired: %v", err)
+	}
+
+	active, err := st.ListActiveSchedulerCooldowns(ctx, now)
+	if err != nil {
+		t.Fatalf("ListActiveSchedulerCooldowns: %v", err)
+	}
+	if len(active) != 1 || active[0].Streak != 3 || active[0].UntilUnixMS != now.Add(2*time.Minute).UnixMilli() {
+		t.Fatalf("unexpected active cooldowns: %+v", active)
+	}
+
+	n, err := st.DeleteExpiredSchedulerCooldowns(ctx, now)
+	if err != nil {
+		t.Fatalf("DeleteExpiredSchedulerCooldowns: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("expected 1 expired row deleted, got=%d", n)
+	}
+
+	if err := st.DeleteSchedulerCooldown(ctx, store.SchedulerCooldownChannelBan, "1"); err != nil {
+		t.Fatalf("DeleteSchedulerCooldown: %v", err)
+	}
+	active, err = st.ListActiveSchedulerCooldowns(ctx, now)
+	if err != nil {
+		t.Fatalf("ListActiveSchedulerCooldowns: %v", err)
+	}
+	if len(active) != 0 {
+		t.Fatalf("expected no active cooldowns, got %+v", active)
+	}
+}
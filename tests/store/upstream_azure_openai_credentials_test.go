@@ -0,0 +1,88 @@
+package store_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"realms/internal/store"
+)
+
+func TestAzureOpenAICredential_CRUDAndEndpointAPIVersion(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "realms.db") + "?_busy_timeout=1000"
+
+	db, err := store.OpenSQLite(path)
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	defer db.Close()
+	if err := store.EnsureSQLiteSchema(db); err != nil {
+		t.Fatalf("EnsureSQLiteSchema: %v", err)
+	}
+
+	st := store.New(db)
+	st.SetDialect(store.DialectSQLite)
+
+	ctx := context.Background()
+	channelID, err := st.CreateUpstreamChannel(ctx, store.UpstreamTypeAzureOpenAI, "azure", "", 0, false, false, false, false)
+	if err != nil {
+		t.Fatalf("CreateUpstreamChannel: %v", err)
+	}
+	ep, err := st.SetUpstreamEndpointBaseURL(ctx, channelID, "https://res.openai.azure.com")
+	if err != nil {
+		t.Fatalf("SetUpstreamEndpointBaseURL: %v", err)
+	}
+	if ep.APIVersion != nil {
+		t.Fatalf("expected empty api_version by default, got %q", *ep.APIVersion)
+	}
+	apiVersion := " 2024-10-21 "
+	if err := st.SetUpstreamEndpointAPIVersion(ctx, ep.ID, &apiVersion); err != nil {
+		t.Fatalf("SetUpstreamEndpointAPIVersion: %v", err)
+	}
+	ep, err = st.GetUpstreamEndpointByChannelID(ctx, channelID)
+	if err != nil {
+		t.Fatalf("GetUpstreamEndpointByChannelID: %v", err)
+	}
+	if ep.APIVersion == nil || *ep.APIVersion != "2024-10-21" {
+		t.Fatalf("unexpected api_version: %v", ep.APIVersion)
+	}
+
+	credID, hint, err := st.CreateAzureOpenAICredential(ctx, ep.ID, nil, "azure-key-0123456789")
+	if err != nil {
+		t.Fatalf("CreateAzureOpenAICredential: %v", err)
+	}
+	if hint == nil || *hint == "" {
+		t.Fatalf("expected api_key_hint")
+	}
+	creds, err := st.ListAzureOpenAICredentialsByEndpoint(ctx, ep.ID)
+	if err != nil {
+		t.Fatalf("ListAzureOpenAICredentialsByEndpoint: %v", err)
+	}
+	if len(creds) != 1 || creds[0].ID != credID || creds[0].Status != 1 {
+		t.Fatalf("unexpected creds: %+v", creds)
+	}
+	sec, err := st.GetAzureOpenAICredentialSecret(ctx, credID)
+	if err != nil {
+		t.Fatalf("GetAzureOpenAICredentialSecret: %v", err)
+	}
+	if sec.APIKey != "azure-key-0123456789" || sec.EndpointID != ep.ID {
+		t.Fatalf("unexpected secret: %+v", sec)
+	}
+
+	if err := st.UpdateUpstreamChannelNewAPISetting(ctx, channelID, store.UpstreamChannelSetting{ChatCompletionsEnabled: true, EmbeddingsEnabled: true}); err != nil {
+		t.Fatalf("expected azure channel to accept embeddings: %v", err)
+	}
+	if err := st.UpdateUpstreamChannelNewAPISetting(ctx, channelID, store.UpstreamChannelSetting{MessagesEnabled: true}); err == nil {
+		t.Fatalf("expected azure channel to reject messages")
+	}
+
+	if err := st.DeleteUpstreamChannel(ctx, channelID); err != nil {
+		t.Fatalf("DeleteUpstreamChannel: %v", err)
+	}
+	if _, err := st.GetAzureOpenAICredentialByID(ctx, credID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected credential to be deleted with channel, got err=%v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package store_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"realms/internal/store"
+)
+
+func TestParseBedrockCredentialKey(t *testing.T) {
+	in, err := store.ParseBedrockCredentialKey(" AKIAEXAMPLE | secret/key+1 | us-east-1 ")
+	if err != nil {
+		t.Fatalf("ParseBedrockCredentialKey: %v", err)
+	}
+	if in.AccessKeyID != "AKIAEXAMPLE" || in.SecretAccessKey != "secret/key+1" || in.Region != "us-east-1" || in.SessionToken != "" {
+		t.Fatalf("unexpected input: %+v", in)
+	}
+	in, err = store.ParseBedrockCredentialKey("AKIAEXAMPLE|secret|eu-west-1|session-token")
+	if err != nil || in.SessionToken != "session-token" {
+		t.Fatalf("expected session token, got=%+v err=%v", in, err)
+	}
+	for _, raw := range []string{"", "AKIAEXAMPLE|secret", "AKIAEXAMPLE||us-east-1", "a|b|c|d|e"} {
+		if _, err := store.ParseBedrockCredentialKey(raw); err == nil {
+			t.Fatalf("expected error for %q", raw)
+		}
+	}
+}
+
+func TestBedrockCredential_CRUDAndChannelCascade(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "realms.db") + "?_busy_timeout=1000"
+
+	db, err := store.OpenSQLite(path)
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	defer db.Close()
+	if err := store.EnsureSQLiteSchema(db); err != nil {
+		t.Fatalf("EnsureSQLiteSchema: %v", err)
+	}
+
+	st := store.New(db)
+	st.SetDialect(store.DialectSQLite)
+
+	ctx := context.Background()
+	channelID, err := st.CreateUpstreamChannel(ctx, store.UpstreamTypeBedrock, "bedrock", "", 0, false, false, false, false)
+	if err != nil {
+		t.Fatalf("CreateUpstreamChannel: %v", err)
+	}
+	ep, err := st.SetUpstreamEndpointBaseURL(ctx, channelID, "https://bedrock-runtime.us-east-1.amazonaws.com")
+	if err != nil {
+		t.Fatalf("SetUpstreamEndpointBaseURL: %v", err)
+	}
+
+	credID, hint, err := st.CreateBedrockCredential(ctx, ep.ID, nil, store.BedrockCredentialInput{
+		AccessKeyID:     "AKIAEXAMPLE123456",
+		SecretAccessKey: "secret-access-key",
+		SessionToken:    "session-token",
+		Region:          "us-east-1",
+	})
+	if err != nil {
+		t.Fatalf("CreateBedrockCredential: %v", err)
+	}
+	if hint == nil || *hint == "" {
+		t.Fatalf("expected access_key_hint")
+	}
+	creds, err := st.ListBedrockCredentialsByEndpoint(ctx, ep.ID)
+	if err != nil {
+		t.Fatalf("ListBedrockCredentialsByEndpoint: %v", err)
+	}
+	if len(creds) != 1 || creds[0].ID != credID || creds[0].Region != "us-east-1" || creds[0].Status != 1 {
+		t.Fatalf("unexpected creds: %+v", creds)
+	}
+	sec, err := st.GetBedrockCredentialSecret(ctx, credID)
+	if err != nil {
+		t.Fatalf("GetBedrockCredentialSecret: %v", err)
+	}
+	if sec.AccessKeyID != "AKIAEXAMPLE123456" || sec.SecretAccessKey != "secret-access-key" || sec.SessionToken != "session-token" || sec.EndpointID != ep.ID {
+		t.Fatalf("unexpected secret: %+v", sec)
+	}
+
+	if err := st.UpdateUpstreamChannelNewAPISetting(ctx, channelID, store.UpstreamChannelSetting{ChatCompletionsEnabled: true, ResponsesEnabled: true}); err != nil {
+		t.Fatalf("UpdateUpstreamChannelNewAPISetting: %v", err)
+	}
+	ch, err := st.GetUpstreamChannelByID(ctx, channelID)
+	if err != nil {
+		t.Fatalf("GetUpstreamChannelByID: %v", err)
+	}
+	if !ch.Setting.ChatCompletionsEnabled || ch.Setting.ResponsesEnabled {
+		t.Fatalf("expected bedrock to keep chat and drop responses, got=%+v", ch.Setting)
+	}
+
+	if err := st.DeleteUpstreamChannel(ctx, channelID); err != nil {
+		t.Fatalf("DeleteUpstreamChannel: %v", err)
+	}
+	if _, err := st.GetBedrockCredentialByID(ctx, credID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected credential to be deleted with channel, got err=%v", err)
+	}
+}
@@ -329,7 +329,7 @@ func TestUpstreamChannel_AnthropicAllowsEmptyAPISettings(t *testing.T) {
 	if err != nil {
 		t.Fatalf("GetUpstreamChannelByID(anthropic after update): %v", err)
 	}
-	if ch.Setting.ChatCompletionsEnabled || ch.Setting.ResponsesEnabled {
-		t.Fatalf("expected anthropic update to clear openai api capabilities, got %+v", ch.Setting)
+	if !ch.Setting.ChatCompletionsEnabled || ch.Setting.ResponsesEnabled {
+		t.Fatalf("expected anthropic update to keep translated chat/completions and clear responses, got %+v", ch.Setting)
 	}
 }
@@ -0,0 +1,74 @@
+package store_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"realms/internal/store"
+)
+
+func TestGeminiCredential_CRUDAndChannelCascade(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "realms.db") + "?_busy_timeout=1000"
+
+	db, err := store.OpenSQLite(path)
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	defer db.Close()
+	if err := store.EnsureSQLiteSchema(db); err != nil {
+		t.Fatalf("EnsureSQLiteSchema: %v", err)
+	}
+
+	st := store.New(db)
+	st.SetDialect(store.DialectSQLite)
+
+	ctx := context.Background()
+	channelID, err := st.CreateUpstreamChannel(ctx, store.UpstreamTypeGemini, "gemini", "", 0, false, false, false, false)
+	if err != nil {
+		t.Fatalf("CreateUpstreamChannel: %v", err)
+	}
+	ep, err := st.SetUpstreamEndpointBaseURL(ctx, channelID, "https://generativelanguage.googleapis.com")
+	if err != nil {
+		t.Fatalf("SetUpstreamEndpointBaseURL: %v", err)
+	}
+
+	credID, hint, err := st.CreateGeminiCredential(ctx, ep.ID, nil, "AIzaSyExampleKey1234")
+	if err != nil {
+		t.Fatalf("CreateGeminiCredential: %v", err)
+	}
+	if hint == nil || *hint == "" {
+		t.Fatalf("expected api_key_hint")
+	}
+	creds, err := st.ListGeminiCredentialsByEndpoint(ctx, ep.ID)
+	if err != nil {
+		t.Fatalf("ListGeminiCredentialsByEndpoint: %v", err)
+	}
+	if len(creds) != 1 || creds[0].ID != credID || creds[0].Status != 1 {
+		t.Fatalf("unexpected creds: %+v", creds)
+	}
+	sec, err := st.GetGeminiCredentialSecret(ctx, credID)
+	if err != nil {
+		t.Fatalf("GetGeminiCredentialSecret: %v", err)
+	}
+	if sec.APIKey != "AIzaSyExampleKey1234" || sec.EndpointID != ep.ID {
+		t.Fatalf("unexpected secret: %+v", sec)
+	}
+
+	if err := st.UpdateUpstreamChannelNewAPISetting(ctx, channelID, store.UpstreamChannelSetting{ChatCompletionsEnabled: true}); err == nil {
+		t.Fatalf("expected gemini channel to reject chat_completions")
+	}
+	if err := st.UpdateUpstreamChannelNewAPISetting(ctx, channelID, store.UpstreamChannelSetting{}); err != nil {
+		t.Fatalf("UpdateUpstreamChannelNewAPISetting: %v", err)
+	}
+
+	if err := st.DeleteUpstreamChannel(ctx, channelID); err != nil {
+		t.Fatalf("DeleteUpstreamChannel: %v", err)
+	}
+	if _, err := st.GetGeminiCredentialByID(ctx, credID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected credential to be deleted with channel, got err=%v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"realms/internal/store"
+)
+
+func TestUsageEventRoutingTrace_SQLite_FinalizeKeepsExistingWhenNil(t *testing.T) {
+	st := openBatchTestStore(t)
+	ctx := context.Background()
+
+	userID, err := st.CreateUser(ctx, "trace@example.com", "trace", []byte("pw-hash"), store.UserRoleUser)
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	name := "t1"
+	tokenID, _, err := st.CreateUserToken(ctx, userID, &name, "sk-test-trace")
+	if err != nil {
+		t.Fatalf("CreateUserToken: %v", err)
+	}
+	usageID, err := st.ReserveUsage(ctx, store.ReserveUsageInput{
+		RequestID:        "req_trace_1",
+		UserID:           userID,
+		TokenID:          tokenID,
+		ReservedUSD:      decimal.Zero,
+		ReserveExpiresAt: time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("ReserveUsage: %v", err)
+	}
+
+	if got, err := st.GetUsageEventRoutingTrace(ctx, usageID); err != nil || got != "" {
+		t.Fatalf("expected empty trace before finalize, got=%q err=%v", got, err)
+	}
+
+	trace := `{"dry_run":false,"candidates":[{"channel_id":1,"priority":0}],"skips":[],"pick":{"channel_id":1}}`
+	if err := st.FinalizeUsageEvent(ctx, store.FinalizeUsageEventInput{
+		UsageEventID: usageID,
+		Endpoint:     "/v1/responses",
+		Method:       "POST",
+		StatusCode:   200,
+		RoutingTrace: &trace,
+	}); err != nil {
+		t.Fatalf("FinalizeUsageEvent: %v", err)
+	}
+	if got, err := st.GetUsageEventRoutingTrace(ctx, usageID); err != nil || got != trace {
+		t.Fatalf("unexpected trace after finalize, got=%q err=%v", got, err)
+	}
+
+	// 未开启追踪的再次 finalize 不应清空已有记录。
+	if err := st.FinalizeUsageEvent(ctx, store.FinalizeUsageEventInput{
+		UsageEventID: usageID,
+		Endpoint:     "/v1/responses",
+		Method:       "POST",
+		StatusCode:   200,
+	}); err != nil {
+		t.Fatalf("FinalizeUsageEvent(no trace): %v", err)
+	}
+	if got, err := st.GetUsageEventRoutingTrace(ctx, usageID); err != nil || got != trace {
+		t.Fatalf("expected trace preserved, got=%q err=%v", got, err)
+	}
+}
@@ -0,0 +1,158 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"realms/internal/quota"
+	"realms/internal/store"
+)
+
+type stubQuotaProvider struct {
+	reserveCalls int
+}
+
+func (p *stubQuotaProvider) Reserve(_ context.Context, _ quota.ReserveInput) (quota.ReserveResult, error) {
+	p.reserveCalls++
+	return quota.ReserveResult{UsageEventID: 1}, nil
+}
+
+func (p *stubQuotaProvider) Commit(_ context.Context, _ quota.CommitInput) error { return nil }
+func (p *stubQuotaProvider) Void(_ context.Context, _ int64) error               { return nil }
+
+func TestUserTokenLimits_SQLite(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "realms.db") + "?_busy_timeout=1000"
+
+	db, err := store.OpenSQLite(path)
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	defer db.Close()
+	if err := store.EnsureSQLiteSchema(db); err != nil {
+		t.Fatalf("EnsureSQLiteSchema: %v", err)
+	}
+
+	st := store.New(db)
+	st.SetDialect(store.DialectSQLite)
+
+	ctx := context.Background()
+	userID, err := st.CreateUser(ctx, "alice@example.com", "alice", []byte("pw-hash"), store.UserRoleUser)
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	name := "limited"
+	tokenID, _, err := st.CreateUserToken(ctx, userID, &name, "sk-test-limited")
+	if err != nil {
+		t.Fatalf("CreateUserToken: %v", err)
+	}
+
+	limits, err := st.GetUserTokenLimits(ctx, tokenID)
+	if err != nil {
+		t.Fatalf("GetUserTokenLimits: %v", err)
+	}
+	if limits.ExpiresAt != nil || len(limits.BudgetWindows()) != 0 || len(limits.AllowedModels) != 0 {
+		t.Fatalf("expected no limits by default, got %+v", limits)
+	}
+
+	budget1d := decimal.RequireFromString("1.5")
+	if err := st.UpdateUserTokenLimits(ctx, userID, tokenID, store.UserTokenLimits{
+		Budget1dUSD:   &budget1d,
+		AllowedModels: []string{" gpt-5 ", "GPT-5", "", "claude-sonnet"},
+	}); err != nil {
+		t.Fatalf("UpdateUserTokenLimits: %v", err)
+	}
+	tok, err := st.GetUserTokenByID(ctx, userID, tokenID)
+	if err != nil {
+		t.Fatalf("GetUserTokenByID: %v", err)
+	}
+	if tok.Limits.Budget1dUSD == nil || !tok.Limits.Budget1dUSD.Equal(budget1d) {
+		t.Fatalf("unexpected budget_1d_usd: %+v", tok.Limits.Budget1dUSD)
+	}
+	if got := tok.Limits.AllowedModels; len(got) != 2 || got[0] != "claude-sonnet" || got[1] != "gpt-5" {
+		t.Fatalf("unexpected allowed_models: %v", got)
+	}
+	if err := st.UpdateUserTokenLimits(ctx, userID+1, tokenID, store.UserTokenLimits{}); err == nil {
+		t.Fatalf("expected update by other user to fail")
+	}
+
+	next := &stubQuotaProvider{}
+	p := quota.NewTokenLimitProvider(st, next)
+	model := func(s string) *string { return &s }
+
+	if _, err := p.Reserve(ctx, quota.ReserveInput{UserID: userID, TokenID: tokenID, Model: model("gpt-4o")}); !errors.Is(err, quota.ErrTokenModelNotAllowed) {
+		t.Fatalf("expected ErrTokenModelNotAllowed, got %v", err)
+	}
+	if _, err := p.Reserve(ctx, quota.ReserveInput{UserID: userID, TokenID: tokenID, Model: model("GPT-5")}); err != nil {
+		t.Fatalf("Reserve(allowed model): %v", err)
+	}
+	if next.reserveCalls != 1 {
+		t.Fatalf("expected next provider to be called once, got %d", next.reserveCalls)
+	}
+
+	now := time.Now().UTC()
+	usageID, err := st.ReserveUsage(ctx, store.ReserveUsageInput{
+		RequestID:        "req-1",
+		UserID:           userID,
+		TokenID:          tokenID,
+		Model:            model("gpt-5"),
+		ReservedUSD:      decimal.Zero,
+		ReserveExpiresAt: now.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("ReserveUsage: %v", err)
+	}
+	if err := st.CommitUsage(ctx, store.CommitUsageInput{
+		UsageEventID: usageID,
+		CommittedUSD: decimal.RequireFromString("1.5"),
+	}); err != nil {
+		t.Fatalf("CommitUsage: %v", err)
+	}
+	if _, err := p.Reserve(ctx, quota.ReserveInput{UserID: userID, TokenID: tokenID, Model: model("gpt-5")}); !errors.Is(err, quota.ErrTokenBudgetExceeded) {
+		t.Fatalf("expected ErrTokenBudgetExceeded, got %v", err)
+	}
+
+	// 预留事务内校验：已有预留 + 本次预留超过上限时拒绝，而非仅比较已用额度。
+	name2 := "capped"
+	cappedID, _, err := st.CreateUserToken(ctx, userID, &name2, "sk-test-capped")
+	if err != nil {
+		t.Fatalf("CreateUserToken(capped): %v", err)
+	}
+	budget := decimal.RequireFromString("1")
+	if err := st.UpdateUserTokenLimits(ctx, userID, cappedID, store.UserTokenLimits{BudgetUSD: &budget}); err != nil {
+		t.Fatalf("UpdateUserTokenLimits(capped): %v", err)
+	}
+	reserve := func(requestID string) error {
+		_, err := st.ReserveUsage(ctx, store.ReserveUsageInput{
+			RequestID:        requestID,
+			UserID:           userID,
+			TokenID:          cappedID,
+			Model:            model("gpt-5"),
+			ReservedUSD:      decimal.RequireFromString("0.6"),
+			ReserveExpiresAt: now.Add(time.Hour),
+		})
+		return err
+	}
+	if err := reserve("req-capped-1"); err != nil {
+		t.Fatalf("ReserveUsage(within budget): %v", err)
+	}
+	if err := reserve("req-capped-2"); !errors.Is(err, quota.ErrTokenBudgetExceeded) {
+		t.Fatalf("expected ErrTokenBudgetExceeded for overshooting reservation, got %v", err)
+	}
+
+	if _, err := st.GetTokenAuthByRawToken(ctx, "sk-test-limited"); err != nil {
+		t.Fatalf("GetTokenAuthByRawToken(before expiry): %v", err)
+	}
+	expired := now.Add(-time.Minute)
+	if err := st.UpdateUserTokenLimits(ctx, userID, tokenID, store.UserTokenLimits{ExpiresAt: &expired}); err != nil {
+		t.Fatalf("UpdateUserTokenLimits(expires_at): %v", err)
+	}
+	if _, err := st.GetTokenAuthByRawToken(ctx, "sk-test-limited"); !errors.Is(err, store.ErrUserTokenExpired) {
+		t.Fatalf("expected ErrUserTokenExpired, got %v", err)
+	}
+}
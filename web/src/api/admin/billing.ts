@@ -1,5 +1,6 @@
 import { api } from '../client';
 import type { APIResponse } from '../types';
+import type { AdminRateLimit } from './mainGroups';
 
 export type AdminSubscriptionPlan = {
   id: number;
@@ -61,6 +62,16 @@ export async function deleteAdminSubscriptionPlan(planID: number) {
   return res.data;
 }
 
+export async function getAdminSubscriptionPlanRateLimit(planID: number) {
+  const res = await api.get<APIResponse<AdminRateLimit>>(`/api/admin/subscriptions/${planID}/rate-limit`);
+  return res.data;
+}
+
+export async function updateAdminSubscriptionPlanRateLimit(planID: number, req: AdminRateLimit) {
+  const res = await api.put<APIResponse<void>>(`/api/admin/subscriptions/${planID}/rate-limit`, req);
+  return res.data;
+}
+
 export type AdminSubscriptionOrder = {
   id: number;
   user_email: string;
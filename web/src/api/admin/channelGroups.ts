@@ -1,12 +1,16 @@
 import { api } from '../client';
+import type { ChannelSchedule } from '../channels';
 import type { APIResponse } from '../types';
 
+export type ChannelGroupRoutingMode = 'priority' | 'cost';
+
 export type AdminChannelGroup = {
   id: number;
   name: string;
   description?: string | null;
   price_multiplier: string;
   status: number;
+  routing_mode?: ChannelGroupRoutingMode;
   created_at: string;
   updated_at: string;
   is_default?: boolean;
@@ -31,6 +35,30 @@ export type AdminChannelGroupMember = {
 
   priority: number;
   promotion: boolean;
+  schedule?: ChannelSchedule | null;
+
+  canary_status?: '' | 'active' | 'rolled_back';
+  canary_percent?: number;
+  canary_started_at?: string;
+};
+
+export type AdminChannelHealthStats = {
+  requests: number;
+  failures: number;
+  error_rate: number;
+  avg_latency_ms: number;
+  avg_first_token_ms: number;
+};
+
+export type AdminChannelGroupCanary = {
+  member_id: number;
+  channel_id: number;
+  canary_status: '' | 'active' | 'rolled_back';
+  canary_percent: number;
+  canary_started_at?: string;
+  canary: AdminChannelHealthStats;
+  siblings: AdminChannelHealthStats;
+  sibling_channel_ids: number[];
 };
 
 export type AdminChannelRef = {
@@ -66,6 +94,7 @@ type CreateAdminChannelGroupRequest = {
   description?: string | null;
   price_multiplier?: string;
   status?: number;
+  routing_mode?: ChannelGroupRoutingMode;
 };
 
 export async function createAdminChannelGroup(req: CreateAdminChannelGroupRequest) {
@@ -93,6 +122,7 @@ type UpdateAdminChannelGroupRequest = {
   description?: string | null;
   price_multiplier?: string;
   status?: number;
+  routing_mode?: ChannelGroupRoutingMode;
 };
 
 export async function updateAdminChannelGroup(groupID: number, req: UpdateAdminChannelGroupRequest) {
@@ -115,8 +145,11 @@ export async function createAdminChildChannelGroup(parentGroupID: number, req: C
   return res.data;
 }
 
-export async function addAdminChannelGroupChannelMember(parentGroupID: number, channelID: number) {
-  const res = await api.post<APIResponse<void>>(`/api/admin/channel-groups/${parentGroupID}/children/channels`, { channel_id: channelID });
+export async function addAdminChannelGroupChannelMember(parentGroupID: number, channelID: number, canaryPercent?: number) {
+  const res = await api.post<APIResponse<void>>(`/api/admin/channel-groups/${parentGroupID}/children/channels`, {
+    channel_id: channelID,
+    canary_percent: canaryPercent || undefined,
+  });
   return res.data;
 }
 
@@ -134,3 +167,28 @@ export async function reorderAdminChannelGroupMembers(parentGroupID: number, ord
   const res = await api.post<APIResponse<void>>(`/api/admin/channel-groups/${parentGroupID}/children/reorder`, orderedMemberIDs);
   return res.data;
 }
+
+export async function updateAdminChannelGroupMemberSchedule(parentGroupID: number, memberID: number, schedule: ChannelSchedule | null) {
+  const res = await api.put<APIResponse<void>>(`/api/admin/channel-groups/${parentGroupID}/members/${memberID}/schedule`, { schedule });
+  return res.data;
+}
+
+export async function getAdminChannelGroupMemberCanary(parentGroupID: number, memberID: number) {
+  const res = await api.get<APIResponse<AdminChannelGroupCanary>>(`/api/admin/channel-groups/${parentGroupID}/members/${memberID}/canary`);
+  return res.data;
+}
+
+export async function updateAdminChannelGroupMemberCanary(parentGroupID: number, memberID: number, canaryPercent: number) {
+  const res = await api.put<APIResponse<void>>(`/api/admin/channel-groups/${parentGroupID}/members/${memberID}/canary`, { canary_percent: canaryPercent });
+  return res.data;
+}
+
+export async function promoteAdminChannelGroupMemberCanary(parentGroupID: number, memberID: number) {
+  const res = await api.post<APIResponse<void>>(`/api/admin/channel-groups/${parentGroupID}/members/${memberID}/canary/promote`);
+  return res.data;
+}
+
+export async function rollbackAdminChannelGroupMemberCanary(parentGroupID: number, memberID: number) {
+  const res = await api.post<APIResponse<void>>(`/api/admin/channel-groups/${parentGroupID}/members/${memberID}/canary/rollback`);
+  return res.data;
+}
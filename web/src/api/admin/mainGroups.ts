@@ -57,3 +57,18 @@ export async function replaceAdminMainGroupSubgroups(name: string, subgroups: st
   const res = await api.put<APIResponse<void>>(`/api/admin/main-groups/${encodeURIComponent(name)}/subgroups`, { subgroups });
   return res.data;
 }
+
+export type AdminRateLimit = {
+  rpm_limit: number;
+  tpm_limit: number;
+};
+
+export async function getAdminMainGroupRateLimit(name: string) {
+  const res = await api.get<APIResponse<AdminRateLimit>>(`/api/admin/main-groups/${encodeURIComponent(name)}/rate-limit`);
+  return res.data;
+}
+
+export async function updateAdminMainGroupRateLimit(name: string, req: AdminRateLimit) {
+  const res = await api.put<APIResponse<void>>(`/api/admin/main-groups/${encodeURIComponent(name)}/rate-limit`, req);
+  return res.data;
+}
@@ -0,0 +1,39 @@
+import { api } from '../client';
+import type { APIResponse } from '../types';
+
+export type RouteTraceCandidate = {
+  channel_id: number;
+  route_group?: string;
+  priority: number;
+  promotion?: boolean;
+};
+
+export type RouteTraceSkip = {
+  level: 'group' | 'channel' | 'endpoint' | 'credential';
+  group?: string;
+  channel_id?: number;
+  endpoint_id?: number;
+  credential_key?: string;
+  reason: string;
+};
+
+export type RouteTracePick = {
+  channel_id: number;
+  channel_type: string;
+  endpoint_id: number;
+  credential_key: string;
+  route_group?: string;
+};
+
+export type RouteTrace = {
+  dry_run: boolean;
+  candidates: RouteTraceCandidate[];
+  skips: RouteTraceSkip[];
+  pick?: RouteTracePick;
+  error?: string;
+};
+
+export async function explainAdminRoute(req: { token_id: number; model: string; endpoint?: string; route_key?: string }) {
+  const res = await api.post<APIResponse<RouteTrace>>('/api/admin/routing/explain', req);
+  return res.data;
+}
@@ -1,5 +1,6 @@
 import { api } from '../client';
 import type { APIResponse } from '../types';
+import type { RouteTrace } from './routing';
 
 export type AdminUsageWindow = {
   window: string;
@@ -29,6 +30,14 @@ export type AdminUsageUser = {
   reserved_usd: string;
 };
 
+export type AdminUsageOrg = {
+  org_id: number;
+  name: string;
+  requests: number;
+  committed_usd: string;
+  reserved_usd: string;
+};
+
 export type AdminUsageEvent = {
   id: number;
   time: string;
@@ -69,6 +78,7 @@ export type AdminUsagePage = {
   limit: number;
   window: AdminUsageWindow;
   top_users: AdminUsageUser[];
+  top_orgs?: AdminUsageOrg[];
   events: AdminUsageEvent[];
   next_before_id?: number;
   prev_after_id?: number;
@@ -97,6 +107,7 @@ export type UsageEventDetail = {
   event_id: number;
   pricing_breakdown?: UsageEventPricingBreakdown;
   model_check?: UsageEventModelCheck;
+  routing_trace?: RouteTrace;
 };
 
 export type UsageEventModelCheck = {
@@ -107,6 +107,29 @@ export async function createTopupOrder(amountCNY: string) {
   return res.data;
 }
 
+export type BalanceTransactionView = {
+  id: number;
+  type: string;
+  amount_usd: string;
+  balance_after_usd: string;
+  usage_event_id?: number;
+  topup_order_id?: number;
+  redemption_code_id?: number;
+  actor_user_id?: number;
+  note?: string;
+  created_at: string;
+};
+
+export type BalanceTransactionsResponse = {
+  transactions: BalanceTransactionView[];
+  next_before_id?: number;
+};
+
+export async function listBalanceTransactions(params?: { limit?: number; before_id?: number }) {
+  const res = await api.get<APIResponse<BalanceTransactionsResponse>>('/api/billing/balance/transactions', { params });
+  return res.data;
+}
+
 export async function getPayPage(kind: string, orderId: number) {
   const res = await api.get<APIResponse<BillingPayPageResponse>>(`/api/billing/pay/${encodeURIComponent(kind)}/${orderId}`);
   return res.data;
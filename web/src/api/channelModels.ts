@@ -8,6 +8,8 @@ export type ChannelModelRuntime = {
   banned_remaining?: string;
   ban_streak: number;
   banned_active: boolean;
+  ttft_ewma_ms?: number;
+  error_rate_ewma?: number;
 };
 
 export type ChannelModelBinding = {
@@ -16,6 +18,10 @@ export type ChannelModelBinding = {
   public_id: string;
   upstream_model: string;
   status: number;
+  cost_input_usd_per_1m?: string;
+  cost_output_usd_per_1m?: string;
+  cost_cache_input_usd_per_1m?: string;
+  cost_cache_output_usd_per_1m?: string;
   runtime: ChannelModelRuntime;
 };
 
@@ -58,3 +64,20 @@ export async function updateChannelModel(
   );
   return res.data;
 }
+
+export async function updateChannelModelCost(
+  channelID: number,
+  bindingID: number,
+  cost: {
+    cost_input_usd_per_1m?: string | null;
+    cost_output_usd_per_1m?: string | null;
+    cost_cache_input_usd_per_1m?: string | null;
+    cost_cache_output_usd_per_1m?: string | null;
+  },
+) {
+  const res = await api.put<APIResponse<void>>(
+    `/api/channel/${channelID}/models/${bindingID}/cost`,
+    cost,
+  );
+  return res.data;
+}
@@ -6,12 +6,26 @@ export type UpstreamChannelSetting = {
   thinking_to_content?: boolean;
   chat_completions_enabled?: boolean;
   responses_enabled?: boolean;
+  embeddings_enabled?: boolean;
+  messages_enabled?: boolean;
   proxy?: string;
   pass_through_body_enabled?: boolean;
   system_prompt?: string;
   system_prompt_override?: boolean;
 };
 
+// ChannelScheduleWindow：days 为星期几（0=周日），为空表示每天；end < start 表示跨午夜，end == start 表示全天。
+// 时间按管理后台时区解释。
+export type ChannelScheduleWindow = {
+  days?: number[];
+  start: string;
+  end: string;
+};
+
+export type ChannelSchedule = {
+  windows: ChannelScheduleWindow[];
+};
+
 export type Channel = {
   id: number;
   type: string;
@@ -28,6 +42,7 @@ export type Channel = {
   allow_safety_identifier: boolean;
 
   openai_organization?: string | null;
+  api_version?: string | null;
   test_model?: string | null;
   remark?: string | null;
   auto_ban?: boolean;
@@ -38,6 +53,8 @@ export type Channel = {
   model_suffix_preserve?: string;
   request_body_blacklist?: string;
   request_body_whitelist?: string;
+  schedule?: ChannelSchedule | null;
+  limits?: CredentialLimits;
 
   tag?: string | null;
   weight: number;
@@ -72,6 +89,11 @@ export type ChannelRuntime = {
   banned_remaining?: string;
   ban_streak: number;
   banned_active: boolean;
+  selections: number;
+  weight_share?: string;
+  traffic_share?: string;
+  ttft_ewma_ms?: number;
+  error_rate_ewma?: number;
 };
 
 export type ChannelAdminItem = Channel & {
@@ -111,6 +133,7 @@ type CreateChannelRequest = {
   name: string;
   groups?: string;
   base_url: string;
+  api_version?: string;
   key?: string;
   priority?: number;
   promotion?: boolean;
@@ -127,6 +150,7 @@ type UpdateChannelRequest = {
   name?: string;
   groups?: string;
   base_url?: string;
+  api_version?: string;
   key?: string;
   status?: number;
   priority?: number;
@@ -167,12 +191,20 @@ export async function getChannel(channelID: number) {
   return res.data;
 }
 
+// CredentialLimits 为 credential/endpoint 的 RPM/TPM/并发上限；缺省表示不限制（credential 缺省时继承 endpoint）。
+export type CredentialLimits = {
+  rpm_limit?: number | null;
+  tpm_limit?: number | null;
+  max_concurrency?: number | null;
+};
+
 export type ChannelCredential = {
   id: number;
   name?: string | null;
   api_key_hint?: string | null;
   masked_key: string;
   status: number;
+  limits?: CredentialLimits;
 };
 
 export type CodexOAuthAccount = {
@@ -198,6 +230,7 @@ export type CodexOAuthAccount = {
   quota_secondary_reset_at?: string | null;
   quota_updated_at?: string | null;
   quota_error?: string | null;
+  limits?: CredentialLimits;
   created_at: string;
   updated_at: string;
 };
@@ -220,6 +253,21 @@ export async function deleteChannelCredential(channelID: number, credentialID: n
   return res.data;
 }
 
+export async function updateChannelCredentialLimits(channelID: number, credentialID: number, limits: CredentialLimits) {
+  const res = await api.put<APIResponse<void>>(`/api/channel/${channelID}/credentials/${credentialID}/limits`, limits);
+  return res.data;
+}
+
+export async function updateChannelCodexAccountLimits(channelID: number, accountID: number, limits: CredentialLimits) {
+  const res = await api.put<APIResponse<void>>(`/api/channel/${channelID}/codex-accounts/${accountID}/limits`, limits);
+  return res.data;
+}
+
+export async function updateChannelLimits(channelID: number, limits: CredentialLimits) {
+  const res = await api.put<APIResponse<void>>(`/api/channel/${channelID}/limits`, limits);
+  return res.data;
+}
+
 export async function listChannelCodexAccounts(channelID: number) {
   const res = await api.get<APIResponse<CodexOAuthAccount[]>>(`/api/channel/${channelID}/codex-accounts`);
   return res.data;
@@ -334,6 +382,8 @@ export async function updateChannelSetting(
     thinking_to_content?: boolean;
     chat_completions_enabled?: boolean;
     responses_enabled?: boolean;
+    embeddings_enabled?: boolean;
+    messages_enabled?: boolean;
     proxy?: string;
     pass_through_body_enabled?: boolean;
     system_prompt?: string;
@@ -373,3 +423,9 @@ export async function updateChannelStatusCodeMapping(channelID: number, statusCo
   const res = await api.put<APIResponse<void>>(`/api/channel/${channelID}/status_code_mapping`, { status_code_mapping: statusCodeMapping });
   return res.data;
 }
+
+// schedule 为 null 时清除时间窗（全天可用）。
+export async function updateChannelSchedule(channelID: number, schedule: ChannelSchedule | null) {
+  const res = await api.put<APIResponse<void>>(`/api/channel/${channelID}/schedule`, { schedule });
+  return res.data;
+}
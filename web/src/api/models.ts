@@ -27,6 +27,7 @@ export type ManagedModel = {
   priority_output_usd_per_1m?: string | null;
   priority_cache_input_usd_per_1m?: string | null;
   high_context_pricing?: ManagedModelHighContextPricing | null;
+  batch_discount?: string | null;
   status: number;
   icon_url?: string | null;
 };
@@ -45,6 +46,7 @@ export type UserManagedModel = {
   priority_output_usd_per_1m?: string | null;
   priority_cache_input_usd_per_1m?: string | null;
   high_context_pricing?: ManagedModelHighContextPricing | null;
+  batch_discount?: string | null;
   status: number;
   icon_url?: string | null;
 };
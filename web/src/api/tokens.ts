@@ -9,6 +9,38 @@ export type UserToken = {
   created_at: string;
   revoked_at?: string | null;
   last_used_at?: string | null;
+
+  expires_at?: string | null;
+  budget_usd?: string | null;
+  budget_1d_usd?: string | null;
+  budget_7d_usd?: string | null;
+  budget_30d_usd?: string | null;
+  allowed_models?: string[] | null;
+  rpm_limit?: number;
+  tpm_limit?: number;
+
+  allowed_ips?: string[] | null;
+  allowed_referers?: string[] | null;
+  access_violation_limit?: number;
+  access_violations?: number;
+  last_client_ip?: string | null;
+};
+
+export type UserTokenAccess = {
+  allowed_ips: string[];
+  allowed_referers: string[];
+  access_violation_limit: number;
+};
+
+export type UserTokenLimits = {
+  expires_at?: string | null;
+  budget_usd?: string | null;
+  budget_1d_usd?: string | null;
+  budget_7d_usd?: string | null;
+  budget_30d_usd?: string | null;
+  allowed_models?: string[];
+  rpm_limit?: number;
+  tpm_limit?: number;
 };
 
 type CreatedToken = {
@@ -34,6 +66,16 @@ export async function createUserToken(name?: string) {
   return res.data;
 }
 
+export async function updateUserTokenLimits(tokenID: number, limits: UserTokenLimits) {
+  const res = await api.put<APIResponse<UserToken>>(`/api/token/${tokenID}/limits`, limits);
+  return res.data;
+}
+
+export async function updateUserTokenAccess(tokenID: number, access: UserTokenAccess) {
+  const res = await api.put<APIResponse<UserToken>>(`/api/token/${tokenID}/access`, access);
+  return res.data;
+}
+
 export async function rotateUserToken(tokenID: number) {
   const res = await api.post<APIResponse<CreatedToken>>(`/api/token/${tokenID}/rotate`);
   return res.data;
@@ -25,6 +25,8 @@ import {
   revealUserToken,
   revokeUserToken,
   rotateUserToken,
+  updateUserTokenAccess,
+  updateUserTokenLimits,
   type UserTokenChannelGroups,
   type UserToken,
 } from '../api/tokens';
@@ -55,6 +57,42 @@ type TokenChannelGroupRow = {
   description?: string | null;
 };
 
+function toDateTimeLocal(raw: string): string {
+  const d = new Date(raw);
+  if (Number.isNaN(d.getTime())) return '';
+  const pad = (n: number) => String(n).padStart(2, '0');
+  return `${d.getFullYear()}-${pad(d.getMonth() + 1)}-${pad(d.getDate())}T${pad(d.getHours())}:${pad(d.getMinutes())}`;
+}
+
+function tokenHasLimits(t: UserToken): boolean {
+  return !!(t.expires_at || t.budget_usd || t.budget_1d_usd || t.budget_7d_usd || t.budget_30d_usd || (t.allowed_models && t.allowed_models.length > 0) || (t.rpm_limit || 0) > 0 || (t.tpm_limit || 0) > 0);
+}
+
+function tokenLimitsSummary(t: UserToken): string {
+  const parts: string[] = [];
+  if (t.expires_at) parts.push(`过期：${formatLocalDateTimeMinute(t.expires_at)}`);
+  if (t.budget_usd) parts.push(`累计 $${t.budget_usd}`);
+  if (t.budget_1d_usd) parts.push(`1 天 $${t.budget_1d_usd}`);
+  if (t.budget_7d_usd) parts.push(`7 天 $${t.budget_7d_usd}`);
+  if (t.budget_30d_usd) parts.push(`30 天 $${t.budget_30d_usd}`);
+  if (t.allowed_models && t.allowed_models.length > 0) parts.push(`模型：${t.allowed_models.join(', ')}`);
+  if ((t.rpm_limit || 0) > 0) parts.push(`RPM ${t.rpm_limit}`);
+  if ((t.tpm_limit || 0) > 0) parts.push(`TPM ${t.tpm_limit}`);
+  return parts.join('；');
+}
+
+function tokenHasAccessRules(t: UserToken): boolean {
+  return !!((t.allowed_ips && t.allowed_ips.length > 0) || (t.allowed_referers && t.allowed_referers.length > 0));
+}
+
+function tokenAccessSummary(t: UserToken): string {
+  const parts: string[] = [];
+  if (t.allowed_ips && t.allowed_ips.length > 0) parts.push(`IP：${t.allowed_ips.join(', ')}`);
+  if (t.allowed_referers && t.allowed_referers.length > 0) parts.push(`Referer：${t.allowed_referers.join(', ')}`);
+  if (t.access_violation_limit) parts.push(`违规 ${t.access_violation_limit} 次自动撤销`);
+  return parts.join('；');
+}
+
 function wrapDndListeners(listeners: SortableRowRenderArgs['listeners']): SortableRowRenderArgs['listeners'] {
   if (!listeners) return listeners;
 
@@ -148,6 +186,25 @@ export function TokensPage() {
   const [usageLoading, setUsageLoading] = useState(false);
   const [usageErr, setUsageErr] = useState('');
 
+  const openTokenLimitsModalBtnRef = useRef<HTMLButtonElement | null>(null);
+  const [limitsToken, setLimitsToken] = useState<UserToken | null>(null);
+  const [limitsExpiresAt, setLimitsExpiresAt] = useState('');
+  const [limitsBudget, setLimitsBudget] = useState('');
+  const [limitsBudget1d, setLimitsBudget1d] = useState('');
+  const [limitsBudget7d, setLimitsBudget7d] = useState('');
+  const [limitsBudget30d, setLimitsBudget30d] = useState('');
+  const [limitsModels, setLimitsModels] = useState('');
+  const [limitsRPM, setLimitsRPM] = useState('');
+  const [limitsTPM, setLimitsTPM] = useState('');
+  const [limitsErr, setLimitsErr] = useState('');
+
+  const openTokenAccessModalBtnRef = useRef<HTMLButtonElement | null>(null);
+  const [accessToken, setAccessToken] = useState<UserToken | null>(null);
+  const [accessIPs, setAccessIPs] = useState('');
+  const [accessReferers, setAccessReferers] = useState('');
+  const [accessViolationLimit, setAccessViolationLimit] = useState('');
+  const [accessErr, setAccessErr] = useState('');
+
   const channelGroupIDByNameRef = useRef<Map<string, number>>(new Map());
   const nextChannelGroupIDRef = useRef(1);
 
@@ -497,6 +554,31 @@ export function TokensPage() {
     void refreshUsage(t.id, { start: '', end: '' });
   }
 
+  function openTokenLimitsModal(t: UserToken) {
+    setTokensErr('');
+    setLimitsToken(t);
+    setLimitsExpiresAt(t.expires_at ? toDateTimeLocal(t.expires_at) : '');
+    setLimitsBudget(t.budget_usd || '');
+    setLimitsBudget1d(t.budget_1d_usd || '');
+    setLimitsBudget7d(t.budget_7d_usd || '');
+    setLimitsBudget30d(t.budget_30d_usd || '');
+    setLimitsModels((t.allowed_models || []).join(', '));
+    setLimitsRPM(t.rpm_limit ? String(t.rpm_limit) : '');
+    setLimitsTPM(t.tpm_limit ? String(t.tpm_limit) : '');
+    setLimitsErr('');
+    window.setTimeout(() => openTokenLimitsModalBtnRef.current?.click(), 0);
+  }
+
+  function openTokenAccessModal(t: UserToken) {
+    setTokensErr('');
+    setAccessToken(t);
+    setAccessIPs((t.allowed_ips || []).join('\n'));
+    setAccessReferers((t.allowed_referers || []).join('\n'));
+    setAccessViolationLimit(t.access_violation_limit ? String(t.access_violation_limit) : '');
+    setAccessErr('');
+    window.setTimeout(() => openTokenAccessModalBtnRef.current?.click(), 0);
+  }
+
   async function openTokenGroupsModal(t: UserToken) {
     setTokensErr('');
     setErr('');
@@ -585,6 +667,9 @@ export function TokensPage() {
                       <th scope="col" className="fw-medium py-3">
                         状态
                       </th>
+                      <th scope="col" className="fw-medium py-3">
+                        最近 IP
+                      </th>
                       <th scope="col" className="fw-medium text-end pe-4 py-3">
                         操作
                       </th>
@@ -593,13 +678,13 @@ export function TokensPage() {
                   <tbody className="border-top-0">
                     {tokensLoading ? (
                       <tr>
-                        <td colSpan={4} className="text-center py-5 text-muted">
+                        <td colSpan={5} className="text-center py-5 text-muted">
                           加载中…
                         </td>
                       </tr>
                     ) : tokens.length === 0 ? (
                       <tr>
-                        <td colSpan={4} className="text-center py-5 text-muted">
+                        <td colSpan={5} className="text-center py-5 text-muted">
                           <div className="mb-2">
                             <span className="fs-3 text-light-emphasis material-symbols-rounded">inbox</span>
                           </div>
@@ -626,11 +711,35 @@ export function TokensPage() {
                             )}
                           </td>
                           <td className="py-3">
-                            {t.status === 1 ? (
+                            {t.status !== 1 ? (
+                              <span className="badge bg-secondary bg-opacity-10 text-secondary rounded-pill px-2">已撤销</span>
+                            ) : t.expires_at && new Date(t.expires_at).getTime() <= Date.now() ? (
+                              <span className="badge bg-warning bg-opacity-10 text-warning rounded-pill px-2">已过期</span>
+                            ) : (
                               <span className="badge bg-success bg-opacity-10 text-success rounded-pill px-2">活跃</span>
+                            )}
+                            {t.status === 1 && tokenHasLimits(t) ? (
+                              <span className="badge bg-info bg-opacity-10 text-info rounded-pill px-2 ms-1" title={tokenLimitsSummary(t)}>
+                                受限
+                              </span>
+                            ) : null}
+                            {t.status === 1 && tokenHasAccessRules(t) ? (
+                              <span className="badge bg-info bg-opacity-10 text-info rounded-pill px-2 ms-1" title={tokenAccessSummary(t)}>
+                                来源受限
+                              </span>
+                            ) : null}
+                          </td>
+                          <td className="py-3">
+                            {t.last_client_ip ? (
+                              <code className="small text-muted">{t.last_client_ip}</code>
                             ) : (
-                              <span className="badge bg-secondary bg-opacity-10 text-secondary rounded-pill px-2">已撤销</span>
+                              <span className="text-muted small">-</span>
                             )}
+                            {t.access_violations ? (
+                              <span className="badge bg-danger bg-opacity-10 text-danger rounded-pill px-2 ms-1" title="来源违规次数">
+                                违规 {t.access_violations}
+                              </span>
+                            ) : null}
                           </td>
                           <td className="text-end pe-4 py-3">
                             {t.status === 1 ? (
@@ -701,6 +810,28 @@ export function TokensPage() {
                                 </button>
 
                                 <span className="text-muted small mx-2">|</span>
+
+                                <button
+                                  className="btn btn-link text-secondary p-0 text-decoration-none small"
+                                  type="button"
+                                  disabled={tokensLoading}
+                                  onClick={() => openTokenLimitsModal(t)}
+                                >
+                                  限制
+                                </button>
+
+                                <span className="text-muted small mx-2">|</span>
+
+                                <button
+                                  className="btn btn-link text-secondary p-0 text-decoration-none small"
+                                  type="button"
+                                  disabled={tokensLoading}
+                                  onClick={() => openTokenAccessModal(t)}
+                                >
+                                  来源
+                                </button>
+
+                                <span className="text-muted small mx-2">|</span>
                               </>
                             ) : null}
 
@@ -795,6 +926,187 @@ export function TokensPage() {
       {/* programmatically open the token-usage modal */}
       <button ref={openTokenUsageModalBtnRef} type="button" className="d-none" data-bs-toggle="modal" data-bs-target="#tokenUsageModal"></button>
 
+      {/* programmatically open the token-access modal */}
+      <button ref={openTokenAccessModalBtnRef} type="button" className="d-none" data-bs-toggle="modal" data-bs-target="#tokenAccessModal"></button>
+
+      <BootstrapModal
+        id="tokenAccessModal"
+        title={`来源限制${accessToken?.name ? `：${accessToken.name}` : ''}`}
+        dialogClassName="modal-dialog-centered"
+        onHidden={() => {
+          setAccessToken(null);
+          setAccessErr('');
+        }}
+      >
+        <form
+          className="row g-3"
+          onSubmit={async (e) => {
+            e.preventDefault();
+            if (!accessToken) return;
+            setAccessErr('');
+            const splitList = (raw: string) =>
+              raw
+                .split(/[\s,]+/)
+                .map((v) => v.trim())
+                .filter((v) => v);
+            const limitRaw = accessViolationLimit.trim();
+            const limit = limitRaw ? Number.parseInt(limitRaw, 10) : 0;
+            if (!Number.isFinite(limit) || limit < 0) {
+              setAccessErr('违规次数上限应为非负整数');
+              return;
+            }
+            try {
+              const res = await updateUserTokenAccess(accessToken.id, {
+                allowed_ips: splitList(accessIPs),
+                allowed_referers: splitList(accessReferers),
+                access_violation_limit: limit,
+              });
+              if (!res.success) throw new Error(res.message || '保存失败');
+              closeModalById('tokenAccessModal');
+              await refresh();
+            } catch (e) {
+              setAccessErr(e instanceof Error ? e.message : '保存失败');
+            }
+          }}
+        >
+          {accessErr ? <div className="col-12"><div className="alert alert-danger small mb-0">{accessErr}</div></div> : null}
+          <div className="col-12">
+            <label className="form-label">IP 白名单</label>
+            <textarea
+              className="form-control font-monospace"
+              rows={3}
+              placeholder={'留空表示不限制，每行一个 IP 或 CIDR\n例如 203.0.113.0/24、2001:db8::/32'}
+              value={accessIPs}
+              onChange={(e) => setAccessIPs(e.target.value)}
+            />
+            {accessToken?.last_client_ip ? (
+              <div className="form-text small text-muted">
+                最近一次使用的 IP：<code>{accessToken.last_client_ip}</code>
+              </div>
+            ) : null}
+          </div>
+          <div className="col-12">
+            <label className="form-label">Referer 白名单</label>
+            <textarea
+              className="form-control font-monospace"
+              rows={2}
+              placeholder="留空表示不限制，例如 example.com、*.example.com"
+              value={accessReferers}
+              onChange={(e) => setAccessReferers(e.target.value)}
+            />
+            <div className="form-text small text-muted">按请求的 Origin/Referer 主机名匹配；配置后不带来源头的请求将被拒绝。</div>
+          </div>
+          <div className="col-12">
+            <label className="form-label">违规自动撤销</label>
+            <input
+              className="form-control"
+              inputMode="numeric"
+              placeholder="0 或留空表示不自动撤销"
+              value={accessViolationLimit}
+              onChange={(e) => setAccessViolationLimit(e.target.value)}
+            />
+            <div className="form-text small text-muted">来源不符的请求返回 403 并记录审计事件；累计达到该次数后令牌自动撤销。保存后违规计数清零。</div>
+          </div>
+          <div className="modal-footer border-top-0 px-0 pb-0">
+            <button type="button" className="btn btn-light" data-bs-dismiss="modal">
+              取消
+            </button>
+            <button className="btn btn-primary px-4" type="submit" disabled={!accessToken}>
+              保存
+            </button>
+          </div>
+        </form>
+      </BootstrapModal>
+
+      {/* programmatically open the token-limits modal */}
+      <button ref={openTokenLimitsModalBtnRef} type="button" className="d-none" data-bs-toggle="modal" data-bs-target="#tokenLimitsModal"></button>
+
+      <BootstrapModal
+        id="tokenLimitsModal"
+        title={`令牌限制${limitsToken?.name ? `：${limitsToken.name}` : ''}`}
+        dialogClassName="modal-dialog-centered"
+        onHidden={() => {
+          setLimitsToken(null);
+          setLimitsErr('');
+        }}
+      >
+        <form
+          className="row g-3"
+          onSubmit={async (e) => {
+            e.preventDefault();
+            if (!limitsToken) return;
+            setLimitsErr('');
+            try {
+              const res = await updateUserTokenLimits(limitsToken.id, {
+                expires_at: limitsExpiresAt ? new Date(limitsExpiresAt).toISOString() : null,
+                budget_usd: limitsBudget.trim() || null,
+                budget_1d_usd: limitsBudget1d.trim() || null,
+                budget_7d_usd: limitsBudget7d.trim() || null,
+                budget_30d_usd: limitsBudget30d.trim() || null,
+                allowed_models: limitsModels
+                  .split(/[\s,]+/)
+                  .map((m) => m.trim())
+                  .filter((m) => m),
+                rpm_limit: Number.parseInt(limitsRPM.trim() || '0', 10) || 0,
+                tpm_limit: Number.parseInt(limitsTPM.trim() || '0', 10) || 0,
+              });
+              if (!res.success) throw new Error(res.message || '保存失败');
+              closeModalById('tokenLimitsModal');
+              await refresh();
+            } catch (e) {
+              setLimitsErr(e instanceof Error ? e.message : '保存失败');
+            }
+          }}
+        >
+          {limitsErr ? <div className="col-12"><div className="alert alert-danger small mb-0">{limitsErr}</div></div> : null}
+          <div className="col-12">
+            <label className="form-label">过期时间</label>
+            <input className="form-control" type="datetime-local" value={limitsExpiresAt} onChange={(e) => setLimitsExpiresAt(e.target.value)} />
+            <div className="form-text small text-muted">留空表示永不过期；过期后使用该令牌的请求返回 token_expired。</div>
+          </div>
+          <div className="col-6">
+            <label className="form-label">累计消费上限 (USD)</label>
+            <input className="form-control" inputMode="decimal" placeholder="不限" value={limitsBudget} onChange={(e) => setLimitsBudget(e.target.value)} />
+          </div>
+          <div className="col-6">
+            <label className="form-label">近 1 天上限 (USD)</label>
+            <input className="form-control" inputMode="decimal" placeholder="不限" value={limitsBudget1d} onChange={(e) => setLimitsBudget1d(e.target.value)} />
+          </div>
+          <div className="col-6">
+            <label className="form-label">近 7 天上限 (USD)</label>
+            <input className="form-control" inputMode="decimal" placeholder="不限" value={limitsBudget7d} onChange={(e) => setLimitsBudget7d(e.target.value)} />
+          </div>
+          <div className="col-6">
+            <label className="form-label">近 30 天上限 (USD)</label>
+            <input className="form-control" inputMode="decimal" placeholder="不限" value={limitsBudget30d} onChange={(e) => setLimitsBudget30d(e.target.value)} />
+          </div>
+          <div className="col-12">
+            <label className="form-label">允许的模型</label>
+            <textarea className="form-control font-monospace" rows={3} placeholder="留空表示不限制，多个模型用逗号或换行分隔" value={limitsModels} onChange={(e) => setLimitsModels(e.target.value)} />
+            <div className="form-text small text-muted">超出上限的请求返回 token_budget_exceeded；白名单外的模型返回 model_not_allowed。</div>
+          </div>
+          <div className="col-6">
+            <label className="form-label">每分钟请求数 (RPM)</label>
+            <input className="form-control" inputMode="numeric" placeholder="不限" value={limitsRPM} onChange={(e) => setLimitsRPM(e.target.value)} />
+          </div>
+          <div className="col-6">
+            <label className="form-label">每分钟 Token 数 (TPM)</label>
+            <input className="form-control" inputMode="numeric" placeholder="不限" value={limitsTPM} onChange={(e) => setLimitsTPM(e.target.value)} />
+          </div>
+          <div className="col-12">
+            <div className="form-text small text-muted mt-0">滑动窗口限流，超出时返回 429 并附带 Retry-After；账号所在分组或订阅套餐的限额同时生效。</div>
+          </div>
+          <div className="modal-footer border-top-0 px-0 pb-0">
+            <button type="button" className="btn btn-light" data-bs-dismiss="modal">
+              取消
+            </button>
+            <button className="btn btn-primary px-4" type="submit" disabled={!limitsToken}>
+              保存
+            </button>
+          </div>
+        </form>
+      </BootstrapModal>
+
       <BootstrapModal
         id="tokenUsageModal"
         title="令牌用量"
@@ -1,7 +1,13 @@
 import { useCallback, useEffect, useState } from 'react';
 import { Link, useNavigate } from 'react-router-dom';
 
-import { createTopupOrder, getTopupPage, type BillingTopupPageResponse } from '../api/billing';
+import {
+  createTopupOrder,
+  getTopupPage,
+  listBalanceTransactions,
+  type BalanceTransactionView,
+  type BillingTopupPageResponse,
+} from '../api/billing';
 import { DividedStack } from '../components/DividedStack';
 import { RedemptionCodeCard } from '../components/RedemptionCodeCard';
 import { SegmentedFrame } from '../components/SegmentedFrame';
@@ -13,6 +19,25 @@ function orderBadge(status: string): string {
   return 'badge bg-secondary bg-opacity-10 text-secondary';
 }
 
+const balanceTxLabels: Record<string, string> = {
+  opening: '期初余额',
+  topup: '充值',
+  redemption: '兑换码',
+  admin_credit: '管理员入账',
+  usage_reserve: '用量预扣',
+  usage_debit: '用量补扣',
+  usage_refund: '用量返还',
+  usage_void: '请求失败返还',
+  usage_expire: '预扣过期返还',
+};
+
+function balanceTxReference(t: BalanceTransactionView): string {
+  if (t.usage_event_id) return `用量 #${t.usage_event_id}`;
+  if (t.topup_order_id) return `充值订单 #${t.topup_order_id}`;
+  if (t.redemption_code_id) return `兑换码 #${t.redemption_code_id}`;
+  return t.note || '-';
+}
+
 export function TopupPage() {
   const navigate = useNavigate();
 
@@ -23,6 +48,25 @@ export function TopupPage() {
 
   const [amountCNY, setAmountCNY] = useState('');
 
+  const [txs, setTxs] = useState<BalanceTransactionView[]>([]);
+  const [txNextBeforeID, setTxNextBeforeID] = useState<number | undefined>(undefined);
+  const [txLoading, setTxLoading] = useState(false);
+
+  const loadTransactions = useCallback(async (beforeID?: number) => {
+    setTxLoading(true);
+    try {
+      const res = await listBalanceTransactions({ limit: 20, before_id: beforeID });
+      if (!res.success) throw new Error(res.message || '加载余额明细失败');
+      const page = res.data?.transactions || [];
+      setTxs((prev) => (beforeID ? [...prev, ...page] : page));
+      setTxNextBeforeID(res.data?.next_before_id);
+    } catch (e) {
+      setErr(e instanceof Error ? e.message : '加载余额明细失败');
+    } finally {
+      setTxLoading(false);
+    }
+  }, []);
+
   const refresh = useCallback(async (nextNotice?: string) => {
     setErr('');
     setLoading(true);
@@ -43,6 +87,10 @@ export function TopupPage() {
     void refresh();
   }, [refresh]);
 
+  useEffect(() => {
+    void loadTransactions();
+  }, [loadTransactions]);
+
   const orders = data?.topup_orders || [];
   const hasPayment = (data?.payment_channels || []).length > 0;
 
@@ -209,6 +257,52 @@ export function TopupPage() {
             )}
           </div>
         </div>
+
+        <div>
+          <div className="d-flex align-items-center mb-3">
+            <h4 className="mb-0 fw-bold">余额明细</h4>
+          </div>
+
+          <div className="card border-0 overflow-hidden mb-0">
+            {txs.length ? (
+              <div className="table-responsive">
+                <table className="table table-hover align-middle mb-0">
+                  <thead className="table-light">
+                    <tr>
+                      <th>时间</th>
+                      <th>类型</th>
+                      <th>变动</th>
+                      <th>变动后余额</th>
+                      <th>关联</th>
+                    </tr>
+                  </thead>
+                  <tbody>
+                    {txs.map((t) => (
+                      <tr key={t.id}>
+                        <td className="text-muted small">{new Date(t.created_at).toLocaleString()}</td>
+                        <td>{balanceTxLabels[t.type] || t.type}</td>
+                        <td className={`fw-semibold font-monospace ${t.amount_usd.startsWith('-') ? 'text-danger' : 'text-success'}`}>
+                          {t.amount_usd.startsWith('-') ? t.amount_usd : `+${t.amount_usd}`}
+                        </td>
+                        <td className="font-monospace small">{t.balance_after_usd}</td>
+                        <td className="text-muted small">{balanceTxReference(t)}</td>
+                      </tr>
+                    ))}
+                  </tbody>
+                </table>
+              </div>
+            ) : (
+              <div className="card-body p-4 text-muted">{txLoading ? '加载中…' : '暂无余额变动记录。'}</div>
+            )}
+            {txNextBeforeID ? (
+              <div className="card-body border-top text-center py-2">
+                <button type="button" className="btn btn-sm btn-outline-secondary" disabled={txLoading} onClick={() => void loadTransactions(txNextBeforeID)}>
+                  {txLoading ? '加载中…' : '加载更多'}
+                </button>
+              </div>
+            ) : null}
+          </div>
+        </div>
         </DividedStack>
       </SegmentedFrame>
     </div>
@@ -19,7 +19,7 @@ import { CSS } from '@dnd-kit/utilities';
 
 import { BootstrapModal } from '../../components/BootstrapModal';
 import { SegmentedFrame } from '../../components/SegmentedFrame';
-import { closeModalById } from '../../components/modal';
+import { closeModalById, showModalById } from '../../components/modal';
 import { PortalDragOverlay } from '../../components/PortalDragOverlay';
 import {
   addAdminChannelGroupChannelMember,
@@ -27,9 +27,14 @@ import {
   deleteAdminChannelGroupChannelMember,
   deleteAdminChannelGroupGroupMember,
   getAdminChannelGroupDetail,
+  getAdminChannelGroupMemberCanary,
   getAdminChannelGroupPointer,
+  promoteAdminChannelGroupMemberCanary,
   reorderAdminChannelGroupMembers,
+  rollbackAdminChannelGroupMemberCanary,
+  updateAdminChannelGroupMemberCanary,
   upsertAdminChannelGroupPointer,
+  type AdminChannelGroupCanary,
   type AdminChannelGroupDetail,
   type AdminChannelGroupMember,
   type AdminChannelGroupPointer,
@@ -113,6 +118,10 @@ function SortableRow({
 function channelTypeLabel(t: string): string {
   if (t === 'openai_compatible') return 'OpenAI 兼容';
   if (t === 'anthropic') return 'Anthropic';
+  if (t === 'gemini') return 'Gemini';
+  if (t === 'azure_openai') return 'Azure OpenAI';
+  if (t === 'bedrock') return 'AWS Bedrock';
+  if (t === 'vertex') return 'Vertex AI';
   if (t === 'codex_oauth') return 'Codex OAuth';
   return t;
 }
@@ -133,12 +142,46 @@ export function ChannelGroupDetailPage() {
   const [notice, setNotice] = useState('');
 
   const [addChannelID, setAddChannelID] = useState('');
+  const [addCanaryPercent, setAddCanaryPercent] = useState('');
+
+  const [canaryMember, setCanaryMember] = useState<AdminChannelGroupMember | null>(null);
+  const [canary, setCanary] = useState<AdminChannelGroupCanary | null>(null);
+  const [canaryPercent, setCanaryPercent] = useState('');
+  const [canaryErr, setCanaryErr] = useState('');
 
   const [childName, setChildName] = useState('');
   const [childDesc, setChildDesc] = useState('');
   const [childMultiplier, setChildMultiplier] = useState('1');
   const [childStatus, setChildStatus] = useState(1);
 
+  async function openCanary(m: AdminChannelGroupMember) {
+    setCanaryMember(m);
+    setCanary(null);
+    setCanaryErr('');
+    setCanaryPercent(String(m.canary_percent || 10));
+    showModalById('channelCanaryModal');
+    try {
+      const res = await getAdminChannelGroupMemberCanary(groupId, m.member_id);
+      if (!res.success) throw new Error(res.message || '加载失败');
+      setCanary(res.data || null);
+    } catch (e) {
+      setCanaryErr(e instanceof Error ? e.message : '加载失败');
+    }
+  }
+
+  async function runCanaryAction(action: () => Promise<{ success: boolean; message?: string }>, okNotice: string) {
+    setCanaryErr('');
+    try {
+      const res = await action();
+      if (!res.success) throw new Error(res.message || '操作失败');
+      closeModalById('channelCanaryModal');
+      await refresh();
+      setNotice(okNotice);
+    } catch (e) {
+      setCanaryErr(e instanceof Error ? e.message : '操作失败');
+    }
+  }
+
   async function refresh() {
     setErr('');
     setNotice('');
@@ -554,6 +597,11 @@ export function ChannelGroupDetailPage() {
                                                     <i className="ri-fire-line me-1"></i>优先
                                                   </span>
                                                 ) : null}
+                                                {m.canary_status === 'active' ? (
+                                                  <span className="badge rounded-pill bg-info bg-opacity-10 text-info px-2">灰度 {m.canary_percent || 0}%</span>
+                                                ) : m.canary_status === 'rolled_back' ? (
+                                                  <span className="badge rounded-pill bg-danger bg-opacity-10 text-danger px-2">灰度已回滚</span>
+                                                ) : null}
                                               </div>
                                               <div className="d-flex flex-wrap align-items-center gap-2 small text-muted mt-1">
                                                 {ch.base_url ? (
@@ -596,27 +644,37 @@ export function ChannelGroupDetailPage() {
                                           ? (() => {
                                               const channelID = m.member_channel_id;
                                               return (
-                                                <button
-                                                  type="button"
-                                                  className="btn btn-sm btn-light border text-warning"
-                                                  title="设为指针"
-                                                  disabled={isPointerChannel}
-                                                  onClick={async () => {
-                                                    if (!window.confirm('确认将该渠道设为该组指针？')) return;
-                                                    setErr('');
-                                                    setNotice('');
-                                                    try {
-                                                      const res = await upsertAdminChannelGroupPointer(groupId, { channel_id: channelID, pinned: true });
-                                                      if (!res.success) throw new Error(res.message || '设置失败');
-                                                      setNotice('已设置指针');
-                                                      await refresh();
-                                                    } catch (e) {
-                                                      setErr(e instanceof Error ? e.message : '设置失败');
-                                                    }
-                                                  }}
-                                                >
-                                                  <i className="ri-pushpin-2-line"></i>
-                                                </button>
+                                                <>
+                                                  <button
+                                                    type="button"
+                                                    className="btn btn-sm btn-light border text-info"
+                                                    title="灰度"
+                                                    onClick={() => void openCanary(m)}
+                                                  >
+                                                    <i className="ri-flask-line"></i>
+                                                  </button>
+                                                  <button
+                                                    type="button"
+                                                    className="btn btn-sm btn-light border text-warning"
+                                                    title="设为指针"
+                                                    disabled={isPointerChannel}
+                                                    onClick={async () => {
+                                                      if (!window.confirm('确认将该渠道设为该组指针？')) return;
+                                                      setErr('');
+                                                      setNotice('');
+                                                      try {
+                                                        const res = await upsertAdminChannelGroupPointer(groupId, { channel_id: channelID, pinned: true });
+                                                        if (!res.success) throw new Error(res.message || '设置失败');
+                                                        setNotice('已设置指针');
+                                                        await refresh();
+                                                      } catch (e) {
+                                                        setErr(e instanceof Error ? e.message : '设置失败');
+                                                      }
+                                                    }}
+                                                  >
+                                                    <i className="ri-pushpin-2-line"></i>
+                                                  </button>
+                                                </>
                                               );
                                             })()
                                           : null}
@@ -682,6 +740,7 @@ export function ChannelGroupDetailPage() {
         dialogClassName="modal-dialog-centered"
         onHidden={() => {
           setAddChannelID('');
+          setAddCanaryPercent('');
         }}
       >
         <form
@@ -693,9 +752,12 @@ export function ChannelGroupDetailPage() {
             try {
               const id = Number.parseInt(addChannelID, 10);
               if (!Number.isFinite(id) || id <= 0) throw new Error('请选择渠道');
-              const res = await addAdminChannelGroupChannelMember(groupId, id);
+              const pct = addCanaryPercent.trim() ? Number.parseInt(addCanaryPercent, 10) : 0;
+              if (!Number.isFinite(pct) || pct < 0 || pct > 100) throw new Error('灰度比例应为 1-100');
+              const res = await addAdminChannelGroupChannelMember(groupId, id, pct);
               if (!res.success) throw new Error(res.message || '添加失败');
               setAddChannelID('');
+              setAddCanaryPercent('');
               setNotice('已添加');
               closeModalById('addChannelToGroupModal');
               await refresh();
@@ -716,6 +778,22 @@ export function ChannelGroupDetailPage() {
             </select>
             <div className="form-text small text-muted">添加后会更新该渠道的 groups 缓存。</div>
           </div>
+          <div className="col-12">
+            <label className="form-label">灰度比例（可选）</label>
+            <div className="input-group">
+              <input
+                className="form-control"
+                type="number"
+                min={1}
+                max={100}
+                placeholder="留空则直接全量加入"
+                value={addCanaryPercent}
+                onChange={(e) => setAddCanaryPercent(e.target.value)}
+              />
+              <span className="input-group-text">%</span>
+            </div>
+            <div className="form-text small text-muted">按会话哈希分流：仅该比例的请求会优先路由到新渠道，同一会话保持稳定。</div>
+          </div>
           <div className="modal-footer border-top-0 px-0 pb-0">
             <button type="button" className="btn btn-light" data-bs-dismiss="modal">
               取消
@@ -795,6 +873,108 @@ export function ChannelGroupDetailPage() {
           </div>
         </form>
       </BootstrapModal>
+
+      <BootstrapModal
+        id="channelCanaryModal"
+        title={`灰度：${canaryMember?.member_channel_name || `渠道 #${canaryMember?.member_channel_id || ''}`}`}
+        dialogClassName="modal-dialog-centered"
+        onHidden={() => {
+          setCanaryMember(null);
+          setCanary(null);
+          setCanaryErr('');
+        }}
+      >
+        <div className="d-flex flex-column gap-3">
+          {canaryErr ? <div className="alert alert-danger small mb-0">{canaryErr}</div> : null}
+          {canary ? (
+            <>
+              <div className="small text-muted">
+                {canary.canary_status === 'active'
+                  ? `灰度中（${canary.canary_percent}%），自 ${canary.canary_started_at ? new Date(canary.canary_started_at).toLocaleString() : '-'} 起统计`
+                  : canary.canary_status === 'rolled_back'
+                    ? '灰度已回滚：该渠道不再接收流量，可重新灰度或直接转正。'
+                    : '未开启灰度：以下为最近 24 小时统计。'}
+              </div>
+              <table className="table table-sm mb-0">
+                <thead className="table-light">
+                  <tr>
+                    <th></th>
+                    <th className="text-end">请求数</th>
+                    <th className="text-end">错误率</th>
+                    <th className="text-end">平均延迟</th>
+                    <th className="text-end">平均首字</th>
+                  </tr>
+                </thead>
+                <tbody>
+                  {[
+                    { label: '该渠道', stats: canary.canary },
+                    { label: `同组其他渠道（${canary.sibling_channel_ids.length}）`, stats: canary.siblings },
+                  ].map((row) => (
+                    <tr key={row.label}>
+                      <td>{row.label}</td>
+                      <td className="text-end font-monospace">{row.stats.requests}</td>
+                      <td className={`text-end font-monospace ${row.stats.requests > 0 && row.stats.error_rate > canary.siblings.error_rate && row.label === '该渠道' ? 'text-danger' : ''}`}>
+                        {row.stats.requests > 0 ? `${(row.stats.error_rate * 100).toFixed(1)}%` : '-'}
+                      </td>
+                      <td className="text-end font-monospace">{row.stats.requests > 0 ? `${Math.round(row.stats.avg_latency_ms)} ms` : '-'}</td>
+                      <td className="text-end font-monospace">{row.stats.avg_first_token_ms > 0 ? `${Math.round(row.stats.avg_first_token_ms)} ms` : '-'}</td>
+                    </tr>
+                  ))}
+                </tbody>
+              </table>
+            </>
+          ) : !canaryErr ? (
+            <div className="small text-muted">加载中…</div>
+          ) : null}
+          <div>
+            <label className="form-label">灰度比例</label>
+            <div className="input-group">
+              <input className="form-control" type="number" min={1} max={100} value={canaryPercent} onChange={(e) => setCanaryPercent(e.target.value)} />
+              <span className="input-group-text">%</span>
+              <button
+                type="button"
+                className="btn btn-outline-primary"
+                disabled={!canaryMember}
+                onClick={() => {
+                  if (!canaryMember) return;
+                  const pct = Number.parseInt(canaryPercent, 10);
+                  void runCanaryAction(() => updateAdminChannelGroupMemberCanary(groupId, canaryMember.member_id, pct), '已保存灰度比例');
+                }}
+              >
+                {canaryMember?.canary_status === 'active' ? '调整比例' : '开始灰度'}
+              </button>
+            </div>
+            <div className="form-text small text-muted">错误率超过阈值时系统会自动回滚（见 gateway.canary_rollback_error_rate）。</div>
+          </div>
+          <div className="modal-footer border-top-0 px-0 pb-0">
+            <button type="button" className="btn btn-light" data-bs-dismiss="modal">
+              关闭
+            </button>
+            <button
+              type="button"
+              className="btn btn-outline-danger"
+              disabled={!canaryMember || canaryMember.canary_status !== 'active'}
+              onClick={() => {
+                if (!canaryMember || !window.confirm('确认回滚灰度？该渠道将不再接收流量。')) return;
+                void runCanaryAction(() => rollbackAdminChannelGroupMemberCanary(groupId, canaryMember.member_id), '已回滚');
+              }}
+            >
+              回滚
+            </button>
+            <button
+              type="button"
+              className="btn btn-success px-4"
+              disabled={!canaryMember || !canaryMember.canary_status}
+              onClick={() => {
+                if (!canaryMember || !window.confirm('确认转正？该渠道将按优先级全量参与路由。')) return;
+                void runCanaryAction(() => promoteAdminChannelGroupMemberCanary(groupId, canaryMember.member_id), '已转正');
+              }}
+            >
+              转正
+            </button>
+          </div>
+        </div>
+      </BootstrapModal>
       </div>
     </DndContext>
   );
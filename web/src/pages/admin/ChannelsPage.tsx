@@ -48,6 +48,7 @@ import {
   type ChannelModelProbeResult,
   type ChannelTimeSeriesPoint,
   type CodexOAuthAccount,
+  type CredentialLimits,
 } from "../../api/channels";
 import {
   listAdminChannelGroups,
@@ -67,14 +68,25 @@ import { fillDailyBuckets } from "../../utils/timeSeries";
 function channelTypeLabel(t: string): string {
   if (t === "openai_compatible") return "OpenAI 兼容";
   if (t === "anthropic") return "Anthropic";
+  if (t === "gemini") return "Gemini";
+  if (t === "azure_openai") return "Azure OpenAI";
+  if (t === "bedrock") return "AWS Bedrock";
+  if (t === "vertex") return "Vertex AI";
   if (t === "codex_oauth") return "Codex OAuth";
   return t;
 }
 
 function defaultAPISettingsForChannelType(
-  t: "openai_compatible" | "anthropic" | "codex_oauth",
+  t: "openai_compatible"
+    | "anthropic"
+    | "gemini"
+    | "azure_openai"
+    | "bedrock"
+    | "vertex"
+    | "codex_oauth",
 ): { chat: boolean; responses: boolean } {
-  if (t === "openai_compatible") return { chat: true, responses: true };
+  if (t === "openai_compatible" || t === "azure_openai")
+    return { chat: true, responses: true };
   if (t === "codex_oauth") return { chat: false, responses: true };
   return { chat: false, responses: false };
 }
@@ -91,6 +103,14 @@ function statusBadge(status: number): { cls: string; label: string } {
   };
 }
 
+function formatCredentialLimits(limits?: CredentialLimits): string {
+  const parts: string[] = [];
+  if (limits?.rpm_limit) parts.push(`RPM ${formatIntComma(limits.rpm_limit)}`);
+  if (limits?.tpm_limit) parts.push(`TPM ${formatIntComma(limits.tpm_limit)}`);
+  if (limits?.max_concurrency) parts.push(`并发 ${limits.max_concurrency}`);
+  return parts.length > 0 ? parts.join(" · ") : "继承渠道默认";
+}
+
 type ChannelPatch = Partial<{
   name: string;
   status: number;
@@ -210,8 +230,11 @@ function ChannelCommonTab({
   setEditName,
   editStatus,
   setEditStatus,
+  channelType,
   editBaseURL,
   setEditBaseURL,
+  editAPIVersion,
+  setEditAPIVersion,
   editGroups,
   setEditGroups,
   editPriority,
@@ -236,8 +259,11 @@ function ChannelCommonTab({
   setEditName: (v: string) => void;
   editStatus: number;
   setEditStatus: (v: number) => void;
+  channelType: string;
   editBaseURL: string;
   setEditBaseURL: (v: string) => void;
+  editAPIVersion: string;
+  setEditAPIVersion: (v: string) => void;
   editGroups: string;
   setEditGroups: (v: string) => void;
   editPriority: string;
@@ -261,6 +287,7 @@ function ChannelCommonTab({
       name: editName,
       status: editStatus,
       base_url: editBaseURL,
+      api_version: editAPIVersion,
       groups: editGroups,
       priority: editPriority,
       promotion: editPromotion,
@@ -276,6 +303,8 @@ function ChannelCommonTab({
         name: v.name.trim(),
         status: v.status,
         base_url: v.base_url.trim(),
+        api_version:
+          channelType === "azure_openai" ? v.api_version.trim() : undefined,
         groups: v.groups.trim(),
         priority: Number.parseInt(v.priority, 10) || 0,
         promotion: !!v.promotion,
@@ -370,6 +399,20 @@ function ChannelCommonTab({
                 保存后立即生效；密钥与模型绑定可在本弹窗继续配置。
               </div>
             </div>
+            {channelType === "azure_openai" ? (
+              <div className="col-12">
+                <label className="form-label fw-medium">API 版本（api-version）</label>
+                <input
+                  className="form-control font-monospace"
+                  value={editAPIVersion}
+                  onChange={(e) => setEditAPIVersion(e.target.value)}
+                  placeholder="2024-10-21"
+                />
+                <div className="form-text small text-muted">
+                  留空使用默认版本；填写 <code>v1</code> 时走 <code>/openai/v1/*</code> 接口。
+                </div>
+              </div>
+            ) : null}
 
             <div className="col-12">
               <label className="form-label fw-medium">渠道组设置</label>
@@ -584,6 +627,10 @@ function ChannelAdvancedTab({
   setSettingChatCompletionsEnabled,
   settingResponsesEnabled,
   setSettingResponsesEnabled,
+  settingEmbeddingsEnabled,
+  setSettingEmbeddingsEnabled,
+  settingMessagesEnabled,
+  setSettingMessagesEnabled,
   settingPassThroughBodyEnabled,
   setSettingPassThroughBodyEnabled,
   settingProxy,
@@ -627,6 +674,10 @@ function ChannelAdvancedTab({
   setSettingChatCompletionsEnabled: (v: boolean) => void;
   settingResponsesEnabled: boolean;
   setSettingResponsesEnabled: (v: boolean) => void;
+  settingEmbeddingsEnabled: boolean;
+  setSettingEmbeddingsEnabled: (v: boolean) => void;
+  settingMessagesEnabled: boolean;
+  setSettingMessagesEnabled: (v: boolean) => void;
   settingPassThroughBodyEnabled: boolean;
   setSettingPassThroughBodyEnabled: (v: boolean) => void;
   settingProxy: string;
@@ -680,24 +731,43 @@ function ChannelAdvancedTab({
       thinking_to_content: settingThinkingToContent,
       chat_completions_enabled: settingChatCompletionsEnabled,
       responses_enabled: settingResponsesEnabled,
+      embeddings_enabled: settingEmbeddingsEnabled,
+      messages_enabled: settingMessagesEnabled,
       pass_through_body_enabled: settingPassThroughBodyEnabled,
       proxy: settingProxy,
       system_prompt: settingSystemPrompt,
       system_prompt_override: settingSystemPromptOverride,
     },
     validate: (v) => {
-      if (channelType === "anthropic") {
-        if (v.chat_completions_enabled) {
-          return "anthropic 渠道不支持 chat/completions";
-        }
+      if (
+        channelType === "anthropic" ||
+        channelType === "bedrock" ||
+        channelType === "vertex"
+      ) {
         if (v.responses_enabled) {
-          return "anthropic 渠道不支持 responses";
+          return `${channelType} 渠道不支持 responses`;
+        }
+        return "";
+      }
+      if (channelType === "gemini") {
+        if (v.chat_completions_enabled || v.responses_enabled) {
+          return "gemini 渠道不支持 chat/completions 与 responses";
         }
         return "";
       }
       if (!v.chat_completions_enabled && !v.responses_enabled) {
         return "至少启用一个接口能力";
       }
+      if (
+        channelType !== "openai_compatible" &&
+        channelType !== "azure_openai" &&
+        v.embeddings_enabled
+      ) {
+        return `${channelType} 渠道不支持 embeddings`;
+      }
+      if (channelType !== "openai_compatible" && v.messages_enabled) {
+        return `${channelType} 渠道不支持 messages 协议转换`;
+      }
       if (channelType === "codex_oauth" && v.chat_completions_enabled) {
         return `${channelType} 渠道不支持 chat/completions`;
       }
@@ -924,7 +994,7 @@ function ChannelAdvancedTab({
                   id="setting_chat_completions_enabled"
                   checked={settingChatCompletionsEnabled}
                   disabled={
-                    channelType === "codex_oauth" || channelType === "anthropic"
+                    channelType === "codex_oauth" || channelType === "gemini"
                   }
                   onChange={(e) =>
                     setSettingChatCompletionsEnabled(e.target.checked)
@@ -939,8 +1009,12 @@ function ChannelAdvancedTab({
                 <div className="form-text small text-muted">
                   {channelType === "codex_oauth"
                     ? "codex_oauth 上游只支持 responses。"
-                    : channelType === "anthropic"
-                      ? "anthropic 上游不支持 OpenAI chat/completions。"
+                    : channelType === "gemini"
+                      ? "gemini 渠道仅承接原生 /v1beta/models/* 请求。"
+                      : channelType === "anthropic" ||
+                          channelType === "bedrock" ||
+                          channelType === "vertex"
+                      ? "开启后：chat/completions 请求会转换为 Messages 协议转发到该渠道。"
                       : "关闭后：该渠道不会参与 chat/completions 请求选路。"}
                 </div>
               </div>
@@ -950,7 +1024,12 @@ function ChannelAdvancedTab({
                   type="checkbox"
                   id="setting_responses_enabled"
                   checked={settingResponsesEnabled}
-                  disabled={channelType === "anthropic"}
+                  disabled={
+                    channelType === "anthropic" ||
+                    channelType === "bedrock" ||
+                    channelType === "vertex" ||
+                    channelType === "gemini"
+                  }
                   onChange={(e) => setSettingResponsesEnabled(e.target.checked)}
                 />
                 <label
@@ -960,11 +1039,64 @@ function ChannelAdvancedTab({
                   启用 <code>/v1/responses</code>
                 </label>
                 <div className="form-text small text-muted">
-                  {channelType === "anthropic"
-                    ? "anthropic 上游不支持 OpenAI responses。"
+                  {channelType === "anthropic" ||
+                  channelType === "bedrock" ||
+                  channelType === "vertex" ||
+                  channelType === "gemini"
+                    ? `${channelType} 上游不支持 OpenAI responses。`
                     : "关闭后：该渠道不会参与 responses 请求选路。"}
                 </div>
               </div>
+              <div className="form-check">
+                <input
+                  className="form-check-input"
+                  type="checkbox"
+                  id="setting_embeddings_enabled"
+                  checked={settingEmbeddingsEnabled}
+                  disabled={
+                    channelType !== "openai_compatible" &&
+                    channelType !== "azure_openai"
+                  }
+                  onChange={(e) =>
+                    setSettingEmbeddingsEnabled(e.target.checked)
+                  }
+                />
+                <label
+                  className="form-check-label"
+                  htmlFor="setting_embeddings_enabled"
+                >
+                  启用 <code>/v1/embeddings</code>
+                </label>
+                <div className="form-text small text-muted">
+                  {channelType !== "openai_compatible" &&
+                  channelType !== "azure_openai"
+                    ? "仅 openai_compatible / azure_openai 上游支持 embeddings。"
+                    : "开启后：该渠道才会参与 embeddings 请求选路。"}
+                </div>
+              </div>
+              {channelType === "openai_compatible" ? (
+                <div className="form-check">
+                  <input
+                    className="form-check-input"
+                    type="checkbox"
+                    id="setting_messages_enabled"
+                    checked={settingMessagesEnabled}
+                    onChange={(e) =>
+                      setSettingMessagesEnabled(e.target.checked)
+                    }
+                  />
+                  <label
+                    className="form-check-label"
+                    htmlFor="setting_messages_enabled"
+                  >
+                    承接 <code>/v1/messages</code>
+                  </label>
+                  <div className="form-text small text-muted">
+                    开启后：Anthropic Messages 请求会转换为 chat/completions（或
+                    responses）协议转发到该渠道。
+                  </div>
+                </div>
+              ) : null}
               <div className="form-check">
                 <input
                   className="form-check-input"
@@ -1324,10 +1456,17 @@ export function ChannelsPage() {
   ];
 
   const [createType, setCreateType] = useState<
-    "openai_compatible" | "anthropic" | "codex_oauth"
+    "openai_compatible"
+    | "anthropic"
+    | "gemini"
+    | "azure_openai"
+    | "bedrock"
+    | "vertex"
+    | "codex_oauth"
   >("openai_compatible");
   const [createName, setCreateName] = useState("");
   const [createBaseURL, setCreateBaseURL] = useState("https://api.openai.com");
+  const [createAPIVersion, setCreateAPIVersion] = useState("");
   const [createKey, setCreateKey] = useState("");
   const [createGroups, setCreateGroups] = useState("");
   const [createPriority, setCreatePriority] = useState("0");
@@ -1355,6 +1494,7 @@ export function ChannelsPage() {
   const [editName, setEditName] = useState("");
   const [editGroups, setEditGroups] = useState("");
   const [editBaseURL, setEditBaseURL] = useState("");
+  const [editAPIVersion, setEditAPIVersion] = useState("");
   const [editStatus, setEditStatus] = useState(1);
   const [editPriority, setEditPriority] = useState("0");
   const [editPromotion, setEditPromotion] = useState(false);
@@ -1403,6 +1543,9 @@ export function ChannelsPage() {
   const [settingChatCompletionsEnabled, setSettingChatCompletionsEnabled] =
     useState(true);
   const [settingResponsesEnabled, setSettingResponsesEnabled] = useState(true);
+  const [settingEmbeddingsEnabled, setSettingEmbeddingsEnabled] =
+    useState(false);
+  const [settingMessagesEnabled, setSettingMessagesEnabled] = useState(false);
   const [settingPassThroughBodyEnabled, setSettingPassThroughBodyEnabled] =
     useState(false);
   const [settingProxy, setSettingProxy] = useState("");
@@ -1927,6 +2070,7 @@ export function ChannelsPage() {
         setEditName(ch.name || "");
         setEditGroups(ch.groups || "");
         setEditBaseURL(ch.base_url || "");
+        setEditAPIVersion(ch.api_version || "");
         setEditStatus(ch.status || 0);
         setEditPriority(String(ch.priority || 0));
         setEditPromotion(!!ch.promotion);
@@ -1976,9 +2120,20 @@ export function ChannelsPage() {
         const setting = ch.setting || {};
         setSettingThinkingToContent(!!setting.thinking_to_content);
         setSettingChatCompletionsEnabled(
-          setting.chat_completions_enabled !== false,
+          ch.type === "anthropic" ||
+            ch.type === "bedrock" ||
+            ch.type === "vertex" ||
+            ch.type === "gemini"
+            ? !!setting.chat_completions_enabled
+            : setting.chat_completions_enabled !== false,
         );
-        setSettingResponsesEnabled(setting.responses_enabled !== false);
+        setSettingResponsesEnabled(
+          ch.type === "gemini"
+            ? !!setting.responses_enabled
+            : setting.responses_enabled !== false,
+        );
+        setSettingEmbeddingsEnabled(!!setting.embeddings_enabled);
+        setSettingMessagesEnabled(!!setting.messages_enabled);
         setSettingPassThroughBodyEnabled(!!setting.pass_through_body_enabled);
         setSettingProxy(setting.proxy || "");
         setSettingSystemPrompt(setting.system_prompt || "");
@@ -2492,6 +2647,30 @@ export function ChannelsPage() {
                                   </span>
                                 </div>
                               ) : null}
+                              {runtime?.available && runtime.weight_share ? (
+                                <div className="mt-1">
+                                  <span
+                                    className="badge bg-light text-secondary border"
+                                    title={`同优先级内的权重占比与实际分流占比（启动以来已选中 ${runtime.selections} 次）`}
+                                  >
+                                    权重 {ch.weight || 0} · {runtime.weight_share}
+                                    {runtime.traffic_share
+                                      ? ` / 实际 ${runtime.traffic_share}`
+                                      : ""}
+                                  </span>
+                                </div>
+                              ) : null}
+                              {runtime?.available && runtime.error_rate_ewma !== undefined ? (
+                                <div className="mt-1">
+                                  <span
+                                    className="badge bg-light text-secondary border"
+                                    title="调度器在本实例观测的首字延迟与错误率（EWMA），同优先级渠道优先较快者"
+                                  >
+                                    TTFT {runtime.ttft_ewma_ms !== undefined ? `${runtime.ttft_ewma_ms}ms` : "-"} · 错误率{" "}
+                                    {(runtime.error_rate_ewma * 100).toFixed(1)}%
+                                  </span>
+                                </div>
+                              ) : null}
                             </td>
                             <td className="text-end pe-4 text-nowrap">
                               <div className="d-flex gap-1 justify-content-end">
@@ -3533,6 +3712,7 @@ export function ChannelsPage() {
           setCreateType("openai_compatible");
           setCreateName("");
           setCreateBaseURL("https://api.openai.com");
+          setCreateAPIVersion("");
           setCreateKey("");
           setCreateGroups("");
           setCreatePriority("0");
@@ -3556,6 +3736,10 @@ export function ChannelsPage() {
                 type: createType,
                 name: createName.trim(),
                 base_url: createBaseURL.trim(),
+                api_version:
+                  createType === "azure_openai"
+                    ? createAPIVersion.trim() || undefined
+                    : undefined,
                 key:
                   createType === "codex_oauth"
                     ? undefined
@@ -3588,6 +3772,10 @@ export function ChannelsPage() {
                 const t = e.target.value as
                   | "openai_compatible"
                   | "anthropic"
+                  | "gemini"
+                  | "azure_openai"
+                  | "bedrock"
+                  | "vertex"
                   | "codex_oauth";
                 if (!allowCodexOAuth && t === "codex_oauth") return;
                 setCreateType(t);
@@ -3598,6 +3786,12 @@ export function ChannelsPage() {
                   setCreateBaseURL("https://api.openai.com");
                 if (t === "anthropic")
                   setCreateBaseURL("https://api.anthropic.com");
+                if (t === "gemini")
+                  setCreateBaseURL("https://generativelanguage.googleapis.com");
+                if (t === "azure_openai") setCreateBaseURL("");
+                if (t === "bedrock") setCreateBaseURL("");
+                if (t === "vertex")
+                  setCreateBaseURL("https://aiplatform.googleapis.com");
                 if (t === "codex_oauth") {
                   setCreateBaseURL("https://chatgpt.com/backend-api/codex");
                   setCreateKey("");
@@ -3608,6 +3802,10 @@ export function ChannelsPage() {
                 openai_compatible（OpenAI 兼容）
               </option>
               <option value="anthropic">anthropic（Anthropic）</option>
+              <option value="gemini">gemini（Gemini 原生）</option>
+              <option value="azure_openai">azure_openai（Azure OpenAI）</option>
+              <option value="bedrock">bedrock（AWS Bedrock）</option>
+              <option value="vertex">vertex（Google Vertex AI）</option>
               {allowCodexOAuth ? (
                 <option value="codex_oauth">codex_oauth（Codex OAuth）</option>
               ) : null}
@@ -3629,10 +3827,32 @@ export function ChannelsPage() {
               className="form-control font-monospace"
               value={createBaseURL}
               onChange={(e) => setCreateBaseURL(e.target.value)}
-              placeholder="https://api.openai.com"
+              placeholder={
+                createType === "azure_openai"
+                  ? "https://{resource}.openai.azure.com"
+                  : createType === "bedrock"
+                    ? "https://bedrock-runtime.{region}.amazonaws.com"
+                    : createType === "vertex"
+                      ? "https://{location}-aiplatform.googleapis.com"
+                    : "https://api.openai.com"
+              }
               required
             />
           </div>
+          {createType === "azure_openai" ? (
+            <div className="col-md-4">
+              <label className="form-label">API 版本（api-version）</label>
+              <input
+                className="form-control font-monospace"
+                value={createAPIVersion}
+                onChange={(e) => setCreateAPIVersion(e.target.value)}
+                placeholder="2024-10-21"
+              />
+              <div className="form-text small text-muted">
+                deployment 名取渠道绑定的上游模型。
+              </div>
+            </div>
+          ) : null}
           <div className="col-md-4">
             <label className="form-label">优先级</label>
             <input
@@ -3681,7 +3901,15 @@ export function ChannelsPage() {
                 value={createKey}
                 onChange={(e) => setCreateKey(e.target.value)}
                 placeholder={
-                  createType === "anthropic" ? "sk-ant-..." : "sk-..."
+                  createType === "anthropic"
+                    ? "sk-ant-..."
+                    : createType === "gemini"
+                      ? "AIza..."
+                      : createType === "bedrock"
+                        ? "AccessKeyID|SecretAccessKey|Region[|SessionToken]"
+                        : createType === "vertex"
+                          ? "service account JSON（可追加 location 字段）"
+                          : "sk-..."
                 }
                 autoComplete="new-password"
               />
@@ -3699,7 +3927,11 @@ export function ChannelsPage() {
                 id="createChatCompletionsEnabled"
                 checked={createChatCompletionsEnabled}
                 disabled={
-                  createType === "codex_oauth" || createType === "anthropic"
+                  createType === "codex_oauth" ||
+                  createType === "anthropic" ||
+                  createType === "bedrock" ||
+                  createType === "vertex" ||
+                  createType === "gemini"
                 }
                 onChange={(e) =>
                   setCreateChatCompletionsEnabled(e.target.checked)
@@ -3718,7 +3950,12 @@ export function ChannelsPage() {
                 type="checkbox"
                 id="createResponsesEnabled"
                 checked={createResponsesEnabled}
-                disabled={createType === "anthropic"}
+                disabled={
+                  createType === "anthropic" ||
+                  createType === "bedrock" ||
+                  createType === "vertex" ||
+                  createType === "gemini"
+                }
                 onChange={(e) => setCreateResponsesEnabled(e.target.checked)}
               />
               <label
@@ -3729,7 +3966,7 @@ export function ChannelsPage() {
               </label>
             </div>
             <div className="form-text small text-muted mb-2">
-              至少启用一个接口能力；codex_oauth 仅支持 responses；anthropic 不使用这两个 OpenAI 接口能力。
+              至少启用一个接口能力；codex_oauth 仅支持 responses；openai_compatible 仅启用 chat/completions 时，/v1/responses 请求会桥接为 chat 请求转发（不支持 previous_response_id 续链）；anthropic / bedrock / vertex / gemini 不使用这两个 OpenAI 接口能力（gemini 仅承接 /v1beta/models/*；vertex 同时承接 Claude Messages 与 Gemini 原生请求）。
             </div>
             <div className="form-check">
               <input
@@ -3869,6 +4106,8 @@ export function ChannelsPage() {
           setSettingThinkingToContent(false);
           setSettingChatCompletionsEnabled(true);
           setSettingResponsesEnabled(true);
+          setSettingEmbeddingsEnabled(false);
+          setSettingMessagesEnabled(false);
           setSettingPassThroughBodyEnabled(false);
           setSettingProxy("");
           setSettingSystemPrompt("");
@@ -3954,8 +4193,11 @@ export function ChannelsPage() {
                 setEditName={setEditName}
                 editStatus={editStatus}
                 setEditStatus={setEditStatus}
+                channelType={settingsChannel.type}
                 editBaseURL={editBaseURL}
                 setEditBaseURL={setEditBaseURL}
+                editAPIVersion={editAPIVersion}
+                setEditAPIVersion={setEditAPIVersion}
                 editGroups={editGroups}
                 setEditGroups={setEditGroups}
                 editPriority={editPriority}
@@ -4208,6 +4450,7 @@ export function ChannelsPage() {
                                 <tr>
                                   <th className="ps-3">名称</th>
                                   <th>密钥提示</th>
+                                  <th>限额</th>
                                   <th>状态</th>
                                   <th className="text-end pe-3">操作</th>
                                 </tr>
@@ -4231,6 +4474,9 @@ export function ChannelsPage() {
                                         {c.masked_key || "-"}
                                       </code>
                                     </td>
+                                    <td className="small text-muted">
+                                      {formatCredentialLimits(c.limits)}
+                                    </td>
                                     <td>
                                       {c.status === 1 ? (
                                         <span className="badge rounded-pill bg-success bg-opacity-10 text-success px-2">
@@ -4705,6 +4951,10 @@ export function ChannelsPage() {
                 }
                 settingResponsesEnabled={settingResponsesEnabled}
                 setSettingResponsesEnabled={setSettingResponsesEnabled}
+                settingEmbeddingsEnabled={settingEmbeddingsEnabled}
+                setSettingEmbeddingsEnabled={setSettingEmbeddingsEnabled}
+                settingMessagesEnabled={settingMessagesEnabled}
+                setSettingMessagesEnabled={setSettingMessagesEnabled}
                 settingPassThroughBodyEnabled={settingPassThroughBodyEnabled}
                 setSettingPassThroughBodyEnabled={
                   setSettingPassThroughBodyEnabled
@@ -4,10 +4,12 @@ import { listAdminChannelGroups, type AdminChannelGroup } from '../../api/admin/
 import {
   createAdminMainGroup,
   deleteAdminMainGroup,
+  getAdminMainGroupRateLimit,
   listAdminMainGroupSubgroups,
   listAdminMainGroups,
   replaceAdminMainGroupSubgroups,
   updateAdminMainGroup,
+  updateAdminMainGroupRateLimit,
   type AdminMainGroup,
   type UpdateAdminMainGroupRequest,
 } from '../../api/admin/mainGroups';
@@ -48,6 +50,11 @@ export function MainGroupsPage() {
   const [addSubgroup, setAddSubgroup] = useState('');
   const [subgroupsAutosaveResetKey, setSubgroupsAutosaveResetKey] = useState(0);
 
+  const [rateLimitFor, setRateLimitFor] = useState<AdminMainGroup | null>(null);
+  const [rateLimitRPM, setRateLimitRPM] = useState('');
+  const [rateLimitTPM, setRateLimitTPM] = useState('');
+  const [rateLimitErr, setRateLimitErr] = useState('');
+
   const selectableChannelGroups = useMemo(() => channelGroups.filter((g) => g.status === 1).slice().sort((a, b) => a.name.localeCompare(b.name, 'zh-CN')), [channelGroups]);
   const channelGroupByName = useMemo(() => {
     const m = new Map<string, AdminChannelGroup>();
@@ -115,6 +122,22 @@ export function MainGroupsPage() {
     }
   }
 
+  async function openRateLimitModal(g: AdminMainGroup) {
+    setErr('');
+    setNotice('');
+    setRateLimitErr('');
+    try {
+      const res = await getAdminMainGroupRateLimit(g.name);
+      if (!res.success) throw new Error(res.message || '加载限流配置失败');
+      setRateLimitRPM(res.data?.rpm_limit ? String(res.data.rpm_limit) : '');
+      setRateLimitTPM(res.data?.tpm_limit ? String(res.data.tpm_limit) : '');
+      setRateLimitFor(g);
+      window.setTimeout(() => document.getElementById('openMainGroupRateLimitModal')?.click(), 0);
+    } catch (e) {
+      setErr(e instanceof Error ? e.message : '加载限流配置失败');
+    }
+  }
+
   function removeSubgroup(name: string) {
     setSubgroups((prev) => {
       return prev.filter((x) => x !== name);
@@ -272,6 +295,9 @@ export function MainGroupsPage() {
                               <button type="button" className="btn btn-sm btn-light border text-primary" title="配置子组" onClick={() => void openSubgroupsModal(g)}>
                                 <i className="ri-node-tree"></i>
                               </button>
+                              <button type="button" className="btn btn-sm btn-light border text-warning" title="限流 (RPM/TPM)" onClick={() => void openRateLimitModal(g)}>
+                                <i className="ri-speed-up-line"></i>
+                              </button>
                               <button
                                 type="button"
                                 className="btn btn-sm btn-light border text-success"
@@ -491,6 +517,61 @@ export function MainGroupsPage() {
           </div>
         )}
       </BootstrapModal>
+
+      <button type="button" id="openMainGroupRateLimitModal" data-bs-toggle="modal" data-bs-target="#editMainGroupRateLimitModal" className="d-none" />
+      <BootstrapModal
+        id="editMainGroupRateLimitModal"
+        title={rateLimitFor ? `限流：${rateLimitFor.name}` : '限流'}
+        dialogClassName="modal-dialog-centered"
+        onHidden={() => {
+          setRateLimitFor(null);
+          setRateLimitErr('');
+        }}
+      >
+        <form
+          className="row g-3"
+          onSubmit={async (e) => {
+            e.preventDefault();
+            if (!rateLimitFor) return;
+            setRateLimitErr('');
+            setSaving(true);
+            try {
+              const res = await updateAdminMainGroupRateLimit(rateLimitFor.name, {
+                rpm_limit: Number.parseInt(rateLimitRPM.trim() || '0', 10) || 0,
+                tpm_limit: Number.parseInt(rateLimitTPM.trim() || '0', 10) || 0,
+              });
+              if (!res.success) throw new Error(res.message || '保存失败');
+              closeModalById('editMainGroupRateLimitModal');
+              setNotice('已保存');
+            } catch (e) {
+              setRateLimitErr(e instanceof Error ? e.message : '保存失败');
+            } finally {
+              setSaving(false);
+            }
+          }}
+        >
+          {rateLimitErr ? <div className="col-12"><div className="alert alert-danger small mb-0">{rateLimitErr}</div></div> : null}
+          <div className="col-6">
+            <label className="form-label">每分钟请求数 (RPM)</label>
+            <input className="form-control" inputMode="numeric" placeholder="不限" value={rateLimitRPM} onChange={(e) => setRateLimitRPM(e.target.value)} />
+          </div>
+          <div className="col-6">
+            <label className="form-label">每分钟 Token 数 (TPM)</label>
+            <input className="form-control" inputMode="numeric" placeholder="不限" value={rateLimitTPM} onChange={(e) => setRateLimitTPM(e.target.value)} />
+          </div>
+          <div className="col-12">
+            <div className="form-text small text-muted mt-0">按用户聚合（该用户的所有令牌共享）；用户有生效中的订阅套餐且套餐配置了同项限额时，以套餐为准。留空或 0 表示不限制。</div>
+          </div>
+          <div className="modal-footer border-top-0 px-0 pb-0">
+            <button type="button" className="btn btn-light" data-bs-dismiss="modal">
+              取消
+            </button>
+            <button className="btn btn-primary px-4" type="submit" disabled={!rateLimitFor || saving}>
+              保存
+            </button>
+          </div>
+        </form>
+      </BootstrapModal>
     </div>
   );
 }
@@ -46,6 +46,7 @@ type ModelForm = {
   high_context_cache_output_usd_per_1m: string;
   high_context_source: string;
   high_context_source_detail: string;
+  batch_discount: string;
   status: number;
 };
 
@@ -72,6 +73,7 @@ function modelToForm(m: ManagedModel, fallbackGroupName: string): ModelForm {
     high_context_cache_output_usd_per_1m: m.high_context_pricing?.cache_output_usd_per_1m || '',
     high_context_source: m.high_context_pricing?.source || '',
     high_context_source_detail: m.high_context_pricing?.source_detail || '',
+    batch_discount: m.batch_discount || '',
     status: m.status || 0,
   };
 }
@@ -204,6 +206,7 @@ export function ModelsAdminPage() {
     high_context_cache_output_usd_per_1m: '',
     high_context_source: '',
     high_context_source_detail: '',
+    batch_discount: '',
     status: 1,
   });
 
@@ -229,6 +232,7 @@ export function ModelsAdminPage() {
     high_context_cache_output_usd_per_1m: '',
     high_context_source: '',
     high_context_source_detail: '',
+    batch_discount: '',
     status: 1,
   });
 
@@ -325,6 +329,7 @@ export function ModelsAdminPage() {
           priority_output_usd_per_1m: optionalPriceValue(v.priority_output_usd_per_1m),
           priority_cache_input_usd_per_1m: optionalPriceValue(v.priority_cache_input_usd_per_1m),
           high_context_pricing: highContextPricingValue(v),
+          batch_discount: optionalPriceValue(v.batch_discount),
           status: v.status,
         };
         const res = await updateManagedModelAdmin(nextModel);
@@ -589,6 +594,7 @@ export function ModelsAdminPage() {
             high_context_cache_output_usd_per_1m: '',
             high_context_source: '',
             high_context_source_detail: '',
+            batch_discount: '',
             status: 1,
           });
           setCreateLookupErr('');
@@ -617,6 +623,7 @@ export function ModelsAdminPage() {
                 priority_output_usd_per_1m: optionalPriceValue(createForm.priority_output_usd_per_1m),
                 priority_cache_input_usd_per_1m: optionalPriceValue(createForm.priority_cache_input_usd_per_1m),
                 high_context_pricing: highContextPricingValue(createForm),
+                batch_discount: optionalPriceValue(createForm.batch_discount),
                 status: createForm.status,
               });
               if (!res.success) throw new Error(res.message || '创建失败');
@@ -770,6 +777,11 @@ export function ModelsAdminPage() {
               <span className="input-group-text">/ 1M Token</span>
             </div>
           </div>
+          <div className="col-md-4">
+            <label className="form-label">Batch 折扣</label>
+            <input className="form-control" value={createForm.batch_discount} onChange={(e) => setCreateForm((p) => ({ ...p, batch_discount: e.target.value }))} inputMode="decimal" placeholder="例如 0.5，留空表示无折扣" />
+            <div className="form-text">Batch API 请求按最终单价 ×（1 − 折扣）计费，取值 0~1。</div>
+          </div>
 
           <div className="col-12">
             <div className="form-check form-switch mt-1">
@@ -1156,6 +1168,11 @@ export function ModelsAdminPage() {
               </div>
               <div className="form-text">打开后会自动按基础价格推导 Fast 定价；关闭后 Fast 不可用。</div>
             </div>
+            <div className="col-md-4">
+              <label className="form-label">Batch 折扣</label>
+              <input className="form-control" value={editForm.batch_discount} onChange={(e) => setEditForm((p) => ({ ...p, batch_discount: e.target.value }))} inputMode="decimal" placeholder="例如 0.5，留空表示无折扣" />
+              <div className="form-text">Batch API 请求按最终单价 ×（1 − 折扣）计费，取值 0~1。</div>
+            </div>
 
             <div className="col-12">
               <div className="form-check form-switch mt-1">
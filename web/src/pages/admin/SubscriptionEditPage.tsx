@@ -5,7 +5,9 @@ import { listAdminChannelGroups, type AdminChannelGroup } from '../../api/admin/
 import {
   deleteAdminSubscriptionPlan,
   getAdminSubscriptionPlan,
+  getAdminSubscriptionPlanRateLimit,
   updateAdminSubscriptionPlan,
+  updateAdminSubscriptionPlanRateLimit,
   type AdminSubscriptionPlan,
 } from '../../api/admin/billing';
 import { SegmentedFrame } from '../../components/SegmentedFrame';
@@ -34,6 +36,8 @@ export function SubscriptionEditPage() {
   const [limit7d, setLimit7d] = useState('');
   const [limit1d, setLimit1d] = useState('');
   const [limit5h, setLimit5h] = useState('');
+  const [rpmLimit, setRPMLimit] = useState('');
+  const [tpmLimit, setTPMLimit] = useState('');
 
   const defaultGroupName = useMemo(() => {
     const byDefault = (groups.find((g) => g.is_default)?.name || '').trim();
@@ -49,7 +53,11 @@ export function SubscriptionEditPage() {
     setLoading(true);
     try {
       if (!Number.isFinite(planId) || planId <= 0) throw new Error('参数错误');
-      const [planRes, groupsRes] = await Promise.all([getAdminSubscriptionPlan(planId), listAdminChannelGroups()]);
+      const [planRes, groupsRes, rateLimitRes] = await Promise.all([
+        getAdminSubscriptionPlan(planId),
+        listAdminChannelGroups(),
+        getAdminSubscriptionPlanRateLimit(planId),
+      ]);
       if (!groupsRes.success) throw new Error(groupsRes.message || '加载渠道组失败');
       const nextGroups = groupsRes.data || [];
       setGroups(nextGroups);
@@ -69,6 +77,9 @@ export function SubscriptionEditPage() {
         setLimit7d(p.limit_7d || '');
         setLimit1d(p.limit_1d || '');
         setLimit5h(p.limit_5h || '');
+        const rl = rateLimitRes.success ? rateLimitRes.data : undefined;
+        setRPMLimit(rl?.rpm_limit ? String(rl.rpm_limit) : '');
+        setTPMLimit(rl?.tpm_limit ? String(rl.tpm_limit) : '');
       }
       setAutosaveResetKey((x) => x + 1);
     } catch (e) {
@@ -132,6 +143,34 @@ export function SubscriptionEditPage() {
     },
   });
 
+  const rateLimitAutosave = useAutoSave({
+    enabled: !!plan && !loading && !saving,
+    value: { rpm_limit: rpmLimit.trim(), tpm_limit: tpmLimit.trim() },
+    resetKey: autosaveResetKey,
+    validate: (v) => {
+      if (!plan) return '未加载';
+      if (v.rpm_limit && !/^\d+$/.test(v.rpm_limit)) return 'RPM 必须为非负整数';
+      if (v.tpm_limit && !/^\d+$/.test(v.tpm_limit)) return 'TPM 必须为非负整数';
+      return '';
+    },
+    save: async (v) => {
+      if (!plan) return;
+      setErr('');
+      setNotice('');
+      setSaving(true);
+      try {
+        const res = await updateAdminSubscriptionPlanRateLimit(plan.id, {
+          rpm_limit: Number.parseInt(v.rpm_limit || '0', 10) || 0,
+          tpm_limit: Number.parseInt(v.tpm_limit || '0', 10) || 0,
+        });
+        if (!res.success) throw new Error(res.message || '保存失败');
+        setNotice('已自动保存');
+      } finally {
+        setSaving(false);
+      }
+    },
+  });
+
   return (
     <div className="fade-in-up">
       <SegmentedFrame>
@@ -246,6 +285,19 @@ export function SubscriptionEditPage() {
                   </div>
                 </div>
 
+                <div className="col-md-6">
+                  <label className="form-label">每分钟请求数（RPM）</label>
+                  <input className="form-control" value={rpmLimit} onChange={(e) => setRPMLimit(e.target.value)} inputMode="numeric" placeholder="留空=沿用用户分组" />
+                </div>
+                <div className="col-md-6">
+                  <label className="form-label">每分钟 Token 数（TPM）</label>
+                  <input className="form-control" value={tpmLimit} onChange={(e) => setTPMLimit(e.target.value)} inputMode="numeric" placeholder="留空=沿用用户分组" />
+                </div>
+                <div className="col-12 d-flex justify-content-between align-items-center">
+                  <div className="form-text small text-muted mt-0">订阅生效期间按用户覆盖用户分组的同项限流；多个生效订阅取较宽松者。</div>
+                  <AutoSaveIndicator status={rateLimitAutosave.status} blockedReason={rateLimitAutosave.blockedReason} error={rateLimitAutosave.error} onRetry={rateLimitAutosave.retry} className="small" />
+                </div>
+
                 <div className="col-12 d-grid d-md-flex justify-content-md-between gap-2">
                   <button
                     type="button"
@@ -265,6 +265,7 @@ export function UsageAdminPage() {
 
   const windowStats = data?.window;
   const topUsers = data?.top_users || [];
+  const topOrgs = data?.top_orgs || [];
   const events = data?.events || [];
   const seriesStart = data?.start || "";
   const seriesEnd = data?.end || "";
@@ -1060,6 +1061,49 @@ export function UsageAdminPage() {
               </div>
             </div>
 
+            {topOrgs.length > 0 ? (
+              <div className="col-12">
+                <div className="card border-0 p-0 overflow-hidden">
+                  <div className="card-header bg-white py-3 border-bottom-0 px-4">
+                    <h5 className="mb-0 fw-bold">
+                      <i className="ri-building-line me-2"></i>
+                      消费排行组织（统计区间）
+                    </h5>
+                  </div>
+                  <div className="card-body p-0">
+                    <div className="table-responsive">
+                      <table className="table table-hover align-middle mb-0 border-0">
+                        <thead className="table-light text-muted smaller uppercase">
+                          <tr>
+                            <th className="ps-4 border-0">组织</th>
+                            <th className="text-end border-0">请求数</th>
+                            <th className="text-end border-0">已结算费用</th>
+                            <th className="text-end pe-4 border-0">预留中</th>
+                          </tr>
+                        </thead>
+                        <tbody>
+                          {topOrgs.map((o) => (
+                            <tr key={o.org_id}>
+                              <td className="ps-4 fw-bold small">{o.name}</td>
+                              <td className="text-end font-monospace small">
+                                {o.requests}
+                              </td>
+                              <td className="text-end font-monospace small fw-bold text-dark">
+                                {o.committed_usd}
+                              </td>
+                              <td className="text-end font-monospace small text-muted pe-4">
+                                {o.reserved_usd}
+                              </td>
+                            </tr>
+                          ))}
+                        </tbody>
+                      </table>
+                    </div>
+                  </div>
+                </div>
+              </div>
+            ) : null}
+
             <div className="col-12">
               <div className="card border-0 p-0 overflow-hidden">
                 <div className="card-header bg-white py-3 border-bottom-0 px-4 d-flex justify-content-between align-items-center">